@@ -672,6 +672,8 @@ type RefreshInfo struct {
 	Last     string `json:"last,omitempty"`
 	Hold     string `json:"hold,omitempty"`
 	Next     string `json:"next,omitempty"`
+	// Blackout contains the refresh.blackout setting.
+	Blackout string `json:"blackout,omitempty"`
 }
 
 // SysInfo holds system information
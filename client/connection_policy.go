@@ -0,0 +1,122 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ConnectionPolicyOptions select the plug and the slot whose connection
+// policy is explained by ConnectionPolicy.
+type ConnectionPolicyOptions struct {
+	Plug PlugRef
+	Slot SlotRef
+	// PlugSnapYaml and SlotSnapYaml, if set, are the snap.yaml of snaps
+	// to use instead of the installed ones.
+	PlugSnapYaml string
+	SlotSnapYaml string
+	// PlugSnapDeclaration and SlotSnapDeclaration, if set, are encoded
+	// snap declarations to use instead of the ones known to snapd.
+	PlugSnapDeclaration string
+	SlotSnapDeclaration string
+}
+
+// ConnectionPolicy explains whether a plug and a slot are allowed to be
+// connected and auto-connected.
+type ConnectionPolicy struct {
+	Interface      string                       `json:"interface"`
+	Plug           PlugRef                      `json:"plug"`
+	Slot           SlotRef                      `json:"slot"`
+	Connection     *ConnectionPolicyExplanation `json:"connection"`
+	AutoConnection *ConnectionPolicyExplanation `json:"auto-connection"`
+}
+
+// ConnectionPolicyExplanation describes how the declarations were evaluated
+// to decide whether a connection or auto-connection is allowed.
+type ConnectionPolicyExplanation struct {
+	// Kind is either "connection" or "auto-connection".
+	Kind    string                  `json:"kind"`
+	Allowed bool                    `json:"allowed"`
+	Error   string                  `json:"error,omitempty"`
+	Rules   []*ConnectionPolicyRule `json:"rules"`
+}
+
+// ConnectionPolicyRule describes how the plug or slot rule of a declaration
+// was considered.
+type ConnectionPolicyRule struct {
+	// Declaration is one of "plug-snap-declaration",
+	// "slot-snap-declaration" or "base-declaration".
+	Declaration string `json:"declaration"`
+	Side        string `json:"side"`
+	// Status is one of "no-declaration", "no-rule" or "evaluated".
+	Status   string                     `json:"status"`
+	Subrules []*ConnectionPolicySubrule `json:"subrules,omitempty"`
+}
+
+// ConnectionPolicySubrule describes how a subrule, such as
+// deny-auto-connection, was evaluated.
+type ConnectionPolicySubrule struct {
+	Name         string                         `json:"name"`
+	Matched      bool                           `json:"matched"`
+	Alternatives []*ConnectionPolicyAlternative `json:"alternatives"`
+}
+
+// ConnectionPolicyAlternative describes how one of the alternative
+// constraints of a subrule was evaluated.
+type ConnectionPolicyAlternative struct {
+	Constraints string `json:"constraints"`
+	Matched     bool   `json:"matched"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// ConnectionPolicy asks snapd to evaluate the policy which applies to the
+// connection of the given plug and slot, and to explain its verdict.
+func (client *Client) ConnectionPolicy(opts *ConnectionPolicyOptions) (*ConnectionPolicy, error) {
+	payload := struct {
+		Action string `json:"action"`
+		Params struct {
+			Plug                PlugRef `json:"plug"`
+			Slot                SlotRef `json:"slot"`
+			PlugSnapYaml        string  `json:"plug-snap-yaml,omitempty"`
+			SlotSnapYaml        string  `json:"slot-snap-yaml,omitempty"`
+			PlugSnapDeclaration string  `json:"plug-snap-declaration,omitempty"`
+			SlotSnapDeclaration string  `json:"slot-snap-declaration,omitempty"`
+		} `json:"params"`
+	}{
+		Action: "connection-policy",
+	}
+	payload.Params.Plug = opts.Plug
+	payload.Params.Slot = opts.Slot
+	payload.Params.PlugSnapYaml = opts.PlugSnapYaml
+	payload.Params.SlotSnapYaml = opts.SlotSnapYaml
+	payload.Params.PlugSnapDeclaration = opts.PlugSnapDeclaration
+	payload.Params.SlotSnapDeclaration = opts.SlotSnapDeclaration
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(&payload); err != nil {
+		return nil, err
+	}
+	var policy ConnectionPolicy
+	if _, err := client.doSync("POST", "/v2/debug", nil, nil, &body, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
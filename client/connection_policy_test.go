@@ -0,0 +1,100 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestConnectionPolicy(c *C) {
+	cs.rsp = `{"type": "sync", "result": {
+		"interface": "raw-usb",
+		"plug": {"snap": "consumer", "plug": "raw-usb"},
+		"slot": {"snap": "core", "slot": "raw-usb"},
+		"connection": {"kind": "connection", "allowed": true, "rules": [
+			{"declaration": "base-declaration", "side": "slot", "status": "evaluated", "subrules": [
+				{"name": "deny-connection", "matched": false, "alternatives": [{"constraints": "false", "matched": false, "reason": "not allowed"}]},
+				{"name": "allow-connection", "matched": true, "alternatives": [{"constraints": "true", "matched": true}]}
+			]}
+		]},
+		"auto-connection": {"kind": "auto-connection", "allowed": false, "error": "auto-connection denied", "rules": [
+			{"declaration": "plug-snap-declaration", "side": "plug", "status": "no-declaration"}
+		]}
+	}}`
+	policy, err := cs.cli.ConnectionPolicy(&client.ConnectionPolicyOptions{
+		Plug:                client.PlugRef{Snap: "consumer", Name: "raw-usb"},
+		Slot:                client.SlotRef{Name: "raw-usb"},
+		PlugSnapYaml:        "name: consumer\n",
+		PlugSnapDeclaration: "type: snap-declaration\n",
+	})
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "POST")
+	c.Check(cs.req.URL.Path, Equals, "/v2/debug")
+
+	var body map[string]any
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), IsNil)
+	c.Check(body, DeepEquals, map[string]any{
+		"action": "connection-policy",
+		"params": map[string]any{
+			"plug":                  map[string]any{"snap": "consumer", "plug": "raw-usb"},
+			"slot":                  map[string]any{"snap": "", "slot": "raw-usb"},
+			"plug-snap-yaml":        "name: consumer\n",
+			"plug-snap-declaration": "type: snap-declaration\n",
+		},
+	})
+
+	c.Check(policy, DeepEquals, &client.ConnectionPolicy{
+		Interface: "raw-usb",
+		Plug:      client.PlugRef{Snap: "consumer", Name: "raw-usb"},
+		Slot:      client.SlotRef{Snap: "core", Name: "raw-usb"},
+		Connection: &client.ConnectionPolicyExplanation{
+			Kind:    "connection",
+			Allowed: true,
+			Rules: []*client.ConnectionPolicyRule{{
+				Declaration: "base-declaration",
+				Side:        "slot",
+				Status:      "evaluated",
+				Subrules: []*client.ConnectionPolicySubrule{{
+					Name: "deny-connection",
+					Alternatives: []*client.ConnectionPolicyAlternative{
+						{Constraints: "false", Reason: "not allowed"},
+					},
+				}, {
+					Name:    "allow-connection",
+					Matched: true,
+					Alternatives: []*client.ConnectionPolicyAlternative{
+						{Constraints: "true", Matched: true},
+					},
+				}},
+			}},
+		},
+		AutoConnection: &client.ConnectionPolicyExplanation{
+			Kind:  "auto-connection",
+			Error: "auto-connection denied",
+			Rules: []*client.ConnectionPolicyRule{
+				{Declaration: "plug-snap-declaration", Side: "plug", Status: "no-declaration"},
+			},
+		},
+	})
+}
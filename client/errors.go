@@ -171,6 +171,9 @@ const (
 	// ErrorKindInterfacesRequestsRuleConflict: a rule with conflicting path pattern and permissions already exists.
 	ErrorKindInterfacesRequestsRuleConflict ErrorKind = "interfaces-requests-rule-conflict"
 
+	// ErrorKindInterfacesRequestsRuleAdminManaged: the rule was provisioned by the system administrator and cannot be modified or removed.
+	ErrorKindInterfacesRequestsRuleAdminManaged ErrorKind = "interfaces-requests-rule-admin-managed"
+
 	// ErrorKindMissingSnapResourcePair: cannot find a snap-resource-pair when attempting to sideload a component.
 	ErrorKindMissingSnapResourcePair ErrorKind = "missing-snap-resource-pair"
 
@@ -197,6 +200,9 @@ const (
 
 	// ErrorKindInvalidRecoveryKey: recovery key itself or its ID is invalid.
 	ErrorKindInvalidRecoveryKey ErrorKind = "invalid-recovery-key"
+
+	// ErrorKindSnapshotKeyRequired: the snapshot set is encrypted and none of its keys is available.
+	ErrorKindSnapshotKeyRequired ErrorKind = "snapshot-key-required"
 )
 
 // Maintenance error kinds.
@@ -85,9 +85,15 @@ type Snap struct {
 	Tracks []string `json:"tracks,omitempty"`
 
 	Health *SnapHealth `json:"health,omitempty"`
+	// HealthHistory is the last health states of the snap, oldest first. It
+	// is only returned for a single snap.
+	HealthHistory []SnapHealth `json:"health-history,omitempty"`
 
 	// Hold is the time until which the snap's refreshes are held by the user.
 	Hold *time.Time `json:"hold,omitempty"`
+	// RolloutDue is the time until which the staged rollout delays the
+	// auto-refresh to this revision, for refresh candidates.
+	RolloutDue *time.Time `json:"rollout-due,omitempty"`
 	// GatingHold is the time until which the snap's refreshes are held by a snap.
 	GatingHold *time.Time `json:"gating-hold,omitempty"`
 	// if RefreshInhibit is nil, then there is no pending refresh.
@@ -300,3 +306,24 @@ func (client *Client) Snap(name string) (*Snap, *ResultInfo, error) {
 	}
 	return snap, ri, nil
 }
+
+// SnapChangelog holds the changelog of a revision of a snap.
+type SnapChangelog struct {
+	Revision  snap.Revision `json:"revision"`
+	Version   string        `json:"version,omitempty"`
+	Changelog string        `json:"changelog,omitempty"`
+	// Candidate is set if the snap would be refreshed to the revision.
+	Candidate bool `json:"candidate,omitempty"`
+}
+
+// SnapChangelogs returns the changelog of the installed revision of the
+// given snap and of the revision it would be refreshed to, if any.
+func (client *Client) SnapChangelogs(name string) ([]*SnapChangelog, error) {
+	var changelogs []*SnapChangelog
+	path := fmt.Sprintf("/v2/snaps/%s", name)
+	q := url.Values{"select": []string{"changelog"}}
+	if _, err := client.doSync("GET", path, q, nil, nil, &changelogs); err != nil {
+		return nil, xerrors.Errorf("cannot retrieve changelog of snap %q: %w", name, err)
+	}
+	return changelogs, nil
+}
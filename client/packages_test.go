@@ -379,6 +379,31 @@ func (cs *clientSuite) TestClientSnapRefreshInhibited(c *check.C) {
 	cs.testClientSnap(c, refreshInhibited)
 }
 
+func (cs *clientSuite) TestClientSnapChangelogs(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": [
+			{"revision": "1", "version": "1.0", "changelog": "* first"},
+			{"revision": "2", "version": "2.0", "changelog": "* second", "candidate": true}
+		]
+	}`
+	changelogs, err := cs.cli.SnapChangelogs("foo")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/foo")
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{"select": []string{"changelog"}})
+	c.Check(changelogs, check.DeepEquals, []*client.SnapChangelog{{
+		Revision:  snap.R(1),
+		Version:   "1.0",
+		Changelog: "* first",
+	}, {
+		Revision:  snap.R(2),
+		Version:   "2.0",
+		Changelog: "* second",
+		Candidate: true,
+	}})
+}
+
 func (cs *clientSuite) TestAppInfoNoServiceNoDaemon(c *check.C) {
 	buf, err := json.MarshalIndent(client.AppInfo{Name: "hello"}, "\t", "\t")
 	c.Assert(err, check.IsNil)
@@ -0,0 +1,152 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// PromptingRule holds a rule for AppArmor prompting.
+type PromptingRule struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	User      uint32    `json:"user"`
+	Snap      string    `json:"snap"`
+	Interface string    `json:"interface"`
+	// Constraints are kept in their raw form, as their fields depend on the
+	// interface of the rule.
+	Constraints map[string]json.RawMessage `json:"constraints"`
+	// AdminManaged is set if the rule was provisioned by the system
+	// administrator, in which case it cannot be modified or removed.
+	AdminManaged bool `json:"admin-managed,omitempty"`
+}
+
+// PromptingRulesOptions select the rules returned by PromptingRules.
+type PromptingRulesOptions struct {
+	Snap      string
+	Interface string
+}
+
+// PromptingRules returns the AppArmor prompting rules which apply to the
+// current user, optionally limited to the given snap and/or interface.
+func (client *Client) PromptingRules(opts *PromptingRulesOptions) ([]*PromptingRule, error) {
+	query := url.Values{}
+	if opts != nil {
+		if opts.Snap != "" {
+			query.Set("snap", opts.Snap)
+		}
+		if opts.Interface != "" {
+			query.Set("interface", opts.Interface)
+		}
+	}
+	var rules []*PromptingRule
+	if _, err := client.doSync("GET", "/v2/interfaces/requests/rules", query, nil, nil, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// AddPromptingRule adds an AppArmor prompting rule for the current user with
+// the given snap, interface and constraints, and returns the resulting rule.
+func (client *Client) AddPromptingRule(snap, iface string, constraints map[string]json.RawMessage) (*PromptingRule, error) {
+	payload := struct {
+		Action string `json:"action"`
+		Rule   struct {
+			Snap        string                     `json:"snap"`
+			Interface   string                     `json:"interface"`
+			Constraints map[string]json.RawMessage `json:"constraints"`
+		} `json:"rule"`
+	}{
+		Action: "add",
+	}
+	payload.Rule.Snap = snap
+	payload.Rule.Interface = iface
+	payload.Rule.Constraints = constraints
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(&payload); err != nil {
+		return nil, err
+	}
+	headers := map[string]string{"Content-Type": "application/json"}
+	var rule PromptingRule
+	if _, err := client.doSync("POST", "/v2/interfaces/requests/rules", nil, headers, &body, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// PromptingAuditEntry records the decision taken for some or all of the
+// permissions of an AppArmor prompting request.
+type PromptingAuditEntry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	User        uint32    `json:"user"`
+	Snap        string    `json:"snap"`
+	Interface   string    `json:"interface"`
+	Path        string    `json:"path"`
+	Permissions []string  `json:"permissions"`
+	Outcome     string    `json:"outcome"`
+	// Source is one of "rule", "reply", "timeout", or "default".
+	Source   string `json:"source"`
+	RuleID   string `json:"rule-id,omitempty"`
+	PromptID string `json:"prompt-id,omitempty"`
+}
+
+// PromptingAuditOptions select the entries returned by PromptingAudit.
+type PromptingAuditOptions struct {
+	Snap      string
+	Interface string
+	Source    string
+	Since     time.Time
+	// Limit is the number of most recent entries to return, if not zero.
+	Limit int
+}
+
+// PromptingAudit returns the entries of the AppArmor prompting audit log
+// which concern the current user, or all users if called by an admin,
+// optionally limited by the given options.
+func (client *Client) PromptingAudit(opts *PromptingAuditOptions) ([]*PromptingAuditEntry, error) {
+	query := url.Values{}
+	if opts != nil {
+		if opts.Snap != "" {
+			query.Set("snap", opts.Snap)
+		}
+		if opts.Interface != "" {
+			query.Set("interface", opts.Interface)
+		}
+		if opts.Source != "" {
+			query.Set("source", opts.Source)
+		}
+		if !opts.Since.IsZero() {
+			query.Set("since", opts.Since.Format(time.RFC3339Nano))
+		}
+		if opts.Limit > 0 {
+			query.Set("limit", strconv.Itoa(opts.Limit))
+		}
+	}
+	var entries []*PromptingAuditEntry
+	if _, err := client.doSync("GET", "/v2/interfaces/requests/audit", query, nil, nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
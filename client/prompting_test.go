@@ -0,0 +1,152 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestPromptingRules(c *C) {
+	cs.rsp = `{"type": "sync", "result": [{
+		"id": "0000000000000002",
+		"timestamp": "2026-01-02T03:04:05Z",
+		"user": 4294967295,
+		"snap": "firefox",
+		"interface": "home",
+		"constraints": {"path-pattern": "/home/*/Downloads/**", "permissions": {"read": {"outcome": "allow", "lifespan": "forever"}}},
+		"admin-managed": true
+	}]}`
+	rules, err := cs.cli.PromptingRules(&client.PromptingRulesOptions{Snap: "firefox", Interface: "home"})
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/interfaces/requests/rules")
+	c.Check(cs.req.URL.Query().Get("snap"), Equals, "firefox")
+	c.Check(cs.req.URL.Query().Get("interface"), Equals, "home")
+	c.Check(rules, DeepEquals, []*client.PromptingRule{{
+		ID:        "0000000000000002",
+		Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		User:      4294967295,
+		Snap:      "firefox",
+		Interface: "home",
+		Constraints: map[string]json.RawMessage{
+			"path-pattern": json.RawMessage(`"/home/*/Downloads/**"`),
+			"permissions":  json.RawMessage(`{"read": {"outcome": "allow", "lifespan": "forever"}}`),
+		},
+		AdminManaged: true,
+	}})
+}
+
+func (cs *clientSuite) TestPromptingRulesNoOptions(c *C) {
+	cs.rsp = `{"type": "sync", "result": []}`
+	rules, err := cs.cli.PromptingRules(nil)
+	c.Assert(err, IsNil)
+	c.Check(rules, HasLen, 0)
+	c.Check(cs.req.URL.RawQuery, Equals, "")
+}
+
+func (cs *clientSuite) TestAddPromptingRule(c *C) {
+	cs.rsp = `{"type": "sync", "result": {
+		"id": "0000000000000003",
+		"timestamp": "2026-01-02T03:04:05Z",
+		"user": 1000,
+		"snap": "firefox",
+		"interface": "camera",
+		"constraints": {"permissions": {"access": {"outcome": "deny", "lifespan": "forever"}}}
+	}}`
+	constraints := map[string]json.RawMessage{
+		"permissions": json.RawMessage(`{"access":{"outcome":"deny","lifespan":"forever"}}`),
+	}
+	rule, err := cs.cli.AddPromptingRule("firefox", "camera", constraints)
+	c.Assert(err, IsNil)
+	c.Check(rule.ID, Equals, "0000000000000003")
+	c.Check(rule.User, Equals, uint32(1000))
+	c.Check(rule.AdminManaged, Equals, false)
+
+	c.Check(cs.req.Method, Equals, "POST")
+	c.Check(cs.req.URL.Path, Equals, "/v2/interfaces/requests/rules")
+	c.Check(cs.req.Header.Get("Content-Type"), Equals, "application/json")
+	var body map[string]any
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), IsNil)
+	c.Check(body, DeepEquals, map[string]any{
+		"action": "add",
+		"rule": map[string]any{
+			"snap":      "firefox",
+			"interface": "camera",
+			"constraints": map[string]any{
+				"permissions": map[string]any{
+					"access": map[string]any{"outcome": "deny", "lifespan": "forever"},
+				},
+			},
+		},
+	})
+}
+
+func (cs *clientSuite) TestPromptingAudit(c *C) {
+	cs.rsp = `{"type": "sync", "result": [{
+		"timestamp": "2026-01-02T03:04:05Z",
+		"user": 1000,
+		"snap": "firefox",
+		"interface": "home",
+		"path": "/home/test/foo",
+		"permissions": ["read", "write"],
+		"outcome": "allow",
+		"source": "rule",
+		"rule-id": "0000000000000002"
+	}]}`
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries, err := cs.cli.PromptingAudit(&client.PromptingAuditOptions{
+		Snap:      "firefox",
+		Interface: "home",
+		Source:    "rule",
+		Since:     since,
+		Limit:     10,
+	})
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/interfaces/requests/audit")
+	c.Check(cs.req.URL.Query(), DeepEquals, url.Values{
+		"snap":      []string{"firefox"},
+		"interface": []string{"home"},
+		"source":    []string{"rule"},
+		"since":     []string{"2026-01-01T00:00:00Z"},
+		"limit":     []string{"10"},
+	})
+	c.Check(entries, DeepEquals, []*client.PromptingAuditEntry{{
+		Timestamp:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		User:        1000,
+		Snap:        "firefox",
+		Interface:   "home",
+		Path:        "/home/test/foo",
+		Permissions: []string{"read", "write"},
+		Outcome:     "allow",
+		Source:      "rule",
+		RuleID:      "0000000000000002",
+	}})
+
+	_, err = cs.cli.PromptingAudit(nil)
+	c.Assert(err, IsNil)
+	c.Check(cs.req.URL.RawQuery, Equals, "")
+}
@@ -23,6 +23,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/snapcore/snapd/gadget/quantity"
@@ -45,6 +46,17 @@ type QuotaGroupResult struct {
 	Services    []string     `json:"services,omitempty"`
 	Constraints *QuotaValues `json:"constraints,omitempty"`
 	Current     *QuotaValues `json:"current,omitempty"`
+	// History is the usage of the resources with a limit sampled over time,
+	// oldest first, only returned when asked for.
+	History []QuotaUsageSample `json:"history,omitempty"`
+}
+
+// QuotaUsageSample is the usage of the limited resources of a quota group at
+// a given time.
+type QuotaUsageSample struct {
+	Time    time.Time     `json:"time"`
+	Memory  quantity.Size `json:"memory,omitempty"`
+	Threads int           `json:"threads,omitempty"`
 }
 
 type QuotaCPUValues struct {
@@ -66,12 +78,33 @@ type QuotaJournalValues struct {
 	*QuotaJournalRate
 }
 
+// QuotaIOValues are the block I/O limits of a quota group. When changing a
+// group, an explicit value of 0 removes the limit.
+type QuotaIOValues struct {
+	ReadBandwidth  *quantity.Size `json:"read-bandwidth,omitempty"`
+	WriteBandwidth *quantity.Size `json:"write-bandwidth,omitempty"`
+	Weight         *int           `json:"weight,omitempty"`
+}
+
+// QuotaNetworkValues holds whether IP accounting is enabled for a quota group
+// as a constraint, and its network traffic as current usage.
+type QuotaNetworkValues struct {
+	Accounting *bool         `json:"accounting,omitempty"`
+	Ingress    quantity.Size `json:"ingress,omitempty"`
+	Egress     quantity.Size `json:"egress,omitempty"`
+}
+
 type QuotaValues struct {
-	Memory  quantity.Size       `json:"memory,omitempty"`
-	CPU     *QuotaCPUValues     `json:"cpu,omitempty"`
-	CPUSet  *QuotaCPUSetValues  `json:"cpu-set,omitempty"`
-	Threads int                 `json:"threads,omitempty"`
-	Journal *QuotaJournalValues `json:"journal,omitempty"`
+	Memory        quantity.Size       `json:"memory,omitempty"`
+	MemoryHigh    *quantity.Size      `json:"memory-high,omitempty"`
+	MemoryLow     *quantity.Size      `json:"memory-low,omitempty"`
+	MemorySwapMax *quantity.Size      `json:"memory-swap-max,omitempty"`
+	CPU           *QuotaCPUValues     `json:"cpu,omitempty"`
+	CPUSet        *QuotaCPUSetValues  `json:"cpu-set,omitempty"`
+	Threads       int                 `json:"threads,omitempty"`
+	Journal       *QuotaJournalValues `json:"journal,omitempty"`
+	IO            *QuotaIOValues      `json:"io,omitempty"`
+	Network       *QuotaNetworkValues `json:"network,omitempty"`
 }
 
 type EnsureQuotaOptions struct {
@@ -120,13 +153,26 @@ func (client *Client) EnsureQuota(groupName string, opts *EnsureQuotaOptions) (c
 }
 
 func (client *Client) GetQuotaGroup(groupName string) (*QuotaGroupResult, error) {
+	return client.getQuotaGroup(groupName, nil)
+}
+
+// GetQuotaGroupWithHistory returns the quota group along with the usage
+// samples of its limited resources taken over the given duration.
+func (client *Client) GetQuotaGroupWithHistory(groupName string, history time.Duration) (*QuotaGroupResult, error) {
+	if history <= 0 {
+		return nil, fmt.Errorf("cannot get quota group usage history over a non-positive duration")
+	}
+	return client.getQuotaGroup(groupName, url.Values{"history": []string{history.String()}})
+}
+
+func (client *Client) getQuotaGroup(groupName string, query url.Values) (*QuotaGroupResult, error) {
 	if groupName == "" {
 		return nil, fmt.Errorf("cannot get quota group without a name")
 	}
 
 	var res *QuotaGroupResult
 	path := fmt.Sprintf("/v2/quotas/%s", groupName)
-	if _, err := client.doSync("GET", path, nil, nil, nil, &res); err != nil {
+	if _, err := client.doSync("GET", path, query, nil, nil, &res); err != nil {
 		return nil, err
 	}
 
@@ -155,6 +155,36 @@ func (cs *clientSuite) TestGetQuotaGroup(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestGetQuotaGroupWithHistory(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"group-name":"foo",
+			"constraints": { "memory": 999 },
+			"current": { "memory": 450 },
+			"history": [
+				{"time": "2026-01-01T12:00:00Z", "memory": 400},
+				{"time": "2026-01-01T12:01:00Z", "memory": 450}
+			]
+		}
+	}`
+
+	grp, err := cs.cli.GetQuotaGroupWithHistory("foo", 90*time.Minute)
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/quotas/foo")
+	c.Check(cs.req.URL.Query().Get("history"), check.Equals, "1h30m0s")
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.Check(grp.History, check.DeepEquals, []client.QuotaUsageSample{
+		{Time: t0, Memory: 400},
+		{Time: t0.Add(time.Minute), Memory: 450},
+	})
+
+	_, err = cs.cli.GetQuotaGroupWithHistory("foo", 0)
+	c.Check(err, check.ErrorMatches, "cannot get quota group usage history over a non-positive duration")
+}
+
 func (cs *clientSuite) TestGetQuotaGroupError(c *check.C) {
 	cs.status = 500
 	cs.rsp = `{"type": "error"}`
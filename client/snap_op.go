@@ -28,6 +28,8 @@ import (
 	"mime/multipart"
 	"os"
 	"path/filepath"
+
+	"github.com/snapcore/snapd/snap"
 )
 
 // TransactionType says whether we want to treat each snap separately
@@ -64,6 +66,9 @@ type SnapOptions struct {
 	Time             string          `json:"time,omitempty"`
 	HoldLevel        string          `json:"hold-level,omitempty"`
 	Users            []string        `json:"users,omitempty"`
+
+	SnapshotOptions    map[string]*snap.SnapshotOptions `json:"snapshot-options,omitempty"`
+	SnapshotEncryption *SnapshotEncryption              `json:"snapshot-encryption,omitempty"`
 }
 
 func writeFieldBool(mw *multipart.Writer, key string, val bool) error {
@@ -136,6 +141,10 @@ type multiActionData struct {
 	Time           string              `json:"time,omitempty"`
 	HoldLevel      string              `json:"hold-level,omitempty"`
 	Components     map[string][]string `json:"components,omitempty"`
+	DryRun         bool                `json:"dry-run,omitempty"`
+
+	SnapshotOptions    map[string]*snap.SnapshotOptions `json:"snapshot-options,omitempty"`
+	SnapshotEncryption *SnapshotEncryption              `json:"snapshot-encryption,omitempty"`
 }
 
 // Install adds the snap with the given name from the given channel (or
@@ -170,6 +179,54 @@ func (client *Client) RefreshMany(names []string, components map[string][]string
 	return client.doMultiSnapAction("refresh", names, components, options)
 }
 
+// RefreshImpact describes what refreshing a snap would do.
+type RefreshImpact struct {
+	Name            string        `json:"name"`
+	CurrentRevision snap.Revision `json:"current-revision"`
+	Revision        snap.Revision `json:"revision"`
+	Version         string        `json:"version,omitempty"`
+	Channel         string        `json:"channel,omitempty"`
+	DownloadSize    int64         `json:"download-size"`
+	DiskSpace       uint64        `json:"disk-space"`
+
+	Base          string   `json:"base,omitempty"`
+	PreviousBase  string   `json:"previous-base,omitempty"`
+	Prerequisites []string `json:"prerequisites,omitempty"`
+
+	NewPlugs     []string `json:"new-plugs,omitempty"`
+	NewSlots     []string `json:"new-slots,omitempty"`
+	RemovedPlugs []string `json:"removed-plugs,omitempty"`
+	RemovedSlots []string `json:"removed-slots,omitempty"`
+	Disconnected []string `json:"disconnected,omitempty"`
+
+	RestartServices      []string `json:"restart-services,omitempty"`
+	GateAutoRefreshHooks []string `json:"gate-auto-refresh-hooks,omitempty"`
+}
+
+// RefreshDryRun reports what refreshing the given snaps, or all snaps if
+// none are given, would do, without refreshing anything.
+func (client *Client) RefreshDryRun(names []string) ([]*RefreshImpact, error) {
+	action := multiActionData{
+		Action: "refresh",
+		Snaps:  names,
+		DryRun: true,
+	}
+	data, err := json.Marshal(&action)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal multi-snap action: %s", err)
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	var report []*RefreshImpact
+	if _, err := client.doSync("POST", "/v2/snaps", nil, headers, bytes.NewBuffer(data), &report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
 func (client *Client) HoldRefreshes(name string, options *SnapOptions) (changeID string, err error) {
 	return client.doSnapAction("hold", name, nil, options)
 }
@@ -205,8 +262,10 @@ func (client *Client) Switch(name string, options *SnapOptions) (changeID string
 }
 
 // SnapshotMany snapshots many snaps (all, if names empty) for many users (all, if users is empty).
-func (client *Client) SnapshotMany(names []string, users []string) (setID uint64, changeID string, err error) {
-	result, changeID, err := client.doMultiSnapActionFull("snapshot", names, nil, &SnapOptions{Users: users})
+// The snapshots of the snaps in options only hold the paths they include and do not exclude.
+// The snapshots are encrypted if enc is not nil.
+func (client *Client) SnapshotMany(names []string, users []string, options map[string]*snap.SnapshotOptions, enc *SnapshotEncryption) (setID uint64, changeID string, err error) {
+	result, changeID, err := client.doMultiSnapActionFull("snapshot", names, nil, &SnapOptions{Users: users, SnapshotOptions: options, SnapshotEncryption: enc})
 	if err != nil {
 		return 0, "", err
 	}
@@ -269,6 +328,8 @@ func (client *Client) doMultiSnapActionFull(actionName string, snaps []string, c
 		action.ValidationSets = options.ValidationSets
 		action.Time = options.Time
 		action.HoldLevel = options.HoldLevel
+		action.SnapshotOptions = options.SnapshotOptions
+		action.SnapshotEncryption = options.SnapshotEncryption
 	}
 
 	data, err := json.Marshal(&action)
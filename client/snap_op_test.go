@@ -33,6 +33,7 @@ import (
 	"gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -108,7 +109,7 @@ func (cs *clientSuite) TestClientMultiOpSnapServerError(c *check.C) {
 		_, err := s.op(cs.cli, nil, nil)
 		c.Check(err, check.ErrorMatches, `.*fail`, check.Commentf(s.action))
 	}
-	_, _, err := cs.cli.SnapshotMany(nil, nil)
+	_, _, err := cs.cli.SnapshotMany(nil, nil, nil, nil)
 	c.Check(err, check.ErrorMatches, `.*fail`)
 }
 
@@ -128,7 +129,7 @@ func (cs *clientSuite) TestClientMultiOpSnapResponseError(c *check.C) {
 		_, err := s.op(cs.cli, nil, nil)
 		c.Check(err, check.ErrorMatches, `.*server error: "Internal Server Error"`, check.Commentf(s.action))
 	}
-	_, _, err := cs.cli.SnapshotMany(nil, nil)
+	_, _, err := cs.cli.SnapshotMany(nil, nil, nil, nil)
 	c.Check(err, check.ErrorMatches, `.*server error: "Internal Server Error"`)
 }
 
@@ -292,7 +293,7 @@ func (cs *clientSuite) TestClientMultiSnapshot(c *check.C) {
 		"status-code": 202,
 		"type": "async"
 	}`
-	setID, changeID, err := cs.cli.SnapshotMany([]string{pkgName}, nil)
+	setID, changeID, err := cs.cli.SnapshotMany([]string{pkgName}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(cs.req.Header.Get("Content-Type"), check.Equals, "application/json")
 
@@ -309,6 +310,51 @@ func (cs *clientSuite) TestClientMultiSnapshot(c *check.C) {
 	c.Check(changeID, check.Equals, "d728")
 }
 
+func (cs *clientSuite) TestClientMultiSnapshotEncrypted(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"result": {"set-id": 42},
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	_, _, err := cs.cli.SnapshotMany([]string{pkgName}, nil, nil, &client.SnapshotEncryption{Recipients: []string{"age1…"}})
+	c.Assert(err, check.IsNil)
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	jsonBody := make(map[string]any)
+	err = json.Unmarshal(body, &jsonBody)
+	c.Assert(err, check.IsNil)
+	c.Check(jsonBody["snapshot-encryption"], check.DeepEquals, map[string]any{
+		"recipients": []any{"age1…"},
+	})
+}
+
+func (cs *clientSuite) TestClientMultiSnapshotOptions(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"result": {"set-id": 42},
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	options := map[string]*snap.SnapshotOptions{
+		pkgName: {Include: []string{"$SNAP_USER_DATA/.config"}},
+	}
+	_, _, err := cs.cli.SnapshotMany([]string{pkgName}, nil, options, nil)
+	c.Assert(err, check.IsNil)
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	jsonBody := make(map[string]any)
+	err = json.Unmarshal(body, &jsonBody)
+	c.Assert(err, check.IsNil)
+	c.Check(jsonBody["snapshot-options"], check.DeepEquals, map[string]any{
+		pkgName: map[string]any{"include": []any{"$SNAP_USER_DATA/.config"}},
+	})
+}
+
 func (cs *clientSuite) TestClientOpInstallPath(c *check.C) {
 	cs.status = 202
 	cs.rsp = `{
@@ -908,6 +954,60 @@ func (cs *clientSuite) TestClientRefreshWithValidationSets(c *check.C) {
 	c.Check(cs.req.Header["Content-Type"], check.DeepEquals, []string{"application/json"})
 }
 
+func (cs *clientSuite) TestClientRefreshDryRun(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": [{
+			"name": "foo",
+			"current-revision": "1",
+			"revision": "2",
+			"version": "2.0",
+			"channel": "latest/stable",
+			"download-size": 1000,
+			"disk-space": 6000,
+			"base": "core24",
+			"previous-base": "core22",
+			"prerequisites": ["core24"],
+			"new-plugs": ["network"],
+			"removed-slots": ["content"],
+			"disconnected": ["bar:content foo:content"],
+			"restart-services": ["svc"],
+			"gate-auto-refresh-hooks": ["bar"]
+		}]
+	}`
+
+	report, err := cs.cli.RefreshDryRun([]string{"foo"})
+	c.Assert(err, check.IsNil)
+	c.Check(report, check.DeepEquals, []*client.RefreshImpact{{
+		Name:                 "foo",
+		CurrentRevision:      snap.R(1),
+		Revision:             snap.R(2),
+		Version:              "2.0",
+		Channel:              "latest/stable",
+		DownloadSize:         1000,
+		DiskSpace:            6000,
+		Base:                 "core24",
+		PreviousBase:         "core22",
+		Prerequisites:        []string{"core24"},
+		NewPlugs:             []string{"network"},
+		RemovedSlots:         []string{"content"},
+		Disconnected:         []string{"bar:content foo:content"},
+		RestartServices:      []string{"svc"},
+		GateAutoRefreshHooks: []string{"bar"},
+	}})
+
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps")
+	c.Check(cs.req.Header["Content-Type"], check.DeepEquals, []string{"application/json"})
+	var body map[string]any
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]any{
+		"action":  "refresh",
+		"snaps":   []any{"foo"},
+		"dry-run": true,
+	})
+}
+
 func (cs *clientSuite) TestClientHoldMany(c *check.C) {
 	cs.status = 202
 	cs.rsp = `{
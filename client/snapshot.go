@@ -50,6 +50,25 @@ type snapshotAction struct {
 	Action string   `json:"action"`
 	Snaps  []string `json:"snaps,omitempty"`
 	Users  []string `json:"users,omitempty"`
+
+	Options    *snap.SnapshotOptions `json:"options,omitempty"`
+	Encryption *SnapshotEncryption   `json:"encryption,omitempty"`
+}
+
+// SnapshotEncryption holds the keys to encrypt snapshots to, or to decrypt
+// them with.
+type SnapshotEncryption struct {
+	// Recipients are age X25519 recipients ("age1…") to encrypt to.
+	Recipients []string `json:"recipients,omitempty"`
+	// Passphrase is a passphrase to encrypt to, or decrypt with.
+	Passphrase string `json:"passphrase,omitempty"`
+	// Identities are the content of age identity files to decrypt with.
+	Identities []string `json:"identities,omitempty"`
+}
+
+// IsEncrypted returns whether the snapshot is encrypted.
+func (sh *Snapshot) IsEncrypted() bool {
+	return len(sh.KeyFingerprints) > 0
 }
 
 // A Snapshot is a collection of archives with a simple metadata json file
@@ -77,6 +96,13 @@ type Snapshot struct {
 	SHA3_384 map[string]string `json:"sha3-384"`
 	// the sum of the archive sizes
 	Size int64 `json:"size,omitempty"`
+	// set if the archives are uncompressed tar streams stored as chunks
+	// shared with other snapshots, with each archive entry of the
+	// snapshot file holding the list of its chunks
+	Chunked bool `json:"chunked,omitempty"`
+	// the fingerprints of the keys the archives are encrypted to, set if
+	// the snapshot is encrypted
+	KeyFingerprints []string `json:"key-fingerprints,omitempty"`
 
 	// dynamic snapshot options
 	Options *snap.SnapshotOptions `json:"options,omitempty"`
@@ -195,26 +221,32 @@ func (client *Client) ForgetSnapshots(setID uint64, snaps []string) (changeID st
 // CheckSnapshots verifies the archive checksums in the given snapshot set.
 //
 // If snaps or users are non-empty, limit to checking only those
-// archives of the snapshot.
-func (client *Client) CheckSnapshots(setID uint64, snaps []string, users []string) (changeID string, err error) {
+// archives of the snapshot. Encrypted snapshots are decrypted with the
+// keys in enc, if any, or with the ones snapd has.
+func (client *Client) CheckSnapshots(setID uint64, snaps []string, users []string, enc *SnapshotEncryption) (changeID string, err error) {
 	return client.snapshotAction(&snapshotAction{
-		SetID:  setID,
-		Action: "check",
-		Snaps:  snaps,
-		Users:  users,
+		SetID:      setID,
+		Action:     "check",
+		Snaps:      snaps,
+		Users:      users,
+		Encryption: enc,
 	})
 }
 
 // RestoreSnapshots extracts the given snapshot set.
 //
 // If snaps or users are non-empty, limit to checking only those
-// archives of the snapshot.
-func (client *Client) RestoreSnapshots(setID uint64, snaps []string, users []string) (changeID string, err error) {
+// archives of the snapshot. If options are given, only the paths they
+// include and do not exclude are restored. Encrypted snapshots are
+// decrypted as for CheckSnapshots.
+func (client *Client) RestoreSnapshots(setID uint64, snaps []string, users []string, options *snap.SnapshotOptions, enc *SnapshotEncryption) (changeID string, err error) {
 	return client.snapshotAction(&snapshotAction{
-		SetID:  setID,
-		Action: "restore",
-		Snaps:  snaps,
-		Users:  users,
+		SetID:      setID,
+		Action:     "restore",
+		Snaps:      snaps,
+		Users:      users,
+		Options:    options,
+		Encryption: enc,
 	})
 }
 
@@ -98,7 +98,7 @@ func (cs *clientSuite) TestClientSnapshotSets(c *check.C) {
 	})
 }
 
-func (cs *clientSuite) testClientSnapshotActionFull(c *check.C, action string, users []string, f func() (string, error)) {
+func (cs *clientSuite) testClientSnapshotActionFull(c *check.C, action string, users []string, enc *client.SnapshotEncryption, f func() (string, error)) {
 	cs.status = 202
 	cs.rsp = `{
 		"status-code": 202,
@@ -117,6 +117,7 @@ func (cs *clientSuite) testClientSnapshotActionFull(c *check.C, action string, u
 	c.Check(act.Action, check.Equals, action)
 	c.Check(act.Snaps, check.DeepEquals, []string{"asnap", "bsnap"})
 	c.Check(act.Users, check.DeepEquals, users)
+	c.Check(act.Encryption, check.DeepEquals, enc)
 
 	c.Check(cs.req.Method, check.Equals, "POST")
 	c.Check(cs.req.URL.Path, check.Equals, "/v2/snapshots")
@@ -124,14 +125,19 @@ func (cs *clientSuite) testClientSnapshotActionFull(c *check.C, action string, u
 }
 
 func (cs *clientSuite) TestClientForgetSnapshot(c *check.C) {
-	cs.testClientSnapshotActionFull(c, "forget", nil, func() (string, error) {
+	cs.testClientSnapshotActionFull(c, "forget", nil, nil, func() (string, error) {
 		return cs.cli.ForgetSnapshots(42, []string{"asnap", "bsnap"})
 	})
 }
 
-func (cs *clientSuite) testClientSnapshotAction(c *check.C, action string, f func(uint64, []string, []string) (string, error)) {
-	cs.testClientSnapshotActionFull(c, action, []string{"auser", "buser"}, func() (string, error) {
-		return f(42, []string{"asnap", "bsnap"}, []string{"auser", "buser"})
+func (cs *clientSuite) testClientSnapshotAction(c *check.C, action string, f func(uint64, []string, []string, *client.SnapshotEncryption) (string, error)) {
+	cs.testClientSnapshotActionFull(c, action, []string{"auser", "buser"}, nil, func() (string, error) {
+		return f(42, []string{"asnap", "bsnap"}, []string{"auser", "buser"}, nil)
+	})
+
+	enc := &client.SnapshotEncryption{Passphrase: "secret", Identities: []string{"AGE-SECRET-KEY-1…"}}
+	cs.testClientSnapshotActionFull(c, action, []string{"auser", "buser"}, enc, func() (string, error) {
+		return f(42, []string{"asnap", "bsnap"}, []string{"auser", "buser"}, enc)
 	})
 }
 
@@ -140,7 +146,29 @@ func (cs *clientSuite) TestClientCheckSnapshots(c *check.C) {
 }
 
 func (cs *clientSuite) TestClientRestoreSnapshots(c *check.C) {
-	cs.testClientSnapshotAction(c, "restore", cs.cli.RestoreSnapshots)
+	cs.testClientSnapshotAction(c, "restore", func(setID uint64, snaps, users []string, enc *client.SnapshotEncryption) (string, error) {
+		return cs.cli.RestoreSnapshots(setID, snaps, users, nil, enc)
+	})
+}
+
+func (cs *clientSuite) TestClientRestoreSnapshotsPaths(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"status-code": 202,
+		"type": "async",
+		"change": "1too3"
+	}`
+	options := &snap.SnapshotOptions{
+		Include: []string{"$SNAP_USER_DATA/.config"},
+		Exclude: []string{"$SNAP_USER_DATA/.config/cache"},
+	}
+	_, err := cs.cli.RestoreSnapshots(42, nil, []string{"auser"}, options, nil)
+	c.Assert(err, check.IsNil)
+
+	act, err := client.UnmarshalSnapshotAction(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(act.Action, check.Equals, "restore")
+	c.Check(act.Options, check.DeepEquals, options)
 }
 
 func (cs *clientSuite) TestClientExportSnapshotSpecificErr(c *check.C) {
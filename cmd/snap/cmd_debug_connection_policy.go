@@ -0,0 +1,179 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/snap/snapfile"
+)
+
+type cmdDebugConnectionPolicy struct {
+	clientMixin
+	PlugSnapFile        flags.Filename `long:"plug-snap-file"`
+	PlugSnapDeclaration flags.Filename `long:"plug-snap-declaration"`
+	SlotSnapFile        flags.Filename `long:"slot-snap-file"`
+	SlotSnapDeclaration flags.Filename `long:"slot-snap-declaration"`
+
+	Positionals struct {
+		PlugSpec SnapAndNameStrict `required:"yes"`
+		SlotSpec SnapAndNameStrict `required:"yes"`
+	} `positional-args:"true" required:"true"`
+}
+
+func init() {
+	addDebugCommand("connection-policy",
+		i18n.G("Explain the policy deciding whether a plug and slot may connect"),
+		i18n.G(`
+The connection-policy command evaluates the snap declarations, the base
+declaration and the model, as done when connecting the given plug and slot,
+and shows each rule which was considered, which constraints matched or did
+not, and whether the connection and the auto-connection are allowed.
+
+The plug or slot snap can be a local .snap file, and its snap declaration can
+be provided as a file, for instance to check the policy before the snap is
+uploaded. The snap declaration is not checked to be signed by a trusted key.
+`),
+		func() flags.Commander {
+			return &cmdDebugConnectionPolicy{}
+		}, map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"plug-snap-file": i18n.G("Use the given .snap file instead of the installed plug snap"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"plug-snap-declaration": i18n.G("Use the snap declaration in the given file for the plug snap"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"slot-snap-file": i18n.G("Use the given .snap file instead of the installed slot snap"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"slot-snap-declaration": i18n.G("Use the snap declaration in the given file for the slot snap"),
+		}, []argDesc{
+			// TRANSLATORS: This needs to begin with < and end with >
+			{name: i18n.G("<snap>:<plug>")},
+			// TRANSLATORS: This needs to begin with < and end with >
+			{name: i18n.G("<snap>:<slot>")},
+		})
+}
+
+func snapYamlFromFile(path flags.Filename) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	snapf, err := snapfile.Open(string(path))
+	if err != nil {
+		return "", err
+	}
+	snapYaml, err := snapf.ReadFile("meta/snap.yaml")
+	if err != nil {
+		return "", fmt.Errorf(i18n.G("cannot read snap.yaml of %q: %v"), path, err)
+	}
+	return string(snapYaml), nil
+}
+
+func snapDeclarationFromFile(path flags.Filename) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	decl, err := os.ReadFile(string(path))
+	if err != nil {
+		return "", err
+	}
+	return string(decl), nil
+}
+
+func (x *cmdDebugConnectionPolicy) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	opts := &client.ConnectionPolicyOptions{
+		Plug: client.PlugRef{Snap: x.Positionals.PlugSpec.Snap, Name: x.Positionals.PlugSpec.Name},
+		Slot: client.SlotRef{Snap: x.Positionals.SlotSpec.Snap, Name: x.Positionals.SlotSpec.Name},
+	}
+	var err error
+	if opts.PlugSnapYaml, err = snapYamlFromFile(x.PlugSnapFile); err != nil {
+		return err
+	}
+	if opts.SlotSnapYaml, err = snapYamlFromFile(x.SlotSnapFile); err != nil {
+		return err
+	}
+	if opts.PlugSnapDeclaration, err = snapDeclarationFromFile(x.PlugSnapDeclaration); err != nil {
+		return err
+	}
+	if opts.SlotSnapDeclaration, err = snapDeclarationFromFile(x.SlotSnapDeclaration); err != nil {
+		return err
+	}
+
+	policy, err := x.client.ConnectionPolicy(opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(Stdout, "interface: %s\n", policy.Interface)
+	fmt.Fprintf(Stdout, "plug: %s:%s\n", policy.Plug.Snap, policy.Plug.Name)
+	fmt.Fprintf(Stdout, "slot: %s:%s\n", policy.Slot.Snap, policy.Slot.Name)
+	printConnectionPolicyExplanation(Stdout, policy.Connection)
+	printConnectionPolicyExplanation(Stdout, policy.AutoConnection)
+	return nil
+}
+
+func connectionPolicyVerdict(allowed bool) string {
+	if allowed {
+		return "allowed"
+	}
+	return "not allowed"
+}
+
+func connectionPolicyMatch(matched bool) string {
+	if matched {
+		return "matched"
+	}
+	return "not matched"
+}
+
+func printConnectionPolicyExplanation(w io.Writer, explanation *client.ConnectionPolicyExplanation) {
+	if explanation == nil {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", explanation.Kind)
+	for _, rule := range explanation.Rules {
+		fmt.Fprintf(w, "  %s %s rule: %s\n", rule.Declaration, rule.Side, strings.ReplaceAll(rule.Status, "-", " "))
+		for _, subrule := range rule.Subrules {
+			fmt.Fprintf(w, "    %s: %s\n", subrule.Name, connectionPolicyMatch(subrule.Matched))
+			for _, alt := range subrule.Alternatives {
+				if alt.Reason != "" {
+					fmt.Fprintf(w, "      %s: %s (%s)\n", alt.Constraints, connectionPolicyMatch(alt.Matched), alt.Reason)
+				} else {
+					fmt.Fprintf(w, "      %s: %s\n", alt.Constraints, connectionPolicyMatch(alt.Matched))
+				}
+			}
+		}
+	}
+	if explanation.Error != "" {
+		fmt.Fprintf(w, "  verdict: %s (%s)\n", connectionPolicyVerdict(explanation.Allowed), explanation.Error)
+	} else {
+		fmt.Fprintf(w, "  verdict: %s\n", connectionPolicyVerdict(explanation.Allowed))
+	}
+}
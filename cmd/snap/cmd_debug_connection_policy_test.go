@@ -0,0 +1,154 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+const connectionPolicyResult = `{"type": "sync", "result": {
+	"interface": "raw-usb",
+	"plug": {"snap": "consumer", "plug": "raw-usb"},
+	"slot": {"snap": "core", "slot": "raw-usb"},
+	"connection": {"kind": "connection", "allowed": true, "rules": [
+		{"declaration": "plug-snap-declaration", "side": "plug", "status": "no-rule"},
+		{"declaration": "slot-snap-declaration", "side": "slot", "status": "no-declaration"},
+		{"declaration": "base-declaration", "side": "plug", "status": "no-rule"},
+		{"declaration": "base-declaration", "side": "slot", "status": "evaluated", "subrules": [
+			{"name": "deny-connection", "matched": false, "alternatives": [{"constraints": "false", "matched": false, "reason": "not allowed"}]},
+			{"name": "allow-connection", "matched": true, "alternatives": [{"constraints": "true", "matched": true}]}
+		]}
+	]},
+	"auto-connection": {"kind": "auto-connection", "allowed": false, "error": "auto-connection not allowed by plug rule of interface \"raw-usb\" for \"consumer\" snap", "rules": [
+		{"declaration": "plug-snap-declaration", "side": "plug", "status": "evaluated", "subrules": [
+			{"name": "deny-auto-connection", "matched": false, "alternatives": [{"constraints": "false", "matched": false, "reason": "not allowed"}]},
+			{"name": "allow-auto-connection", "matched": false, "alternatives": [{"constraints": "slot-attributes, on-model", "matched": false, "reason": "attribute \"usb-vendor\" value \"1193\" does not match ^(1194)$"}]}
+		]}
+	]}
+}}`
+
+const connectionPolicyOutput = `interface: raw-usb
+plug: consumer:raw-usb
+slot: core:raw-usb
+connection:
+  plug-snap-declaration plug rule: no rule
+  slot-snap-declaration slot rule: no declaration
+  base-declaration plug rule: no rule
+  base-declaration slot rule: evaluated
+    deny-connection: not matched
+      false: not matched (not allowed)
+    allow-connection: matched
+      true: matched
+  verdict: allowed
+auto-connection:
+  plug-snap-declaration plug rule: evaluated
+    deny-auto-connection: not matched
+      false: not matched (not allowed)
+    allow-auto-connection: not matched
+      slot-attributes, on-model: not matched (attribute "usb-vendor" value "1193" does not match ^(1194)$)
+  verdict: not allowed (auto-connection not allowed by plug rule of interface "raw-usb" for "consumer" snap)
+`
+
+func (s *SnapSuite) TestDebugConnectionPolicy(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/debug")
+			c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]any{
+				"action": "connection-policy",
+				"params": map[string]any{
+					"plug": map[string]any{"snap": "consumer", "plug": "raw-usb"},
+					"slot": map[string]any{"snap": "", "slot": "raw-usb"},
+				},
+			})
+			fmt.Fprintln(w, connectionPolicyResult)
+		default:
+			c.Fatalf("expected to get 1 request, now on %d", n+1)
+		}
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "connection-policy", "consumer:raw-usb", ":raw-usb"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, connectionPolicyOutput)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(n, check.Equals, 1)
+}
+
+func (s *SnapSuite) TestDebugConnectionPolicyLocalSnap(c *check.C) {
+	snapYaml := "name: consumer\nversion: 1\nplugs:\n  raw-usb:\n"
+	// a snap directory, as used by snap try, can be used as a .snap file
+	snapPath := c.MkDir()
+	snaptest.PopulateDir(snapPath, [][]string{{"meta/snap.yaml", snapYaml}})
+	declPath := filepath.Join(c.MkDir(), "consumer.assert")
+	c.Assert(os.WriteFile(declPath, []byte("type: snap-declaration\n"), 0644), check.IsNil)
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			var body map[string]any
+			c.Assert(json.NewDecoder(r.Body).Decode(&body), check.IsNil)
+			c.Check(body["params"], check.DeepEquals, map[string]any{
+				"plug":                  map[string]any{"snap": "consumer", "plug": "raw-usb"},
+				"slot":                  map[string]any{"snap": "core", "slot": "raw-usb"},
+				"plug-snap-yaml":        snapYaml,
+				"plug-snap-declaration": "type: snap-declaration\n",
+			})
+			fmt.Fprintln(w, connectionPolicyResult)
+		default:
+			c.Fatalf("expected to get 1 request, now on %d", n+1)
+		}
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "connection-policy",
+		"--plug-snap-file", snapPath, "--plug-snap-declaration", declPath, "consumer:raw-usb", "core:raw-usb"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, connectionPolicyOutput)
+	c.Check(n, check.Equals, 1)
+}
+
+func (s *SnapSuite) TestDebugConnectionPolicyErrors(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request")
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "connection-policy", "consumer:raw-usb"})
+	c.Check(err, check.ErrorMatches, `the required argument .* was not provided`)
+
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"debug", "connection-policy", "consumer", "core:raw-usb"})
+	c.Check(err, check.ErrorMatches, `invalid value: "consumer" \(want snap:name or :name\)`)
+
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"debug", "connection-policy",
+		"--slot-snap-declaration", filepath.Join(c.MkDir(), "missing"), "consumer:raw-usb", "core:raw-usb"})
+	c.Check(err, check.ErrorMatches, `open .*/missing: no such file or directory`)
+}
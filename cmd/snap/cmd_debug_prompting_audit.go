@@ -0,0 +1,129 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdDebugPromptingAudit struct {
+	clientMixin
+	timeMixin
+	Snap      string `long:"snap"`
+	Interface string `long:"interface"`
+	Source    string `long:"source" choice:"rule" choice:"reply" choice:"timeout" choice:"default"`
+	Since     string `long:"since"`
+	Limit     int    `long:"limit"`
+}
+
+func init() {
+	addDebugCommand("prompting-audit",
+		i18n.G("Show the audit log of AppArmor prompting decisions"),
+		i18n.G(`
+The prompting-audit command displays the decisions taken for requests which
+were subject to AppArmor prompting, along with their source: the rule which
+matched the request, the reply of the user to a prompt, or the expiration of
+a prompt.
+
+Admin users are shown the decisions for requests of all users. Only the
+decisions recorded in the current and the previous audit log are kept.
+`),
+		func() flags.Commander {
+			return &cmdDebugPromptingAudit{}
+		}, timeDescs.also(map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"snap": i18n.G("Only show decisions for the given snap"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"interface": i18n.G("Only show decisions for the given interface"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"source": i18n.G("Only show decisions with the given source (one of: rule, reply, timeout, default)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"since": i18n.G("Only show decisions taken at or after the given time (in RFC 3339 format)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"limit": i18n.G("Only show the given number of most recent decisions"),
+		}), nil)
+}
+
+func (x *cmdDebugPromptingAudit) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	opts := &client.PromptingAuditOptions{
+		Snap:      x.Snap,
+		Interface: x.Interface,
+		Source:    x.Source,
+		Limit:     x.Limit,
+	}
+	if x.Since != "" {
+		since, err := time.Parse(time.RFC3339, x.Since)
+		if err != nil {
+			return fmt.Errorf(i18n.G("cannot parse --since time: %v"), err)
+		}
+		opts.Since = since
+	}
+
+	if x.Limit < 0 {
+		return fmt.Errorf(i18n.G("cannot use a negative --limit"))
+	}
+
+	x.setClient(mkClient())
+	entries, err := x.client.PromptingAudit(opts)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("No prompting decisions were recorded."))
+		return nil
+	}
+
+	w := tabWriter()
+	defer w.Flush()
+
+	fmt.Fprintln(w, i18n.G("Time\tUser\tSnap\tInterface\tPath\tPermissions\tOutcome\tSource\tRule\tPrompt"))
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			x.fmtTime(entry.Timestamp),
+			entry.User,
+			entry.Snap,
+			entry.Interface,
+			entry.Path,
+			strings.Join(entry.Permissions, ","),
+			entry.Outcome,
+			entry.Source,
+			valueOrDash(entry.RuleID),
+			valueOrDash(entry.PromptID))
+	}
+	return nil
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
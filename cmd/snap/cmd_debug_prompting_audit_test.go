@@ -0,0 +1,87 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestDebugPromptingAudit(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/interfaces/requests/audit")
+		c.Check(r.URL.Query().Get("snap"), Equals, "firefox")
+		c.Check(r.URL.Query().Get("source"), Equals, "rule")
+		c.Check(r.URL.Query().Get("since"), Equals, "2026-01-01T00:00:00Z")
+		c.Check(r.URL.Query().Get("limit"), Equals, "2")
+		fmt.Fprint(w, `{"type": "sync", "result": [
+			{"timestamp": "2026-01-02T03:04:05Z", "user": 1000, "snap": "firefox", "interface": "home", "path": "/home/test/foo", "permissions": ["read", "write"], "outcome": "allow", "source": "rule", "rule-id": "0000000000000002"},
+			{"timestamp": "2026-01-02T03:05:05Z", "user": 1000, "snap": "firefox", "interface": "camera", "path": "/dev/video0", "permissions": ["access"], "outcome": "deny", "source": "rule", "rule-id": "0000000000000003", "prompt-id": "0000000000000007"}
+		]}`)
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "prompting-audit", "--abs-time", "--snap=firefox", "--source=rule", "--since=2026-01-01T00:00:00Z", "--limit=2"})
+	c.Assert(err, IsNil)
+	c.Check(rest, HasLen, 0)
+	c.Check(n, Equals, 1)
+	c.Check(s.Stdout(), Equals, `
+Time                  User  Snap     Interface  Path            Permissions  Outcome  Source  Rule              Prompt
+2026-01-02T03:04:05Z  1000  firefox  home       /home/test/foo  read,write   allow    rule    0000000000000002  -
+2026-01-02T03:05:05Z  1000  firefox  camera     /dev/video0     access       deny     rule    0000000000000003  0000000000000007
+`[1:])
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestDebugPromptingAuditEmpty(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.RawQuery, Equals, "")
+		fmt.Fprint(w, `{"type": "sync", "result": []}`)
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "prompting-audit"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "")
+	c.Check(s.Stderr(), Equals, "No prompting decisions were recorded.\n")
+}
+
+func (s *SnapSuite) TestDebugPromptingAuditBadSince(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Errorf("unexpected request")
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "prompting-audit", "--since=yesterday"})
+	c.Check(err, ErrorMatches, `cannot parse --since time: .*`)
+}
+
+func (s *SnapSuite) TestDebugPromptingAuditBadLimit(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Errorf("unexpected request")
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "prompting-audit", "--limit=-1"})
+	c.Check(err, ErrorMatches, `cannot use a negative --limit`)
+}
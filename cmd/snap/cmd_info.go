@@ -51,6 +51,7 @@ type infoCmd struct {
 	timeMixin
 
 	Verbose    bool `long:"verbose"`
+	Changelog  bool `long:"changelog"`
 	Positional struct {
 		Snaps []anySnapName `positional-arg-name:"<snap>" required:"1"`
 	} `positional-args:"yes" required:"yes"`
@@ -75,6 +76,8 @@ func init() {
 		}, colorDescs.also(timeDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"verbose": i18n.G("Include more details on the snap (expanded notes, base, etc.)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"changelog": i18n.G("Include the changelog of the installed revision and of the pending refresh, if any"),
 		}), nil)
 }
 
@@ -156,6 +159,7 @@ type infoWriter struct {
 	localSnap  *client.Snap
 	remoteSnap *client.Snap
 	resInfo    *client.ResultInfo
+	changelogs []*client.SnapChangelog
 	path       string
 	// fields that don't change and so can be set once
 	writeflusher
@@ -168,6 +172,7 @@ type infoWriter struct {
 
 func (iw *infoWriter) setupDiskSnap(path string, diskSnap *client.Snap) {
 	iw.localSnap, iw.remoteSnap, iw.resInfo = nil, nil, nil
+	iw.changelogs = nil
 	iw.path = path
 	iw.diskSnap = diskSnap
 	iw.theSnap = diskSnap
@@ -175,6 +180,7 @@ func (iw *infoWriter) setupDiskSnap(path string, diskSnap *client.Snap) {
 
 func (iw *infoWriter) setupSnap(localSnap, remoteSnap *client.Snap, resInfo *client.ResultInfo) {
 	iw.path, iw.diskSnap = "", nil
+	iw.changelogs = nil
 	iw.localSnap = localSnap
 	iw.remoteSnap = remoteSnap
 	iw.resInfo = resInfo
@@ -441,6 +447,28 @@ func (iw *infoWriter) printDescr() {
 	printDescr(iw, iw.theSnap.Description, iw.termWidth)
 }
 
+func (iw *infoWriter) maybePrintChangelog() {
+	if len(iw.changelogs) == 0 {
+		return
+	}
+
+	fmt.Fprintln(iw, "changelog:")
+	for _, cl := range iw.changelogs {
+		kind := "installed"
+		if cl.Candidate {
+			kind = "refresh"
+		}
+		if cl.Changelog == "" {
+			fmt.Fprintf(iw, "  %s %s (%s): unset\n", kind, cl.Version, cl.Revision)
+			continue
+		}
+		fmt.Fprintf(iw, "  %s %s (%s): |\n", kind, cl.Version, cl.Revision)
+		for _, line := range strings.Split(strings.TrimRightFunc(cl.Changelog, unicode.IsSpace), "\n") {
+			strutil.WordWrapPadded(iw, []rune(line), "    ", iw.termWidth)
+		}
+	}
+}
+
 func (iw *infoWriter) maybePrintCommands() {
 	if len(iw.theSnap.Apps) == 0 {
 		return
@@ -681,6 +709,9 @@ func (x *infoCmd) Execute([]string) error {
 			remoteSnap, resInfo, _ := x.client.FindOne(snap.InstanceSnap(snapName))
 			localSnap, _, _ := x.client.Snap(snapName)
 			iw.setupSnap(localSnap, remoteSnap, resInfo)
+			if x.Changelog && localSnap != nil {
+				iw.changelogs, _ = x.client.SnapChangelogs(snapName)
+			}
 		}
 		// note diskSnap == nil, or localSnap == nil and remoteSnap == nil
 
@@ -707,6 +738,7 @@ func (x *infoCmd) Execute([]string) error {
 		iw.printLicense()
 		iw.maybePrintPrice()
 		iw.printDescr()
+		iw.maybePrintChangelog()
 		iw.maybePrintCommands()
 		iw.maybePrintServices()
 		iw.maybePrintNotes()
@@ -0,0 +1,213 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/osutil"
+)
+
+var shortPromptingRulesHelp = i18n.G("Export or import AppArmor prompting rules")
+var longPromptingRulesHelp = i18n.G(`
+The prompting-rules command exports the AppArmor prompting rules of the
+current user to a file, or imports rules from such a file.
+
+Only the permissions of rules which apply forever are exported. Rules
+provisioned by the system administrator are not exported, as they already
+apply to all users.
+
+The files use the same format as the rules which the system administrator
+provisions in /etc/snapd/prompting-rules.d.
+`)
+
+var shortPromptingRulesExportHelp = i18n.G("Export AppArmor prompting rules")
+var longPromptingRulesExportHelp = i18n.G(`
+The export sub-command writes the AppArmor prompting rules of the current user
+to the given file, or to standard output if no file is given.
+`)
+
+var shortPromptingRulesImportHelp = i18n.G("Import AppArmor prompting rules")
+var longPromptingRulesImportHelp = i18n.G(`
+The import sub-command adds the AppArmor prompting rules in the given file as
+rules of the current user.
+`)
+
+type cmdPromptingRules struct{}
+
+type cmdPromptingRulesExport struct {
+	clientMixin
+	Snap       string `long:"snap" description:"Only export rules for the given snap"`
+	Interface  string `long:"interface" description:"Only export rules for the given interface"`
+	Positional struct {
+		File string `positional-arg-name:"<file>" description:"file to write the rules to"`
+	} `positional-args:"yes"`
+}
+
+type cmdPromptingRulesImport struct {
+	clientMixin
+	Positional struct {
+		File string `positional-arg-name:"<file>" description:"file to read the rules from" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	cmd := addCommand("prompting-rules", shortPromptingRulesHelp, longPromptingRulesHelp, func() flags.Commander {
+		return &cmdPromptingRules{}
+	}, nil, nil)
+	cmd.extra = func(c *flags.Command) {
+		c.AddCommand("export", shortPromptingRulesExportHelp, longPromptingRulesExportHelp, &cmdPromptingRulesExport{})
+		c.AddCommand("import", shortPromptingRulesImportHelp, longPromptingRulesImportHelp, &cmdPromptingRulesImport{})
+	}
+}
+
+func (x *cmdPromptingRules) Execute(args []string) error {
+	return flag.ErrHelp
+}
+
+// promptingRuleJSON is the format of an exported rule. The constraints are
+// those which would be given when adding the rule.
+type promptingRuleJSON struct {
+	Snap        string                     `json:"snap"`
+	Interface   string                     `json:"interface"`
+	Constraints map[string]json.RawMessage `json:"constraints"`
+}
+
+type promptingRulesFileJSON struct {
+	Rules []*promptingRuleJSON `json:"rules"`
+}
+
+type promptingPermissionEntry struct {
+	Outcome  string `json:"outcome"`
+	Lifespan string `json:"lifespan"`
+}
+
+// exportedPromptingRule returns the given rule in the format used when
+// exporting it, retaining only the permissions with lifespan "forever". If the
+// rule has no such permissions, returns nil.
+func exportedPromptingRule(rule *client.PromptingRule) (*promptingRuleJSON, error) {
+	var permissions map[string]*promptingPermissionEntry
+	if err := json.Unmarshal(rule.Constraints["permissions"], &permissions); err != nil {
+		return nil, fmt.Errorf("cannot decode permissions of rule %s: %v", rule.ID, err)
+	}
+	for perm, entry := range permissions {
+		if entry.Lifespan != "forever" {
+			delete(permissions, perm)
+		}
+	}
+	if len(permissions) == 0 {
+		return nil, nil
+	}
+	permissionsJSON, err := json.Marshal(permissions)
+	if err != nil {
+		return nil, err
+	}
+	constraints := make(map[string]json.RawMessage, len(rule.Constraints))
+	for key, value := range rule.Constraints {
+		constraints[key] = value
+	}
+	constraints["permissions"] = permissionsJSON
+	return &promptingRuleJSON{
+		Snap:        rule.Snap,
+		Interface:   rule.Interface,
+		Constraints: constraints,
+	}, nil
+}
+
+func (x *cmdPromptingRulesExport) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+	x.setClient(mkClient())
+
+	rules, err := x.client.PromptingRules(&client.PromptingRulesOptions{
+		Snap:      x.Snap,
+		Interface: x.Interface,
+	})
+	if err != nil {
+		return err
+	}
+	exported := promptingRulesFileJSON{
+		Rules: make([]*promptingRuleJSON, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		if rule.AdminManaged {
+			continue
+		}
+		exportedRule, err := exportedPromptingRule(rule)
+		if err != nil {
+			return err
+		}
+		if exportedRule != nil {
+			exported.Rules = append(exported.Rules, exportedRule)
+		}
+	}
+	data, err := json.MarshalIndent(&exported, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if x.Positional.File == "" || x.Positional.File == "-" {
+		_, err := Stdout.Write(data)
+		return err
+	}
+	if err := osutil.AtomicWriteFile(x.Positional.File, data, 0o600, 0); err != nil {
+		return err
+	}
+	fmt.Fprintf(Stdout, i18n.NG("Exported %d rule to %q\n", "Exported %d rules to %q\n", len(exported.Rules)), len(exported.Rules), x.Positional.File)
+	return nil
+}
+
+func (x *cmdPromptingRulesImport) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+	x.setClient(mkClient())
+
+	var r io.Reader = Stdin
+	if x.Positional.File != "-" {
+		f, err := os.Open(x.Positional.File)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var imported promptingRulesFileJSON
+	if err := json.NewDecoder(r).Decode(&imported); err != nil {
+		return fmt.Errorf(i18n.G("cannot decode prompting rules: %v"), err)
+	}
+	for i, rule := range imported.Rules {
+		if _, err := x.client.AddPromptingRule(rule.Snap, rule.Interface, rule.Constraints); err != nil {
+			return fmt.Errorf(i18n.G("cannot import rule for snap %q (%d rules were imported): %v"), rule.Snap, i, err)
+		}
+	}
+	fmt.Fprintf(Stdout, i18n.NG("Imported %d rule\n", "Imported %d rules\n", len(imported.Rules)), len(imported.Rules))
+	return nil
+}
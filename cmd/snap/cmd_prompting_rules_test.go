@@ -0,0 +1,172 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+const promptingRulesResponse = `{"type": "sync", "result": [
+	{
+		"id": "0000000000000001",
+		"timestamp": "2026-01-02T03:04:05Z",
+		"user": 1000,
+		"snap": "firefox",
+		"interface": "home",
+		"constraints": {
+			"path-pattern": "/home/test/Downloads/**",
+			"permissions": {
+				"read": {"outcome": "allow", "lifespan": "forever"},
+				"write": {"outcome": "allow", "lifespan": "timespan", "expiration": "2026-01-02T04:04:05Z"}
+			}
+		}
+	},
+	{
+		"id": "0000000000000002",
+		"timestamp": "2026-01-02T03:04:05Z",
+		"user": 1000,
+		"snap": "firefox",
+		"interface": "camera",
+		"constraints": {
+			"permissions": {
+				"access": {"outcome": "deny", "lifespan": "session", "session-id": "0123456789ABCDEF"}
+			}
+		}
+	},
+	{
+		"id": "0000000000000003",
+		"timestamp": "2026-01-02T03:04:05Z",
+		"user": 4294967295,
+		"snap": "firefox",
+		"interface": "home",
+		"constraints": {
+			"path-pattern": "/home/*/.ssh/**",
+			"permissions": {
+				"read": {"outcome": "deny", "lifespan": "forever"}
+			}
+		},
+		"admin-managed": true
+	}
+]}`
+
+const exportedPromptingRules = `{
+  "rules": [
+    {
+      "snap": "firefox",
+      "interface": "home",
+      "constraints": {
+        "path-pattern": "/home/test/Downloads/**",
+        "permissions": {
+          "read": {
+            "outcome": "allow",
+            "lifespan": "forever"
+          }
+        }
+      }
+    }
+  ]
+}
+`
+
+func (s *SnapSuite) TestPromptingRulesExport(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/interfaces/requests/rules")
+		c.Check(r.URL.Query().Get("snap"), Equals, "firefox")
+		fmt.Fprint(w, promptingRulesResponse)
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "export", "--snap=firefox"})
+	c.Assert(err, IsNil)
+	c.Check(rest, HasLen, 0)
+	c.Check(s.Stdout(), Equals, exportedPromptingRules)
+	c.Check(s.Stderr(), Equals, "")
+	s.ResetStdStreams()
+
+	path := filepath.Join(c.MkDir(), "rules.json")
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "export", "--snap=firefox", path})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, fmt.Sprintf("Exported 1 rule to %q\n", path))
+	c.Check(path, testutil.FileEquals, exportedPromptingRules)
+	c.Check(n, Equals, 2)
+}
+
+func (s *SnapSuite) TestPromptingRulesImport(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, Equals, "POST")
+		c.Check(r.URL.Path, Equals, "/v2/interfaces/requests/rules")
+		body := DecodedRequestBody(c, r)
+		c.Check(body["action"], Equals, "add")
+		rule := body["rule"].(map[string]any)
+		c.Check(rule["snap"], Equals, "firefox")
+		switch n {
+		case 1:
+			c.Check(rule["interface"], Equals, "home")
+			fmt.Fprint(w, `{"type": "sync", "result": {"id": "0000000000000004", "snap": "firefox", "interface": "home"}}`)
+		case 2:
+			c.Check(rule["interface"], Equals, "camera")
+			w.WriteHeader(409)
+			fmt.Fprint(w, `{"type": "error", "status-code": 409, "result": {"message": "a rule with conflicting path pattern and permission already exists in the rule database", "kind": "interfaces-requests-rule-conflict"}}`)
+		default:
+			c.Errorf("unexpected request %d", n)
+		}
+	})
+
+	path := filepath.Join(c.MkDir(), "rules.json")
+	c.Assert(os.WriteFile(path, []byte(exportedPromptingRules), 0o600), IsNil)
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "import", path})
+	c.Assert(err, IsNil)
+	c.Check(rest, HasLen, 0)
+	c.Check(s.Stdout(), Equals, "Imported 1 rule\n")
+	s.ResetStdStreams()
+
+	rules := `{"rules": [
+		{"snap": "firefox", "interface": "home", "constraints": {"path-pattern": "/home/test/**", "permissions": {"read": {"outcome": "allow", "lifespan": "forever"}}}},
+		{"snap": "firefox", "interface": "camera", "constraints": {"permissions": {"access": {"outcome": "allow", "lifespan": "forever"}}}}
+	]}`
+	c.Assert(os.WriteFile(path, []byte(rules), 0o600), IsNil)
+	n = 0
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "import", path})
+	c.Check(err, ErrorMatches, `cannot import rule for snap "firefox" \(1 rules were imported\): a rule with conflicting path pattern .*`)
+	c.Check(n, Equals, 2)
+}
+
+func (s *SnapSuite) TestPromptingRulesImportInvalid(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Errorf("unexpected request")
+	})
+
+	path := filepath.Join(c.MkDir(), "rules.json")
+	c.Assert(os.WriteFile(path, []byte("{"), 0o600), IsNil)
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "import", path})
+	c.Check(err, ErrorMatches, "cannot decode prompting rules: unexpected EOF")
+}
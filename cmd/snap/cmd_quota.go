@@ -85,6 +85,16 @@ with the remove-quota command and recreated with a lower limit. Increasing the
 memory limit for a quota group does not restart any services associated with 
 snaps in the quota group.
 
+The memory high limit is a soft limit, above which the processes of the quota
+group are throttled and their memory reclaimed rather than killed. The memory
+low protection is the amount of memory of the quota group protected from being
+reclaimed, and the memory swap limit is the amount of swap the quota group can
+use. They can be increased and decreased after being set on a quota group, and
+can be removed again by setting them to "none".
+Sub-groups cannot have a larger memory high or swap limit than their parent
+groups, and the memory protections of sub-groups cannot exceed the one of their
+parent group combined. These limits require cgroup v2.
+
 The CPU limit for a quota group can be both increased and decreased after being
 set on a quota group. The CPU limit can be specified as a single percentage which
 means that the quota group is allowed an overall percentage of the CPU resources. Setting
@@ -99,12 +109,27 @@ The threads limit for a quota group can be increased but not decreased. To
 decrease the threads limit for a quota group, the entire group must be removed
 with the remove-quota command and recreated with a lower limit.
 
+The IO bandwidth limits and the IO weight can be increased and decreased after
+being set on a quota group, and removed again by setting them to "none". The
+bandwidth limits are given in bytes per second, e.g. 10MB or 10MB/s, and only
+apply to the single disk holding the snap data, reads and writes to any other
+disk are not limited. The weight is between 1 and 10000, and determines the
+share of IO time the quota group gets relative to other groups and services,
+the default weight being 100. IO quotas require cgroup v2.
+
+The network traffic of a quota group is not accounted by default. Setting
+--network-accounting=true enables it, and snap quota then reports the traffic
+received and sent by the group. It can be disabled again with
+--network-accounting=false.
+
 The journal limits can be increased and decreased after being set on a group.
 Setting a journal limit will cause the snaps in the group to be put into the same
 journal namespace. This will affect the behaviour of the log command.
 
-New quotas can be set on existing quota groups, but existing quotas cannot be removed
-from a quota group, without removing and recreating the entire group.
+New quotas can be set on existing quota groups, but existing quotas other than
+the IO ones, the memory high and swap limits and the memory low protection
+cannot be removed from a quota group, without removing and recreating the
+entire group.
 
 Adding new snaps to a quota group will result in all non-disabled services in 
 that snap being restarted.
@@ -117,11 +142,18 @@ func init() {
 		func() flags.Commander { return &cmdSetQuota{} },
 		waitDescs.also(map[string]string{
 			"memory":             i18n.G("Memory quota"),
+			"memory-high":        i18n.G("Memory soft limit, above which the processes are throttled"),
+			"memory-low":         i18n.G("Memory protected from being reclaimed"),
+			"memory-swap-max":    i18n.G("Swap quota"),
 			"cpu":                i18n.G("CPU quota"),
 			"cpu-set":            i18n.G("CPU set quota"),
 			"threads":            i18n.G("Threads quota"),
 			"journal-size":       i18n.G("Journal size quota"),
 			"journal-rate-limit": i18n.G("Journal rate limit as <message count>/<message period>"),
+			"io-read-bandwidth":  i18n.G("IO read bandwidth quota, in bytes per second"),
+			"io-write-bandwidth": i18n.G("IO write bandwidth quota, in bytes per second"),
+			"io-weight":          i18n.G("IO weight, between 1 and 10000"),
+			"network-accounting": i18n.G("Whether to account the network traffic of the group (true|false)"),
 			"parent":             i18n.G("Parent quota group"),
 		}), nil)
 	addCommand("quota", shortQuotaHelp, longQuotaHelp, func() flags.Commander { return &cmdQuota{} }, nil, nil)
@@ -133,11 +165,18 @@ type cmdSetQuota struct {
 	waitMixin
 
 	MemoryMax        string `long:"memory" optional:"true"`
+	MemoryHigh       string `long:"memory-high" optional:"true"`
+	MemoryLow        string `long:"memory-low" optional:"true"`
+	MemorySwapMax    string `long:"memory-swap-max" optional:"true"`
 	CPUMax           string `long:"cpu" optional:"true"`
 	CPUSet           string `long:"cpu-set" optional:"true"`
 	ThreadsMax       string `long:"threads" optional:"true"`
 	JournalSizeMax   string `long:"journal-size" optional:"true"`
 	JournalRateLimit string `long:"journal-rate-limit" optional:"true"`
+	IOReadBandwidth  string `long:"io-read-bandwidth" optional:"true"`
+	IOWriteBandwidth string `long:"io-write-bandwidth" optional:"true"`
+	IOWeight         string `long:"io-weight" optional:"true"`
+	NetworkAccount   string `long:"network-accounting" optional:"true"`
 	Parent           string `long:"parent" optional:"true"`
 	Positional       struct {
 		GroupName string        `positional-arg-name:"<group-name>" required:"true"`
@@ -194,6 +233,25 @@ func parseJournalRateQuota(journalRateLimit string) (count int, period time.Dura
 	return count, period, nil
 }
 
+// quotaNone removes an io limit, memory high or swap limit or memory protection
+// from a quota group.
+const quotaNone = "none"
+
+func parseIOBandwidthQuota(bandwidth string) (quantity.Size, error) {
+	if bandwidth == quotaNone {
+		return 0, nil
+	}
+	// the bandwidth is a byte size per second, with an optional "/s"
+	value, err := strutil.ParseByteSize(strings.TrimSuffix(bandwidth, "/s"))
+	if err != nil {
+		return 0, err
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("bandwidth must be larger than zero")
+	}
+	return quantity.Size(value), nil
+}
+
 func (x *cmdSetQuota) parseQuotas() (*client.QuotaValues, error) {
 	var quotaValues client.QuotaValues
 
@@ -204,6 +262,39 @@ func (x *cmdSetQuota) parseQuotas() (*client.QuotaValues, error) {
 		}
 		quotaValues.Memory = quantity.Size(value)
 	}
+	if x.MemoryHigh != "" {
+		var high quantity.Size
+		if x.MemoryHigh != quotaNone {
+			value, err := strutil.ParseByteSize(x.MemoryHigh)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse memory high limit %q: %v", x.MemoryHigh, err)
+			}
+			high = quantity.Size(value)
+		}
+		quotaValues.MemoryHigh = &high
+	}
+	if x.MemoryLow != "" {
+		var low quantity.Size
+		if x.MemoryLow != quotaNone {
+			value, err := strutil.ParseByteSize(x.MemoryLow)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse memory low protection %q: %v", x.MemoryLow, err)
+			}
+			low = quantity.Size(value)
+		}
+		quotaValues.MemoryLow = &low
+	}
+	if x.MemorySwapMax != "" {
+		var swapMax quantity.Size
+		if x.MemorySwapMax != quotaNone {
+			value, err := strutil.ParseByteSize(x.MemorySwapMax)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse memory swap limit %q: %v", x.MemorySwapMax, err)
+			}
+			swapMax = quantity.Size(value)
+		}
+		quotaValues.MemorySwapMax = &swapMax
+	}
 
 	if x.CPUMax != "" {
 		countValue, percentageValue, err := parseCpuQuota(x.CPUMax)
@@ -266,12 +357,52 @@ func (x *cmdSetQuota) parseQuotas() (*client.QuotaValues, error) {
 		}
 	}
 
+	if x.IOReadBandwidth != "" || x.IOWriteBandwidth != "" || x.IOWeight != "" {
+		quotaValues.IO = &client.QuotaIOValues{}
+		if x.IOReadBandwidth != "" {
+			value, err := parseIOBandwidthQuota(x.IOReadBandwidth)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse IO read bandwidth %q: %v", x.IOReadBandwidth, err)
+			}
+			quotaValues.IO.ReadBandwidth = &value
+		}
+		if x.IOWriteBandwidth != "" {
+			value, err := parseIOBandwidthQuota(x.IOWriteBandwidth)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse IO write bandwidth %q: %v", x.IOWriteBandwidth, err)
+			}
+			quotaValues.IO.WriteBandwidth = &value
+		}
+		if x.IOWeight == quotaNone {
+			weight := 0
+			quotaValues.IO.Weight = &weight
+		} else if x.IOWeight != "" {
+			value, err := strconv.ParseUint(x.IOWeight, 10, 32)
+			if err != nil || value < 1 || value > 10000 {
+				return nil, fmt.Errorf("cannot use IO weight value %q: weight must be between 1 and 10000", x.IOWeight)
+			}
+			weight := int(value)
+			quotaValues.IO.Weight = &weight
+		}
+	}
+
+	if x.NetworkAccount != "" {
+		enabled, err := strconv.ParseBool(x.NetworkAccount)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse network accounting value %q: must be true or false", x.NetworkAccount)
+		}
+		quotaValues.Network = &client.QuotaNetworkValues{Accounting: &enabled}
+	}
+
 	return &quotaValues, nil
 }
 
 func (x *cmdSetQuota) hasQuotaSet() bool {
-	return x.MemoryMax != "" || x.CPUMax != "" || x.CPUSet != "" ||
-		x.ThreadsMax != "" || x.JournalSizeMax != "" || x.JournalRateLimit != ""
+	return x.MemoryMax != "" || x.MemoryHigh != "" || x.MemoryLow != "" || x.MemorySwapMax != "" ||
+		x.CPUMax != "" || x.CPUSet != "" ||
+		x.ThreadsMax != "" || x.JournalSizeMax != "" || x.JournalRateLimit != "" ||
+		x.IOReadBandwidth != "" || x.IOWriteBandwidth != "" || x.IOWeight != "" ||
+		x.NetworkAccount != ""
 }
 
 func (x *cmdSetQuota) splitSnapsAndServices() (snaps []string, services []string) {
@@ -416,6 +547,18 @@ func (x *cmdQuota) Execute(args []string) (err error) {
 		val := strings.TrimSpace(fmtSize(int64(group.Constraints.Memory)))
 		fmt.Fprintf(w, "  memory:\t%s\n", val)
 	}
+	if group.Constraints.MemoryHigh != nil {
+		val := strings.TrimSpace(fmtSize(int64(*group.Constraints.MemoryHigh)))
+		fmt.Fprintf(w, "  memory-high:\t%s\n", val)
+	}
+	if group.Constraints.MemoryLow != nil {
+		val := strings.TrimSpace(fmtSize(int64(*group.Constraints.MemoryLow)))
+		fmt.Fprintf(w, "  memory-low:\t%s\n", val)
+	}
+	if group.Constraints.MemorySwapMax != nil {
+		val := strings.TrimSpace(fmtSize(int64(*group.Constraints.MemorySwapMax)))
+		fmt.Fprintf(w, "  memory-swap-max:\t%s\n", val)
+	}
 	if group.Constraints.CPU != nil {
 		fmt.Fprintf(w, "  cpu-count:\t%d\n", group.Constraints.CPU.Count)
 		fmt.Fprintf(w, "  cpu-percentage:\t%d\n", group.Constraints.CPU.Percentage)
@@ -438,6 +581,22 @@ func (x *cmdQuota) Execute(args []string) (err error) {
 				group.Constraints.Journal.RatePeriod)
 		}
 	}
+	if group.Constraints.IO != nil {
+		if group.Constraints.IO.ReadBandwidth != nil {
+			val := strings.TrimSpace(fmtSize(int64(*group.Constraints.IO.ReadBandwidth)))
+			fmt.Fprintf(w, "  io-read-bandwidth:\t%s/s\n", val)
+		}
+		if group.Constraints.IO.WriteBandwidth != nil {
+			val := strings.TrimSpace(fmtSize(int64(*group.Constraints.IO.WriteBandwidth)))
+			fmt.Fprintf(w, "  io-write-bandwidth:\t%s/s\n", val)
+		}
+		if group.Constraints.IO.Weight != nil {
+			fmt.Fprintf(w, "  io-weight:\t%d\n", *group.Constraints.IO.Weight)
+		}
+	}
+	if group.Constraints.Network != nil && group.Constraints.Network.Accounting != nil {
+		fmt.Fprintf(w, "  network-accounting:\t%t\n", *group.Constraints.Network.Accounting)
+	}
 
 	memoryUsage := "0B"
 	currentThreads := 0
@@ -447,12 +606,17 @@ func (x *cmdQuota) Execute(args []string) (err error) {
 	}
 
 	fmt.Fprintf(w, "current:\n")
-	if group.Constraints.Memory != 0 {
+	if group.Constraints.Memory != 0 || group.Constraints.MemoryHigh != nil {
 		fmt.Fprintf(w, "  memory:\t%s\n", memoryUsage)
 	}
 	if group.Constraints.Threads != 0 {
 		fmt.Fprintf(w, "  threads:\t%d\n", currentThreads)
 	}
+	// network traffic is only reported with network accounting enabled
+	if group.Current != nil && group.Current.Network != nil {
+		fmt.Fprintf(w, "  network-received:\t%s\n", strings.TrimSpace(fmtSize(int64(group.Current.Network.Ingress))))
+		fmt.Fprintf(w, "  network-sent:\t%s\n", strings.TrimSpace(fmtSize(int64(group.Current.Network.Egress))))
+	}
 
 	if len(group.Subgroups) > 0 {
 		fmt.Fprint(w, "subgroups:\n")
@@ -527,6 +691,15 @@ func (x *cmdQuotas) Execute(args []string) (err error) {
 		if q.Constraints.Memory != 0 {
 			grpConstraints = append(grpConstraints, "memory="+strings.TrimSpace(fmtSize(int64(q.Constraints.Memory))))
 		}
+		if q.Constraints.MemoryHigh != nil {
+			grpConstraints = append(grpConstraints, "memory-high="+strings.TrimSpace(fmtSize(int64(*q.Constraints.MemoryHigh))))
+		}
+		if q.Constraints.MemoryLow != nil {
+			grpConstraints = append(grpConstraints, "memory-low="+strings.TrimSpace(fmtSize(int64(*q.Constraints.MemoryLow))))
+		}
+		if q.Constraints.MemorySwapMax != nil {
+			grpConstraints = append(grpConstraints, "memory-swap-max="+strings.TrimSpace(fmtSize(int64(*q.Constraints.MemorySwapMax))))
+		}
 
 		// format cpu constraint as cpu=NxM%,cpu-set=x,y,z
 		if q.Constraints.CPU != nil {
@@ -560,10 +733,23 @@ func (x *cmdQuotas) Execute(args []string) (err error) {
 			}
 		}
 
+		// format io constraint as io-read-bandwidth=xMB/s,io-write-bandwidth=xMB/s,io-weight=N
+		if q.Constraints.IO != nil {
+			if q.Constraints.IO.ReadBandwidth != nil {
+				grpConstraints = append(grpConstraints, "io-read-bandwidth="+strings.TrimSpace(fmtSize(int64(*q.Constraints.IO.ReadBandwidth)))+"/s")
+			}
+			if q.Constraints.IO.WriteBandwidth != nil {
+				grpConstraints = append(grpConstraints, "io-write-bandwidth="+strings.TrimSpace(fmtSize(int64(*q.Constraints.IO.WriteBandwidth)))+"/s")
+			}
+			if q.Constraints.IO.Weight != nil {
+				grpConstraints = append(grpConstraints, "io-weight="+strconv.Itoa(*q.Constraints.IO.Weight))
+			}
+		}
+
 		// format current resource values as memory=N,threads=N
 		var grpCurrent []string
 		if q.Current != nil {
-			if q.Constraints.Memory != 0 && q.Current.Memory != 0 {
+			if (q.Constraints.Memory != 0 || q.Constraints.MemoryHigh != nil) && q.Current.Memory != 0 {
 				grpCurrent = append(grpCurrent, "memory="+strings.TrimSpace(fmtSize(int64(q.Current.Memory))))
 			}
 			if q.Constraints.Threads != 0 && q.Current.Threads != 0 {
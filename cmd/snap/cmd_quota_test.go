@@ -259,6 +259,80 @@ func (s *quotaSuite) TestParseQuotas(c *check.C) {
 	}
 }
 
+func (s *quotaSuite) TestParseIOQuotas(c *check.C) {
+	for _, testData := range []struct {
+		readBandwidth     string
+		writeBandwidth    string
+		weight            string
+		networkAccounting string
+
+		quotas string
+		err    string
+	}{
+		{readBandwidth: "10MB", quotas: `{"io":{"read-bandwidth":10000000}}`},
+		{readBandwidth: "10MB/s", writeBandwidth: "1KB/s", quotas: `{"io":{"read-bandwidth":10000000,"write-bandwidth":1000}}`},
+		{weight: "500", quotas: `{"io":{"weight":500}}`},
+		{readBandwidth: "none", weight: "none", quotas: `{"io":{"read-bandwidth":0,"weight":0}}`},
+		{networkAccounting: "true", quotas: `{"network":{"accounting":true}}`},
+		{networkAccounting: "false", quotas: `{"network":{"accounting":false}}`},
+
+		// Error cases
+		{readBandwidth: "10", err: `cannot parse IO read bandwidth "10": cannot parse "10": need a number with a unit as input`},
+		{writeBandwidth: "0B/s", err: `cannot parse IO write bandwidth "0B/s": bandwidth must be larger than zero`},
+		{weight: "0", err: `cannot use IO weight value "0": weight must be between 1 and 10000`},
+		{weight: "10001", err: `cannot use IO weight value "10001": weight must be between 1 and 10000`},
+		{weight: "x", err: `cannot use IO weight value "x": weight must be between 1 and 10000`},
+		{networkAccounting: "maybe", err: `cannot parse network accounting value "maybe": must be true or false`},
+	} {
+		quotas, err := main.ParseIOQuotaValues(testData.readBandwidth, testData.writeBandwidth, testData.weight, testData.networkAccounting)
+		testLabel := check.Commentf("%v", testData)
+		if testData.err == "" {
+			c.Check(err, check.IsNil, testLabel)
+			var jsonQuota bytes.Buffer
+			err := json.NewEncoder(&jsonQuota).Encode(quotas)
+			c.Assert(err, check.IsNil, testLabel)
+			c.Check(strings.TrimSpace(jsonQuota.String()), check.Equals, testData.quotas, testLabel)
+		} else {
+			c.Check(err, check.ErrorMatches, testData.err, testLabel)
+		}
+	}
+}
+
+func (s *quotaSuite) TestParseMemoryQuotas(c *check.C) {
+	for _, testData := range []struct {
+		memoryMax     string
+		memoryHigh    string
+		memoryLow     string
+		memorySwapMax string
+
+		quotas string
+		err    string
+	}{
+		{memoryHigh: "512MB", quotas: `{"memory-high":512000000}`},
+		{memoryMax: "1GB", memoryHigh: "512MB", memoryLow: "128MB", quotas: `{"memory":1000000000,"memory-high":512000000,"memory-low":128000000}`},
+		{memorySwapMax: "256MB", quotas: `{"memory-swap-max":256000000}`},
+		// the memory high and swap limits and protection can be removed
+		{memoryHigh: "none", memoryLow: "none", memorySwapMax: "none", quotas: `{"memory-high":0,"memory-low":0,"memory-swap-max":0}`},
+
+		// Error cases
+		{memoryHigh: "512", err: `cannot parse memory high limit "512": cannot parse "512": need a number with a unit as input`},
+		{memoryLow: "x", err: `cannot parse memory low protection "x": .*`},
+		{memorySwapMax: "-1MB", err: `cannot parse memory swap limit "-1MB": .*`},
+	} {
+		quotas, err := main.ParseMemoryQuotaValues(testData.memoryMax, testData.memoryHigh, testData.memoryLow, testData.memorySwapMax)
+		testLabel := check.Commentf("%v", testData)
+		if testData.err == "" {
+			c.Check(err, check.IsNil, testLabel)
+			var jsonQuota bytes.Buffer
+			err := json.NewEncoder(&jsonQuota).Encode(quotas)
+			c.Assert(err, check.IsNil, testLabel)
+			c.Check(strings.TrimSpace(jsonQuota.String()), check.Equals, testData.quotas, testLabel)
+		} else {
+			c.Check(err, check.ErrorMatches, testData.err, testLabel)
+		}
+	}
+}
+
 func (s *quotaSuite) TestSetQuotaInvalidArgs(c *check.C) {
 	const json = `{
 		"type": "sync",
@@ -517,6 +591,71 @@ current:
 	c.Check(s.quotaGetGroupHandlerCalls, check.Equals, 1)
 }
 
+func (s *quotaSuite) TestIOQuotaGroupSimple(c *check.C) {
+	const jsonTemplate = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"group-name": "foo",
+			"constraints": {"io":{"read-bandwidth":10000000,"write-bandwidth":5000000,"weight":50},"network":{"accounting":true}},
+			"current": {"network":{"ingress":2000,"egress":1000}}
+		}
+	}`
+
+	s.RedirectClientToTestServer(s.makeFakeGetQuotaGroupHandler(c, jsonTemplate))
+
+	outputTemplate := `
+name:  foo
+constraints:
+  io-read-bandwidth:   10.0MB/s
+  io-write-bandwidth:  5.00MB/s
+  io-weight:           50
+  network-accounting:  true
+current:
+  network-received:  2000B
+  network-sent:      1000B
+`[1:]
+
+	rest, err := main.Parser(main.Client()).ParseArgs([]string{"quota", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, outputTemplate)
+	c.Check(s.quotaGetGroupHandlerCalls, check.Equals, 1)
+}
+
+func (s *quotaSuite) TestMemorySoftLimitsQuotaGroupSimple(c *check.C) {
+	const jsonTemplate = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"group-name": "foo",
+			"constraints": {"memory":1000000000,"memory-high":512000000,"memory-low":128000000,"memory-swap-max":256000000},
+			"current": {"memory":200000000}
+		}
+	}`
+
+	s.RedirectClientToTestServer(s.makeFakeGetQuotaGroupHandler(c, jsonTemplate))
+
+	outputTemplate := `
+name:  foo
+constraints:
+  memory:           1.00GB
+  memory-high:      512MB
+  memory-low:       128MB
+  memory-swap-max:  256MB
+current:
+  memory:  200MB
+`[1:]
+
+	rest, err := main.Parser(main.Client()).ParseArgs([]string{"quota", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.HasLen, 0)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(s.Stdout(), check.Equals, outputTemplate)
+	c.Check(s.quotaGetGroupHandlerCalls, check.Equals, 1)
+}
+
 func (s *quotaSuite) TestSetQuotaGroupCreateNew(c *check.C) {
 	const postJSON = `{"type": "async", "status-code": 202,"change":"42", "result": []}`
 	fakeHandlerOpts := fakeQuotaGroupPostHandlerOpts{
@@ -108,6 +108,11 @@ requests from 'snap refresh target-snap'.
 When snaps are specified --hold is effective on both their auto-refreshes
 and general refresh requests from 'snap refresh'. However, specific snap
 requests from 'snap refresh target-snap' remain unblocked and will proceed.
+
+Dry-run (--dry-run) reports what refreshing the snaps would do, such as the
+new revisions, the download and disk space needed, base, plug and slot
+changes, services that are restarted and the gate-auto-refresh hooks that
+are run, without refreshing anything.
 `)
 
 var longTryHelp = i18n.G(`
@@ -976,6 +981,7 @@ type cmdRefresh struct {
 	Transaction      client.TransactionType `long:"transaction" default:"per-snap" choice:"all-snaps" choice:"per-snap"`
 	Hold             string                 `long:"hold" optional:"yes" optional-value:"forever"`
 	Unhold           bool                   `long:"unhold"`
+	DryRun           bool                   `long:"dry-run"`
 	Positional       struct {
 		Snaps []installedSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
@@ -1096,6 +1102,9 @@ func (x *cmdRefresh) showRefreshTimes() error {
 	} else {
 		fmt.Fprintf(Stdout, "next: n/a\n")
 	}
+	if sysinfo.Refresh.Blackout != "" {
+		fmt.Fprintf(Stdout, "blackout: %s\n", sysinfo.Refresh.Blackout)
+	}
 	return nil
 }
 
@@ -1126,6 +1135,57 @@ func (x *cmdRefresh) listRefresh() error {
 	return nil
 }
 
+func (x *cmdRefresh) refreshDryRun(names []string) error {
+	report, err := x.client.RefreshDryRun(names)
+	if err != nil {
+		return err
+	}
+	if len(report) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("All snaps up to date."))
+		return nil
+	}
+
+	w := tabWriter()
+	defer w.Flush()
+
+	list := func(key string, values []string) {
+		if len(values) > 0 {
+			fmt.Fprintf(w, "  %s:\t%s\n", key, strings.Join(values, ", "))
+		}
+	}
+	for _, impact := range report {
+		fmt.Fprintf(w, "%s:\n", impact.Name)
+		if impact.CurrentRevision.Unset() {
+			fmt.Fprintf(w, "  revision:\t%s\n", impact.Revision)
+		} else {
+			fmt.Fprintf(w, "  revision:\t%s -> %s\n", impact.CurrentRevision, impact.Revision)
+		}
+		if impact.Version != "" {
+			fmt.Fprintf(w, "  version:\t%s\n", impact.Version)
+		}
+		if impact.Channel != "" {
+			fmt.Fprintf(w, "  channel:\t%s\n", impact.Channel)
+		}
+		fmt.Fprintf(w, "  download-size:\t%s\n", strutil.SizeToStr(impact.DownloadSize))
+		fmt.Fprintf(w, "  disk-space:\t%s\n", strutil.SizeToStr(int64(impact.DiskSpace)))
+		switch {
+		case impact.PreviousBase != "":
+			fmt.Fprintf(w, "  base:\t%s -> %s\n", impact.PreviousBase, impact.Base)
+		case impact.Base != "":
+			fmt.Fprintf(w, "  base:\t%s\n", impact.Base)
+		}
+		list("prerequisites", impact.Prerequisites)
+		list("new-plugs", impact.NewPlugs)
+		list("new-slots", impact.NewSlots)
+		list("removed-plugs", impact.RemovedPlugs)
+		list("removed-slots", impact.RemovedSlots)
+		list("disconnected", impact.Disconnected)
+		list("restart-services", impact.RestartServices)
+		list("gate-auto-refresh-hooks", impact.GateAutoRefreshHooks)
+	}
+	return nil
+}
+
 func (x *cmdRefresh) Execute([]string) error {
 	if err := x.setChannelFromCommandline(); err != nil {
 		return err
@@ -1141,6 +1201,15 @@ func (x *cmdRefresh) Execute([]string) error {
 		return x.showRefreshTimes()
 	}
 
+	if x.DryRun {
+		if x.List || x.Tracking || x.Hold != "" || x.Unhold || x.Amend || x.Revision != "" ||
+			x.Cohort != "" || x.LeaveCohort || x.IgnoreValidation || x.IgnoreRunning ||
+			x.Transaction != client.TransactionPerSnap || x.asksForMode() || x.asksForChannel() {
+			return errors.New(i18n.G("cannot use --dry-run with other flags"))
+		}
+		return x.refreshDryRun(installedSnapNames(x.Positional.Snaps))
+	}
+
 	if x.List {
 		if len(x.Positional.Snaps) > 0 || x.asksForMode() || x.asksForChannel() {
 			return errors.New(i18n.G("--list does not accept additional arguments"))
@@ -1664,6 +1733,8 @@ func init() {
 			"hold": i18n.G("Hold refreshes for a specified duration (or forever, if no value is specified)"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"unhold": i18n.G("Remove refresh hold"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"dry-run": i18n.G("Show what refreshing would do without refreshing anything"),
 		}), nil)
 	addCommand("try", shortTryHelp, longTryHelp, func() flags.Commander { return &cmdTry{} }, waitDescs.also(modeDescs), nil)
 	addCommand("enable", shortEnableHelp, longEnableHelp, func() flags.Commander { return &cmdEnable{} }, waitDescs, nil)
@@ -1849,6 +1849,76 @@ foo +4.2update1 +17 +436MB +bar +-.*
 	c.Check(n, check.Equals, 1)
 }
 
+func (s *SnapSuite) TestRefreshDryRun(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+			c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]any{
+				"action":  "refresh",
+				"snaps":   []any{"foo", "bar"},
+				"dry-run": true,
+			})
+			fmt.Fprintln(w, `{"type": "sync", "result": [
+{"name": "bar", "current-revision": "3", "revision": "4", "version": "1.1", "download-size": 1000, "disk-space": 5243880},
+{"name": "foo", "current-revision": "1", "revision": "2", "version": "2.0", "channel": "latest/stable", "download-size": 436375552, "disk-space": 441618432, "base": "core24", "previous-base": "core22", "prerequisites": ["core24"], "new-plugs": ["network", "home"], "removed-slots": ["content"], "disconnected": ["baz:content foo:content"], "restart-services": ["svc"], "gate-auto-refresh-hooks": ["baz"]}
+]}`)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--dry-run", "foo", "bar"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `bar:
+  revision:       3 -> 4
+  version:        1.1
+  download-size:  1kB
+  disk-space:     5MB
+foo:
+  revision:                 1 -> 2
+  version:                  2.0
+  channel:                  latest/stable
+  download-size:            436MB
+  disk-space:               441MB
+  base:                     core22 -> core24
+  prerequisites:            core24
+  new-plugs:                network, home
+  removed-slots:            content
+  disconnected:             baz:content foo:content
+  restart-services:         svc
+  gate-auto-refresh-hooks:  baz
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(n, check.Equals, 1)
+}
+
+func (s *SnapSuite) TestRefreshDryRunNoUpdates(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--dry-run"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.Stderr(), check.Equals, "All snaps up to date.\n")
+}
+
+func (s *SnapSuite) TestRefreshDryRunOtherFlags(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatal("expected to get 0 requests")
+	})
+
+	for _, flag := range []string{"--list", "--beta", "--revision=2", "--hold", "--ignore-validation"} {
+		_, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--dry-run", flag, "foo"})
+		c.Check(err, check.ErrorMatches, "cannot use --dry-run with other flags", check.Commentf(flag))
+	}
+}
+
 func mockTrackingResponse(w io.Writer, snaps map[string]string) {
 	type snapResult struct {
 		Name    string `json:"name"`
@@ -2081,6 +2151,32 @@ next: 2017-04-26T00:58:00+02:00
 	c.Check(n, check.Equals, 1)
 }
 
+func (s *SnapSuite) TestRefreshTimeShowsBlackout(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/system-info")
+			fmt.Fprintln(w, `{"type": "sync", "status-code": 200, "result": {"refresh": {"timer": "0:00-24:00/4", "last": "2017-04-25T17:35:00+02:00", "next": "2017-04-26T00:58:00+02:00", "blackout": "2017-12-20..2018-01-06"}}}`)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--time", "--abs-time"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `timer: 0:00-24:00/4
+last: 2017-04-25T17:35:00+02:00
+next: 2017-04-26T00:58:00+02:00
+blackout: 2017-12-20..2018-01-06
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(n, check.Equals, 1)
+}
+
 func (s *SnapSuite) TestRefreshTimeShowsHolds(c *check.C) {
 	type testcase struct {
 		in  string
@@ -20,6 +20,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -28,7 +29,9 @@ import (
 
 	"github.com/jessevdk/go-flags"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/strutil/quantity"
 )
@@ -62,6 +65,16 @@ for which users, or a combination of these.
 If a snap is included in a save operation, excluding its system and
 configuration data from the snapshot is not currently possible. This
 restriction may be lifted in the future.
+
+The data of the named snaps can be limited to some paths with --include,
+and some paths can be left out with --exclude. Paths start with one of
+$SNAP_DATA, $SNAP_COMMON, $SNAP_USER_DATA or $SNAP_USER_COMMON, and '*'
+matches any sequence of characters other than '/'.
+
+The snapshot can be encrypted to one or more age recipients with
+--encrypt-to, or to a passphrase, which is then asked for, with
+--passphrase. An encrypted snapshot can only be checked or restored
+with one of the identities or the passphrase it was encrypted to.
 `)
 var longForgetHelp = i18n.G(`
 The forget command deletes a snapshot. This operation can not be
@@ -87,6 +100,10 @@ for which users, or a combination of these.
 If a snap is included in a check-snapshot operation, excluding its
 system and configuration data from the check is not currently
 possible. This restriction may be lifted in the future.
+
+Encrypted snapshots are decrypted with the identities given with
+--identity, or with the passphrase asked for with --passphrase, in
+addition to the identities kept on the device.
 `)
 var longRestoreHelp = i18n.G(`
 The restore command replaces the current user, system and
@@ -100,6 +117,16 @@ for which users, or a combination of these.
 If a snap is included in a restore operation, excluding its system and
 configuration data from the restore is not currently possible. This
 restriction may be lifted in the future.
+
+The restore can be limited to some paths with --include, and some paths
+can be left out with --exclude, using the same syntax as the save
+command. The matching files are then restored on top of the current
+data, which is otherwise left alone, and the configuration of the snaps
+is not restored.
+
+Encrypted snapshots are decrypted with the identities given with
+--identity, or with the passphrase asked for with --passphrase, in
+addition to the identities kept on the device.
 `)
 
 var longExportSnapshotHelp = i18n.G(`
@@ -160,6 +187,9 @@ func (x *savedCmd) Execute([]string) error {
 			if sh.Auto {
 				notes = append(notes, "auto")
 			}
+			if sh.IsEncrypted() {
+				notes = append(notes, "encrypted")
+			}
 			if sh.Broken != "" {
 				notes = append(notes, "broken: "+sh.Broken)
 			}
@@ -178,7 +208,11 @@ func (x *savedCmd) Execute([]string) error {
 type saveCmd struct {
 	waitMixin
 	durationMixin
-	Users      string `long:"users"`
+	Users      string   `long:"users"`
+	Include    []string `long:"include"`
+	Exclude    []string `long:"exclude"`
+	EncryptTo  []string `long:"encrypt-to"`
+	Passphrase bool     `long:"passphrase"`
 	Positional struct {
 		Snaps []installedSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
@@ -187,7 +221,31 @@ type saveCmd struct {
 func (x *saveCmd) Execute([]string) error {
 	snaps := installedSnapNames(x.Positional.Snaps)
 	users := strutil.CommaSeparatedList(x.Users)
-	setID, changeID, err := x.client.SnapshotMany(snaps, users)
+	var options map[string]*snap.SnapshotOptions
+	if len(x.Include) > 0 || len(x.Exclude) > 0 {
+		if len(snaps) == 0 {
+			return errors.New(i18n.G("cannot use --include or --exclude without naming snaps"))
+		}
+		options = make(map[string]*snap.SnapshotOptions, len(snaps))
+		for _, name := range snaps {
+			options[name] = &snap.SnapshotOptions{Include: x.Include, Exclude: x.Exclude}
+		}
+	}
+	var enc *client.SnapshotEncryption
+	if len(x.EncryptTo) > 0 || x.Passphrase {
+		if len(x.EncryptTo) > 0 && x.Passphrase {
+			return errors.New(i18n.G("cannot use --encrypt-to and --passphrase together"))
+		}
+		enc = &client.SnapshotEncryption{Recipients: x.EncryptTo}
+		if x.Passphrase {
+			passphrase, err := readSnapshotPassphrase(true)
+			if err != nil {
+				return err
+			}
+			enc.Passphrase = passphrase
+		}
+	}
+	setID, changeID, err := x.client.SnapshotMany(snaps, users, options, enc)
 	if err != nil {
 		return err
 	}
@@ -241,8 +299,110 @@ func (x *forgetCmd) Execute([]string) error {
 	return nil
 }
 
+// snapshotKeysMixin holds the keys to decrypt an encrypted snapshot with.
+type snapshotKeysMixin struct {
+	Identities []flags.Filename `long:"identity"`
+	Passphrase bool             `long:"passphrase"`
+}
+
+var snapshotKeysDescs = mixinDescs{
+	// TRANSLATORS: This should not start with a lowercase letter.
+	"identity": i18n.G("Decrypt the snapshot with the age identities in the given file (can be repeated)"),
+	// TRANSLATORS: This should not start with a lowercase letter.
+	"passphrase": i18n.G("Decrypt the snapshot with a passphrase, asked for interactively"),
+}
+
+func (x *snapshotKeysMixin) encryption() (*client.SnapshotEncryption, error) {
+	if len(x.Identities) == 0 && !x.Passphrase {
+		return nil, nil
+	}
+	enc := &client.SnapshotEncryption{}
+	for _, fn := range x.Identities {
+		data, err := os.ReadFile(string(fn))
+		if err != nil {
+			return nil, fmt.Errorf(i18n.G("cannot read identity file: %v"), err)
+		}
+		enc.Identities = append(enc.Identities, string(data))
+	}
+	if x.Passphrase {
+		passphrase, err := readSnapshotPassphrase(false)
+		if err != nil {
+			return nil, err
+		}
+		enc.Passphrase = passphrase
+	}
+	return enc, nil
+}
+
+// withKeys calls op with the keys given on the command line. If the snapshot
+// turns out to be encrypted to a passphrase that was not given, and snap is
+// run interactively, the passphrase is asked for and op is retried.
+func (x *snapshotKeysMixin) withKeys(op func(*client.SnapshotEncryption) (string, error)) (string, error) {
+	enc, err := x.encryption()
+	if err != nil {
+		return "", err
+	}
+	changeID, err := op(enc)
+	if x.Passphrase || !isStdinTTY || !needsSnapshotPassphrase(err) {
+		return changeID, err
+	}
+	passphrase, err := readSnapshotPassphrase(false)
+	if err != nil {
+		return "", err
+	}
+	if enc == nil {
+		enc = &client.SnapshotEncryption{}
+	}
+	enc.Passphrase = passphrase
+	return op(enc)
+}
+
+// needsSnapshotPassphrase returns whether the error is about a snapshot
+// encrypted to a passphrase.
+func needsSnapshotPassphrase(err error) bool {
+	var cerr *client.Error
+	if !errors.As(err, &cerr) || cerr.Kind != client.ErrorKindSnapshotKeyRequired {
+		return false
+	}
+	value, _ := cerr.Value.(map[string]any)
+	fingerprints, _ := value["fingerprints"].([]any)
+	for _, fp := range fingerprints {
+		if fp == "passphrase" {
+			return true
+		}
+	}
+	return false
+}
+
+func readSnapshotPassphrase(confirm bool) (string, error) {
+	fmt.Fprint(Stdout, i18n.G("Snapshot passphrase: "))
+	passphrase, err := ReadPassword(0)
+	fmt.Fprint(Stdout, "\n")
+	if err != nil {
+		return "", err
+	}
+	// strings.TrimSpace needed because we get \r from the pty in the tests
+	p := strings.TrimSpace(string(passphrase))
+	if p == "" {
+		return "", errors.New(i18n.G("passphrase cannot be empty"))
+	}
+	if confirm {
+		fmt.Fprint(Stdout, i18n.G("Repeat passphrase: "))
+		again, err := ReadPassword(0)
+		fmt.Fprint(Stdout, "\n")
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(string(again)) != p {
+			return "", errors.New(i18n.G("passphrases do not match"))
+		}
+	}
+	return p, nil
+}
+
 type checkSnapshotCmd struct {
 	waitMixin
+	snapshotKeysMixin
 	Users      string `long:"users"`
 	Positional struct {
 		ID    snapshotID          `positional-arg-name:"<id>"`
@@ -257,7 +417,9 @@ func (x *checkSnapshotCmd) Execute([]string) error {
 	}
 	snaps := installedSnapNames(x.Positional.Snaps)
 	users := strutil.CommaSeparatedList(x.Users)
-	changeID, err := x.client.CheckSnapshots(setID, snaps, users)
+	changeID, err := x.withKeys(func(enc *client.SnapshotEncryption) (string, error) {
+		return x.client.CheckSnapshots(setID, snaps, users, enc)
+	})
 	if err != nil {
 		return err
 	}
@@ -282,7 +444,10 @@ func (x *checkSnapshotCmd) Execute([]string) error {
 
 type restoreCmd struct {
 	waitMixin
-	Users      string `long:"users"`
+	snapshotKeysMixin
+	Users      string   `long:"users"`
+	Include    []string `long:"include"`
+	Exclude    []string `long:"exclude"`
 	Positional struct {
 		ID    snapshotID          `positional-arg-name:"<id>"`
 		Snaps []installedSnapName `positional-arg-name:"<snap>"`
@@ -296,7 +461,13 @@ func (x *restoreCmd) Execute([]string) error {
 	}
 	snaps := installedSnapNames(x.Positional.Snaps)
 	users := strutil.CommaSeparatedList(x.Users)
-	changeID, err := x.client.RestoreSnapshots(setID, snaps, users)
+	var options *snap.SnapshotOptions
+	if len(x.Include) > 0 || len(x.Exclude) > 0 {
+		options = &snap.SnapshotOptions{Include: x.Include, Exclude: x.Exclude}
+	}
+	changeID, err := x.withKeys(func(enc *client.SnapshotEncryption) (string, error) {
+		return x.client.RestoreSnapshots(setID, snaps, users, options, enc)
+	})
 	if err != nil {
 		return err
 	}
@@ -340,6 +511,14 @@ func init() {
 		}, durationDescs.also(waitDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"users": i18n.G("Snapshot data of only specific users (comma-separated) (default: all users)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"include": i18n.G("Snapshot only the data matching the given path (can be repeated)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"exclude": i18n.G("Do not snapshot the data matching the given path (can be repeated)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"encrypt-to": i18n.G("Encrypt the snapshot to the given age recipient (can be repeated)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"passphrase": i18n.G("Encrypt the snapshot to a passphrase, asked for interactively"),
 		}), nil)
 
 	addCommand("restore",
@@ -347,9 +526,13 @@ func init() {
 		longRestoreHelp,
 		func() flags.Commander {
 			return &restoreCmd{}
-		}, waitDescs.also(map[string]string{
+		}, waitDescs.also(snapshotKeysDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"users": i18n.G("Restore data of only specific users (comma-separated) (default: all users)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"include": i18n.G("Restore only the data matching the given path (can be repeated)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"exclude": i18n.G("Do not restore the data matching the given path (can be repeated)"),
 		}), []argDesc{
 			{
 				name: "<id>",
@@ -384,7 +567,7 @@ func init() {
 		longCheckHelp,
 		func() flags.Commander {
 			return &checkSnapshotCmd{}
-		}, waitDescs.also(map[string]string{
+		}, waitDescs.also(snapshotKeysDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"users": i18n.G("Check data of only specific users (comma-separated) (default: all users)"),
 		}), []argDesc{
@@ -20,6 +20,7 @@
 package main_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
@@ -167,3 +168,134 @@ Set  Snap  Age    Version  Rev   Size    Notes
 1    htop  %-6s 2        1168      1B  -
 `, ageStr))
 }
+
+func (s *SnapSuite) TestSnapshotSaveEncrypted(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snaps":
+			n++
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]any{
+				"action":              "snapshot",
+				"snapshot-encryption": map[string]any{"recipients": []any{"age1foo", "age1bar"}},
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "9", "result": {"set-id": 42}}`)
+		default:
+			c.Errorf("unexpected path %q", r.URL.Path)
+		}
+	})
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"save", "--no-wait", "--encrypt-to=age1foo", "--encrypt-to=age1bar"})
+	c.Assert(err, IsNil)
+	c.Check(n, Equals, 1)
+
+	_, err = main.Parser(main.Client()).ParseArgs([]string{"save", "--encrypt-to=age1foo", "--passphrase"})
+	c.Check(err, ErrorMatches, "cannot use --encrypt-to and --passphrase together")
+}
+
+func (s *SnapSuite) TestSnapshotRestoreAsksForPassphrase(c *C) {
+	defer main.MockIsStdinTTY(true)()
+	s.password = "secret"
+
+	var encs []any
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snapshots":
+			body := DecodedRequestBody(c, r)
+			encs = append(encs, body["encryption"])
+			if body["encryption"] == nil {
+				w.WriteHeader(400)
+				fmt.Fprintln(w, `{"type": "error", "status-code": 400, "result": {"message": "snapshot set #1 is encrypted, and none of the keys it is encrypted to is available", "kind": "snapshot-key-required", "value": {"set-id": 1, "fingerprints": ["passphrase"]}}}`)
+				return
+			}
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "9"}`)
+		case "/v2/changes/9":
+			fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done", "data": {}}}`)
+		default:
+			c.Errorf("unexpected path %q", r.URL.Path)
+		}
+	})
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"restore", "1"})
+	c.Assert(err, IsNil)
+	c.Check(encs, DeepEquals, []any{nil, map[string]any{"passphrase": "secret"}})
+	c.Check(s.Stdout(), Equals, "Snapshot passphrase: \nRestored snapshot #1.\n")
+}
+
+func (s *SnapSuite) TestSnapshotCheckWithIdentity(c *C) {
+	idFile := filepath.Join(c.MkDir(), "key.txt")
+	c.Assert(os.WriteFile(idFile, []byte("AGE-SECRET-KEY-1…\n"), 0600), IsNil)
+
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snapshots":
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]any{
+				"set":        json.Number("4"),
+				"action":     "check",
+				"encryption": map[string]any{"identities": []any{"AGE-SECRET-KEY-1…\n"}},
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "9"}`)
+		default:
+			c.Errorf("unexpected path %q", r.URL.Path)
+		}
+	})
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"check-snapshot", "--no-wait", "--identity", idFile, "4"})
+	c.Assert(err, IsNil)
+}
+
+func (s *SnapSuite) TestSnapshotSavePaths(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snaps":
+			n++
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]any{
+				"action": "snapshot",
+				"snaps":  []any{"foo", "bar"},
+				"snapshot-options": map[string]any{
+					"foo": map[string]any{"include": []any{"$SNAP_USER_DATA/.config"}, "exclude": []any{"$SNAP_USER_DATA/.config/cache"}},
+					"bar": map[string]any{"include": []any{"$SNAP_USER_DATA/.config"}, "exclude": []any{"$SNAP_USER_DATA/.config/cache"}},
+				},
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "9", "result": {"set-id": 42}}`)
+		default:
+			c.Errorf("unexpected path %q", r.URL.Path)
+		}
+	})
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"save", "--no-wait", "--include=$SNAP_USER_DATA/.config", "--exclude=$SNAP_USER_DATA/.config/cache", "foo", "bar"})
+	c.Assert(err, IsNil)
+	c.Check(n, Equals, 1)
+
+	_, err = main.Parser(main.Client()).ParseArgs([]string{"save", "--include=$SNAP_USER_DATA/.config"})
+	c.Check(err, ErrorMatches, "cannot use --include or --exclude without naming snaps")
+}
+
+func (s *SnapSuite) TestSnapshotRestorePaths(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snapshots":
+			n++
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]any{
+				"set":     json.Number("1"),
+				"action":  "restore",
+				"users":   []any{"me"},
+				"options": map[string]any{"include": []any{"$SNAP_USER_DATA/.config/app.conf"}},
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "9"}`)
+		default:
+			c.Errorf("unexpected path %q", r.URL.Path)
+		}
+	})
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"restore", "--no-wait", "--users=me", "--include=$SNAP_USER_DATA/.config/app.conf", "1"})
+	c.Assert(err, IsNil)
+	c.Check(n, Equals, 1)
+}
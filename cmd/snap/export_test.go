@@ -479,6 +479,28 @@ func ParseQuotaValues(maxMemory, cpuMax, cpuSet, threadsMax, journalSizeMax, jou
 	return quotas.parseQuotas()
 }
 
+func ParseIOQuotaValues(readBandwidth, writeBandwidth, weight, networkAccounting string) (*client.QuotaValues, error) {
+	var quotas cmdSetQuota
+
+	quotas.IOReadBandwidth = readBandwidth
+	quotas.IOWriteBandwidth = writeBandwidth
+	quotas.IOWeight = weight
+	quotas.NetworkAccount = networkAccounting
+
+	return quotas.parseQuotas()
+}
+
+func ParseMemoryQuotaValues(memoryMax, memoryHigh, memoryLow, memorySwapMax string) (*client.QuotaValues, error) {
+	var quotas cmdSetQuota
+
+	quotas.MemoryMax = memoryMax
+	quotas.MemoryHigh = memoryHigh
+	quotas.MemoryLow = memoryLow
+	quotas.MemorySwapMax = memorySwapMax
+
+	return quotas.parseQuotas()
+}
+
 func MockSeedWriterReadManifest(f func(manifestFile string) (*seedwriter.Manifest, error)) (restore func()) {
 	restore = testutil.Backup(&seedwriterReadManifest)
 	seedwriterReadManifest = f
@@ -22,6 +22,7 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
@@ -64,6 +65,9 @@ type Notes struct {
 	Health           string
 	Price            string
 	Held             bool
+	// RolloutDue is until when a staged rollout delays the refresh, if
+	// it does.
+	RolloutDue time.Time
 }
 
 func NotesFromChannelSnapInfo(ref *snap.ChannelSnapInfo) *Notes {
@@ -83,6 +87,9 @@ func NotesFromRemote(snp *client.Snap, resInfo *client.ResultInfo) *Notes {
 	if resInfo != nil {
 		notes.Price = getPriceString(snp.Prices, resInfo.SuggestedCurrency, snp.Status)
 	}
+	if snp.RolloutDue != nil && snp.RolloutDue.After(timeNow()) {
+		notes.RolloutDue = *snp.RolloutDue
+	}
 
 	return notes
 }
@@ -172,6 +179,12 @@ func (n *Notes) String() string {
 		ns = append(ns, i18n.G("held"))
 	}
 
+	if !n.RolloutDue.IsZero() {
+		// TRANSLATORS: the auto-refresh to this update is delayed by a
+		// staged rollout; %s is a relative time like "tomorrow at 10:00 UTC"
+		ns = append(ns, fmt.Sprintf(i18n.G("staged until %s"), timeutilHuman(n.RolloutDue)))
+	}
+
 	if len(ns) == 0 {
 		return "-"
 	}
@@ -102,6 +102,19 @@ func (notesSuite) TestNotesHeld(c *check.C) {
 	}).String(), check.Equals, "held")
 }
 
+func (notesSuite) TestNotesRolloutDelayed(c *check.C) {
+	due := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	restore := snap.MockTimeutilHuman(func(t time.Time) string {
+		c.Check(t, check.Equals, due)
+		return "tomorrow at 10:00 UTC"
+	})
+	defer restore()
+
+	c.Check((&snap.Notes{
+		RolloutDue: due,
+	}).String(), check.Equals, "staged until tomorrow at 10:00 UTC")
+}
+
 func (notesSuite) TestNotesNothing(c *check.C) {
 	c.Check((&snap.Notes{}).String(), check.Equals, "-")
 }
@@ -137,3 +150,17 @@ func (notesSuite) TestHeldNoteFromLocal(c *check.C) {
 	c.Check(snap.NotesFromLocal(&client.Snap{Hold: &past}).Held, check.Equals, false)
 	c.Check(snap.NotesFromLocal(&client.Snap{GatingHold: &future}).Held, check.Equals, false)
 }
+
+func (notesSuite) TestRolloutDelayedNoteFromRemote(c *check.C) {
+	now := time.Now()
+	restore := snap.MockTimeNow(func() time.Time {
+		return now
+	})
+	defer restore()
+
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+	c.Check(snap.NotesFromRemote(&client.Snap{RolloutDue: &future}, nil).RolloutDue, check.Equals, future)
+	c.Check(snap.NotesFromRemote(&client.Snap{RolloutDue: &past}, nil).RolloutDue.IsZero(), check.Equals, true)
+	c.Check(snap.NotesFromRemote(&client.Snap{}, nil).RolloutDue.IsZero(), check.Equals, true)
+}
@@ -92,6 +92,7 @@ var api = []*Command{
 	requestsPromptCmd,
 	requestsRulesCmd,
 	requestsRuleCmd,
+	requestsAuditCmd,
 	systemSecurebootCmd,
 	systemVolumesCmd,
 }
@@ -194,6 +195,9 @@ var (
 	snapstateInstallComponentPath           = snapstate.InstallComponentPath
 	snapstateInstallComponents              = snapstate.InstallComponents
 	snapstateRefreshCandidates              = snapstate.RefreshCandidates
+	snapstateRefreshRolloutDue              = snapstate.RefreshRolloutDue
+	snapstateRefreshDryRun                  = snapstate.RefreshDryRun
+	snapstateChangelogs                     = snapstate.Changelogs
 	snapstateTryPath                        = snapstate.TryPath
 	snapstateStoreUpdateGoal                = snapstate.StoreUpdateGoal
 	snapstateUpdateWithGoal                 = snapstate.UpdateWithGoal
@@ -45,7 +45,7 @@ var debugCmd = &Command{
 	Actions: []string{
 		"add-warning", "unshow-warnings", "ensure-state-soon",
 		"can-manage-refreshes", "prune", "stacktraces",
-		"create-recovery-system", "migrate-home",
+		"create-recovery-system", "migrate-home", "connection-policy",
 	},
 	ReadAccess:  openAccess{},
 	WriteAccess: rootAccess{},
@@ -58,6 +58,8 @@ type debugAction struct {
 		ChgID string `json:"chg-id"`
 
 		RecoverySystemLabel string `json:"recovery-system-label"`
+
+		connectionPolicyParams
 	} `json:"params"`
 	Snaps []string `json:"snaps"`
 }
@@ -459,6 +461,8 @@ func postDebug(c *Command, r *http.Request, user *auth.UserState) Response {
 		return createRecovery(st, a.Params.RecoverySystemLabel)
 	case "migrate-home":
 		return migrateHome(st, a.Snaps)
+	case "connection-policy":
+		return explainConnectionPolicy(c, st, &a.Params.connectionPolicyParams)
 	default:
 		return BadRequest("unknown debug action: %v", a.Action)
 	}
@@ -0,0 +1,96 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+type connectionPolicyParams struct {
+	Plug interfaces.PlugRef `json:"plug"`
+	Slot interfaces.SlotRef `json:"slot"`
+	// PlugSnapYaml and SlotSnapYaml, if set, are the snap.yaml of snaps
+	// which are not installed, for instance before they are uploaded.
+	PlugSnapYaml string `json:"plug-snap-yaml"`
+	SlotSnapYaml string `json:"slot-snap-yaml"`
+	// PlugSnapDeclaration and SlotSnapDeclaration, if set, are encoded
+	// snap declarations used instead of the ones in the assertions
+	// database. They are not checked to be signed by a trusted key.
+	PlugSnapDeclaration string `json:"plug-snap-declaration"`
+	SlotSnapDeclaration string `json:"slot-snap-declaration"`
+}
+
+func connectionPolicySide(kind, snapName, name, snapYaml, encodedDecl string) (*ifacestate.ConnectionPolicySide, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%s name is required", kind)
+	}
+	side := &ifacestate.ConnectionPolicySide{
+		Snap: snapName,
+		Name: name,
+	}
+	if encodedDecl != "" {
+		a, err := asserts.Decode([]byte(encodedDecl))
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode %s snap declaration: %v", kind, err)
+		}
+		decl, ok := a.(*asserts.SnapDeclaration)
+		if !ok {
+			return nil, fmt.Errorf("cannot use %q assertion as %s snap declaration", a.Type().Name, kind)
+		}
+		side.SnapDeclaration = decl
+	}
+	if snapYaml != "" {
+		info, err := snap.InfoFromSnapYaml([]byte(snapYaml))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s snap.yaml: %v", kind, err)
+		}
+		if side.SnapDeclaration != nil {
+			info.SnapID = side.SnapDeclaration.SnapID()
+		}
+		if side.Snap == "" {
+			side.Snap = info.InstanceName()
+		}
+		side.SnapInfo = info
+	}
+	return side, nil
+}
+
+func explainConnectionPolicy(c *Command, st *state.State, params *connectionPolicyParams) Response {
+	plugSide, err := connectionPolicySide("plug", params.Plug.Snap, params.Plug.Name, params.PlugSnapYaml, params.PlugSnapDeclaration)
+	if err != nil {
+		return BadRequest("cannot explain connection policy: %v", err)
+	}
+	slotSide, err := connectionPolicySide("slot", params.Slot.Snap, params.Slot.Name, params.SlotSnapYaml, params.SlotSnapDeclaration)
+	if err != nil {
+		return BadRequest("cannot explain connection policy: %v", err)
+	}
+
+	explanation, err := c.d.overlord.InterfaceManager().ExplainConnectionPolicy(plugSide, slotSide)
+	if err != nil {
+		return BadRequest("cannot explain connection policy: %v", err)
+	}
+	return SyncResponse(explanation)
+}
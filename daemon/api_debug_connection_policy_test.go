@@ -0,0 +1,179 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/interfaces/policy"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+)
+
+var _ = check.Suite(&connectionPolicySuite{})
+
+type connectionPolicySuite struct {
+	apiBaseSuite
+}
+
+func (s *connectionPolicySuite) SetUpTest(c *check.C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectRootAccess()
+	s.AddCleanup(builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"}))
+}
+
+func (s *connectionPolicySuite) explain(c *check.C, params map[string]any) *ifacestate.ConnectionPolicyExplanation {
+	rsp := s.syncReq(c, s.request(c, params), nil, actionIsExpected)
+	c.Assert(rsp.Result, check.FitsTypeOf, &ifacestate.ConnectionPolicyExplanation{})
+	return rsp.Result.(*ifacestate.ConnectionPolicyExplanation)
+}
+
+func (s *connectionPolicySuite) request(c *check.C, params map[string]any) *http.Request {
+	body, err := json.Marshal(map[string]any{
+		"action": "connection-policy",
+		"params": params,
+	})
+	c.Assert(err, check.IsNil)
+	req, err := http.NewRequest("POST", "/v2/debug", bytes.NewReader(body))
+	c.Assert(err, check.IsNil)
+	return req
+}
+
+func (s *connectionPolicySuite) TestConnectionPolicyInstalledSnaps(c *check.C) {
+	s.daemon(c)
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	explanation := s.explain(c, map[string]any{
+		"plug": map[string]any{"snap": "consumer", "plug": "plug"},
+		"slot": map[string]any{"snap": "producer", "slot": "slot"},
+	})
+	c.Check(explanation.Interface, check.Equals, "test")
+	c.Check(explanation.Plug, check.Equals, interfaces.PlugRef{Snap: "consumer", Name: "plug"})
+	c.Check(explanation.Slot, check.Equals, interfaces.SlotRef{Snap: "producer", Name: "slot"})
+	c.Check(explanation.Connection.Kind, check.Equals, "connection")
+	c.Check(explanation.Connection.Allowed, check.Equals, true)
+	c.Check(explanation.AutoConnection.Kind, check.Equals, "auto-connection")
+	c.Check(explanation.AutoConnection.Allowed, check.Equals, true)
+}
+
+func (s *connectionPolicySuite) TestConnectionPolicyLocalSnap(c *check.C) {
+	s.daemon(c)
+	s.mockSnap(c, producerYaml)
+
+	headers := map[string]any{
+		"format":       "1",
+		"series":       "16",
+		"snap-name":    "consumer",
+		"snap-id":      "consumeridididididididididididid",
+		"publisher-id": "can0nical",
+		"timestamp":    time.Now().Format(time.RFC3339),
+		"plugs": map[string]any{
+			"test": map[string]any{
+				"deny-auto-connection": "true",
+			},
+		},
+	}
+	decl, err := s.StoreSigning.Sign(asserts.SnapDeclarationType, headers, nil, "")
+	c.Assert(err, check.IsNil)
+
+	// the plug side is not installed
+	explanation := s.explain(c, map[string]any{
+		"plug":                  map[string]any{"plug": "plug"},
+		"slot":                  map[string]any{"snap": "producer", "slot": "slot"},
+		"plug-snap-yaml":        consumerYaml,
+		"plug-snap-declaration": string(asserts.Encode(decl)),
+	})
+	c.Check(explanation.Plug, check.Equals, interfaces.PlugRef{Snap: "consumer", Name: "plug"})
+	c.Check(explanation.Connection.Allowed, check.Equals, true)
+	c.Check(explanation.AutoConnection, check.DeepEquals, &policy.Explanation{
+		Kind:    "auto-connection",
+		Allowed: false,
+		Error:   `auto-connection denied by plug rule of interface "test" for "consumer" snap`,
+		Rules: []*policy.RuleExplanation{
+			{Declaration: policy.PlugSnapDeclaration, Side: "plug", Status: policy.RuleStatusEvaluated, Subrules: []*policy.SubruleExplanation{
+				{Name: "deny-auto-connection", Matched: true, Alternatives: []*policy.AlternativeExplanation{
+					{Constraints: "true", Matched: true},
+				}},
+			}},
+		},
+	})
+}
+
+func (s *connectionPolicySuite) TestConnectionPolicyErrors(c *check.C) {
+	s.daemon(c)
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	for _, t := range []struct {
+		params map[string]any
+		err    string
+	}{{
+		params: map[string]any{
+			"slot": map[string]any{"snap": "producer", "slot": "slot"},
+		},
+		err: `cannot explain connection policy: plug name is required`,
+	}, {
+		params: map[string]any{
+			"plug": map[string]any{"snap": "consumer", "plug": "plug"},
+		},
+		err: `cannot explain connection policy: slot name is required`,
+	}, {
+		params: map[string]any{
+			"plug": map[string]any{"snap": "consumer", "plug": "plug"},
+			"slot": map[string]any{"snap": "producer", "slot": "whatslot"},
+		},
+		err: `cannot explain connection policy: snap "producer" has no slot named "whatslot"`,
+	}, {
+		params: map[string]any{
+			"plug":           map[string]any{"snap": "consumer", "plug": "plug"},
+			"slot":           map[string]any{"snap": "producer", "slot": "slot"},
+			"plug-snap-yaml": "name: [",
+		},
+		err: `cannot explain connection policy: cannot read plug snap.yaml: .*`,
+	}, {
+		params: map[string]any{
+			"plug":                  map[string]any{"snap": "consumer", "plug": "plug"},
+			"slot":                  map[string]any{"snap": "producer", "slot": "slot"},
+			"slot-snap-declaration": "garbage",
+		},
+		err: `cannot explain connection policy: cannot decode slot snap declaration: .*`,
+	}, {
+		params: map[string]any{
+			"plug":                  map[string]any{"snap": "consumer", "plug": "plug"},
+			"slot":                  map[string]any{"snap": "producer", "slot": "slot"},
+			"slot-snap-declaration": string(asserts.Encode(s.StoreSigning.StoreAccountKey(""))),
+		},
+		err: `cannot explain connection policy: cannot use "account-key" assertion as slot snap declaration`,
+	}} {
+		rspe := s.errorReq(c, s.request(c, t.params), nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Matches, t.err)
+	}
+}
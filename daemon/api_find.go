@@ -24,6 +24,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -32,6 +33,7 @@ import (
 	"github.com/snapcore/snapd/httputil"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/store"
 )
@@ -157,7 +159,7 @@ func searchStore(c *Command, r *http.Request, user *auth.UserState) Response {
 		SuggestedCurrency: theStore.SuggestedCurrency(),
 	}
 
-	return sendStorePackages(route, found, fresp)
+	return sendStorePackages(route, found, fresp, nil)
 }
 
 func findOne(c *Command, r *http.Request, user *auth.UserState, name string) Response {
@@ -216,16 +218,36 @@ func storeUpdates(c *Command, r *http.Request, user *auth.UserState) Response {
 	}
 
 	state.Lock()
-	updates, err := snapstateRefreshCandidates(state, user)
+	updates, rolloutDue, err := refreshCandidatesWithRolloutDue(state, user)
 	state.Unlock()
 	if err != nil {
 		return InternalError("cannot list updates: %v", err)
 	}
 
-	return sendStorePackages(route, updates, nil)
+	return sendStorePackages(route, updates, nil, rolloutDue)
 }
 
-func sendStorePackages(route *mux.Route, found []*snap.Info, resp *findResponse) StructuredResponse {
+// refreshCandidatesWithRolloutDue returns the refresh candidates, and until
+// when the staged rollout delays the auto-refresh to them, if it does.
+func refreshCandidatesWithRolloutDue(st *state.State, user *auth.UserState) ([]*snap.Info, map[string]time.Time, error) {
+	updates, err := snapstateRefreshCandidates(st, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	rolloutDue := make(map[string]time.Time, len(updates))
+	for _, up := range updates {
+		due, err := snapstateRefreshRolloutDue(st, up.InstanceName(), up.Revision)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !due.IsZero() {
+			rolloutDue[up.InstanceName()] = due
+		}
+	}
+	return updates, rolloutDue, nil
+}
+
+func sendStorePackages(route *mux.Route, found []*snap.Info, resp *findResponse, rolloutDue map[string]time.Time) StructuredResponse {
 	results := make([]*json.RawMessage, 0, len(found))
 	for _, x := range found {
 		url, err := route.URL("name", x.InstanceName())
@@ -234,7 +256,11 @@ func sendStorePackages(route *mux.Route, found []*snap.Info, resp *findResponse)
 			continue
 		}
 
-		data, err := json.Marshal(webify(mapRemote(x), url.String()))
+		result := mapRemote(x)
+		if due, ok := rolloutDue[x.InstanceName()]; ok {
+			result.RolloutDue = &due
+		}
+		data, err := json.Marshal(webify(result, url.String()))
 		if err != nil {
 			return InternalError("%v", err)
 		}
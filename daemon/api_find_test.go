@@ -25,6 +25,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -117,6 +118,46 @@ func (s *findSuite) TestFindRefreshes(c *check.C) {
 	c.Check(fetchedValidationSets, check.Equals, true)
 }
 
+func (s *findSuite) TestFindRefreshesRolloutDue(c *check.C) {
+	s.daemon(c)
+
+	restore := daemon.MockAssertstateFetchAllValidationSets(func(*state.State, int, *assertstate.RefreshAssertionsOptions) error {
+		return nil
+	})
+	defer restore()
+
+	due := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	restore = daemon.MockSnapstateRefreshRolloutDue(func(st *state.State, name string, rev snap.Revision) (time.Time, error) {
+		if name == "delayed" {
+			return due, nil
+		}
+		return time.Time{}, nil
+	})
+	defer restore()
+
+	s.rsnaps = []*snap.Info{{
+		SideInfo:      snap.SideInfo{RealName: "delayed", Revision: snap.R(2)},
+		Architectures: []string{"all"},
+	}, {
+		SideInfo:      snap.SideInfo{RealName: "due", Revision: snap.R(2)},
+		Architectures: []string{"all"},
+	}}
+	s.mockSnap(c, "name: delayed\nversion: 1.0")
+	s.mockSnap(c, "name: due\nversion: 1.0")
+
+	req, err := http.NewRequest("GET", "/v2/find?select=refresh", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	snaps := snapList(rsp.Result)
+	c.Assert(snaps, check.HasLen, 2)
+	c.Check(snaps[0]["name"], check.Equals, "delayed")
+	c.Check(snaps[0]["rollout-due"], check.Equals, "2026-03-01T12:00:00Z")
+	c.Check(snaps[1]["name"], check.Equals, "due")
+	c.Check(snaps[1]["rollout-due"], check.IsNil)
+}
+
 func (s *findSuite) TestFindRefreshSideloaded(c *check.C) {
 	d := s.daemon(c)
 
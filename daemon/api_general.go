@@ -136,6 +136,10 @@ func sysInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 	if err != nil {
 		return InternalError("cannot get refresh schedule: %s", err)
 	}
+	var refreshBlackout string
+	if err := tr.GetMaybe("core", "refresh.blackout", &refreshBlackout); err != nil {
+		return InternalError("cannot get refresh blackout: %s", err)
+	}
 	users, err := auth.Users(st)
 	if err != nil && !errors.Is(err, state.ErrNoState) {
 		return InternalError("cannot get user auth data: %s", err)
@@ -155,6 +159,8 @@ func sysInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 		Last: formatRefreshTime(lastRefresh),
 		Hold: formatRefreshTime(refreshHold),
 		Next: formatRefreshTime(nextRefresh),
+
+		Blackout: refreshBlackout,
 	}
 	if !legacySchedule {
 		refreshInfo.Timer = refreshScheduleStr
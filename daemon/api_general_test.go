@@ -134,6 +134,7 @@ func (s *generalSuite) TestSysInfo(c *check.C) {
 	tr := config.NewTransaction(st)
 	tr.Set("core", "refresh.schedule", "00:00-9:00/12:00-13:00")
 	tr.Set("core", "refresh.timer", "8:00~9:00/2")
+	tr.Set("core", "refresh.blackout", "2026-12-20..2027-01-06")
 	tr.Set("core", "experimental.parallel-instances", "false")
 	tr.Set("core", "experimental.quota-groups", "true")
 	tr.Commit()
@@ -161,8 +162,9 @@ func (s *generalSuite) TestSysInfo(c *check.C) {
 			"snap-bin-dir":   dirs.SnapBinariesDir,
 		},
 		"refresh": map[string]any{
-			// only the "timer" field
-			"timer": "8:00~9:00/2",
+			// only the "timer" and "blackout" fields
+			"timer":    "8:00~9:00/2",
+			"blackout": "2026-12-20..2027-01-06",
 		},
 		"confinement":      "partial",
 		"sandbox-features": map[string]any{"confinement-options": []any{"classic", "devmode"}},
@@ -243,6 +243,8 @@ func (s *noticesSuite) TestNoticesShowsTypesAllowedForSnap(c *C) {
 	addNotice(c, st, nil, state.WarningNotice, "danger", nil)
 	addNotice(c, st, nil, state.SnapRunInhibitNotice, "snap-name", nil)
 	addNotice(c, st, nil, state.InterfacesRequestsPromptNotice, "def", nil)
+	// quota usage is only for admins, no interface grants access to it
+	addNotice(c, st, nil, state.QuotaUsageNotice, "group", nil)
 	st.Unlock()
 
 	// Check that a snap request without specifying types filter only shows
@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/interfaces/prompting"
@@ -74,6 +75,12 @@ var (
 		// authentication.
 		WriteAccess: interfaceOpenAccess{Interfaces: []string{"snap-interfaces-requests-control"}},
 	}
+
+	requestsAuditCmd = &Command{
+		Path:       "/v2/interfaces/requests/audit",
+		GET:        getAudit,
+		ReadAccess: interfaceOpenAccess{Interfaces: []string{"snap-interfaces-requests-control"}},
+	}
 )
 
 // getUserID returns the UID specified by the user-id parameter of the query,
@@ -273,6 +280,9 @@ func promptingError(err error) *apiError {
 		if errors.As(err, &permissionsErr) {
 			apiErr.Value = (*requestedPermissionsNotMatchedError)(permissionsErr)
 		}
+	case errors.Is(err, prompting_errors.ErrRuleAdminManaged):
+		apiErr.Status = 403
+		apiErr.Kind = client.ErrorKindInterfacesRequestsRuleAdminManaged
 	case errors.Is(err, prompting_errors.ErrRuleConflict):
 		apiErr.Status = 409
 		apiErr.Kind = client.ErrorKindInterfacesRequestsRuleConflict
@@ -566,3 +576,51 @@ func postRule(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest(`action must be "add" or "remove"`)
 	}
 }
+
+// getAudit returns the entries of the prompting audit log for the user making
+// the request. Admin users get the entries of all users, unless the user-id
+// parameter is given.
+func getAudit(c *Command, r *http.Request, user *auth.UserState) Response {
+	userID, errorResp := getUserID(r)
+	if errorResp != nil {
+		return errorResp
+	}
+
+	if !getInterfaceManager(c).AppArmorPromptingRunning() {
+		return promptingNotRunningError()
+	}
+
+	query := r.URL.Query()
+	filter := &apparmorprompting.AuditFilter{
+		User:      userID,
+		Snap:      query.Get("snap"),
+		Interface: query.Get("interface"),
+		Source:    apparmorprompting.AuditSource(query.Get("source")),
+	}
+	if filter.Source != "" {
+		if err := apparmorprompting.ValidateAuditSource(filter.Source); err != nil {
+			return BadRequest(`invalid "source" parameter: %v`, err)
+		}
+	}
+	if since := query.Get("since"); since != "" {
+		sinceTime, err := time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			return BadRequest(`invalid "since" parameter: %v`, err)
+		}
+		filter.Since = sinceTime
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return BadRequest(`invalid "limit" parameter: must be a positive integer`)
+		}
+		filter.Limit = n
+	}
+
+	entries, err := getInterfaceManager(c).InterfacesRequestsManager().AuditLog(filter)
+	if err != nil {
+		return InternalError("cannot read prompting audit log: %v", err)
+	}
+
+	return SyncResponse(entries)
+}
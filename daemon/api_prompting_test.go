@@ -49,6 +49,7 @@ type fakeInterfacesRequestsManager struct {
 	prompt       *requestprompts.Prompt
 	rule         *requestrules.Rule
 	satisfiedIDs []prompting.IDType
+	auditEntries []*apparmorprompting.AuditEntry
 	err          error
 
 	// Store most recent received values
@@ -63,6 +64,7 @@ type fakeInterfacesRequestsManager struct {
 	lifespan             prompting.LifespanType
 	duration             string
 	clientActivity       bool
+	auditFilter          *apparmorprompting.AuditFilter
 }
 
 func (m *fakeInterfacesRequestsManager) Prompts(userID uint32, clientActivity bool) ([]*requestprompts.Prompt, error) {
@@ -130,6 +132,11 @@ func (m *fakeInterfacesRequestsManager) RemoveRule(userID uint32, ruleID prompti
 	return m.rule, m.err
 }
 
+func (m *fakeInterfacesRequestsManager) AuditLog(filter *apparmorprompting.AuditFilter) ([]*apparmorprompting.AuditEntry, error) {
+	m.auditFilter = filter
+	return m.auditEntries, m.err
+}
+
 type promptingSuite struct {
 	apiBaseSuite
 
@@ -562,6 +569,18 @@ func (s *promptingSuite) TestPromptingError(c *C) {
 				"type":        "error",
 			},
 		},
+		{
+			err: prompting_errors.ErrRuleAdminManaged,
+			body: map[string]any{
+				"result": map[string]any{
+					"message": "cannot modify or remove rule managed by the system administrator",
+					"kind":    "interfaces-requests-rule-admin-managed",
+				},
+				"status":      "Forbidden",
+				"status-code": 403.0,
+				"type":        "error",
+			},
+		},
 		{
 			err: &prompting_errors.RequestedPathNotMatchedError{
 				Requested: "foo",
@@ -1154,3 +1173,111 @@ func (s *promptingSuite) TestPostRuleRemoveHappy(c *C) {
 	c.Check(ok, Equals, true)
 	c.Check(rule, DeepEquals, s.manager.rule)
 }
+
+func (s *promptingSuite) TestGetAuditHappy(c *C) {
+	s.daemon(c)
+
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, testCase := range []struct {
+		vars     string
+		uid      uint32
+		expected *apparmorprompting.AuditFilter
+	}{
+		{
+			"",
+			1000,
+			&apparmorprompting.AuditFilter{User: 1000},
+		},
+		{
+			"?snap=firefox&interface=home&source=timeout&since=2026-01-02T03:04:05Z&limit=10",
+			1000,
+			&apparmorprompting.AuditFilter{
+				User:      1000,
+				Snap:      "firefox",
+				Interface: "home",
+				Source:    apparmorprompting.AuditSourceTimeout,
+				Since:     since,
+				Limit:     10,
+			},
+		},
+		{
+			// Admins get the entries of all users by default
+			"",
+			0,
+			&apparmorprompting.AuditFilter{},
+		},
+		{
+			"?user-id=1234",
+			0,
+			&apparmorprompting.AuditFilter{User: 1234},
+		},
+	} {
+		s.manager = &fakeInterfacesRequestsManager{}
+		s.manager.auditEntries = []*apparmorprompting.AuditEntry{
+			{
+				Timestamp:   since,
+				User:        1000,
+				Snap:        "firefox",
+				Interface:   "home",
+				Path:        "/home/test/foo",
+				Permissions: []string{"read"},
+				Outcome:     prompting.OutcomeDeny,
+				Source:      apparmorprompting.AuditSourceTimeout,
+				PromptID:    prompting.IDType(0x1234),
+			},
+		}
+
+		rsp := s.makeSyncReq(c, "GET", "/v2/interfaces/requests/audit"+testCase.vars, testCase.uid, nil)
+
+		c.Check(s.manager.auditFilter, DeepEquals, testCase.expected, Commentf("vars: %q", testCase.vars))
+		entries, ok := rsp.Result.([]*apparmorprompting.AuditEntry)
+		c.Check(ok, Equals, true)
+		c.Check(entries, DeepEquals, s.manager.auditEntries)
+	}
+}
+
+func (s *promptingSuite) TestGetAuditErrors(c *C) {
+	s.daemon(c)
+
+	for _, testCase := range []struct {
+		vars   string
+		status int
+		errMsg string
+	}{
+		{
+			"?source=foo",
+			400,
+			`invalid "source" parameter: invalid audit source: "foo"`,
+		},
+		{
+			"?since=yesterday",
+			400,
+			`invalid "since" parameter: .*`,
+		},
+		{
+			"?limit=0",
+			400,
+			`invalid "limit" parameter: must be a positive integer`,
+		},
+		{
+			"?user-id=1234",
+			403,
+			`only admins may use the "user-id" parameter`,
+		},
+	} {
+		req, err := http.NewRequest("GET", "/v2/interfaces/requests/audit"+testCase.vars, nil)
+		c.Assert(err, IsNil)
+		req.RemoteAddr = "pid=100;uid=1000;socket=;"
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, Equals, testCase.status)
+		c.Check(rspe.Message, Matches, testCase.errMsg)
+	}
+
+	s.manager.err = fmt.Errorf("boom")
+	req, err := http.NewRequest("GET", "/v2/interfaces/requests/audit", nil)
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=1000;socket=;"
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, Equals, 500)
+	c.Check(rspe.Message, Equals, "cannot read prompting audit log: boom")
+}
@@ -22,9 +22,11 @@ package daemon
 import (
 	"net/http"
 	"sort"
+	"time"
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/jsonutil"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/servicestate"
 	"github.com/snapcore/snapd/overlord/state"
@@ -63,6 +65,8 @@ var (
 	servicestateCreateQuota = servicestate.CreateQuota
 	servicestateUpdateQuota = servicestate.UpdateQuota
 	servicestateRemoveQuota = servicestate.RemoveQuota
+
+	servicestateQuotaUsageHistory = servicestate.QuotaUsageHistory
 )
 
 var quoteControlChangeKind = swfeats.RegisterChangeKind("quota-control")
@@ -70,7 +74,7 @@ var quoteControlChangeKind = swfeats.RegisterChangeKind("quota-control")
 var getQuotaUsage = func(grp *quota.Group) (*client.QuotaValues, error) {
 	var currentUsage client.QuotaValues
 
-	if grp.MemoryLimit != 0 {
+	if grp.MemoryLimit != 0 || grp.MemoryHigh != 0 {
 		mem, err := grp.CurrentMemoryUsage()
 		if err != nil {
 			return nil, err
@@ -86,12 +90,37 @@ var getQuotaUsage = func(grp *quota.Group) (*client.QuotaValues, error) {
 		currentUsage.Threads = threads
 	}
 
+	// network traffic is only accounted for groups that asked for it, and
+	// accounting needs BPF support, so it is only reported when available
+	if grp.NetworkAccounting {
+		if ingress, egress, err := grp.CurrentNetworkUsage(); err == nil {
+			currentUsage.Network = &client.QuotaNetworkValues{
+				Ingress: ingress,
+				Egress:  egress,
+			}
+		} else {
+			logger.Debugf("cannot get network usage of quota group %q: %v", grp.Name, err)
+		}
+	}
+
 	return &currentUsage, nil
 }
 
 func createQuotaValues(grp *quota.Group) *client.QuotaValues {
 	var constraints client.QuotaValues
 	constraints.Memory = grp.MemoryLimit
+	if grp.MemoryHigh != 0 {
+		high := grp.MemoryHigh
+		constraints.MemoryHigh = &high
+	}
+	if grp.MemoryLow != 0 {
+		low := grp.MemoryLow
+		constraints.MemoryLow = &low
+	}
+	if grp.MemorySwapMax != 0 {
+		swapMax := grp.MemorySwapMax
+		constraints.MemorySwapMax = &swapMax
+	}
 	constraints.Threads = grp.ThreadLimit
 
 	if grp.CPULimit != nil {
@@ -114,6 +143,25 @@ func createQuotaValues(grp *quota.Group) *client.QuotaValues {
 			}
 		}
 	}
+	if grp.IOLimit != nil {
+		constraints.IO = &client.QuotaIOValues{}
+		if grp.IOLimit.ReadBandwidth != 0 {
+			readBandwidth := grp.IOLimit.ReadBandwidth
+			constraints.IO.ReadBandwidth = &readBandwidth
+		}
+		if grp.IOLimit.WriteBandwidth != 0 {
+			writeBandwidth := grp.IOLimit.WriteBandwidth
+			constraints.IO.WriteBandwidth = &writeBandwidth
+		}
+		if grp.IOLimit.Weight != 0 {
+			weight := grp.IOLimit.Weight
+			constraints.IO.Weight = &weight
+		}
+	}
+	if grp.NetworkAccounting {
+		accounting := true
+		constraints.Network = &client.QuotaNetworkValues{Accounting: &accounting}
+	}
 	return &constraints
 }
 
@@ -166,6 +214,15 @@ func getQuotaGroupInfo(c *Command, r *http.Request, _ *auth.UserState) Response
 		return BadRequest(err.Error())
 	}
 
+	var history time.Duration
+	if s := r.URL.Query().Get("history"); s != "" {
+		var err error
+		history, err = time.ParseDuration(s)
+		if err != nil || history <= 0 {
+			return BadRequest("invalid history duration %q", s)
+		}
+	}
+
 	st := c.d.overlord.State()
 	st.Lock()
 	defer st.Unlock()
@@ -192,6 +249,15 @@ func getQuotaGroupInfo(c *Command, r *http.Request, _ *auth.UserState) Response
 		Constraints: createQuotaValues(group),
 		Current:     currentUsage,
 	}
+	if history > 0 {
+		for _, sample := range servicestateQuotaUsageHistory(st, groupName, time.Now().Add(-history)) {
+			res.History = append(res.History, client.QuotaUsageSample{
+				Time:    sample.Time,
+				Memory:  sample.Memory,
+				Threads: sample.Threads,
+			})
+		}
+	}
 	return SyncResponse(res)
 }
 
@@ -200,6 +266,17 @@ func quotaValuesToResources(values client.QuotaValues) quota.Resources {
 	if values.Memory != 0 {
 		resourcesBuilder.WithMemoryLimit(values.Memory)
 	}
+	// as for io limits, an explicit value of 0 removes the memory high or
+	// swap limit or protection
+	if values.MemoryHigh != nil {
+		resourcesBuilder.WithMemoryHigh(*values.MemoryHigh)
+	}
+	if values.MemoryLow != nil {
+		resourcesBuilder.WithMemoryLow(*values.MemoryLow)
+	}
+	if values.MemorySwapMax != nil {
+		resourcesBuilder.WithMemorySwapMax(*values.MemorySwapMax)
+	}
 	if values.CPU != nil {
 		if values.CPU.Count != 0 {
 			resourcesBuilder.WithCPUCount(values.CPU.Count)
@@ -223,6 +300,21 @@ func quotaValuesToResources(values client.QuotaValues) quota.Resources {
 			resourcesBuilder.WithJournalRate(values.Journal.RateCount, values.Journal.RatePeriod)
 		}
 	}
+	// an explicit value of 0 removes the io limit
+	if values.IO != nil {
+		if values.IO.ReadBandwidth != nil {
+			resourcesBuilder.WithIOReadBandwidth(*values.IO.ReadBandwidth)
+		}
+		if values.IO.WriteBandwidth != nil {
+			resourcesBuilder.WithIOWriteBandwidth(*values.IO.WriteBandwidth)
+		}
+		if values.IO.Weight != nil {
+			resourcesBuilder.WithIOWeight(*values.IO.Weight)
+		}
+	}
+	if values.Network != nil && values.Network.Accounting != nil {
+		resourcesBuilder.WithNetworkAccounting(*values.Network.Accounting)
+	}
 	return resourcesBuilder.Build()
 }
 
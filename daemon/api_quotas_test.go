@@ -90,6 +90,9 @@ func (s *apiQuotaSuite) TestCreateQuotaValues(c *check.C) {
 			WithCPUSet([]int{0, 1}).
 			WithJournalRate(150, time.Second).
 			WithJournalSize(quantity.SizeMiB).
+			WithIOReadBandwidth(10*quantity.SizeMiB).
+			WithIOWeight(50).
+			WithNetworkAccounting(true).
 			Build())
 	allGroups, err2 := servicestate.AllQuotas(st)
 	st.Unlock()
@@ -118,6 +121,16 @@ func (s *apiQuotaSuite) TestCreateQuotaValues(c *check.C) {
 			RatePeriod: time.Second,
 		},
 	})
+	readBandwidth := 10 * quantity.SizeMiB
+	weight := 50
+	c.Check(quotaValues.IO, check.DeepEquals, &client.QuotaIOValues{
+		ReadBandwidth: &readBandwidth,
+		Weight:        &weight,
+	})
+	accounting := true
+	c.Check(quotaValues.Network, check.DeepEquals, &client.QuotaNetworkValues{
+		Accounting: &accounting,
+	})
 }
 
 func (s *apiQuotaSuite) TestPostQuotaUnknownAction(c *check.C) {
@@ -488,6 +501,155 @@ func (s *apiQuotaSuite) TestPostEnsureQuotaUpdateMemoryHappy(c *check.C) {
 	c.Assert(s.ensureSoonCalled, check.Equals, 1)
 }
 
+func (s *apiQuotaSuite) TestPostEnsureQuotaUpdateIOHappy(c *check.C) {
+	st := s.d.Overlord().State()
+	st.Lock()
+	err := servicestatetest.MockQuotaInState(st, "ginger-ale", "", nil, nil,
+		quota.NewResourcesBuilder().
+			WithMemoryLimit(quantity.SizeMiB).
+			Build())
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+
+	updateCalled := 0
+	r := daemon.MockServicestateUpdateQuota(func(st *state.State, name string, opts servicestate.UpdateQuotaOptions) (*state.TaskSet, error) {
+		updateCalled++
+		c.Assert(name, check.Equals, "ginger-ale")
+		c.Assert(opts, check.DeepEquals, servicestate.UpdateQuotaOptions{
+			NewResourceLimits: quota.NewResourcesBuilder().
+				WithIOReadBandwidth(20 * quantity.SizeMiB).
+				WithIOWriteBandwidth(0).
+				WithIOWeight(200).
+				WithNetworkAccounting(true).
+				Build(),
+		})
+		ts := state.NewTaskSet(st.NewTask("foo-quota", "..."))
+		return ts, nil
+	})
+	defer r()
+
+	readBandwidth := 20 * quantity.SizeMiB
+	// an explicit 0 removes the write bandwidth limit
+	writeBandwidth := quantity.Size(0)
+	weight := 200
+	accounting := true
+	data, err := json.Marshal(daemon.PostQuotaGroupData{
+		Action:    "ensure",
+		GroupName: "ginger-ale",
+		Constraints: client.QuotaValues{
+			IO: &client.QuotaIOValues{
+				ReadBandwidth:  &readBandwidth,
+				WriteBandwidth: &writeBandwidth,
+				Weight:         &weight,
+			},
+			Network: &client.QuotaNetworkValues{
+				Accounting: &accounting,
+			},
+		},
+	})
+	c.Assert(err, check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/quotas", bytes.NewBuffer(data))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, check.Equals, 202)
+	c.Assert(updateCalled, check.Equals, 1)
+}
+
+func (s *apiQuotaSuite) TestPostEnsureQuotaUpdateMemorySoftLimitsHappy(c *check.C) {
+	st := s.d.Overlord().State()
+	st.Lock()
+	err := servicestatetest.MockQuotaInState(st, "ginger-ale", "", nil, nil,
+		quota.NewResourcesBuilder().
+			WithMemoryLimit(quantity.SizeGiB).
+			Build())
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+
+	updateCalled := 0
+	r := daemon.MockServicestateUpdateQuota(func(st *state.State, name string, opts servicestate.UpdateQuotaOptions) (*state.TaskSet, error) {
+		updateCalled++
+		c.Assert(name, check.Equals, "ginger-ale")
+		c.Assert(opts, check.DeepEquals, servicestate.UpdateQuotaOptions{
+			NewResourceLimits: quota.NewResourcesBuilder().
+				WithMemoryHigh(512 * quantity.SizeMiB).
+				WithMemoryLow(128 * quantity.SizeMiB).
+				WithMemorySwapMax(256 * quantity.SizeMiB).
+				Build(),
+		})
+		ts := state.NewTaskSet(st.NewTask("foo-quota", "..."))
+		return ts, nil
+	})
+	defer r()
+
+	high, low := 512*quantity.SizeMiB, 128*quantity.SizeMiB
+	swapMax := 256 * quantity.SizeMiB
+	data, err := json.Marshal(daemon.PostQuotaGroupData{
+		Action:    "ensure",
+		GroupName: "ginger-ale",
+		Constraints: client.QuotaValues{
+			MemoryHigh:    &high,
+			MemoryLow:     &low,
+			MemorySwapMax: &swapMax,
+		},
+	})
+	c.Assert(err, check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/quotas", bytes.NewBuffer(data))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, check.Equals, 202)
+	c.Assert(updateCalled, check.Equals, 1)
+}
+
+func (s *apiQuotaSuite) TestPostEnsureQuotaRemoveMemorySoftLimits(c *check.C) {
+	st := s.d.Overlord().State()
+	st.Lock()
+	err := servicestatetest.MockQuotaInState(st, "ginger-ale", "", nil, nil,
+		quota.NewResourcesBuilder().
+			WithMemoryLimit(quantity.SizeGiB).
+			WithMemoryHigh(512*quantity.SizeMiB).
+			WithMemoryLow(128*quantity.SizeMiB).
+			WithMemorySwapMax(256*quantity.SizeMiB).
+			Build())
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+
+	updateCalled := 0
+	r := daemon.MockServicestateUpdateQuota(func(st *state.State, name string, opts servicestate.UpdateQuotaOptions) (*state.TaskSet, error) {
+		updateCalled++
+		// an explicit value of 0 removes the limits
+		c.Assert(opts, check.DeepEquals, servicestate.UpdateQuotaOptions{
+			NewResourceLimits: quota.NewResourcesBuilder().
+				WithMemoryHigh(0).
+				WithMemoryLow(0).
+				WithMemorySwapMax(0).
+				Build(),
+		})
+		ts := state.NewTaskSet(st.NewTask("foo-quota", "..."))
+		return ts, nil
+	})
+	defer r()
+
+	var none quantity.Size
+	data, err := json.Marshal(daemon.PostQuotaGroupData{
+		Action:    "ensure",
+		GroupName: "ginger-ale",
+		Constraints: client.QuotaValues{
+			MemoryHigh:    &none,
+			MemoryLow:     &none,
+			MemorySwapMax: &none,
+		},
+	})
+	c.Assert(err, check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/quotas", bytes.NewBuffer(data))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, check.Equals, 202)
+	c.Assert(updateCalled, check.Equals, 1)
+}
+
 func (s *apiQuotaSuite) TestPostEnsureQuotaUpdateConflicts(c *check.C) {
 	st := s.d.Overlord().State()
 	st.Lock()
@@ -846,6 +1008,49 @@ func (s *apiQuotaSuite) TestGetQuota(c *check.C) {
 	c.Check(s.ensureSoonCalled, check.Equals, 0)
 }
 
+func (s *apiQuotaSuite) TestGetQuotaWithHistory(c *check.C) {
+	st := s.d.Overlord().State()
+	st.Lock()
+	mockQuotas(st, c)
+	st.Unlock()
+
+	r := daemon.MockGetQuotaUsage(func(grp *quota.Group) (*client.QuotaValues, error) {
+		return &client.QuotaValues{Memory: quantity.Size(500)}, nil
+	})
+	defer r()
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var since time.Time
+	r = daemon.MockServicestateQuotaUsageHistory(func(_ *state.State, name string, t time.Time) []servicestate.QuotaUsageSample {
+		c.Check(name, check.Equals, "bar")
+		since = t
+		return []servicestate.QuotaUsageSample{
+			{Time: t0, Memory: 400},
+			{Time: t0.Add(time.Minute), Memory: 500},
+		}
+	})
+	defer r()
+
+	req, err := http.NewRequest("GET", "/v2/quotas/bar?history=1h", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Assert(rsp.Status, check.Equals, 200)
+	res := rsp.Result.(client.QuotaGroupResult)
+	c.Check(res.History, check.DeepEquals, []client.QuotaUsageSample{
+		{Time: t0, Memory: 400},
+		{Time: t0.Add(time.Minute), Memory: 500},
+	})
+	c.Check(time.Since(since) >= time.Hour, check.Equals, true)
+	c.Check(time.Since(since) < time.Hour+time.Minute, check.Equals, true)
+
+	for _, history := range []string{"foo", "-1h", "0"} {
+		req, err := http.NewRequest("GET", "/v2/quotas/bar?history="+history, nil)
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, fmt.Sprintf("invalid history duration %q", history))
+	}
+}
+
 func (s *apiQuotaSuite) TestGetQuotaInvalidName(c *check.C) {
 	st := s.d.Overlord().State()
 	st.Lock()
@@ -107,6 +107,14 @@ func getSnapInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 	name := vars["name"]
 
 	st := c.d.overlord.State()
+	switch sel := r.URL.Query().Get("select"); sel {
+	case "":
+	case "changelog":
+		return snapChangelogs(st, name)
+	default:
+		return BadRequest("invalid select parameter: %q", sel)
+	}
+
 	about, err := localSnapInfo(st, name)
 	if err != nil {
 		if err == errNoSnap {
@@ -133,6 +141,30 @@ func getSnapInfo(c *Command, r *http.Request, user *auth.UserState) Response {
 	return SyncResponse(result)
 }
 
+func snapChangelogs(st *state.State, name string) Response {
+	st.Lock()
+	defer st.Unlock()
+
+	changelogs, err := snapstateChangelogs(st, name)
+	if err != nil {
+		if _, ok := err.(*snap.NotInstalledError); ok {
+			return SnapNotFound(name, err)
+		}
+		return InternalError("cannot get changelog of %q snap: %v", name, err)
+	}
+
+	result := make([]*client.SnapChangelog, 0, len(changelogs))
+	for _, cl := range changelogs {
+		result = append(result, &client.SnapChangelog{
+			Revision:  cl.Revision,
+			Version:   cl.Version,
+			Changelog: cl.Changelog,
+			Candidate: cl.Candidate,
+		})
+	}
+	return SyncResponse(result)
+}
+
 func webify(result *client.Snap, resource string) *client.Snap {
 	if result.Icon == "" || strings.HasPrefix(result.Icon, "http") {
 		return result
@@ -210,6 +242,10 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("%s", err)
 	}
 
+	if inst.DryRun {
+		return snapRefreshDryRun(r.Context(), &inst, st)
+	}
+
 	impl := inst.dispatch()
 	if impl == nil {
 		return BadRequest("unknown action %s", inst.Action)
@@ -298,10 +334,12 @@ type snapInstruction struct {
 	Snaps                  []string                         `json:"snaps"`
 	Users                  []string                         `json:"users"`
 	SnapshotOptions        map[string]*snap.SnapshotOptions `json:"snapshot-options"`
+	SnapshotEncryption     *client.SnapshotEncryption       `json:"snapshot-encryption"`
 	ValidationSets         []string                         `json:"validation-sets"`
 	QuotaGroupName         string                           `json:"quota-group"`
 	Time                   string                           `json:"time"`
 	HoldLevel              string                           `json:"hold-level"`
+	DryRun                 bool                             `json:"dry-run"`
 
 	// The fields below should not be unmarshalled into. Do not export them.
 	userID int
@@ -479,6 +517,10 @@ func (inst *snapInstruction) validate() error {
 		return err
 	}
 
+	if inst.SnapshotEncryption != nil && inst.Action != snapshotCmdAction {
+		return fmt.Errorf("snapshot-encryption can only be specified for snapshot action")
+	}
+
 	if inst.Action == snapshotCmdAction {
 		inst.cleanSnapshotOptions()
 	}
@@ -491,6 +533,15 @@ func (inst *snapInstruction) validate() error {
 		}
 	}
 
+	if inst.DryRun {
+		if inst.Action != refreshCmdAction {
+			return fmt.Errorf(`dry-run can only be specified for the "refresh" action`)
+		}
+		if inst.Channel != "" || !inst.Revision.Unset() || inst.CohortKey != "" || inst.LeaveCohort || len(inst.ValidationSets) > 0 || len(inst.CompsRaw) > 0 {
+			return fmt.Errorf("dry-run cannot be combined with other refresh options")
+		}
+	}
+
 	return inst.snapRevisionOptions.validate()
 }
 
@@ -881,6 +932,10 @@ func snapOpMany(c *Command, r *http.Request, user *auth.UserState) Response {
 		inst.userID = user.ID
 	}
 
+	if inst.DryRun {
+		return snapRefreshDryRun(r.Context(), &inst, st)
+	}
+
 	op := inst.dispatchForMany()
 	if op == nil {
 		return BadRequest("unsupported multi-snap operation %q", inst.Action)
@@ -920,6 +975,41 @@ func snapOpMany(c *Command, r *http.Request, user *auth.UserState) Response {
 	return AsyncResponse(res.Result, chg.ID())
 }
 
+// snapRefreshDryRun reports what refreshing the snaps of the instruction would
+// do, without creating a change.
+func snapRefreshDryRun(ctx context.Context, inst *snapInstruction, st *state.State) Response {
+	report, err := snapstateRefreshDryRun(ctx, st, inst.Snaps, snapstate.Options{
+		UserID: inst.userID,
+	})
+	if err != nil {
+		return inst.errToResponse(err)
+	}
+
+	impacts := make([]*client.RefreshImpact, 0, len(report))
+	for _, impact := range report {
+		impacts = append(impacts, &client.RefreshImpact{
+			Name:                 impact.InstanceName,
+			CurrentRevision:      impact.CurrentRevision,
+			Revision:             impact.Revision,
+			Version:              impact.Version,
+			Channel:              impact.Channel,
+			DownloadSize:         impact.DownloadSize,
+			DiskSpace:            impact.DiskSpace,
+			Base:                 impact.Base,
+			PreviousBase:         impact.PreviousBase,
+			Prerequisites:        impact.Prerequisites,
+			NewPlugs:             impact.NewPlugs,
+			NewSlots:             impact.NewSlots,
+			RemovedPlugs:         impact.RemovedPlugs,
+			RemovedSlots:         impact.RemovedSlots,
+			Disconnected:         impact.Disconnected,
+			RestartServices:      impact.RestartServices,
+			GateAutoRefreshHooks: impact.GateAutoRefreshHooks,
+		})
+	}
+	return SyncResponse(impacts)
+}
+
 type snapManyActionFunc func(context.Context, *snapInstruction, *state.State) (*snapInstructionResult, error)
 
 func (inst *snapInstruction) dispatchForMany() (op snapManyActionFunc) {
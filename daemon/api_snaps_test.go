@@ -45,6 +45,7 @@ import (
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/healthstate"
+	"github.com/snapcore/snapd/overlord/snapshotstate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/snapstate/sequence"
 	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
@@ -632,7 +633,7 @@ func (s *snapsSuite) TestPostSnapsRemoveManyWithPurge(c *check.C) {
 func (s *snapsSuite) TestPostSnapsOptionsClean(c *check.C) {
 	var snapshotSaveCalled int
 	defer daemon.MockSnapshotSave(func(s *state.State, snaps, users []string,
-		options map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error) {
+		options map[string]*snap.SnapshotOptions, enc *snapshotstate.Encryption) (uint64, []string, *state.TaskSet, error) {
 		snapshotSaveCalled++
 
 		c.Check(snaps, check.HasLen, 3)
@@ -886,6 +887,97 @@ func (s *snapsSuite) TestRefreshMany(c *check.C) {
 	c.Check(refreshAssertionsOpts.IsRefreshOfAllSnaps, check.Equals, false)
 }
 
+func (s *snapsSuite) TestPostSnapsRefreshDryRun(c *check.C) {
+	var calledNames []string
+	defer daemon.MockSnapstateRefreshDryRun(func(_ context.Context, st *state.State, names []string, opts snapstate.Options) ([]*snapstate.RefreshImpact, error) {
+		calledNames = names
+		return []*snapstate.RefreshImpact{{
+			InstanceName:         "foo",
+			CurrentRevision:      snap.R(1),
+			Revision:             snap.R(2),
+			Version:              "2.0",
+			DownloadSize:         1000,
+			DiskSpace:            6000,
+			Base:                 "core24",
+			PreviousBase:         "core22",
+			NewPlugs:             []string{"network"},
+			RestartServices:      []string{"svc"},
+			GateAutoRefreshHooks: []string{"bar"},
+		}}, nil
+	})()
+	defer daemon.MockSnapstateUpdateWithGoal(func(context.Context, *state.State, snapstate.UpdateGoal, func(*snap.Info, *snapstate.SnapState) bool, snapstate.Options) ([]string, *snapstate.UpdateTaskSets, error) {
+		c.Fatal("unexpected refresh")
+		return nil, nil, nil
+	})()
+
+	d := s.daemonWithOverlordMockAndStore()
+
+	buf := strings.NewReader(`{"action": "refresh", "snaps": ["foo"], "dry-run": true}`)
+	req, err := http.NewRequest("POST", "/v2/snaps", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(calledNames, check.DeepEquals, []string{"foo"})
+	c.Check(rsp.Result, check.DeepEquals, []*client.RefreshImpact{{
+		Name:                 "foo",
+		CurrentRevision:      snap.R(1),
+		Revision:             snap.R(2),
+		Version:              "2.0",
+		DownloadSize:         1000,
+		DiskSpace:            6000,
+		Base:                 "core24",
+		PreviousBase:         "core22",
+		NewPlugs:             []string{"network"},
+		RestartServices:      []string{"svc"},
+		GateAutoRefreshHooks: []string{"bar"},
+	}})
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+}
+
+func (s *snapsSuite) TestPostSnapRefreshDryRun(c *check.C) {
+	var calledNames []string
+	defer daemon.MockSnapstateRefreshDryRun(func(_ context.Context, st *state.State, names []string, opts snapstate.Options) ([]*snapstate.RefreshImpact, error) {
+		calledNames = names
+		return nil, nil
+	})()
+
+	s.daemonWithOverlordMockAndStore()
+
+	buf := strings.NewReader(`{"action": "refresh", "dry-run": true}`)
+	req, err := http.NewRequest("POST", "/v2/snaps/foo", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(calledNames, check.DeepEquals, []string{"foo"})
+	c.Check(rsp.Result, check.DeepEquals, []*client.RefreshImpact{})
+}
+
+func (s *snapsSuite) TestPostSnapsRefreshDryRunInvalid(c *check.C) {
+	s.daemonWithOverlordMockAndStore()
+
+	for _, tc := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action": "install", "snaps": ["foo"], "dry-run": true}`, `dry-run can only be specified for the "refresh" action`},
+		{`{"action": "refresh", "validation-sets": ["foo/bar"], "dry-run": true}`, `dry-run cannot be combined with other refresh options`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/snaps", strings.NewReader(tc.body))
+		c.Assert(err, check.IsNil)
+		req.Header.Set("Content-Type", "application/json")
+
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf(tc.body))
+		c.Check(rspe.Message, check.Equals, tc.err, check.Commentf(tc.body))
+	}
+}
+
 func (s *snapsSuite) TestRefreshManyIgnoreRunning(c *check.C) {
 	defer daemon.MockAssertstateRefreshSnapAssertions(func(s *state.State, userID int, opts *assertstate.RefreshAssertionsOptions) error {
 		return nil
@@ -1335,6 +1427,60 @@ func (s *snapsSuite) TestSnapInfoNoneFound(c *check.C) {
 	c.Check(s.errorReq(c, req, nil, actionIsExpected).Status, check.Equals, 404)
 }
 
+func (s *snapsSuite) TestSnapInfoChangelog(c *check.C) {
+	s.expectSnapsNameReadAccess()
+	s.daemon(c)
+
+	restore := daemon.MockSnapstateChangelogs(func(st *state.State, name string) ([]*snapstate.SnapChangelog, error) {
+		c.Check(name, check.Equals, "foo")
+		return []*snapstate.SnapChangelog{{
+			Revision:  snap.R(1),
+			Version:   "1.0",
+			Changelog: "* first",
+		}, {
+			Revision:  snap.R(2),
+			Version:   "2.0",
+			Changelog: "* second",
+			Candidate: true,
+		}}, nil
+	})
+	defer restore()
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo?select=changelog", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Result, check.DeepEquals, []*client.SnapChangelog{{
+		Revision:  snap.R(1),
+		Version:   "1.0",
+		Changelog: "* first",
+	}, {
+		Revision:  snap.R(2),
+		Version:   "2.0",
+		Changelog: "* second",
+		Candidate: true,
+	}})
+}
+
+func (s *snapsSuite) TestSnapInfoChangelogNotFound(c *check.C) {
+	s.expectSnapsNameReadAccess()
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo?select=changelog", nil)
+	c.Assert(err, check.IsNil)
+	c.Check(s.errorReq(c, req, nil, actionIsExpected).Status, check.Equals, 404)
+}
+
+func (s *snapsSuite) TestSnapInfoInvalidSelect(c *check.C) {
+	s.expectSnapsNameReadAccess()
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo?select=potato", nil)
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `invalid select parameter: "potato"`)
+}
+
 func (s *snapsSuite) TestSnapInfoIgnoresRemoteErrors(c *check.C) {
 	s.expectSnapsNameReadAccess()
 	s.daemon(c)
@@ -1641,6 +1787,39 @@ func (s *snapsSuite) TestSnapInfoReturnsRefreshFailures(c *check.C) {
 	c.Check(snapInfo.RefreshFailures, check.DeepEquals, expectedRefreshFailures)
 }
 
+func (s *snapsSuite) TestSnapInfoReturnsHealthHistory(c *check.C) {
+	s.expectSnapsNameReadAccess()
+	d := s.daemon(c)
+	s.mkInstalledInState(c, d, "foo", "bar", "v0", snap.R(5), true, "")
+
+	t0 := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("health", map[string]healthstate.HealthState{
+		"foo": {Revision: snap.R(5), Timestamp: t0.Add(time.Minute), Status: healthstate.OkayStatus},
+	})
+	st.Set("health-history", map[string][]healthstate.HealthState{
+		"foo": {
+			{Revision: snap.R(5), Timestamp: t0, Status: healthstate.ErrorStatus, Message: "broken", Code: "broken-code"},
+			{Revision: snap.R(5), Timestamp: t0.Add(time.Minute), Status: healthstate.OkayStatus},
+		},
+	})
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/snaps/foo", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil, actionIsExpected)
+
+	c.Assert(rsp.Result, check.FitsTypeOf, &client.Snap{})
+	snapInfo := rsp.Result.(*client.Snap)
+	c.Check(snapInfo.Health, check.DeepEquals, &client.SnapHealth{Revision: snap.R(5), Timestamp: t0.Add(time.Minute), Status: "okay"})
+	c.Check(snapInfo.HealthHistory, check.DeepEquals, []client.SnapHealth{
+		{Revision: snap.R(5), Timestamp: t0, Status: "error", Message: "broken", Code: "broken-code"},
+		{Revision: snap.R(5), Timestamp: t0.Add(time.Minute), Status: "okay"},
+	})
+}
+
 func (s *snapsSuite) TestMapLocalFields(c *check.C) {
 	media := snap.MediaInfos{
 		{
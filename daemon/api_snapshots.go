@@ -34,6 +34,7 @@ import (
 	"github.com/snapcore/snapd/overlord/snapshotstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/overlord/swfeats"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/strutil"
 )
 
@@ -61,6 +62,7 @@ var (
 	snapshotSave    = snapshotstate.Save
 	snapshotExport  = snapshotstate.Export
 	snapshotImport  = snapshotstate.Import
+	snapshotFetch   = snapshotstate.Fetch
 )
 
 var (
@@ -97,6 +99,9 @@ type snapshotAction struct {
 	Action string   `json:"action"`
 	Snaps  []string `json:"snaps,omitempty"`
 	Users  []string `json:"users,omitempty"`
+
+	Options    *snap.SnapshotOptions      `json:"options,omitempty"`
+	Encryption *client.SnapshotEncryption `json:"encryption,omitempty"`
 }
 
 func (action snapshotAction) String() string {
@@ -135,6 +140,15 @@ func changeSnapshots(c *Command, r *http.Request, user *auth.UserState) Response
 		return BadRequest("snapshot operation requires action")
 	}
 
+	if action.Options != nil {
+		if action.Action != "restore" {
+			return BadRequest(`snapshot %q operation cannot specify options`, action.Action)
+		}
+		if err := action.Options.Validate(); err != nil {
+			return BadRequest("invalid snapshot options: %v", err)
+		}
+	}
+
 	var affected []string
 	var ts *state.TaskSet
 	var err error
@@ -143,30 +157,48 @@ func changeSnapshots(c *Command, r *http.Request, user *auth.UserState) Response
 	st.Lock()
 	defer st.Unlock()
 
+	enc := snapshotEncryption(action.Encryption)
+
 	var changeKind string
 	switch action.Action {
 	case "check":
-		affected, ts, err = snapshotCheck(st, action.SetID, action.Snaps, action.Users)
+		affected, ts, err = snapshotCheck(st, action.SetID, action.Snaps, action.Users, enc)
 		changeKind = checkSnapshotChangeKind
 	case "restore":
-		affected, ts, err = snapshotRestore(st, action.SetID, action.Snaps, action.Users)
+		affected, ts, err = snapshotRestore(st, action.SetID, action.Snaps, action.Users, action.Options, enc)
+		if err == client.ErrSnapshotSetNotFound {
+			// the set might only be available from the snapshots target;
+			// do not hold the state lock while downloading it
+			st.Unlock()
+			_, err = snapshotFetch(r.Context(), st, action.SetID)
+			st.Lock()
+			if err == nil {
+				affected, ts, err = snapshotRestore(st, action.SetID, action.Snaps, action.Users, action.Options, enc)
+			}
+		}
 		changeKind = restoreSnapshotChangeKind
 	case "forget":
 		if len(action.Users) != 0 {
 			return BadRequest(`snapshot "forget" operation cannot specify users`)
 		}
+		if action.Encryption != nil {
+			return BadRequest(`snapshot "forget" operation cannot specify encryption`)
+		}
 		affected, ts, err = snapshotForget(st, action.SetID, action.Snaps)
 		changeKind = forgetSnapshotChangeKind
 	default:
 		return BadRequest("unknown snapshot operation %q", action.Action)
 	}
 
-	switch err {
+	switch err := err.(type) {
 	case nil:
 		// woo
-	case client.ErrSnapshotSetNotFound, client.ErrSnapshotSnapsNotFound:
-		return NotFound("%v", err)
+	case *snapshotstate.DecryptionKeyRequiredError:
+		return SnapshotKeyRequired(err)
 	default:
+		if err == client.ErrSnapshotSetNotFound || err == client.ErrSnapshotSnapsNotFound {
+			return NotFound("%v", err)
+		}
 		return InternalError("%v", err)
 	}
 
@@ -230,7 +262,7 @@ func doSnapshotImport(c *Command, r *http.Request, user *auth.UserState) Respons
 }
 
 func snapshotMany(_ context.Context, inst *snapInstruction, st *state.State) (*snapInstructionResult, error) {
-	setID, snapshotted, ts, err := snapshotSave(st, inst.Snaps, inst.Users, inst.SnapshotOptions)
+	setID, snapshotted, ts, err := snapshotSave(st, inst.Snaps, inst.Users, inst.SnapshotOptions, snapshotEncryption(inst.SnapshotEncryption))
 	if err != nil {
 		return nil, err
 	}
@@ -250,3 +282,16 @@ func snapshotMany(_ context.Context, inst *snapInstruction, st *state.State) (*s
 		Result:   map[string]any{"set-id": setID},
 	}, nil
 }
+
+// snapshotEncryption converts the encryption keys of a request into the ones
+// snapshotstate understands.
+func snapshotEncryption(enc *client.SnapshotEncryption) *snapshotstate.Encryption {
+	if enc == nil {
+		return nil
+	}
+	return &snapshotstate.Encryption{
+		Recipients: enc.Recipients,
+		Passphrase: enc.Passphrase,
+		Identities: enc.Identities,
+	}
+}
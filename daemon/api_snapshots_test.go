@@ -53,9 +53,10 @@ func (s *snapshotSuite) SetUpTest(c *check.C) {
 
 func (s *snapshotSuite) TestSnapshotManyOptionsNone(c *check.C) {
 	defer daemon.MockSnapshotSave(func(s *state.State, snaps, users []string,
-		options map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error) {
+		options map[string]*snap.SnapshotOptions, enc *snapshotstate.Encryption) (uint64, []string, *state.TaskSet, error) {
 		c.Check(snaps, check.HasLen, 2)
 		c.Check(options, check.IsNil)
+		c.Check(enc, check.IsNil)
 		t := s.NewTask("fake-snapshot-2", "Snapshot two")
 		return 1, snaps, state.NewTaskSet(t), nil
 	})()
@@ -74,7 +75,7 @@ func (s *snapshotSuite) TestSnapshotManyOptionsNone(c *check.C) {
 func (s *snapshotSuite) TestSnapshotManyOptionsFull(c *check.C) {
 	var snapshotSaveCalled int
 	defer daemon.MockSnapshotSave(func(s *state.State, snaps, users []string,
-		options map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error) {
+		options map[string]*snap.SnapshotOptions, enc *snapshotstate.Encryption) (uint64, []string, *state.TaskSet, error) {
 		snapshotSaveCalled++
 		c.Check(snaps, check.HasLen, 2)
 		c.Check(options, check.HasLen, 2)
@@ -99,9 +100,30 @@ func (s *snapshotSuite) TestSnapshotManyOptionsFull(c *check.C) {
 	c.Check(snapshotSaveCalled, check.Equals, 1)
 }
 
+func (s *snapshotSuite) TestSnapshotManyEncrypted(c *check.C) {
+	var snapshotSaveCalled int
+	defer daemon.MockSnapshotSave(func(s *state.State, snaps, users []string,
+		options map[string]*snap.SnapshotOptions, enc *snapshotstate.Encryption) (uint64, []string, *state.TaskSet, error) {
+		snapshotSaveCalled++
+		c.Check(enc, check.DeepEquals, &snapshotstate.Encryption{Recipients: []string{"age1foo", "age1bar"}})
+		t := s.NewTask("fake-snapshot-2", "Snapshot two")
+		return 1, snaps, state.NewTaskSet(t), nil
+	})()
+
+	inst := daemon.MustUnmarshalSnapInstruction(c, `{"action": "snapshot", "snaps": ["foo"],
+	"snapshot-encryption": {"recipients": ["age1foo", "age1bar"]}}`)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	_, err := inst.DispatchForMany()(context.Background(), inst, st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(snapshotSaveCalled, check.Equals, 1)
+}
+
 func (s *snapshotSuite) TestSnapshotManyError(c *check.C) {
 	defer daemon.MockSnapshotSave(func(s *state.State, snaps, users []string,
-		options map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error) {
+		options map[string]*snap.SnapshotOptions, enc *snapshotstate.Encryption) (uint64, []string, *state.TaskSet, error) {
 		c.Check(snaps, check.HasLen, 2)
 		return 0, nil, nil, &snap.NotInstalledError{Snap: "foo"}
 	})()
@@ -253,11 +275,11 @@ func (s *snapshotSuite) TestChangeSnapshots400(c *check.C) {
 func (s *snapshotSuite) TestChangeSnapshots404(c *check.C) {
 	var done string
 	expectedError := errors.New("bzzt")
-	defer daemon.MockSnapshotCheck(func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error) {
+	defer daemon.MockSnapshotCheck(func(*state.State, uint64, []string, []string, *snapshotstate.Encryption) ([]string, *state.TaskSet, error) {
 		done = "check"
 		return nil, nil, expectedError
 	})()
-	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error) {
+	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string, *snap.SnapshotOptions, *snapshotstate.Encryption) ([]string, *state.TaskSet, error) {
 		done = "restore"
 		return nil, nil, expectedError
 	})()
@@ -284,11 +306,11 @@ func (s *snapshotSuite) TestChangeSnapshots404(c *check.C) {
 func (s *snapshotSuite) TestChangeSnapshots500(c *check.C) {
 	var done string
 	expectedError := errors.New("bzzt")
-	defer daemon.MockSnapshotCheck(func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error) {
+	defer daemon.MockSnapshotCheck(func(*state.State, uint64, []string, []string, *snapshotstate.Encryption) ([]string, *state.TaskSet, error) {
 		done = "check"
 		return nil, nil, expectedError
 	})()
-	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error) {
+	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string, *snap.SnapshotOptions, *snapshotstate.Encryption) ([]string, *state.TaskSet, error) {
 		done = "restore"
 		return nil, nil, expectedError
 	})()
@@ -311,11 +333,11 @@ func (s *snapshotSuite) TestChangeSnapshots500(c *check.C) {
 
 func (s *snapshotSuite) TestChangeSnapshot(c *check.C) {
 	var done string
-	defer daemon.MockSnapshotCheck(func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error) {
+	defer daemon.MockSnapshotCheck(func(*state.State, uint64, []string, []string, *snapshotstate.Encryption) ([]string, *state.TaskSet, error) {
 		done = "check"
 		return []string{"foo"}, state.NewTaskSet(), nil
 	})()
-	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error) {
+	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string, *snap.SnapshotOptions, *snapshotstate.Encryption) ([]string, *state.TaskSet, error) {
 		done = "restore"
 		return []string{"foo"}, state.NewTaskSet(), nil
 	})()
@@ -355,6 +377,128 @@ func (s *snapshotSuite) TestChangeSnapshot(c *check.C) {
 	}
 }
 
+func (s *snapshotSuite) TestChangeSnapshotEncryption(c *check.C) {
+	var encs []*snapshotstate.Encryption
+	defer daemon.MockSnapshotCheck(func(_ *state.State, _ uint64, _, _ []string, enc *snapshotstate.Encryption) ([]string, *state.TaskSet, error) {
+		encs = append(encs, enc)
+		return []string{"foo"}, state.NewTaskSet(), nil
+	})()
+	defer daemon.MockSnapshotRestore(func(_ *state.State, _ uint64, _, _ []string, _ *snap.SnapshotOptions, enc *snapshotstate.Encryption) ([]string, *state.TaskSet, error) {
+		encs = append(encs, enc)
+		return []string{"foo"}, state.NewTaskSet(), nil
+	})()
+
+	for _, action := range []string{"check", "restore"} {
+		body := fmt.Sprintf(`{"set": 42, "action": "%s", "encryption": {"passphrase": "secret", "identities": ["AGE-SECRET-KEY-1…"]}}`, action)
+		req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(body))
+		c.Assert(err, check.IsNil)
+		rsp := s.asyncReq(c, req, nil, actionIsExpected)
+		c.Check(rsp.Status, check.Equals, 202)
+	}
+	c.Check(encs, check.DeepEquals, []*snapshotstate.Encryption{
+		{Passphrase: "secret", Identities: []string{"AGE-SECRET-KEY-1…"}},
+		{Passphrase: "secret", Identities: []string{"AGE-SECRET-KEY-1…"}},
+	})
+
+	req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(`{"set": 42, "action": "forget", "encryption": {"passphrase": "secret"}}`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, `snapshot "forget" operation cannot specify encryption`)
+}
+
+func (s *snapshotSuite) TestChangeSnapshotRestorePaths(c *check.C) {
+	var restoreOptions *snap.SnapshotOptions
+	defer daemon.MockSnapshotRestore(func(_ *state.State, _ uint64, _, _ []string, options *snap.SnapshotOptions, _ *snapshotstate.Encryption) ([]string, *state.TaskSet, error) {
+		restoreOptions = options
+		return []string{"foo"}, state.NewTaskSet(), nil
+	})()
+
+	body := `{"set": 42, "action": "restore", "options": {"include": ["$SNAP_USER_DATA/.config"], "exclude": ["$SNAP_USER_DATA/.config/cache"]}}`
+	req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(body))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Status, check.Equals, 202)
+	c.Check(restoreOptions, check.DeepEquals, &snap.SnapshotOptions{
+		Include: []string{"$SNAP_USER_DATA/.config"},
+		Exclude: []string{"$SNAP_USER_DATA/.config/cache"},
+	})
+
+	for body, expected := range map[string]string{
+		`{"set": 42, "action": "restore", "options": {"include": ["/etc"]}}`:          `invalid snapshot options: snapshot include path must start with one of .*`,
+		`{"set": 42, "action": "check", "options": {"include": ["$SNAP_DATA/foo"]}}`:  `snapshot "check" operation cannot specify options`,
+		`{"set": 42, "action": "forget", "options": {"exclude": ["$SNAP_DATA/foo"]}}`: `snapshot "forget" operation cannot specify options`,
+	} {
+		req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil, actionIsExpected)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Matches, expected)
+	}
+}
+
+func (s *snapshotSuite) TestChangeSnapshotKeyRequired(c *check.C) {
+	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string, *snap.SnapshotOptions, *snapshotstate.Encryption) ([]string, *state.TaskSet, error) {
+		return nil, nil, &snapshotstate.DecryptionKeyRequiredError{SetID: 42, Fingerprints: []string{"passphrase"}}
+	})()
+
+	req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(`{"set": 42, "action": "restore"}`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Kind, check.Equals, client.ErrorKindSnapshotKeyRequired)
+	c.Check(rspe.Message, check.Equals, "snapshot set #42 is encrypted, and none of the keys it is encrypted to is available")
+	c.Check(rspe.Value, check.DeepEquals, map[string]any{
+		"set-id":       uint64(42),
+		"fingerprints": []string{"passphrase"},
+	})
+}
+
+func (s *snapshotSuite) TestRestoreSnapshotFetchesFromTarget(c *check.C) {
+	var calls []string
+	fetched := false
+	defer daemon.MockSnapshotRestore(func(st *state.State, setID uint64, snaps, users []string, options *snap.SnapshotOptions, enc *snapshotstate.Encryption) ([]string, *state.TaskSet, error) {
+		calls = append(calls, "restore")
+		if !fetched {
+			return nil, nil, client.ErrSnapshotSetNotFound
+		}
+		return []string{"foo"}, state.NewTaskSet(), nil
+	})()
+	defer daemon.MockSnapshotFetch(func(ctx context.Context, st *state.State, setID uint64) ([]string, error) {
+		calls = append(calls, "fetch")
+		c.Check(setID, check.Equals, uint64(42))
+		// the state is not locked while fetching
+		st.Lock()
+		st.Unlock()
+		fetched = true
+		return []string{"foo"}, nil
+	})()
+
+	req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(`{"set": 42, "action": "restore"}`))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil, actionIsExpected)
+	c.Check(rsp.Status, check.Equals, 202)
+	c.Check(calls, check.DeepEquals, []string{"restore", "fetch", "restore"})
+}
+
+func (s *snapshotSuite) TestRestoreSnapshotFetchError(c *check.C) {
+	restoreCalls := 0
+	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string, *snap.SnapshotOptions, *snapshotstate.Encryption) ([]string, *state.TaskSet, error) {
+		restoreCalls++
+		return nil, nil, client.ErrSnapshotSetNotFound
+	})()
+	defer daemon.MockSnapshotFetch(func(context.Context, *state.State, uint64) ([]string, error) {
+		return nil, errors.New("cannot download snapshot set 42: checksum mismatch")
+	})()
+
+	req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(`{"set": 42, "action": "restore"}`))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil, actionIsExpected)
+	c.Check(rspe.Status, check.Equals, 500)
+	c.Check(rspe.Message, check.Equals, "cannot download snapshot set 42: checksum mismatch")
+	c.Check(restoreCalls, check.Equals, 1)
+}
+
 func (s *snapshotSuite) TestExportSnapshots(c *check.C) {
 	var snapshotExportCalled int
 
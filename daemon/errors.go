@@ -30,6 +30,7 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/overlord/fdestate"
 	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapshotstate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/store"
@@ -268,6 +269,18 @@ func InsufficientSpace(dserr *snapstate.InsufficientSpaceError) *apiError {
 	}
 }
 
+func SnapshotKeyRequired(err *snapshotstate.DecryptionKeyRequiredError) *apiError {
+	return &apiError{
+		Status:  400,
+		Message: err.Error(),
+		Kind:    client.ErrorKindSnapshotKeyRequired,
+		Value: map[string]any{
+			"set-id":       err.SetID,
+			"fingerprints": err.Fingerprints,
+		},
+	}
+}
+
 func KeyslotsNotFound(err *fdestate.KeyslotRefsNotFoundError) *apiError {
 	return &apiError{
 		Status:  400,
@@ -398,6 +411,8 @@ func errToResponse(err error, snaps []string, fallback errorResponder, format st
 			snapName = err.Snap
 		case *snapstate.InsufficientSpaceError:
 			return InsufficientSpace(err)
+		case *snapshotstate.DecryptionKeyRequiredError:
+			return SnapshotKeyRequired(err)
 		case *fdestate.KeyslotRefsNotFoundError:
 			return KeyslotsNotFound(err)
 		case *fdestate.KeyslotsAlreadyExistsError:
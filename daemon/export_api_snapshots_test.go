@@ -32,7 +32,7 @@ import (
 	"github.com/snapcore/snapd/snap"
 )
 
-func MockSnapshotSave(newSave func(*state.State, []string, []string, map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error)) (restore func()) {
+func MockSnapshotSave(newSave func(*state.State, []string, []string, map[string]*snap.SnapshotOptions, *snapshotstate.Encryption) (uint64, []string, *state.TaskSet, error)) (restore func()) {
 	oldSave := snapshotSave
 	snapshotSave = newSave
 	return func() {
@@ -56,7 +56,7 @@ func MockSnapshotExport(newExport func(context.Context, *state.State, uint64) (*
 	}
 }
 
-func MockSnapshotCheck(newCheck func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error)) (restore func()) {
+func MockSnapshotCheck(newCheck func(*state.State, uint64, []string, []string, *snapshotstate.Encryption) ([]string, *state.TaskSet, error)) (restore func()) {
 	oldCheck := snapshotCheck
 	snapshotCheck = newCheck
 	return func() {
@@ -64,7 +64,7 @@ func MockSnapshotCheck(newCheck func(*state.State, uint64, []string, []string) (
 	}
 }
 
-func MockSnapshotRestore(newRestore func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error)) (restore func()) {
+func MockSnapshotRestore(newRestore func(*state.State, uint64, []string, []string, *snap.SnapshotOptions, *snapshotstate.Encryption) ([]string, *state.TaskSet, error)) (restore func()) {
 	oldRestore := snapshotRestore
 	snapshotRestore = newRestore
 	return func() {
@@ -88,6 +88,14 @@ func MockSnapshotImport(newImport func(context.Context, *state.State, io.Reader)
 	}
 }
 
+func MockSnapshotFetch(newFetch func(context.Context, *state.State, uint64) ([]string, error)) (restore func()) {
+	oldFetch := snapshotFetch
+	snapshotFetch = newFetch
+	return func() {
+		snapshotFetch = oldFetch
+	}
+}
+
 func MustUnmarshalSnapInstruction(c *check.C, jinst string) *snapInstruction {
 	var inst snapInstruction
 	if err := json.Unmarshal([]byte(jinst), &inst); err != nil {
@@ -443,6 +443,18 @@ func MockAssertstateFetchAllValidationSets(f func(*state.State, int, *assertstat
 	return testutil.Mock(&assertstateFetchAllValidationSets, f)
 }
 
+func MockSnapstateRefreshDryRun(f func(context.Context, *state.State, []string, snapstate.Options) ([]*snapstate.RefreshImpact, error)) (restore func()) {
+	return testutil.Mock(&snapstateRefreshDryRun, f)
+}
+
+func MockSnapstateChangelogs(f func(*state.State, string) ([]*snapstate.SnapChangelog, error)) (restore func()) {
+	return testutil.Mock(&snapstateChangelogs, f)
+}
+
+func MockSnapstateRefreshRolloutDue(f func(*state.State, string, snap.Revision) (time.Time, error)) (restore func()) {
+	return testutil.Mock(&snapstateRefreshRolloutDue, f)
+}
+
 func MockConfdbstateLoadConfdbAsync(f func(*state.State, *confdb.View, []string, map[string]any) (string, error)) (restore func()) {
 	return testutil.Mock(&confdbstateLoadConfdbAsync, f)
 }
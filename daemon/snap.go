@@ -45,6 +45,7 @@ type aboutSnap struct {
 	info           *snap.Info
 	snapst         *snapstate.SnapState
 	health         *client.SnapHealth
+	healthHistory  []client.SnapHealth
 	refreshInhibit *client.SnapRefreshInhibit
 
 	hold       time.Time
@@ -80,6 +81,14 @@ func localSnapInfo(st *state.State, name string) (aboutSnap, error) {
 	if err != nil {
 		return aboutSnap{}, err
 	}
+	history, err := healthstate.History(st, name)
+	if err != nil {
+		return aboutSnap{}, err
+	}
+	var healthHistory []client.SnapHealth
+	for _, h := range history {
+		healthHistory = append(healthHistory, *clientHealthFromHealthstate(h))
+	}
 
 	userHold, gatingHold, err := getUserAndGatingHolds(st, name)
 	if err != nil {
@@ -92,6 +101,7 @@ func localSnapInfo(st *state.State, name string) (aboutSnap, error) {
 		info:           info,
 		snapst:         &snapst,
 		health:         clientHealthFromHealthstate(health),
+		healthHistory:  healthHistory,
 		refreshInhibit: refreshInhibit,
 		hold:           userHold,
 		gatingHold:     gatingHold,
@@ -272,6 +282,7 @@ func mapLocal(about aboutSnap, sd clientutil.StatusDecorator) *client.Snap {
 		result.MountedFrom, _ = os.Readlink(result.MountedFrom)
 	}
 	result.Health = about.health
+	result.HealthHistory = about.healthHistory
 	result.RefreshInhibit = about.refreshInhibit
 
 	if !about.hold.IsZero() {
@@ -66,8 +66,9 @@ var (
 	SnapBootstrapRunDir  string
 	SnapVoidDir          string
 
-	SnapInterfacesRequestsRunDir   string
-	SnapInterfacesRequestsStateDir string
+	SnapInterfacesRequestsRunDir        string
+	SnapInterfacesRequestsStateDir      string
+	SnapInterfacesRequestsAdminRulesDir string
 
 	SnapdMaintenanceFile string
 
@@ -82,9 +83,10 @@ var (
 	SnapAssertsSpoolDir   string
 	SnapSeqDir            string
 
-	SnapStateFile     string
-	SnapStateLockFile string
-	SnapSystemKeyFile string
+	SnapStateFile        string
+	SnapStateJournalFile string
+	SnapStateLockFile    string
+	SnapSystemKeyFile    string
 
 	SnapRepairConfigFile string
 	SnapRepairDir        string
@@ -387,6 +389,11 @@ func SnapStateFileUnder(rootdir string) string {
 	return filepath.Join(rootdir, snappyDir, "state.json")
 }
 
+// SnapStateJournalFileUnder returns the path to snapd state journal file under rootdir.
+func SnapStateJournalFileUnder(rootdir string) string {
+	return filepath.Join(rootdir, snappyDir, "state.journal")
+}
+
 // SnapStateLockFileUnder returns the path to snapd state lock file under rootdir.
 func SnapStateLockFileUnder(rootdir string) string {
 	return filepath.Join(rootdir, snappyDir, "state.lock")
@@ -604,6 +611,7 @@ func SetRootDir(rootdir string) {
 
 	SnapInterfacesRequestsRunDir = filepath.Join(SnapRunDir, "interfaces-requests")
 	SnapInterfacesRequestsStateDir = filepath.Join(rootdir, snappyDir, "interfaces-requests")
+	SnapInterfacesRequestsAdminRulesDir = filepath.Join(rootdir, "/etc/snapd/prompting-rules.d")
 
 	SnapdStoreSSLCertsDir = filepath.Join(rootdir, snappyDir, "ssl/store-certs")
 
@@ -617,6 +625,7 @@ func SetRootDir(rootdir string) {
 	SnapSeqDir = filepath.Join(rootdir, snappyDir, "sequence")
 
 	SnapStateFile = SnapStateFileUnder(rootdir)
+	SnapStateJournalFile = SnapStateJournalFileUnder(rootdir)
 	SnapStateLockFile = SnapStateLockFileUnder(rootdir)
 	SnapSystemKeyFile = filepath.Join(rootdir, snappyDir, "system-key")
 
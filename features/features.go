@@ -81,6 +81,8 @@ const (
 	Clustering
 	// RemoteDeviceManagement enables experimental remote management of the device through the Store.
 	RemoteDeviceManagement
+	// StateJournal enables incremental persistence of snapd state through a write-ahead journal.
+	StateJournal
 	// lastFeature is the final known feature, it is only used for testing.
 	lastFeature
 )
@@ -133,6 +135,8 @@ var featureNames = map[SnapdFeature]string{
 	Clustering:         "clustering",
 
 	RemoteDeviceManagement: "remote-device-management",
+
+	StateJournal: "state-journal",
 }
 
 // featuresEnabledWhenUnset contains a set of features that are enabled when not explicitly configured.
@@ -156,6 +160,7 @@ var featuresExported = map[SnapdFeature]bool{
 	RefreshAppAwarenessUX: true,
 	Confdb:                true,
 	AppArmorPrompting:     true,
+	StateJournal:          true,
 }
 
 var (
@@ -67,6 +67,7 @@ func (*featureSuite) TestName(c *C) {
 	check(features.ContentCompatLabel, "content-compatibility-label")
 	check(features.Clustering, "clustering")
 	check(features.RemoteDeviceManagement, "remote-device-management")
+	check(features.StateJournal, "state-journal")
 
 	c.Check(tested, Equals, features.NumberOfFeatures())
 	c.Check(func() { _ = features.SnapdFeature(1000).String() }, PanicMatches, "unknown feature flag code 1000")
@@ -110,6 +111,7 @@ func (*featureSuite) TestIsExported(c *C) {
 	check(features.ContentCompatLabel, false)
 	check(features.Clustering, false)
 	check(features.RemoteDeviceManagement, false)
+	check(features.StateJournal, true)
 
 	c.Check(tested, Equals, features.NumberOfFeatures())
 }
@@ -238,6 +240,7 @@ func (*featureSuite) TestIsEnabledWhenUnset(c *C) {
 	check(features.ContentCompatLabel, false)
 	check(features.Clustering, false)
 	check(features.RemoteDeviceManagement, false)
+	check(features.StateJournal, false)
 
 	c.Check(tested, Equals, features.NumberOfFeatures())
 }
@@ -251,6 +254,7 @@ func (*featureSuite) TestControlFile(c *C) {
 	c.Check(features.RefreshAppAwarenessUX.ControlFile(), Equals, "/var/lib/snapd/features/refresh-app-awareness-ux")
 	c.Check(features.Confdb.ControlFile(), Equals, "/var/lib/snapd/features/confdb")
 	c.Check(features.AppArmorPrompting.ControlFile(), Equals, "/var/lib/snapd/features/apparmor-prompting")
+	c.Check(features.StateJournal.ControlFile(), Equals, "/var/lib/snapd/features/state-journal")
 	// Features that are not exported don't have a control file.
 	c.Check(features.Layouts.ControlFile, PanicMatches, `cannot compute the control file of feature "layouts" because that feature is not exported`)
 }
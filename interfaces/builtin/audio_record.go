@@ -20,6 +20,8 @@
 package builtin
 
 import (
+	"strings"
+
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/snap"
@@ -54,6 +56,23 @@ const audioRecordConnectedPlugAppArmor = `
 # interface is connected.
 `
 
+// audioRecordPromptAppArmor gives access to the ALSA capture devices, prompting
+// the user for every access. It is only used if prompting is enabled, as the
+// audio service is otherwise the one mediating audio recording.
+const audioRecordPromptAppArmor = `
+# Capture devices, accesses to which are prompted for
+###PROMPT### /dev/snd/pcmC[0-9]*D[0-9]*c rw,
+`
+
+// DetectAudioRecordFromPath returns true if the given path corresponds to an
+// AppArmor rule with the prompt prefix from the audio-record interface.
+//
+// XXX: this is only necessary until metadata tags are fully supported by the
+// AppArmor parser and kernel. Then, this function should be removed.
+func DetectAudioRecordFromPath(path string) bool {
+	return strings.HasPrefix(path, "/dev/snd/pcmC") && strings.HasSuffix(path, "c")
+}
+
 type audioRecordInterface struct{}
 
 func (iface *audioRecordInterface) Name() string {
@@ -70,6 +89,9 @@ func (iface *audioRecordInterface) StaticInfo() interfaces.StaticInfo {
 
 func (iface *audioRecordInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
 	spec.AddSnippet(audioRecordConnectedPlugAppArmor)
+	if spec.UsePromptPrefix() {
+		spec.AddSnippet(audioRecordPromptAppArmor)
+	}
 	return nil
 }
 
@@ -113,6 +113,37 @@ func (s *AudioRecordInterfaceSuite) TestAppArmor(c *C) {
 	c.Assert(spec.SecurityTags(), HasLen, 0)
 }
 
+func (s *AudioRecordInterfaceSuite) TestAppArmorPrompting(c *C) {
+	// without prompting, access to the capture devices is left to other
+	// interfaces
+	spec := apparmor.NewSpecification(s.plug.AppSet())
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.coreSlot), IsNil)
+	c.Check(spec.SnippetForTag("snap.consumer.app"), Not(testutil.Contains), "/dev/snd/pcmC")
+
+	backend := &apparmor.Backend{}
+	spec = backend.NewSpecification(s.plug.AppSet(), interfaces.ConfinementOptions{AppArmorPrompting: true}).(*apparmor.Specification)
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.coreSlot), IsNil)
+	c.Check(spec.SnippetForTag("snap.consumer.app"), testutil.Contains, "###PROMPT### /dev/snd/pcmC[0-9]*D[0-9]*c rw,\n")
+}
+
+func (s *AudioRecordInterfaceSuite) TestDetectAudioRecordFromPath(c *C) {
+	for _, path := range []string{
+		"/dev/snd/pcmC0D0c",
+		"/dev/snd/pcmC1D12c",
+	} {
+		c.Check(builtin.DetectAudioRecordFromPath(path), Equals, true, Commentf("%q should be detected as audio-record path", path))
+	}
+
+	for _, path := range []string{
+		"/dev/snd/pcmC0D0p",
+		"/dev/snd/controlC0",
+		"/dev/video0",
+		"/home/ubuntu/pcmC0D0c",
+	} {
+		c.Check(builtin.DetectAudioRecordFromPath(path), Equals, false, Commentf("%q should not be detected as audio-record path", path))
+	}
+}
+
 func (s *AudioRecordInterfaceSuite) TestAppArmorOnClassic(c *C) {
 	restore := release.MockOnClassic(false)
 	defer restore()
@@ -21,9 +21,12 @@ package builtin
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/udev"
 	"github.com/snapcore/snapd/snap"
 )
@@ -55,7 +58,9 @@ const blockDevicesBaseDeclarationSlots = `
 // /dev/pd[a-d] rw,                         # Parallel port IDE
 // /dev/pf[0-3] rw,                         # Parallel port ATAPI
 // /dev/ub[a-z] rw,                         # USB block device
-const blockDevicesConnectedPlugAppArmor = `
+const blockDevicesConnectedPlugAppArmor = blockDevicesDetectionConnectedPlugAppArmor + blockDevicesDeviceNodesConnectedPlugAppArmor + blockDevicesToolsConnectedPlugAppArmor
+
+const blockDevicesDetectionConnectedPlugAppArmor = `
 # Description: Allow write access to raw disk block devices.
 
 @{PROC}/devices r,
@@ -66,7 +71,9 @@ const blockDevicesConnectedPlugAppArmor = `
 /sys/devices/platform/soc/**/mmc_host/** r,
 # Allow reading major and minor numbers for block special files of NVMe namespaces.
 /sys/devices/**/nvme/**/dev r,
+`
 
+const blockDevicesDeviceNodesConnectedPlugAppArmor = `
 # Access to raw devices, not individual partitions
 /dev/hd[a-t] rwk,                                          # IDE, MFM, RLL
 /dev/sd{,[a-h]}[a-z] rwk,                                  # SCSI
@@ -98,7 +105,12 @@ const blockDevicesConnectedPlugAppArmor = `
 # access here makes sense, whereas access to individual partitions is delegated
 # to the raw-volume interface.
 /dev/nvme{[0-9],[1-9][0-9]} rwk,                           # NVMe (up to 100 devices)
+`
 
+// blockDevicesToolsConnectedPlugAppArmor holds the rules which are needed to
+// manipulate block devices, whether access is granted to all of them or only
+// to the one of a slot created for a hotplugged disk.
+const blockDevicesToolsConnectedPlugAppArmor = `
 # SCSI device commands, et al
 capability sys_rawio,
 
@@ -163,10 +175,48 @@ func (iface *blockDevicesInterface) BeforePreparePlug(plug *snap.PlugInfo) error
 	return nil
 }
 
+// Pattern to match the raw disk device nodes for which slots are created when
+// they are hotplugged. It must match the device nodes allowed by
+// blockDevicesDeviceNodesConnectedPlugAppArmor.
+var blockDevicesDiskPattern = regexp.MustCompile(`^/dev/(hd[a-t]|sd[a-z]|sd[a-h][a-z]|sdi[a-v]|mmcblk[0-9]{1,3}|vd[a-z]|nvme[0-9]{1,2}n[1-9][0-9]?)$`)
+
+// blockDevicesPartitionSuffix returns the suffix of the partitions of the
+// given disk, to be followed by the partition number.
+func blockDevicesPartitionSuffix(disk string) string {
+	if last := disk[len(disk)-1]; last >= '0' && last <= '9' {
+		return "p"
+	}
+	return ""
+}
+
+// BeforePrepareSlot checks the validity of the path attribute of slots
+// created for hotplugged disks.
+func (iface *blockDevicesInterface) BeforePrepareSlot(slot *snap.SlotInfo) error {
+	if _, ok := slot.Attrs["path"]; !ok {
+		return nil
+	}
+	_, err := verifySlotPathAttribute(&interfaces.SlotRef{Snap: slot.Snap.InstanceName(), Name: slot.Name}, slot, blockDevicesDiskPattern, invalidDeviceNodeSlotPathErrFmt)
+	return err
+}
+
 func (iface *blockDevicesInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
 	var allowPartitions bool
 	_ = plug.Attr("allow-partitions", &allowPartitions)
 
+	if hasPathAttr(slot) {
+		cleanedPath, err := verifySlotPathAttribute(slot.Ref(), slot, blockDevicesDiskPattern, invalidDeviceNodeSlotPathErrFmt)
+		if err != nil {
+			return nil
+		}
+		spec.AddSnippet(blockDevicesDetectionConnectedPlugAppArmor)
+		spec.AddSnippet(fmt.Sprintf("%s rwk,", cleanedPath))
+		if allowPartitions {
+			spec.AddSnippet(fmt.Sprintf("%s%s[1-9]{,[0-9]} rwk,", cleanedPath, blockDevicesPartitionSuffix(cleanedPath)))
+		}
+		spec.AddSnippet(blockDevicesToolsConnectedPlugAppArmor)
+		return nil
+	}
+
 	if err := iface.commonInterface.AppArmorConnectedPlug(spec, plug, slot); err != nil {
 		return err
 	}
@@ -181,6 +231,19 @@ func (iface *blockDevicesInterface) UDevConnectedPlug(spec *udev.Specification,
 	var allowPartitions bool
 	_ = plug.Attr("allow-partitions", &allowPartitions)
 
+	if hasPathAttr(slot) {
+		cleanedPath, err := verifySlotPathAttribute(slot.Ref(), slot, blockDevicesDiskPattern, invalidDeviceNodeSlotPathErrFmt)
+		if err != nil {
+			return nil
+		}
+		disk := strings.TrimPrefix(cleanedPath, "/dev/")
+		spec.TagDevice(fmt.Sprintf(`SUBSYSTEM=="block", KERNEL=="%s"`, disk))
+		if allowPartitions {
+			spec.TagDevice(fmt.Sprintf(`SUBSYSTEM=="block", ENV{DEVTYPE}=="partition", KERNEL=="%s%s[0-9]*"`, disk, blockDevicesPartitionSuffix(disk)))
+		}
+		return nil
+	}
+
 	if err := iface.commonInterface.UDevConnectedPlug(spec, plug, slot); err != nil {
 		return err
 	}
@@ -194,6 +257,27 @@ func (iface *blockDevicesInterface) UDevConnectedPlug(spec *udev.Specification,
 	return nil
 }
 
+// HotplugDeviceDetected proposes a slot for raw disks, which grants access to
+// that disk only, and to its partitions if the plug allows it.
+func (iface *blockDevicesInterface) HotplugDeviceDetected(di *hotplug.HotplugDeviceInfo) (*hotplug.ProposedSlot, error) {
+	if di.Subsystem() != "block" || di.DeviceType() != "disk" || !blockDevicesDiskPattern.MatchString(di.DeviceName()) {
+		return nil, nil
+	}
+	slot := hotplug.ProposedSlot{
+		Attrs: map[string]any{
+			"path": di.DeviceName(),
+		},
+	}
+	addHotplugUSBAttrs(di, slot.Attrs)
+	return &slot, nil
+}
+
+// HotplugKey returns a key based on the serial of the disk, since the model
+// identifier used by the default key is not set for most disks.
+func (iface *blockDevicesInterface) HotplugKey(di *hotplug.HotplugDeviceInfo) (snap.HotplugKey, error) {
+	return hotplugKeyFromAttributes(di, "ID_SERIAL"), nil
+}
+
 type blockDevicesInterface struct {
 	commonInterface
 }
@@ -29,8 +29,10 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/udev"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -177,3 +179,121 @@ func (s *blockDevicesInterfaceSuite) TestAutoConnect(c *C) {
 func (s *blockDevicesInterfaceSuite) TestInterfaces(c *C) {
 	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
 }
+
+const blockDevicesHotplugCoreYaml = `name: core
+version: 0
+type: os
+slots:
+  disk:
+    interface: block-devices
+    path: %s
+`
+
+func (s *blockDevicesInterfaceSuite) TestSanitizeHotplugSlot(c *C) {
+	for _, path := range []string{"/dev/sdb", "/dev/sdab", "/dev/mmcblk0", "/dev/nvme0n1", "/dev/vda"} {
+		info := snaptest.MockInfo(c, fmt.Sprintf(blockDevicesHotplugCoreYaml, path), nil)
+		c.Check(interfaces.BeforePrepareSlot(s.iface, info.Slots["disk"]), IsNil, Commentf("path %q", path))
+	}
+	for _, path := range []string{"/dev/sdb1", "/dev/mmcblk0p1", "/dev/loop0", "/dev/zfs", "/dev/../dev/sda", `""`} {
+		info := snaptest.MockInfo(c, fmt.Sprintf(blockDevicesHotplugCoreYaml, path), nil)
+		c.Check(interfaces.BeforePrepareSlot(s.iface, info.Slots["disk"]), NotNil, Commentf("path %q", path))
+	}
+}
+
+func (s *blockDevicesInterfaceSuite) TestAppArmorSpecHotplugSlot(c *C) {
+	slot, _ := MockConnectedSlot(c, fmt.Sprintf(blockDevicesHotplugCoreYaml, "/dev/sdb"), nil, "disk")
+	appSet, err := interfaces.NewSnapAppSet(s.plug.Snap(), nil)
+	c.Assert(err, IsNil)
+	spec := apparmor.NewSpecification(appSet)
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, slot), IsNil)
+	c.Assert(spec.SecurityTags(), DeepEquals, []string{"snap.consumer.app"})
+	snippet := spec.SnippetForTag("snap.consumer.app")
+	c.Check(snippet, testutil.Contains, "/dev/sdb rwk,")
+	c.Check(snippet, testutil.Contains, "/sys/devices/**/block/** r,")
+	c.Check(snippet, testutil.Contains, "capability sys_rawio,")
+	c.Check(snippet, Not(testutil.Contains), "/dev/sd{,[a-h]}[a-z] rwk,")
+	c.Check(snippet, Not(testutil.Contains), "/dev/sdb[1-9]")
+}
+
+func (s *blockDevicesInterfaceSuite) TestAppArmorSpecHotplugSlotWithPartitions(c *C) {
+	plug, _ := MockConnectedPlug(c, blockDevicesWithPartitionsConsumerYaml, nil, "block-devices")
+	for path, partitions := range map[string]string{
+		"/dev/sdb":     "/dev/sdb[1-9]{,[0-9]} rwk,",
+		"/dev/mmcblk0": "/dev/mmcblk0p[1-9]{,[0-9]} rwk,",
+		"/dev/nvme0n1": "/dev/nvme0n1p[1-9]{,[0-9]} rwk,",
+	} {
+		slot, _ := MockConnectedSlot(c, fmt.Sprintf(blockDevicesHotplugCoreYaml, path), nil, "disk")
+		appSet, err := interfaces.NewSnapAppSet(plug.Snap(), nil)
+		c.Assert(err, IsNil)
+		spec := apparmor.NewSpecification(appSet)
+		c.Assert(spec.AddConnectedPlug(s.iface, plug, slot), IsNil)
+		snippet := spec.SnippetForTag("snap.consumer.app")
+		c.Check(snippet, testutil.Contains, path+" rwk,")
+		c.Check(snippet, testutil.Contains, partitions)
+		c.Check(snippet, Not(testutil.Contains), "/dev/sd[a-z][1-9]{,[0-6]} rwk,")
+	}
+}
+
+func (s *blockDevicesInterfaceSuite) TestUDevSpecHotplugSlot(c *C) {
+	slot, _ := MockConnectedSlot(c, fmt.Sprintf(blockDevicesHotplugCoreYaml, "/dev/sdb"), nil, "disk")
+	appSet, err := interfaces.NewSnapAppSet(s.plug.Snap(), nil)
+	c.Assert(err, IsNil)
+	spec := udev.NewSpecification(appSet)
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, slot), IsNil)
+	c.Assert(spec.Snippets(), HasLen, 2)
+	c.Assert(spec.Snippets(), testutil.Contains, `# block-devices
+SUBSYSTEM=="block", KERNEL=="sdb", TAG+="snap_consumer_app"`)
+}
+
+func (s *blockDevicesInterfaceSuite) TestUDevSpecHotplugSlotWithPartitions(c *C) {
+	plug, _ := MockConnectedPlug(c, blockDevicesWithPartitionsConsumerYaml, nil, "block-devices")
+	slot, _ := MockConnectedSlot(c, fmt.Sprintf(blockDevicesHotplugCoreYaml, "/dev/mmcblk0"), nil, "disk")
+	appSet, err := interfaces.NewSnapAppSet(plug.Snap(), nil)
+	c.Assert(err, IsNil)
+	spec := udev.NewSpecification(appSet)
+	c.Assert(spec.AddConnectedPlug(s.iface, plug, slot), IsNil)
+	c.Assert(spec.Snippets(), HasLen, 3)
+	c.Assert(spec.Snippets(), testutil.Contains, `# block-devices
+SUBSYSTEM=="block", KERNEL=="mmcblk0", TAG+="snap_consumer_app"`)
+	c.Assert(spec.Snippets(), testutil.Contains, `# block-devices
+SUBSYSTEM=="block", ENV{DEVTYPE}=="partition", KERNEL=="mmcblk0p[0-9]*", TAG+="snap_consumer_app"`)
+}
+
+func (s *blockDevicesInterfaceSuite) TestHotplugDeviceDetected(c *C) {
+	hotplugIface := s.iface.(hotplug.Definer)
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/sdb", "DEVTYPE": "disk", "ID_BUS": "usb", "ID_SERIAL": "SanDisk_Ultra_0001", "ID_VENDOR_ID": "0781", "ID_MODEL_ID": "5581", "ACTION": "add", "SUBSYSTEM": "block"})
+	c.Assert(err, IsNil)
+	proposedSlot, err := hotplugIface.HotplugDeviceDetected(di)
+	c.Assert(err, IsNil)
+	c.Assert(proposedSlot, DeepEquals, &hotplug.ProposedSlot{
+		Attrs: map[string]any{"path": "/dev/sdb", "usb-vendor": int64(0x0781), "usb-product": int64(0x5581)},
+	})
+
+	for _, env := range []map[string]string{
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/sdb1", "DEVTYPE": "partition", "ACTION": "add", "SUBSYSTEM": "block"},
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/loop3", "DEVTYPE": "disk", "ACTION": "add", "SUBSYSTEM": "block"},
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/dm-0", "DEVTYPE": "disk", "ACTION": "add", "SUBSYSTEM": "block"},
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/ttyUSB0", "ACTION": "add", "SUBSYSTEM": "tty"},
+	} {
+		di, err := hotplug.NewHotplugDeviceInfo(env)
+		c.Assert(err, IsNil)
+		proposedSlot, err := hotplugIface.HotplugDeviceDetected(di)
+		c.Assert(err, IsNil)
+		c.Check(proposedSlot, IsNil, Commentf("%v", env))
+	}
+}
+
+func (s *blockDevicesInterfaceSuite) TestHotplugKey(c *C) {
+	keyHandler := s.iface.(hotplug.HotplugKeyHandler)
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/sdb", "DEVTYPE": "disk", "ID_SERIAL": "SanDisk_Ultra_0001", "ACTION": "add", "SUBSYSTEM": "block"})
+	c.Assert(err, IsNil)
+	key, err := keyHandler.HotplugKey(di)
+	c.Assert(err, IsNil)
+	c.Check(key, Not(Equals), snap.HotplugKey(""))
+
+	di, err = hotplug.NewHotplugDeviceInfo(map[string]string{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/vda", "DEVTYPE": "disk", "ACTION": "add", "SUBSYSTEM": "block"})
+	c.Assert(err, IsNil)
+	key, err = keyHandler.HotplugKey(di)
+	c.Assert(err, IsNil)
+	c.Check(key, Equals, snap.HotplugKey(""))
+}
@@ -20,7 +20,15 @@
 package builtin
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/hotplug"
+	"github.com/snapcore/snapd/interfaces/udev"
+	"github.com/snapcore/snapd/snap"
 )
 
 const cameraSummary = `allows access to all cameras`
@@ -39,7 +47,11 @@ const cameraConnectedPlugAppArmor = `
 
 # VideoCore cameras (shared device with VideoCore/EGL)
 ###PROMPT### /dev/vchiq rw,
+` + cameraDetectionConnectedPlugAppArmor
 
+// cameraDetectionConnectedPlugAppArmor is also used for slots created for
+// hotplugged cameras, which grant access to a single device.
+const cameraDetectionConnectedPlugAppArmor = `
 # Allow detection of cameras. Leaks plugged in USB device info
 /sys/bus/usb/devices/ r,
 /sys/devices/pci**/usb*/**/busnum r,
@@ -73,8 +85,74 @@ var cameraConnectedPlugUDev = []string{
 	`KERNEL=="vchiq"`,
 }
 
+// Pattern to match the device nodes of hotplugged cameras.
+var cameraDeviceNodePattern = regexp.MustCompile("^/dev/video[0-9]{1,3}$")
+
+// cameraInterface is the type for the camera interface. The implicit slot
+// grants access to all cameras, while slots created for hotplugged cameras
+// have a path attribute and grant access to that camera only.
+type cameraInterface struct {
+	commonInterface
+}
+
+// BeforePrepareSlot checks the validity of the path attribute of slots
+// created for hotplugged cameras.
+func (iface *cameraInterface) BeforePrepareSlot(slot *snap.SlotInfo) error {
+	if _, ok := slot.Attrs["path"]; !ok {
+		return nil
+	}
+	_, err := verifySlotPathAttribute(&interfaces.SlotRef{Snap: slot.Snap.InstanceName(), Name: slot.Name}, slot, cameraDeviceNodePattern, invalidDeviceNodeSlotPathErrFmt)
+	return err
+}
+
+func (iface *cameraInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	if !hasPathAttr(slot) {
+		return iface.commonInterface.AppArmorConnectedPlug(spec, plug, slot)
+	}
+	cleanedPath, err := verifySlotPathAttribute(slot.Ref(), slot, cameraDeviceNodePattern, invalidDeviceNodeSlotPathErrFmt)
+	if err != nil {
+		return nil
+	}
+	spec.AddSnippet(fmt.Sprintf("###PROMPT### %s rwk,", cleanedPath))
+	spec.AddSnippet(cameraDetectionConnectedPlugAppArmor)
+	return nil
+}
+
+func (iface *cameraInterface) UDevConnectedPlug(spec *udev.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	if !hasPathAttr(slot) {
+		return iface.commonInterface.UDevConnectedPlug(spec, plug, slot)
+	}
+	cleanedPath, err := verifySlotPathAttribute(slot.Ref(), slot, cameraDeviceNodePattern, invalidDeviceNodeSlotPathErrFmt)
+	if err != nil {
+		return nil
+	}
+	spec.TagDevice(fmt.Sprintf(`SUBSYSTEM=="video4linux", KERNEL=="%s"`, strings.TrimPrefix(cleanedPath, "/dev/")))
+	return nil
+}
+
+// HotplugDeviceDetected proposes a slot for video capture devices. Cameras
+// often expose further device nodes, such as for metadata, for which no slot
+// is proposed.
+func (iface *cameraInterface) HotplugDeviceDetected(di *hotplug.HotplugDeviceInfo) (*hotplug.ProposedSlot, error) {
+	capabilities, _ := di.Attribute("ID_V4L_CAPABILITIES")
+	if di.Subsystem() != "video4linux" || !cameraDeviceNodePattern.MatchString(di.DeviceName()) || !strings.Contains(capabilities, ":capture:") {
+		return nil, nil
+	}
+	slot := hotplug.ProposedSlot{
+		Label: "allows access to a specific camera",
+		Attrs: map[string]any{
+			"path": di.DeviceName(),
+		},
+	}
+	if product, _ := di.Attribute("ID_V4L_PRODUCT"); product != "" {
+		slot.Label = fmt.Sprintf("allows access to camera %s", product)
+	}
+	addHotplugUSBAttrs(di, slot.Attrs)
+	return &slot, nil
+}
+
 func init() {
-	registerIface(&commonInterface{
+	registerIface(&cameraInterface{commonInterface{
 		name:                  "camera",
 		summary:               cameraSummary,
 		implicitOnCore:        true,
@@ -82,5 +160,5 @@ func init() {
 		baseDeclarationSlots:  cameraBaseDeclarationSlots,
 		connectedPlugAppArmor: cameraConnectedPlugAppArmor,
 		connectedPlugUDev:     cameraConnectedPlugUDev,
-	})
+	}})
 }
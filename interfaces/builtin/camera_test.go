@@ -28,17 +28,21 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/udev"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
 )
 
 type CameraInterfaceSuite struct {
-	iface    interfaces.Interface
-	slot     *interfaces.ConnectedSlot
-	slotInfo *snap.SlotInfo
-	plug     *interfaces.ConnectedPlug
-	plugInfo *snap.PlugInfo
+	iface           interfaces.Interface
+	slot            *interfaces.ConnectedSlot
+	slotInfo        *snap.SlotInfo
+	hotplugSlot     *interfaces.ConnectedSlot
+	hotplugSlotInfo *snap.SlotInfo
+	plug            *interfaces.ConnectedPlug
+	plugInfo        *snap.PlugInfo
 }
 
 var _ = Suite(&CameraInterfaceSuite{
@@ -62,8 +66,20 @@ slots:
 func (s *CameraInterfaceSuite) SetUpTest(c *C) {
 	s.plug, s.plugInfo = MockConnectedPlug(c, cameraConsumerYaml, nil, "camera")
 	s.slot, s.slotInfo = MockConnectedSlot(c, cameraCoreYaml, nil, "camera")
+	s.hotplugSlot, s.hotplugSlotInfo = MockConnectedSlot(c, cameraHotplugCoreYaml, nil, "webcam")
 }
 
+const cameraHotplugCoreYaml = `name: core
+version: 0
+type: os
+slots:
+  webcam:
+    interface: camera
+    path: /dev/video2
+    usb-vendor: 0x046d
+    usb-product: 0x0825
+`
+
 func (s *CameraInterfaceSuite) TestName(c *C) {
 	c.Assert(s.iface.Name(), Equals, "camera")
 }
@@ -133,3 +149,83 @@ func (s *CameraInterfaceSuite) TestDetectCameraFromPath(c *C) {
 		c.Check(builtin.DetectCameraFromPath(path), Equals, false, Commentf("%q should not be detected as camera path"))
 	}
 }
+
+func (s *CameraInterfaceSuite) TestSanitizeHotplugSlot(c *C) {
+	c.Assert(interfaces.BeforePrepareSlot(s.iface, s.hotplugSlotInfo), IsNil)
+
+	for _, path := range []string{"/dev/vchiq", "/dev/video", "/dev/video0000", "/dev/../dev/video0", ""} {
+		const mockSnapYaml = `name: core
+version: 0
+type: os
+slots:
+  webcam:
+    interface: camera
+    path: %q
+`
+		info := snaptest.MockInfo(c, fmt.Sprintf(mockSnapYaml, path), nil)
+		slot := info.Slots["webcam"]
+		c.Check(interfaces.BeforePrepareSlot(s.iface, slot), NotNil, Commentf("path %q", path))
+	}
+}
+
+func (s *CameraInterfaceSuite) TestAppArmorSpecHotplugSlot(c *C) {
+	appSet, err := interfaces.NewSnapAppSet(s.plug.Snap(), nil)
+	c.Assert(err, IsNil)
+	spec := apparmor.NewSpecification(appSet)
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.hotplugSlot), IsNil)
+	c.Assert(spec.SecurityTags(), DeepEquals, []string{"snap.consumer.app"})
+	snippet := spec.SnippetForTag("snap.consumer.app")
+	c.Check(snippet, testutil.Contains, "###PROMPT### /dev/video2 rwk,")
+	c.Check(snippet, testutil.Contains, "/run/udev/data/c81:[0-9]* r,")
+	c.Check(snippet, Not(testutil.Contains), "/dev/video[0-9]*")
+	c.Check(snippet, Not(testutil.Contains), "/dev/vchiq")
+}
+
+func (s *CameraInterfaceSuite) TestUDevSpecHotplugSlot(c *C) {
+	appSet, err := interfaces.NewSnapAppSet(s.plug.Snap(), nil)
+	c.Assert(err, IsNil)
+	spec := udev.NewSpecification(appSet)
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.hotplugSlot), IsNil)
+	c.Assert(spec.Snippets(), HasLen, 2)
+	c.Assert(spec.Snippets(), testutil.Contains, `# camera
+SUBSYSTEM=="video4linux", KERNEL=="video2", TAG+="snap_consumer_app"`)
+}
+
+func (s *CameraInterfaceSuite) TestHotplugDeviceDetected(c *C) {
+	hotplugIface := s.iface.(hotplug.Definer)
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/video2", "ID_VENDOR_ID": "046d", "ID_MODEL_ID": "0825", "ID_V4L_PRODUCT": "UVC Camera (046d:0825)", "ID_V4L_CAPABILITIES": ":capture:", "ACTION": "add", "SUBSYSTEM": "video4linux"})
+	c.Assert(err, IsNil)
+	proposedSlot, err := hotplugIface.HotplugDeviceDetected(di)
+	c.Assert(err, IsNil)
+	c.Assert(proposedSlot, DeepEquals, &hotplug.ProposedSlot{
+		Label: "allows access to camera UVC Camera (046d:0825)",
+		Attrs: map[string]any{"path": "/dev/video2", "usb-vendor": int64(0x046d), "usb-product": int64(0x0825)},
+	})
+
+	// cameras which are not attached via USB have no vendor or product
+	di, err = hotplug.NewHotplugDeviceInfo(map[string]string{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/video0", "ID_V4L_CAPABILITIES": ":capture:", "ACTION": "add", "SUBSYSTEM": "video4linux"})
+	c.Assert(err, IsNil)
+	proposedSlot, err = hotplugIface.HotplugDeviceDetected(di)
+	c.Assert(err, IsNil)
+	c.Assert(proposedSlot, DeepEquals, &hotplug.ProposedSlot{
+		Label: "allows access to a specific camera",
+		Attrs: map[string]any{"path": "/dev/video0"},
+	})
+}
+
+func (s *CameraInterfaceSuite) TestHotplugDeviceDetectedNotCamera(c *C) {
+	hotplugIface := s.iface.(hotplug.Definer)
+	for _, env := range []map[string]string{
+		// metadata device node of a camera
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/video3", "ID_V4L_CAPABILITIES": ":", "ACTION": "add", "SUBSYSTEM": "video4linux"},
+		// not a video device
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/ttyUSB0", "ID_V4L_CAPABILITIES": ":capture:", "ACTION": "add", "SUBSYSTEM": "tty"},
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/vbi0", "ID_V4L_CAPABILITIES": ":capture:", "ACTION": "add", "SUBSYSTEM": "video4linux"},
+	} {
+		di, err := hotplug.NewHotplugDeviceInfo(env)
+		c.Assert(err, IsNil)
+		proposedSlot, err := hotplugIface.HotplugDeviceDetected(di)
+		c.Assert(err, IsNil)
+		c.Check(proposedSlot, IsNil, Commentf("%v", env))
+	}
+}
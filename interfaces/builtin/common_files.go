@@ -36,6 +36,9 @@ type commonFilesInterface struct {
 
 	apparmorHeader    string
 	extraPathValidate func(string) error
+	// promptable is set if accesses to the paths of the plug may be
+	// prompted for by the user.
+	promptable bool
 }
 
 // filesAAPerm can either be files{Read,Write} and converted to a string
@@ -76,13 +79,13 @@ func formatPath(ip any) (string, error) {
 	return fmt.Sprintf("%s%q", prefix, p), nil
 }
 
-func allowPathAccess(buf *bytes.Buffer, perm filesAAPerm, paths []any) error {
+func allowPathAccess(buf *bytes.Buffer, prefix string, perm filesAAPerm, paths []any) error {
 	for _, rawPath := range paths {
 		p, err := formatPath(rawPath)
 		if err != nil {
 			return err
 		}
-		fmt.Fprintf(buf, "%s %s,\n", p, perm)
+		fmt.Fprintf(buf, "%s%s %s,\n", prefix, p, perm)
 	}
 	return nil
 }
@@ -162,10 +165,14 @@ func (iface *commonFilesInterface) AppArmorConnectedPlug(spec *apparmor.Specific
 
 	errPrefix := fmt.Sprintf(`cannot connect plug %s: `, plug.Name())
 	buf := bytes.NewBufferString(iface.apparmorHeader)
-	if err := allowPathAccess(buf, filesRead, reads); err != nil {
+	prefix := ""
+	if iface.promptable {
+		prefix = "###PROMPT### "
+	}
+	if err := allowPathAccess(buf, prefix, filesRead, reads); err != nil {
 		return fmt.Errorf("%s%v", errPrefix, err)
 	}
-	if err := allowPathAccess(buf, filesWrite, writes); err != nil {
+	if err := allowPathAccess(buf, prefix, filesWrite, writes); err != nil {
 		return fmt.Errorf("%s%v", errPrefix, err)
 	}
 	spec.AddSnippet(buf.String())
@@ -27,6 +27,7 @@ import (
 
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/udev"
 	"github.com/snapcore/snapd/snap"
 )
@@ -85,9 +86,11 @@ func (iface *hidrawInterface) BeforePrepareSlot(slot *snap.SlotInfo) error {
 	path = filepath.Clean(path)
 
 	if iface.hasUsbAttrs(slot) {
-		// Must be path attribute where symlink will be placed and usb vendor and product identifiers
+		// Must be path attribute where symlink will be placed and usb vendor
+		// and product identifiers, or, for slots of hotplugged devices, the
+		// device node with the identifiers of the device
 		// Check the path attribute is in the allowable pattern
-		if !hidrawUDevSymlinkPattern.MatchString(path) {
+		if !hidrawUDevSymlinkPattern.MatchString(path) && !hidrawDeviceNodePattern.MatchString(path) {
 			return fmt.Errorf("hidraw path attribute specifies invalid symlink location")
 		}
 
@@ -117,6 +120,9 @@ func (iface *hidrawInterface) BeforePrepareSlot(slot *snap.SlotInfo) error {
 }
 
 func (iface *hidrawInterface) UDevPermanentSlot(spec *udev.Specification, slot *snap.SlotInfo) error {
+	if !iface.usesUDevSymlink(slot) {
+		return nil
+	}
 	usbVendor, ok := slot.Attrs["usb-vendor"].(int64)
 	if !ok {
 		return nil
@@ -137,7 +143,7 @@ SUBSYSTEM=="hidraw", SUBSYSTEMS=="usb", ATTRS{idVendor}=="%04x", ATTRS{idProduct
 }
 
 func (iface *hidrawInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
-	if iface.hasUsbAttrs(slot) {
+	if iface.usesUDevSymlink(slot) {
 		// This apparmor rule must match hidrawDeviceNodePattern
 		// UDev tagging and device cgroups will restrict down to the specific device
 		spec.AddSnippet("/dev/hidraw[0-9]{,[0-9],[0-9][0-9]} rw,")
@@ -157,7 +163,7 @@ func (iface *hidrawInterface) AppArmorConnectedPlug(spec *apparmor.Specification
 
 func (iface *hidrawInterface) UDevConnectedPlug(spec *udev.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
 	hasOnlyPath := true
-	if iface.hasUsbAttrs(slot) {
+	if iface.usesUDevSymlink(slot) {
 		hasOnlyPath = false
 	}
 
@@ -193,6 +199,43 @@ func (iface *hidrawInterface) AutoConnect(*snap.PlugInfo, *snap.SlotInfo) bool {
 	return true
 }
 
+func (iface *hidrawInterface) HotplugDeviceDetected(di *hotplug.HotplugDeviceInfo) (*hotplug.ProposedSlot, error) {
+	bus, _ := di.Attribute("ID_BUS")
+	if di.Subsystem() != "hidraw" || bus != "usb" || !hidrawDeviceNodePattern.MatchString(di.DeviceName()) {
+		return nil, nil
+	}
+
+	slot := hotplug.ProposedSlot{
+		Attrs: map[string]any{
+			"path": di.DeviceName(),
+		},
+	}
+	addHotplugUSBAttrs(di, slot.Attrs)
+	return &slot, nil
+}
+
+// HotplugKey returns a key which tells apart the hidraw devices of the
+// different interfaces of a USB device, which the default key does not.
+func (iface *hidrawInterface) HotplugKey(di *hotplug.HotplugDeviceInfo) (snap.HotplugKey, error) {
+	return hotplugKeyFromAttributes(di, "ID_VENDOR_ID", "ID_MODEL_ID", "ID_SERIAL", "ID_USB_INTERFACE_NUM"), nil
+}
+
+func (iface *hidrawInterface) HandledByGadget(di *hotplug.HotplugDeviceInfo, slot *snap.SlotInfo) bool {
+	var usbVendor, usbProduct int64
+	if err := slot.Attr("usb-vendor", &usbVendor); err == nil {
+		if err := slot.Attr("usb-product", &usbProduct); err != nil {
+			return false
+		}
+		return slotDeviceAttrEqual(di, "ID_VENDOR_ID", usbVendor) && slotDeviceAttrEqual(di, "ID_MODEL_ID", usbProduct)
+	}
+
+	var path string
+	if err := slot.Attr("path", &path); err != nil {
+		return false
+	}
+	return di.DeviceName() == path
+}
+
 func (iface *hidrawInterface) hasUsbAttrs(attrs interfaces.Attrer) bool {
 	var v int64
 	if err := attrs.Attr("usb-vendor", &v); err == nil {
@@ -204,6 +247,21 @@ func (iface *hidrawInterface) hasUsbAttrs(attrs interfaces.Attrer) bool {
 	return false
 }
 
+// usesUDevSymlink returns whether the slot identifies the device by its usb
+// vendor and product identifiers, with the path attribute being the udev
+// symlink to create for it. Slots of hotplugged devices have the identifiers
+// too, but their path attribute is the device node itself.
+func (iface *hidrawInterface) usesUDevSymlink(attrs interfaces.Attrer) bool {
+	if !iface.hasUsbAttrs(attrs) {
+		return false
+	}
+	var path string
+	if err := attrs.Attr("path", &path); err != nil {
+		return true
+	}
+	return !hidrawDeviceNodePattern.MatchString(filepath.Clean(path))
+}
+
 func init() {
 	registerIface(&hidrawInterface{})
 }
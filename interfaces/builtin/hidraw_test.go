@@ -28,6 +28,7 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/udev"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snaptest"
@@ -377,3 +378,96 @@ SUBSYSTEM=="hidraw", SUBSYSTEMS=="usb", ATTRS{idVendor}=="ffff", ATTRS{idProduct
 func (s *HidrawInterfaceSuite) TestInterfaces(c *C) {
 	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
 }
+
+func (s *HidrawInterfaceSuite) TestHotplugDeviceDetected(c *C) {
+	hotplugIface := s.iface.(hotplug.Definer)
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/hidraw3", "ID_VENDOR_ID": "1234", "ID_MODEL_ID": "5678", "ACTION": "add", "SUBSYSTEM": "hidraw", "ID_BUS": "usb"})
+	c.Assert(err, IsNil)
+	proposedSlot, err := hotplugIface.HotplugDeviceDetected(di)
+	c.Assert(err, IsNil)
+	c.Assert(proposedSlot, DeepEquals, &hotplug.ProposedSlot{Attrs: map[string]any{"path": "/dev/hidraw3", "usb-vendor": int64(0x1234), "usb-product": int64(0x5678)}})
+
+	// the proposed slot is valid
+	cleaned, err := proposedSlot.Clean()
+	c.Assert(err, IsNil)
+	slotInfo := &snap.SlotInfo{Snap: s.osSnapInfo, Name: "hidraw3", Interface: "hidraw", Attrs: cleaned.Attrs}
+	c.Assert(interfaces.BeforePrepareSlot(s.iface, slotInfo), IsNil)
+
+	// and only grants access to the device node, despite the usb identifiers
+	slot := interfaces.NewConnectedSlot(slotInfo, s.testSlot1.AppSet(), nil, nil)
+	apparmorSpec := apparmor.NewSpecification(s.testPlugPort1.AppSet())
+	c.Assert(apparmorSpec.AddConnectedPlug(s.iface, s.testPlugPort1, slot), IsNil)
+	c.Check(apparmorSpec.SnippetForTag("snap.client-snap.app-accessing-2-devices"), Equals, "/dev/hidraw3 rw,")
+	udevSpec := udev.NewSpecification(s.testPlugPort1.AppSet())
+	c.Assert(udevSpec.AddConnectedPlug(s.iface, s.testPlugPort1, slot), IsNil)
+	c.Assert(udevSpec.Snippets(), HasLen, 2)
+	c.Check(udevSpec.Snippets()[0], Equals, `# hidraw
+SUBSYSTEM=="hidraw", KERNEL=="hidraw3", TAG+="snap_client-snap_app-accessing-2-devices"`)
+	udevSpec = udev.NewSpecification(s.testSlot1.AppSet())
+	c.Assert(udevSpec.AddPermanentSlot(s.iface, slotInfo), IsNil)
+	c.Check(udevSpec.Snippets(), HasLen, 0)
+}
+
+func (s *HidrawInterfaceSuite) TestHotplugDeviceDetectedNotHidraw(c *C) {
+	hotplugIface := s.iface.(hotplug.Definer)
+	for _, env := range []map[string]string{
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/hidraw3", "ACTION": "add", "SUBSYSTEM": "hidraw", "ID_BUS": "bluetooth"},
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/hidraw9999", "ACTION": "add", "SUBSYSTEM": "hidraw", "ID_BUS": "usb"},
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/ttyUSB0", "ACTION": "add", "SUBSYSTEM": "tty", "ID_BUS": "usb"},
+	} {
+		di, err := hotplug.NewHotplugDeviceInfo(env)
+		c.Assert(err, IsNil)
+		proposedSlot, err := hotplugIface.HotplugDeviceDetected(di)
+		c.Assert(err, IsNil)
+		c.Check(proposedSlot, IsNil, Commentf("%v", env))
+	}
+}
+
+func (s *HidrawInterfaceSuite) TestHotplugKey(c *C) {
+	keyHandler := s.iface.(hotplug.HotplugKeyHandler)
+	env := map[string]string{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/hidraw3", "ID_VENDOR_ID": "1234", "ID_MODEL_ID": "5678", "ID_SERIAL": "Foo_Bar_0001", "ID_USB_INTERFACE_NUM": "00", "ACTION": "add", "SUBSYSTEM": "hidraw", "ID_BUS": "usb"}
+	di, err := hotplug.NewHotplugDeviceInfo(env)
+	c.Assert(err, IsNil)
+	key1, err := keyHandler.HotplugKey(di)
+	c.Assert(err, IsNil)
+	c.Check(key1, Not(Equals), snap.HotplugKey(""))
+
+	// the same device node on another interface of the device has another key
+	env["ID_USB_INTERFACE_NUM"] = "01"
+	di, err = hotplug.NewHotplugDeviceInfo(env)
+	c.Assert(err, IsNil)
+	key2, err := keyHandler.HotplugKey(di)
+	c.Assert(err, IsNil)
+	c.Check(key2, Not(Equals), snap.HotplugKey(""))
+	c.Check(key2, Not(Equals), key1)
+
+	// while the device node does not matter
+	env["ID_USB_INTERFACE_NUM"] = "00"
+	env["DEVNAME"] = "/dev/hidraw7"
+	di, err = hotplug.NewHotplugDeviceInfo(env)
+	c.Assert(err, IsNil)
+	key3, err := keyHandler.HotplugKey(di)
+	c.Assert(err, IsNil)
+	c.Check(key3, Equals, key1)
+
+	// no key without a serial
+	delete(env, "ID_SERIAL")
+	di, err = hotplug.NewHotplugDeviceInfo(env)
+	c.Assert(err, IsNil)
+	key4, err := keyHandler.HotplugKey(di)
+	c.Assert(err, IsNil)
+	c.Check(key4, Equals, snap.HotplugKey(""))
+}
+
+func (s *HidrawInterfaceSuite) TestHotplugHandledByGadget(c *C) {
+	byGadgetPred := s.iface.(hotplug.HandledByGadgetPredicate)
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/hidraw0", "ACTION": "add", "SUBSYSTEM": "hidraw", "ID_BUS": "usb"})
+	c.Assert(err, IsNil)
+	c.Check(byGadgetPred.HandledByGadget(di, s.testSlot1Info), Equals, true)
+	c.Check(byGadgetPred.HandledByGadget(di, s.testSlot2Info), Equals, false)
+
+	di, err = hotplug.NewHotplugDeviceInfo(map[string]string{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/hidraw5", "ID_VENDOR_ID": "ffff", "ID_MODEL_ID": "ffff", "ACTION": "add", "SUBSYSTEM": "hidraw", "ID_BUS": "usb"})
+	c.Assert(err, IsNil)
+	c.Check(byGadgetPred.HandledByGadget(di, s.testUDev2Info), Equals, true)
+	c.Check(byGadgetPred.HandledByGadget(di, s.testUDev1Info), Equals, false)
+}
@@ -19,6 +19,17 @@
 
 package builtin
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/hotplug"
+	"github.com/snapcore/snapd/interfaces/udev"
+	"github.com/snapcore/snapd/snap"
+)
+
 const rawusbSummary = `allows raw access to all USB devices`
 
 const rawusbBaseDeclarationSlots = `
@@ -40,7 +51,16 @@ const rawusbConnectedPlugAppArmor = `
 
 # Allow raw access to USB printers (i.e. for receipt printers in POS systems).
 /dev/usb/lp[0-9]* rwk,
+` + rawusbDetectionConnectedPlugAppArmor
 
+// rawusbDeviceConnectedPlugAppArmor is used for slots created for hotplugged
+// USB devices. Device cgroup restricts access to the device of the slot.
+const rawusbDeviceConnectedPlugAppArmor = `
+# Description: Allow raw access to a specific USB device.
+/dev/bus/usb/[0-9][0-9][0-9]/[0-9][0-9][0-9] rw,
+` + rawusbDetectionConnectedPlugAppArmor
+
+const rawusbDetectionConnectedPlugAppArmor = `
 # Allow detection of usb devices. Leaks plugged in USB device info
 /sys/bus/usb/devices/ r,
 /sys/devices/pci**/usb[0-9]** r,
@@ -69,8 +89,92 @@ var rawusbConnectedPlugUDev = []string{
 	`SUBSYSTEM=="tty", ENV{ID_BUS}=="usb"`,
 }
 
+// rawUsbInterface is the type for the raw-usb interface. The implicit slot
+// grants access to all USB devices, while slots created for hotplugged USB
+// devices have usb-vendor and usb-product attributes and grant access to the
+// devices with those identifiers only.
+type rawUsbInterface struct {
+	commonInterface
+}
+
+func (iface *rawUsbInterface) hasUsbAttrs(attrs interfaces.Attrer) bool {
+	_, ok := attrs.Lookup("usb-vendor")
+	return ok
+}
+
+func (iface *rawUsbInterface) usbAttrs(attrs interfaces.Attrer) (usbVendor, usbProduct int64, err error) {
+	if err := attrs.Attr("usb-vendor", &usbVendor); err != nil {
+		return 0, 0, fmt.Errorf("raw-usb slot failed to find usb-vendor attribute")
+	}
+	if usbVendor < 0x1 || usbVendor > 0xFFFF {
+		return 0, 0, fmt.Errorf("raw-usb usb-vendor attribute not valid: %d", usbVendor)
+	}
+	if err := attrs.Attr("usb-product", &usbProduct); err != nil {
+		return 0, 0, fmt.Errorf("raw-usb slot failed to find usb-product attribute")
+	}
+	if usbProduct < 0x0 || usbProduct > 0xFFFF {
+		return 0, 0, fmt.Errorf("raw-usb usb-product attribute not valid: %d", usbProduct)
+	}
+	return usbVendor, usbProduct, nil
+}
+
+// BeforePrepareSlot checks the validity of the attributes of slots created
+// for hotplugged USB devices.
+func (iface *rawUsbInterface) BeforePrepareSlot(slot *snap.SlotInfo) error {
+	if !iface.hasUsbAttrs(slot) {
+		return nil
+	}
+	_, _, err := iface.usbAttrs(slot)
+	return err
+}
+
+func (iface *rawUsbInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	if !iface.hasUsbAttrs(slot) {
+		return iface.commonInterface.AppArmorConnectedPlug(spec, plug, slot)
+	}
+	spec.AddSnippet(rawusbDeviceConnectedPlugAppArmor)
+	return nil
+}
+
+func (iface *rawUsbInterface) UDevConnectedPlug(spec *udev.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	if !iface.hasUsbAttrs(slot) {
+		return iface.commonInterface.UDevConnectedPlug(spec, plug, slot)
+	}
+	usbVendor, usbProduct, err := iface.usbAttrs(slot)
+	if err != nil {
+		return nil
+	}
+	spec.TagDevice(fmt.Sprintf(`SUBSYSTEM=="usb", ATTR{idVendor}=="%04x", ATTR{idProduct}=="%04x"`, usbVendor, usbProduct))
+	return nil
+}
+
+// HotplugDeviceDetected proposes a slot for USB devices, other than hubs,
+// which is scoped to the vendor and product identifiers of the device.
+func (iface *rawUsbInterface) HotplugDeviceDetected(di *hotplug.HotplugDeviceInfo) (*hotplug.ProposedSlot, error) {
+	if di.Subsystem() != "usb" || di.DeviceType() != "usb_device" {
+		return nil, nil
+	}
+	// The TYPE attribute holds the class/subclass/protocol of the device,
+	// class 9 being hubs.
+	if deviceType, _ := di.Attribute("TYPE"); strings.HasPrefix(deviceType, "9/") {
+		return nil, nil
+	}
+	usbVendor, usbProduct, ok := hotplugUSBIDs(di)
+	if !ok || usbVendor == 0 {
+		return nil, nil
+	}
+	slot := hotplug.ProposedSlot{
+		Label: "allows raw access to a specific USB device",
+		Attrs: map[string]any{
+			"usb-vendor":  usbVendor,
+			"usb-product": usbProduct,
+		},
+	}
+	return &slot, nil
+}
+
 func init() {
-	registerIface(&commonInterface{
+	registerIface(&rawUsbInterface{commonInterface{
 		name:                  "raw-usb",
 		summary:               rawusbSummary,
 		implicitOnCore:        true,
@@ -79,5 +183,5 @@ func init() {
 		connectedPlugAppArmor: rawusbConnectedPlugAppArmor,
 		connectedPlugSecComp:  rawusbConnectedPlugSecComp,
 		connectedPlugUDev:     rawusbConnectedPlugUDev,
-	})
+	}})
 }
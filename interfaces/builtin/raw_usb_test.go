@@ -28,18 +28,22 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/seccomp"
 	"github.com/snapcore/snapd/interfaces/udev"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
 )
 
 type RawUsbInterfaceSuite struct {
-	iface    interfaces.Interface
-	slotInfo *snap.SlotInfo
-	slot     *interfaces.ConnectedSlot
-	plugInfo *snap.PlugInfo
-	plug     *interfaces.ConnectedPlug
+	iface           interfaces.Interface
+	slotInfo        *snap.SlotInfo
+	slot            *interfaces.ConnectedSlot
+	hotplugSlotInfo *snap.SlotInfo
+	hotplugSlot     *interfaces.ConnectedSlot
+	plugInfo        *snap.PlugInfo
+	plug            *interfaces.ConnectedPlug
 }
 
 var _ = Suite(&RawUsbInterfaceSuite{
@@ -63,8 +67,19 @@ slots:
 func (s *RawUsbInterfaceSuite) SetUpTest(c *C) {
 	s.plug, s.plugInfo = MockConnectedPlug(c, rawusbConsumerYaml, nil, "raw-usb")
 	s.slot, s.slotInfo = MockConnectedSlot(c, rawusbCoreYaml, nil, "raw-usb")
+	s.hotplugSlot, s.hotplugSlotInfo = MockConnectedSlot(c, rawusbHotplugCoreYaml, nil, "scanner")
 }
 
+const rawusbHotplugCoreYaml = `name: core
+version: 0
+type: os
+slots:
+  scanner:
+    interface: raw-usb
+    usb-vendor: 0x04a9
+    usb-product: 0x1909
+`
+
 func (s *RawUsbInterfaceSuite) TestName(c *C) {
 	c.Assert(s.iface.Name(), Equals, "raw-usb")
 }
@@ -125,3 +140,90 @@ func (s *RawUsbInterfaceSuite) TestAutoConnect(c *C) {
 func (s *RawUsbInterfaceSuite) TestInterfaces(c *C) {
 	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
 }
+
+func (s *RawUsbInterfaceSuite) TestSanitizeHotplugSlot(c *C) {
+	c.Assert(interfaces.BeforePrepareSlot(s.iface, s.hotplugSlotInfo), IsNil)
+
+	const mockSnapYaml = `name: core
+version: 0
+type: os
+slots:
+  scanner:
+    interface: raw-usb
+    %s
+`
+	for attrs, expectedErr := range map[string]string{
+		"usb-vendor: 0x0000\n    usb-product: 0x1909":  "raw-usb usb-vendor attribute not valid: 0",
+		"usb-vendor: 0x04a9\n    usb-product: 0x10000": "raw-usb usb-product attribute not valid: 65536",
+		"usb-vendor: 0x04a9":                           "raw-usb slot failed to find usb-product attribute",
+		"usb-vendor: foo\n    usb-product: 0x1909":     "raw-usb slot failed to find usb-vendor attribute",
+	} {
+		info := snaptest.MockInfo(c, fmt.Sprintf(mockSnapYaml, attrs), nil)
+		slot := info.Slots["scanner"]
+		c.Check(interfaces.BeforePrepareSlot(s.iface, slot), ErrorMatches, expectedErr)
+	}
+}
+
+func (s *RawUsbInterfaceSuite) TestAppArmorSpecHotplugSlot(c *C) {
+	appSet, err := interfaces.NewSnapAppSet(s.plug.Snap(), nil)
+	c.Assert(err, IsNil)
+	spec := apparmor.NewSpecification(appSet)
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.hotplugSlot), IsNil)
+	c.Assert(spec.SecurityTags(), DeepEquals, []string{"snap.consumer.app"})
+	snippet := spec.SnippetForTag("snap.consumer.app")
+	c.Check(snippet, testutil.Contains, `/dev/bus/usb/[0-9][0-9][0-9]/[0-9][0-9][0-9] rw,`)
+	c.Check(snippet, testutil.Contains, `/run/udev/data/+usb:* r,`)
+	c.Check(snippet, Not(testutil.Contains), `/dev/tty{USB,ACM}[0-9]* rwk,`)
+	c.Check(snippet, Not(testutil.Contains), `/dev/usb/lp[0-9]* rwk,`)
+}
+
+func (s *RawUsbInterfaceSuite) TestSecCompSpecHotplugSlot(c *C) {
+	appSet, err := interfaces.NewSnapAppSet(s.plug.Snap(), nil)
+	c.Assert(err, IsNil)
+	spec := seccomp.NewSpecification(appSet)
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.hotplugSlot), IsNil)
+	c.Assert(spec.SnippetForTag("snap.consumer.app"), testutil.Contains, "NETLINK_KOBJECT_UEVENT")
+}
+
+func (s *RawUsbInterfaceSuite) TestUDevSpecHotplugSlot(c *C) {
+	appSet, err := interfaces.NewSnapAppSet(s.plug.Snap(), nil)
+	c.Assert(err, IsNil)
+	spec := udev.NewSpecification(appSet)
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.hotplugSlot), IsNil)
+	c.Assert(spec.Snippets(), HasLen, 2)
+	c.Assert(spec.Snippets(), testutil.Contains, `# raw-usb
+SUBSYSTEM=="usb", ATTR{idVendor}=="04a9", ATTR{idProduct}=="1909", TAG+="snap_consumer_app"`)
+}
+
+func (s *RawUsbInterfaceSuite) TestHotplugDeviceDetected(c *C) {
+	hotplugIface := s.iface.(hotplug.Definer)
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/bus/usb/001/004", "DEVTYPE": "usb_device", "TYPE": "0/0/0", "ID_VENDOR_ID": "04a9", "ID_MODEL_ID": "1909", "ACTION": "add", "SUBSYSTEM": "usb"})
+	c.Assert(err, IsNil)
+	proposedSlot, err := hotplugIface.HotplugDeviceDetected(di)
+	c.Assert(err, IsNil)
+	c.Assert(proposedSlot, DeepEquals, &hotplug.ProposedSlot{
+		Label: "allows raw access to a specific USB device",
+		Attrs: map[string]any{"usb-vendor": int64(0x04a9), "usb-product": int64(0x1909)},
+	})
+}
+
+func (s *RawUsbInterfaceSuite) TestHotplugDeviceDetectedNotUSBDevice(c *C) {
+	hotplugIface := s.iface.(hotplug.Definer)
+	for _, env := range []map[string]string{
+		// hub
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/bus/usb/001/001", "DEVTYPE": "usb_device", "TYPE": "9/0/1", "ID_VENDOR_ID": "1d6b", "ID_MODEL_ID": "0002", "ACTION": "add", "SUBSYSTEM": "usb"},
+		// interface of a device
+		{"DEVPATH": "/sys/foo/bar", "DEVTYPE": "usb_interface", "ID_VENDOR_ID": "04a9", "ID_MODEL_ID": "1909", "ACTION": "add", "SUBSYSTEM": "usb"},
+		// invalid identifiers
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/bus/usb/001/004", "DEVTYPE": "usb_device", "ID_VENDOR_ID": "xyz", "ID_MODEL_ID": "1909", "ACTION": "add", "SUBSYSTEM": "usb"},
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/bus/usb/001/004", "DEVTYPE": "usb_device", "ID_VENDOR_ID": "04a9", "ACTION": "add", "SUBSYSTEM": "usb"},
+		// not a USB device
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/ttyUSB0", "ID_VENDOR_ID": "04a9", "ID_MODEL_ID": "1909", "ACTION": "add", "SUBSYSTEM": "tty"},
+	} {
+		di, err := hotplug.NewHotplugDeviceInfo(env)
+		c.Assert(err, IsNil)
+		proposedSlot, err := hotplugIface.HotplugDeviceDetected(di)
+		c.Assert(err, IsNil)
+		c.Check(proposedSlot, IsNil, Commentf("%v", env))
+	}
+}
@@ -19,6 +19,17 @@
 
 package builtin
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/hotplug"
+	"github.com/snapcore/snapd/snap"
+)
+
 const removableMediaSummary = `allows access to mounted removable storage`
 
 const removableMediaBaseDeclarationSlots = `
@@ -41,21 +52,156 @@ const removableMediaConnectedPlugAppArmor = `
 
 # Mount points could be in /run/media/<user>/* or /media/<user>/*
 /{,run/}media/*/ r,
-/{,run/}media/*/** mrwklix,
+###PROMPT### /{,run/}media/*/** mrwklix,
 
 # Allow read-only access to /mnt to enumerate items.
 /mnt/ r,
 # Allow write access to anything under /mnt
-/mnt/** mrwklix,
+###PROMPT### /mnt/** mrwklix,
 `
 
+// removableMediaVolumeConnectedPlugAppArmor is used for slots created for
+// hotplugged filesystems, and grants access to the mount points of the
+// filesystem with the given volume name only.
+const removableMediaVolumeConnectedPlugAppArmor = `
+# Description: Can access a specific removable storage filesystem
+
+# Allow read-access to /run/ for navigating to removable media.
+/run/ r,
+/{,run/}media/ r,
+
+# Mount points could be in /run/media/<user>/<volume> or /media/<user>/<volume>
+/{,run/}media/*/%[1]s/ r,
+###PROMPT### /{,run/}media/*/%[1]s/** mrwklix,
+`
+
+// Pattern to match the volume names of hotplugged filesystems, which are
+// used as mount point names. Labels with other characters are not used as
+// volume names, as they are not safe to use in AppArmor rules.
+var removableMediaVolumePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// Pattern to match the UUIDs of hotplugged filesystems, as reported by udev.
+var removableMediaUUIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]{0,63}$`)
+
+// removableMediaDeviceNodePattern matches the device nodes of storage which
+// is considered removable when not attached via USB.
+var removableMediaDeviceNodePattern = regexp.MustCompile(`^/dev/mmcblk[0-9]{1,2}(p[0-9]{1,2})?$`)
+
+// removableMediaInterface is the type for the removable-media interface. The
+// implicit slot grants access to all mounted removable storage, while slots
+// created for hotplugged filesystems grant access to that filesystem only.
+// These identify the filesystem by its fs-uuid attribute, which declarations
+// can rely on, as the volume attribute is the name of its mount point, which
+// comes from the label and can be set to anything.
+type removableMediaInterface struct {
+	commonInterface
+}
+
+// BeforePrepareSlot checks the validity of the fs-uuid and volume attributes
+// of slots created for hotplugged filesystems.
+func (iface *removableMediaInterface) BeforePrepareSlot(slot *snap.SlotInfo) error {
+	_, hasVolume := slot.Attrs["volume"]
+	_, hasUUID := slot.Attrs["fs-uuid"]
+	if !hasVolume && !hasUUID {
+		return nil
+	}
+	var uuid string
+	if err := slot.Attr("fs-uuid", &uuid); err != nil {
+		return err
+	}
+	if !removableMediaUUIDPattern.MatchString(uuid) {
+		return fmt.Errorf("removable-media fs-uuid attribute must be a valid filesystem UUID: %q", uuid)
+	}
+	_, err := removableMediaVolume(slot)
+	return err
+}
+
+func removableMediaVolume(attrs interfaces.Attrer) (string, error) {
+	var volume string
+	if err := attrs.Attr("volume", &volume); err != nil {
+		return "", err
+	}
+	if !removableMediaVolumePattern.MatchString(volume) {
+		return "", fmt.Errorf("removable-media volume attribute must be a valid volume name: %q", volume)
+	}
+	return volume, nil
+}
+
+func (iface *removableMediaInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+	if _, ok := slot.Lookup("volume"); !ok {
+		return iface.commonInterface.AppArmorConnectedPlug(spec, plug, slot)
+	}
+	volume, err := removableMediaVolume(slot)
+	if err != nil {
+		return nil
+	}
+	spec.AddSnippet(fmt.Sprintf(removableMediaVolumeConnectedPlugAppArmor, volume))
+	return nil
+}
+
+// HotplugDeviceDetected proposes a slot for filesystems on USB storage and
+// SD cards, identified by their UUID. Mount points are named after the
+// filesystem label, or its UUID if it has no label, so the volume attribute
+// of the slot is set to the one which is used.
+func (iface *removableMediaInterface) HotplugDeviceDetected(di *hotplug.HotplugDeviceInfo) (*hotplug.ProposedSlot, error) {
+	if di.Subsystem() != "block" {
+		return nil, nil
+	}
+	if usage, _ := di.Attribute("ID_FS_USAGE"); usage != "filesystem" {
+		return nil, nil
+	}
+	if bus, _ := di.Attribute("ID_BUS"); bus != "usb" && !removableMediaDeviceNodePattern.MatchString(di.DeviceName()) {
+		return nil, nil
+	}
+	uuid, _ := di.Attribute("ID_FS_UUID")
+	if !removableMediaUUIDPattern.MatchString(uuid) {
+		return nil, nil
+	}
+	volume, _ := di.Attribute("ID_FS_LABEL")
+	if volume == "" {
+		volume = uuid
+	}
+	if !removableMediaVolumePattern.MatchString(volume) {
+		return nil, nil
+	}
+	slot := hotplug.ProposedSlot{
+		Label: fmt.Sprintf("allows access to removable storage volume %s (UUID %s)", volume, uuid),
+		Attrs: map[string]any{
+			"fs-uuid": uuid,
+			"volume":  volume,
+		},
+	}
+	addHotplugUSBAttrs(di, slot.Attrs)
+	return &slot, nil
+}
+
+// HotplugKey returns a key based on the UUID of the filesystem, so that the
+// slot follows the filesystem whichever device it is inserted into.
+func (iface *removableMediaInterface) HotplugKey(di *hotplug.HotplugDeviceInfo) (snap.HotplugKey, error) {
+	return hotplugKeyFromAttributes(di, "ID_FS_UUID"), nil
+}
+
+// DetectRemovableMediaFromPath returns true if the given path corresponds to
+// an AppArmor rule with the prompt prefix from the removable-media interface.
+//
+// XXX: this is only necessary until metadata tags are fully supported by the
+// AppArmor parser and kernel. Then, this function should be removed.
+func DetectRemovableMediaFromPath(path string) bool {
+	for _, prefix := range []string{"/media/", "/run/media/", "/mnt/"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
-	registerIface(&commonInterface{
+	registerIface(&removableMediaInterface{commonInterface{
 		name:                  "removable-media",
 		summary:               removableMediaSummary,
 		implicitOnCore:        true,
 		implicitOnClassic:     true,
 		baseDeclarationSlots:  removableMediaBaseDeclarationSlots,
 		connectedPlugAppArmor: removableMediaConnectedPlugAppArmor,
-	})
+	}})
 }
@@ -20,12 +20,16 @@
 package builtin_test
 
 import (
+	"fmt"
+
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -79,9 +83,137 @@ func (s *RemovableMediaInterfaceSuite) TestUsedSecuritySystems(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(apparmorSpec.SecurityTags(), DeepEquals, []string{"snap.client-snap.other"})
 	c.Check(apparmorSpec.SnippetForTag("snap.client-snap.other"), testutil.Contains, "/{,run/}media/*/ r")
-	c.Check(apparmorSpec.SnippetForTag("snap.client-snap.other"), testutil.Contains, "/mnt/** mrwklix,")
+	c.Check(apparmorSpec.SnippetForTag("snap.client-snap.other"), testutil.Contains, "###PROMPT### /{,run/}media/*/** mrwklix,")
+	c.Check(apparmorSpec.SnippetForTag("snap.client-snap.other"), testutil.Contains, "###PROMPT### /mnt/** mrwklix,")
+}
+
+func (s *RemovableMediaInterfaceSuite) TestDetectRemovableMediaFromPath(c *C) {
+	for _, path := range []string{
+		"/media/ubuntu/usb/foo",
+		"/run/media/ubuntu/usb/",
+		"/mnt/disk/bar",
+	} {
+		c.Check(builtin.DetectRemovableMediaFromPath(path), Equals, true, Commentf("%q should be detected as removable-media path", path))
+	}
+
+	for _, path := range []string{
+		"/media",
+		"/mediafoo/bar",
+		"/run/user/1000/foo",
+		"/home/ubuntu/mnt/foo",
+	} {
+		c.Check(builtin.DetectRemovableMediaFromPath(path), Equals, false, Commentf("%q should not be detected as removable-media path", path))
+	}
 }
 
 func (s *RemovableMediaInterfaceSuite) TestInterfaces(c *C) {
 	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
 }
+
+const removableMediaHotplugSlotYaml = `name: core
+version: 1.0
+type: os
+slots:
+ usb-stick:
+  interface: removable-media
+  fs-uuid: 1234-ABCD
+  volume: %s
+`
+
+func (s *RemovableMediaInterfaceSuite) TestSanitizeHotplugSlot(c *C) {
+	for _, volume := range []string{"BACKUP", "my-disk_2.0", "1234-ABCD", "0f6a4e1e-5c2b-4d0e-9b7c-2e5c2b9d1f0a"} {
+		info := snaptest.MockInfo(c, fmt.Sprintf(removableMediaHotplugSlotYaml, volume), nil)
+		c.Check(interfaces.BeforePrepareSlot(s.iface, info.Slots["usb-stick"]), IsNil, Commentf("volume %q", volume))
+	}
+	for _, volume := range []string{`"my disk"`, `"../foo"`, `"*"`, `"{a,b}"`, `""`} {
+		info := snaptest.MockInfo(c, fmt.Sprintf(removableMediaHotplugSlotYaml, volume), nil)
+		c.Check(interfaces.BeforePrepareSlot(s.iface, info.Slots["usb-stick"]), ErrorMatches, "removable-media volume attribute must be a valid volume name: .*", Commentf("volume %s", volume))
+	}
+
+	// the filesystem must be identified by its UUID
+	for yaml, expectedErr := range map[string]string{
+		"  volume: BACKUP\n":                    `snap "core" does not have attribute "fs-uuid" for interface "removable-media"`,
+		"  fs-uuid: 1234-ABCD\n":                `snap "core" does not have attribute "volume" for interface "removable-media"`,
+		"  fs-uuid: ../foo\n  volume: BACKUP\n": `removable-media fs-uuid attribute must be a valid filesystem UUID: "../foo"`,
+		"  fs-uuid: \"\"\n  volume: BACKUP\n":   `removable-media fs-uuid attribute must be a valid filesystem UUID: ""`,
+	} {
+		info := snaptest.MockInfo(c, "name: core\nversion: 1.0\ntype: os\nslots:\n usb-stick:\n  interface: removable-media\n"+yaml, nil)
+		c.Check(interfaces.BeforePrepareSlot(s.iface, info.Slots["usb-stick"]), ErrorMatches, expectedErr, Commentf("%s", yaml))
+	}
+}
+
+func (s *RemovableMediaInterfaceSuite) TestAppArmorSpecHotplugSlot(c *C) {
+	slot, _ := MockConnectedSlot(c, fmt.Sprintf(removableMediaHotplugSlotYaml, "BACKUP"), nil, "usb-stick")
+	appSet, err := interfaces.NewSnapAppSet(s.plug.Snap(), nil)
+	c.Assert(err, IsNil)
+	spec := apparmor.NewSpecification(appSet)
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, slot), IsNil)
+	c.Assert(spec.SecurityTags(), DeepEquals, []string{"snap.client-snap.other"})
+	snippet := spec.SnippetForTag("snap.client-snap.other")
+	c.Check(snippet, testutil.Contains, "/{,run/}media/*/BACKUP/ r,")
+	c.Check(snippet, testutil.Contains, "###PROMPT### /{,run/}media/*/BACKUP/** mrwklix,")
+	c.Check(snippet, Not(testutil.Contains), "/{,run/}media/*/** mrwklix,")
+	c.Check(snippet, Not(testutil.Contains), "/mnt/")
+}
+
+func (s *RemovableMediaInterfaceSuite) TestHotplugDeviceDetected(c *C) {
+	hotplugIface := s.iface.(hotplug.Definer)
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/sdb1", "DEVTYPE": "partition", "ID_BUS": "usb", "ID_FS_USAGE": "filesystem", "ID_FS_LABEL": "BACKUP", "ID_FS_UUID": "1234-ABCD", "ID_VENDOR_ID": "0781", "ID_MODEL_ID": "5581", "ACTION": "add", "SUBSYSTEM": "block"})
+	c.Assert(err, IsNil)
+	proposedSlot, err := hotplugIface.HotplugDeviceDetected(di)
+	c.Assert(err, IsNil)
+	c.Assert(proposedSlot, DeepEquals, &hotplug.ProposedSlot{
+		Label: "allows access to removable storage volume BACKUP (UUID 1234-ABCD)",
+		Attrs: map[string]any{"fs-uuid": "1234-ABCD", "volume": "BACKUP", "usb-vendor": int64(0x0781), "usb-product": int64(0x5581)},
+	})
+
+	// the UUID is used when the label cannot be used as volume name
+	di, err = hotplug.NewHotplugDeviceInfo(map[string]string{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/mmcblk1p1", "DEVTYPE": "partition", "ID_FS_USAGE": "filesystem", "ID_FS_UUID": "1234-ABCD", "ACTION": "add", "SUBSYSTEM": "block"})
+	c.Assert(err, IsNil)
+	proposedSlot, err = hotplugIface.HotplugDeviceDetected(di)
+	c.Assert(err, IsNil)
+	c.Assert(proposedSlot, DeepEquals, &hotplug.ProposedSlot{
+		Label: "allows access to removable storage volume 1234-ABCD (UUID 1234-ABCD)",
+		Attrs: map[string]any{"fs-uuid": "1234-ABCD", "volume": "1234-ABCD"},
+	})
+}
+
+func (s *RemovableMediaInterfaceSuite) TestHotplugDeviceDetectedNotRemovableMedia(c *C) {
+	hotplugIface := s.iface.(hotplug.Definer)
+	for _, env := range []map[string]string{
+		// internal disk
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/sda1", "DEVTYPE": "partition", "ID_BUS": "ata", "ID_FS_USAGE": "filesystem", "ID_FS_UUID": "1234-ABCD", "ACTION": "add", "SUBSYSTEM": "block"},
+		// not a filesystem
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/sdb", "DEVTYPE": "disk", "ID_BUS": "usb", "ACTION": "add", "SUBSYSTEM": "block"},
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/sdb2", "DEVTYPE": "partition", "ID_BUS": "usb", "ID_FS_USAGE": "crypto", "ID_FS_UUID": "1234-ABCD", "ACTION": "add", "SUBSYSTEM": "block"},
+		// label is not safe
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/sdb1", "DEVTYPE": "partition", "ID_BUS": "usb", "ID_FS_USAGE": "filesystem", "ID_FS_LABEL": "my disk", "ID_FS_UUID": "1234-ABCD", "ACTION": "add", "SUBSYSTEM": "block"},
+		// no UUID to identify the filesystem
+		{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/sdb1", "DEVTYPE": "partition", "ID_BUS": "usb", "ID_FS_USAGE": "filesystem", "ID_FS_LABEL": "BACKUP", "ACTION": "add", "SUBSYSTEM": "block"},
+	} {
+		di, err := hotplug.NewHotplugDeviceInfo(env)
+		c.Assert(err, IsNil)
+		proposedSlot, err := hotplugIface.HotplugDeviceDetected(di)
+		c.Assert(err, IsNil)
+		c.Check(proposedSlot, IsNil, Commentf("%v", env))
+	}
+}
+
+func (s *RemovableMediaInterfaceSuite) TestHotplugKey(c *C) {
+	keyHandler := s.iface.(hotplug.HotplugKeyHandler)
+	env := map[string]string{"DEVPATH": "/sys/foo/bar", "DEVNAME": "/dev/sdb1", "DEVTYPE": "partition", "ID_BUS": "usb", "ID_FS_USAGE": "filesystem", "ID_FS_UUID": "1234-ABCD", "ACTION": "add", "SUBSYSTEM": "block"}
+	di, err := hotplug.NewHotplugDeviceInfo(env)
+	c.Assert(err, IsNil)
+	key1, err := keyHandler.HotplugKey(di)
+	c.Assert(err, IsNil)
+	c.Check(key1, Not(Equals), snap.HotplugKey(""))
+
+	// the key follows the filesystem
+	env["DEVNAME"] = "/dev/sdc1"
+	env["DEVPATH"] = "/sys/foo/baz"
+	di, err = hotplug.NewHotplugDeviceInfo(env)
+	c.Assert(err, IsNil)
+	key2, err := keyHandler.HotplugKey(di)
+	c.Assert(err, IsNil)
+	c.Check(key2, Equals, key1)
+}
@@ -20,14 +20,17 @@
 package builtin
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/sandbox/apparmor"
 	"github.com/snapcore/snapd/snap"
@@ -194,3 +197,59 @@ func stringListAttribute(attrer interfaces.Attrer, key string) ([]string, error)
 
 	return stringList, nil
 }
+
+// hasPathAttr returns whether the path attribute is set, which for
+// interfaces with an implicit slot distinguishes the slots created for
+// hotplugged devices.
+func hasPathAttr(attrs interfaces.Attrer) bool {
+	_, ok := attrs.Lookup("path")
+	return ok
+}
+
+// hotplugUSBIDs returns the USB vendor and product IDs of a hotplugged
+// device, as reported in hexadecimal by udev.
+func hotplugUSBIDs(di *hotplug.HotplugDeviceInfo) (vendor, product int64, ok bool) {
+	vendorAttr, vOk := di.Attribute("ID_VENDOR_ID")
+	productAttr, pOk := di.Attribute("ID_MODEL_ID")
+	if !vOk || !pOk {
+		return 0, 0, false
+	}
+	vendor, err := strconv.ParseInt(vendorAttr, 16, 64)
+	if err != nil || vendor < 0 || vendor > 0xFFFF {
+		return 0, 0, false
+	}
+	product, err = strconv.ParseInt(productAttr, 16, 64)
+	if err != nil || product < 0 || product > 0xFFFF {
+		return 0, 0, false
+	}
+	return vendor, product, true
+}
+
+// addHotplugUSBAttrs sets the usb-vendor and usb-product attributes of a
+// proposed slot if the hotplugged device is a USB device, so that snap
+// declarations can use them to scope connection and auto-connection rules.
+func addHotplugUSBAttrs(di *hotplug.HotplugDeviceInfo, attrs map[string]any) {
+	if vendor, product, ok := hotplugUSBIDs(di); ok {
+		attrs["usb-vendor"] = vendor
+		attrs["usb-product"] = product
+	}
+}
+
+// hotplugKeyFromAttributes computes a hotplug key from the values of the
+// given udev attributes of a device, for interfaces which propose slots for
+// devices that the default key does not tell apart. An empty key is returned,
+// which causes the device to be ignored, if any of the attributes is missing.
+func hotplugKeyFromAttributes(di *hotplug.HotplugDeviceInfo, attrs ...string) snap.HotplugKey {
+	key := sha256.New()
+	for _, attr := range attrs {
+		val, ok := di.Attribute(attr)
+		if !ok || val == "" {
+			return ""
+		}
+		key.Write([]byte(attr))
+		key.Write([]byte{0})
+		key.Write([]byte(val))
+		key.Write([]byte{0})
+	}
+	return snap.HotplugKey(fmt.Sprintf("%x", key.Sum(nil)))
+}
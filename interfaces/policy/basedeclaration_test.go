@@ -703,6 +703,70 @@ plugs:
 	c.Check(err, IsNil)
 }
 
+func (s *baseDeclSuite) TestAutoConnectionHotplugSlotsOverride(c *C) {
+	// the base declaration does not auto-connect slots created for
+	// hotplugged devices, but they carry stable attributes identifying the
+	// device, which snap declarations can use to scope auto-connection to
+	// specific devices on specific models
+	const plugsSlots = `
+plugs:
+  raw-usb:
+    allow-auto-connection:
+      slot-attributes:
+        usb-vendor: 1193
+        usb-product: 6409
+      on-model:
+        - my-brand/my-model1
+  removable-media:
+    allow-auto-connection:
+      slot-attributes:
+        fs-uuid: 1234-ABCD
+`
+	snapDecl := s.mockSnapDecl(c, "plug-snap", "J60k4JY0HppjwOjW8dZdYc8obXKxujRu", "canonical", plugsSlots)
+
+	const rawUsbHotplugSlotYaml = `name: core
+version: 0
+type: os
+slots:
+  raw-usb:
+    usb-vendor: 0x04a9
+    usb-product: 0x%04x
+`
+	tests := []struct {
+		iface    string
+		slotYaml string
+		model    *asserts.Model
+		err      string // "" => no error
+	}{
+		{"raw-usb", fmt.Sprintf(rawUsbHotplugSlotYaml, 0x1909), myModel1, ""},
+		{"raw-usb", fmt.Sprintf(rawUsbHotplugSlotYaml, 0x1909), otherModel, `auto-connection not allowed by plug rule of interface "raw-usb" for "plug-snap" snap`},
+		{"raw-usb", fmt.Sprintf(rawUsbHotplugSlotYaml, 0x190a), myModel1, `auto-connection not allowed by plug rule of interface "raw-usb" for "plug-snap" snap`},
+		// the implicit slot granting access to all devices
+		{"raw-usb", "name: core\nversion: 0\ntype: os\nslots:\n  raw-usb:\n", myModel1, `auto-connection not allowed by plug rule of interface "raw-usb" for "plug-snap" snap`},
+		{"removable-media", "name: core\nversion: 0\ntype: os\nslots:\n  removable-media:\n    fs-uuid: 1234-ABCD\n    volume: BACKUP\n", otherModel, ""},
+		// another filesystem with the same label
+		{"removable-media", "name: core\nversion: 0\ntype: os\nslots:\n  removable-media:\n    fs-uuid: 5678-EF01\n    volume: BACKUP\n", otherModel, `auto-connection not allowed by plug rule of interface "removable-media" for "plug-snap" snap`},
+		{"removable-media", "name: core\nversion: 0\ntype: os\nslots:\n  removable-media:\n", otherModel, `auto-connection not allowed by plug rule of interface "removable-media" for "plug-snap" snap`},
+	}
+	for _, t := range tests {
+		cand := s.connectCand(c, t.iface, t.slotYaml, fmt.Sprintf("name: plug-snap\nversion: 0\nplugs:\n  %s:\n", t.iface))
+		cand.PlugSnapDeclaration = snapDecl
+		cand.Model = t.model
+
+		_, err := cand.CheckAutoConnect()
+		if t.err == "" {
+			c.Check(err, IsNil, Commentf("%s", t.slotYaml))
+		} else {
+			c.Check(err, ErrorMatches, t.err, Commentf("%s", t.slotYaml))
+		}
+
+		// without the snap declaration the base declaration denies it
+		cand.PlugSnapDeclaration = nil
+		_, err = cand.CheckAutoConnect()
+		c.Check(err, ErrorMatches, fmt.Sprintf(`auto-connection denied by slot rule of interface %q`, t.iface))
+	}
+}
+
 func (s *baseDeclSuite) TestAutoConnectionPackagekitControlOverride(c *C) {
 	cand := s.connectCand(c, "packagekit-control", "", "")
 	_, err := cand.CheckAutoConnect()
@@ -0,0 +1,229 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package policy
+
+import (
+	"strings"
+
+	"github.com/snapcore/snapd/asserts"
+)
+
+// Declaration identifies a declaration considered when checking a
+// connection.
+type Declaration string
+
+const (
+	PlugSnapDeclaration Declaration = "plug-snap-declaration"
+	SlotSnapDeclaration Declaration = "slot-snap-declaration"
+	BaseDeclaration     Declaration = "base-declaration"
+)
+
+// RuleStatus describes how the rule of a declaration for an interface was
+// considered when checking a connection.
+type RuleStatus string
+
+const (
+	// RuleStatusNoDeclaration indicates that there is no declaration for
+	// the snap, so the next declaration was considered.
+	RuleStatusNoDeclaration RuleStatus = "no-declaration"
+	// RuleStatusNoRule indicates that the declaration has no rule for the
+	// interface, so the next declaration was considered.
+	RuleStatusNoRule RuleStatus = "no-rule"
+	// RuleStatusEvaluated indicates that the rule was evaluated and decided
+	// the verdict.
+	RuleStatusEvaluated RuleStatus = "evaluated"
+)
+
+// Explanation describes how the declarations were evaluated to decide
+// whether a connection or auto-connection is allowed.
+type Explanation struct {
+	// Kind is either "connection" or "auto-connection".
+	Kind    string `json:"kind"`
+	Allowed bool   `json:"allowed"`
+	// Error is the error returned by Check or CheckAutoConnect, if any.
+	Error string `json:"error,omitempty"`
+	// Rules are the declaration rules considered, in order. Only the last
+	// one, if any, has been evaluated.
+	Rules []*RuleExplanation `json:"rules"`
+}
+
+// RuleExplanation describes how the rule of a declaration was considered.
+type RuleExplanation struct {
+	Declaration Declaration `json:"declaration"`
+	// Side is either "plug" or "slot".
+	Side   string     `json:"side"`
+	Status RuleStatus `json:"status"`
+	// Subrules are the deny and allow subrules of an evaluated rule, the
+	// allow one being evaluated only if the deny one did not match.
+	Subrules []*SubruleExplanation `json:"subrules,omitempty"`
+}
+
+// SubruleExplanation describes how a subrule, such as deny-auto-connection,
+// was evaluated.
+type SubruleExplanation struct {
+	Name    string `json:"name"`
+	Matched bool   `json:"matched"`
+	// Alternatives are the alternative constraints of the subrule, up to
+	// the first which matched.
+	Alternatives []*AlternativeExplanation `json:"alternatives"`
+}
+
+// AlternativeExplanation describes how one of the alternative constraints of
+// a subrule was evaluated.
+type AlternativeExplanation struct {
+	// Constraints summarizes the constraints of the alternative, as a list
+	// of the constraint names, or "true" or "false" for those which match
+	// always or never.
+	Constraints string `json:"constraints"`
+	Matched     bool   `json:"matched"`
+	// Reason tells which constraint did not match.
+	Reason string `json:"reason,omitempty"`
+}
+
+func (e *Explanation) addRule(decl Declaration, side string, status RuleStatus) {
+	if e == nil {
+		return
+	}
+	e.Rules = append(e.Rules, &RuleExplanation{
+		Declaration: decl,
+		Side:        side,
+		Status:      status,
+	})
+}
+
+func (e *Explanation) addSubrule(name string) {
+	if e == nil || len(e.Rules) == 0 {
+		return
+	}
+	rule := e.Rules[len(e.Rules)-1]
+	rule.Subrules = append(rule.Subrules, &SubruleExplanation{
+		Name:         name,
+		Alternatives: []*AlternativeExplanation{},
+	})
+}
+
+func (e *Explanation) addAlternative(constraints string, err error) {
+	if e == nil || len(e.Rules) == 0 {
+		return
+	}
+	rule := e.Rules[len(e.Rules)-1]
+	if len(rule.Subrules) == 0 {
+		return
+	}
+	subrule := rule.Subrules[len(rule.Subrules)-1]
+	alt := &AlternativeExplanation{
+		Constraints: constraints,
+		Matched:     err == nil,
+	}
+	if err != nil {
+		alt.Reason = err.Error()
+	} else {
+		subrule.Matched = true
+	}
+	subrule.Alternatives = append(subrule.Alternatives, alt)
+}
+
+type constraintsSummary []string
+
+func (s *constraintsSummary) addAttributes(name string, c *asserts.AttributeConstraints) {
+	if c != nil && c != asserts.AlwaysMatchAttributes && c != asserts.NeverMatchAttributes {
+		*s = append(*s, name)
+	}
+}
+
+func (s *constraintsSummary) addIf(name string, set bool) {
+	if set {
+		*s = append(*s, name)
+	}
+}
+
+func (s *constraintsSummary) addDeviceScope(c *asserts.DeviceScopeConstraint) {
+	if c == nil {
+		return
+	}
+	s.addIf("on-store", len(c.Store) != 0)
+	s.addIf("on-brand", len(c.Brand) != 0)
+	s.addIf("on-model", len(c.Model) != 0)
+}
+
+func (s constraintsSummary) String() string {
+	if len(s) == 0 {
+		return "true"
+	}
+	return strings.Join(s, ", ")
+}
+
+func describePlugConnectionConstraints(c *asserts.PlugConnectionConstraints) string {
+	if c.PlugAttributes == asserts.NeverMatchAttributes {
+		return "false"
+	}
+	var s constraintsSummary
+	s.addIf("plug-names", c.PlugNames != nil)
+	s.addIf("slot-names", c.SlotNames != nil)
+	s.addAttributes("plug-attributes", c.PlugAttributes)
+	s.addAttributes("slot-attributes", c.SlotAttributes)
+	s.addIf("slot-snap-type", len(c.SlotSnapTypes) != 0)
+	s.addIf("slot-snap-id", len(c.SlotSnapIDs) != 0)
+	s.addIf("slot-publisher-id", len(c.SlotPublisherIDs) != 0)
+	s.addIf("on-classic", c.OnClassic != nil)
+	s.addIf("on-core-desktop", c.OnCoreDesktop != nil)
+	s.addDeviceScope(c.DeviceScope)
+	return s.String()
+}
+
+func describeSlotConnectionConstraints(c *asserts.SlotConnectionConstraints) string {
+	if c.SlotAttributes == asserts.NeverMatchAttributes {
+		return "false"
+	}
+	var s constraintsSummary
+	s.addIf("plug-names", c.PlugNames != nil)
+	s.addIf("slot-names", c.SlotNames != nil)
+	s.addAttributes("plug-attributes", c.PlugAttributes)
+	s.addAttributes("slot-attributes", c.SlotAttributes)
+	s.addIf("slot-snap-type", len(c.SlotSnapTypes) != 0)
+	s.addIf("plug-snap-type", len(c.PlugSnapTypes) != 0)
+	s.addIf("plug-snap-id", len(c.PlugSnapIDs) != 0)
+	s.addIf("plug-publisher-id", len(c.PlugPublisherIDs) != 0)
+	s.addIf("on-classic", c.OnClassic != nil)
+	s.addIf("on-core-desktop", c.OnCoreDesktop != nil)
+	s.addDeviceScope(c.DeviceScope)
+	return s.String()
+}
+
+// Explain checks whether the connection, or the auto-connection if
+// autoConnect is set, is allowed, as Check and CheckAutoConnect do, and
+// explains how the declarations were evaluated to reach the verdict.
+func (connc *ConnectCandidate) Explain(autoConnect bool) *Explanation {
+	kind := "connection"
+	if autoConnect {
+		kind = "auto-connection"
+	}
+	explained := *connc
+	explained.explanation = &Explanation{
+		Kind:  kind,
+		Rules: []*RuleExplanation{},
+	}
+	_, err := explained.check(kind)
+	explained.explanation.Allowed = err == nil
+	if err != nil {
+		explained.explanation.Error = err.Error()
+	}
+	return explained.explanation
+}
@@ -0,0 +1,99 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package policy_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces/policy"
+)
+
+func (s *baseDeclSuite) TestExplainBaseDeclaration(c *C) {
+	cand := s.connectCand(c, "raw-usb", "name: core\nversion: 0\ntype: os\nslots:\n  raw-usb:\n", "")
+
+	explanation := cand.Explain(true)
+	c.Check(explanation, DeepEquals, &policy.Explanation{
+		Kind:    "auto-connection",
+		Allowed: false,
+		Error:   `auto-connection denied by slot rule of interface "raw-usb"`,
+		Rules: []*policy.RuleExplanation{
+			{Declaration: policy.PlugSnapDeclaration, Side: "plug", Status: policy.RuleStatusNoDeclaration},
+			{Declaration: policy.SlotSnapDeclaration, Side: "slot", Status: policy.RuleStatusNoDeclaration},
+			{Declaration: policy.BaseDeclaration, Side: "plug", Status: policy.RuleStatusNoRule},
+			{Declaration: policy.BaseDeclaration, Side: "slot", Status: policy.RuleStatusEvaluated, Subrules: []*policy.SubruleExplanation{
+				{Name: "deny-auto-connection", Matched: true, Alternatives: []*policy.AlternativeExplanation{
+					{Constraints: "true", Matched: true},
+				}},
+			}},
+		},
+	})
+	_, err := cand.CheckAutoConnect()
+	c.Check(err, ErrorMatches, explanation.Error)
+
+	explanation = cand.Explain(false)
+	c.Check(explanation.Kind, Equals, "connection")
+	c.Check(explanation.Allowed, Equals, true)
+	c.Check(explanation.Error, Equals, "")
+	c.Assert(explanation.Rules, HasLen, 4)
+	c.Check(explanation.Rules[3].Subrules, DeepEquals, []*policy.SubruleExplanation{
+		{Name: "deny-connection", Matched: false, Alternatives: []*policy.AlternativeExplanation{
+			{Constraints: "false", Matched: false, Reason: "not allowed"},
+		}},
+		{Name: "allow-connection", Matched: true, Alternatives: []*policy.AlternativeExplanation{
+			{Constraints: "true", Matched: true},
+		}},
+	})
+	c.Check(cand.Check(), IsNil)
+}
+
+func (s *baseDeclSuite) TestExplainSnapDeclaration(c *C) {
+	cand := s.connectCand(c, "raw-usb", "name: core\nversion: 0\ntype: os\nslots:\n  raw-usb:\n    usb-vendor: 0x04a9\n    usb-product: 0x190a\n", "")
+	cand.PlugSnapDeclaration = s.mockSnapDecl(c, "plug-snap", "J60k4JY0HppjwOjW8dZdYc8obXKxujRu", "canonical", `
+plugs:
+  raw-usb:
+    allow-auto-connection:
+      -
+        slot-attributes:
+          usb-vendor: 1193
+          usb-product: 6409
+        on-model:
+          - my-brand/my-model1
+      -
+        slot-attributes:
+          usb-vendor: 1193
+          usb-product: 6410
+`)
+	cand.Model = myModel1
+
+	explanation := cand.Explain(true)
+	c.Check(explanation.Allowed, Equals, true)
+	c.Check(explanation.Error, Equals, "")
+	c.Check(explanation.Rules, DeepEquals, []*policy.RuleExplanation{
+		{Declaration: policy.PlugSnapDeclaration, Side: "plug", Status: policy.RuleStatusEvaluated, Subrules: []*policy.SubruleExplanation{
+			{Name: "deny-auto-connection", Matched: false, Alternatives: []*policy.AlternativeExplanation{
+				{Constraints: "false", Matched: false, Reason: "not allowed"},
+			}},
+			{Name: "allow-auto-connection", Matched: true, Alternatives: []*policy.AlternativeExplanation{
+				{Constraints: "slot-attributes, on-model", Matched: false, Reason: `attribute "usb-product" value "6410" does not match ^(6409)$`},
+				{Constraints: "slot-attributes", Matched: true},
+			}},
+		}},
+	})
+}
@@ -156,6 +156,9 @@ func checkPlugConnectionAltConstraints(connc *ConnectCandidate, altConstraints [
 	// OR of constraints
 	for _, constraints := range altConstraints {
 		err := checkPlugConnectionConstraints1(connc, constraints)
+		if connc.explanation != nil {
+			connc.explanation.addAlternative(describePlugConnectionConstraints(constraints), err)
+		}
 		if err == nil {
 			return constraints, nil
 		}
@@ -212,6 +215,9 @@ func checkSlotConnectionAltConstraints(connc *ConnectCandidate, altConstraints [
 	// OR of constraints
 	for _, constraints := range altConstraints {
 		err := checkSlotConnectionConstraints1(connc, constraints)
+		if connc.explanation != nil {
+			connc.explanation.addAlternative(describeSlotConnectionConstraints(constraints), err)
+		}
 		if err == nil {
 			return constraints, nil
 		}
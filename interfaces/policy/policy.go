@@ -140,6 +140,9 @@ type ConnectCandidate struct {
 
 	// Are compatibility labels enabled?
 	CompatEnabled bool
+
+	// explanation, if set, records how the declarations are evaluated.
+	explanation *Explanation
 }
 
 func nestedGet(which string, attrs interfaces.Attrer, path string) (any, error) {
@@ -201,10 +204,12 @@ func (connc *ConnectCandidate) checkPlugRule(kind string, rule *asserts.PlugRule
 		denyConst = rule.DenyAutoConnection
 		allowConst = rule.AllowAutoConnection
 	}
+	connc.explanation.addSubrule("deny-" + kind)
 	if _, err := checkPlugConnectionAltConstraints(connc, denyConst); err == nil {
 		return nil, fmt.Errorf("%s denied by plug rule of interface %q%s", kind, connc.Plug.Interface(), context)
 	}
 
+	connc.explanation.addSubrule("allow-" + kind)
 	allowedConstraints, err := checkPlugConnectionAltConstraints(connc, allowConst)
 	if err != nil {
 		return nil, fmt.Errorf("%s not allowed by plug rule of interface %q%s", kind, connc.Plug.Interface(), context)
@@ -223,10 +228,12 @@ func (connc *ConnectCandidate) checkSlotRule(kind string, rule *asserts.SlotRule
 		denyConst = rule.DenyAutoConnection
 		allowConst = rule.AllowAutoConnection
 	}
+	connc.explanation.addSubrule("deny-" + kind)
 	if _, err := checkSlotConnectionAltConstraints(connc, denyConst); err == nil {
 		return nil, fmt.Errorf("%s denied by slot rule of interface %q%s", kind, connc.Plug.Interface(), context)
 	}
 
+	connc.explanation.addSubrule("allow-" + kind)
 	allowedConstraints, err := checkSlotConnectionAltConstraints(connc, allowConst)
 	if err != nil {
 		return nil, fmt.Errorf("%s not allowed by slot rule of interface %q%s", kind, connc.Plug.Interface(), context)
@@ -248,20 +255,32 @@ func (connc *ConnectCandidate) check(kind string) (interfaces.SideArity, error)
 
 	if plugDecl := connc.PlugSnapDeclaration; plugDecl != nil {
 		if rule := plugDecl.PlugRule(iface); rule != nil {
+			connc.explanation.addRule(PlugSnapDeclaration, "plug", RuleStatusEvaluated)
 			return connc.checkPlugRule(kind, rule, true)
 		}
+		connc.explanation.addRule(PlugSnapDeclaration, "plug", RuleStatusNoRule)
+	} else {
+		connc.explanation.addRule(PlugSnapDeclaration, "plug", RuleStatusNoDeclaration)
 	}
 	if slotDecl := connc.SlotSnapDeclaration; slotDecl != nil {
 		if rule := slotDecl.SlotRule(iface); rule != nil {
+			connc.explanation.addRule(SlotSnapDeclaration, "slot", RuleStatusEvaluated)
 			return connc.checkSlotRule(kind, rule, true)
 		}
+		connc.explanation.addRule(SlotSnapDeclaration, "slot", RuleStatusNoRule)
+	} else {
+		connc.explanation.addRule(SlotSnapDeclaration, "slot", RuleStatusNoDeclaration)
 	}
 	if rule := baseDecl.PlugRule(iface); rule != nil {
+		connc.explanation.addRule(BaseDeclaration, "plug", RuleStatusEvaluated)
 		return connc.checkPlugRule(kind, rule, false)
 	}
+	connc.explanation.addRule(BaseDeclaration, "plug", RuleStatusNoRule)
 	if rule := baseDecl.SlotRule(iface); rule != nil {
+		connc.explanation.addRule(BaseDeclaration, "slot", RuleStatusEvaluated)
 		return connc.checkSlotRule(kind, rule, false)
 	}
+	connc.explanation.addRule(BaseDeclaration, "slot", RuleStatusNoRule)
 	return nil, nil
 }
 
@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
@@ -77,6 +78,12 @@ func parseInterfaceSpecificConstraints(iface string, constraintsJSON Constraints
 		interfaceSpecific = &InterfaceSpecificConstraintsHome{}
 	case "camera":
 		interfaceSpecific = &InterfaceSpecificConstraintsCamera{}
+	case "removable-media":
+		interfaceSpecific = &InterfaceSpecificConstraintsRemovableMedia{}
+	case "personal-files":
+		interfaceSpecific = &InterfaceSpecificConstraintsPersonalFiles{}
+	case "audio-record":
+		interfaceSpecific = &InterfaceSpecificConstraintsAudioRecord{}
 	default:
 		return nil, prompting_errors.NewInvalidInterfaceError(iface, availableInterfaces())
 	}
@@ -92,47 +99,78 @@ func parseInterfaceSpecificConstraints(iface string, constraintsJSON Constraints
 	return interfaceSpecific, nil
 }
 
+// parsePathPatternJSON parses the "path-pattern" field of the given
+// constraints. If isPatch is true, the field may be omitted, in which case
+// the returned path pattern is nil.
+func parsePathPatternJSON(constraintsJSON ConstraintsJSON, isPatch bool) (*patterns.PathPattern, error) {
+	pathPatternJSON, ok := constraintsJSON["path-pattern"]
+	if isPatch && (!ok || pathPatternJSON == nil) {
+		return nil, nil
+	}
+	if !ok {
+		return nil, prompting_errors.NewInvalidPathPatternError("", "no path pattern")
+	}
+	var pathPattern patterns.PathPattern
+	if err := pathPattern.UnmarshalJSON(pathPatternJSON); err != nil {
+		return nil, err
+	}
+	return &pathPattern, nil
+}
+
+// pathPatternToJSON returns a ConstraintsJSON holding the given path pattern.
+func pathPatternToJSON(pathPattern *patterns.PathPattern) (ConstraintsJSON, error) {
+	constraintsJSON := make(ConstraintsJSON)
+	pathPatternJSON, err := json.Marshal(pathPattern)
+	if err != nil {
+		return nil, err
+	}
+	constraintsJSON["path-pattern"] = pathPatternJSON
+	return constraintsJSON, nil
+}
+
+// PathPatternWithin returns true if every variant of the given path pattern
+// is one of the given directories or lies beneath one of them.
+func PathPatternWithin(pathPattern *patterns.PathPattern, dirs []string) bool {
+	within := true
+	pathPattern.RenderAllVariants(func(index int, variant patterns.PatternVariant) {
+		v := variant.String()
+		for _, dir := range dirs {
+			dir = strings.TrimSuffix(dir, "/")
+			if v == dir || strings.HasPrefix(v, dir+"/") {
+				return
+			}
+		}
+		within = false
+	})
+	return within
+}
+
 type InterfaceSpecificConstraintsHome struct {
 	Pattern *patterns.PathPattern
 }
 
 func (constraints *InterfaceSpecificConstraintsHome) parseJSON(constraintsJSON ConstraintsJSON) error {
 	// Expect fields: "path-pattern"
-	pathPatternJSON, ok := constraintsJSON["path-pattern"]
-	if !ok {
-		return prompting_errors.NewInvalidPathPatternError("", "no path pattern")
-	}
-	var pathPattern patterns.PathPattern
-	if err := pathPattern.UnmarshalJSON(pathPatternJSON); err != nil {
+	pathPattern, err := parsePathPatternJSON(constraintsJSON, false)
+	if err != nil {
 		return err
 	}
-	constraints.Pattern = &pathPattern
+	constraints.Pattern = pathPattern
 	return nil
 }
 
 func (constraints *InterfaceSpecificConstraintsHome) parsePatchJSON(constraintsJSON ConstraintsJSON) error {
 	// Optional fields: "path-pattern"
-	pathPatternJSON, ok := constraintsJSON["path-pattern"]
-	if !ok || pathPatternJSON == nil {
-		constraints.Pattern = nil
-		return nil
-	}
-	var pathPattern patterns.PathPattern
-	if err := pathPattern.UnmarshalJSON(pathPatternJSON); err != nil {
+	pathPattern, err := parsePathPatternJSON(constraintsJSON, true)
+	if err != nil {
 		return err
 	}
-	constraints.Pattern = &pathPattern
+	constraints.Pattern = pathPattern
 	return nil
 }
 
 func (constraints *InterfaceSpecificConstraintsHome) toJSON() (ConstraintsJSON, error) {
-	constraintsJSON := make(ConstraintsJSON)
-	pathPatternJSON, err := json.Marshal(constraints.Pattern)
-	if err != nil {
-		return nil, err
-	}
-	constraintsJSON["path-pattern"] = pathPatternJSON
-	return constraintsJSON, nil
+	return pathPatternToJSON(constraints.Pattern)
 }
 
 func (constraints *InterfaceSpecificConstraintsHome) pathPattern() *patterns.PathPattern {
@@ -183,6 +221,137 @@ func (constraints *InterfaceSpecificConstraintsCamera) patch(existing InterfaceS
 	return &InterfaceSpecificConstraintsCamera{}
 }
 
+// removableMediaDirs are the directories under which removable media is
+// mounted, and to which removable-media path patterns are limited.
+var removableMediaDirs = []string{"/media", "/run/media", "/mnt"}
+
+// InterfaceSpecificConstraintsRemovableMedia hold a path pattern which must
+// lie within the directories where removable media is mounted.
+type InterfaceSpecificConstraintsRemovableMedia struct {
+	Pattern *patterns.PathPattern
+}
+
+func (constraints *InterfaceSpecificConstraintsRemovableMedia) validate() error {
+	if constraints.Pattern != nil && !PathPatternWithin(constraints.Pattern, removableMediaDirs) {
+		return prompting_errors.NewInvalidPathPatternError(constraints.Pattern.String(), "removable-media path pattern must be within /media, /run/media or /mnt")
+	}
+	return nil
+}
+
+func (constraints *InterfaceSpecificConstraintsRemovableMedia) parseJSON(constraintsJSON ConstraintsJSON) error {
+	// Expect fields: "path-pattern"
+	pathPattern, err := parsePathPatternJSON(constraintsJSON, false)
+	if err != nil {
+		return err
+	}
+	constraints.Pattern = pathPattern
+	return constraints.validate()
+}
+
+func (constraints *InterfaceSpecificConstraintsRemovableMedia) parsePatchJSON(constraintsJSON ConstraintsJSON) error {
+	// Optional fields: "path-pattern"
+	pathPattern, err := parsePathPatternJSON(constraintsJSON, true)
+	if err != nil {
+		return err
+	}
+	constraints.Pattern = pathPattern
+	return constraints.validate()
+}
+
+func (constraints *InterfaceSpecificConstraintsRemovableMedia) toJSON() (ConstraintsJSON, error) {
+	return pathPatternToJSON(constraints.Pattern)
+}
+
+func (constraints *InterfaceSpecificConstraintsRemovableMedia) pathPattern() *patterns.PathPattern {
+	return constraints.Pattern
+}
+
+func (constraints *InterfaceSpecificConstraintsRemovableMedia) patch(existing InterfaceSpecificConstraints) InterfaceSpecificConstraints {
+	newConstraints := &InterfaceSpecificConstraintsRemovableMedia{}
+	if existingRemovableMedia, ok := existing.(*InterfaceSpecificConstraintsRemovableMedia); ok {
+		newConstraints.Pattern = existingRemovableMedia.Pattern
+	}
+	if constraints != nil && constraints.Pattern != nil {
+		newConstraints.Pattern = constraints.Pattern
+	}
+	return newConstraints
+}
+
+// InterfaceSpecificConstraintsPersonalFiles hold a path pattern which must lie
+// within the paths declared by the personal-files plugs of the snap. As those
+// depend on the snap, they are checked by the caller using PathPatternWithin.
+type InterfaceSpecificConstraintsPersonalFiles struct {
+	Pattern *patterns.PathPattern
+}
+
+func (constraints *InterfaceSpecificConstraintsPersonalFiles) parseJSON(constraintsJSON ConstraintsJSON) error {
+	// Expect fields: "path-pattern"
+	pathPattern, err := parsePathPatternJSON(constraintsJSON, false)
+	if err != nil {
+		return err
+	}
+	constraints.Pattern = pathPattern
+	return nil
+}
+
+func (constraints *InterfaceSpecificConstraintsPersonalFiles) parsePatchJSON(constraintsJSON ConstraintsJSON) error {
+	// Optional fields: "path-pattern"
+	pathPattern, err := parsePathPatternJSON(constraintsJSON, true)
+	if err != nil {
+		return err
+	}
+	constraints.Pattern = pathPattern
+	return nil
+}
+
+func (constraints *InterfaceSpecificConstraintsPersonalFiles) toJSON() (ConstraintsJSON, error) {
+	return pathPatternToJSON(constraints.Pattern)
+}
+
+func (constraints *InterfaceSpecificConstraintsPersonalFiles) pathPattern() *patterns.PathPattern {
+	return constraints.Pattern
+}
+
+func (constraints *InterfaceSpecificConstraintsPersonalFiles) patch(existing InterfaceSpecificConstraints) InterfaceSpecificConstraints {
+	newConstraints := &InterfaceSpecificConstraintsPersonalFiles{}
+	if existingPersonalFiles, ok := existing.(*InterfaceSpecificConstraintsPersonalFiles); ok {
+		newConstraints.Pattern = existingPersonalFiles.Pattern
+	}
+	if constraints != nil && constraints.Pattern != nil {
+		newConstraints.Pattern = constraints.Pattern
+	}
+	return newConstraints
+}
+
+// InterfaceSpecificConstraintsAudioRecord don't have any fields. All
+// audio-record prompts, replies, and rules concern access to all audio capture
+// devices.
+type InterfaceSpecificConstraintsAudioRecord struct{}
+
+func (constraints *InterfaceSpecificConstraintsAudioRecord) parseJSON(constraintsJSON ConstraintsJSON) error {
+	// Don't expect any fields
+	return nil
+}
+
+func (constraints *InterfaceSpecificConstraintsAudioRecord) parsePatchJSON(constraintsJSON ConstraintsJSON) error {
+	// Don't expect any fields
+	return nil
+}
+
+func (constraints *InterfaceSpecificConstraintsAudioRecord) toJSON() (ConstraintsJSON, error) {
+	return make(ConstraintsJSON), nil
+}
+
+func (constraints *InterfaceSpecificConstraintsAudioRecord) pathPattern() *patterns.PathPattern {
+	pathPattern, _ := patterns.ParsePathPattern("/**")
+	// Error cannot occur, this is a known good pattern.
+	return pathPattern
+}
+
+func (constraints *InterfaceSpecificConstraintsAudioRecord) patch(existing InterfaceSpecificConstraints) InterfaceSpecificConstraints {
+	return &InterfaceSpecificConstraintsAudioRecord{}
+}
+
 // Constraints hold information about the applicability of a new rule to
 // particular requests and permissions. When creating a new rule, snapd
 // converts Constraints to RuleConstraints.
@@ -871,8 +1040,11 @@ var (
 	// List of permissions available for each interface. This also defines the
 	// order in which the permissions should be presented.
 	interfacePermissionsAvailable = map[string][]string{
-		"home":   {"read", "write", "execute"},
-		"camera": {"access"},
+		"home":            {"read", "write", "execute"},
+		"camera":          {"access"},
+		"removable-media": {"read", "write", "execute"},
+		"personal-files":  {"read", "write"},
+		"audio-record":    {"access"},
 	}
 
 	// A mapping from interfaces which support AppArmor file permissions to
@@ -890,6 +1062,18 @@ var (
 		"camera": {
 			"access": notify.AA_MAY_READ | notify.AA_MAY_GETATTR | notify.AA_MAY_WRITE | notify.AA_MAY_APPEND,
 		},
+		"removable-media": {
+			"read":    notify.AA_MAY_READ | notify.AA_MAY_GETATTR,
+			"write":   notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_CREATE | notify.AA_MAY_DELETE | notify.AA_MAY_RENAME | notify.AA_MAY_SETATTR | notify.AA_MAY_CHMOD | notify.AA_MAY_LOCK | notify.AA_MAY_LINK,
+			"execute": notify.AA_MAY_EXEC | notify.AA_EXEC_MMAP,
+		},
+		"personal-files": {
+			"read":  notify.AA_MAY_READ | notify.AA_MAY_GETATTR,
+			"write": notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_CREATE | notify.AA_MAY_DELETE | notify.AA_MAY_RENAME | notify.AA_MAY_SETATTR | notify.AA_MAY_CHMOD | notify.AA_MAY_LOCK | notify.AA_MAY_LINK,
+		},
+		"audio-record": {
+			"access": notify.AA_MAY_READ | notify.AA_MAY_GETATTR | notify.AA_MAY_WRITE | notify.AA_MAY_APPEND,
+		},
 	}
 )
 
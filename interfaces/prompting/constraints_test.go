@@ -123,6 +123,67 @@ func (s *constraintsSuite) TestParseInterfaceSpecificConstraintsHappy(c *C) {
 			expected:            &prompting.InterfaceSpecificConstraintsCamera{},
 			expectedPathPattern: mustParsePathPattern(c, "/**"),
 		},
+		{
+			iface: "removable-media",
+			constraintsJSON: prompting.ConstraintsJSON{
+				"path-pattern": json.RawMessage(`"/media/test/usb/**"`),
+			},
+			isPatch: false,
+			expected: &prompting.InterfaceSpecificConstraintsRemovableMedia{
+				Pattern: mustParsePathPattern(c, "/media/test/usb/**"),
+			},
+			expectedPathPattern: mustParsePathPattern(c, "/media/test/usb/**"),
+		},
+		{
+			iface: "removable-media",
+			constraintsJSON: prompting.ConstraintsJSON{
+				"path-pattern": json.RawMessage(`"/{mnt,run/media}/*/photos/**"`),
+			},
+			isPatch: false,
+			expected: &prompting.InterfaceSpecificConstraintsRemovableMedia{
+				Pattern: mustParsePathPattern(c, "/{mnt,run/media}/*/photos/**"),
+			},
+			expectedPathPattern: mustParsePathPattern(c, "/{mnt,run/media}/*/photos/**"),
+		},
+		{
+			iface:               "removable-media",
+			constraintsJSON:     prompting.ConstraintsJSON{},
+			isPatch:             true,
+			expected:            &prompting.InterfaceSpecificConstraintsRemovableMedia{},
+			expectedPathPattern: nil,
+		},
+		{
+			iface: "personal-files",
+			constraintsJSON: prompting.ConstraintsJSON{
+				"path-pattern": json.RawMessage(`"/home/test/.config/foo/**"`),
+			},
+			isPatch: false,
+			expected: &prompting.InterfaceSpecificConstraintsPersonalFiles{
+				Pattern: mustParsePathPattern(c, "/home/test/.config/foo/**"),
+			},
+			expectedPathPattern: mustParsePathPattern(c, "/home/test/.config/foo/**"),
+		},
+		{
+			iface:               "personal-files",
+			constraintsJSON:     prompting.ConstraintsJSON{},
+			isPatch:             true,
+			expected:            &prompting.InterfaceSpecificConstraintsPersonalFiles{},
+			expectedPathPattern: nil,
+		},
+		{
+			iface:               "audio-record",
+			constraintsJSON:     prompting.ConstraintsJSON{},
+			isPatch:             false,
+			expected:            &prompting.InterfaceSpecificConstraintsAudioRecord{},
+			expectedPathPattern: mustParsePathPattern(c, "/**"),
+		},
+		{
+			iface:               "audio-record",
+			constraintsJSON:     prompting.ConstraintsJSON{"foo": json.RawMessage(`"bar"`)},
+			isPatch:             true,
+			expected:            &prompting.InterfaceSpecificConstraintsAudioRecord{},
+			expectedPathPattern: mustParsePathPattern(c, "/**"),
+		},
 	} {
 		result, err := prompting.ParseInterfaceSpecificConstraints(testCase.iface, testCase.constraintsJSON, testCase.isPatch)
 		c.Check(err, IsNil, Commentf("testCase: %+v", testCase))
@@ -160,6 +221,36 @@ func (s *constraintsSuite) TestParseInterfaceSpecificConstraintsUnhappy(c *C) {
 			isPatch:     false,
 			expectedErr: `invalid path pattern: pattern must start with '/': "invalid-pattern"`,
 		},
+		{
+			iface: "removable-media",
+			constraintsJSON: prompting.ConstraintsJSON{
+				"path-pattern": json.RawMessage(`"/home/test/foo"`),
+			},
+			isPatch:     false,
+			expectedErr: `invalid path pattern: removable-media path pattern must be within /media, /run/media or /mnt: "/home/test/foo"`,
+		},
+		{
+			iface: "removable-media",
+			constraintsJSON: prompting.ConstraintsJSON{
+				"path-pattern": json.RawMessage(`"/{media,home}/test/**"`),
+			},
+			isPatch:     true,
+			expectedErr: `invalid path pattern: removable-media path pattern must be within /media, /run/media or /mnt: "/{media,home}/test/\*\*"`,
+		},
+		{
+			iface: "removable-media",
+			constraintsJSON: prompting.ConstraintsJSON{
+				"path-pattern": json.RawMessage(`"/mediafoo/bar"`),
+			},
+			isPatch:     false,
+			expectedErr: `invalid path pattern: removable-media path pattern must be within /media, /run/media or /mnt: "/mediafoo/bar"`,
+		},
+		{
+			iface:           "personal-files",
+			constraintsJSON: prompting.ConstraintsJSON{},
+			isPatch:         false,
+			expectedErr:     `invalid path pattern: no path pattern: ""`,
+		},
 	} {
 		result, err := prompting.ParseInterfaceSpecificConstraints(testCase.iface, testCase.constraintsJSON, testCase.isPatch)
 		c.Check(result, IsNil, Commentf("testCase: %+v", testCase))
@@ -167,6 +258,29 @@ func (s *constraintsSuite) TestParseInterfaceSpecificConstraintsUnhappy(c *C) {
 	}
 }
 
+func (s *constraintsSuite) TestPathPatternWithin(c *C) {
+	dirs := []string{"/media", "/run/media/", "/mnt"}
+	for _, testCase := range []struct {
+		pattern  string
+		expected bool
+	}{
+		{"/media", true},
+		{"/media/test/foo", true},
+		{"/run/media/test/**", true},
+		{"/mnt/**/*.txt", true},
+		{"/{media,mnt}/*/foo", true},
+		{"/media{,/test}/**", true},
+		{"/mediafoo", false},
+		{"/med*/foo", false},
+		{"/**", false},
+		{"/{media,home}/test", false},
+		{"/run/**", false},
+	} {
+		pathPattern := mustParsePathPattern(c, testCase.pattern)
+		c.Check(prompting.PathPatternWithin(pathPattern, dirs), Equals, testCase.expected, Commentf("pattern: %s", testCase.pattern))
+	}
+}
+
 func (s *constraintsSuite) TestUnmarshalConstraintsHappy(c *C) {
 	for _, testCase := range []struct {
 		iface           string
@@ -39,6 +39,7 @@ var (
 	// Validation errors which may be returned over the API
 	ErrPatchedRuleHasNoPerms   = errors.New("cannot patch rule to have no permissions")
 	ErrNewSessionRuleNoSession = errors.New(`cannot create rule with lifespan "session" when user session is not present`)
+	ErrRuleAdminManaged        = errors.New("cannot modify or remove rule managed by the system administrator")
 
 	// Validation errors which should never be used directly apart from
 	// checking errors.Is(), and should otherwise always be wrapped in
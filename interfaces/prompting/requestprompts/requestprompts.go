@@ -206,7 +206,8 @@ type promptConstraints struct {
 }
 
 // promptConstraintsJSONHome defines the marshalled json structure of
-// promptConstraints for the home interface.
+// promptConstraints for the home interface, and the other interfaces whose
+// prompts concern a particular path: removable-media and personal-files.
 type promptConstraintsJSONHome struct {
 	Path                 string   `json:"path"`
 	RequestedPermissions []string `json:"requested-permissions"`
@@ -214,7 +215,8 @@ type promptConstraintsJSONHome struct {
 }
 
 // promptConstraintsJSONCamera defines the marshalled json structure of
-// promptConstraints for the camera interface.
+// promptConstraints for the camera interface, and the other interfaces whose
+// prompts concern access to devices as a whole: audio-record.
 type promptConstraintsJSONCamera struct {
 	RequestedPermissions []string `json:"requested-permissions"`
 	AvailablePermissions []string `json:"available-permissions"`
@@ -228,14 +230,14 @@ func (pc *promptConstraints) MarshalJSON() ([]byte, error) {
 // corresponding to the given interface.
 func (pc *promptConstraints) marshalForInterface(iface string) ([]byte, error) {
 	switch iface {
-	case "home":
+	case "home", "removable-media", "personal-files":
 		constraintsJSON := &promptConstraintsJSONHome{
 			Path:                 pc.path,
 			RequestedPermissions: pc.outstandingPermissions,
 			AvailablePermissions: pc.availablePermissions,
 		}
 		return json.Marshal(constraintsJSON)
-	case "camera":
+	case "camera", "audio-record":
 		constraintsJSON := &promptConstraintsJSONCamera{
 			RequestedPermissions: pc.outstandingPermissions,
 			AvailablePermissions: pc.availablePermissions,
@@ -1762,6 +1762,45 @@ func (s *requestpromptsSuite) TestPromptMarshalJSON(c *C) {
 			outstandingPerms: []string{"access"},
 			expected:         `{"id":"0000000000000002","timestamp":"2024-08-14T09:47:03.350324989-05:00","snap":"thunderbird","pid":112358,"cgroup":"0::/user.slice/user-1000.slice/user@1000.service/app.slice/some-cgroup.scope","interface":"camera","constraints":{"requested-permissions":["access"],"available-permissions":["access"]}}`,
 		},
+		{
+			metadata: &prompting.Metadata{
+				User:      s.defaultUser,
+				Snap:      "nautilus",
+				PID:       4321,
+				Cgroup:    "0::/user.slice/user-1000.slice/user@1000.service/app.slice/some-cgroup.scope",
+				Interface: "removable-media",
+			},
+			path:             "/media/test/usb/foo",
+			requestedPerms:   []string{"read", "write"},
+			outstandingPerms: []string{"read", "write"},
+			expected:         `{"id":"0000000000000003","timestamp":"2024-08-14T09:47:03.350324989-05:00","snap":"nautilus","pid":4321,"cgroup":"0::/user.slice/user-1000.slice/user@1000.service/app.slice/some-cgroup.scope","interface":"removable-media","constraints":{"path":"/media/test/usb/foo","requested-permissions":["read","write"],"available-permissions":["read","write","execute"]}}`,
+		},
+		{
+			metadata: &prompting.Metadata{
+				User:      s.defaultUser,
+				Snap:      "firefox",
+				PID:       5678,
+				Cgroup:    "0::/user.slice/user-1000.slice/user@1000.service/app.slice/some-cgroup.scope",
+				Interface: "personal-files",
+			},
+			path:             "/home/test/.mozilla/firefox/profiles.ini",
+			requestedPerms:   []string{"read"},
+			outstandingPerms: []string{"read"},
+			expected:         `{"id":"0000000000000004","timestamp":"2024-08-14T09:47:03.350324989-05:00","snap":"firefox","pid":5678,"cgroup":"0::/user.slice/user-1000.slice/user@1000.service/app.slice/some-cgroup.scope","interface":"personal-files","constraints":{"path":"/home/test/.mozilla/firefox/profiles.ini","requested-permissions":["read"],"available-permissions":["read","write"]}}`,
+		},
+		{
+			metadata: &prompting.Metadata{
+				User:      s.defaultUser,
+				Snap:      "audacity",
+				PID:       8765,
+				Cgroup:    "0::/user.slice/user-1000.slice/user@1000.service/app.slice/some-cgroup.scope",
+				Interface: "audio-record",
+			},
+			path:             "/dev/snd/pcmC0D0c",
+			requestedPerms:   []string{"access"},
+			outstandingPerms: []string{"access"},
+			expected:         `{"id":"0000000000000005","timestamp":"2024-08-14T09:47:03.350324989-05:00","snap":"audacity","pid":8765,"cgroup":"0::/user.slice/user-1000.slice/user@1000.service/app.slice/some-cgroup.scope","interface":"audio-record","constraints":{"requested-permissions":["access"],"available-permissions":["access"]}}`,
+		},
 	} {
 		fakeRequest := listener.Request{
 			ID: 0x1234,
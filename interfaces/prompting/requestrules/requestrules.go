@@ -26,6 +26,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -53,6 +54,11 @@ var (
 	errNoUserSession = errors.New("cannot find systemd user session tmpfs for user")
 )
 
+// AdminRulesUser is the user associated with rules provisioned by the system
+// administrator, which apply to all users. It corresponds to (uid_t)-1, which
+// is never the ID of an actual user.
+const AdminRulesUser uint32 = math.MaxUint32
+
 // Rule stores the contents of a request rule.
 type Rule struct {
 	ID          prompting.IDType           `json:"id"`
@@ -61,16 +67,21 @@ type Rule struct {
 	Snap        string                     `json:"snap"`
 	Interface   string                     `json:"interface"`
 	Constraints *prompting.RuleConstraints `json:"constraints"`
+	// AdminManaged is set if the rule was provisioned by the system
+	// administrator. Such rules have AdminRulesUser as their user, apply to
+	// all users, and cannot be modified or removed through the API.
+	AdminManaged bool `json:"admin-managed,omitempty"`
 }
 
 func (rule *Rule) UnmarshalJSON(data []byte) error {
 	type ruleJSON struct {
-		ID          prompting.IDType          `json:"id"`
-		Timestamp   time.Time                 `json:"timestamp"`
-		User        uint32                    `json:"user"`
-		Snap        string                    `json:"snap"`
-		Interface   string                    `json:"interface"`
-		Constraints prompting.ConstraintsJSON `json:"constraints"`
+		ID           prompting.IDType          `json:"id"`
+		Timestamp    time.Time                 `json:"timestamp"`
+		User         uint32                    `json:"user"`
+		Snap         string                    `json:"snap"`
+		Interface    string                    `json:"interface"`
+		Constraints  prompting.ConstraintsJSON `json:"constraints"`
+		AdminManaged bool                      `json:"admin-managed,omitempty"`
 	}
 	var intermediate ruleJSON
 	if err := json.Unmarshal(data, &intermediate); err != nil {
@@ -86,9 +97,16 @@ func (rule *Rule) UnmarshalJSON(data []byte) error {
 	rule.Snap = intermediate.Snap
 	rule.Interface = intermediate.Interface
 	rule.Constraints = constraints
+	rule.AdminManaged = intermediate.AdminManaged
 	return nil
 }
 
+// appliesToUser returns true if the rule was created by the given user or was
+// provisioned by the system administrator.
+func (rule *Rule) appliesToUser(user uint32) bool {
+	return rule.User == user || rule.AdminManaged
+}
+
 // Validate verifies internal correctness of the rule's constraints and
 // permissions and prunes any expired permissions. Returns a
 // [prompting.PermExpirationStatus] indicating whether all, any, or no
@@ -216,9 +234,95 @@ func New(notifyRule func(userID uint32, ruleID prompting.IDType, data map[string
 	if err = rdb.load(); err != nil {
 		logger.Noticef("cannot load rule database: %v; using new empty rule database", err)
 	}
+	rdb.loadAdminRules()
 	return rdb, nil
 }
 
+// adminRuleJSON is the format of a rule provisioned by the system
+// administrator. The constraints are those which would be given when adding
+// the rule over the API.
+type adminRuleJSON struct {
+	Snap        string                    `json:"snap"`
+	Interface   string                    `json:"interface"`
+	Constraints prompting.ConstraintsJSON `json:"constraints"`
+}
+
+// adminRulesFileJSON is the format of the files in the admin rules directory.
+type adminRulesFileJSON struct {
+	Rules []*adminRuleJSON `json:"rules"`
+}
+
+// loadAdminRules adds the rules provisioned by the system administrator in the
+// JSON files of the admin rules directory to the rule database.
+//
+// Admin rules are never saved to the rule database file, since they are read
+// again from the admin rules directory whenever the rule database is created.
+// Rules which are invalid, have permissions whose lifespan is not "forever",
+// or conflict with other admin rules are skipped.
+func (rdb *RuleDB) loadAdminRules() {
+	paths, err := filepath.Glob(filepath.Join(dirs.SnapInterfacesRequestsAdminRulesDir, "*.json"))
+	if err != nil {
+		// Only ErrBadPattern, which cannot occur
+		return
+	}
+	at := prompting.At{
+		Time: time.Now(),
+		// Admin rules do not have lifespan "session", so no session ID
+	}
+	for _, path := range paths {
+		if err := rdb.loadAdminRulesFile(path, at); err != nil {
+			logger.Noticef("cannot load admin prompting rules from %s: %v", path, err)
+		}
+	}
+}
+
+func (rdb *RuleDB) loadAdminRulesFile(path string, at prompting.At) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var wrapped adminRulesFileJSON
+	if err := json.NewDecoder(f).Decode(&wrapped); err != nil {
+		return err
+	}
+	for i, adminRule := range wrapped.Rules {
+		if err := rdb.addAdminRule(adminRule, at); err != nil {
+			logger.Noticef("cannot add admin prompting rule %d from %s: %v", i, path, err)
+		}
+	}
+	return nil
+}
+
+// addAdminRule adds the given rule provisioned by the system administrator to
+// the rule database without saving it, merging it with any existing admin rule
+// with an identical path pattern.
+//
+// The caller must ensure that the database lock is held for writing.
+func (rdb *RuleDB) addAdminRule(adminRule *adminRuleJSON, at prompting.At) error {
+	if adminRule.Snap == "" {
+		return errors.New(`rule must have a "snap" field`)
+	}
+	constraints, err := prompting.UnmarshalConstraints(adminRule.Interface, adminRule.Constraints)
+	if err != nil {
+		return err
+	}
+	for perm, entry := range constraints.Permissions {
+		if entry.Lifespan != prompting.LifespanForever {
+			return fmt.Errorf("permission %q must have lifespan %q", perm, prompting.LifespanForever)
+		}
+	}
+	rule, err := rdb.makeNewRule(AdminRulesUser, adminRule.Snap, adminRule.Interface, constraints, at)
+	if err != nil {
+		return err
+	}
+	rule.AdminManaged = true
+	const save = false
+	_, _, err = rdb.addOrMergeRule(rule, at, save)
+	return err
+}
+
 // rulesDBJSON is a helper type for wrapping request rule DB for serialization
 // when storing to disk. Should not used in contexts relating to the API.
 type rulesDBJSON struct {
@@ -355,7 +459,14 @@ func (rdb *RuleDB) load() (retErr error) {
 //
 // The caller must ensure that the database lock is held.
 func (rdb *RuleDB) save() error {
-	b, err := json.Marshal(rulesDBJSON{Rules: rdb.rules})
+	// Admin rules are read from the admin rules directory, so don't save them.
+	rules := make([]*Rule, 0, len(rdb.rules))
+	for _, rule := range rdb.rules {
+		if !rule.AdminManaged {
+			rules = append(rules, rule)
+		}
+	}
+	b, err := json.Marshal(rulesDBJSON{Rules: rules})
 	if err != nil {
 		// Should not occur, marshalling should always succeed
 		logger.Noticef("cannot marshal rule DB: %v", err)
@@ -1125,13 +1236,47 @@ var isPathPermAllowed = (*RuleDB).isPathPermAllowed
 // allowed or denied by existing rules for the given user, snap, and interface,
 // at the given point in time.
 //
+// Rules provisioned by the system administrator take precedence over the
+// rules of the given user.
+//
 // If no rule applies, returns prompting_errors.ErrNoMatchingRule.
 func (rdb *RuleDB) isPathPermAllowed(user uint32, snap string, iface string, path string, permission string, at prompting.At) (bool, error) {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
+	allowed, err := rdb.isPathPermAllowedForUser(AdminRulesUser, snap, iface, path, permission, at)
+	if !errors.Is(err, prompting_errors.ErrNoMatchingRule) {
+		return allowed, err
+	}
+	return rdb.isPathPermAllowedForUser(user, snap, iface, path, permission, at)
+}
+
+// isPathPermAllowedForUser checks whether the given path with the given
+// permission is allowed or denied by the rules stored for the given user,
+// snap, and interface, at the given point in time.
+//
+// If no rule applies, returns prompting_errors.ErrNoMatchingRule.
+//
+// The caller must ensure that the database lock is held.
+func (rdb *RuleDB) isPathPermAllowedForUser(user uint32, snap string, iface string, path string, permission string, at prompting.At) (bool, error) {
+	matchingEntry, err := rdb.matchingVariantEntry(user, snap, iface, path, permission, at)
+	if err != nil {
+		return false, err
+	}
+	return matchingEntry.Outcome.AsBool()
+}
+
+// matchingVariantEntry returns the non-expired variant entry with the highest
+// precedence which matches the given path with the given permission, among
+// the rules stored for the given user, snap, and interface, at the given point
+// in time.
+//
+// If no rule applies, returns prompting_errors.ErrNoMatchingRule.
+//
+// The caller must ensure that the database lock is held.
+func (rdb *RuleDB) matchingVariantEntry(user uint32, snap string, iface string, path string, permission string, at prompting.At) (*variantEntry, error) {
 	permissionMap := rdb.permissionDBForUserSnapInterfacePermission(user, snap, iface, permission)
 	if permissionMap == nil {
-		return false, prompting_errors.ErrNoMatchingRule
+		return nil, prompting_errors.ErrNoMatchingRule
 	}
 	variantMap := permissionMap.VariantEntries
 	var matchingVariants []patterns.PatternVariant
@@ -1146,21 +1291,73 @@ func (rdb *RuleDB) isPathPermAllowed(user uint32, snap string, iface string, pat
 		matched, err := patterns.PathPatternMatches(variantStr, path)
 		if err != nil {
 			// Only possible error is ErrBadPattern, which should not occur
-			return false, fmt.Errorf("internal error: while matching path pattern: %w", err)
+			return nil, fmt.Errorf("internal error: while matching path pattern: %w", err)
 		}
 		if matched {
 			matchingVariants = append(matchingVariants, variantEntry.Variant)
 		}
 	}
 	if len(matchingVariants) == 0 {
-		return false, prompting_errors.ErrNoMatchingRule
+		return nil, prompting_errors.ErrNoMatchingRule
 	}
 	highestPrecedenceVariant, err := patterns.HighestPrecedencePattern(matchingVariants, path)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	matchingEntry := variantMap[highestPrecedenceVariant.String()]
-	return matchingEntry.Outcome.AsBool()
+	return &matchingEntry, nil
+}
+
+// MatchingRuleIDs returns a map from each of the given permissions to the ID
+// of the rule which determines the outcome of a request with the given
+// parameters for that permission. Permissions which are not matched by any
+// existing rule are omitted from the map.
+//
+// If several non-expired rules render to the same highest precedence pattern
+// variant, the rule with the lowest ID is returned.
+func (rdb *RuleDB) MatchingRuleIDs(user uint32, snap string, iface string, path string, permissions []string) (map[string]prompting.IDType, error) {
+	currSession, err := readOrAssignUserSessionID(rdb, user)
+	if err != nil && !errors.Is(err, errNoUserSession) {
+		return nil, err
+	}
+	at := prompting.At{
+		Time:      time.Now(),
+		SessionID: currSession,
+	}
+
+	rdb.mutex.RLock()
+	defer rdb.mutex.RUnlock()
+
+	ruleIDs := make(map[string]prompting.IDType, len(permissions))
+	for _, perm := range permissions {
+		// Rules provisioned by the system administrator take precedence, as
+		// they do in isPathPermAllowed.
+		entry, err := rdb.matchingVariantEntry(AdminRulesUser, snap, iface, path, perm, at)
+		if errors.Is(err, prompting_errors.ErrNoMatchingRule) {
+			entry, err = rdb.matchingVariantEntry(user, snap, iface, path, perm, at)
+		}
+		if errors.Is(err, prompting_errors.ErrNoMatchingRule) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		var ruleID prompting.IDType
+		for id, rulePermissionEntry := range entry.RuleEntries {
+			if rulePermissionEntry.Expired(at) {
+				continue
+			}
+			if !found || id < ruleID {
+				ruleID = id
+				found = true
+			}
+		}
+		if found {
+			ruleIDs[perm] = ruleID
+		}
+	}
+	return ruleIDs, nil
 }
 
 // RuleWithID returns the rule with the given ID.
@@ -1173,12 +1370,13 @@ func (rdb *RuleDB) RuleWithID(user uint32, id prompting.IDType) (*Rule, error) {
 	return rdb.lookupRuleByIDForUser(user, id)
 }
 
-// Rules returns all rules which apply to the given user.
+// Rules returns all rules which apply to the given user, including those
+// provisioned by the system administrator.
 func (rdb *RuleDB) Rules(user uint32) []*Rule {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user
+		return rule.appliesToUser(user)
 	}
 	return rdb.rulesInternal(ruleFilter)
 }
@@ -1226,7 +1424,7 @@ func (rdb *RuleDB) RulesForSnap(user uint32, snap string) []*Rule {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user && rule.Snap == snap
+		return rule.appliesToUser(user) && rule.Snap == snap
 	}
 	return rdb.rulesInternal(ruleFilter)
 }
@@ -1237,7 +1435,7 @@ func (rdb *RuleDB) RulesForInterface(user uint32, iface string) []*Rule {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user && rule.Interface == iface
+		return rule.appliesToUser(user) && rule.Interface == iface
 	}
 	return rdb.rulesInternal(ruleFilter)
 }
@@ -1248,13 +1446,13 @@ func (rdb *RuleDB) RulesForSnapInterface(user uint32, snap string, iface string)
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user && rule.Snap == snap && rule.Interface == iface
+		return rule.appliesToUser(user) && rule.Snap == snap && rule.Interface == iface
 	}
 	return rdb.rulesInternal(ruleFilter)
 }
 
-// lookupRuleByIDForUser returns the rule with the given ID, if it exists, for the
-// given user. Otherwise, returns an error.
+// lookupRuleByIDForUser returns the rule with the given ID, if it exists and
+// applies to the given user. Otherwise, returns an error.
 //
 // The caller must ensure that the database lock is held.
 func (rdb *RuleDB) lookupRuleByIDForUser(user uint32, id prompting.IDType) (*Rule, error) {
@@ -1262,7 +1460,7 @@ func (rdb *RuleDB) lookupRuleByIDForUser(user uint32, id prompting.IDType) (*Rul
 	if err != nil {
 		return nil, err
 	}
-	if rule.User != user {
+	if !rule.appliesToUser(user) {
 		return nil, prompting_errors.ErrRuleNotAllowed
 	}
 	return rule, nil
@@ -1270,7 +1468,9 @@ func (rdb *RuleDB) lookupRuleByIDForUser(user uint32, id prompting.IDType) (*Rul
 
 // RemoveRule the rule with the given ID from the rule database. If the rule
 // does not apply to the given user, returns prompting_errors.ErrRuleNotAllowed.
-// If successful, saves the database to disk.
+// If the rule was provisioned by the system administrator, returns
+// prompting_errors.ErrRuleAdminManaged. If successful, saves the database to
+// disk.
 func (rdb *RuleDB) RemoveRule(user uint32, id prompting.IDType) (*Rule, error) {
 	rdb.mutex.Lock()
 	defer rdb.mutex.Unlock()
@@ -1284,6 +1484,9 @@ func (rdb *RuleDB) RemoveRule(user uint32, id prompting.IDType) (*Rule, error) {
 		// The rule doesn't exist or the user doesn't have access
 		return nil, err
 	}
+	if rule.AdminManaged {
+		return nil, prompting_errors.ErrRuleAdminManaged
+	}
 
 	rdb.removeRuleByIDFromRulesList(id)
 	// We know the rule exists, so this should not error
@@ -1405,6 +1608,8 @@ func (rdb *RuleDB) RemoveRulesForSnapInterface(user uint32, snap string, iface s
 // session, respectively). Since neither outcome nor lifespan are omitempty,
 // the unmarshaller enforces this for us.
 //
+// Rules provisioned by the system administrator cannot be patched.
+//
 // Even if the given patch contents exactly match the existing rule contents,
 // the timestamp of the rule is updated to the current time. If there is any
 // error while modifying the rule, the rule is rolled back to its previous
@@ -1422,6 +1627,9 @@ func (rdb *RuleDB) PatchRule(user uint32, id prompting.IDType, constraintsPatch
 	if err != nil {
 		return nil, err
 	}
+	if origRule.AdminManaged {
+		return nil, prompting_errors.ErrRuleAdminManaged
+	}
 
 	// XXX: we don't currently check whether the rule is fully expired or not.
 	// Do we want to support patching a rule for which all the permissions
@@ -3328,6 +3328,132 @@ func (s *requestrulesSuite) TestPatchRuleExpired(c *C) {
 	c.Check(patched, DeepEquals, rule)
 }
 
+func (s *requestrulesSuite) TestAdminRules(c *C) {
+	c.Assert(os.MkdirAll(dirs.SnapInterfacesRequestsAdminRulesDir, 0o755), IsNil)
+	adminRules := `{"rules":[
+	{"snap":"lxd","interface":"home","constraints":{"path-pattern":"/home/*/Documents/**","permissions":{"read":{"outcome":"allow","lifespan":"forever"}}}},
+	{"snap":"lxd","interface":"home","constraints":{"path-pattern":"/home/*/.ssh/**","permissions":{"read":{"outcome":"deny","lifespan":"forever"}}}},
+	{"snap":"lxd","interface":"home","constraints":{"path-pattern":"/home/*/tmp/**","permissions":{"read":{"outcome":"allow","lifespan":"timespan","duration":"10m"}}}},
+	{"interface":"home","constraints":{"path-pattern":"/home/*/foo/**","permissions":{"read":{"outcome":"allow","lifespan":"forever"}}}}
+]}`
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapInterfacesRequestsAdminRulesDir, "10-lxd.json"), []byte(adminRules), 0o644), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapInterfacesRequestsAdminRulesDir, "20-bad.json"), []byte("{"), 0o644), IsNil)
+	// Files without the .json suffix are ignored
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapInterfacesRequestsAdminRulesDir, "README"), []byte("not rules"), 0o644), IsNil)
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+	c.Check(logbuf.String(), testutil.Contains, `cannot add admin prompting rule 2 from `+filepath.Join(dirs.SnapInterfacesRequestsAdminRulesDir, "10-lxd.json")+`: permission "read" must have lifespan "forever"`)
+	c.Check(logbuf.String(), testutil.Contains, `cannot add admin prompting rule 3 from `+filepath.Join(dirs.SnapInterfacesRequestsAdminRulesDir, "10-lxd.json")+`: rule must have a "snap" field`)
+	c.Check(logbuf.String(), testutil.Contains, `cannot load admin prompting rules from `+filepath.Join(dirs.SnapInterfacesRequestsAdminRulesDir, "20-bad.json")+`: unexpected EOF`)
+	// Loading admin rules records no notices
+	s.checkNewNotices(c, nil)
+
+	// Admin rules apply to every user
+	for _, user := range []uint32{s.defaultUser, s.defaultUser + 1} {
+		rules := rdb.Rules(user)
+		c.Assert(rules, HasLen, 2)
+		for _, rule := range rules {
+			c.Check(rule.AdminManaged, Equals, true)
+			c.Check(rule.User, Equals, requestrules.AdminRulesUser)
+		}
+		c.Check(rdb.RulesForSnapInterface(user, "lxd", "home"), HasLen, 2)
+		c.Check(rdb.RulesForSnap(user, "thunderbird"), HasLen, 0)
+	}
+
+	// Admin rules take precedence over user rules
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "lxd",
+		Interface:   "home",
+		PathPattern: "/home/test/{Documents,.ssh}/**",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeDeny,
+		Lifespan:    prompting.LifespanForever,
+	}
+	userRule, err := addRuleFromTemplate(c, rdb, template, template)
+	c.Assert(err, IsNil)
+	s.checkNewNoticesSimple(c, nil, userRule)
+
+	allowed, anyDenied, outstanding, err := rdb.IsRequestAllowed(s.defaultUser, "lxd", "home", "/home/test/Documents/foo", []string{"read"})
+	c.Check(err, IsNil)
+	c.Check(allowed, DeepEquals, []string{"read"})
+	c.Check(anyDenied, Equals, false)
+	c.Check(outstanding, HasLen, 0)
+	allowed, anyDenied, outstanding, err = rdb.IsRequestAllowed(s.defaultUser+1, "lxd", "home", "/home/other/.ssh/id_rsa", []string{"read"})
+	c.Check(err, IsNil)
+	c.Check(allowed, HasLen, 0)
+	c.Check(anyDenied, Equals, true)
+	c.Check(outstanding, HasLen, 0)
+	allowed, anyDenied, outstanding, err = rdb.IsRequestAllowed(s.defaultUser, "lxd", "home", "/home/test/Documents/foo", []string{"write"})
+	c.Check(err, IsNil)
+	c.Check(allowed, HasLen, 0)
+	c.Check(anyDenied, Equals, false)
+	c.Check(outstanding, DeepEquals, []string{"write"})
+
+	// Admin rules are not saved to the rule database file
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{userRule})
+
+	// Admin rules cannot be patched or removed by the user
+	adminRule := rdb.Rules(s.defaultUser + 1)[0]
+	_, err = rdb.PatchRule(s.defaultUser+1, adminRule.ID, nil)
+	c.Check(err, Equals, prompting_errors.ErrRuleAdminManaged)
+	_, err = rdb.RemoveRule(s.defaultUser+1, adminRule.ID)
+	c.Check(err, Equals, prompting_errors.ErrRuleAdminManaged)
+	removed, err := rdb.RemoveRulesForSnap(s.defaultUser, "lxd")
+	c.Check(err, IsNil)
+	c.Check(removed, DeepEquals, []*requestrules.Rule{userRule})
+	s.checkNewNoticesSimple(c, map[string]string{"removed": "removed"}, userRule)
+	c.Check(rdb.Rules(s.defaultUser), HasLen, 2)
+
+	// Admin rules are loaded again when the rule DB is recreated
+	c.Assert(rdb.Close(), IsNil)
+	rdb, err = requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+	c.Check(rdb.Rules(s.defaultUser), HasLen, 2)
+	s.checkWrittenRuleDB(c, nil)
+}
+
+func (s *requestrulesSuite) TestMatchingRuleIDs(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "lxd",
+		Interface:   "home",
+		PathPattern: "/home/test/**",
+		Permissions: []string{"read", "write"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}
+	broadRule, err := addRuleFromTemplate(c, rdb, template, template)
+	c.Assert(err, IsNil)
+	narrowRule, err := addRuleFromTemplate(c, rdb, template, &addRuleContents{
+		PathPattern: "/home/test/.ssh/**",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeDeny,
+	})
+	c.Assert(err, IsNil)
+
+	ruleIDs, err := rdb.MatchingRuleIDs(s.defaultUser, "lxd", "home", "/home/test/.ssh/id_rsa", []string{"read", "write", "execute"})
+	c.Check(err, IsNil)
+	c.Check(ruleIDs, DeepEquals, map[string]prompting.IDType{
+		"read":  narrowRule.ID,
+		"write": broadRule.ID,
+	})
+
+	ruleIDs, err = rdb.MatchingRuleIDs(s.defaultUser, "lxd", "home", "/home/test/foo", []string{"read"})
+	c.Check(err, IsNil)
+	c.Check(ruleIDs, DeepEquals, map[string]prompting.IDType{"read": broadRule.ID})
+
+	ruleIDs, err = rdb.MatchingRuleIDs(s.defaultUser+1, "lxd", "home", "/home/test/foo", []string{"read"})
+	c.Check(err, IsNil)
+	c.Check(ruleIDs, HasLen, 0)
+}
+
 func (s *requestrulesSuite) TestUserSessionIDCache(c *C) {
 	rdb, err := requestrules.New(s.defaultNotifyRule)
 	c.Assert(err, IsNil)
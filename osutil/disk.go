@@ -37,8 +37,9 @@ func (e *NotEnoughDiskSpaceError) Error() string {
 	return fmt.Sprintf("insufficient space in %q, at least %s more is required", e.Path, strutil.SizeToStr(e.Delta))
 }
 
-// diskFree returns free disk space for the given path
-func diskFree(path string) (uint64, error) {
+// DiskFree returns the disk space available to unprivileged users for the
+// given path.
+func DiskFree(path string) (uint64, error) {
 	var st syscall.Statfs_t
 	if err := syscallStatfs(path, &st); err != nil {
 		return 0, err
@@ -49,7 +50,7 @@ func diskFree(path string) (uint64, error) {
 
 // CheckFreeSpace checks if there is enough disk space for the given path
 func CheckFreeSpace(path string, minSize uint64) error {
-	free, err := diskFree(path)
+	free, err := DiskFree(path)
 	if err != nil {
 		return err
 	}
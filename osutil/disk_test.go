@@ -68,3 +68,17 @@ func (s *diskSuite) TestCheckFreeSpacePathError(c *C) {
 	err := osutil.CheckFreeSpace("/does/not/exist/path", 8193)
 	c.Assert(os.IsNotExist(err), Equals, true)
 }
+
+func (s *diskSuite) TestDiskFree(c *C) {
+	restore := osutil.MockSyscallStatfs(func(path string, st *syscall.Statfs_t) error {
+		c.Assert(path, Equals, "/path")
+		st.Bsize = 4096
+		st.Bavail = 3
+		return nil
+	})
+	defer restore()
+
+	free, err := osutil.DiskFree("/path")
+	c.Assert(err, IsNil)
+	c.Check(free, Equals, uint64(3*4096))
+}
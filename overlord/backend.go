@@ -1,7 +1,7 @@
 // -*- Mode: Go; indent-tabs-mode: t -*-
 
 /*
- * Copyright (C) 2016 Canonical Ltd
+ * Copyright (C) 2016-2026 Canonical Ltd
  *
  * This program is free software: you can redistribute it and/or modify
  * it under the terms of the GNU General Public License version 3 as
@@ -20,9 +20,17 @@
 package overlord
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
 	"time"
 
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/state"
 )
 
 type overlordStateBackend struct {
@@ -37,3 +45,165 @@ func (osb *overlordStateBackend) Checkpoint(data []byte) error {
 func (osb *overlordStateBackend) EnsureBefore(d time.Duration) {
 	osb.ensureBefore(d)
 }
+
+// minStateJournalCompactSize is the journal size under which the state
+// journal is never compacted, regardless of the size of the state file.
+var minStateJournalCompactSize int64 = 1024 * 1024
+
+// journaledStateBackend persists the state incrementally by appending
+// deltas to a journal, compacting it into the state file once the journal
+// grows larger than the state file itself.
+type journaledStateBackend struct {
+	*overlordStateBackend
+	journal *stateJournal
+}
+
+func (jsb *journaledStateBackend) Checkpoint(data []byte) error {
+	if err := jsb.overlordStateBackend.Checkpoint(data); err != nil {
+		return err
+	}
+	return jsb.journal.reset(data)
+}
+
+func (jsb *journaledStateBackend) CheckpointDelta(delta *state.Delta, full func() []byte) error {
+	if jsb.journal.needsCompaction() {
+		return jsb.Checkpoint(full())
+	}
+	return jsb.journal.append(delta)
+}
+
+// stateJournal is an append-only file of state deltas, one JSON document per
+// line, following a header line that identifies the state file the deltas
+// apply to.
+type stateJournal struct {
+	path string
+	f    *os.File
+	size int64
+	// maxSize is the size after which the journal needs compaction
+	maxSize int64
+	// broken is set when an append could not be cleanly rolled back
+	broken bool
+}
+
+type stateJournalHeader struct {
+	Format     int    `json:"format"`
+	BaseSHA256 string `json:"base-sha256"`
+}
+
+func stateJournalHeaderFor(base []byte) stateJournalHeader {
+	sum := sha256.Sum256(base)
+	return stateJournalHeader{
+		Format:     1,
+		BaseSHA256: hex.EncodeToString(sum[:]),
+	}
+}
+
+// reset starts a new empty journal on top of the given state file content.
+func (j *stateJournal) reset(base []byte) error {
+	if j.f != nil {
+		j.f.Close()
+		j.f = nil
+	}
+	header, err := json.Marshal(stateJournalHeaderFor(base))
+	if err != nil {
+		return err
+	}
+	header = append(header, '\n')
+	if err := osutil.AtomicWriteFile(j.path, header, 0600, 0); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	j.f = f
+	j.size = int64(len(header))
+	j.maxSize = int64(len(base))
+	if j.maxSize < minStateJournalCompactSize {
+		j.maxSize = minStateJournalCompactSize
+	}
+	j.broken = false
+	return nil
+}
+
+func (j *stateJournal) needsCompaction() bool {
+	return j.f == nil || j.broken || j.size > j.maxSize
+}
+
+func (j *stateJournal) append(delta *state.Delta) error {
+	record, err := json.Marshal(delta)
+	if err != nil {
+		return err
+	}
+	record = append(record, '\n')
+	n, err := j.f.Write(record)
+	if err == nil {
+		err = j.f.Sync()
+	}
+	if err != nil {
+		// drop what might have been partially written so that a
+		// retry does not leave garbage in the middle of the journal
+		if terr := j.f.Truncate(j.size); terr != nil {
+			j.broken = true
+		}
+		return err
+	}
+	j.size += int64(n)
+	return nil
+}
+
+func (j *stateJournal) close() {
+	if j.f != nil {
+		j.f.Close()
+		j.f = nil
+	}
+}
+
+// readStateJournal returns the deltas recorded in the journal at path on top
+// of the given state file content. A journal recorded on top of a different
+// state file is stale and is ignored. Reading stops at the first incomplete
+// or invalid record, as only the last record can have been interrupted
+// before being acknowledged.
+func readStateJournal(path string, base []byte) ([]*state.Delta, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		logger.Noticef("ignoring state journal without a complete header")
+		return nil, nil
+	}
+	var header stateJournalHeader
+	if err := json.Unmarshal(line, &header); err != nil || header != stateJournalHeaderFor(base) {
+		logger.Noticef("ignoring stale state journal")
+		return nil, nil
+	}
+
+	var deltas []*state.Delta
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) != 0 {
+				logger.Noticef("ignoring incomplete last record of state journal")
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var delta state.Delta
+		if err := json.Unmarshal(line, &delta); err != nil {
+			logger.Noticef("ignoring invalid state journal record %d and following: %v", len(deltas)+1, err)
+			break
+		}
+		deltas = append(deltas, &delta)
+	}
+	return deltas, nil
+}
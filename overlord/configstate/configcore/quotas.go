@@ -0,0 +1,61 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+func init() {
+	// add supported configuration of this module
+	supportedConfigurations["core.quotas.usage-alert.threshold"] = true
+	supportedConfigurations["core.quotas.usage-alert.duration"] = true
+}
+
+func validateQuotaUsageAlert(tr RunTransaction) error {
+	threshold, err := coreCfg(tr, "quotas.usage-alert.threshold")
+	if err != nil {
+		return err
+	}
+	if threshold != "" {
+		value, err := strconv.ParseUint(threshold, 10, 8)
+		if err != nil || value < 1 || value > 100 {
+			return fmt.Errorf("quotas.usage-alert.threshold must be a percentage between 1 and 100, not %q", threshold)
+		}
+	}
+
+	duration, err := coreCfg(tr, "quotas.usage-alert.duration")
+	if err != nil {
+		return err
+	}
+	if duration != "" {
+		dur, err := time.ParseDuration(duration)
+		if err != nil {
+			return fmt.Errorf("quotas.usage-alert.duration cannot be parsed: %v", err)
+		}
+		if dur < 0 {
+			return fmt.Errorf("quotas.usage-alert.duration cannot be negative")
+		}
+	}
+	return nil
+}
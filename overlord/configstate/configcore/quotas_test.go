@@ -0,0 +1,63 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build !nomanagers
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package configcore_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/configcore"
+)
+
+type quotasSuite struct {
+	configcoreSuite
+}
+
+var _ = Suite(&quotasSuite{})
+
+func (s *quotasSuite) TestConfigureQuotaUsageAlertHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"quotas.usage-alert.threshold": 90,
+			"quotas.usage-alert.duration":  "10m",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *quotasSuite) TestConfigureQuotaUsageAlertInvalid(c *C) {
+	for _, t := range []struct {
+		conf map[string]any
+		err  string
+	}{
+		{map[string]any{"quotas.usage-alert.threshold": 0}, `quotas.usage-alert.threshold must be a percentage between 1 and 100, not "0"`},
+		{map[string]any{"quotas.usage-alert.threshold": "101"}, `quotas.usage-alert.threshold must be a percentage between 1 and 100, not "101"`},
+		{map[string]any{"quotas.usage-alert.threshold": "lots"}, `quotas.usage-alert.threshold must be a percentage between 1 and 100, not "lots"`},
+		{map[string]any{"quotas.usage-alert.duration": "soon"}, `quotas.usage-alert.duration cannot be parsed: .*`},
+		{map[string]any{"quotas.usage-alert.duration": "-1m"}, `quotas.usage-alert.duration cannot be negative`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  t.conf,
+		})
+		c.Check(err, ErrorMatches, t.err, Commentf("%v", t.conf))
+	}
+}
@@ -43,6 +43,11 @@ func init() {
 	supportedConfigurations["core.refresh.retain"] = true
 	supportedConfigurations["core.refresh.rate-limit"] = true
 	supportedConfigurations["core.refresh.max-inhibition-days"] = true
+	supportedConfigurations["core.refresh.health-revert-after"] = true
+	supportedConfigurations["core.refresh.rollout-window"] = true
+	supportedConfigurations["core.refresh.blackout"] = true
+	supportedConfigurations["core.refresh.idle-time"] = true
+	supportedConfigurations["core.refresh.min-free-space"] = true
 }
 
 func reportOrIgnoreInvalidManageRefreshes(tr RunTransaction, optName string) error {
@@ -163,3 +168,80 @@ func validateRefreshRateLimit(tr RunTransaction) error {
 	}
 	return nil
 }
+
+func validateRefreshHealthRevertAfter(tr RunTransaction) error {
+	revertAfter, err := coreCfg(tr, "refresh.health-revert-after")
+	if err != nil {
+		return err
+	}
+	// unset disables reverting
+	if revertAfter == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(revertAfter)
+	if err != nil || d < time.Minute {
+		return fmt.Errorf("refresh.health-revert-after must be a duration of at least 1m, not %q", revertAfter)
+	}
+	return nil
+}
+
+// maxRolloutWindow is the longest window auto-refreshes can be spread over.
+const maxRolloutWindow = 30 * 24 * time.Hour
+
+func validateRefreshRolloutWindow(tr RunTransaction) error {
+	window, err := coreCfg(tr, "refresh.rollout-window")
+	if err != nil {
+		return err
+	}
+	// unset disables the staged rollout
+	if window == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d < 0 || d > maxRolloutWindow {
+		return fmt.Errorf("rollout-window must be a duration between 0 and %s, not %q", maxRolloutWindow, window)
+	}
+	return nil
+}
+
+func validateRefreshMaintenanceWindow(tr RunTransaction) error {
+	blackout, err := coreCfg(tr, "refresh.blackout")
+	if err != nil {
+		return err
+	}
+	if blackout != "" {
+		if _, err := timeutil.ParseDateRanges(blackout); err != nil {
+			return fmt.Errorf("refresh.blackout %v", err)
+		}
+	}
+
+	idleTime, err := coreCfg(tr, "refresh.idle-time")
+	if err != nil {
+		return err
+	}
+	// unset means not waiting for the device to be idle
+	if idleTime == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(idleTime)
+	if err != nil || d < time.Minute || d > 24*time.Hour {
+		return fmt.Errorf("refresh.idle-time must be a duration between 1m and 24h, not %q", idleTime)
+	}
+	return nil
+}
+
+func validateRefreshMinFreeSpace(tr RunTransaction) error {
+	minFreeSpace, err := coreCfg(tr, "refresh.min-free-space")
+	if err != nil {
+		return err
+	}
+	// unset disables the disk pressure policy
+	if minFreeSpace == "" {
+		return nil
+	}
+	size, err := strutil.ParseByteSize(minFreeSpace)
+	if err != nil || size <= 0 {
+		return fmt.Errorf("min-free-space must be a size larger than 0, not %q", minFreeSpace)
+	}
+	return nil
+}
@@ -252,3 +252,146 @@ func (s *refreshSuite) TestConfigureRefreshMaxInhibitionDays(c *C) {
 		}
 	}
 }
+
+func (s *refreshSuite) TestConfigureRefreshHealthRevertAfter(c *C) {
+	data := []struct {
+		val any
+		err string
+	}{
+		{val: "zzz", err: `refresh.health-revert-after must be a duration of at least 1m, not "zzz"`},
+		{val: "30s", err: `refresh.health-revert-after must be a duration of at least 1m, not "30s"`},
+		{val: 10, err: `refresh.health-revert-after must be a duration of at least 1m, not "10"`},
+		// happy cases
+		{val: nil}, // disabled
+		{val: ""},  // disabled
+		{val: "1m"},
+		{val: "2h30m"},
+	}
+	for _, tc := range data {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"refresh.health-revert-after": tc.val,
+			},
+		})
+		if tc.err != "" {
+			c.Check(err, ErrorMatches, tc.err)
+		} else {
+			c.Check(err, IsNil)
+		}
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshRolloutWindow(c *C) {
+	data := []struct {
+		val any
+		err string
+	}{
+		{val: "zzz", err: `rollout-window must be a duration between 0 and 720h0m0s, not "zzz"`},
+		{val: "-1h", err: `rollout-window must be a duration between 0 and 720h0m0s, not "-1h"`},
+		{val: "721h", err: `rollout-window must be a duration between 0 and 720h0m0s, not "721h"`},
+		// happy cases
+		{val: nil}, // disabled
+		{val: ""},  // disabled
+		{val: "0s"},
+		{val: "72h"},
+	}
+	for _, tc := range data {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"refresh.rollout-window": tc.val,
+			},
+		})
+		if tc.err != "" {
+			c.Check(err, ErrorMatches, tc.err)
+		} else {
+			c.Check(err, IsNil)
+		}
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshMinFreeSpace(c *C) {
+	data := []struct {
+		val any
+		err string
+	}{
+		{val: "lots", err: `min-free-space must be a size larger than 0, not "lots"`},
+		{val: "0B", err: `min-free-space must be a size larger than 0, not "0B"`},
+		// happy cases
+		{val: nil}, // disabled
+		{val: ""},  // disabled
+		{val: "512MB"},
+		{val: "2GB"},
+	}
+	for _, tc := range data {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"refresh.min-free-space": tc.val,
+			},
+		})
+		if tc.err != "" {
+			c.Check(err, ErrorMatches, tc.err)
+		} else {
+			c.Check(err, IsNil)
+		}
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshBlackout(c *C) {
+	data := []struct {
+		val any
+		err string
+	}{
+		{val: "christmas", err: `refresh.blackout cannot parse "christmas": not a valid date`},
+		{val: "2026-12-20..2026-12-01", err: `refresh.blackout cannot parse "2026-12-20..2026-12-01": range ends before it starts`},
+		// happy cases
+		{val: nil},
+		{val: ""},
+		{val: "2026-12-24"},
+		{val: "2026-12-20..2027-01-06,2027-04-01"},
+	}
+	for _, tc := range data {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"refresh.blackout": tc.val,
+			},
+		})
+		if tc.err != "" {
+			c.Check(err, ErrorMatches, tc.err)
+		} else {
+			c.Check(err, IsNil)
+		}
+	}
+}
+
+func (s *refreshSuite) TestConfigureRefreshIdleTime(c *C) {
+	data := []struct {
+		val any
+		err string
+	}{
+		{val: "zzz", err: `refresh.idle-time must be a duration between 1m and 24h, not "zzz"`},
+		{val: "30s", err: `refresh.idle-time must be a duration between 1m and 24h, not "30s"`},
+		{val: "25h", err: `refresh.idle-time must be a duration between 1m and 24h, not "25h"`},
+		// happy cases
+		{val: nil},
+		{val: ""},
+		{val: "1m"},
+		{val: "30m"},
+	}
+	for _, tc := range data {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"refresh.idle-time": tc.val,
+			},
+		})
+		if tc.err != "" {
+			c.Check(err, ErrorMatches, tc.err)
+		} else {
+			c.Check(err, IsNil)
+		}
+	}
+}
@@ -59,7 +59,22 @@ func init() {
 	validateOnly := &flags{validatedOnlyStateConfig: true}
 	addWithStateHandler(validateRefreshSchedule, nil, validateOnly)
 	addWithStateHandler(validateRefreshRateLimit, nil, validateOnly)
+	addWithStateHandler(validateRefreshHealthRevertAfter, nil, validateOnly)
+	addWithStateHandler(validateRefreshRolloutWindow, nil, validateOnly)
+	addWithStateHandler(validateRefreshMaintenanceWindow, nil, validateOnly)
+	addWithStateHandler(validateRefreshMinFreeSpace, nil, validateOnly)
 	addWithStateHandler(validateAutomaticSnapshotsExpiration, nil, validateOnly)
+	addWithStateHandler(validateSnapshotsDeduplicate, nil, validateOnly)
+	addWithStateHandler(validateScheduledSnapshots, nil, validateOnly)
+	addWithStateHandler(validateQuotaUsageAlert, nil, validateOnly)
+	addWithStateHandler(validateStorePeerToPeer, nil, validateOnly)
+	addWithStateHandler(validateStoreDownloadRateLimit, nil, validateOnly)
+
+	// store.mirror
+	addWithStateHandler(validateStoreMirror, handleStoreMirror, nil)
+
+	// snapshots.target
+	addWithStateHandler(validateSnapshotsTarget, handleSnapshotsTarget, nil)
 
 	// netplan.*
 	addWithStateHandler(validateNetplanSettings, handleNetplanConfiguration, coreOnly)
@@ -22,12 +22,31 @@ package configcore
 
 import (
 	"fmt"
+	"strconv"
 	"time"
+
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/snap/naming"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 func init() {
 	// add supported configuration of this module
 	supportedConfigurations["core.snapshots.automatic.retention"] = true
+	supportedConfigurations["core.snapshots.target"] = true
+	supportedConfigurations["core.snapshots.deduplicate"] = true
+	supportedConfigurations["core.snapshots.schedule"] = true
+	supportedConfigurations["core.snapshots.scheduled.snaps"] = true
+	for _, key := range scheduledSnapshotsRetentionKeys {
+		supportedConfigurations["core."+key] = true
+	}
+}
+
+var scheduledSnapshotsRetentionKeys = []string{
+	"snapshots.scheduled.keep-last",
+	"snapshots.scheduled.keep-daily",
+	"snapshots.scheduled.keep-weekly",
 }
 
 func validateAutomaticSnapshotsExpiration(tr RunTransaction) error {
@@ -46,3 +65,77 @@ func validateAutomaticSnapshotsExpiration(tr RunTransaction) error {
 	}
 	return nil
 }
+
+func validateSnapshotsTarget(tr RunTransaction) error {
+	target, err := coreCfg(tr, "snapshots.target")
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		return nil
+	}
+	if _, err := backend.NewTarget(backend.TargetURLWithCredentials(target), nil); err != nil {
+		return fmt.Errorf("snapshots.target is invalid: %v", err)
+	}
+	return nil
+}
+
+// handleSnapshotsTarget keeps the credentials of snapshots.target out of the
+// system configuration, which anyone can read.
+func handleSnapshotsTarget(tr RunTransaction, opts *fsOnlyContext) error {
+	target, err := coreCfg(tr, "snapshots.target")
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		return backend.RemoveTargetCredentials()
+	}
+	stripped, err := backend.StoreTargetCredentials(target)
+	if err != nil {
+		return err
+	}
+	if stripped != target {
+		tr.Set("core", "snapshots.target", stripped)
+	}
+	return nil
+}
+
+func validateSnapshotsDeduplicate(tr RunTransaction) error {
+	return validateBoolFlag(tr, "snapshots.deduplicate")
+}
+
+func validateScheduledSnapshots(tr RunTransaction) error {
+	timer, err := coreCfg(tr, "snapshots.schedule")
+	if err != nil {
+		return err
+	}
+	if timer != "" {
+		if _, err := timeutil.ParseSchedule(timer); err != nil {
+			return fmt.Errorf("snapshots.schedule cannot be parsed: %v", err)
+		}
+	}
+
+	snaps, err := coreCfg(tr, "snapshots.scheduled.snaps")
+	if err != nil {
+		return err
+	}
+	for _, name := range strutil.CommaSeparatedList(snaps) {
+		if err := naming.ValidateInstance(name); err != nil {
+			return fmt.Errorf("snapshots.scheduled.snaps is invalid: %v", err)
+		}
+	}
+
+	for _, key := range scheduledSnapshotsRetentionKeys {
+		value, err := coreCfg(tr, key)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+		if _, err := strconv.ParseUint(value, 10, 16); err != nil {
+			return fmt.Errorf("%s must be a non-negative number, not %q", key, value)
+		}
+	}
+	return nil
+}
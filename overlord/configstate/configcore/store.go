@@ -22,16 +22,27 @@ package configcore
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/sysconfig"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 func init() {
 	supportedConfigurations["core.store.access"] = true
+	supportedConfigurations["core.store.peer-to-peer"] = true
+	supportedConfigurations["core.store.peer-to-peer-interfaces"] = true
+	supportedConfigurations["core.store.mirror"] = true
+	supportedConfigurations["core.store.download-rate-limit"] = true
+	supportedConfigurations["core.store.download-rate-limit-schedule"] = true
 }
 
 func validateStoreAccess(cfg ConfGetter) error {
@@ -48,6 +59,86 @@ func validateStoreAccess(cfg ConfGetter) error {
 	}
 }
 
+// network interface names as accepted by the kernel, see dev_valid_name
+var validNetworkInterface = regexp.MustCompile(`^[^\s/:,]{1,15}$`)
+
+func validateStorePeerToPeer(tr RunTransaction) error {
+	if err := validateBoolFlag(tr, "store.peer-to-peer"); err != nil {
+		return err
+	}
+	ifaces, err := coreCfg(tr, "store.peer-to-peer-interfaces")
+	if err != nil {
+		return err
+	}
+	if ifaces == "" {
+		return nil
+	}
+	for _, iface := range strings.Split(ifaces, ",") {
+		if !validNetworkInterface.MatchString(iface) || iface == "." || iface == ".." {
+			return fmt.Errorf("store.peer-to-peer-interfaces must be a comma separated list of network interfaces, not %q", ifaces)
+		}
+	}
+	return nil
+}
+
+func validateStoreMirror(tr RunTransaction) error {
+	dir, err := coreCfg(tr, "store.mirror")
+	if err != nil {
+		return err
+	}
+	if dir != "" && (!filepath.IsAbs(dir) || filepath.Clean(dir) != dir) {
+		return fmt.Errorf("store.mirror must be a clean absolute path, not %q", dir)
+	}
+	return nil
+}
+
+func handleStoreMirror(tr RunTransaction, opts *fsOnlyContext) error {
+	mirrorInChanges := false
+	for _, name := range tr.Changes() {
+		if name == "core.store.mirror" {
+			mirrorInChanges = true
+			break
+		}
+	}
+	if !mirrorInChanges {
+		return nil
+	}
+	dir, err := coreCfg(tr, "store.mirror")
+	if err != nil {
+		return err
+	}
+	// XXX ideally we should do this only when committing, like for
+	// proxy.store
+	st := tr.State()
+	st.Lock()
+	defer st.Unlock()
+	snapstate.SetStoreMirror(st, dir)
+	return nil
+}
+
+func validateStoreDownloadRateLimit(tr RunTransaction) error {
+	rateLimit, err := coreCfg(tr, "store.download-rate-limit")
+	if err != nil {
+		return err
+	}
+	if rateLimit != "" {
+		if _, err := strutil.ParseByteSize(rateLimit); err != nil {
+			return fmt.Errorf("cannot parse store.download-rate-limit: %v", err)
+		}
+	}
+
+	schedule, err := coreCfg(tr, "store.download-rate-limit-schedule")
+	if err != nil {
+		return err
+	}
+	if schedule != "" {
+		if _, err := timeutil.ParseSchedule(schedule); err != nil {
+			return fmt.Errorf("cannot parse store.download-rate-limit-schedule: %v", err)
+		}
+	}
+	return nil
+}
+
 // repairConfig is a set of configuration data that is consumed by the
 // snap-repair command. This struct is duplicated in cmd/snap-repair.
 type repairConfig struct {
@@ -22,6 +22,7 @@ package configcore_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -29,6 +30,9 @@ import (
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/overlord/configstate/configcore"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/store/mirror"
 )
 
 type storeSuite struct {
@@ -95,3 +99,131 @@ func (s *storeSuite) TestFilesystemOnlyApply(c *C) {
 
 	c.Check(repairConfig.StoreOffline, Equals, true)
 }
+
+func (s *storeSuite) TestConfigureStorePeerToPeer(c *C) {
+	for _, value := range []any{"true", "false", true, false} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"store.peer-to-peer": value,
+			},
+		})
+		c.Check(err, IsNil, Commentf("%v", value))
+	}
+
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		conf: map[string]any{
+			"store.peer-to-peer": "maybe",
+		},
+	})
+	c.Assert(err, ErrorMatches, `store.peer-to-peer can only be set to 'true' or 'false'`)
+}
+
+func (s *storeSuite) TestConfigureStorePeerToPeerInterfaces(c *C) {
+	for _, value := range []string{"", "eth0", "eth0,wlp2s0", "enp0s31f6"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"store.peer-to-peer-interfaces": value,
+			},
+		})
+		c.Check(err, IsNil, Commentf("%v", value))
+	}
+
+	for _, value := range []string{"eth0,", "eth 0", "../eth0", "..", "averyveryverylongname"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"store.peer-to-peer-interfaces": value,
+			},
+		})
+		c.Check(err, ErrorMatches, `store.peer-to-peer-interfaces must be a comma separated list of network interfaces, not ".*"`, Commentf("%v", value))
+	}
+}
+
+func (s *storeSuite) TestConfigureStoreMirror(c *C) {
+	for _, value := range []string{"", "/media/usb/snaps", "/srv/mirror"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"store.mirror": value,
+			},
+		})
+		c.Check(err, IsNil, Commentf("%v", value))
+	}
+
+	for _, value := range []string{"relative/path", "/media/usb/../snaps", "/srv/mirror/"} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]any{
+				"store.mirror": value,
+			},
+		})
+		c.Check(err, ErrorMatches, fmt.Sprintf(`store.mirror must be a clean absolute path, not %q`, value))
+	}
+}
+
+func (s *storeSuite) TestConfigureStoreMirrorUpdatesStore(c *C) {
+	s.state.Lock()
+	sto := &store.Store{}
+	snapstate.ReplaceStore(s.state, sto)
+	s.state.Unlock()
+
+	dir := c.MkDir()
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"store.mirror": dir,
+		},
+	})
+	c.Assert(err, IsNil)
+
+	s.state.Lock()
+	mirrorStore := snapstate.Store(s.state, nil)
+	s.state.Unlock()
+	c.Assert(mirrorStore, FitsTypeOf, &mirror.Store{})
+	c.Check(mirrorStore.(*mirror.Store).Dir(), Equals, dir)
+
+	err = configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]any{
+			"store.mirror": "",
+		},
+	})
+	c.Assert(err, IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(snapstate.Store(s.state, nil), Equals, sto)
+}
+
+func (s *storeSuite) TestConfigureStoreDownloadRateLimit(c *C) {
+	for _, conf := range []map[string]any{
+		{"store.download-rate-limit": ""},
+		{"store.download-rate-limit": "512kB"},
+		{"store.download-rate-limit": "2MB", "store.download-rate-limit-schedule": "9:00-17:00"},
+		{"store.download-rate-limit": "2MB", "store.download-rate-limit-schedule": "mon-fri,8:00-12:00,13:00-18:00"},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  conf,
+		})
+		c.Check(err, IsNil, Commentf("%v", conf))
+	}
+
+	for _, tc := range []struct {
+		conf map[string]any
+		err  string
+	}{
+		{map[string]any{"store.download-rate-limit": "fast"}, `cannot parse store.download-rate-limit: .*`},
+		{map[string]any{"store.download-rate-limit": "-1MB"}, `cannot parse store.download-rate-limit: .*`},
+		{map[string]any{"store.download-rate-limit-schedule": "25:00-26:00"}, `cannot parse store.download-rate-limit-schedule: .*`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf:  tc.conf,
+		})
+		c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.conf))
+	}
+}
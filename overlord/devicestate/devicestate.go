@@ -57,6 +57,7 @@ import (
 	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/snap/snapfile"
 	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 var (
@@ -161,6 +162,16 @@ func findKnownRevisionOfModel(st *state.State, mod *asserts.Model) (modRevision
 	return a.Revision(), nil
 }
 
+// deviceSerial returns the serial of the device, or an empty string if it
+// was not registered yet.
+func deviceSerial(st *state.State) (string, error) {
+	device, err := internal.Device(st)
+	if err != nil {
+		return "", err
+	}
+	return device.Serial, nil
+}
+
 // auto-refresh
 func canAutoRefresh(st *state.State) (bool, error) {
 	// we need to be seeded first
@@ -295,8 +306,10 @@ func delayedCrossMgrInit() {
 	})
 	snapstate.CanAutoRefresh = canAutoRefresh
 	snapstate.IsOnMeteredConnection = netutil.IsOnMeteredConnection
+	snapstate.DeviceIdleSince = timeutil.IdleSince
 	snapstate.DeviceCtx = DeviceCtx
 	snapstate.RemodelingChange = RemodelingChange
+	snapstate.DeviceSerial = deviceSerial
 }
 
 // proxyStore returns the store assertion for the proxy store if one is set.
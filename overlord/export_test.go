@@ -71,6 +71,14 @@ func MockPruneTicker(f func(t *time.Ticker) <-chan time.Time) (restore func()) {
 	}
 }
 
+// MockMinStateJournalCompactSize sets the size under which the state journal
+// is never compacted.
+func MockMinStateJournalCompactSize(size int64) (restore func()) {
+	restore = testutil.Backup(&minStateJournalCompactSize)
+	minStateJournalCompactSize = size
+	return restore
+}
+
 // MockEnsureNext sets o.ensureNext for tests.
 func MockEnsureNext(o *Overlord, t time.Time) {
 	o.ensureNext = t
@@ -20,7 +20,13 @@
 package healthstate
 
 import (
+	"context"
 	"time"
+
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
 )
 
 func MockCheckTimeout(t time.Duration) (restore func()) {
@@ -32,3 +38,42 @@ func MockCheckTimeout(t time.Duration) (restore func()) {
 }
 
 var KnownStatuses = knownStatuses
+
+var SetHealth = setHealth
+
+func MockHealthHistorySize(n int) (restore func()) {
+	restore = testutil.Backup(&healthHistorySize)
+	healthHistorySize = n
+	return restore
+}
+
+func MockTimeNow(f func() time.Time) (restore func()) {
+	restore = testutil.Backup(&timeNow)
+	timeNow = f
+	return restore
+}
+
+func MockSnapstateRevert(f func(st *state.State, name string, flags snapstate.Flags, fromChange string) (*state.TaskSet, error)) (restore func()) {
+	restore = testutil.Backup(&snapstateRevert)
+	snapstateRevert = f
+	return restore
+}
+
+func MockRunPeriodicCheck(f func(hookMgr *hookstate.HookManager, ctx context.Context, hooksup *hookstate.HookSetup) error) (restore func()) {
+	restore = testutil.Backup(&runPeriodicCheck)
+	runPeriodicCheck = f
+	return restore
+}
+
+// WaitChecks waits for the running periodic checks to be done.
+func (m *HealthManager) WaitChecks() {
+	m.checksWg.Wait()
+}
+
+func (m *HealthManager) EnsurePeriodicChecks() error {
+	return m.ensurePeriodicChecks()
+}
+
+func (m *HealthManager) EnsureHealthRevert() error {
+	return m.ensureHealthRevert()
+}
@@ -0,0 +1,420 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package healthstate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+	"github.com/snapcore/snapd/snap"
+)
+
+var (
+	revertSnapChangeKind = swfeats.RegisterChangeKind("revert-snap")
+
+	// healthRevertCheckInterval is the interval between checks for snaps
+	// to revert because of their health, as part of Ensure().
+	healthRevertCheckInterval = time.Minute
+
+	snapstateRevert = snapstate.Revert
+
+	// runPeriodicCheck runs the check-health hook without a change, so
+	// that frequent checks do not pile up changes in the state.
+	runPeriodicCheck = func(hookMgr *hookstate.HookManager, ctx context.Context, hooksup *hookstate.HookSetup) error {
+		_, err := hookMgr.EphemeralRunHook(ctx, hooksup, nil)
+		return err
+	}
+
+	timeNow = time.Now
+)
+
+func init() {
+	swfeats.RegisterEnsure("HealthManager", "ensurePeriodicChecks")
+	swfeats.RegisterEnsure("HealthManager", "ensureHealthRevert")
+}
+
+// HealthManager runs the check-health hook of snaps periodically, and
+// reverts snaps that stay in error after a refresh, if configured to.
+type HealthManager struct {
+	state   *state.State
+	hookMgr *hookstate.HookManager
+
+	// lastCheck is when the check-health hook of each snap was last run
+	// periodically, it is only kept in memory.
+	lastCheck map[string]time.Time
+	// nextCheckTime is when the next periodic check is due.
+	nextCheckTime time.Time
+
+	// checksMu protects running, the snaps whose periodic check is
+	// currently running.
+	checksMu sync.Mutex
+	running  map[string]bool
+	checksWg sync.WaitGroup
+	// stopChecks cancels the running periodic checks.
+	checksCtx  context.Context
+	stopChecks context.CancelFunc
+
+	lastRevertCheckTime time.Time
+}
+
+// Manager returns a new HealthManager.
+func Manager(st *state.State, hookMgr *hookstate.HookManager) *HealthManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HealthManager{
+		state:      st,
+		hookMgr:    hookMgr,
+		lastCheck:  make(map[string]time.Time),
+		running:    make(map[string]bool),
+		checksCtx:  ctx,
+		stopChecks: cancel,
+	}
+}
+
+// Ensure implements StateManager.Ensure.
+func (m *HealthManager) Ensure() error {
+	if err := m.ensurePeriodicChecks(); err != nil {
+		return err
+	}
+	if err := m.ensureHealthRevert(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Stop implements StateStopper. It stops the running periodic checks and
+// waits for them to be done.
+func (m *HealthManager) Stop() {
+	m.stopChecks()
+	m.checksWg.Wait()
+}
+
+// isRunning returns whether the periodic check of the given snap is running.
+func (m *HealthManager) isRunning(name string) bool {
+	m.checksMu.Lock()
+	defer m.checksMu.Unlock()
+	return m.running[name]
+}
+
+// startCheck runs the check-health hook of the snap in the background.
+func (m *HealthManager) startCheck(name string, rev snap.Revision) {
+	m.checksMu.Lock()
+	m.running[name] = true
+	m.checksMu.Unlock()
+
+	hooksup := hookSetup(name, rev)
+	m.checksWg.Add(1)
+	go func() {
+		defer m.checksWg.Done()
+		defer func() {
+			m.checksMu.Lock()
+			delete(m.running, name)
+			m.checksMu.Unlock()
+		}()
+		// the hook handler records the health, also when the hook fails
+		if err := runPeriodicCheck(m.hookMgr, m.checksCtx, hooksup); err != nil {
+			logger.Noticef("cannot run periodic health check of %q: %v", name, err)
+		}
+	}()
+}
+
+// checkInterval returns the interval at which the check-health hook of the
+// given snap asks to be run, or zero.
+func checkInterval(snapst *snapstate.SnapState) time.Duration {
+	if !snapst.Active {
+		return 0
+	}
+	info, err := snapst.CurrentInfo()
+	if err != nil {
+		return 0
+	}
+	hook := info.Hooks["check-health"]
+	if hook == nil {
+		return 0
+	}
+	return time.Duration(hook.CheckInterval)
+}
+
+// ensurePeriodicChecks runs the check-health hook of the snaps that declare
+// a check-interval for it, once the interval has passed since their health
+// was last checked. The hook is run in the background without a change, and
+// not while another change affects the snap.
+func (m *HealthManager) ensurePeriodicChecks() error {
+	now := timeNow()
+	if now.Before(m.nextCheckTime) {
+		return nil
+	}
+
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	snapStates, err := snapstate.All(m.state)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(snapStates))
+	for name := range snapStates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	logger.Trace("ensure", "manager", "HealthManager", "func", "ensurePeriodicChecks")
+
+	var next time.Time
+	for _, name := range names {
+		snapst := snapStates[name]
+		interval := checkInterval(snapst)
+		if interval == 0 {
+			delete(m.lastCheck, name)
+			continue
+		}
+		last, ok := m.lastCheck[name]
+		if !ok {
+			// the health set at install or refresh, or by snapctl,
+			// counts as a check
+			health, err := Get(m.state, name)
+			if err != nil {
+				return err
+			}
+			if health != nil {
+				last = health.Timestamp
+			}
+		}
+		due := last.Add(interval)
+		if now.Before(due) {
+			m.lastCheck[name] = last
+			if next.IsZero() || due.Before(next) {
+				next = due
+			}
+			continue
+		}
+		if m.isRunning(name) {
+			// the previous check has not finished yet
+			continue
+		}
+		if err := snapstate.CheckChangeConflict(m.state, name, nil); err != nil {
+			// try again once the other change is done
+			logger.Debugf("cannot run periodic health check of %q: %v", name, err)
+			continue
+		}
+		m.startCheck(name, snapst.Current)
+		m.lastCheck[name] = now
+		if due = now.Add(interval); next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+
+	// snaps can be installed or refreshed in the meantime, so look again
+	// in a while at most
+	if next.IsZero() || next.After(now.Add(healthRevertCheckInterval)) {
+		next = now.Add(healthRevertCheckInterval)
+	}
+	m.nextCheckTime = next
+	m.state.EnsureBefore(next.Sub(now))
+	return nil
+}
+
+// healthRevertAfter returns how long the health of a refreshed snap needs to
+// stay in error before the snap is reverted, or zero if it never is.
+func healthRevertAfter(st *state.State) (time.Duration, error) {
+	tr := config.NewTransaction(st)
+	var revertAfter string
+	if err := tr.Get("core", "refresh.health-revert-after", &revertAfter); err != nil && !config.IsNoOption(err) {
+		return 0, err
+	}
+	if revertAfter == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(revertAfter)
+	if err != nil {
+		return 0, fmt.Errorf("invalid refresh.health-revert-after: %v", err)
+	}
+	return d, nil
+}
+
+// refreshHealth tracks the health of the current revision of a snap since
+// the snap was last refreshed, to tell whether the refresh broke it. Unlike
+// the bounded health history, it covers all the health checks since the
+// refresh.
+type refreshHealth struct {
+	Revision    snap.Revision `json:"revision"`
+	RefreshTime time.Time     `json:"refresh-time"`
+	// ErrorSince is when the health first went into error after the
+	// refresh, if it was not healthy before.
+	ErrorSince time.Time `json:"error-since"`
+	// Healthy is set once the health was not in error after the refresh,
+	// as any later error is then not caused by the refresh.
+	Healthy bool `json:"healthy,omitempty"`
+	// Reverted is set once a revert from the revision was attempted, so
+	// that it is only attempted once.
+	Reverted bool `json:"reverted,omitempty"`
+}
+
+// matches returns whether rh is about the current revision of the snap and
+// its last refresh.
+func (rh *refreshHealth) matches(snapst *snapstate.SnapState) bool {
+	return rh.Revision == snapst.Current && snapst.LastRefreshTime != nil && rh.RefreshTime.Equal(*snapst.LastRefreshTime)
+}
+
+func allRefreshHealth(st *state.State) (map[string]*refreshHealth, error) {
+	var all map[string]*refreshHealth
+	if err := st.Get("health-refresh", &all); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	if all == nil {
+		all = make(map[string]*refreshHealth)
+	}
+	return all, nil
+}
+
+// trackRefreshHealth records the given health of the snap in the health
+// tracked since its last refresh.
+func trackRefreshHealth(st *state.State, instanceName string, health *HealthState) error {
+	var snapst snapstate.SnapState
+	if err := snapstate.Get(st, instanceName, &snapst); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return nil
+		}
+		return err
+	}
+	if snapst.LastRefreshTime == nil || health.Revision != snapst.Current || health.Timestamp.Before(*snapst.LastRefreshTime) {
+		return nil
+	}
+	all, err := allRefreshHealth(st)
+	if err != nil {
+		return err
+	}
+	rh := all[instanceName]
+	if rh == nil || !rh.matches(&snapst) {
+		rh = &refreshHealth{
+			Revision:    snapst.Current,
+			RefreshTime: *snapst.LastRefreshTime,
+		}
+	}
+	if health.Status != ErrorStatus {
+		rh.Healthy = true
+	} else if !rh.Healthy && rh.ErrorSince.IsZero() {
+		rh.ErrorSince = health.Timestamp
+	}
+	all[instanceName] = rh
+	st.Set("health-refresh", all)
+	return nil
+}
+
+// discardRefreshHealth forgets the health tracked since the last refresh of
+// the given snap, once it is removed.
+func discardRefreshHealth(st *state.State, instanceName string) error {
+	var all map[string]json.RawMessage
+	if err := st.Get("health-refresh", &all); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if _, ok := all[instanceName]; ok {
+		delete(all, instanceName)
+		st.Set("health-refresh", all)
+	}
+	return nil
+}
+
+// ensureHealthRevert reverts the snaps whose health has been in error ever
+// since they were refreshed, for longer than configured with
+// refresh.health-revert-after.
+func (m *HealthManager) ensureHealthRevert() error {
+	now := timeNow()
+	if now.Before(m.lastRevertCheckTime.Add(healthRevertCheckInterval)) {
+		return nil
+	}
+	m.lastRevertCheckTime = now
+
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	revertAfter, err := healthRevertAfter(m.state)
+	if err != nil {
+		logger.Noticef("cannot check for snaps to revert: %v", err)
+		return nil
+	}
+	if revertAfter == 0 {
+		return nil
+	}
+
+	healths, err := All(m.state)
+	if err != nil {
+		return err
+	}
+	refreshHealths, err := allRefreshHealth(m.state)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(healths))
+	for name, health := range healths {
+		if health.Status == ErrorStatus {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	logger.Trace("ensure", "manager", "HealthManager", "func", "ensureHealthRevert")
+
+	for _, name := range names {
+		var snapst snapstate.SnapState
+		if err := snapstate.Get(m.state, name, &snapst); err != nil {
+			continue
+		}
+		if !snapst.Active || healths[name].Revision != snapst.Current {
+			continue
+		}
+		rh := refreshHealths[name]
+		if rh == nil || !rh.matches(&snapst) || rh.Healthy || rh.Reverted || rh.ErrorSince.IsZero() {
+			continue
+		}
+		since := rh.ErrorSince
+		if now.Sub(since) < revertAfter {
+			continue
+		}
+		if err := snapstate.CheckChangeConflict(m.state, name, nil); err != nil {
+			logger.Debugf("cannot revert %q yet: %v", name, err)
+			continue
+		}
+		rh.Reverted = true
+		m.state.Set("health-refresh", refreshHealths)
+		ts, err := snapstateRevert(m.state, name, snapstate.Flags{}, "")
+		if err != nil {
+			logger.Noticef("cannot revert %q after its health stayed in error for %s: %v", name, now.Sub(since).Round(time.Second), err)
+			continue
+		}
+		logger.Noticef("reverting %q, its health stayed in error for %s after it was refreshed", name, now.Sub(since).Round(time.Second))
+		chg := m.state.NewChange(revertSnapChangeKind, fmt.Sprintf("Revert %q snap after failed health check", name))
+		chg.AddAll(ts)
+		chg.Set("snap-names", []string{name})
+	}
+
+	m.state.EnsureBefore(healthRevertCheckInterval)
+	return nil
+}
@@ -0,0 +1,417 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package healthstate_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/healthstate"
+	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type healthMgrSuite struct {
+	testutil.BaseTest
+
+	state *state.State
+	mgr   *healthstate.HealthManager
+	now   time.Time
+
+	reverted []string
+
+	checksMu sync.Mutex
+	checks   []*hookstate.HookSetup
+}
+
+var _ = check.Suite(&healthMgrSuite{})
+
+func (s *healthMgrSuite) SetUpTest(c *check.C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+
+	s.now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.AddCleanup(healthstate.MockTimeNow(func() time.Time { return s.now }))
+	s.reverted = nil
+	s.AddCleanup(healthstate.MockSnapstateRevert(func(st *state.State, name string, flags snapstate.Flags, fromChange string) (*state.TaskSet, error) {
+		s.reverted = append(s.reverted, name)
+		if name == "no-previous" {
+			return nil, errors.New("no revision to revert to")
+		}
+		return state.NewTaskSet(st.NewTask("fake-revert", "...")), nil
+	}))
+
+	s.checks = nil
+	s.AddCleanup(healthstate.MockRunPeriodicCheck(func(hookMgr *hookstate.HookManager, ctx context.Context, hooksup *hookstate.HookSetup) error {
+		s.checksMu.Lock()
+		defer s.checksMu.Unlock()
+		s.checks = append(s.checks, hooksup)
+		return nil
+	}))
+
+	s.state = state.New(nil)
+	s.mgr = healthstate.Manager(s.state, nil)
+}
+
+func (s *healthMgrSuite) mockSnap(c *check.C, snapYaml string, refreshed time.Time) {
+	info := snaptest.MockSnapCurrent(c, snapYaml, &snap.SideInfo{Revision: snap.R(2)})
+	si := &info.SideInfo
+	si.RealName = info.SnapName()
+	snapst := &snapstate.SnapState{
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{{RealName: si.RealName, Revision: snap.R(1)}, si}),
+		Current:  snap.R(2),
+		Active:   true,
+		SnapType: "app",
+	}
+	if !refreshed.IsZero() {
+		snapst.LastRefreshTime = &refreshed
+	}
+	s.state.Lock()
+	defer s.state.Unlock()
+	snapstate.Set(s.state, si.RealName, snapst)
+}
+
+func (s *healthMgrSuite) setHealth(c *check.C, name string, rev snap.Revision, status healthstate.HealthStatus) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Assert(healthstate.SetHealth(s.state, name, &healthstate.HealthState{
+		Revision:  rev,
+		Timestamp: s.now,
+		Status:    status,
+	}), check.IsNil)
+}
+
+// ensurePeriodicChecks runs the periodic checks due and waits for them.
+func (s *healthMgrSuite) ensurePeriodicChecks(c *check.C) {
+	c.Assert(s.mgr.EnsurePeriodicChecks(), check.IsNil)
+	s.mgr.WaitChecks()
+}
+
+func (s *healthMgrSuite) changes(kind string) []*state.Change {
+	s.state.Lock()
+	defer s.state.Unlock()
+	var chgs []*state.Change
+	for _, chg := range s.state.Changes() {
+		if chg.Kind() == kind {
+			chgs = append(chgs, chg)
+		}
+	}
+	return chgs
+}
+
+func (s *healthMgrSuite) TestPeriodicChecks(c *check.C) {
+	s.mockSnap(c, `name: periodic
+version: 1
+hooks:
+  check-health:
+    check-interval: 10m
+`, time.Time{})
+	s.mockSnap(c, `name: not-periodic
+version: 1
+hooks:
+  check-health:
+`, time.Time{})
+
+	// the health set after the snap was installed counts as a check
+	s.setHealth(c, "periodic", snap.R(2), healthstate.OkayStatus)
+
+	s.now = s.now.Add(5 * time.Minute)
+	s.ensurePeriodicChecks(c)
+	c.Check(s.checks, check.HasLen, 0)
+
+	s.now = s.now.Add(5 * time.Minute)
+	s.ensurePeriodicChecks(c)
+	c.Assert(s.checks, check.HasLen, 1)
+	c.Check(s.checks[0].Snap, check.Equals, "periodic")
+	c.Check(s.checks[0].Hook, check.Equals, "check-health")
+	c.Check(s.checks[0].Revision, check.Equals, snap.R(2))
+	c.Check(s.checks[0].Optional, check.Equals, true)
+
+	// the hook is run without a change
+	s.state.Lock()
+	c.Check(s.state.Changes(), check.HasLen, 0)
+	s.state.Unlock()
+
+	// not again until the interval has passed
+	s.now = s.now.Add(9 * time.Minute)
+	s.ensurePeriodicChecks(c)
+	c.Check(s.checks, check.HasLen, 1)
+
+	s.now = s.now.Add(time.Minute)
+	s.ensurePeriodicChecks(c)
+	c.Check(s.checks, check.HasLen, 2)
+}
+
+func (s *healthMgrSuite) TestPeriodicChecksNotConcurrent(c *check.C) {
+	s.mockSnap(c, `name: periodic
+version: 1
+hooks:
+  check-health:
+    check-interval: 10m
+`, time.Time{})
+
+	// the check takes longer than the interval
+	unblock := make(chan struct{})
+	restore := healthstate.MockRunPeriodicCheck(func(hookMgr *hookstate.HookManager, ctx context.Context, hooksup *hookstate.HookSetup) error {
+		s.checksMu.Lock()
+		s.checks = append(s.checks, hooksup)
+		s.checksMu.Unlock()
+		<-unblock
+		return nil
+	})
+	defer restore()
+
+	c.Assert(s.mgr.EnsurePeriodicChecks(), check.IsNil)
+	s.now = s.now.Add(10 * time.Minute)
+	c.Assert(s.mgr.EnsurePeriodicChecks(), check.IsNil)
+
+	close(unblock)
+	s.mgr.WaitChecks()
+	c.Check(s.checks, check.HasLen, 1)
+
+	s.now = s.now.Add(time.Minute)
+	s.ensurePeriodicChecks(c)
+	c.Check(s.checks, check.HasLen, 2)
+}
+
+func (s *healthMgrSuite) TestPeriodicChecksConflict(c *check.C) {
+	s.mockSnap(c, `name: periodic
+version: 1
+hooks:
+  check-health:
+    check-interval: 10m
+`, time.Time{})
+
+	// the hook manager tells which snaps hook tasks affect
+	_, err := hookstate.Manager(s.state, state.NewTaskRunner(s.state))
+	c.Assert(err, check.IsNil)
+
+	// the health check run after a refresh is still running
+	s.state.Lock()
+	chg := s.state.NewChange("refresh-snap", "...")
+	chg.AddTask(healthstate.Hook(s.state, "periodic", snap.R(2)))
+	s.state.Unlock()
+
+	s.ensurePeriodicChecks(c)
+	c.Check(s.checks, check.HasLen, 0)
+
+	s.state.Lock()
+	chg.SetStatus(state.DoneStatus)
+	s.state.Unlock()
+
+	s.now = s.now.Add(time.Minute)
+	s.ensurePeriodicChecks(c)
+	c.Check(s.checks, check.HasLen, 1)
+}
+
+func (s *healthMgrSuite) TestPeriodicChecksConflictWithRefresh(c *check.C) {
+	s.mockSnap(c, `name: periodic
+version: 1
+hooks:
+  check-health:
+    check-interval: 10m
+`, time.Time{})
+
+	// the hook manager tells which snaps hook tasks affect
+	_, err := hookstate.Manager(s.state, state.NewTaskRunner(s.state))
+	c.Assert(err, check.IsNil)
+
+	// a refresh of the snap is in progress
+	s.state.Lock()
+	refreshChg := s.state.NewChange("refresh-snap", "...")
+	refreshTask := s.state.NewTask("link-snap", "...")
+	refreshTask.Set("snap-setup", &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{RealName: "periodic", Revision: snap.R(3)},
+	})
+	refreshChg.AddTask(refreshTask)
+	s.state.Unlock()
+
+	// so no periodic check is started
+	s.now = s.now.Add(10 * time.Minute)
+	s.ensurePeriodicChecks(c)
+	c.Check(s.checks, check.HasLen, 0)
+
+	s.state.Lock()
+	refreshChg.SetStatus(state.DoneStatus)
+	s.state.Unlock()
+
+	s.now = s.now.Add(time.Minute)
+	s.ensurePeriodicChecks(c)
+	c.Check(s.checks, check.HasLen, 1)
+}
+
+func (s *healthMgrSuite) TestStopCancelsPeriodicChecks(c *check.C) {
+	s.mockSnap(c, `name: periodic
+version: 1
+hooks:
+  check-health:
+    check-interval: 10m
+`, time.Time{})
+
+	started := make(chan struct{})
+	restore := healthstate.MockRunPeriodicCheck(func(hookMgr *hookstate.HookManager, ctx context.Context, hooksup *hookstate.HookSetup) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	defer restore()
+
+	c.Assert(s.mgr.EnsurePeriodicChecks(), check.IsNil)
+	<-started
+	// returns once the check was cancelled
+	s.mgr.Stop()
+}
+
+func (s *healthMgrSuite) TestHealthRevertDisabledByDefault(c *check.C) {
+	s.mockSnap(c, "name: broken\nversion: 1", s.now)
+	s.now = s.now.Add(time.Minute)
+	s.setHealth(c, "broken", snap.R(2), healthstate.ErrorStatus)
+
+	s.now = s.now.Add(time.Hour)
+	c.Assert(s.mgr.EnsureHealthRevert(), check.IsNil)
+	c.Check(s.reverted, check.HasLen, 0)
+}
+
+func (s *healthMgrSuite) TestHealthRevert(c *check.C) {
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.health-revert-after", "10m")
+	tr.Commit()
+	s.state.Unlock()
+
+	s.mockSnap(c, "name: broken\nversion: 1", s.now)
+	// it was fine before the refresh
+	s.setHealth(c, "broken", snap.R(1), healthstate.OkayStatus)
+	// in error since the refresh, except for a while in between
+	s.mockSnap(c, "name: recovered\nversion: 1", s.now)
+	// in error, but was never refreshed
+	s.mockSnap(c, "name: never-refreshed\nversion: 1", time.Time{})
+	s.mockSnap(c, "name: no-previous\nversion: 1", s.now)
+
+	s.now = s.now.Add(time.Minute)
+	s.setHealth(c, "broken", snap.R(2), healthstate.ErrorStatus)
+	s.setHealth(c, "recovered", snap.R(2), healthstate.ErrorStatus)
+	s.setHealth(c, "never-refreshed", snap.R(2), healthstate.ErrorStatus)
+	s.setHealth(c, "no-previous", snap.R(2), healthstate.ErrorStatus)
+
+	s.now = s.now.Add(time.Minute)
+	s.setHealth(c, "recovered", snap.R(2), healthstate.OkayStatus)
+
+	// not in error for long enough yet
+	s.now = s.now.Add(8 * time.Minute)
+	c.Assert(s.mgr.EnsureHealthRevert(), check.IsNil)
+	c.Check(s.reverted, check.HasLen, 0)
+
+	s.now = s.now.Add(time.Minute)
+	s.setHealth(c, "broken", snap.R(2), healthstate.ErrorStatus)
+	s.setHealth(c, "recovered", snap.R(2), healthstate.ErrorStatus)
+	c.Assert(s.mgr.EnsureHealthRevert(), check.IsNil)
+	c.Check(s.reverted, check.DeepEquals, []string{"broken", "no-previous"})
+
+	chgs := s.changes("revert-snap")
+	c.Assert(chgs, check.HasLen, 1)
+	s.state.Lock()
+	c.Check(chgs[0].Summary(), check.Equals, `Revert "broken" snap after failed health check`)
+	var snapNames []string
+	c.Assert(chgs[0].Get("snap-names", &snapNames), check.IsNil)
+	c.Check(snapNames, check.DeepEquals, []string{"broken"})
+	chgs[0].SetStatus(state.DoneStatus)
+	s.state.Unlock()
+
+	// a revert is only attempted once per revision
+	s.now = s.now.Add(time.Hour)
+	c.Assert(s.mgr.EnsureHealthRevert(), check.IsNil)
+	c.Check(s.reverted, check.DeepEquals, []string{"broken", "no-previous"})
+}
+
+func (s *healthMgrSuite) setHealthRevertAfter(c *check.C, revertAfter string) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.health-revert-after", revertAfter)
+	tr.Commit()
+}
+
+func (s *healthMgrSuite) TestHealthRevertLongAfterRefresh(c *check.C) {
+	s.setHealthRevertAfter(c, "10m")
+
+	// healthy after the refresh, and then in error for more checks than
+	// the history keeps
+	s.mockSnap(c, "name: foo\nversion: 1", s.now)
+	s.now = s.now.Add(time.Minute)
+	s.setHealth(c, "foo", snap.R(2), healthstate.OkayStatus)
+	for i := 0; i < 30; i++ {
+		s.now = s.now.Add(5 * time.Minute)
+		s.setHealth(c, "foo", snap.R(2), healthstate.ErrorStatus)
+	}
+	c.Assert(s.mgr.EnsureHealthRevert(), check.IsNil)
+	c.Check(s.reverted, check.HasLen, 0)
+}
+
+func (s *healthMgrSuite) TestHealthRevertAfterMoreErrorsThanHistory(c *check.C) {
+	// a long time compared to the check interval
+	s.setHealthRevertAfter(c, "3h")
+
+	s.mockSnap(c, "name: broken\nversion: 1", s.now)
+	start := s.now
+	for s.now.Sub(start) < 3*time.Hour {
+		s.now = s.now.Add(5 * time.Minute)
+		s.setHealth(c, "broken", snap.R(2), healthstate.ErrorStatus)
+	}
+	c.Assert(s.mgr.EnsureHealthRevert(), check.IsNil)
+	c.Check(s.reverted, check.HasLen, 0)
+
+	s.now = s.now.Add(5 * time.Minute)
+	s.setHealth(c, "broken", snap.R(2), healthstate.ErrorStatus)
+	c.Assert(s.mgr.EnsureHealthRevert(), check.IsNil)
+	c.Check(s.reverted, check.DeepEquals, []string{"broken"})
+
+	// the revert is attempted only once, also after a restart
+	s.mgr = healthstate.Manager(s.state, nil)
+	s.now = s.now.Add(time.Hour)
+	c.Assert(s.mgr.EnsureHealthRevert(), check.IsNil)
+	c.Check(s.reverted, check.DeepEquals, []string{"broken"})
+
+	// until the snap is refreshed again
+	s.state.Lock()
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "broken", &snapst), check.IsNil)
+	refreshed := s.now
+	snapst.LastRefreshTime = &refreshed
+	snapstate.Set(s.state, "broken", &snapst)
+	s.state.Unlock()
+	s.now = s.now.Add(time.Minute)
+	s.setHealth(c, "broken", snap.R(2), healthstate.ErrorStatus)
+	s.now = s.now.Add(3 * time.Hour)
+	c.Assert(s.mgr.EnsureHealthRevert(), check.IsNil)
+	c.Check(s.reverted, check.DeepEquals, []string{"broken", "broken"})
+}
@@ -35,7 +35,12 @@ import (
 	"github.com/snapcore/snapd/strutil"
 )
 
-var checkTimeout = 30 * time.Second
+var (
+	checkTimeout = 30 * time.Second
+	// healthHistorySize is the number of health states kept in the
+	// history of each snap.
+	healthHistorySize = 20
+)
 
 func init() {
 	if s, ok := os.LookupEnv("SNAPD_CHECK_HEALTH_HOOK_TIMEOUT"); ok {
@@ -47,19 +52,22 @@ func init() {
 	}
 
 	snapstate.CheckHealthHook = Hook
+	snapstate.DiscardSnapHealth = discardHealth
 }
 
-func Hook(st *state.State, snapName string, snapRev snap.Revision) *state.Task {
-	summary := fmt.Sprintf("Run health check of %q snap", snapName)
-	hooksup := &hookstate.HookSetup{
+func hookSetup(snapName string, snapRev snap.Revision) *hookstate.HookSetup {
+	return &hookstate.HookSetup{
 		Snap:     snapName,
 		Revision: snapRev,
 		Hook:     "check-health",
 		Optional: true,
 		Timeout:  checkTimeout,
 	}
+}
 
-	return hookstate.HookTask(st, summary, hooksup, nil)
+func Hook(st *state.State, snapName string, snapRev snap.Revision) *state.Task {
+	summary := fmt.Sprintf("Run health check of %q snap", snapName)
+	return hookstate.HookTask(st, summary, hookSetup(snapName, snapRev), nil)
 }
 
 type HealthStatus int
@@ -165,8 +173,12 @@ func (h *healthHandler) appendHealth(health *HealthState) error {
 }
 
 func appendHealth(ctx *hookstate.Context, health *HealthState) error {
-	st := ctx.State()
+	return setHealth(ctx.State(), ctx.InstanceName(), health)
+}
 
+// setHealth saves the given health as the current one of the snap, appends it
+// to its bounded history and tracks it since the last refresh of the snap.
+func setHealth(st *state.State, instanceName string, health *HealthState) error {
 	var hs map[string]*HealthState
 	if err := st.Get("health", &hs); err != nil {
 		if !errors.Is(err, state.ErrNoState) {
@@ -174,10 +186,24 @@ func appendHealth(ctx *hookstate.Context, health *HealthState) error {
 		}
 		hs = map[string]*HealthState{}
 	}
-	hs[ctx.InstanceName()] = health
+	hs[instanceName] = health
 	st.Set("health", hs)
 
-	return nil
+	var history map[string][]*HealthState
+	if err := st.Get("health-history", &history); err != nil {
+		if !errors.Is(err, state.ErrNoState) {
+			return err
+		}
+		history = map[string][]*HealthState{}
+	}
+	snapHistory := append(history[instanceName], health)
+	if len(snapHistory) > healthHistorySize {
+		snapHistory = snapHistory[len(snapHistory)-healthHistorySize:]
+	}
+	history[instanceName] = snapHistory
+	st.Set("health-history", history)
+
+	return trackRefreshHealth(st, instanceName, health)
 }
 
 // SetFromHookContext extracts the health of a snap from a hook
@@ -225,3 +251,35 @@ func Get(st *state.State, snap string) (*HealthState, error) {
 
 	return &health, nil
 }
+
+// discardHealth removes the current health and the health history of the
+// given snap, once it is removed.
+func discardHealth(st *state.State, instanceName string) error {
+	var hs map[string]json.RawMessage
+	if err := st.Get("health", &hs); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if _, ok := hs[instanceName]; ok {
+		delete(hs, instanceName)
+		st.Set("health", hs)
+	}
+
+	var history map[string]json.RawMessage
+	if err := st.Get("health-history", &history); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if _, ok := history[instanceName]; ok {
+		delete(history, instanceName)
+		st.Set("health-history", history)
+	}
+	return discardRefreshHealth(st, instanceName)
+}
+
+// History returns the last health states of the given snap, oldest first.
+func History(st *state.State, snap string) ([]*HealthState, error) {
+	var history map[string][]*HealthState
+	if err := st.Get("health-history", &history); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return history[snap], nil
+}
@@ -199,6 +199,10 @@ func (s *healthSuite) testHealth(c *check.C, cond healthHookTestCondition) {
 	}
 }
 
+func (s *healthSuite) TestEnsureLoopLogging(c *check.C) {
+	testutil.CheckEnsureLoopLogging("healthmgr.go", c, true)
+}
+
 func (*healthSuite) TestStatusHappy(c *check.C) {
 	for i, str := range healthstate.KnownStatuses {
 		status, err := healthstate.StatusLookup(str)
@@ -253,3 +257,80 @@ func (s *healthSuite) TestSetFromHookContextEmpty(c *check.C) {
 	// no health in the context -> no health in state
 	c.Check(s.state.Get("health", &hs), testutil.ErrorIs, state.ErrNoState)
 }
+
+func (s *healthSuite) TestHistory(c *check.C) {
+	defer healthstate.MockHealthHistorySize(3)()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	history, err := healthstate.History(s.state, "test-snap")
+	c.Assert(err, check.IsNil)
+	c.Check(history, check.HasLen, 0)
+
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		health := &healthstate.HealthState{
+			Revision:  snap.R(42),
+			Timestamp: t0.Add(time.Duration(i) * time.Minute),
+			Status:    healthstate.HealthStatus(i + 1),
+		}
+		c.Assert(healthstate.SetHealth(s.state, "test-snap", health), check.IsNil)
+	}
+	c.Assert(healthstate.SetHealth(s.state, "other-snap", &healthstate.HealthState{Status: healthstate.OkayStatus}), check.IsNil)
+
+	// only the last states are kept, oldest first
+	history, err = healthstate.History(s.state, "test-snap")
+	c.Assert(err, check.IsNil)
+	c.Check(history, check.DeepEquals, []*healthstate.HealthState{
+		{Revision: snap.R(42), Timestamp: t0.Add(time.Minute), Status: healthstate.WaitingStatus},
+		{Revision: snap.R(42), Timestamp: t0.Add(2 * time.Minute), Status: healthstate.BlockedStatus},
+		{Revision: snap.R(42), Timestamp: t0.Add(3 * time.Minute), Status: healthstate.ErrorStatus},
+	})
+	// and the current health is the last one
+	health, err := healthstate.Get(s.state, "test-snap")
+	c.Assert(err, check.IsNil)
+	c.Check(health, check.DeepEquals, history[2])
+
+	history, err = healthstate.History(s.state, "other-snap")
+	c.Assert(err, check.IsNil)
+	c.Check(history, check.HasLen, 1)
+}
+
+func (s *healthSuite) TestDiscardSnapHealth(c *check.C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for _, name := range []string{"test-snap", "other-snap"} {
+		c.Assert(healthstate.SetHealth(s.state, name, &healthstate.HealthState{Status: healthstate.OkayStatus}), check.IsNil)
+	}
+	s.state.Set("health-refresh", map[string]any{
+		"test-snap":  map[string]any{"revision": "1"},
+		"other-snap": map[string]any{"revision": "1"},
+	})
+
+	// set by healthstate, called when the last revision of a snap is removed
+	c.Assert(snapstate.DiscardSnapHealth(s.state, "test-snap"), check.IsNil)
+
+	health, err := healthstate.Get(s.state, "test-snap")
+	c.Assert(err, check.IsNil)
+	c.Check(health, check.IsNil)
+	history, err := healthstate.History(s.state, "test-snap")
+	c.Assert(err, check.IsNil)
+	c.Check(history, check.HasLen, 0)
+
+	// other snaps are left alone
+	health, err = healthstate.Get(s.state, "other-snap")
+	c.Assert(err, check.IsNil)
+	c.Check(health, check.NotNil)
+	history, err = healthstate.History(s.state, "other-snap")
+	c.Assert(err, check.IsNil)
+	c.Check(history, check.HasLen, 1)
+	var refreshHealth map[string]any
+	c.Assert(s.state.Get("health-refresh", &refreshHealth), check.IsNil)
+	c.Check(refreshHealth, check.HasLen, 1)
+	c.Check(refreshHealth["other-snap"], check.NotNil)
+
+	// and unknown snaps are fine
+	c.Assert(snapstate.DiscardSnapHealth(s.state, "unknown-snap"), check.IsNil)
+}
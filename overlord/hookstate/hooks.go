@@ -40,6 +40,7 @@ func init() {
 	snapstate.SetupRemoveComponentHook = SetupRemoveComponentHook
 	snapstate.SetupPreRefreshHook = SetupPreRefreshHook
 	snapstate.SetupPostRefreshHook = SetupPostRefreshHook
+	snapstate.SetupPostRefreshCheckHook = SetupPostRefreshCheckHook
 	snapstate.SetupRemoveHook = SetupRemoveHook
 	snapstate.SetupGateAutoRefreshHook = SetupGateAutoRefreshHook
 }
@@ -125,6 +126,22 @@ func SetupPostRefreshHook(st *state.State, snapName string) *state.Task {
 	return HookTask(st, summary, hooksup, nil)
 }
 
+// postRefreshCheckTimeout is how long the post-refresh-check hook may run
+// before the check is considered failed.
+var postRefreshCheckTimeout = 5 * time.Minute
+
+func SetupPostRefreshCheckHook(st *state.State, snapName string) *state.Task {
+	hooksup := &HookSetup{
+		Snap:     snapName,
+		Hook:     "post-refresh-check",
+		Optional: true,
+		Timeout:  postRefreshCheckTimeout,
+	}
+
+	summary := fmt.Sprintf(i18n.G("Run post-refresh-check hook of %q snap if present"), hooksup.Snap)
+	return HookTask(st, summary, hooksup, nil)
+}
+
 func SetupPreRefreshHook(st *state.State, snapName string) *state.Task {
 	hooksup := &HookSetup{
 		Snap:     snapName,
@@ -383,6 +400,7 @@ func setupHooks(hookMgr *HookManager) {
 
 	hookMgr.Register(regexp.MustCompile("^install$"), handlerGenerator)
 	hookMgr.Register(regexp.MustCompile("^post-refresh$"), handlerGenerator)
+	hookMgr.Register(regexp.MustCompile("^post-refresh-check$"), handlerGenerator)
 	hookMgr.Register(regexp.MustCompile("^pre-refresh$"), handlerGenerator)
 	hookMgr.Register(regexp.MustCompile("^remove$"), handlerGenerator)
 	hookMgr.Register(regexp.MustCompile("^gate-auto-refresh$"), gateAutoRefreshHandlerGenerator)
@@ -537,3 +537,72 @@ func (s *gateAutoRefreshHookSuite) TestGateAutorefreshHookErrorHoldErrorLogged(c
 	c.Check(hint, Equals, runinhibit.HintNotInhibited)
 	c.Check(info, Equals, runinhibit.InhibitInfo{})
 }
+
+const snapWithPostRefreshCheckYaml = `name: snap-c
+version: 1
+hooks:
+    post-refresh-check:
+`
+
+type postRefreshCheckHookSuite struct {
+	baseHookManagerSuite
+}
+
+var _ = Suite(&postRefreshCheckHookSuite{})
+
+func (s *postRefreshCheckHookSuite) SetUpTest(c *C) {
+	s.commonSetUpTest(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	si := &snap.SideInfo{RealName: "snap-c", SnapID: "snap-c-id", Revision: snap.R(2)}
+	snaptest.MockSnap(c, snapWithPostRefreshCheckYaml, si)
+	snapstate.Set(s.state, "snap-c", &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:  snap.R(2),
+	})
+}
+
+func (s *postRefreshCheckHookSuite) TearDownTest(c *C) {
+	s.commonTearDownTest(c)
+}
+
+func (s *postRefreshCheckHookSuite) TestSetupPostRefreshCheckHook(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	task := hookstate.SetupPostRefreshCheckHook(s.state, "snap-c")
+	c.Check(task.Kind(), Equals, "run-hook")
+	c.Check(task.Summary(), Equals, `Run post-refresh-check hook of "snap-c" snap if present`)
+
+	var hooksup hookstate.HookSetup
+	c.Assert(task.Get("hook-setup", &hooksup), IsNil)
+	c.Check(hooksup, DeepEquals, hookstate.HookSetup{
+		Snap:     "snap-c",
+		Hook:     "post-refresh-check",
+		Optional: true,
+		Timeout:  5 * time.Minute,
+	})
+}
+
+func (s *postRefreshCheckHookSuite) TestPostRefreshCheckHookFailure(c *C) {
+	cmd := testutil.MockCommand(c, "snap", ">&2 echo 'service is not answering'; exit 1")
+	defer cmd.Restore()
+
+	s.state.Lock()
+	task := hookstate.SetupPostRefreshCheckHook(s.state, "snap-c")
+	chg := s.state.NewChange("refresh", "...")
+	chg.AddTask(task)
+	s.state.Unlock()
+
+	c.Assert(s.o.Settle(5*time.Second), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(cmd.Calls(), DeepEquals, [][]string{{"snap", "run", "--hook", "post-refresh-check", "-r", "unset", "snap-c"}})
+	c.Check(task.Status(), Equals, state.ErrorStatus)
+	c.Check(chg.Err(), ErrorMatches, `(?s).*service is not answering.*`)
+}
@@ -0,0 +1,403 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package apparmorprompting
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces/prompting"
+	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
+	"github.com/snapcore/snapd/logger"
+)
+
+var auditTimeNow = time.Now
+
+// maxAuditLogSize is the size above which the audit log is rotated. Only the
+// last rotated log is kept, so the audit log takes up at most about twice
+// this size.
+var maxAuditLogSize int64 = 1024 * 1024
+
+// auditReadChunkSize is the size of the chunks in which the audit log is read
+// from its end.
+var auditReadChunkSize = 64 * 1024
+
+// AuditSource is the source of a decision recorded in the audit log.
+type AuditSource string
+
+const (
+	// AuditSourceRule indicates that the permissions were allowed or denied
+	// by the rule whose ID is recorded in the entry.
+	AuditSourceRule AuditSource = "rule"
+	// AuditSourceReply indicates that the user replied to the prompt whose ID
+	// is recorded in the entry. If the reply created a rule, its ID is
+	// recorded as well.
+	AuditSourceReply AuditSource = "reply"
+	// AuditSourceTimeout indicates that the prompt whose ID is recorded in
+	// the entry expired before the user replied to it, so the permissions
+	// were denied.
+	AuditSourceTimeout AuditSource = "timeout"
+	// AuditSourceDefault indicates that the permissions were denied without
+	// being matched by any rule, since another permission of the same request
+	// was denied, or since the request could not be checked against the
+	// existing rules.
+	AuditSourceDefault AuditSource = "default"
+)
+
+var auditSources = []AuditSource{
+	AuditSourceRule,
+	AuditSourceReply,
+	AuditSourceTimeout,
+	AuditSourceDefault,
+}
+
+// ValidateAuditSource returns an error if the given audit source is not one
+// which is recorded in the audit log.
+func ValidateAuditSource(source AuditSource) error {
+	for _, s := range auditSources {
+		if source == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid audit source: %q", source)
+}
+
+// AuditEntry records the decision taken for some or all of the permissions of
+// a request.
+type AuditEntry struct {
+	Timestamp   time.Time             `json:"timestamp"`
+	User        uint32                `json:"user"`
+	Snap        string                `json:"snap"`
+	Interface   string                `json:"interface"`
+	Path        string                `json:"path"`
+	Permissions []string              `json:"permissions"`
+	Outcome     prompting.OutcomeType `json:"outcome"`
+	Source      AuditSource           `json:"source"`
+	RuleID      prompting.IDType      `json:"rule-id,omitempty"`
+	PromptID    prompting.IDType      `json:"prompt-id,omitempty"`
+}
+
+// AuditFilter selects the entries returned by AuditLog. Fields with their
+// zero value match any entry.
+type AuditFilter struct {
+	// User is the ID of the user whose entries should be returned. Since
+	// requests from the root user are never prompted for, zero matches the
+	// entries of all users.
+	User      uint32
+	Snap      string
+	Interface string
+	Source    AuditSource
+	// Since causes only entries recorded at or after the given time to be
+	// returned.
+	Since time.Time
+	// Limit causes only the given number of most recent matching entries
+	// to be returned.
+	Limit int
+}
+
+func (f *AuditFilter) matches(entry *AuditEntry) bool {
+	if f == nil {
+		return true
+	}
+	switch {
+	case f.User != 0 && entry.User != f.User:
+		return false
+	case f.Snap != "" && entry.Snap != f.Snap:
+		return false
+	case f.Interface != "" && entry.Interface != f.Interface:
+		return false
+	case f.Source != "" && entry.Source != f.Source:
+		return false
+	case !f.Since.IsZero() && entry.Timestamp.Before(f.Since):
+		return false
+	}
+	return true
+}
+
+type pendingPrompt struct {
+	user   uint32
+	prompt *requestprompts.Prompt
+}
+
+// auditLog is an append-only log of the decisions taken for requests, stored
+// as one JSON object per line. Once the log grows above maxAuditLogSize, it is
+// moved aside, replacing the previously rotated log.
+type auditLog struct {
+	mutex sync.Mutex
+	path  string
+	// pending holds the prompts which are awaiting a decision, so that an
+	// entry can be recorded if they expire, since the prompt DB only reports
+	// the ID of expired prompts.
+	pending map[prompting.IDType]*pendingPrompt
+}
+
+func newAuditLog() *auditLog {
+	return &auditLog{
+		path:    filepath.Join(dirs.SnapInterfacesRequestsStateDir, "audit.log"),
+		pending: make(map[prompting.IDType]*pendingPrompt),
+	}
+}
+
+// record appends the given entries to the audit log, setting their timestamp
+// to the current time.
+//
+// Errors are logged rather than returned, as failing to record an entry must
+// not prevent a reply from being sent for the request.
+func (l *auditLog) record(entries ...*AuditEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.appendEntries(entries); err != nil {
+		logger.Noticef("cannot record prompting audit entries: %v", err)
+	}
+}
+
+func (l *auditLog) appendEntries(entries []*AuditEntry) error {
+	now := auditTimeNow()
+	var data []byte
+	for _, entry := range entries {
+		entry.Timestamp = now
+		entryJSON, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		data = append(data, entryJSON...)
+		data = append(data, '\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if fi.Size() > maxAuditLogSize {
+		return os.Rename(l.path, l.rotatedPath())
+	}
+	return nil
+}
+
+func (l *auditLog) rotatedPath() string {
+	return l.path + ".1"
+}
+
+// entries returns the entries in the audit log which match the given filter,
+// in the order in which they were recorded.
+//
+// The log is read from its end, and only as far back as needed for the Since
+// and Limit of the filter, as entries are recorded in chronological order.
+func (l *auditLog) entries(filter *AuditFilter) ([]*AuditEntry, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var newestFirst []*AuditEntry
+	done := false
+	handleLine := func(line []byte) bool {
+		var entry AuditEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			// An entry may have been truncated if snapd was interrupted
+			// while writing it, so skip it rather than discarding the
+			// whole log.
+			logger.Noticef("cannot decode prompting audit entry: %v", err)
+			return true
+		}
+		if filter != nil && !filter.Since.IsZero() && entry.Timestamp.Before(filter.Since) {
+			done = true
+			return false
+		}
+		if !filter.matches(&entry) {
+			return true
+		}
+		newestFirst = append(newestFirst, &entry)
+		if filter != nil && filter.Limit > 0 && len(newestFirst) >= filter.Limit {
+			done = true
+			return false
+		}
+		return true
+	}
+	for _, path := range []string{l.path, l.rotatedPath()} {
+		if err := readLinesFromEnd(path, handleLine); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if done {
+			break
+		}
+	}
+
+	entries := make([]*AuditEntry, 0, len(newestFirst))
+	for i := len(newestFirst) - 1; i >= 0; i-- {
+		entries = append(entries, newestFirst[i])
+	}
+	return entries, nil
+}
+
+// readLinesFromEnd calls f with the non-empty lines of the file at the given
+// path, starting from the last one, until f returns false.
+func readLinesFromEnd(path string, f func(line []byte) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	chunk := make([]byte, auditReadChunkSize)
+	// partial holds the start of the line which the read chunks begin in
+	var partial []byte
+	for offset := fi.Size(); offset > 0; {
+		n := int64(len(chunk))
+		if n > offset {
+			n = offset
+		}
+		offset -= n
+		if _, err := file.ReadAt(chunk[:n], offset); err != nil {
+			return err
+		}
+		partial = append(append([]byte(nil), chunk[:n]...), partial...)
+		for {
+			i := bytes.LastIndexByte(partial, '\n')
+			if i < 0 {
+				break
+			}
+			line := partial[i+1:]
+			partial = partial[:i]
+			if len(line) > 0 && !f(line) {
+				return nil
+			}
+		}
+	}
+	if len(partial) > 0 {
+		f(partial)
+	}
+	return nil
+}
+
+// addPendingPrompt keeps track of the given prompt until a decision is taken
+// for it.
+func (l *auditLog) addPendingPrompt(user uint32, prompt *requestprompts.Prompt) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.pending[prompt.ID] = &pendingPrompt{
+		user:   user,
+		prompt: prompt,
+	}
+}
+
+// promptResolved stops keeping track of the prompt with the given ID, and
+// records an entry if the prompt expired.
+//
+// Entries for prompts which were replied to or satisfied by rules are
+// recorded by the manager, since only it knows the outcome of the decision.
+func (l *auditLog) promptResolved(user uint32, id prompting.IDType, resolved string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	pending, ok := l.pending[id]
+	if !ok || pending.user != user {
+		return
+	}
+	delete(l.pending, id)
+	if resolved != "expired" {
+		return
+	}
+	permissions := pending.prompt.Constraints.OutstandingPermissions()
+	if len(permissions) == 0 {
+		return
+	}
+	entry := &AuditEntry{
+		User:        user,
+		Snap:        pending.prompt.Snap,
+		Interface:   pending.prompt.Interface,
+		Path:        pending.prompt.Constraints.Path(),
+		Permissions: permissions,
+		Outcome:     prompting.OutcomeDeny,
+		Source:      AuditSourceTimeout,
+		PromptID:    id,
+	}
+	if err := l.appendEntries([]*AuditEntry{entry}); err != nil {
+		logger.Noticef("cannot record prompting audit entries: %v", err)
+	}
+}
+
+// ruleDecisionEntries returns the entries recording the decisions for the
+// given permissions of a request, grouped by the ID of the rule which decided
+// each permission, if any, and by outcome.
+//
+// The given ruleIDs map from permission to the ID of the rule which decided
+// it, and outcomes map from permission to the outcome of that rule. Any
+// permissions without a rule are recorded as denied by default.
+func ruleDecisionEntries(template AuditEntry, permissions []string, ruleIDs map[string]prompting.IDType, outcomes map[string]prompting.OutcomeType) []*AuditEntry {
+	type decisionKey struct {
+		ruleID  prompting.IDType
+		outcome prompting.OutcomeType
+	}
+	var entries []*AuditEntry
+	byKey := make(map[decisionKey]*AuditEntry)
+	for _, perm := range permissions {
+		ruleID, matched := ruleIDs[perm]
+		key := decisionKey{
+			ruleID:  ruleID,
+			outcome: outcomes[perm],
+		}
+		if !matched {
+			key = decisionKey{outcome: prompting.OutcomeDeny}
+		}
+		entry, ok := byKey[key]
+		if !ok {
+			newEntry := template
+			newEntry.Permissions = nil
+			newEntry.Outcome = key.outcome
+			newEntry.RuleID = key.ruleID
+			newEntry.Source = AuditSourceRule
+			if !matched {
+				newEntry.Source = AuditSourceDefault
+			}
+			entry = &newEntry
+			byKey[key] = entry
+			entries = append(entries, entry)
+		}
+		entry.Permissions = append(entry.Permissions, perm)
+	}
+	return entries
+}
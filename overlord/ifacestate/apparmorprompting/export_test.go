@@ -20,6 +20,7 @@
 package apparmorprompting
 
 import (
+	"os/user"
 	"time"
 
 	"github.com/snapcore/snapd/interfaces/prompting"
@@ -108,6 +109,16 @@ func MockPromptingInterfaceFromTagsets(f func(tagsets notify.TagsetMap) (string,
 	return testutil.Mock(&promptingInterfaceFromTagsets, f)
 }
 
+func MockPersonalFilesPaths(f func(st *state.State, userID uint32, snap string) ([]string, error)) (restore func()) {
+	return testutil.Mock(&personalFilesPaths, f)
+}
+
+func MockUserLookupId(f func(uid string) (*user.User, error)) (restore func()) {
+	return testutil.Mock(&userLookupId, f)
+}
+
+var PersonalFilesPathsFromRepo = personalFilesPathsFromRepo
+
 func (m *InterfacesRequestsManager) PromptDB() *requestprompts.PromptDB {
 	return m.prompts
 }
@@ -116,6 +127,34 @@ func (m *InterfacesRequestsManager) RuleDB() *requestrules.RuleDB {
 	return m.rules
 }
 
+// NotifyPrompt calls the closure which the prompt DB calls to record a prompt
+// notice, so that tests can simulate prompts expiring.
+func (m *InterfacesRequestsManager) NotifyPrompt(userID uint32, promptID prompting.IDType, data map[string]string) error {
+	return m.notifyPrompt(userID, promptID, data)
+}
+
+func MockAuditTimeNow(f func() time.Time) (restore func()) {
+	return testutil.Mock(&auditTimeNow, f)
+}
+
+func MockMaxAuditLogSize(size int64) (restore func()) {
+	return testutil.Mock(&maxAuditLogSize, size)
+}
+
+func MockAuditReadChunkSize(size int) (restore func()) {
+	return testutil.Mock(&auditReadChunkSize, size)
+}
+
+var NewAuditLog = newAuditLog
+
+func (l *auditLog) Record(entries ...*AuditEntry) {
+	l.record(entries...)
+}
+
+func (l *auditLog) Entries(filter *AuditFilter) ([]*AuditEntry, error) {
+	return l.entries(filter)
+}
+
 var (
 	NewNoticeBackends   = newNoticeBackends
 	RegisterWithManager = (*noticeBackends).registerWithManager
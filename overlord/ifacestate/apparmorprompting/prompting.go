@@ -22,6 +22,9 @@ package apparmorprompting
 import (
 	"errors"
 	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
 	"sync"
 
 	"gopkg.in/tomb.v2"
@@ -29,9 +32,11 @@ import (
 	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/interfaces/prompting"
 	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
+	"github.com/snapcore/snapd/interfaces/prompting/patterns"
 	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
 	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify/listener"
 	"github.com/snapcore/snapd/snap/naming"
@@ -47,8 +52,113 @@ var (
 	promptsHandleReadying = (*requestprompts.PromptDB).HandleReadying
 
 	promptingInterfaceFromTagsets = prompting.InterfaceFromTagsets
+
+	personalFilesPaths = personalFilesPathsFromRepo
+
+	userLookupId = user.LookupId
 )
 
+// personalFilesPathsFromRepo returns the paths declared by the connected
+// personal-files plugs of the given snap, with $HOME expanded to the home
+// directory of the given user.
+func personalFilesPathsFromRepo(st *state.State, userID uint32, snap string) ([]string, error) {
+	st.Lock()
+	repo := ifacerepo.Get(st)
+	st.Unlock()
+
+	var declared []string
+	for _, plug := range repo.Plugs(snap) {
+		if plug.Interface != "personal-files" {
+			continue
+		}
+		conns, err := repo.Connected(snap, plug.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(conns) == 0 {
+			continue
+		}
+		for _, attr := range []string{"read", "write"} {
+			var paths []any
+			if err := plug.Attr(attr, &paths); err != nil {
+				continue
+			}
+			for _, p := range paths {
+				if path, ok := p.(string); ok {
+					declared = append(declared, path)
+				}
+			}
+		}
+	}
+	if len(declared) == 0 {
+		return nil, nil
+	}
+
+	u, err := userLookupId(strconv.FormatUint(uint64(userID), 10))
+	if err != nil {
+		return nil, fmt.Errorf("cannot look up home directory of user %d: %w", userID, err)
+	}
+	paths := make([]string, 0, len(declared))
+	for _, path := range declared {
+		paths = append(paths, strings.Replace(path, "$HOME", u.HomeDir, 1))
+	}
+	return paths, nil
+}
+
+type personalFilesKey struct {
+	userID uint32
+	snap   string
+}
+
+// personalFilesCache caches the personal-files paths of snaps for each user,
+// so that requests do not need the state lock or a user lookup every time.
+type personalFilesCache struct {
+	mu    sync.Mutex
+	paths map[personalFilesKey][]string
+	// generation is increased on every invalidation, so that paths looked
+	// up concurrently with a change of connections are not cached
+	generation uint64
+}
+
+// get returns the personal-files paths of the given snap for the given user.
+func (c *personalFilesCache) get(st *state.State, userID uint32, snap string) ([]string, error) {
+	key := personalFilesKey{userID: userID, snap: snap}
+	c.mu.Lock()
+	paths, ok := c.paths[key]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok {
+		return paths, nil
+	}
+
+	paths, err := personalFilesPaths(st, userID, snap)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		if c.paths == nil {
+			c.paths = make(map[personalFilesKey][]string)
+		}
+		c.paths[key] = paths
+	}
+	return paths, nil
+}
+
+// invalidate forgets the personal-files paths of the given snap.
+func (c *personalFilesCache) invalidate(snap string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for key := range c.paths {
+		if key.snap == snap {
+			delete(c.paths, key)
+		}
+	}
+}
+
 type listenerBackend interface {
 	Close() error
 	Run() error
@@ -68,6 +178,7 @@ type Manager interface {
 	RuleWithID(userID uint32, ruleID prompting.IDType) (*requestrules.Rule, error)
 	PatchRule(userID uint32, ruleID prompting.IDType, constraintsPatchJSON prompting.ConstraintsJSON) (*requestrules.Rule, error)
 	RemoveRule(userID uint32, ruleID prompting.IDType) (*requestrules.Rule, error)
+	AuditLog(filter *AuditFilter) ([]*AuditEntry, error)
 }
 
 // verify that InterfacesRequestsManager implements Manager
@@ -83,6 +194,9 @@ type InterfacesRequestsManager struct {
 	listener listenerBackend
 	prompts  *requestprompts.PromptDB
 	rules    *requestrules.RuleDB
+	audit    *auditLog
+
+	personalFiles personalFilesCache
 
 	// ready should block method calls which depend on the manager having re-
 	// received all pending requests which were previously sent before snapd
@@ -95,12 +209,18 @@ type InterfacesRequestsManager struct {
 	// actually getting the chance to handle the request.
 	ready chan struct{}
 
+	state *state.State
+
 	notifyPrompt func(userID uint32, promptID prompting.IDType, data map[string]string) error
 	notifyRule   func(userID uint32, ruleID prompting.IDType, data map[string]string) error
 }
 
 func New(s *state.State) (m *InterfacesRequestsManager, retErr error) {
+	audit := newAuditLog()
 	notifyPrompt := func(userID uint32, promptID prompting.IDType, data map[string]string) error {
+		if resolved, ok := data["resolved"]; ok {
+			audit.promptResolved(userID, promptID, resolved)
+		}
 		// TODO: add some sort of queue so that notifyPrompt calls can return
 		// quickly without waiting for state lock and AddNotice() to return.
 		s.Lock()
@@ -157,7 +277,9 @@ func New(s *state.State) (m *InterfacesRequestsManager, retErr error) {
 		listener:     listenerBackend,
 		prompts:      promptsBackend,
 		rules:        rulesBackend,
+		audit:        audit,
 		ready:        make(chan struct{}),
+		state:        s,
 		notifyPrompt: notifyPrompt,
 		notifyRule:   notifyRule,
 	}
@@ -273,14 +395,10 @@ func (m *InterfacesRequestsManager) handleListenerReq(req *listener.Request) err
 	if err != nil {
 		if errors.Is(err, prompting_errors.ErrNoInterfaceTags) {
 			// There were no tags registered with a snapd interface, so we
-			// look at the path to decide whether it's "home" or "camera".
+			// look at the path to decide which interface it's from.
 			// XXX: this is a temporary workaround until metadata tags are
 			// supported by the AppArmor parser and kernel.
-			if builtin.DetectCameraFromPath(req.Path) {
-				iface = "camera"
-			} else {
-				iface = "home"
-			}
+			iface = m.interfaceFromPath(userID, snap, req.Path)
 		} else {
 			// There was either more than one interface associated with tags, or
 			// none which applied to all requested permissions. Since we can't
@@ -313,6 +431,7 @@ func (m *InterfacesRequestsManager) handleListenerReq(req *listener.Request) err
 		case len(outstandingPerms) == 0:
 			logger.Debugf("request allowed by existing rule: %+v", req)
 		}
+		m.recordRequestDecision(userID, snap, iface, path, permissions, allowedPerms)
 		// Allow any requested permissions which were explicitly allowed by
 		// existing rules (there may be no such permissions) and let the
 		// listener deny all permissions which were not explicitly included in
@@ -340,6 +459,7 @@ func (m *InterfacesRequestsManager) handleListenerReq(req *listener.Request) err
 		// We weren't able to create a new prompt, so respond with the best
 		// information we have, which is to allow any permissions which were
 		// allowed by existing rules, and let the listener deny the rest.
+		m.recordRequestDecision(userID, snap, iface, path, permissions, allowedPerms)
 		allowedPermission, _ := prompting.AbstractPermissionsToAppArmorPermissions(iface, allowedPerms)
 		// Error should not occur, but if it does, allowedPermission is set to
 		// empty, leaving it to the listener to default deny all permissions.
@@ -350,11 +470,83 @@ func (m *InterfacesRequestsManager) handleListenerReq(req *listener.Request) err
 		logger.Debugf("new prompt merged with identical existing prompt: %+v", newPrompt)
 	} else {
 		logger.Debugf("adding prompt to internal storage: %+v", newPrompt)
+		m.audit.addPendingPrompt(userID, newPrompt)
+	}
+
+	return nil
+}
+
+// recordRequestDecision records in the audit log the decision taken for a
+// request with the given parameters without prompting the user, where the
+// given allowed permissions were allowed and the rest denied.
+//
+// The caller must ensure that the manager lock is held.
+func (m *InterfacesRequestsManager) recordRequestDecision(userID uint32, snap string, iface string, path string, permissions []string, allowedPerms []string) {
+	ruleIDs, err := m.rules.MatchingRuleIDs(userID, snap, iface, path, permissions)
+	if err != nil {
+		logger.Noticef("cannot find rules matching request for audit log: %v", err)
+	}
+	outcomes := make(map[string]prompting.OutcomeType, len(permissions))
+	for _, perm := range permissions {
+		outcomes[perm] = prompting.OutcomeDeny
+		if strutil.ListContains(allowedPerms, perm) {
+			outcomes[perm] = prompting.OutcomeAllow
+		}
+	}
+	template := AuditEntry{
+		User:      userID,
+		Snap:      snap,
+		Interface: iface,
+		Path:      path,
 	}
+	m.audit.record(ruleDecisionEntries(template, permissions, ruleIDs, outcomes)...)
+}
 
+// interfaceFromPath returns the interface whose AppArmor rules with the
+// prompt prefix allowed the given path to be requested, defaulting to "home".
+func (m *InterfacesRequestsManager) interfaceFromPath(userID uint32, snap string, path string) string {
+	switch {
+	case builtin.DetectCameraFromPath(path):
+		return "camera"
+	case builtin.DetectAudioRecordFromPath(path):
+		return "audio-record"
+	case builtin.DetectRemovableMediaFromPath(path):
+		return "removable-media"
+	}
+	// The home interface does not grant access to hidden files and
+	// directories, so those are only requested through personal-files.
+	paths, err := m.personalFiles.get(m.state, userID, snap)
+	if err != nil {
+		logger.Noticef("cannot get personal-files paths of snap %q: %v", snap, err)
+		return "home"
+	}
+	for _, dir := range paths {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return "personal-files"
+		}
+	}
+	return "home"
+}
+
+// checkPersonalFilesPathPattern checks that the given path pattern lies within
+// the paths declared by the connected personal-files plugs of the given snap.
+func (m *InterfacesRequestsManager) checkPersonalFilesPathPattern(userID uint32, snap string, pathPattern *patterns.PathPattern) error {
+	paths, err := m.personalFiles.get(m.state, userID, snap)
+	if err != nil {
+		return err
+	}
+	if !prompting.PathPatternWithin(pathPattern, paths) {
+		return prompting_errors.NewInvalidPathPatternError(pathPattern.String(), "personal-files path pattern must be within the paths declared by the snap")
+	}
 	return nil
 }
 
+// InvalidatePersonalFilesPaths forgets the cached personal-files paths of the
+// given snap. It must be called whenever the connections of its plugs change.
+func (m *InterfacesRequestsManager) InvalidatePersonalFilesPaths(snap string) {
+	m.personalFiles.invalidate(snap)
+}
+
 func (m *InterfacesRequestsManager) disconnect() error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -438,6 +630,11 @@ func (m *InterfacesRequestsManager) HandleReply(userID uint32, promptID promptin
 	if err != nil {
 		return nil, fmt.Errorf("cannot decode request body into prompt reply: %w", err)
 	}
+	if prompt.Interface == "personal-files" {
+		if err := m.checkPersonalFilesPathPattern(userID, prompt.Snap, constraints.PathPattern()); err != nil {
+			return nil, fmt.Errorf("cannot decode request body into prompt reply: %w", err)
+		}
+	}
 
 	// Check that constraints matches original requested path.
 	// AppArmor is responsible for pre-vetting that all paths which appear
@@ -502,6 +699,21 @@ func (m *InterfacesRequestsManager) HandleReply(userID uint32, promptID promptin
 		return nil, retErr
 	}
 
+	replyEntry := &AuditEntry{
+		User:        userID,
+		Snap:        prompt.Snap,
+		Interface:   prompt.Interface,
+		Path:        prompt.Constraints.Path(),
+		Permissions: prompt.Constraints.OutstandingPermissions(),
+		Outcome:     outcome,
+		Source:      AuditSourceReply,
+		PromptID:    promptID,
+	}
+	if newRule != nil {
+		replyEntry.RuleID = newRule.ID
+	}
+	m.audit.record(replyEntry)
+
 	if lifespan == prompting.LifespanSingle {
 		return []prompting.IDType{}, nil
 	}
@@ -518,15 +730,75 @@ func (m *InterfacesRequestsManager) applyRuleToOutstandingPrompts(rule *requestr
 		Snap:      rule.Snap,
 		Interface: rule.Interface,
 	}
+	// Keep the permissions which were outstanding before the rule was
+	// applied, so that the decisions taken by the rule can be audited.
+	type outstandingPrompt struct {
+		prompt      *requestprompts.Prompt
+		permissions []string
+	}
+	var outstandingPrompts []outstandingPrompt
+	prompts, _ := m.prompts.Prompts(rule.User, false)
+	for _, prompt := range prompts {
+		if prompt.Snap != rule.Snap || prompt.Interface != rule.Interface {
+			continue
+		}
+		outstandingPrompts = append(outstandingPrompts, outstandingPrompt{
+			prompt:      prompt,
+			permissions: append([]string(nil), prompt.Constraints.OutstandingPermissions()...),
+		})
+	}
+
 	satisfiedPromptIDs, err := m.prompts.HandleNewRule(metadata, rule.Constraints)
 	if err != nil {
 		// The rule's constraints and outcome were already validated, so an
 		// error should not occur here unless the prompt DB was already closed.
 		logger.Noticef("error when handling new rule: %v", err)
 	}
+
+	var entries []*AuditEntry
+	for _, outstanding := range outstandingPrompts {
+		// Permissions which remain outstanding have not been decided yet.
+		decidedPerms := outstanding.permissions
+		if !promptIDListContains(satisfiedPromptIDs, outstanding.prompt.ID) {
+			remaining := outstanding.prompt.Constraints.OutstandingPermissions()
+			decidedPerms = make([]string, 0, len(outstanding.permissions))
+			for _, perm := range outstanding.permissions {
+				if !strutil.ListContains(remaining, perm) {
+					decidedPerms = append(decidedPerms, perm)
+				}
+			}
+		}
+		ruleIDs := make(map[string]prompting.IDType, len(decidedPerms))
+		outcomes := make(map[string]prompting.OutcomeType, len(decidedPerms))
+		for _, perm := range decidedPerms {
+			if entry, ok := rule.Constraints.Permissions[perm]; ok {
+				ruleIDs[perm] = rule.ID
+				outcomes[perm] = entry.Outcome
+			}
+		}
+		template := AuditEntry{
+			User:      rule.User,
+			Snap:      rule.Snap,
+			Interface: rule.Interface,
+			Path:      outstanding.prompt.Constraints.Path(),
+			PromptID:  outstanding.prompt.ID,
+		}
+		entries = append(entries, ruleDecisionEntries(template, decidedPerms, ruleIDs, outcomes)...)
+	}
+	m.audit.record(entries...)
+
 	return satisfiedPromptIDs
 }
 
+func promptIDListContains(ids []prompting.IDType, id prompting.IDType) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
 // Rules returns all rules for the user with the given user ID and,
 // optionally, only those for the given snap and/or interface.
 func (m *InterfacesRequestsManager) Rules(userID uint32, snap string, iface string) ([]*requestrules.Rule, error) {
@@ -563,6 +835,11 @@ func (m *InterfacesRequestsManager) AddRule(userID uint32, snap string, iface st
 	if err != nil {
 		return nil, fmt.Errorf("cannot decode request body for rules endpoint: %w", err)
 	}
+	if iface == "personal-files" {
+		if err := m.checkPersonalFilesPathPattern(userID, snap, constraints.PathPattern()); err != nil {
+			return nil, fmt.Errorf("cannot decode request body for rules endpoint: %w", err)
+		}
+	}
 
 	newRule, err := m.rules.AddRule(userID, snap, iface, constraints)
 	if err != nil {
@@ -629,6 +906,11 @@ func (m *InterfacesRequestsManager) PatchRule(userID uint32, ruleID prompting.ID
 		// XXX: should this say "... or deletion" like daemon does?
 		return nil, fmt.Errorf("cannot decode request body into request rule modification: %w", err)
 	}
+	if personalFiles, ok := constraintsPatch.InterfaceSpecific.(*prompting.InterfaceSpecificConstraintsPersonalFiles); ok && personalFiles.Pattern != nil {
+		if err := m.checkPersonalFilesPathPattern(userID, origRule.Snap, personalFiles.Pattern); err != nil {
+			return nil, fmt.Errorf("cannot decode request body into request rule modification: %w", err)
+		}
+	}
 
 	patchedRule, err := m.rules.PatchRule(userID, ruleID, constraintsPatch)
 	if err != nil {
@@ -653,3 +935,11 @@ func (m *InterfacesRequestsManager) RemoveRule(userID uint32, ruleID prompting.I
 	rule, err := m.rules.RemoveRule(userID, ruleID)
 	return rule, err
 }
+
+// AuditLog returns the entries of the audit log of prompting decisions which
+// match the given filter, in the order in which they were recorded.
+func (m *InterfacesRequestsManager) AuditLog(filter *AuditFilter) ([]*AuditEntry, error) {
+	// The audit log has an internal mutex, and it is independent of the
+	// state of the prompts and rules backends, so no lock need be held.
+	return m.audit.entries(filter)
+}
@@ -25,7 +25,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -33,15 +35,19 @@ import (
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
 	"github.com/snapcore/snapd/interfaces/prompting"
 	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
 	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
 	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/ifacestate/apparmorprompting"
+	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify/listener"
+	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -65,6 +71,10 @@ func (s *apparmorpromptingSuite) SetUpTest(c *C) {
 
 	s.st = state.New(nil)
 	s.defaultUser = 1000
+
+	s.AddCleanup(apparmorprompting.MockPersonalFilesPaths(func(st *state.State, userID uint32, snap string) ([]string, error) {
+		return nil, nil
+	}))
 }
 
 func requestWithReplyChan(req *listener.Request) (*listener.Request, chan notify.AppArmorPermission) {
@@ -301,6 +311,41 @@ func (s *apparmorpromptingSuite) TestHandleListenerRequestInterfaceSelection(c *
 	c.Check(prompts[1].Interface, Equals, "camera")
 	c.Check(prompts[2].Interface, Equals, "home")
 	c.Check(prompts[3].Interface, Equals, "camera")
+
+	restorePersonalFiles := apparmorprompting.MockPersonalFilesPaths(func(st *state.State, userID uint32, snap string) ([]string, error) {
+		c.Check(st, Equals, s.st)
+		c.Check(userID, Equals, s.defaultUser)
+		if snap != "snap7" {
+			return nil, nil
+		}
+		return []string{"/home/test/.config/foo"}, nil
+	})
+	for i, testCase := range []struct {
+		label string
+		path  string
+		iface string
+	}{
+		{"snap5", "/dev/snd/pcmC0D0c", "audio-record"},
+		{"snap6", "/media/test/usb/foo", "removable-media"},
+		{"snap7", "/home/test/.config/foo/bar", "personal-files"},
+		{"snap8", "/home/test/.config/foo/bar", "home"},
+	} {
+		req = &listener.Request{
+			// Most fields don't matter here
+			ID:         uint64(5 + i),
+			Label:      testCase.label,
+			SubjectUID: s.defaultUser,
+			Permission: notify.AA_MAY_READ,
+			Path:       testCase.path,
+		}
+		reqChan <- req
+		time.Sleep(10 * time.Millisecond)
+		prompts, err = mgr.Prompts(s.defaultUser, clientActivity)
+		c.Check(err, IsNil)
+		c.Assert(prompts, HasLen, 5+i)
+		c.Check(prompts[4+i].Interface, Equals, testCase.iface, Commentf("path: %s", testCase.path))
+	}
+	restorePersonalFiles()
 	restore()
 
 	// Explicitly set some other interface based on tags.
@@ -312,8 +357,8 @@ func (s *apparmorpromptingSuite) TestHandleListenerRequestInterfaceSelection(c *
 	})
 	req, replyChan := requestWithReplyChan(&listener.Request{
 		// Most fields don't matter here
-		ID:         5,
-		Label:      "snap5",
+		ID:         9,
+		Label:      "snap9",
 		SubjectUID: s.defaultUser,
 		Permission: notify.AA_MAY_OPEN,
 	})
@@ -1276,6 +1321,216 @@ func (s *apparmorpromptingSuite) TestRequestMerged(c *C) {
 	c.Assert(mgr.Stop(), IsNil)
 }
 
+func (s *apparmorpromptingSuite) TestAuditLog(c *C) {
+	readyChan, reqChan, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	fakeNow := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	restore = apparmorprompting.MockAuditTimeNow(func() time.Time {
+		fakeNow = fakeNow.Add(time.Minute)
+		return fakeNow
+	})
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+
+	// simulateRequest checks mgr.Prompts, so make sure we close readyChan first
+	close(readyChan)
+
+	entries, err := mgr.AuditLog(nil)
+	c.Check(err, IsNil)
+	c.Check(entries, HasLen, 0)
+
+	// Prompt for read and write, and for execute
+	rwReq, rwReplyChan := requestWithReplyChan(&listener.Request{
+		Permission: notify.AA_MAY_READ | notify.AA_MAY_WRITE,
+	})
+	_, rwPrompt := s.simulateRequest(c, reqChan, mgr, rwReq, false)
+	execReq, _ := requestWithReplyChan(&listener.Request{
+		Path:       "/home/test/bin/foo",
+		Permission: notify.AA_MAY_EXEC,
+	})
+	_, execPrompt := s.simulateRequest(c, reqChan, mgr, execReq, false)
+
+	// A new rule partially satisfies the read/write prompt
+	constraints := prompting.ConstraintsJSON{
+		"path-pattern": json.RawMessage(`"/home/test/foo"`),
+		"permissions":  json.RawMessage(`{"read":{"outcome":"allow","lifespan":"forever"}}`),
+	}
+	rule, err := mgr.AddRule(s.defaultUser, "firefox", "home", constraints)
+	c.Assert(err, IsNil)
+
+	// The user replies to the rest of the prompt
+	replyConstraints := prompting.ConstraintsJSON{
+		"path-pattern": json.RawMessage(`"/home/test/foo"`),
+		"permissions":  json.RawMessage(`["write"]`),
+	}
+	_, err = mgr.HandleReply(s.defaultUser, rwPrompt.ID, replyConstraints, prompting.OutcomeDeny, prompting.LifespanSingle, "", false)
+	c.Assert(err, IsNil)
+	_, err = waitForReply(rwReplyChan)
+	c.Assert(err, IsNil)
+
+	// A request is allowed by the rule without prompting
+	readReq, readReplyChan := requestWithReplyChan(&listener.Request{
+		Permission: notify.AA_MAY_READ,
+	})
+	s.fillInPartialRequest(readReq)
+	reqChan <- readReq
+	_, err = waitForReply(readReplyChan)
+	c.Assert(err, IsNil)
+
+	// The execute prompt expires
+	c.Assert(mgr.NotifyPrompt(s.defaultUser, execPrompt.ID, map[string]string{"resolved": "expired"}), IsNil)
+
+	expected := []*apparmorprompting.AuditEntry{
+		{
+			Timestamp:   time.Date(2026, 1, 2, 3, 5, 5, 0, time.UTC),
+			User:        s.defaultUser,
+			Snap:        "firefox",
+			Interface:   "home",
+			Path:        "/home/test/foo",
+			Permissions: []string{"read"},
+			Outcome:     prompting.OutcomeAllow,
+			Source:      apparmorprompting.AuditSourceRule,
+			RuleID:      rule.ID,
+			PromptID:    rwPrompt.ID,
+		},
+		{
+			Timestamp:   time.Date(2026, 1, 2, 3, 6, 5, 0, time.UTC),
+			User:        s.defaultUser,
+			Snap:        "firefox",
+			Interface:   "home",
+			Path:        "/home/test/foo",
+			Permissions: []string{"write"},
+			Outcome:     prompting.OutcomeDeny,
+			Source:      apparmorprompting.AuditSourceReply,
+			PromptID:    rwPrompt.ID,
+		},
+		{
+			Timestamp:   time.Date(2026, 1, 2, 3, 7, 5, 0, time.UTC),
+			User:        s.defaultUser,
+			Snap:        "firefox",
+			Interface:   "home",
+			Path:        "/home/test/foo",
+			Permissions: []string{"read"},
+			Outcome:     prompting.OutcomeAllow,
+			Source:      apparmorprompting.AuditSourceRule,
+			RuleID:      rule.ID,
+		},
+		{
+			Timestamp:   time.Date(2026, 1, 2, 3, 8, 5, 0, time.UTC),
+			User:        s.defaultUser,
+			Snap:        "firefox",
+			Interface:   "home",
+			Path:        "/home/test/bin/foo",
+			Permissions: []string{"execute"},
+			Outcome:     prompting.OutcomeDeny,
+			Source:      apparmorprompting.AuditSourceTimeout,
+			PromptID:    execPrompt.ID,
+		},
+	}
+	entries, err = mgr.AuditLog(nil)
+	c.Check(err, IsNil)
+	c.Check(entries, DeepEquals, expected)
+
+	// Entries can be filtered
+	entries, err = mgr.AuditLog(&apparmorprompting.AuditFilter{Source: apparmorprompting.AuditSourceRule})
+	c.Check(err, IsNil)
+	c.Check(entries, DeepEquals, []*apparmorprompting.AuditEntry{expected[0], expected[2]})
+	entries, err = mgr.AuditLog(&apparmorprompting.AuditFilter{Since: expected[2].Timestamp})
+	c.Check(err, IsNil)
+	c.Check(entries, DeepEquals, expected[2:])
+	entries, err = mgr.AuditLog(&apparmorprompting.AuditFilter{User: s.defaultUser + 1})
+	c.Check(err, IsNil)
+	c.Check(entries, HasLen, 0)
+	entries, err = mgr.AuditLog(&apparmorprompting.AuditFilter{Snap: "firefox", Interface: "camera"})
+	c.Check(err, IsNil)
+	c.Check(entries, HasLen, 0)
+
+	c.Assert(mgr.Stop(), IsNil)
+
+	// The audit log persists across restarts, and malformed entries are
+	// skipped
+	f, err := os.OpenFile(filepath.Join(dirs.SnapInterfacesRequestsStateDir, "audit.log"), os.O_WRONLY|os.O_APPEND, 0)
+	c.Assert(err, IsNil)
+	_, err = f.WriteString(`{"timestamp":`)
+	c.Assert(err, IsNil)
+	c.Assert(f.Close(), IsNil)
+
+	_, _, restore = apparmorprompting.MockListener()
+	defer restore()
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+	mgr, err = apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	entries, err = mgr.AuditLog(nil)
+	c.Check(err, IsNil)
+	c.Check(entries, DeepEquals, expected)
+	c.Check(logbuf.String(), testutil.Contains, "cannot decode prompting audit entry: ")
+
+	// Only the most recent entries are returned with a limit
+	entries, err = mgr.AuditLog(&apparmorprompting.AuditFilter{Limit: 2})
+	c.Check(err, IsNil)
+	c.Check(entries, DeepEquals, expected[2:])
+	entries, err = mgr.AuditLog(&apparmorprompting.AuditFilter{Source: apparmorprompting.AuditSourceRule, Limit: 1})
+	c.Check(err, IsNil)
+	c.Check(entries, DeepEquals, expected[2:3])
+	c.Assert(mgr.Stop(), IsNil)
+}
+
+func (s *apparmorpromptingSuite) TestAuditLogRotated(c *C) {
+	restore := apparmorprompting.MockMaxAuditLogSize(1024)
+	defer restore()
+	restore = apparmorprompting.MockAuditReadChunkSize(100)
+	defer restore()
+	fakeNow := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	restore = apparmorprompting.MockAuditTimeNow(func() time.Time {
+		fakeNow = fakeNow.Add(time.Minute)
+		return fakeNow
+	})
+	defer restore()
+
+	auditLog := apparmorprompting.NewAuditLog()
+	var expected []*apparmorprompting.AuditEntry
+	for i := 0; i < 20; i++ {
+		entry := &apparmorprompting.AuditEntry{
+			User:        s.defaultUser,
+			Snap:        "firefox",
+			Interface:   "home",
+			Path:        fmt.Sprintf("/home/test/foo%d", i),
+			Permissions: []string{"read"},
+			Outcome:     prompting.OutcomeAllow,
+			Source:      apparmorprompting.AuditSourceRule,
+		}
+		auditLog.Record(entry)
+		expected = append(expected, entry)
+	}
+
+	// The log was rotated, and the oldest entries were dropped with the
+	// previously rotated log
+	logPath := filepath.Join(dirs.SnapInterfacesRequestsStateDir, "audit.log")
+	for _, path := range []string{logPath, logPath + ".1"} {
+		fi, err := os.Stat(path)
+		c.Assert(err, IsNil)
+		c.Check(fi.Size() <= 1024+200, Equals, true, Commentf("%s has size %d", path, fi.Size()))
+	}
+	c.Check(logPath+".2", testutil.FileAbsent)
+
+	entries, err := auditLog.Entries(nil)
+	c.Assert(err, IsNil)
+	c.Assert(len(entries) > 10 && len(entries) < 20, Equals, true, Commentf("%d entries", len(entries)))
+	c.Check(entries, DeepEquals, expected[20-len(entries):])
+
+	// Entries are found across the rotated and the current log
+	entries, err = auditLog.Entries(&apparmorprompting.AuditFilter{Since: expected[12].Timestamp})
+	c.Assert(err, IsNil)
+	c.Check(entries, DeepEquals, expected[12:])
+	entries, err = auditLog.Entries(&apparmorprompting.AuditFilter{Limit: 9})
+	c.Assert(err, IsNil)
+	c.Check(entries, DeepEquals, expected[11:])
+}
+
 func (s *apparmorpromptingSuite) TestRules(c *C) {
 	readyChan, _, restore := apparmorprompting.MockListener()
 	defer restore()
@@ -1520,6 +1775,187 @@ func (s *apparmorpromptingSuite) TestAddRuleWithIDPatchRemove(c *C) {
 	c.Assert(mgr.Stop(), IsNil)
 }
 
+func (s *apparmorpromptingSuite) TestPersonalFilesPathPatterns(c *C) {
+	readyChan, reqChan, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	restore = apparmorprompting.MockPersonalFilesPaths(func(st *state.State, userID uint32, snap string) ([]string, error) {
+		c.Check(userID, Equals, s.defaultUser)
+		c.Check(snap, Equals, "firefox")
+		return []string{"/home/test/.mozilla", "/home/test/.config/firefox"}, nil
+	})
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	close(readyChan)
+
+	// Rules must be within the declared paths
+	constraints := prompting.ConstraintsJSON{
+		"path-pattern": json.RawMessage(`"/home/test/{.mozilla,.config/firefox}/**"`),
+		"permissions":  json.RawMessage(`{"read":{"outcome":"allow","lifespan":"forever"}}`),
+	}
+	rule, err := mgr.AddRule(s.defaultUser, "firefox", "personal-files", constraints)
+	c.Assert(err, IsNil)
+	c.Check(rule.Interface, Equals, "personal-files")
+
+	constraints["path-pattern"] = json.RawMessage(`"/home/test/.ssh/**"`)
+	_, err = mgr.AddRule(s.defaultUser, "firefox", "personal-files", constraints)
+	c.Check(err, ErrorMatches, `cannot decode request body for rules endpoint: invalid path pattern: personal-files path pattern must be within the paths declared by the snap: "/home/test/.ssh/\*\*"`)
+
+	constraintsPatch := prompting.ConstraintsJSON{
+		"path-pattern": json.RawMessage(`"/home/test/**"`),
+	}
+	_, err = mgr.PatchRule(s.defaultUser, rule.ID, constraintsPatch)
+	c.Check(err, ErrorMatches, `cannot decode request body into request rule modification: invalid path pattern: personal-files path pattern must be within the paths declared by the snap: "/home/test/\*\*"`)
+
+	constraintsPatch["path-pattern"] = json.RawMessage(`"/home/test/.mozilla/**"`)
+	_, err = mgr.PatchRule(s.defaultUser, rule.ID, constraintsPatch)
+	c.Check(err, IsNil)
+
+	// Replies must be within the declared paths too
+	restore = apparmorprompting.MockPromptingInterfaceFromTagsets(func(notify.TagsetMap) (string, error) {
+		return "personal-files", nil
+	})
+	defer restore()
+	req, replyChan := requestWithReplyChan(&listener.Request{
+		Permission: notify.AA_MAY_WRITE,
+		Path:       "/home/test/.config/firefox/prefs.js",
+	})
+	s.fillInPartialRequest(req)
+	reqChan <- req
+	time.Sleep(10 * time.Millisecond)
+	prompts, err := mgr.Prompts(s.defaultUser, false)
+	c.Assert(err, IsNil)
+	c.Assert(prompts, HasLen, 1)
+	prompt := prompts[0]
+	c.Check(prompt.Interface, Equals, "personal-files")
+
+	replyConstraints := prompting.ConstraintsJSON{
+		"path-pattern": json.RawMessage(`"/home/test/**"`),
+		"permissions":  json.RawMessage(`["write"]`),
+	}
+	_, err = mgr.HandleReply(s.defaultUser, prompt.ID, replyConstraints, prompting.OutcomeAllow, prompting.LifespanForever, "", false)
+	c.Check(err, ErrorMatches, `cannot decode request body into prompt reply: invalid path pattern: personal-files path pattern must be within the paths declared by the snap: "/home/test/\*\*"`)
+
+	replyConstraints["path-pattern"] = json.RawMessage(`"/home/test/.config/firefox/**"`)
+	_, err = mgr.HandleReply(s.defaultUser, prompt.ID, replyConstraints, prompting.OutcomeAllow, prompting.LifespanForever, "", false)
+	c.Check(err, IsNil)
+	allowedPermissions, err := waitForReply(replyChan)
+	c.Assert(err, IsNil)
+	expectedPermissions, err := prompting.AbstractPermissionsToAppArmorPermissions("personal-files", []string{"write"})
+	c.Assert(err, IsNil)
+	c.Check(allowedPermissions, DeepEquals, expectedPermissions)
+
+	c.Assert(mgr.Stop(), IsNil)
+}
+
+func (s *apparmorpromptingSuite) TestPersonalFilesPathsCached(c *C) {
+	readyChan, _, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	lookups := 0
+	paths := []string{"/home/test/.mozilla"}
+	restore = apparmorprompting.MockPersonalFilesPaths(func(st *state.State, userID uint32, snap string) ([]string, error) {
+		c.Check(userID, Equals, s.defaultUser)
+		c.Check(snap, Equals, "firefox")
+		lookups++
+		return paths, nil
+	})
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	close(readyChan)
+
+	constraints := prompting.ConstraintsJSON{
+		"path-pattern": json.RawMessage(`"/home/test/.mozilla/**"`),
+		"permissions":  json.RawMessage(`{"read":{"outcome":"allow","lifespan":"forever"}}`),
+	}
+	_, err = mgr.AddRule(s.defaultUser, "firefox", "personal-files", constraints)
+	c.Assert(err, IsNil)
+	constraints["path-pattern"] = json.RawMessage(`"/home/test/.mozilla/firefox/**"`)
+	_, err = mgr.AddRule(s.defaultUser, "firefox", "personal-files", constraints)
+	c.Assert(err, IsNil)
+	c.Check(lookups, Equals, 1)
+
+	// Changes of the connections are only seen once the paths of the snap
+	// are invalidated
+	paths = []string{"/home/test/.config/firefox"}
+	constraints["path-pattern"] = json.RawMessage(`"/home/test/.config/firefox/**"`)
+	_, err = mgr.AddRule(s.defaultUser, "firefox", "personal-files", constraints)
+	c.Check(err, ErrorMatches, `.*personal-files path pattern must be within the paths declared by the snap.*`)
+	c.Check(lookups, Equals, 1)
+
+	mgr.InvalidatePersonalFilesPaths("other-snap")
+	_, err = mgr.AddRule(s.defaultUser, "firefox", "personal-files", constraints)
+	c.Check(err, NotNil)
+	c.Check(lookups, Equals, 1)
+
+	mgr.InvalidatePersonalFilesPaths("firefox")
+	_, err = mgr.AddRule(s.defaultUser, "firefox", "personal-files", constraints)
+	c.Check(err, IsNil)
+	c.Check(lookups, Equals, 2)
+
+	c.Assert(mgr.Stop(), IsNil)
+}
+
+func (s *apparmorpromptingSuite) TestPersonalFilesPathsFromRepo(c *C) {
+	restore := apparmorprompting.MockUserLookupId(func(uid string) (*user.User, error) {
+		c.Check(uid, Equals, "1000")
+		return &user.User{Uid: uid, HomeDir: "/home/test"}, nil
+	})
+	defer restore()
+
+	repo := interfaces.NewRepository()
+	c.Assert(repo.AddInterface(&ifacetest.TestInterface{InterfaceName: "personal-files"}), IsNil)
+	s.st.Lock()
+	ifacerepo.Replace(s.st, repo)
+	s.st.Unlock()
+
+	const coreYaml = `name: core
+version: 1
+type: os
+slots:
+  personal-files:
+`
+	const firefoxYaml = `name: firefox
+version: 1
+plugs:
+  dot-mozilla:
+    interface: personal-files
+    read: [$HOME/.mozilla]
+    write: [$HOME/.mozilla/firefox]
+  dot-config:
+    interface: personal-files
+    write: [$HOME/.config/firefox]
+  dot-ssh:
+    interface: personal-files
+    read: [$HOME/.ssh]
+`
+	for _, yaml := range []string{coreYaml, firefoxYaml} {
+		info := snaptest.MockInfo(c, yaml, nil)
+		appSet, err := interfaces.NewSnapAppSet(info, nil)
+		c.Assert(err, IsNil)
+		c.Assert(repo.AddAppSet(appSet), IsNil)
+	}
+	for _, plug := range []string{"dot-mozilla", "dot-config"} {
+		cref := &interfaces.ConnRef{PlugRef: interfaces.PlugRef{Snap: "firefox", Name: plug}, SlotRef: interfaces.SlotRef{Snap: "core", Name: "personal-files"}}
+		_, err := repo.Connect(cref, nil, nil, nil, nil, nil)
+		c.Assert(err, IsNil)
+	}
+
+	// only connected plugs are considered
+	paths, err := apparmorprompting.PersonalFilesPathsFromRepo(s.st, s.defaultUser, "firefox")
+	c.Assert(err, IsNil)
+	sort.Strings(paths)
+	c.Check(paths, DeepEquals, []string{"/home/test/.config/firefox", "/home/test/.mozilla", "/home/test/.mozilla/firefox"})
+
+	paths, err = apparmorprompting.PersonalFilesPathsFromRepo(s.st, s.defaultUser, "other")
+	c.Assert(err, IsNil)
+	c.Check(paths, HasLen, 0)
+}
+
 func (s *apparmorpromptingSuite) TestListenerReadyCausesPromptsHandleReadying(c *C) {
 	readyChan, _, restore := apparmorprompting.MockListener()
 	defer restore()
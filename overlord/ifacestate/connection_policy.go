@@ -0,0 +1,194 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/policy"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/snap"
+)
+
+// ConnectionPolicySide identifies the plug or the slot of a connection whose
+// policy is explained.
+type ConnectionPolicySide struct {
+	// Snap is the name of the snap, which defaults to the system snap.
+	Snap string
+	// Name is the name of the plug or slot.
+	Name string
+	// SnapInfo, if set, is used instead of the installed snap, so that the
+	// policy can be checked for a snap before it is uploaded.
+	SnapInfo *snap.Info
+	// SnapDeclaration, if set, is used instead of the snap declaration of
+	// the snap from the assertions database.
+	SnapDeclaration *asserts.SnapDeclaration
+}
+
+// ConnectionPolicyExplanation explains whether a plug and a slot are allowed
+// to be connected and auto-connected.
+type ConnectionPolicyExplanation struct {
+	Interface      string              `json:"interface"`
+	Plug           interfaces.PlugRef  `json:"plug"`
+	Slot           interfaces.SlotRef  `json:"slot"`
+	Connection     *policy.Explanation `json:"connection"`
+	AutoConnection *policy.Explanation `json:"auto-connection"`
+}
+
+// localSnapInfo returns the given snap info, once it is checked to be for
+// the snap of the side and sanitized as it would be when installed.
+func (side *ConnectionPolicySide) localSnapInfo(kind string) (*snap.Info, error) {
+	info := side.SnapInfo
+	if info.InstanceName() != side.Snap {
+		return nil, fmt.Errorf("cannot use snap %q for %s of snap %q", info.InstanceName(), kind, side.Snap)
+	}
+	snap.SanitizePlugsSlots(info)
+	return info, nil
+}
+
+func (side *ConnectionPolicySide) snapDeclaration(c *autoConnectChecker, info *snap.Info) (*asserts.SnapDeclaration, error) {
+	if decl := side.SnapDeclaration; decl != nil {
+		if decl.SnapName() != info.SnapName() {
+			return nil, fmt.Errorf("cannot use snap declaration of snap %q for snap %q", decl.SnapName(), info.SnapName())
+		}
+		return decl, nil
+	}
+	if info.SnapID == "" {
+		return nil, nil
+	}
+	decl, err := c.snapDeclaration(info.SnapID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find snap declaration for %q: %v", info.InstanceName(), err)
+	}
+	return decl, nil
+}
+
+func badInterfaceError(info *snap.Info, kind, name string) error {
+	if reason, ok := info.BadInterfaces[name]; ok {
+		return fmt.Errorf("snap %q has bad %s %q: %s", info.InstanceName(), kind, name, reason)
+	}
+	return fmt.Errorf("snap %q has no %s named %q", info.InstanceName(), kind, name)
+}
+
+// ExplainConnectionPolicy evaluates the declarations to decide whether the
+// given plug and slot are allowed to be connected and auto-connected, as it
+// is done for actual connections, and explains how each declaration rule was
+// considered.
+//
+// The state must be locked by the caller.
+func (m *InterfaceManager) ExplainConnectionPolicy(plugSide, slotSide *ConnectionPolicySide) (*ConnectionPolicyExplanation, error) {
+	st := m.state
+	coreSnapName := SystemSnapName()
+	if plugSide.Snap == "" {
+		plugSide.Snap = coreSnapName
+	}
+	if slotSide.Snap == "" {
+		slotSide.Snap = coreSnapName
+	}
+
+	var plugInfo *snap.PlugInfo
+	if plugSide.SnapInfo != nil {
+		info, err := plugSide.localSnapInfo("plug")
+		if err != nil {
+			return nil, err
+		}
+		if plugInfo = info.Plugs[plugSide.Name]; plugInfo == nil {
+			return nil, badInterfaceError(info, "plug", plugSide.Name)
+		}
+	} else if plugInfo = m.repo.Plug(plugSide.Snap, plugSide.Name); plugInfo == nil {
+		return nil, fmt.Errorf("snap %q has no plug named %q", plugSide.Snap, plugSide.Name)
+	}
+
+	var slotInfo *snap.SlotInfo
+	if slotSide.SnapInfo != nil {
+		info, err := slotSide.localSnapInfo("slot")
+		if err != nil {
+			return nil, err
+		}
+		if slotInfo = info.Slots[slotSide.Name]; slotInfo == nil {
+			return nil, badInterfaceError(info, "slot", slotSide.Name)
+		}
+	} else if slotInfo = m.repo.Slot(slotSide.Snap, slotSide.Name); slotInfo == nil {
+		return nil, fmt.Errorf("snap %q has no slot named %q", slotSide.Snap, slotSide.Name)
+	}
+
+	if plugInfo.Interface != slotInfo.Interface {
+		return nil, fmt.Errorf("cannot connect %s:%s (%q interface) to %s:%s (%q interface)",
+			plugSide.Snap, plugSide.Name, plugInfo.Interface, slotSide.Snap, slotSide.Name, slotInfo.Interface)
+	}
+
+	deviceCtx, err := snapstate.DeviceCtx(st, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	checker, err := newAutoConnectChecker(st, m.repo, deviceCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	plugDecl, err := plugSide.snapDeclaration(checker, plugInfo.Snap)
+	if err != nil {
+		return nil, err
+	}
+	slotDecl, err := slotSide.snapDeclaration(checker, slotInfo.Snap)
+	if err != nil {
+		return nil, err
+	}
+
+	modelAs := deviceCtx.Model()
+	var storeAs *asserts.Store
+	if modelAs.Store() != "" {
+		storeAs, err = assertstate.Store(st, modelAs.Store())
+		if err != nil && !errors.Is(err, &asserts.NotFoundError{}) {
+			return nil, err
+		}
+	}
+
+	plugAppSet, err := interfaces.NewSnapAppSet(plugInfo.Snap, nil)
+	if err != nil {
+		return nil, err
+	}
+	slotAppSet, err := interfaces.NewSnapAppSet(slotInfo.Snap, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cand := policy.ConnectCandidate{
+		Plug:                interfaces.NewConnectedPlug(plugInfo, plugAppSet, nil, nil),
+		PlugSnapDeclaration: plugDecl,
+		Slot:                interfaces.NewConnectedSlot(slotInfo, slotAppSet, nil, nil),
+		SlotSnapDeclaration: slotDecl,
+		BaseDeclaration:     checker.baseDecl,
+		Model:               modelAs,
+		Store:               storeAs,
+		CompatEnabled:       allowCompatLabel(checker.contentCompatEnabled, plugInfo.Interface),
+	}
+	return &ConnectionPolicyExplanation{
+		Interface:      plugInfo.Interface,
+		Plug:           interfaces.PlugRef{Snap: plugSide.Snap, Name: plugSide.Name},
+		Slot:           interfaces.SlotRef{Snap: slotSide.Snap, Name: slotSide.Name},
+		Connection:     cand.Explain(false),
+		AutoConnection: cand.Explain(true),
+	}, nil
+}
@@ -266,6 +266,7 @@ func (m *InterfaceManager) setupProfilesForAppSet(
 	if err := m.repo.RemoveSnap(snapName); err != nil {
 		return err
 	}
+	m.invalidatePromptingPaths(snapName)
 	if err := m.repo.AddAppSet(appSet); err != nil {
 		return err
 	}
@@ -445,6 +446,7 @@ func (m *InterfaceManager) removeProfilesForSnap(task *state.Task, _ *tomb.Tomb,
 	if err := m.repo.RemoveSnap(snapName); err != nil {
 		return err
 	}
+	m.invalidatePromptingPaths(snapName)
 
 	// Remove security artefacts of the snap.
 	if err := m.removeSnapSecurity(task, snapName); err != nil {
@@ -730,11 +732,13 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) (err error)
 	if err != nil || conn == nil {
 		return err
 	}
+	m.invalidatePromptingPaths(plugRef.Snap)
 	defer func() {
 		if err != nil {
 			if err := m.repo.Disconnect(plugRef.Snap, plugRef.Name, slotRef.Snap, slotRef.Name); err != nil {
 				logger.Noticef("cannot undo failed connection: %v", err)
 			}
+			m.invalidatePromptingPaths(plugRef.Snap)
 		}
 	}()
 
@@ -842,6 +846,7 @@ func (m *InterfaceManager) doDisconnect(task *state.Task, _ *tomb.Tomb) error {
 	task.Set("old-conn", conn)
 
 	err = m.repo.Disconnect(plugRef.Snap, plugRef.Name, slotRef.Snap, slotRef.Name)
+	m.invalidatePromptingPaths(plugRef.Snap)
 	if err != nil {
 		_, notConnected := err.(*interfaces.NotConnectedError)
 		_, noPlugOrSlot := err.(*interfaces.NoPlugOrSlotError)
@@ -984,6 +989,7 @@ func (m *InterfaceManager) undoDisconnect(task *state.Task, _ *tomb.Tomb) error
 	if err != nil {
 		return err
 	}
+	m.invalidatePromptingPaths(connRef.PlugRef.Snap)
 
 	slotSnapInfo, err := slotSnapst.CurrentInfo()
 	if err != nil {
@@ -1048,6 +1054,7 @@ func (m *InterfaceManager) undoConnect(task *state.Task, _ *tomb.Tomb) error {
 	if err := m.repo.Disconnect(connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name); err != nil {
 		return err
 	}
+	m.invalidatePromptingPaths(connRef.PlugRef.Snap)
 
 	var delayedSetupProfiles bool
 	if err := task.Get("delayed-setup-profiles", &delayedSetupProfiles); err != nil && !errors.Is(err, state.ErrNoState) {
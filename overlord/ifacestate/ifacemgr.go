@@ -164,6 +164,16 @@ func (m *InterfaceManager) InterfacesRequestsManager() apparmorprompting.Manager
 	return irm
 }
 
+// invalidatePromptingPaths tells the interfaces requests manager, if AppArmor
+// prompting is running, that the connected plugs of the given snap changed.
+func (m *InterfaceManager) invalidatePromptingPaths(snapName string) {
+	irm := m.interfacesRequestsManager
+	if irm == nil {
+		return
+	}
+	irm.InvalidatePersonalFilesPaths(snapName)
+}
+
 // StartUp implements StateStarterUp.Startup.
 func (m *InterfaceManager) StartUp() error {
 	s := m.state
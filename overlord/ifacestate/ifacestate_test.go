@@ -41,6 +41,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/interfaces/policy"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
@@ -9775,6 +9776,131 @@ func (s *interfaceManagerSuite) TestResolveDisconnectFromConns(c *C) {
 	c.Check(err, ErrorMatches, `cannot forget connection other-snap:plug from core:slot, it was not connected`)
 }
 
+func (s *interfaceManagerSuite) mockExplainConnectionPolicy(c *C) *ifacestate.InterfaceManager {
+	restore := assertstest.MockBuiltinBaseDeclaration([]byte(`
+type: base-declaration
+authority-id: canonical
+series: 16
+slots:
+  test:
+    allow-connection:
+      plug-publisher-id:
+        - $SLOT_PUBLISHER_ID
+    allow-auto-connection: false
+`))
+	s.AddCleanup(restore)
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.MockModel(c, nil)
+	s.MockSnapDecl(c, "consumer", "consumer-publisher", nil)
+	s.mockSnap(c, consumerYaml)
+	s.MockSnapDecl(c, "producer", "producer-publisher", nil)
+	s.mockSnap(c, producerYaml)
+	return s.manager(c)
+}
+
+func (s *interfaceManagerSuite) TestExplainConnectionPolicy(c *C) {
+	mgr := s.mockExplainConnectionPolicy(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	explanation, err := mgr.ExplainConnectionPolicy(
+		&ifacestate.ConnectionPolicySide{Snap: "consumer", Name: "plug"},
+		&ifacestate.ConnectionPolicySide{Snap: "producer", Name: "slot"})
+	c.Assert(err, IsNil)
+	c.Check(explanation.Interface, Equals, "test")
+	c.Check(explanation.Plug, Equals, interfaces.PlugRef{Snap: "consumer", Name: "plug"})
+	c.Check(explanation.Slot, Equals, interfaces.SlotRef{Snap: "producer", Name: "slot"})
+
+	c.Check(explanation.Connection, DeepEquals, &policy.Explanation{
+		Kind:    "connection",
+		Allowed: false,
+		Error:   `connection not allowed by slot rule of interface "test"`,
+		Rules: []*policy.RuleExplanation{
+			{Declaration: policy.PlugSnapDeclaration, Side: "plug", Status: policy.RuleStatusNoRule},
+			{Declaration: policy.SlotSnapDeclaration, Side: "slot", Status: policy.RuleStatusNoRule},
+			{Declaration: policy.BaseDeclaration, Side: "plug", Status: policy.RuleStatusNoRule},
+			{Declaration: policy.BaseDeclaration, Side: "slot", Status: policy.RuleStatusEvaluated, Subrules: []*policy.SubruleExplanation{
+				{Name: "deny-connection", Matched: false, Alternatives: []*policy.AlternativeExplanation{
+					{Constraints: "false", Matched: false, Reason: "not allowed"},
+				}},
+				{Name: "allow-connection", Matched: false, Alternatives: []*policy.AlternativeExplanation{
+					{Constraints: "plug-publisher-id", Matched: false, Reason: "publisher id does not match"},
+				}},
+			}},
+		},
+	})
+	c.Check(explanation.AutoConnection.Kind, Equals, "auto-connection")
+	c.Check(explanation.AutoConnection.Allowed, Equals, false)
+	c.Check(explanation.AutoConnection.Error, Equals, `auto-connection not allowed by slot rule of interface "test"`)
+}
+
+func (s *interfaceManagerSuite) TestExplainConnectionPolicyLocalSnap(c *C) {
+	mgr := s.mockExplainConnectionPolicy(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	info, err := snap.InfoFromSnapYaml([]byte(consumerYaml))
+	c.Assert(err, IsNil)
+	headers := map[string]any{
+		"series":       "16",
+		"snap-name":    "consumer",
+		"publisher-id": "producer-publisher",
+		"snap-id":      "consumeridididididididididididid",
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}
+	a, err := s.storeSigning.Sign(asserts.SnapDeclarationType, headers, nil, "")
+	c.Assert(err, IsNil)
+
+	// the local snap is published by the publisher of the slot snap
+	explanation, err := mgr.ExplainConnectionPolicy(
+		&ifacestate.ConnectionPolicySide{Snap: "consumer", Name: "plug", SnapInfo: info, SnapDeclaration: a.(*asserts.SnapDeclaration)},
+		&ifacestate.ConnectionPolicySide{Snap: "producer", Name: "slot"})
+	c.Assert(err, IsNil)
+	c.Check(explanation.Connection.Allowed, Equals, true)
+	c.Check(explanation.Connection.Error, Equals, "")
+	c.Check(explanation.AutoConnection.Allowed, Equals, false)
+}
+
+func (s *interfaceManagerSuite) TestExplainConnectionPolicyErrors(c *C) {
+	mgr := s.mockExplainConnectionPolicy(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	info, err := snap.InfoFromSnapYaml([]byte(consumerYaml))
+	c.Assert(err, IsNil)
+
+	for _, t := range []struct {
+		plug, slot *ifacestate.ConnectionPolicySide
+		err        string
+	}{{
+		plug: &ifacestate.ConnectionPolicySide{Snap: "consumer", Name: "whatplug"},
+		slot: &ifacestate.ConnectionPolicySide{Snap: "producer", Name: "slot"},
+		err:  `snap "consumer" has no plug named "whatplug"`,
+	}, {
+		plug: &ifacestate.ConnectionPolicySide{Snap: "consumer", Name: "plug"},
+		slot: &ifacestate.ConnectionPolicySide{Snap: "producer", Name: "whatslot"},
+		err:  `snap "producer" has no slot named "whatslot"`,
+	}, {
+		plug: &ifacestate.ConnectionPolicySide{Snap: "consumer", Name: "otherplug"},
+		slot: &ifacestate.ConnectionPolicySide{Snap: "producer", Name: "slot"},
+		err:  `cannot connect consumer:otherplug \("test2" interface\) to producer:slot \("test" interface\)`,
+	}, {
+		plug: &ifacestate.ConnectionPolicySide{Snap: "other", Name: "plug", SnapInfo: info},
+		slot: &ifacestate.ConnectionPolicySide{Snap: "producer", Name: "slot"},
+		err:  `cannot use snap "consumer" for plug of snap "other"`,
+	}, {
+		plug: &ifacestate.ConnectionPolicySide{Snap: "consumer", Name: "whatplug", SnapInfo: info},
+		slot: &ifacestate.ConnectionPolicySide{Snap: "producer", Name: "slot"},
+		err:  `snap "consumer" has no plug named "whatplug"`,
+	}} {
+		_, err := mgr.ExplainConnectionPolicy(t.plug, t.slot)
+		c.Check(err, ErrorMatches, t.err)
+	}
+}
+
 func (s *interfaceManagerSuite) TestResolveDisconnectWithRepository(c *C) {
 	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"})
 	mgr := s.manager(c)
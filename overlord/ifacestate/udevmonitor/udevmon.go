@@ -98,6 +98,9 @@ func (m *Monitor) Connect() error {
 			{Env: map[string]string{"SUBSYSTEM": "net"}},
 			{Env: map[string]string{"SUBSYSTEM": "tty"}},
 			{Env: map[string]string{"SUBSYSTEM": "usb"}},
+			{Env: map[string]string{"SUBSYSTEM": "video4linux"}},
+			{Env: map[string]string{"SUBSYSTEM": "hidraw"}},
+			{Env: map[string]string{"SUBSYSTEM": "block"}},
 		}}
 
 	m.monitorStop = m.netlinkConn.Monitor(m.netlinkEvents, m.netlinkErrors, filter)
@@ -22,6 +22,7 @@
 package overlord
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"net/http"
@@ -35,6 +36,7 @@ import (
 	"gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/assertstate"
@@ -92,6 +94,9 @@ var pruneTickerC = func(t *time.Ticker) <-chan time.Time {
 type Overlord struct {
 	stateFLock *osutil.FileLock
 
+	// stateJournal is set when the state is persisted incrementally
+	stateJournal *stateJournal
+
 	stateEng *StateEngine
 	// ensure loop
 	loopTomb    *tomb.Tomb
@@ -121,6 +126,7 @@ type Overlord struct {
 	noticeMgr     *notices.NoticeManager
 	confdbMgr     *confdbstate.ConfdbManager
 	deviceMgmtMgr *devicemgmtstate.DeviceMgmtManager
+	healthMgr     *healthstate.HealthManager
 
 	// proxyConf mediates the http proxy config
 	proxyConf func(req *http.Request) (*url.URL, error)
@@ -135,10 +141,17 @@ func New(restartHandler restart.Handler) (*Overlord, error) {
 		inited: true,
 	}
 
-	backend := &overlordStateBackend{
+	var backend state.Backend = &overlordStateBackend{
 		path:         dirs.SnapStateFile,
 		ensureBefore: o.ensureBefore,
 	}
+	if features.StateJournal.IsEnabled() {
+		o.stateJournal = &stateJournal{path: dirs.SnapStateJournalFile}
+		backend = &journaledStateBackend{
+			overlordStateBackend: backend.(*overlordStateBackend),
+			journal:              o.stateJournal,
+		}
+	}
 	s, restartMgr, err := o.loadState(backend, restartHandler)
 	if err != nil {
 		return nil, err
@@ -207,6 +220,7 @@ func New(restartHandler restart.Handler) (*Overlord, error) {
 		return nil, err
 	}
 	healthstate.Init(hookMgr)
+	o.addManager(healthstate.Manager(s, hookMgr))
 
 	o.addManager(devicemgmtstate.Manager(s, o.runner, deviceMgr))
 
@@ -253,6 +267,8 @@ func (o *Overlord) addManager(mgr StateManager) {
 		o.confdbMgr = x
 	case *devicemgmtstate.DeviceMgmtManager:
 		o.deviceMgmtMgr = x
+	case *healthstate.HealthManager:
+		o.healthMgr = x
 	}
 	o.stateEng.AddManager(mgr)
 }
@@ -331,15 +347,18 @@ func (o *Overlord) loadState(backend state.Backend, restartHandler restart.Handl
 		return s, restartMgr, nil
 	}
 
-	r, err := os.Open(dirs.SnapStateFile)
+	data, err := os.ReadFile(dirs.SnapStateFile)
 	if err != nil {
 		return nil, nil, fmt.Errorf("cannot read the state file: %s", err)
 	}
-	defer r.Close()
 
 	var s *state.State
 	timings.Run(perfTimings, "read-state", "read snapd state from disk", func(tm timings.Measurer) {
-		s, err = state.ReadState(backend, r)
+		data, err = replayStateJournal(data)
+		if err != nil {
+			return
+		}
+		s, err = state.ReadState(backend, bytes.NewReader(data))
 	})
 	if err != nil {
 		return nil, nil, err
@@ -361,6 +380,31 @@ func (o *Overlord) loadState(backend state.Backend, restartHandler restart.Handl
 	return s, restartMgr, nil
 }
 
+// replayStateJournal applies any state journal left over from a previous run
+// on top of the given state file content, and compacts the result back into
+// the state file so that the state file alone is always up to date when snapd
+// starts, whether journaling is enabled or not.
+func replayStateJournal(data []byte) ([]byte, error) {
+	deltas, err := readStateJournal(dirs.SnapStateJournalFile, data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the state journal: %v", err)
+	}
+	if len(deltas) != 0 {
+		logger.Noticef("Replaying %d state journal records", len(deltas))
+		data, err = state.ReplayDeltas(data, deltas)
+		if err != nil {
+			return nil, err
+		}
+		if err := osutil.AtomicWriteFile(dirs.SnapStateFile, data, 0600, 0); err != nil {
+			return nil, fmt.Errorf("cannot compact the state journal: %v", err)
+		}
+	}
+	if err := os.Remove(dirs.SnapStateJournalFile); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot remove the state journal: %v", err)
+	}
+	return data, nil
+}
+
 func initRestart(s *state.State, curBootID string, restartHandler restart.Handler) (*restart.RestartManager, error) {
 	s.Lock()
 	defer s.Unlock()
@@ -377,6 +421,9 @@ func (o *Overlord) newStoreWithContext(storeCtx store.DeviceAndAuthContext) snap
 	} else {
 		sto.SetCachePolicy(store.DefaultCachePolicyCore)
 	}
+	if o.snapMgr != nil {
+		sto.SetPeerFetcher(o.snapMgr.PeerFetcher())
+	}
 	return sto
 }
 
@@ -547,6 +594,16 @@ func (o *Overlord) Stop() error {
 		err = o.loopTomb.Wait()
 	}
 	o.stateEng.Stop()
+	if o.stateJournal != nil {
+		// compact the journal so that the state file alone is
+		// up to date, for the benefit of anything reading it
+		// directly, including older versions of snapd
+		st := o.State()
+		st.Lock()
+		st.RequestFullCheckpoint()
+		st.Unlock()
+		o.stateJournal.close()
+	}
 	if o.stateFLock != nil {
 		// This will also unlock the file
 		o.stateFLock.Close()
@@ -740,6 +797,12 @@ func (o *Overlord) DeviceMgmtManager() *devicemgmtstate.DeviceMgmtManager {
 	return o.deviceMgmtMgr
 }
 
+// HealthManager returns the manager responsible for periodic health checks
+// and health-driven reverts.
+func (o *Overlord) HealthManager() *healthstate.HealthManager {
+	return o.healthMgr
+}
+
 // Mock creates an Overlord without any managers and with a backend
 // not using disk. Managers can be added with AddManager. For testing.
 func Mock() *Overlord {
@@ -20,6 +20,8 @@
 package overlord_test
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -39,6 +41,7 @@ import (
 	"github.com/snapcore/snapd/boot"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/dirs/dirstest"
+	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
@@ -132,6 +135,7 @@ func (ovs *overlordSuite) TestNew(c *C) {
 	c.Check(o.FDEManager(), NotNil)
 	c.Check(o.ConfdbManager(), NotNil)
 	c.Check(o.DeviceMgmtManager(), NotNil)
+	c.Check(o.HealthManager(), NotNil)
 	c.Check(configstateInitCalled, Equals, true)
 
 	o.InterfaceManager().DisableUDevMonitor()
@@ -274,6 +278,134 @@ func (ovs *overlordSuite) TestNewWithInvalidState(c *C) {
 	c.Assert(err, ErrorMatches, "cannot read state: EOF")
 }
 
+func writeStateJournal(c *C, base []byte, records ...string) {
+	sum := sha256.Sum256(base)
+	content := fmt.Sprintf(`{"format":1,"base-sha256":%q}`+"\n", hex.EncodeToString(sum[:]))
+	for _, r := range records {
+		content += r + "\n"
+	}
+	c.Assert(os.MkdirAll(filepath.Dir(dirs.SnapStateJournalFile), 0755), IsNil)
+	c.Assert(os.WriteFile(dirs.SnapStateJournalFile, []byte(content), 0600), IsNil)
+}
+
+func (ovs *overlordSuite) TestNewReplaysStateJournal(c *C) {
+	fakeState := []byte(fmt.Sprintf(`{"data":{"patch-level":%d,"patch-sublevel":%d,"patch-sublevel-last-version":%q,"some":"data","gone":true},"changes":null,"tasks":null,"last-change-id":0,"last-task-id":0,"last-lane-id":0}`, patch.Level, patch.Sublevel, snapdtool.Version))
+	c.Assert(os.WriteFile(dirs.SnapStateFile, fakeState, 0600), IsNil)
+	writeStateJournal(c, fakeState,
+		`{"data":{"set":{"some":"other-data"}},"last-change-id":0,"last-task-id":0,"last-lane-id":0,"last-notice-id":0}`,
+		`{"data":{"set":{"more":42},"removed":["gone"]},"last-change-id":0,"last-task-id":0,"last-lane-id":5,"last-notice-id":0}`,
+		// torn write
+		`{"data":{"set":{"more":`)
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+
+	// the journal was compacted into the state file
+	c.Check(dirs.SnapStateJournalFile, testutil.FileAbsent)
+	c.Check(dirs.SnapStateFile, testutil.FileContains, `"some":"other-data"`)
+
+	st := o.State()
+	st.Lock()
+	defer st.Unlock()
+	var some string
+	c.Assert(st.Get("some", &some), IsNil)
+	c.Check(some, Equals, "other-data")
+	var more int
+	c.Assert(st.Get("more", &more), IsNil)
+	c.Check(more, Equals, 42)
+	c.Check(st.Has("gone"), Equals, false)
+	c.Check(st.NewLane(), Equals, 6)
+}
+
+func (ovs *overlordSuite) TestNewIgnoresStaleStateJournal(c *C) {
+	fakeState := []byte(fmt.Sprintf(`{"data":{"patch-level":%d,"patch-sublevel":%d,"patch-sublevel-last-version":%q,"some":"data"},"changes":null,"tasks":null,"last-change-id":0,"last-task-id":0,"last-lane-id":0}`, patch.Level, patch.Sublevel, snapdtool.Version))
+	c.Assert(os.WriteFile(dirs.SnapStateFile, fakeState, 0600), IsNil)
+	writeStateJournal(c, []byte("other state"),
+		`{"data":{"set":{"some":"other-data"}},"last-change-id":0,"last-task-id":0,"last-lane-id":0,"last-notice-id":0}`)
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+	c.Check(dirs.SnapStateJournalFile, testutil.FileAbsent)
+
+	st := o.State()
+	st.Lock()
+	defer st.Unlock()
+	var some string
+	c.Assert(st.Get("some", &some), IsNil)
+	c.Check(some, Equals, "data")
+}
+
+func (ovs *overlordSuite) TestStateJournalCheckpoints(c *C) {
+	restore := patch.Mock(42, 2, nil)
+	defer restore()
+
+	c.Assert(os.MkdirAll(dirs.FeaturesDir, 0755), IsNil)
+	c.Assert(os.WriteFile(features.StateJournal.ControlFile(), nil, 0644), IsNil)
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+
+	// the state was fully written while starting up
+	c.Check(dirs.SnapStateFile, testutil.FilePresent)
+	c.Check(dirs.SnapStateJournalFile, testutil.FilePresent)
+
+	st := o.State()
+	st.Lock()
+	st.Set("some", "data")
+	st.Unlock()
+
+	st.Lock()
+	st.Set("some", "more-data")
+	st.Unlock()
+
+	// only the journal got the updates
+	c.Check(dirs.SnapStateFile, Not(testutil.FileContains), `"some"`)
+	c.Check(dirs.SnapStateJournalFile, testutil.FileContains, `{"data":{"set":{"some":"data"}}`)
+	c.Check(dirs.SnapStateJournalFile, testutil.FileContains, `{"data":{"set":{"some":"more-data"}}`)
+
+	// stopping compacts the journal into the state file
+	c.Assert(o.Stop(), IsNil)
+	c.Check(dirs.SnapStateFile, testutil.FileContains, `"some":"more-data"`)
+
+	// and reloading gives the latest state back
+	o, err = overlord.New(nil)
+	c.Assert(err, IsNil)
+	st = o.State()
+	st.Lock()
+	defer st.Unlock()
+	var some string
+	c.Assert(st.Get("some", &some), IsNil)
+	c.Check(some, Equals, "more-data")
+}
+
+func (ovs *overlordSuite) TestStateJournalCompaction(c *C) {
+	restore := patch.Mock(42, 2, nil)
+	defer restore()
+	restore = overlord.MockMinStateJournalCompactSize(0)
+	defer restore()
+
+	c.Assert(os.MkdirAll(dirs.FeaturesDir, 0755), IsNil)
+	c.Assert(os.WriteFile(features.StateJournal.ControlFile(), nil, 0644), IsNil)
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+
+	st := o.State()
+	// grow the journal past the size of the state file
+	big := strings.Repeat("x", 100*1024)
+	st.Lock()
+	st.Set("big", big)
+	st.Unlock()
+	c.Check(dirs.SnapStateFile, Not(testutil.FileContains), big)
+
+	st.Lock()
+	st.Set("some", "more-data")
+	st.Unlock()
+	c.Check(dirs.SnapStateFile, testutil.FileContains, big)
+	c.Check(dirs.SnapStateFile, testutil.FileContains, `"some":"more-data"`)
+	c.Check(dirs.SnapStateJournalFile, Not(testutil.FileContains), "more-data")
+}
+
 func (ovs *overlordSuite) TestNewWithPatches(c *C) {
 	p := func(s *state.State) error {
 		s.Set("patched", true)
@@ -20,6 +20,8 @@
 package servicestate
 
 import (
+	"time"
+
 	tomb "gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/overlord/state"
@@ -63,3 +65,25 @@ func MockResourcesCheckFeatureRequirements(f func(*quota.Resources) error) (rest
 	resourcesCheckFeatureRequirements = f
 	return r
 }
+
+func MockQuotaGroupUsage(f func(*quota.Group) (QuotaUsageSample, error)) (restore func()) {
+	r := testutil.Backup(&quotaGroupUsage)
+	quotaGroupUsage = f
+	return r
+}
+
+func MockQuotaUsageHistorySize(n int) (restore func()) {
+	r := testutil.Backup(&quotaUsageHistorySize)
+	quotaUsageHistorySize = n
+	return r
+}
+
+func MockTimeNow(f func() time.Time) (restore func()) {
+	r := testutil.Backup(&timeNow)
+	timeNow = f
+	return r
+}
+
+func (m *ServiceManager) EnsureQuotaUsageSampled() error {
+	return m.ensureQuotaUsageSampled()
+}
@@ -98,6 +98,8 @@ func verifyQuotaRequirements(st *state.State, resourceLimits quota.Resources) er
 	// MemoryLimit requires systemd 211, so it's covered by the initial check
 	// CPUQuota requires systemd 213, so no further checks need to be done
 	// TasksMax requires systemd 228, so no further checks need to be done
+	// IOWeight and IO{Read,Write}BandwidthMax require systemd 230, so no further
+	// checks need to be done either
 
 	// AllowedCPUs requires systemd 243, so we need to verify the version here
 	if resourceLimits.CPUSet != nil {
@@ -106,6 +108,13 @@ func verifyQuotaRequirements(st *state.State, resourceLimits quota.Resources) er
 		}
 	}
 
+	// IPAccounting requires systemd 235, so we need to verify the version here
+	if resourceLimits.Network != nil && resourceLimits.Network.Accounting {
+		if err := systemd.EnsureAtLeast(235); err != nil {
+			return fmt.Errorf("cannot use network accounting with incompatible systemd: %v", err)
+		}
+	}
+
 	// Journal quotas require systemd 245, so we need to verify the version here as well
 	if resourceLimits.Journal != nil {
 		if err := systemd.EnsureAtLeast(245); err != nil {
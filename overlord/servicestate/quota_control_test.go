@@ -145,7 +145,7 @@ func checkQuotaState(c *C, st *state.State, exp map[string]quotaGroupState) {
 func shouldMentionSlice(resources quota.Resources) bool {
 	if resources.Memory == nil && resources.CPU == nil &&
 		resources.CPUSet == nil && resources.Threads == nil &&
-		resources.Journal == nil {
+		resources.Journal == nil && resources.IO == nil {
 		return false
 	}
 	return true
@@ -187,6 +187,9 @@ func checkSliceState(c *C, sliceName string, resources quota.Resources) {
 	if resources.Threads != nil {
 		c.Assert(sliceFileName, testutil.FileContains, fmt.Sprintf("\nThreadsMax=%d\n", resources.Threads.Limit))
 	}
+	if resources.IO != nil {
+		c.Assert(sliceFileName, testutil.FileContains, "\nIOAccounting=true\n")
+	}
 }
 
 func systemctlCallsForSliceStart(name string) []expectedSystemctl {
@@ -2157,6 +2157,72 @@ func (s *quotaHandlersSuite) TestQuotaUpdateJournalQuotaNotAllowedForServices(c
 	c.Assert(err, ErrorMatches, `cannot update limits for group "foo2": journal quotas are not supported for individual services`)
 }
 
+func (s *quotaHandlersSuite) TestCreateAndUpdateIOQuota(c *C) {
+	r := s.mockSystemctlCalls(c, join(
+		// CreateQuota for foo
+		systemctlCallsForCreateQuota("foo", "test-snap"),
+
+		// UpdateQuota for foo
+		[]expectedSystemctl{{expArgs: []string{"daemon-reload"}}},
+
+		// UpdateQuota for foo, removing the weight
+		[]expectedSystemctl{{expArgs: []string{"daemon-reload"}}},
+	))
+	defer r()
+
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	// setup the snap so it exists
+	snapstate.Set(s.state, "test-snap", s.testSnapState)
+	snaptest.MockSnapCurrent(c, testYaml, s.testSnapSideInfo)
+
+	qc := servicestate.QuotaControlAction{
+		Action:         "create",
+		QuotaName:      "foo",
+		ResourceLimits: quota.NewResourcesBuilder().WithIOReadBandwidth(quantity.SizeMiB * 10).Build(),
+		AddSnaps:       []string{"test-snap"},
+	}
+
+	err := s.callDoQuotaControl(&qc)
+	c.Assert(err, IsNil)
+
+	qc = servicestate.QuotaControlAction{
+		Action:         "update",
+		QuotaName:      "foo",
+		ResourceLimits: quota.NewResourcesBuilder().WithIOReadBandwidth(quantity.SizeMiB).WithIOWeight(50).Build(),
+	}
+
+	err = s.callDoQuotaControl(&qc)
+	c.Assert(err, IsNil)
+
+	checkQuotaState(c, st, map[string]quotaGroupState{
+		"foo": {
+			ResourceLimits: quota.NewResourcesBuilder().WithIOReadBandwidth(quantity.SizeMiB).WithIOWeight(50).Build(),
+			Snaps:          []string{"test-snap"},
+		},
+	})
+	c.Check(filepath.Join(dirs.SnapServicesDir, "snap.foo.slice"), testutil.FileContains, "\nIOWeight=50\nIOReadBandwidthMax=/var/snap 1048576\n")
+
+	qc = servicestate.QuotaControlAction{
+		Action:         "update",
+		QuotaName:      "foo",
+		ResourceLimits: quota.NewResourcesBuilder().WithIOWeight(0).Build(),
+	}
+
+	err = s.callDoQuotaControl(&qc)
+	c.Assert(err, IsNil)
+
+	checkQuotaState(c, st, map[string]quotaGroupState{
+		"foo": {
+			ResourceLimits: quota.NewResourcesBuilder().WithIOReadBandwidth(quantity.SizeMiB).Build(),
+			Snaps:          []string{"test-snap"},
+		},
+	})
+	c.Check(filepath.Join(dirs.SnapServicesDir, "snap.foo.slice"), Not(testutil.FileContains), "IOWeight=")
+}
+
 func (s *quotaHandlersSuite) TestCreateJournalQuota(c *C) {
 	r := s.mockSystemctlCalls(c, join(
 		// CreateQuota for foo
@@ -0,0 +1,277 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap/quota"
+)
+
+var (
+	// quotaUsageSampleInterval is the interval between samples of the usage
+	// of quota groups, as part of Ensure().
+	quotaUsageSampleInterval = time.Minute
+	// quotaUsageHistorySize is the number of samples kept for each quota
+	// group, that is an hour with the default interval.
+	quotaUsageHistorySize = 60
+
+	defaultQuotaUsageAlertDuration = 5 * time.Minute
+
+	timeNow = time.Now
+)
+
+// QuotaUsageSample is the memory and thread usage of a quota group at a given
+// time.
+type QuotaUsageSample struct {
+	Time    time.Time
+	Memory  quantity.Size
+	Threads int
+}
+
+// quotaUsageHistory keeps the last samples of a quota group in a ring buffer.
+type quotaUsageHistory struct {
+	samples []QuotaUsageSample
+	next    int
+
+	// aboveSince is when the usage of each resource went above the alert
+	// threshold, and alerted whether a notice was recorded for it since.
+	aboveSince map[string]time.Time
+	alerted    map[string]bool
+}
+
+func newQuotaUsageHistory() *quotaUsageHistory {
+	return &quotaUsageHistory{
+		samples:    make([]QuotaUsageSample, 0, quotaUsageHistorySize),
+		aboveSince: make(map[string]time.Time),
+		alerted:    make(map[string]bool),
+	}
+}
+
+func (h *quotaUsageHistory) add(sample QuotaUsageSample) {
+	if len(h.samples) < cap(h.samples) {
+		h.samples = append(h.samples, sample)
+		return
+	}
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % len(h.samples)
+}
+
+// since returns the samples taken after the given time, oldest first.
+func (h *quotaUsageHistory) since(t time.Time) []QuotaUsageSample {
+	var samples []QuotaUsageSample
+	for i := range h.samples {
+		sample := h.samples[(h.next+i)%len(h.samples)]
+		if sample.Time.After(t) {
+			samples = append(samples, sample)
+		}
+	}
+	return samples
+}
+
+type quotaUsageHistoriesKey struct{}
+
+// The usage history is only kept in memory, and starts over when snapd
+// restarts.
+func quotaUsageHistories(st *state.State) map[string]*quotaUsageHistory {
+	histories, _ := st.Cached(quotaUsageHistoriesKey{}).(map[string]*quotaUsageHistory)
+	if histories == nil {
+		histories = make(map[string]*quotaUsageHistory)
+		st.Cache(quotaUsageHistoriesKey{}, histories)
+	}
+	return histories
+}
+
+// QuotaUsageHistory returns the usage samples of the given quota group taken
+// after the given time, oldest first.
+func QuotaUsageHistory(st *state.State, name string, since time.Time) []QuotaUsageSample {
+	h := quotaUsageHistories(st)[name]
+	if h == nil {
+		return nil
+	}
+	return h.since(since)
+}
+
+var quotaGroupUsage = func(grp *quota.Group) (QuotaUsageSample, error) {
+	mem, err := grp.CurrentMemoryUsage()
+	if err != nil {
+		return QuotaUsageSample{}, err
+	}
+	threads, err := grp.CurrentTaskUsage()
+	if err != nil {
+		return QuotaUsageSample{}, err
+	}
+	return QuotaUsageSample{Time: timeNow(), Memory: mem, Threads: threads}, nil
+}
+
+// quotaUsageAlert returns the usage alert threshold, as a percentage of the
+// limits, and how long the usage needs to stay above it before a notice is
+// recorded. A zero threshold means alerts are disabled.
+func quotaUsageAlert(st *state.State) (threshold int, duration time.Duration, err error) {
+	tr := config.NewTransaction(st)
+	// numbers set through "snap set" are stored as such, but be lax
+	var value any
+	if err := tr.Get("core", "quotas.usage-alert.threshold", &value); err != nil && !config.IsNoOption(err) {
+		return 0, 0, err
+	}
+	if value != nil {
+		threshold, err = strconv.Atoi(fmt.Sprint(value))
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid quotas.usage-alert.threshold: %v", err)
+		}
+	}
+	duration = defaultQuotaUsageAlertDuration
+	var durationStr string
+	if err := tr.Get("core", "quotas.usage-alert.duration", &durationStr); err != nil && !config.IsNoOption(err) {
+		return 0, 0, err
+	}
+	if durationStr != "" {
+		duration, err = time.ParseDuration(durationStr)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid quotas.usage-alert.duration: %v", err)
+		}
+	}
+	return threshold, duration, nil
+}
+
+// checkUsageAlert records a quota-usage notice for the group once the usage
+// of the given resource has been above the threshold for the given duration.
+func (h *quotaUsageHistory) checkUsageAlert(st *state.State, grp *quota.Group, resource string, usage, limit uint64, now time.Time, threshold int, duration time.Duration) error {
+	if threshold == 0 || float64(usage)*100 < float64(limit)*float64(threshold) {
+		delete(h.aboveSince, resource)
+		delete(h.alerted, resource)
+		return nil
+	}
+	if _, ok := h.aboveSince[resource]; !ok {
+		h.aboveSince[resource] = now
+	}
+	if h.alerted[resource] || now.Sub(h.aboveSince[resource]) < duration {
+		return nil
+	}
+	h.alerted[resource] = true
+	// the usage of quota groups is only for admins to see
+	rootUID := uint32(0)
+	_, err := st.AddNotice(&rootUID, state.QuotaUsageNotice, grp.Name, &state.AddNoticeOptions{
+		Data: map[string]string{
+			"resource":  resource,
+			"usage":     strconv.FormatUint(usage, 10),
+			"limit":     strconv.FormatUint(limit, 10),
+			"threshold": strconv.Itoa(threshold),
+		},
+	})
+	return err
+}
+
+// ensureQuotaUsageSampled samples the usage of the quota groups with limits,
+// keeps the samples in their usage history, and records
+// quota-usage notices for groups that stay above the alert threshold.
+func (m *ServiceManager) ensureQuotaUsageSampled() error {
+	now := timeNow()
+	if now.Before(m.lastQuotaUsageSampleTime.Add(quotaUsageSampleInterval)) {
+		return nil
+	}
+	m.lastQuotaUsageSampleTime = now
+
+	m.state.Lock()
+	allGrps, err := AllQuotas(m.state)
+	m.state.Unlock()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(allGrps))
+	for name, grp := range allGrps {
+		if res := grp.GetQuotaResources(); !res.Unset() {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	logger.Trace("ensure", "manager", "ServiceManager", "func", "ensureQuotaUsageSampled")
+
+	// sample without holding the state lock, as this talks to systemd
+	samples := make(map[string]QuotaUsageSample, len(names))
+	for _, name := range names {
+		sample, err := quotaGroupUsage(allGrps[name])
+		if err != nil {
+			logger.Noticef("cannot sample usage of quota group %q: %v", name, err)
+			continue
+		}
+		samples[name] = sample
+	}
+
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	threshold, duration, err := quotaUsageAlert(m.state)
+	if err != nil {
+		logger.Noticef("cannot check quota group usage alerts: %v", err)
+	}
+	histories := quotaUsageHistories(m.state)
+	for name := range histories {
+		if _, ok := allGrps[name]; !ok {
+			delete(histories, name)
+		}
+	}
+	for _, name := range names {
+		sample, ok := samples[name]
+		if !ok {
+			continue
+		}
+		grp := allGrps[name]
+		h := histories[name]
+		if h == nil {
+			h = newQuotaUsageHistory()
+			histories[name] = h
+		}
+		h.add(sample)
+
+		// without a hard memory limit, alert when getting close to the
+		// point where the group gets throttled
+		memoryLimit := grp.MemoryLimit
+		if memoryLimit == 0 {
+			memoryLimit = grp.MemoryHigh
+		}
+		if memoryLimit != 0 {
+			if err := h.checkUsageAlert(m.state, grp, "memory", uint64(sample.Memory), uint64(memoryLimit), sample.Time, threshold, duration); err != nil {
+				return err
+			}
+		}
+		if grp.ThreadLimit != 0 {
+			if err := h.checkUsageAlert(m.state, grp, "threads", uint64(sample.Threads), uint64(grp.ThreadLimit), sample.Time, threshold, duration); err != nil {
+				return err
+			}
+		}
+	}
+
+	// make sure we get to sample again in time
+	m.state.EnsureBefore(quotaUsageSampleInterval)
+	return nil
+}
@@ -0,0 +1,228 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package servicestate_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/servicestate/servicestatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap/quota"
+)
+
+type quotaUsageSuite struct {
+	baseServiceMgrTestSuite
+
+	now   time.Time
+	usage map[string]servicestate.QuotaUsageSample
+}
+
+var _ = Suite(&quotaUsageSuite{})
+
+func (s *quotaUsageSuite) SetUpTest(c *C) {
+	s.baseServiceMgrTestSuite.SetUpTest(c)
+
+	servicestate.MockEnsuredSnapServices(s.mgr, true)
+
+	s.now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.AddCleanup(servicestate.MockTimeNow(func() time.Time { return s.now }))
+	s.usage = make(map[string]servicestate.QuotaUsageSample)
+	s.AddCleanup(servicestate.MockQuotaGroupUsage(func(grp *quota.Group) (servicestate.QuotaUsageSample, error) {
+		sample := s.usage[grp.Name]
+		sample.Time = s.now
+		return sample, nil
+	}))
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	_, err := servicestatetest.PatchQuotas(s.state,
+		&quota.Group{Name: "foo", MemoryLimit: quantity.SizeGiB},
+		&quota.Group{Name: "bar", ThreadLimit: 100},
+		&quota.Group{Name: "baz", CPULimit: &quota.GroupQuotaCPU{Percentage: 50}},
+		&quota.Group{Name: "qux", MemoryHigh: 512 * quantity.SizeMiB},
+	)
+	c.Assert(err, IsNil)
+}
+
+func (s *quotaUsageSuite) sample(c *C, after time.Duration) {
+	s.now = s.now.Add(after)
+	c.Assert(s.mgr.EnsureQuotaUsageSampled(), IsNil)
+}
+
+func (s *quotaUsageSuite) quotaUsageNotices() []*state.Notice {
+	s.state.Lock()
+	defer s.state.Unlock()
+	return s.state.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.QuotaUsageNotice}})
+}
+
+func occurrences(c *C, n *state.Notice) float64 {
+	data, err := json.Marshal(n)
+	c.Assert(err, IsNil)
+	var m map[string]any
+	c.Assert(json.Unmarshal(data, &m), IsNil)
+	return m["occurrences"].(float64)
+}
+
+func (s *quotaUsageSuite) TestHistory(c *C) {
+	defer servicestate.MockQuotaUsageHistorySize(3)()
+
+	start := s.now
+	for i := 1; i <= 4; i++ {
+		s.usage["foo"] = servicestate.QuotaUsageSample{Memory: quantity.Size(i) * quantity.SizeMiB}
+		s.usage["bar"] = servicestate.QuotaUsageSample{Threads: i}
+		s.sample(c, time.Minute)
+	}
+	// sampling is throttled
+	s.usage["foo"] = servicestate.QuotaUsageSample{Memory: quantity.SizeGiB}
+	s.sample(c, time.Second)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// only the last samples are kept, oldest first
+	c.Check(servicestate.QuotaUsageHistory(s.state, "foo", start), DeepEquals, []servicestate.QuotaUsageSample{
+		{Time: start.Add(2 * time.Minute), Memory: 2 * quantity.SizeMiB},
+		{Time: start.Add(3 * time.Minute), Memory: 3 * quantity.SizeMiB},
+		{Time: start.Add(4 * time.Minute), Memory: 4 * quantity.SizeMiB},
+	})
+	c.Check(servicestate.QuotaUsageHistory(s.state, "bar", start.Add(3*time.Minute)), DeepEquals, []servicestate.QuotaUsageSample{
+		{Time: start.Add(4 * time.Minute), Threads: 4},
+	})
+	// groups with any limit are sampled
+	c.Check(servicestate.QuotaUsageHistory(s.state, "baz", start), HasLen, 3)
+	c.Check(servicestate.QuotaUsageHistory(s.state, "unknown", start), HasLen, 0)
+}
+
+func (s *quotaUsageSuite) TestHistoryOfRemovedGroupIsDropped(c *C) {
+	s.sample(c, time.Minute)
+
+	s.state.Lock()
+	c.Check(servicestate.QuotaUsageHistory(s.state, "foo", time.Time{}), HasLen, 1)
+	s.state.Set("quotas", map[string]*quota.Group{"bar": {Name: "bar", ThreadLimit: 100}})
+	s.state.Unlock()
+
+	s.sample(c, time.Minute)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(servicestate.QuotaUsageHistory(s.state, "foo", time.Time{}), HasLen, 0)
+	c.Check(servicestate.QuotaUsageHistory(s.state, "bar", time.Time{}), HasLen, 2)
+}
+
+func (s *quotaUsageSuite) TestNoAlertsByDefault(c *C) {
+	s.usage["foo"] = servicestate.QuotaUsageSample{Memory: quantity.SizeGiB}
+	for i := 0; i < 10; i++ {
+		s.sample(c, time.Minute)
+	}
+	c.Check(s.quotaUsageNotices(), HasLen, 0)
+}
+
+func (s *quotaUsageSuite) TestAlerts(c *C) {
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "quotas.usage-alert.threshold", 90)
+	tr.Set("core", "quotas.usage-alert.duration", "3m")
+	tr.Commit()
+	s.state.Unlock()
+
+	// above the threshold, but not for long enough
+	s.usage["foo"] = servicestate.QuotaUsageSample{Memory: 950 * quantity.SizeMiB}
+	s.sample(c, time.Minute)
+	s.sample(c, time.Minute)
+	s.usage["foo"] = servicestate.QuotaUsageSample{Memory: 100 * quantity.SizeMiB}
+	s.sample(c, time.Minute)
+	s.usage["foo"] = servicestate.QuotaUsageSample{Memory: 950 * quantity.SizeMiB}
+	s.sample(c, time.Minute)
+	s.sample(c, time.Minute)
+	s.sample(c, time.Minute)
+	c.Check(s.quotaUsageNotices(), HasLen, 0)
+
+	s.sample(c, time.Minute)
+	notices := s.quotaUsageNotices()
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].Key(), Equals, "foo")
+	c.Check(notices[0].LastData(), DeepEquals, map[string]string{
+		"resource":  "memory",
+		"usage":     "996147200",
+		"limit":     "1073741824",
+		"threshold": "90",
+	})
+	c.Check(notices[0].String(), Matches, `Notice .* \(0:quota-usage:foo\)`)
+
+	// only one notice while usage stays high
+	s.sample(c, time.Minute)
+	s.sample(c, time.Minute)
+	c.Check(occurrences(c, s.quotaUsageNotices()[0]), Equals, 1.0)
+
+	// once usage went down, a new episode results in a new occurrence
+	s.usage["foo"] = servicestate.QuotaUsageSample{}
+	s.sample(c, time.Minute)
+	s.usage["foo"] = servicestate.QuotaUsageSample{Memory: quantity.SizeGiB}
+	for i := 0; i < 4; i++ {
+		s.sample(c, time.Minute)
+	}
+	notices = s.quotaUsageNotices()
+	c.Assert(notices, HasLen, 1)
+	c.Check(occurrences(c, notices[0]), Equals, 2.0)
+
+	// threads are checked independently
+	s.usage["bar"] = servicestate.QuotaUsageSample{Threads: 100}
+	for i := 0; i < 4; i++ {
+		s.sample(c, time.Minute)
+	}
+	notices = s.quotaUsageNotices()
+	c.Assert(notices, HasLen, 2)
+	c.Check(notices[1].Key(), Equals, "bar")
+	c.Check(notices[1].LastData()["resource"], Equals, "threads")
+}
+
+func (s *quotaUsageSuite) TestAlertsAgainstMemoryHigh(c *C) {
+	s.state.Lock()
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "quotas.usage-alert.threshold", 90)
+	tr.Set("core", "quotas.usage-alert.duration", "1m")
+	tr.Commit()
+	s.state.Unlock()
+
+	// without a memory limit, usage is compared against memory-high
+	s.usage["qux"] = servicestate.QuotaUsageSample{Memory: 500 * quantity.SizeMiB}
+	s.sample(c, time.Minute)
+	s.sample(c, time.Minute)
+
+	notices := s.quotaUsageNotices()
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].Key(), Equals, "qux")
+	c.Check(notices[0].LastData(), DeepEquals, map[string]string{
+		"resource":  "memory",
+		"usage":     "524288000",
+		"limit":     "536870912",
+		"threshold": "90",
+	})
+	// only admins can see the notice
+	uid, isSet := notices[0].UserID()
+	c.Check(isSet, Equals, true)
+	c.Check(uid, Equals, uint32(0))
+}
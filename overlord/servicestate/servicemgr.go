@@ -46,6 +46,7 @@ import (
 
 func init() {
 	swfeats.RegisterEnsure("ServiceManager", "ensureSnapServicesUpdated")
+	swfeats.RegisterEnsure("ServiceManager", "ensureQuotaUsageSampled")
 }
 
 // ServiceManager is responsible for starting and stopping snap services.
@@ -53,6 +54,8 @@ type ServiceManager struct {
 	state *state.State
 
 	ensuredSnapSvcs bool
+
+	lastQuotaUsageSampleTime time.Time
 }
 
 // Manager returns a new service manager.
@@ -211,6 +214,9 @@ func (m *ServiceManager) Ensure() error {
 	if err := m.ensureSnapServicesUpdated(); err != nil {
 		return err
 	}
+	if err := m.ensureQuotaUsageSampled(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -41,6 +41,7 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/quota"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/systemd"
 	"github.com/snapcore/snapd/testutil"
@@ -76,6 +77,12 @@ func (s *baseServiceMgrTestSuite) SetUpTest(c *C) {
 
 	s.restartRequests = nil
 
+	// sampling the usage of quota groups talks to systemd, which the tests
+	// do not expect
+	s.AddCleanup(servicestate.MockQuotaGroupUsage(func(grp *quota.Group) (servicestate.QuotaUsageSample, error) {
+		return servicestate.QuotaUsageSample{}, nil
+	}))
+
 	s.restartObserve = nil
 	s.o = overlord.Mock()
 	s.state = s.o.State()
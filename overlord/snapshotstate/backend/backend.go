@@ -301,11 +301,30 @@ func EstimateSnapshotSize(si *snap.Info, usernames []string, dirOpts *dirs.SnapD
 	return total, nil
 }
 
+// SaveFlags carries extra flags to drive save behavior.
+type SaveFlags struct {
+	// Chunked tells save to store the archives in the chunk store,
+	// deduplicating their content with the one of other snapshots.
+	Chunked bool
+	// Recipients, if any, are the keys to encrypt the archives to.
+	Recipients []Recipient
+}
+
 // Save a snapshot
-func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, dynSnapshotOpts *snap.SnapshotOptions, dirOpts *dirs.SnapDirOptions) (*client.Snapshot, error) {
+func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, dynSnapshotOpts *snap.SnapshotOptions, dirOpts *dirs.SnapDirOptions, flags *SaveFlags) (*client.Snapshot, error) {
 	if err := os.MkdirAll(dirs.SnapshotsDir, 0700); err != nil {
 		return nil, err
 	}
+	if flags == nil {
+		flags = &SaveFlags{}
+	}
+	if flags.Chunked && len(flags.Recipients) > 0 {
+		return nil, fmt.Errorf("cannot save encrypted snapshots in chunks")
+	}
+	var fingerprints []string
+	for _, r := range flags.Recipients {
+		fingerprints = append(fingerprints, r.Fingerprint())
+	}
 
 	snapshot := &client.Snapshot{
 		SetID:    id,
@@ -321,6 +340,9 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]any, use
 		SHA3_384: make(map[string]string),
 		Size:     0,
 		Conf:     cfg,
+		Chunked:  flags.Chunked,
+
+		KeyFingerprints: fingerprints,
 		// Note: Auto is no longer set in the Snapshot.
 	}
 
@@ -333,6 +355,7 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]any, use
 		if err := snapshotOptions.MergeDynamicExcludes(dynSnapshotOpts.Exclude); err != nil {
 			return nil, err
 		}
+		snapshotOptions.Include = dynSnapshotOpts.Include
 	}
 
 	aw, err := osutil.NewAtomicFile(Filename(snapshot), 0600, 0, osutil.NoChown, osutil.NoChown)
@@ -346,7 +369,7 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]any, use
 	defer w.Close() // note this does not close the file descriptor (that's done by hand on the atomic writer, above)
 	savingUserData := false
 	baseDataDir := snap.BaseDataDir(si.InstanceName())
-	if err := addSnapDirToZip(ctx, snapshot, w, "root", archiveName, baseDataDir, savingUserData, snapshotOptions.Exclude); err != nil {
+	if err := addSnapDirToZip(ctx, snapshot, w, "root", archiveName, baseDataDir, savingUserData, snapshotOptions.Include, snapshotOptions.Exclude, flags.Recipients); err != nil {
 		return nil, err
 	}
 
@@ -358,7 +381,7 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]any, use
 	savingUserData = true
 	for _, usr := range users {
 		snapDataDir := filepath.Dir(si.UserDataDir(usr.HomeDir, dirOpts))
-		if err := addSnapDirToZip(ctx, snapshot, w, usr.Username, userArchiveName(usr), snapDataDir, savingUserData, snapshotOptions.Exclude); err != nil {
+		if err := addSnapDirToZip(ctx, snapshot, w, usr.Username, userArchiveName(usr), snapDataDir, savingUserData, snapshotOptions.Include, snapshotOptions.Exclude, flags.Recipients); err != nil {
 			return nil, err
 		}
 	}
@@ -398,8 +421,9 @@ var isTesting = snapdenv.Testing()
 
 // addSnapDirToZip adds the 'common' and the 'rev' revisioned dir under 'snapDir'
 // to the snapshot. If one doesn't exist, it's ignored. If none exists, the
-// operation is skipped.
-func addSnapDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username, entry, snapDir string, savingUserData bool, excludePaths []string) error {
+// operation is skipped. If include paths are given, only what they match is
+// added, and the operation is skipped if they match nothing.
+func addSnapDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username, entry, snapDir string, savingUserData bool, includePaths, excludePaths []string, recipients []Recipient) error {
 	paths, err := pathsForSnapshot(snapDir, snapshot)
 	if err != nil {
 		return err
@@ -409,6 +433,30 @@ func addSnapDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writ
 		return nil
 	}
 
+	members := make([]string, 0, len(paths))
+	for _, path := range paths {
+		members = append(members, filepath.Base(path))
+	}
+
+	if len(includePaths) > 0 {
+		members, err = includedMembers(snapDir, members, expandSnapshotPaths(includePaths, snapshot.Revision, savingUserData))
+		if err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			logger.Debugf("Not saving %q in snapshot #%d of %q as nothing in it is included.", snapDir, snapshot.SetID, snapshot.Snap)
+			return nil
+		}
+	}
+
+	return addToZip(ctx, snapshot, w, username, entry, snapDir, members, expandSnapshotPaths(excludePaths, snapshot.Revision, savingUserData), recipients)
+}
+
+// expandSnapshotPaths expands the snap data directory variables in the given
+// paths to the directories they stand for in the snapshot archives, that are
+// relative to the snap data directory. Paths that are not relevant for the
+// type of data being considered are dropped.
+func expandSnapshotPaths(paths []string, rev snap.Revision, savingUserData bool) []string {
 	expandSnapDataDirs := func(varName string) string {
 		// Validation of the environment variables has already been performed.
 		// We just need to make sure that we consider the right variables
@@ -421,7 +469,7 @@ func addSnapDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writ
 		case varName == "SNAP_DATA" && !savingUserData:
 			fallthrough
 		case varName == "SNAP_USER_DATA" && savingUserData:
-			return snapshot.Revision.String()
+			return rev.String()
 		}
 		// The variable specified does not match the current operating mode
 		// (for example, the variable is SNAP_COMMON but we are saving user
@@ -431,23 +479,75 @@ func addSnapDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writ
 		return "-"
 	}
 
-	var expExcludePaths []string
-	for _, excludePath := range excludePaths {
-		expandedPath := os.Expand(excludePath, expandSnapDataDirs)
+	var expPaths []string
+	for _, path := range paths {
+		expandedPath := os.Expand(path, expandSnapDataDirs)
 		// "-" is the sentinel returned by expandSnapDataDirs() if the
-		// exclusion path is not relevant for the type of data being considered
+		// path is not relevant for the type of data being considered
 		if expandedPath[0] == '-' {
 			continue
 		}
-		expExcludePaths = append(expExcludePaths, expandedPath)
+		expPaths = append(expPaths, expandedPath)
 	}
+	return expPaths
+}
 
-	return addToZip(ctx, snapshot, w, username, entry, paths, expExcludePaths)
+// includedMembers returns the paths under dir, relative to it, that the
+// expanded include patterns match, within the given top level members.
+func includedMembers(dir string, members, includePaths []string) ([]string, error) {
+	var included []string
+	for _, pattern := range includePaths {
+		if !strutil.ListContains(members, strings.SplitN(pattern, "/", 2)[0]) {
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("cannot match snapshot include path %q: %v", pattern, err)
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(dir, match)
+			if err != nil {
+				return nil, err
+			}
+			if !strutil.ListContains(included, rel) {
+				included = append(included, rel)
+			}
+		}
+	}
+	sort.Strings(included)
+	return included, nil
 }
 
-// addToZip adds 'paths' to the snapshot. tar will change into the paths' parent
-// directory before creating the archive so that parent dirs are not added.
-func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username, entry string, paths []string, excludePaths []string) error {
+// pathMatches returns whether the given path, relative to the snap data
+// directory, or one of its parents, matches one of the expanded patterns.
+// As with tar's exclusion, patterns are anchored and "*" does not match "/".
+func pathMatches(patterns []string, path string) bool {
+	components := strings.Split(path, "/")
+	for _, pattern := range patterns {
+		patternComponents := strings.Split(pattern, "/")
+		if len(patternComponents) > len(components) {
+			continue
+		}
+		matches := true
+		for i, pc := range patternComponents {
+			if ok, _ := filepath.Match(pc, components[i]); !ok {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// addToZip adds 'members', relative to 'dir', to the snapshot. tar will change
+// into 'dir' before creating the archive so that parent dirs are not added.
+// For chunked snapshots, the archive is added to the chunk store and only its
+// manifest to the snapshot. If recipients are given, the archive is encrypted
+// to them; its recorded hash and size are still the ones of the plaintext.
+func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username, entry, dir string, members []string, excludePaths []string, recipients []Recipient) error {
 	archiveWriter, err := w.CreateHeader(&zip.FileHeader{Name: entry})
 	if err != nil {
 		return err
@@ -455,27 +555,45 @@ func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, use
 
 	tarArgs := []string{
 		"--create",
-		"--sparse", "--gzip",
+		"--sparse",
+	}
+	var cw *chunkWriter
+	var ew io.WriteCloser
+	archiveOut := archiveWriter
+	if len(recipients) > 0 {
+		ew, err = encryptWriter(archiveWriter, recipients)
+		if err != nil {
+			return fmt.Errorf("cannot encrypt archive: %v", err)
+		}
+		archiveOut = ew
+		tarArgs = append(tarArgs, "--gzip")
+	} else if snapshot.Chunked {
+		// compressing would defeat deduplication, chunks are
+		// compressed individually instead
+		cw = newChunkWriter()
+		archiveOut = cw
+	} else {
+		tarArgs = append(tarArgs, "--gzip")
+	}
+	tarArgs = append(tarArgs,
 		"--format", "gnu",
 		"--anchored",
 		"--no-wildcards-match-slash",
-	}
+	)
 
 	for _, path := range excludePaths {
 		tarArgs = append(tarArgs, fmt.Sprintf("--exclude=%s", path))
 	}
 
-	// use --directory so that the directory is added without its parent dirs
-	for _, path := range paths {
-		parent, dir := filepath.Split(path)
-		tarArgs = append(tarArgs, "--directory", parent, dir)
-	}
+	// use --directory so that the members are added without their parent dirs
+	tarArgs = append(tarArgs, "--directory", dir)
+	tarArgs = append(tarArgs, members...)
 
 	var sz osutil.Sizer
 	hasher := crypto.SHA3_384.New()
 
 	cmd := tarAsUser(username, tarArgs...)
-	cmd.Stdout = io.MultiWriter(archiveWriter, hasher, &sz)
+	cmd.Stdout = io.MultiWriter(archiveOut, hasher, &sz)
 
 	// keep (at most) the last 5 non-empty lines of what 'tar' writes to stderr
 	// (those are the most likely contain the reason for fatal errors)
@@ -504,6 +622,20 @@ func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, use
 		return fmt.Errorf("tar failed: %v", err)
 	}
 
+	if ew != nil {
+		if err := ew.Close(); err != nil {
+			return err
+		}
+	}
+	if cw != nil {
+		if err := cw.Close(); err != nil {
+			return err
+		}
+		if err := json.NewEncoder(archiveWriter).Encode(&cw.manifest); err != nil {
+			return err
+		}
+	}
+
 	snapshot.SHA3_384[entry] = fmt.Sprintf("%x", hasher.Sum(nil))
 	snapshot.Size += sz.Size()
 
@@ -872,6 +1004,13 @@ func unpackVerifySnapshotImport(ctx context.Context, r io.Reader, realSetID uint
 			continue
 		}
 
+		if strings.HasPrefix(header.Name, chunksDirName+"/") {
+			if err := importChunk(strings.TrimPrefix(header.Name, chunksDirName+"/"), tr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		if header.Name == "export.json" {
 			// XXX: read into memory and validate once we
 			// hashes in export.json
@@ -902,6 +1041,11 @@ func unpackVerifySnapshotImport(ctx context.Context, r io.Reader, realSetID uint
 		err = r.Check(context.TODO(), nil)
 		r.Close()
 		snapNames = append(snapNames, r.Snap)
+		if errors.Is(err, ErrNoDecryptionKey) {
+			// it will be checked when it is restored
+			logger.Noticef("Cannot verify the content of imported snapshot %q without its key.", targetPath)
+			err = nil
+		}
 		if err != nil {
 			return snapNames, fmt.Errorf("validation failed for %q: %v", targetPath, err)
 		}
@@ -925,6 +1069,9 @@ type SnapshotExport struct {
 	// open snapshot files
 	snapshotFiles []*os.File
 
+	// chunks used by the snapshots, if chunked
+	chunkIDs []string
+
 	// contentHash of the full snapshot
 	contentHash []byte
 
@@ -940,6 +1087,8 @@ type SnapshotExport struct {
 func NewSnapshotExport(ctx context.Context, setID uint64) (se *SnapshotExport, err error) {
 	var snapshotFiles []*os.File
 	var snapshotSet client.SnapshotSet
+	var chunkIDs []string
+	seenChunks := make(map[string]bool)
 
 	defer func() {
 		// cleanup any open FDs if anything goes wrong
@@ -972,6 +1121,17 @@ func NewSnapshotExport(ctx context.Context, setID uint64) (se *SnapshotExport, e
 				return fmt.Errorf("cannot open file from descriptor %d", fd)
 			}
 			snapshotFiles = append(snapshotFiles, f)
+
+			ids, err := reader.chunkIDs()
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if !seenChunks[id] {
+					seenChunks[id] = true
+					chunkIDs = append(chunkIDs, id)
+				}
+			}
 		}
 		return nil
 	})
@@ -986,7 +1146,7 @@ func NewSnapshotExport(ctx context.Context, setID uint64) (se *SnapshotExport, e
 	if err != nil {
 		return nil, fmt.Errorf("cannot calculate content hash for snapshot export %v: %v", setID, err)
 	}
-	se = &SnapshotExport{snapshotFiles: snapshotFiles, setID: setID, contentHash: h}
+	se = &SnapshotExport{snapshotFiles: snapshotFiles, chunkIDs: chunkIDs, setID: setID, contentHash: h}
 
 	// ensure we never leak FDs even if the user does not call close
 	runtime.SetFinalizer(se, (*SnapshotExport).Close)
@@ -1025,6 +1185,32 @@ func (se *SnapshotExport) Close() {
 	se.snapshotFiles = nil
 }
 
+func writeChunkToTar(tw *tar.Writer, id string) error {
+	f, err := os.Open(chunkPath(id))
+	if err != nil {
+		return fmt.Errorf("cannot export chunk %.7s…: %v", id, err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     path.Join(chunksDirName, id),
+		Size:     stat.Size(),
+		Mode:     0600,
+		ModTime:  stat.ModTime(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("cannot write header for chunk %.7s…: %v", id, err)
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("cannot write data for chunk %.7s…: %v", id, err)
+	}
+	return nil
+}
+
 type contentJSON struct {
 	ContentHash []byte `json:"content-hash"`
 }
@@ -1054,6 +1240,14 @@ func (se *SnapshotExport) StreamTo(w io.Writer) error {
 		return err
 	}
 
+	// write out the chunks first, so that the snapshots can be checked
+	// as soon as they are imported
+	for _, id := range se.chunkIDs {
+		if err := writeChunkToTar(tw, id); err != nil {
+			return err
+		}
+	}
+
 	// write out the individual snapshots
 	for _, snapshotFile := range se.snapshotFiles {
 		stat, err := snapshotFile.Stat()
@@ -450,7 +450,7 @@ func (s *snapshotSuite) TestIterSetIDoverride(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	cfg := map[string]any{"some-setting": false}
 
-	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, uint64(12))
 
@@ -568,8 +568,8 @@ func (s *snapshotSuite) TestAddDirToZipBails(c *check.C) {
 	defer restore()
 	savingUserData := false
 	// note as the zip is nil this would panic if it didn't bail
-	c.Check(backend.AddSnapDirToZip(nil, snapshot, nil, "", "an/entry", filepath.Join(s.root, "nonexistent"), savingUserData, nil), check.IsNil)
-	c.Check(backend.AddSnapDirToZip(nil, snapshot, nil, "", "an/entry", "/etc/passwd", savingUserData, nil), check.IsNil)
+	c.Check(backend.AddSnapDirToZip(nil, snapshot, nil, "", "an/entry", filepath.Join(s.root, "nonexistent"), savingUserData, nil, nil, nil), check.IsNil)
+	c.Check(backend.AddSnapDirToZip(nil, snapshot, nil, "", "an/entry", "/etc/passwd", savingUserData, nil, nil, nil), check.IsNil)
 	c.Check(buf.String(), check.Matches, "(?m).* is does not exist.*")
 }
 
@@ -585,7 +585,7 @@ func (s *snapshotSuite) TestAddDirToZipTarFails(c *check.C) {
 	var buf bytes.Buffer
 	z := zip.NewWriter(&buf)
 	savingUserData := false
-	c.Assert(backend.AddSnapDirToZip(ctx, &client.Snapshot{Revision: rev}, z, "", "an/entry", s.root, savingUserData, nil), check.ErrorMatches, ".* context canceled")
+	c.Assert(backend.AddSnapDirToZip(ctx, &client.Snapshot{Revision: rev}, z, "", "an/entry", s.root, savingUserData, nil, nil, nil), check.ErrorMatches, ".* context canceled")
 }
 
 func (s *snapshotSuite) TestAddDirToZip(c *check.C) {
@@ -602,7 +602,7 @@ func (s *snapshotSuite) TestAddDirToZip(c *check.C) {
 		Revision: rev,
 	}
 	savingUserData := false
-	c.Assert(backend.AddSnapDirToZip(context.Background(), snapshot, z, "", "an/entry", s.root, savingUserData, nil), check.IsNil)
+	c.Assert(backend.AddSnapDirToZip(context.Background(), snapshot, z, "", "an/entry", s.root, savingUserData, nil, nil, nil), check.IsNil)
 	z.Close() // write out the central directory
 
 	c.Check(snapshot.SHA3_384, check.HasLen, 1)
@@ -680,12 +680,198 @@ func (s *snapshotSuite) TestAddDirToZipExclusions(c *check.C) {
 	} {
 		testLabel := check.Commentf("%s/%v", testData.excludes, testData.savingUserData)
 
-		err := backend.AddSnapDirToZip(context.Background(), snapshot, z, "", "an/entry", s.root, testData.savingUserData, testData.excludes)
+		err := backend.AddSnapDirToZip(context.Background(), snapshot, z, "", "an/entry", s.root, testData.savingUserData, nil, testData.excludes, nil)
 		c.Check(err, check.ErrorMatches, "tar failed.*")
 		c.Check(tarArgs, check.DeepEquals, testData.expectedArgs, testLabel)
 	}
 }
 
+func (s *snapshotSuite) TestAddDirToZipInclusions(c *check.C) {
+	rev := snap.R(5)
+	d := filepath.Join(s.root, rev.String())
+	c.Assert(os.MkdirAll(filepath.Join(d, ".config", "app"), 0755), check.IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(s.root, "common"), 0755), check.IsNil)
+	for _, fn := range []string{"a.conf", "b.conf", "app/c.conf"} {
+		c.Assert(os.WriteFile(filepath.Join(d, ".config", fn), nil, 0644), check.IsNil)
+	}
+
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	defer z.Close()
+	snapshot := &client.Snapshot{
+		SHA3_384: map[string]string{},
+		Revision: rev,
+	}
+
+	var members []string
+	restore := backend.MockTarAsUser(func(username string, args ...string) *exec.Cmd {
+		// the members follow the directory
+		for i, arg := range args {
+			if arg == "--directory" {
+				members = args[i+2:]
+			}
+		}
+		return exec.Command("false")
+	})
+	defer restore()
+
+	for _, testData := range []struct {
+		includes        []string
+		savingUserData  bool
+		expectedMembers []string
+	}{
+		{[]string{"$SNAP_DATA/.config/*.conf"}, false, []string{"5/.config/a.conf", "5/.config/b.conf"}},
+		{[]string{"$SNAP_DATA/.config/app", "$SNAP_DATA/.config/a.conf"}, false, []string{"5/.config/a.conf", "5/.config/app"}},
+		{[]string{"$SNAP_USER_DATA/.config/*.conf"}, true, []string{"5/.config/a.conf", "5/.config/b.conf"}},
+		{[]string{"$SNAP_DATA", "$SNAP_COMMON"}, false, []string{"5", "common"}},
+	} {
+		testLabel := check.Commentf("%s/%v", testData.includes, testData.savingUserData)
+		members = nil
+		err := backend.AddSnapDirToZip(context.Background(), snapshot, z, "", "an/entry", s.root, testData.savingUserData, testData.includes, nil, nil)
+		c.Check(err, check.ErrorMatches, "tar failed.*", testLabel)
+		c.Check(members, check.DeepEquals, testData.expectedMembers, testLabel)
+	}
+
+	// nothing is saved if nothing is included
+	for _, includes := range [][]string{{"$SNAP_DATA/nope"}, {"$SNAP_USER_DATA/.config"}} {
+		members = nil
+		err := backend.AddSnapDirToZip(context.Background(), snapshot, z, "", "an/entry", s.root, false, includes, nil, nil)
+		c.Check(err, check.IsNil)
+		c.Check(members, check.IsNil)
+	}
+}
+
+func (s *snapshotSuite) TestPathMatches(c *check.C) {
+	for _, t := range []struct {
+		patterns []string
+		path     string
+		matches  bool
+	}{
+		{[]string{"42/.config"}, "42/.config", true},
+		{[]string{"42/.config"}, "42/.config/app.conf", true},
+		{[]string{"42/.config"}, "42", false},
+		{[]string{"42/.config"}, "42/.configs", false},
+		{[]string{"42/*.conf"}, "42/app.conf", true},
+		{[]string{"42/*.conf"}, "42/sub/app.conf", false},
+		{[]string{"*/app.conf"}, "common/app.conf", true},
+		{[]string{"common/x", "42/y"}, "42/y/z", true},
+		{nil, "42", false},
+	} {
+		c.Check(backend.PathMatches(t.patterns, t.path), check.Equals, t.matches, check.Commentf("%q %q", t.patterns, t.path))
+	}
+}
+
+func (s *snapshotSuite) TestRestorePaths(c *check.C) {
+	logger.SimpleSetup(nil)
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	c.Assert(os.MkdirAll(filepath.Join(info.DataDir(), "conf"), 0700), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(info.DataDir(), "conf", "app.conf"), []byte("good\n"), 0644), check.IsNil)
+
+	shw, err := backend.Save(context.TODO(), 12, info, nil, nil, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+
+	// mess everything up
+	c.Assert(os.RemoveAll(filepath.Join(info.DataDir(), "conf")), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(info.DataDir(), "foo"), []byte("scribble\n"), 0644), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(info.DataDir(), "new"), []byte("new\n"), 0644), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(info.CommonDataDir(), "bar"), []byte("scribble\n"), 0644), check.IsNil)
+
+	// only restore the configuration
+	shr.Paths = &snap.SnapshotOptions{Include: []string{"$SNAP_DATA/conf/*.conf"}}
+	rs, err := shr.Restore(context.TODO(), snap.R(0), nil, logger.Debugf, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(filepath.Join(info.DataDir(), "conf", "app.conf"), testutil.FileEquals, "good\n")
+	st, err := os.Stat(filepath.Join(info.DataDir(), "conf"))
+	c.Assert(err, check.IsNil)
+	c.Check(st.Mode().Perm(), check.Equals, os.FileMode(0700))
+	c.Check(filepath.Join(info.DataDir(), "foo"), testutil.FileEquals, "scribble\n")
+	c.Check(filepath.Join(info.DataDir(), "new"), testutil.FileEquals, "new\n")
+	c.Check(filepath.Join(info.CommonDataDir(), "bar"), testutil.FileEquals, "scribble\n")
+
+	// which can be reverted
+	rs.Revert()
+	c.Check(filepath.Join(info.DataDir(), "conf"), testutil.FileAbsent)
+
+	// restore everything but the common data, on top of what is there
+	shr.Paths = &snap.SnapshotOptions{Exclude: []string{"$SNAP_COMMON"}}
+	rs, err = shr.Restore(context.TODO(), snap.R(0), nil, logger.Debugf, nil)
+	c.Assert(err, check.IsNil)
+	rs.Cleanup()
+	c.Check(filepath.Join(info.DataDir(), "conf", "app.conf"), testutil.FileEquals, "good\n")
+	c.Check(filepath.Join(info.DataDir(), "foo"), testutil.FileEquals, "versioned system canary\n")
+	c.Check(filepath.Join(info.DataDir(), "new"), testutil.FileEquals, "new\n")
+	c.Check(filepath.Join(info.CommonDataDir(), "bar"), testutil.FileEquals, "scribble\n")
+}
+
+func (s *snapshotSuite) TestRestorePathsRefusesSymlinks(c *check.C) {
+	logger.SimpleSetup(nil)
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	c.Assert(os.MkdirAll(filepath.Join(info.DataDir(), "conf"), 0700), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(info.DataDir(), "conf", "app.conf"), []byte("good\n"), 0644), check.IsNil)
+
+	shw, err := backend.Save(context.TODO(), 12, info, nil, nil, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+
+	// the directory is replaced by a link to somewhere else
+	elsewhere := c.MkDir()
+	c.Assert(os.WriteFile(filepath.Join(elsewhere, "app.conf"), []byte("precious\n"), 0644), check.IsNil)
+	c.Assert(os.RemoveAll(filepath.Join(info.DataDir(), "conf")), check.IsNil)
+	c.Assert(os.Symlink(elsewhere, filepath.Join(info.DataDir(), "conf")), check.IsNil)
+
+	shr.Paths = &snap.SnapshotOptions{Include: []string{"$SNAP_DATA/conf/*.conf"}}
+	_, err = shr.Restore(context.TODO(), snap.R(0), nil, logger.Debugf, nil)
+	c.Assert(err, check.ErrorMatches, `cannot restore snapshot into ".*/hello-snap/42/conf": not a directory`)
+	c.Check(filepath.Join(elsewhere, "app.conf"), testutil.FileEquals, "precious\n")
+	entries, err := os.ReadDir(elsewhere)
+	c.Assert(err, check.IsNil)
+	c.Check(entries, check.HasLen, 1)
+
+	// the same goes for the data directory itself
+	c.Assert(os.Remove(filepath.Join(info.DataDir(), "conf")), check.IsNil)
+	c.Assert(os.Rename(info.DataDir(), filepath.Join(elsewhere, "42")), check.IsNil)
+	c.Assert(os.Symlink(filepath.Join(elsewhere, "42"), info.DataDir()), check.IsNil)
+	_, err = shr.Restore(context.TODO(), snap.R(0), nil, logger.Debugf, nil)
+	c.Assert(err, check.ErrorMatches, `cannot restore snapshot into ".*/hello-snap/42": not a directory`)
+	c.Check(filepath.Join(elsewhere, "42", "conf"), testutil.FileAbsent)
+}
+
+func (s *snapshotSuite) TestSavePathsRestore(c *check.C) {
+	logger.SimpleSetup(nil)
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	opts := &snap.SnapshotOptions{Include: []string{"$SNAP_COMMON/bar"}}
+	shw, err := backend.Save(context.TODO(), 12, info, nil, nil, opts, nil, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(shw.Options, check.DeepEquals, opts)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Options, check.DeepEquals, opts)
+	c.Check(shr.Check(context.TODO(), nil), check.IsNil)
+
+	c.Assert(os.WriteFile(filepath.Join(info.CommonDataDir(), "bar"), []byte("scribble\n"), 0644), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(info.CommonDataDir(), "baz"), []byte("baz\n"), 0644), check.IsNil)
+
+	// restoring into another revision
+	c.Assert(os.Rename(info.DataDir(), filepath.Join(filepath.Dir(info.DataDir()), "43")), check.IsNil)
+	rs, err := shr.Restore(context.TODO(), snap.R(43), nil, logger.Debugf, nil)
+	c.Assert(err, check.IsNil)
+	rs.Cleanup()
+	// only what the snapshot holds is restored, the rest is left alone
+	c.Check(filepath.Join(info.CommonDataDir(), "bar"), testutil.FileEquals, "common system canary\n")
+	c.Check(filepath.Join(info.CommonDataDir(), "baz"), testutil.FileEquals, "baz\n")
+	c.Check(filepath.Join(filepath.Dir(info.DataDir()), "43", "foo"), testutil.FileEquals, "versioned system canary\n")
+}
+
 func (s *snapshotSuite) TestHappyRoundtrip(c *check.C) {
 	s.testHappyRoundtrip(c, "marker")
 }
@@ -732,7 +918,7 @@ func (s *snapshotSuite) testHappyRoundtrip(c *check.C, marker string) {
 		return statSnapshotOpts, nil
 	})()
 
-	shw, err := backend.Save(context.TODO(), shID, info, cfg, []string{"snapuser"}, dynSnapshotOpts, nil)
+	shw, err := backend.Save(context.TODO(), shID, info, cfg, []string{"snapuser"}, dynSnapshotOpts, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, shID)
 	c.Check(shw.Snap, check.Equals, info.InstanceName())
@@ -810,7 +996,7 @@ func (s *snapshotSuite) TestOpenSetIDoverride(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	cfg := map[string]any{"some-setting": false}
 
-	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, uint64(12))
 
@@ -834,7 +1020,7 @@ func (s *snapshotSuite) TestRestoreRoundtripDifferentRevision(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	shID := uint64(12)
 
-	shw, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.Revision, check.Equals, info.Revision)
 
@@ -1109,7 +1295,7 @@ func (s *snapshotSuite) TestImportDuplicated(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	shID := uint64(12)
 
-	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	export, err := backend.NewSnapshotExport(ctx, shw.SetID)
@@ -1139,7 +1325,7 @@ func (s *snapshotSuite) TestImportExportRoundtrip(c *check.C) {
 	cfg := map[string]any{"some-setting": false}
 	shID := uint64(12)
 
-	shw, err := backend.Save(ctx, shID, info, cfg, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(ctx, shID, info, cfg, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, shID)
 
@@ -1300,7 +1486,7 @@ func (s *snapshotSuite) TestExportTwice(c *check.C) {
 	}
 	// create a snapshot
 	shID := uint64(12)
-	_, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil, nil)
+	_, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	// content.json + num_files + export.json + footer
@@ -1647,7 +1833,7 @@ func (s *snapshotSuite) TestSnapshotExportContentHash(c *check.C) {
 		Version: "v1.33",
 	}
 	shID := uint64(12)
-	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Check(err, check.IsNil)
 
 	// now export it
@@ -1669,7 +1855,7 @@ func (s *snapshotSuite) TestSnapshotExportContentHash(c *check.C) {
 		},
 		Version: "v1.33",
 	}
-	shw, err = backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil)
+	shw, err = backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Check(err, check.IsNil)
 
 	export3, err := backend.NewSnapshotExport(ctx, shw.SetID)
@@ -0,0 +1,426 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// Chunked snapshots store their archives as uncompressed tar streams split
+// into content-defined chunks. The chunks are kept, compressed, in a content
+// addressed store shared by all snapshot sets, and the archive members of the
+// snapshot zip file hold a manifest listing the chunks to concatenate.
+//
+// Chunk boundaries are found with a gear rolling hash, so that data shifted
+// by insertions or removals still ends up in identical chunks.
+
+const chunksDirName = "chunks"
+
+var (
+	minChunkSize = 256 * 1024
+	maxChunkSize = 4 * 1024 * 1024
+	// cut points are where the low bits of the rolling hash are all
+	// zero, for an average chunk size of about 1MiB
+	chunkMask = uint64(1<<20 - 1)
+
+	// chunks not referenced by any snapshot are only removed once they
+	// have not been written or reused for this long, so that chunks of
+	// in-progress saves and imports are left alone
+	unusedChunkGracePeriod = 24 * time.Hour
+
+	// chunkStoreMu serializes the reuse of a chunk with its removal
+	chunkStoreMu sync.RWMutex
+
+	chunkIDRegexp = regexp.MustCompile("^[0-9a-f]{96}$")
+)
+
+var gearTable = func() (table [256]uint64) {
+	// the table must never change, or identical data saved before and
+	// after the change would not be deduplicated anymore
+	for i := range table {
+		sum := sha256.Sum256([]byte{byte(i)})
+		table[i] = binary.LittleEndian.Uint64(sum[:8])
+	}
+	return table
+}()
+
+func chunksDir() string {
+	return filepath.Join(dirs.SnapshotsDir, chunksDirName)
+}
+
+func chunkPath(id string) string {
+	return filepath.Join(chunksDir(), id[:2], id)
+}
+
+func isChunkID(id string) bool {
+	return chunkIDRegexp.MatchString(id)
+}
+
+// chunkManifest is the content of the archive members of chunked snapshots.
+type chunkManifest struct {
+	// Size is the size of the archive
+	Size   int64      `json:"size"`
+	Chunks []chunkRef `json:"chunks"`
+}
+
+type chunkRef struct {
+	// ID is the SHA3-384 of the chunk data, in hex
+	ID   string `json:"id"`
+	Size int64  `json:"size"`
+}
+
+// storeChunk adds the given data to the chunk store, unless it is already
+// there, and returns its ID.
+func storeChunk(data []byte) (string, error) {
+	h := crypto.SHA3_384.New()
+	h.Write(data)
+	id := fmt.Sprintf("%x", h.Sum(nil))
+
+	chunkStoreMu.RLock()
+	defer chunkStoreMu.RUnlock()
+
+	p := chunkPath(id)
+	if osutil.FileExists(p) {
+		// refresh it so that it is not seen as unused
+		now := timeNow()
+		if err := os.Chtimes(p, now, now); err != nil {
+			return "", fmt.Errorf("cannot reuse chunk %s: %v", id, err)
+		}
+		return id, nil
+	}
+	if err := writeChunk(p, bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("cannot store chunk %s: %v", id, err)
+	}
+	return id, nil
+}
+
+// writeChunk writes the given data compressed to the given path.
+func writeChunk(p string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	aw, err := osutil.NewAtomicFile(p, 0600, 0, osutil.NoChown, osutil.NoChown)
+	if err != nil {
+		return err
+	}
+	// Cancel is a NOP once committed
+	defer aw.Cancel()
+	gz := gzip.NewWriter(aw)
+	if _, err := io.Copy(gz, r); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return aw.Commit()
+}
+
+// readChunk returns the data of the given chunk, after checking it matches
+// its ID and expected size.
+func readChunk(ref chunkRef) ([]byte, error) {
+	if !isChunkID(ref.ID) {
+		return nil, fmt.Errorf("invalid chunk id %q", ref.ID)
+	}
+	f, err := os.Open(chunkPath(ref.ID))
+	if err != nil {
+		return nil, fmt.Errorf("cannot open chunk %.7s…: %v", ref.ID, err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read chunk %.7s…: %v", ref.ID, err)
+	}
+	// never read more than the expected size plus one byte, to detect
+	// bigger chunks without risking reading a huge one
+	data, err := io.ReadAll(io.LimitReader(gz, ref.Size+1))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read chunk %.7s…: %v", ref.ID, err)
+	}
+	if int64(len(data)) != ref.Size {
+		return nil, fmt.Errorf("chunk %.7s… expected size (%d) does not match actual (%d)", ref.ID, ref.Size, len(data))
+	}
+	h := crypto.SHA3_384.New()
+	h.Write(data)
+	if actual := fmt.Sprintf("%x", h.Sum(nil)); actual != ref.ID {
+		return nil, fmt.Errorf("chunk %.7s… does not match its content (%.7s…)", ref.ID, actual)
+	}
+	return data, nil
+}
+
+// chunkWriter splits what is written to it into chunks that it stores,
+// building the manifest of the written stream.
+type chunkWriter struct {
+	buf      []byte
+	hash     uint64
+	manifest chunkManifest
+}
+
+func newChunkWriter() *chunkWriter {
+	return &chunkWriter{buf: make([]byte, 0, maxChunkSize)}
+}
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		cut := cw.cutPoint(p)
+		if cut < 0 {
+			cw.buf = append(cw.buf, p...)
+			written += len(p)
+			break
+		}
+		cw.buf = append(cw.buf, p[:cut]...)
+		if err := cw.flush(); err != nil {
+			return written, err
+		}
+		written += cut
+		p = p[cut:]
+	}
+	return written, nil
+}
+
+// cutPoint feeds p into the rolling hash and returns the length of the
+// prefix of p that completes the current chunk, or -1 if all of p belongs
+// to it.
+func (cw *chunkWriter) cutPoint(p []byte) int {
+	size := len(cw.buf)
+	for i, b := range p {
+		cw.hash = (cw.hash << 1) + gearTable[b]
+		size++
+		if size < minChunkSize {
+			continue
+		}
+		if cw.hash&chunkMask == 0 || size >= maxChunkSize {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func (cw *chunkWriter) flush() error {
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	id, err := storeChunk(cw.buf)
+	if err != nil {
+		return err
+	}
+	cw.manifest.Chunks = append(cw.manifest.Chunks, chunkRef{ID: id, Size: int64(len(cw.buf))})
+	cw.manifest.Size += int64(len(cw.buf))
+	cw.buf = cw.buf[:0]
+	cw.hash = 0
+	return nil
+}
+
+// Close stores the last chunk. The manifest is complete afterwards.
+func (cw *chunkWriter) Close() error {
+	return cw.flush()
+}
+
+// chunkedReader reads the stream described by a manifest.
+type chunkedReader struct {
+	chunks []chunkRef
+	cur    []byte
+}
+
+func (cr *chunkedReader) Read(p []byte) (int, error) {
+	for len(cr.cur) == 0 {
+		if len(cr.chunks) == 0 {
+			return 0, io.EOF
+		}
+		data, err := readChunk(cr.chunks[0])
+		if err != nil {
+			return 0, err
+		}
+		cr.chunks = cr.chunks[1:]
+		cr.cur = data
+	}
+	n := copy(p, cr.cur)
+	cr.cur = cr.cur[n:]
+	return n, nil
+}
+
+func (cr *chunkedReader) Close() error {
+	cr.chunks = nil
+	cr.cur = nil
+	return nil
+}
+
+// readManifest decodes the manifest in the given archive member of a
+// chunked snapshot.
+func readManifest(f *os.File, entry string) (*chunkManifest, error) {
+	body, _, err := zipMember(f, entry)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	var manifest chunkManifest
+	if err := json.NewDecoder(body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("cannot decode manifest of snapshot entry %q: %v", entry, err)
+	}
+	return &manifest, nil
+}
+
+// chunkIDs returns the IDs of the chunks the snapshot is made of, in order
+// of first use going through its entries sorted by name.
+func (r *Reader) chunkIDs() ([]string, error) {
+	if !r.Chunked {
+		return nil, nil
+	}
+	entries := make([]string, 0, len(r.SHA3_384))
+	for entry := range r.SHA3_384 {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+	var ids []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		manifest, err := readManifest(r.File, entry)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range manifest.Chunks {
+			if !seen[ref.ID] {
+				seen[ref.ID] = true
+				ids = append(ids, ref.ID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// importChunk adds a compressed chunk read from an import stream to the
+// chunk store, checking its content matches its ID.
+func importChunk(id string, r io.Reader) error {
+	if !isChunkID(id) {
+		return fmt.Errorf("invalid chunk id %q in import stream", id)
+	}
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("cannot read chunk %.7s…: %v", id, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(gz, int64(maxChunkSize)+1))
+	if err != nil {
+		return fmt.Errorf("cannot read chunk %.7s…: %v", id, err)
+	}
+	if len(data) > maxChunkSize {
+		return fmt.Errorf("chunk %.7s… is too big", id)
+	}
+	h := crypto.SHA3_384.New()
+	h.Write(data)
+	if actual := fmt.Sprintf("%x", h.Sum(nil)); actual != id {
+		return fmt.Errorf("chunk %.7s… does not match its content (%.7s…)", id, actual)
+	}
+	_, err = storeChunk(data)
+	return err
+}
+
+// CleanupUnusedChunks removes the chunks no snapshot refers to anymore from
+// the chunk store, and returns how many were removed.
+func CleanupUnusedChunks(ctx context.Context) (removed int, err error) {
+	if !osutil.IsDirectory(chunksDir()) {
+		return 0, nil
+	}
+
+	used := make(map[string]bool)
+	err = Iter(ctx, func(r *Reader) error {
+		if r.Broken != "" {
+			// its chunks could be wrongly removed otherwise
+			return fmt.Errorf("snapshot %q is broken: %s", r.Name(), r.Broken)
+		}
+		ids, err := r.chunkIDs()
+		if err != nil {
+			return fmt.Errorf("cannot read chunks of snapshot %q: %v", r.Name(), err)
+		}
+		for _, id := range ids {
+			used[id] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("cannot cleanup unused chunks: %v", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(chunksDir(), "*", "*"))
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	for _, p := range paths {
+		id := filepath.Base(p)
+		if used[id] || !isChunkID(id) {
+			continue
+		}
+		ok, err := removeUnusedChunk(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			removed++
+		}
+	}
+	if len(errs) > 0 {
+		return removed, newMultiError("cannot cleanup unused chunks", errs)
+	}
+	if removed > 0 {
+		logger.Debugf("Removed %d unused snapshot chunks.", removed)
+	}
+	return removed, nil
+}
+
+// removeUnusedChunk removes the given chunk if it was not used during the
+// grace period.
+func removeUnusedChunk(p string) (removed bool, err error) {
+	chunkStoreMu.Lock()
+	defer chunkStoreMu.Unlock()
+
+	fi, err := os.Stat(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if timeNow().Sub(fi.ModTime()) < unusedChunkGracePeriod {
+		return false, nil
+	}
+	if err := os.Remove(p); err != nil {
+		return false, err
+	}
+	return true, nil
+}
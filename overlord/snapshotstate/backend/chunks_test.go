@@ -0,0 +1,309 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *snapshotSuite) mockChunkedData(c *check.C, info *snap.Info) []byte {
+	s.restore = append(s.restore, backend.MockChunkSizes(4*1024, 64*1024, 1<<14-1))
+
+	data := make([]byte, 1024*1024)
+	rand.New(rand.NewSource(42)).Read(data)
+	c.Assert(os.WriteFile(filepath.Join(info.DataDir(), "blob"), data, 0644), check.IsNil)
+	return data
+}
+
+func chunkFiles(c *check.C) []string {
+	files, err := filepath.Glob(filepath.Join(dirs.SnapshotsDir, "chunks", "*", "*"))
+	c.Assert(err, check.IsNil)
+	return files
+}
+
+func (s *snapshotSuite) TestChunkedSaveCheckRestore(c *check.C) {
+	logger.SimpleSetup(nil)
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	data := s.mockChunkedData(c, info)
+
+	shw, err := backend.Save(context.TODO(), 12, info, nil, nil, nil, nil, &backend.SaveFlags{Chunked: true})
+	c.Assert(err, check.IsNil)
+	c.Check(shw.Chunked, check.Equals, true)
+	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tgz"})
+	// the archive is not compressed, so it is bigger than the data
+	c.Check(shw.Size > int64(len(data)), check.Equals, true)
+	chunks := chunkFiles(c)
+	c.Check(len(chunks) > 1, check.Equals, true)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Chunked, check.Equals, true)
+	c.Check(shr.Check(context.TODO(), nil), check.IsNil)
+
+	c.Assert(os.WriteFile(filepath.Join(info.DataDir(), "blob"), []byte("scribble"), 0644), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(info.DataDir(), "new-file"), []byte("new"), 0644), check.IsNil)
+	rs, err := shr.Restore(context.TODO(), snap.R(0), nil, logger.Debugf, nil)
+	c.Assert(err, check.IsNil)
+	rs.Cleanup()
+	c.Check(filepath.Join(info.DataDir(), "blob"), testutil.FileEquals, data)
+	c.Check(filepath.Join(info.DataDir(), "foo"), testutil.FileEquals, "versioned system canary\n")
+	c.Check(filepath.Join(info.DataDir(), "new-file"), testutil.FileAbsent)
+	c.Check(filepath.Join(info.CommonDataDir(), "bar"), testutil.FileEquals, "common system canary\n")
+}
+
+func (s *snapshotSuite) TestChunkIDsInOrder(c *check.C) {
+	manifests := map[string]string{
+		"user/zed.tgz": `{"chunks": [{"id": "d"}, {"id": "a"}]}`,
+		"archive.tgz":  `{"chunks": [{"id": "b"}, {"id": "a"}, {"id": "b"}]}`,
+		"user/amy.tgz": `{"chunks": [{"id": "c"}, {"id": "b"}]}`,
+	}
+	f, err := os.Create(filepath.Join(c.MkDir(), "12_hello-snap_v1.33_42.zip"))
+	c.Assert(err, check.IsNil)
+	defer f.Close()
+	w := zip.NewWriter(f)
+	hashes := make(map[string]string)
+	for entry, manifest := range manifests {
+		mw, err := w.Create(entry)
+		c.Assert(err, check.IsNil)
+		_, err = mw.Write([]byte(manifest))
+		c.Assert(err, check.IsNil)
+		hashes[entry] = "some-hash"
+	}
+	c.Assert(w.Close(), check.IsNil)
+
+	r := &backend.Reader{
+		Snapshot: client.Snapshot{Chunked: true, SHA3_384: hashes},
+		File:     f,
+	}
+	// the entries are gone through by name, whatever the order of the map
+	for i := 0; i < 10; i++ {
+		ids, err := r.ChunkIDs()
+		c.Assert(err, check.IsNil)
+		c.Check(ids, check.DeepEquals, []string{"b", "a", "c", "d"})
+	}
+}
+
+func (s *snapshotSuite) TestChunkBoundariesIndependentOfWrites(c *check.C) {
+	s.restore = append(s.restore, backend.MockChunkSizes(4*1024, 64*1024, 1<<14-1))
+	c.Assert(os.MkdirAll(dirs.SnapshotsDir, 0700), check.IsNil)
+
+	data := make([]byte, 1024*1024)
+	rand.New(rand.NewSource(42)).Read(data)
+
+	expected, err := backend.ChunkSizes(data, len(data))
+	c.Assert(err, check.IsNil)
+	c.Check(len(expected) > 1, check.Equals, true)
+	var total int64
+	for _, size := range expected {
+		c.Check(size >= 4*1024 || size == expected[len(expected)-1], check.Equals, true)
+		c.Check(size <= 64*1024, check.Equals, true)
+		total += size
+	}
+	c.Check(total, check.Equals, int64(len(data)))
+
+	for _, writeSize := range []int{1, 1000, 4 * 1024, 100 * 1024} {
+		sizes, err := backend.ChunkSizes(data, writeSize)
+		c.Assert(err, check.IsNil)
+		c.Check(sizes, check.DeepEquals, expected, check.Commentf("write size %d", writeSize))
+	}
+}
+
+func (s *snapshotSuite) TestChunkedSaveDeduplicates(c *check.C) {
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	s.mockChunkedData(c, info)
+
+	_, err := backend.Save(context.TODO(), 12, info, nil, nil, nil, nil, &backend.SaveFlags{Chunked: true})
+	c.Assert(err, check.IsNil)
+	n := len(chunkFiles(c))
+
+	// a small change in a big file only adds a few chunks
+	f, err := os.OpenFile(filepath.Join(info.DataDir(), "blob"), os.O_WRONLY, 0644)
+	c.Assert(err, check.IsNil)
+	_, err = f.WriteAt([]byte("some change"), 512*1024)
+	f.Close()
+	c.Assert(err, check.IsNil)
+
+	_, err = backend.Save(context.TODO(), 13, info, nil, nil, nil, nil, &backend.SaveFlags{Chunked: true})
+	c.Assert(err, check.IsNil)
+	added := len(chunkFiles(c)) - n
+	c.Check(added > 0, check.Equals, true)
+	c.Check(added <= 4, check.Equals, true, check.Commentf("%d chunks added out of %d", added, n))
+}
+
+func (s *snapshotSuite) TestChunkedCheckCorruptedChunk(c *check.C) {
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	s.mockChunkedData(c, info)
+
+	shw, err := backend.Save(context.TODO(), 12, info, nil, nil, nil, nil, &backend.SaveFlags{Chunked: true})
+	c.Assert(err, check.IsNil)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("something else"))
+	gz.Close()
+	chunks := chunkFiles(c)
+	c.Assert(os.WriteFile(chunks[0], buf.Bytes(), 0600), check.IsNil)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Check(context.TODO(), nil), check.ErrorMatches, `chunk [0-9a-f]{7}… expected size \([0-9]+\) does not match actual \(14\)`)
+
+	c.Assert(os.Remove(chunks[0]), check.IsNil)
+	c.Check(shr.Check(context.TODO(), nil), check.ErrorMatches, `cannot open chunk [0-9a-f]{7}…: .* no such file or directory`)
+}
+
+func (s *snapshotSuite) TestChunkedExportImportRoundtrip(c *check.C) {
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	s.mockChunkedData(c, info)
+
+	shw, err := backend.Save(ctx, 12, info, nil, nil, nil, nil, &backend.SaveFlags{Chunked: true})
+	c.Assert(err, check.IsNil)
+	n := len(chunkFiles(c))
+
+	export, err := backend.NewSnapshotExport(ctx, shw.SetID)
+	c.Assert(err, check.IsNil)
+	c.Assert(export.Init(), check.IsNil)
+	buf := bytes.NewBuffer(nil)
+	c.Assert(export.StreamTo(buf), check.IsNil)
+	export.Close()
+	c.Check(buf.Len(), check.Equals, int(export.Size()))
+
+	// chunks are exported along with the snapshot
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	var exportedChunks int
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		if filepath.Dir(hdr.Name) == "chunks" {
+			exportedChunks++
+		}
+	}
+	c.Check(exportedChunks, check.Equals, n)
+
+	c.Assert(os.Remove(backend.Filename(shw)), check.IsNil)
+	c.Assert(os.RemoveAll(filepath.Join(dirs.SnapshotsDir, "chunks")), check.IsNil)
+
+	names, err := backend.Import(ctx, 123, buf, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(names, check.DeepEquals, []string{"hello-snap"})
+	c.Check(chunkFiles(c), check.HasLen, n)
+
+	shr, err := backend.Open(filepath.Join(dirs.SnapshotsDir, "123_hello-snap_v1.33_42.zip"), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Chunked, check.Equals, true)
+	c.Check(shr.Check(ctx, nil), check.IsNil)
+}
+
+func (s *snapshotSuite) TestImportMismatchedChunk(c *check.C) {
+	var chunk bytes.Buffer
+	gz := gzip.NewWriter(&chunk)
+	gz.Write([]byte("not what the id says"))
+	gz.Close()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	id := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	c.Assert(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "chunks/" + id, Size: int64(chunk.Len()), Mode: 0600}), check.IsNil)
+	_, err := tw.Write(chunk.Bytes())
+	c.Assert(err, check.IsNil)
+	c.Assert(tw.Close(), check.IsNil)
+
+	_, err = backend.Import(context.TODO(), 123, &buf, nil)
+	c.Check(err, check.ErrorMatches, `cannot import snapshot 123: chunk 0123456… does not match its content \([0-9a-f]{7}…\)`)
+}
+
+func (s *snapshotSuite) TestCleanupUnusedChunks(c *check.C) {
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	s.mockChunkedData(c, info)
+
+	// no chunk store yet
+	removed, err := backend.CleanupUnusedChunks(ctx)
+	c.Assert(err, check.IsNil)
+	c.Check(removed, check.Equals, 0)
+
+	shw1, err := backend.Save(ctx, 1, info, nil, nil, nil, nil, &backend.SaveFlags{Chunked: true})
+	c.Assert(err, check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(info.DataDir(), "blob"), []byte("other data"), 0644), check.IsNil)
+	shw2, err := backend.Save(ctx, 2, info, nil, nil, nil, nil, &backend.SaveFlags{Chunked: true})
+	c.Assert(err, check.IsNil)
+	n := len(chunkFiles(c))
+
+	// forgetting the first set leaves chunks only it used
+	c.Assert(os.Remove(backend.Filename(shw1)), check.IsNil)
+
+	// which are kept while recent
+	removed, err = backend.CleanupUnusedChunks(ctx)
+	c.Assert(err, check.IsNil)
+	c.Check(removed, check.Equals, 0)
+	c.Check(chunkFiles(c), check.HasLen, n)
+
+	restore := backend.MockTimeNow(func() time.Time { return time.Now().Add(25 * time.Hour) })
+	defer restore()
+	removed, err = backend.CleanupUnusedChunks(ctx)
+	c.Assert(err, check.IsNil)
+	c.Check(removed > 0, check.Equals, true)
+	c.Check(chunkFiles(c), check.HasLen, n-removed)
+
+	shr, err := backend.Open(backend.Filename(shw2), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Check(ctx, nil), check.IsNil)
+}
+
+func (s *snapshotSuite) TestCleanupUnusedChunksBrokenSnapshot(c *check.C) {
+	c.Assert(os.MkdirAll(filepath.Join(dirs.SnapshotsDir, "chunks"), 0700), check.IsNil)
+	defer backend.MockOpen(func(fn string, setID uint64) (*backend.Reader, error) {
+		f, err := os.Open(fn)
+		c.Assert(err, check.IsNil)
+		return &backend.Reader{
+			File:     f,
+			Snapshot: client.Snapshot{SetID: setID, Broken: "xyzzy"},
+		}, nil
+	})()
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapshotsDir, "1_hello-snap_v1.33_42.zip"), nil, 0600), check.IsNil)
+
+	_, err := backend.CleanupUnusedChunks(context.TODO())
+	c.Check(err, check.ErrorMatches, `cannot cleanup unused chunks: snapshot ".*/1_hello-snap_v1\.33_42\.zip" is broken: xyzzy`)
+}
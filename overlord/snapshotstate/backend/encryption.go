@@ -0,0 +1,129 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"filippo.io/age"
+)
+
+// Encrypted snapshots have their archives encrypted in the age v1 format
+// (see https://age-encryption.org/v1), so that they can also be decrypted
+// with standard tools once they have left the device. The metadata of the
+// snapshot stays in the clear, and lists the fingerprints of the keys the
+// archives are encrypted to.
+
+const (
+	// the payload is made of a nonce followed by chunks of up to 64KiB
+	// of plaintext, each with a Poly1305 tag
+	agePayloadNonceSize = 16
+	ageChunkSize        = 64 * 1024
+	ageTagSize          = 16
+)
+
+// ErrNoDecryptionKey is returned when none of the available keys can
+// decrypt an encrypted snapshot.
+var ErrNoDecryptionKey = errors.New("no key to decrypt the snapshot is available")
+
+// A Recipient is a key snapshots can be encrypted to.
+type Recipient interface {
+	age.Recipient
+	// Fingerprint identifies the key, without revealing it.
+	Fingerprint() string
+}
+
+// An Identity is a key encrypted snapshots can be decrypted with.
+type Identity interface {
+	age.Identity
+	// Fingerprint is the one of the matching Recipient.
+	Fingerprint() string
+}
+
+// encryptWriter returns a writer that encrypts what is written to it to the
+// given recipients, writing the result to w. It must be closed for the
+// encrypted stream to be complete.
+func encryptWriter(w io.Writer, recipients []Recipient) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("internal error: no recipients to encrypt to")
+	}
+	ageRecipients := make([]age.Recipient, 0, len(recipients))
+	for _, r := range recipients {
+		if _, ok := r.(Passphrase); ok && len(recipients) > 1 {
+			return nil, fmt.Errorf("cannot encrypt with a passphrase along with other keys")
+		}
+		ageRecipients = append(ageRecipients, r)
+	}
+	return age.Encrypt(w, ageRecipients...)
+}
+
+// decryptReader returns a reader of the plaintext of the encrypted stream
+// read from r, using whichever of the given identities the stream is
+// encrypted to. It also returns how many bytes precede the encrypted
+// chunks of the stream.
+func decryptReader(r io.Reader, identities []Identity) (plain io.Reader, preambleSize int64, err error) {
+	if len(identities) == 0 {
+		return nil, 0, ErrNoDecryptionKey
+	}
+	// the header is read first to know its size, which the size of the
+	// plaintext is computed from
+	br := bufio.NewReader(r)
+	var header bytes.Buffer
+	for {
+		line, err := br.ReadSlice('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, 0, fmt.Errorf("cannot read header: %v", err)
+		}
+		header.Write(line)
+		if bytes.HasPrefix(line, []byte("---")) {
+			break
+		}
+	}
+	headerSize := header.Len()
+
+	ageIdentities := make([]age.Identity, 0, len(identities))
+	for _, id := range identities {
+		ageIdentities = append(ageIdentities, id)
+	}
+	plain, err = age.Decrypt(io.MultiReader(&header, br), ageIdentities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, 0, ErrNoDecryptionKey
+		}
+		return nil, 0, err
+	}
+	return plain, int64(headerSize + agePayloadNonceSize), nil
+}
+
+// plaintextSize returns the size of the plaintext of the given number of
+// bytes of encrypted chunks.
+func plaintextSize(encryptedSize int64) int64 {
+	const encChunkSize = ageChunkSize + ageTagSize
+	chunks := (encryptedSize + encChunkSize - 1) / encChunkSize
+	return encryptedSize - chunks*ageTagSize
+}
@@ -0,0 +1,333 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func encrypt(c *check.C, data []byte, recipients ...backend.Recipient) []byte {
+	var buf bytes.Buffer
+	w, err := backend.EncryptWriter(&buf, recipients)
+	c.Assert(err, check.IsNil)
+	_, err = w.Write(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(w.Close(), check.IsNil)
+	return buf.Bytes()
+}
+
+func decrypt(encrypted []byte, identities ...backend.Identity) ([]byte, error) {
+	r, _, err := backend.DecryptReader(bytes.NewReader(encrypted), identities)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func (s *snapshotSuite) TestEncryptDecryptRoundtrip(c *check.C) {
+	defer backend.MockScryptWorkFactor(10)()
+
+	id, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+	otherID, otherRecipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+
+	for _, size := range []int{0, 1, 64 * 1024, 64*1024 + 1, 200 * 1024} {
+		data := make([]byte, size)
+		rand.New(rand.NewSource(int64(size))).Read(data)
+
+		encrypted := encrypt(c, data, recipient, otherRecipient)
+		for _, i := range []backend.Identity{id, otherID} {
+			plain, err := decrypt(encrypted, i)
+			c.Assert(err, check.IsNil, check.Commentf("size %d", size))
+			c.Check(plain, check.DeepEquals, data, check.Commentf("size %d", size))
+		}
+
+		encrypted = encrypt(c, data, backend.Passphrase("correct horse"))
+		plain, err := decrypt(encrypted, backend.Passphrase("correct horse"))
+		c.Assert(err, check.IsNil, check.Commentf("size %d", size))
+		c.Check(plain, check.DeepEquals, data, check.Commentf("size %d", size))
+	}
+}
+
+func (s *snapshotSuite) TestDecryptWithoutKey(c *check.C) {
+	defer backend.MockScryptWorkFactor(10)()
+
+	_, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+	otherID, _, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+
+	encrypted := encrypt(c, []byte("secret"), recipient)
+	_, err = decrypt(encrypted, otherID, backend.Passphrase("secret"))
+	c.Check(err, check.Equals, backend.ErrNoDecryptionKey)
+
+	encrypted = encrypt(c, []byte("secret"), backend.Passphrase("right"))
+	_, err = decrypt(encrypted, backend.Passphrase("wrong"))
+	c.Check(err, check.Equals, backend.ErrNoDecryptionKey)
+}
+
+func (s *snapshotSuite) TestEncryptPassphraseAlone(c *check.C) {
+	_, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+	_, err = backend.EncryptWriter(io.Discard, []backend.Recipient{recipient, backend.Passphrase("pass")})
+	c.Check(err, check.ErrorMatches, "cannot encrypt with a passphrase along with other keys")
+}
+
+func (s *snapshotSuite) TestDecryptTampered(c *check.C) {
+	id, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+	data := make([]byte, 100*1024)
+	encrypted := encrypt(c, data, recipient)
+
+	// truncated after the first chunk
+	_, err = decrypt(encrypted[:len(encrypted)-(100-64)*1024-16], id)
+	c.Check(err, check.ErrorMatches, "unexpected EOF")
+
+	// truncated in the middle of a chunk
+	_, err = decrypt(encrypted[:len(encrypted)-10], id)
+	c.Check(err, check.ErrorMatches, "failed to decrypt and authenticate payload chunk")
+
+	// modified payload
+	modified := append([]byte(nil), encrypted...)
+	modified[len(modified)-100] ^= 1
+	_, err = decrypt(modified, id)
+	c.Check(err, check.ErrorMatches, "failed to decrypt and authenticate payload chunk")
+
+	// trailing data
+	_, err = decrypt(append(append([]byte(nil), encrypted...), 0), id)
+	c.Check(err, check.ErrorMatches, "failed to decrypt and authenticate payload chunk")
+
+	// modified header
+	modified = bytes.Replace(encrypted, []byte("-> X25519 "), []byte("-> X25519 extra "), 1)
+	_, err = decrypt(modified, id)
+	c.Check(err, check.ErrorMatches, "invalid X25519 recipient block")
+
+	_, err = decrypt([]byte("age-encryption.org/v2\n---\n"), id)
+	c.Check(err, check.ErrorMatches, `failed to read header: .*unexpected intro.*`)
+	_, err = decrypt([]byte("age-encryption.org/v1\n-> X25519"), id)
+	c.Check(err, check.ErrorMatches, "cannot read header: unexpected EOF")
+}
+
+func (s *snapshotSuite) TestDecryptHeaderMACMismatch(c *check.C) {
+	defer backend.MockScryptWorkFactor(10)()
+
+	id, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+	_, otherRecipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+
+	// dropping a recipient keeps the file key usable, but not the mac
+	encrypted := encrypt(c, []byte("data"), recipient, otherRecipient)
+	lines := strings.SplitN(string(encrypted), "\n", 6)
+	stripped := strings.Join(append(lines[:3], lines[5]), "\n")
+	_, err = decrypt([]byte(stripped), id)
+	c.Check(err, check.ErrorMatches, "bad header MAC")
+}
+
+// exampleIdentity and exampleEncrypted are the example key and file of the
+// upstream age Go implementation, encrypted with it.
+const exampleIdentity = "AGE-SECRET-KEY-184JMZMVQH3E6U0PSL869004Y3U2NYV7R30EU99CSEDNPH02YUVFSZW44VU"
+
+var exampleEncrypted = "age-encryption.org/v1\n" +
+	"-> X25519 8hrlM+ZBG3Dd4fF2+a583zdTIWDk8/R41kCYZsvwTW4\n" +
+	"yO4PYdlMWDJ+CxgUNRqY5Z0T/m+g3FCh5jIxGLbCVXc\n" +
+	"--- I/imevZzy8120JSzmJnmn/KMk3p5A11V83Nk41m9NPE\n" +
+	"p\xc5\xe56$\xa1R\x07S\xf9,Z\xd1\x0e\xca\xb2s\xbaMa\x17\x80w\x13" +
+	"\xe88 Az\x1d\xf2\xca\x08\x18\"r\xc8\xf8\\\x85w4\xa11\x1a;u\xe9\x8d\x0e\xaf"
+
+func (s *snapshotSuite) TestDecryptUpstreamExample(c *check.C) {
+	ids, err := backend.ParseIdentities([]byte(exampleIdentity))
+	c.Assert(err, check.IsNil)
+
+	r, preambleSize, err := backend.DecryptReader(strings.NewReader(exampleEncrypted), ids)
+	c.Assert(err, check.IsNil)
+	plain, err := io.ReadAll(r)
+	c.Assert(err, check.IsNil)
+	c.Check(string(plain), check.Equals, "Black lives matter.")
+	c.Check(backend.PlaintextSize(int64(len(exampleEncrypted))-preambleSize), check.Equals, int64(len(plain)))
+}
+
+func (s *snapshotSuite) TestParseRecipientAndIdentities(c *check.C) {
+	id, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+
+	r, err := backend.ParseRecipient(fmt.Sprint(recipient))
+	c.Assert(err, check.IsNil)
+	c.Check(r.Fingerprint(), check.Equals, recipient.Fingerprint())
+	c.Check(r.Fingerprint(), check.Matches, "x25519:[0-9a-f]{16}")
+	c.Check(strings.HasPrefix(fmt.Sprint(recipient), "age1"), check.Equals, true)
+
+	ids, err := backend.ParseIdentities([]byte(fmt.Sprintf("# a comment\n\n%s\n", id)))
+	c.Assert(err, check.IsNil)
+	c.Assert(ids, check.HasLen, 1)
+	c.Check(ids[0].Fingerprint(), check.Equals, recipient.Fingerprint())
+	c.Check(strings.HasPrefix(fmt.Sprint(id), "AGE-SECRET-KEY-1"), check.Equals, true)
+
+	_, err = backend.ParseRecipient(fmt.Sprint(id))
+	c.Check(err, check.ErrorMatches, `cannot parse recipient: got an identity instead`)
+	_, err = backend.ParseRecipient("age1foo")
+	c.Check(err, check.ErrorMatches, `cannot parse recipient: malformed recipient "age1foo": .*`)
+	_, err = backend.ParseIdentities([]byte(fmt.Sprint(recipient)))
+	c.Check(err, check.ErrorMatches, "cannot parse identities: error at line 1: malformed secret key: .*")
+	_, err = backend.ParseIdentities([]byte("# nothing\n"))
+	c.Check(err, check.ErrorMatches, "cannot parse identities: no secret keys found")
+}
+
+func (s *snapshotSuite) writeLocalIdentity(c *check.C, id backend.Identity) {
+	keysDir := filepath.Join(dirs.SnapshotsDir, "keys")
+	c.Assert(os.MkdirAll(keysDir, 0700), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(keysDir, "device.key"), []byte(fmt.Sprintln(id)), 0600), check.IsNil)
+}
+
+func (s *snapshotSuite) TestEncryptedSaveCheckRestore(c *check.C) {
+	logger.SimpleSetup(nil)
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	id, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+
+	shw, err := backend.Save(context.TODO(), 12, info, nil, nil, nil, nil, &backend.SaveFlags{Recipients: []backend.Recipient{recipient}})
+	c.Assert(err, check.IsNil)
+	c.Check(shw.KeyFingerprints, check.DeepEquals, []string{recipient.Fingerprint()})
+	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tgz"})
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.KeyFingerprints, check.DeepEquals, shw.KeyFingerprints)
+
+	// nothing to decrypt it with
+	err = shr.Check(context.TODO(), nil)
+	c.Check(errors.Is(err, backend.ErrNoDecryptionKey), check.Equals, true)
+	_, err = shr.Restore(context.TODO(), snap.R(0), nil, logger.Debugf, nil)
+	c.Check(errors.Is(err, backend.ErrNoDecryptionKey), check.Equals, true)
+
+	shr.Identities = []backend.Identity{id}
+	c.Check(shr.Check(context.TODO(), nil), check.IsNil)
+
+	c.Assert(os.WriteFile(filepath.Join(info.DataDir(), "foo"), []byte("scribble"), 0644), check.IsNil)
+	rs, err := shr.Restore(context.TODO(), snap.R(0), nil, logger.Debugf, nil)
+	c.Assert(err, check.IsNil)
+	rs.Cleanup()
+	c.Check(filepath.Join(info.DataDir(), "foo"), testutil.FileEquals, "versioned system canary\n")
+	c.Check(filepath.Join(info.CommonDataDir(), "bar"), testutil.FileEquals, "common system canary\n")
+}
+
+func (s *snapshotSuite) TestEncryptedCheckWithLocalIdentity(c *check.C) {
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	id, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+
+	shw, err := backend.Save(context.TODO(), 12, info, nil, nil, nil, nil, &backend.SaveFlags{Recipients: []backend.Recipient{recipient}})
+	c.Assert(err, check.IsNil)
+
+	ok, err := backend.CanDecrypt(shw.KeyFingerprints, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(ok, check.Equals, false)
+
+	s.writeLocalIdentity(c, id)
+	ok, err = backend.CanDecrypt(shw.KeyFingerprints, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(ok, check.Equals, true)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Check(context.TODO(), nil), check.IsNil)
+}
+
+func (s *snapshotSuite) TestEncryptedSavePassphrase(c *check.C) {
+	defer backend.MockScryptWorkFactor(10)()
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	shw, err := backend.Save(context.TODO(), 12, info, nil, nil, nil, nil, &backend.SaveFlags{Recipients: []backend.Recipient{backend.Passphrase("pass")}})
+	c.Assert(err, check.IsNil)
+	c.Check(shw.KeyFingerprints, check.DeepEquals, []string{backend.PassphraseFingerprint})
+
+	ok, err := backend.CanDecrypt(shw.KeyFingerprints, []backend.Identity{backend.Passphrase("whatever")})
+	c.Assert(err, check.IsNil)
+	c.Check(ok, check.Equals, true)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	shr.Identities = []backend.Identity{backend.Passphrase("wrong")}
+	c.Check(errors.Is(shr.Check(context.TODO(), nil), backend.ErrNoDecryptionKey), check.Equals, true)
+	shr.Identities = []backend.Identity{backend.Passphrase("pass")}
+	c.Check(shr.Check(context.TODO(), nil), check.IsNil)
+}
+
+func (s *snapshotSuite) TestEncryptedSaveNotChunked(c *check.C) {
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	_, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+
+	_, err = backend.Save(context.TODO(), 12, info, nil, nil, nil, nil, &backend.SaveFlags{Chunked: true, Recipients: []backend.Recipient{recipient}})
+	c.Check(err, check.ErrorMatches, "cannot save encrypted snapshots in chunks")
+}
+
+func (s *snapshotSuite) TestEncryptedExportImportWithoutKey(c *check.C) {
+	ctx := context.TODO()
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	id, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+
+	shw, err := backend.Save(ctx, 12, info, nil, nil, nil, nil, &backend.SaveFlags{Recipients: []backend.Recipient{recipient}})
+	c.Assert(err, check.IsNil)
+
+	export, err := backend.NewSnapshotExport(ctx, shw.SetID)
+	c.Assert(err, check.IsNil)
+	c.Assert(export.Init(), check.IsNil)
+	buf := bytes.NewBuffer(nil)
+	c.Assert(export.StreamTo(buf), check.IsNil)
+	export.Close()
+	// the data does not leave in the clear
+	c.Check(bytes.Contains(buf.Bytes(), []byte("age-encryption.org/v1")), check.Equals, true)
+	c.Check(bytes.Contains(buf.Bytes(), []byte("canary")), check.Equals, false)
+	c.Assert(os.Remove(backend.Filename(shw)), check.IsNil)
+
+	// the import cannot check the content, but still goes through
+	names, err := backend.Import(ctx, 123, buf, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(names, check.DeepEquals, []string{"hello-snap"})
+
+	shr, err := backend.Open(filepath.Join(dirs.SnapshotsDir, "123_hello-snap_v1.33_42.zip"), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	shr.Identities = []backend.Identity{id}
+	c.Check(shr.Check(ctx, nil), check.IsNil)
+}
@@ -41,6 +41,8 @@ var (
 	NewMultiError = newMultiError
 
 	AddSnapDirToZip = addSnapDirToZip
+
+	PathMatches = pathMatches
 )
 
 func MockIsTesting(newIsTesting bool) func() {
@@ -155,3 +157,51 @@ func MockReadSnapshotYaml(f func(si *snap.Info) (*snap.SnapshotOptions, error))
 		snapReadSnapshotYaml = oldReadSnapshotYaml
 	}
 }
+
+func MockChunkSizes(min, max int, mask uint64) (restore func()) {
+	oldMin, oldMax, oldMask := minChunkSize, maxChunkSize, chunkMask
+	minChunkSize, maxChunkSize, chunkMask = min, max, mask
+	return func() {
+		minChunkSize, maxChunkSize, chunkMask = oldMin, oldMax, oldMask
+	}
+}
+
+// ChunkSizes returns the sizes of the chunks the given data is split into,
+// when written in pieces of the given size.
+func ChunkSizes(data []byte, writeSize int) ([]int64, error) {
+	cw := newChunkWriter()
+	for len(data) > 0 {
+		n := writeSize
+		if n > len(data) {
+			n = len(data)
+		}
+		if _, err := cw.Write(data[:n]); err != nil {
+			return nil, err
+		}
+		data = data[n:]
+	}
+	if err := cw.Close(); err != nil {
+		return nil, err
+	}
+	sizes := make([]int64, len(cw.manifest.Chunks))
+	for i, ref := range cw.manifest.Chunks {
+		sizes[i] = ref.Size
+	}
+	return sizes, nil
+}
+
+func (r *Reader) ChunkIDs() ([]string, error) {
+	return r.chunkIDs()
+}
+
+var (
+	EncryptWriter = encryptWriter
+	DecryptReader = decryptReader
+	PlaintextSize = plaintextSize
+)
+
+func MockScryptWorkFactor(n int) (restore func()) {
+	r := testutil.Backup(&scryptWorkFactor)
+	scryptWorkFactor = n
+	return r
+}
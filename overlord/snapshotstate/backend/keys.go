@@ -0,0 +1,191 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend
+
+import (
+	"bytes"
+	"crypto"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+)
+
+const (
+	keysDirName = "keys"
+
+	// PassphraseFingerprint is the fingerprint recorded for snapshots
+	// encrypted with a passphrase.
+	PassphraseFingerprint = "passphrase"
+)
+
+var (
+	// scryptWorkFactor is the log2 of the scrypt cost used when
+	// encrypting with a passphrase
+	scryptWorkFactor = 18
+	// maxScryptWorkFactor is the highest cost accepted when decrypting
+	maxScryptWorkFactor = 22
+)
+
+// keysDir is where the identities used to decrypt snapshots without asking
+// for them are kept.
+func keysDir() string {
+	return filepath.Join(dirs.SnapshotsDir, keysDirName)
+}
+
+func x25519Fingerprint(r *age.X25519Recipient) string {
+	h := crypto.SHA3_384.New()
+	h.Write([]byte(r.String()))
+	return fmt.Sprintf("x25519:%.8x", h.Sum(nil))
+}
+
+type x25519Recipient struct {
+	*age.X25519Recipient
+}
+
+// ParseRecipient parses an age X25519 recipient, of the form "age1…".
+func ParseRecipient(s string) (Recipient, error) {
+	if strings.HasPrefix(strings.ToUpper(s), "AGE-SECRET-KEY-") {
+		// do not include it in the error, it is a secret key
+		return nil, fmt.Errorf("cannot parse recipient: got an identity instead")
+	}
+	r, err := age.ParseX25519Recipient(s)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse recipient: %v", err)
+	}
+	return &x25519Recipient{r}, nil
+}
+
+func (r *x25519Recipient) Fingerprint() string {
+	return x25519Fingerprint(r.X25519Recipient)
+}
+
+type x25519Identity struct {
+	*age.X25519Identity
+}
+
+// GenerateIdentity returns a new random X25519 identity, and its recipient.
+func GenerateIdentity() (identity Identity, recipient Recipient, err error) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &x25519Identity{id}, &x25519Recipient{id.Recipient()}, nil
+}
+
+func (id *x25519Identity) Fingerprint() string {
+	return x25519Fingerprint(id.Recipient())
+}
+
+// ParseIdentities parses age X25519 identities, of the form
+// "AGE-SECRET-KEY-1…", one per line. Empty lines and lines starting with #
+// are ignored.
+func ParseIdentities(data []byte) ([]Identity, error) {
+	ageIDs, err := age.ParseIdentities(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse identities: %v", err)
+	}
+	ids := make([]Identity, 0, len(ageIDs))
+	for _, ageID := range ageIDs {
+		id, ok := ageID.(*age.X25519Identity)
+		if !ok {
+			return nil, fmt.Errorf("cannot parse identities: unsupported identity type %T", ageID)
+		}
+		ids = append(ids, &x25519Identity{id})
+	}
+	return ids, nil
+}
+
+// Passphrase is both a Recipient and an Identity, that encrypts the
+// file key with a key derived from the passphrase with scrypt.
+type Passphrase string
+
+func (p Passphrase) Fingerprint() string {
+	return PassphraseFingerprint
+}
+
+func (p Passphrase) Wrap(fileKey []byte) ([]*age.Stanza, error) {
+	r, err := age.NewScryptRecipient(string(p))
+	if err != nil {
+		return nil, err
+	}
+	r.SetWorkFactor(scryptWorkFactor)
+	return r.Wrap(fileKey)
+}
+
+func (p Passphrase) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	id, err := age.NewScryptIdentity(string(p))
+	if err != nil {
+		return nil, err
+	}
+	id.SetMaxWorkFactor(maxScryptWorkFactor)
+	return id.Unwrap(stanzas)
+}
+
+// localIdentities returns the identities found in the keys directory.
+// Files that cannot be parsed are skipped.
+func localIdentities() ([]Identity, error) {
+	paths, err := filepath.Glob(filepath.Join(keysDir(), "*"))
+	if err != nil {
+		return nil, err
+	}
+	var ids []Identity
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			logger.Noticef("Cannot read snapshot key %q: %v.", p, err)
+			continue
+		}
+		fileIDs, err := ParseIdentities(data)
+		if err != nil {
+			logger.Noticef("Cannot use snapshot key %q: %v.", p, err)
+			continue
+		}
+		ids = append(ids, fileIDs...)
+	}
+	return ids, nil
+}
+
+// CanDecrypt returns whether a snapshot encrypted to the keys with the given
+// fingerprints can be decrypted with either the given identities or those
+// kept on the device. Whether a passphrase is the right one is only known
+// when decrypting.
+func CanDecrypt(fingerprints []string, identities []Identity) (bool, error) {
+	if len(fingerprints) == 0 {
+		return true, nil
+	}
+	local, err := localIdentities()
+	if err != nil {
+		return false, err
+	}
+	for _, id := range append(append([]Identity(nil), identities...), local...) {
+		for _, fp := range fingerprints {
+			if id.Fingerprint() == fp {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,160 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// noFollowDir is a directory open for operating on its entries without
+// following symbolic links. Snapshots are restored by root into directories
+// the user can modify while the restore runs, so a directory replaced by a
+// symbolic link must not redirect the restore to somewhere else.
+type noFollowDir struct {
+	fd   int
+	path string
+}
+
+const noFollowDirFlags = unix.O_RDONLY | unix.O_DIRECTORY | unix.O_NOFOLLOW | unix.O_CLOEXEC
+
+func noFollowErr(op, path string, err error) error {
+	if errors.Is(err, unix.ELOOP) || errors.Is(err, unix.ENOTDIR) {
+		return fmt.Errorf("cannot restore snapshot into %q: not a directory", path)
+	}
+	return &os.PathError{Op: op, Path: path, Err: err}
+}
+
+// openNoFollowDir opens the directory at path, which must not be a symbolic
+// link.
+func openNoFollowDir(path string) (*noFollowDir, error) {
+	path = filepath.Clean(path)
+	fd, err := unix.Open(path, noFollowDirFlags, 0)
+	if err != nil {
+		return nil, noFollowErr("open", path, err)
+	}
+	return &noFollowDir{fd: fd, path: path}, nil
+}
+
+func (d *noFollowDir) Close() error {
+	return unix.Close(d.fd)
+}
+
+// open opens the directory dir, relative to d, one component at a time.
+func (d *noFollowDir) open(dir string) (*noFollowDir, error) {
+	return d.mkdirAllLike(nil, dir, nil)
+}
+
+// mkdirAllLike opens the directory dir, relative to d, one component at a
+// time. If like is set, missing directories are created with the mode and
+// ownership of the ones under like, and the topmost directory created is
+// registered in the RestoreState.
+func (d *noFollowDir) mkdirAllLike(rs *RestoreState, dir string, like *noFollowDir) (*noFollowDir, error) {
+	fd, err := unix.Dup(d.fd)
+	if err != nil {
+		return nil, noFollowErr("dup", d.path, err)
+	}
+	cur := &noFollowDir{fd: fd, path: d.path}
+	var likeCur *noFollowDir
+	if like != nil {
+		if likeCur, err = like.open("."); err != nil {
+			cur.Close()
+			return nil, err
+		}
+		defer func() { likeCur.Close() }()
+	}
+
+	created := false
+	dir = filepath.Clean(dir)
+	if dir == "." {
+		return cur, nil
+	}
+	for _, name := range strings.Split(dir, "/") {
+		path := filepath.Join(cur.path, name)
+		fd, err := unix.Openat(cur.fd, name, noFollowDirFlags, 0)
+		if errors.Is(err, unix.ENOENT) && likeCur != nil {
+			err = mkdirLike(cur, likeCur, name)
+			if err == nil {
+				if !created {
+					rs.Created = append(rs.Created, path)
+					created = true
+				}
+				fd, err = unix.Openat(cur.fd, name, noFollowDirFlags, 0)
+			}
+		}
+		cur.Close()
+		if err != nil {
+			return nil, noFollowErr("open", path, err)
+		}
+		cur = &noFollowDir{fd: fd, path: path}
+
+		if likeCur != nil {
+			next, err := likeCur.open(name)
+			if err != nil {
+				cur.Close()
+				return nil, err
+			}
+			likeCur.Close()
+			likeCur = next
+		}
+	}
+	return cur, nil
+}
+
+// mkdirLike creates the directory name in d with the mode and ownership of
+// the one in like.
+func mkdirLike(d, like *noFollowDir, name string) error {
+	var st unix.Stat_t
+	if err := unix.Fstatat(like.fd, name, &st, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return err
+	}
+	if err := unix.Mkdirat(d.fd, name, st.Mode&0777); err != nil {
+		return err
+	}
+	return unix.Fchownat(d.fd, name, int(st.Uid), int(st.Gid), unix.AT_SYMLINK_NOFOLLOW)
+}
+
+// exists returns whether the entry name exists in d, and whether it is a
+// directory rather than, for example, a symbolic link to one.
+func (d *noFollowDir) exists(name string) (exists, isDir bool, err error) {
+	var st unix.Stat_t
+	err = unix.Fstatat(d.fd, name, &st, unix.AT_SYMLINK_NOFOLLOW)
+	if errors.Is(err, unix.ENOENT) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, &os.PathError{Op: "lstat", Path: filepath.Join(d.path, name), Err: err}
+	}
+	return true, st.Mode&unix.S_IFMT == unix.S_IFDIR, nil
+}
+
+// rename renames the entry oldName in d to newName in dst. Symbolic links are
+// renamed themselves, never followed.
+func (d *noFollowDir) rename(oldName string, dst *noFollowDir, newName string) error {
+	if err := unix.Renameat(d.fd, oldName, dst.fd, newName); err != nil {
+		return &os.LinkError{Op: "rename", Old: filepath.Join(d.path, oldName), New: filepath.Join(dst.path, newName), Err: err}
+	}
+	return nil
+}
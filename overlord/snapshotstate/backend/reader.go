@@ -27,6 +27,7 @@ import (
 	"fmt"
 	"hash"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
@@ -50,6 +51,14 @@ const ExtractFnameSetID = 0
 type Reader struct {
 	*os.File
 	client.Snapshot
+
+	// Identities are tried to decrypt encrypted snapshots, along with
+	// the ones kept on the device.
+	Identities []Identity
+
+	// Paths, if set, limits a restore to the paths it includes and does
+	// not exclude.
+	Paths *snap.SnapshotOptions
 }
 
 // Open a Snapshot given its full filename.
@@ -142,8 +151,48 @@ func Open(fn string, setID uint64) (reader *Reader, e error) {
 	return reader, nil
 }
 
+// openEntry returns the archive stored in the given entry of the snapshot,
+// and its size.
+func (r *Reader) openEntry(entry string) (io.ReadCloser, int64, error) {
+	if len(r.KeyFingerprints) > 0 {
+		return r.openEncryptedEntry(entry)
+	}
+	if !r.Chunked {
+		return zipMember(r.File, entry)
+	}
+	manifest, err := readManifest(r.File, entry)
+	if err != nil {
+		return nil, -1, err
+	}
+	return &chunkedReader{chunks: manifest.Chunks}, manifest.Size, nil
+}
+
+type decryptedEntry struct {
+	io.Reader
+	io.Closer
+}
+
+func (r *Reader) openEncryptedEntry(entry string) (io.ReadCloser, int64, error) {
+	local, err := localIdentities()
+	if err != nil {
+		return nil, -1, err
+	}
+	identities := append(append([]Identity(nil), r.Identities...), local...)
+
+	body, size, err := zipMember(r.File, entry)
+	if err != nil {
+		return nil, -1, err
+	}
+	plain, preambleSize, err := decryptReader(body, identities)
+	if err != nil {
+		body.Close()
+		return nil, -1, fmt.Errorf("cannot decrypt snapshot entry %q: %w", entry, err)
+	}
+	return &decryptedEntry{Reader: plain, Closer: body}, plaintextSize(size - preambleSize), nil
+}
+
 func (r *Reader) checkOne(ctx context.Context, entry string, hasher hash.Hash) error {
-	body, reportedSize, err := zipMember(r.File, entry)
+	body, reportedSize, err := r.openEntry(entry)
 	if err != nil {
 		return err
 	}
@@ -196,6 +245,10 @@ type Logf func(format string, args ...any)
 // If successful this will replace the existing data (for the given revision,
 // or the one in the snapshot) with that contained in the snapshot. It keeps
 // track of the old data in the task so it can be undone (or cleaned up).
+//
+// If the restore is limited to some paths, or the snapshot only holds some
+// paths, the files in the snapshot are restored one by one on top of the
+// existing data instead, which is otherwise left alone.
 func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames []string, logf Logf, opts *dirs.SnapDirOptions) (rs *RestoreState, e error) {
 	rs = &RestoreState{}
 	defer func() {
@@ -217,6 +270,17 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 		curdir = current.String()
 	}
 
+	var include, exclude []string
+	if r.Paths != nil {
+		include, exclude = r.Paths.Include, r.Paths.Exclude
+	}
+	if len(include) == 0 && r.Options != nil {
+		// a snapshot of some paths only restores those, and
+		// leaves the rest of the data alone
+		include = r.Options.Include
+	}
+	partial := len(include) > 0 || len(exclude) > 0
+
 	for entry := range r.SHA3_384 {
 		if err := ctx.Err(); err != nil {
 			return rs, err
@@ -310,10 +374,11 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 
 		logger.Debugf("Restoring %q from %q into %q.", entry, r.Name(), tempdir)
 
-		body, expectedSize, err := zipMember(r.File, entry)
+		body, expectedSize, err := r.openEntry(entry)
 		if err != nil {
 			return rs, err
 		}
+		defer body.Close()
 
 		expectedHash := r.SHA3_384[entry]
 
@@ -322,10 +387,15 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 		// resist the temptation of using archive/tar unless it's proven
 		// that calling out to tar has issues -- there are a lot of
 		// special cases we'd need to consider otherwise
-		cmd := tarAsUser(username,
+		tarArgs := []string{
 			"--extract",
-			"--preserve-permissions", "--preserve-order", "--gunzip",
-			"--directory", tempdir)
+			"--preserve-permissions", "--preserve-order",
+		}
+		if !r.Chunked {
+			tarArgs = append(tarArgs, "--gunzip")
+		}
+		tarArgs = append(tarArgs, "--directory", tempdir)
+		cmd := tarAsUser(username, tarArgs...)
 		cmd.Env = []string{}
 		cmd.Stdin = tr
 		matchCounter := &strutil.MatchCounter{N: 1}
@@ -354,52 +424,167 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 				r.Name(), entry, expectedHash, actualHash)
 		}
 
-		if curdir != "" && curdir != revdir {
-			// rename it in tempdir
-			// this is where we assume the current revision can read the snapshot revision's data
-			if err := os.Rename(filepath.Join(tempdir, revdir), filepath.Join(tempdir, curdir)); err != nil {
-				return rs, err
+		// the temporary directory and the data directory can be changed
+		// by the user in the meantime, so symbolic links are not
+		// followed from now on
+		if err := moveRestoredFiles(rs, tempdir, parent, curdir, revdir, partial, include, exclude, isUser); err != nil {
+			return rs, err
+		}
+
+		sz.Reset()
+		hasher.Reset()
+	}
+
+	return rs, nil
+}
+
+// pathFilter selects paths relative to a snap data directory, with expanded
+// patterns.
+type pathFilter struct {
+	include []string
+	exclude []string
+}
+
+func (f *pathFilter) selects(path string) bool {
+	if len(f.include) > 0 && !pathMatches(f.include, path) {
+		return false
+	}
+	return !pathMatches(f.exclude, path)
+}
+
+// moveRestoredFiles moves the files unpacked in tempdir into the parent of
+// the data directories, renaming the data of revdir to the current revision
+// curdir if set. For partial restores only the selected files are moved, on
+// top of what is there already.
+func moveRestoredFiles(rs *RestoreState, tempdir, parent, curdir, revdir string, partial bool, include, exclude []string, isUser bool) error {
+	src, err := openNoFollowDir(tempdir)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if curdir != "" && curdir != revdir {
+		// rename it in tempdir
+		// this is where we assume the current revision can read the snapshot revision's data
+		exists, _, err := src.exists(revdir)
+		if err != nil {
+			return err
+		}
+		// the snapshot might only hold common data
+		if exists {
+			if err := src.rename(revdir, src, curdir); err != nil {
+				return err
 			}
-			revdir = curdir
 		}
+		revdir = curdir
+	}
 
+	dst, err := openNoFollowDir(parent)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if !partial {
 		for _, dir := range []string{"common", revdir} {
-			if err := moveFile(rs, dir, tempdir, parent); err != nil {
-				return rs, err
+			if err := moveFile(rs, dir, src, dst); err != nil {
+				return err
 			}
 		}
+		return nil
+	}
 
-		sz.Reset()
-		hasher.Reset()
+	// match the paths against the revision the data is restored to
+	rev, err := snap.ParseRevision(revdir)
+	if err != nil {
+		return err
+	}
+	f := &pathFilter{
+		include: expandSnapshotPaths(include, rev, isUser),
+		exclude: expandSnapshotPaths(exclude, rev, isUser),
 	}
+	return mergeFiles(rs, src, dst, f)
+}
 
-	return rs, nil
+// mergeFiles moves the files the filter selects from the sourceDir to the
+// targetDir, on top of what is there already. Directories that do not exist
+// in targetDir are created like the ones in sourceDir. Directories moved and
+// created are registered in the RestoreState.
+func mergeFiles(rs *RestoreState, sourceDir, targetDir *noFollowDir, f *pathFilter) error {
+	return filepath.WalkDir(sourceDir.path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == sourceDir.path {
+			return nil
+		}
+		rel, err := filepath.Rel(sourceDir.path, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && pathMatches(f.exclude, rel) {
+			return filepath.SkipDir
+		}
+		if !f.selects(rel) {
+			// something in the directory might still be included
+			return nil
+		}
+
+		dir, name := filepath.Split(rel)
+		src, err := sourceDir.open(dir)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		dst, err := targetDir.mkdirAllLike(rs, dir, sourceDir)
+		if err != nil {
+			return err
+		}
+		defer dst.Close()
+
+		if d.IsDir() && len(f.exclude) > 0 {
+			exists, isDir, err := dst.exists(name)
+			if err != nil {
+				return err
+			}
+			if exists && isDir {
+				// keep what is excluded, merging the rest
+				return nil
+			}
+		}
+		if err := moveFile(rs, name, src, dst); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
 }
 
 // moveFile moves file from the sourceDir to the targetDir. Directories moved
 // and created are registered in the RestoreState.
-func moveFile(rs *RestoreState, file, sourceDir, targetDir string) error {
-	src := filepath.Join(sourceDir, file)
-	if exists, _, err := osutil.DirExists(src); err != nil {
+func moveFile(rs *RestoreState, file string, sourceDir, targetDir *noFollowDir) error {
+	if exists, _, err := sourceDir.exists(file); err != nil {
 		return err
 	} else if !exists {
 		return nil
 	}
 
-	dst := filepath.Join(targetDir, file)
-	exists, _, err := osutil.DirExists(dst)
+	dst := filepath.Join(targetDir.path, file)
+	exists, _, err := targetDir.exists(file)
 	if err != nil {
 		return err
 	}
 	if exists {
 		rsfn := restoreStateFilename(dst)
-		if err := os.Rename(dst, rsfn); err != nil {
+		if err := targetDir.rename(file, targetDir, filepath.Base(rsfn)); err != nil {
 			return err
 		}
 		rs.Moved = append(rs.Moved, rsfn)
 	}
 
-	if err := os.Rename(src, dst); err != nil {
+	if err := sourceDir.rename(file, targetDir, file); err != nil {
 		return err
 	}
 	rs.Created = append(rs.Created, dst)
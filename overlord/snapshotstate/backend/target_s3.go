@@ -0,0 +1,165 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	s3DefaultRegion   = "us-east-1"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3DateFormat      = "20060102T150405Z"
+)
+
+// newS3Target returns a target storing objects in a bucket of an
+// S3-compatible server, using path-style requests signed with AWS signature
+// version 4.
+func newS3Target(u *url.URL, client *http.Client) (Target, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("cannot use snapshots target %q: missing host", u.Redacted())
+	}
+	if u.User == nil {
+		return nil, fmt.Errorf("cannot use snapshots target %q: missing access and secret keys", u.Redacted())
+	}
+	accessKey := u.User.Username()
+	secretKey, ok := u.User.Password()
+	if accessKey == "" || !ok || secretKey == "" {
+		return nil, fmt.Errorf("cannot use snapshots target %q: missing access and secret keys", u.Redacted())
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		return nil, fmt.Errorf("cannot use snapshots target %q: missing bucket", u.Redacted())
+	}
+	region := u.Query().Get("region")
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	base := baseURL(u, strings.TrimPrefix(u.Scheme, "s3+"))
+	signer := &s3Signer{
+		accessKey: accessKey,
+		secretKey: secretKey,
+		region:    region,
+	}
+	return &httpTarget{
+		client: client,
+		objectURL: func(name string) *url.URL {
+			return objectURL(base, name)
+		},
+		authorize: signer.sign,
+	}, nil
+}
+
+type s3Signer struct {
+	accessKey string
+	secretKey string
+	region    string
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3URIEncode encodes s as required for canonical requests, that is with
+// everything but unreserved characters percent-encoded, and slashes kept
+// as they are unless encodeSlash is set.
+func s3URIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3URIEncode(k, true)+"="+s3URIEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// sign adds the headers authenticating the request. The payload is not
+// signed so that it can be streamed.
+func (s *s3Signer) sign(req *http.Request) {
+	now := timeNow().UTC()
+	amzDate := now.Format(s3DateFormat)
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n",
+		req.URL.Host, s3UnsignedPayload, amzDate)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3URIEncode(req.URL.Path, false),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, s.region)
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(hashedRequest[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
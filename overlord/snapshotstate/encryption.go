@@ -0,0 +1,180 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapshotstate
+
+import (
+	"fmt"
+
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// Encryption holds the keys to encrypt a snapshot set to, when saving it,
+// or to decrypt it with, when checking or restoring it.
+type Encryption struct {
+	// Recipients are age X25519 recipients ("age1…") to encrypt to.
+	Recipients []string
+	// Passphrase is a passphrase to encrypt to, or to decrypt with.
+	Passphrase string
+	// Identities are the content of age identity files, holding X25519
+	// identities ("AGE-SECRET-KEY-1…") to decrypt with.
+	Identities []string
+}
+
+// DecryptionKeyRequiredError is returned when a snapshot set is encrypted
+// to keys that are neither given nor kept on the device.
+type DecryptionKeyRequiredError struct {
+	SetID uint64
+	// Fingerprints are the ones of the keys the set is encrypted to.
+	Fingerprints []string
+}
+
+func (e *DecryptionKeyRequiredError) Error() string {
+	return fmt.Sprintf("snapshot set #%d is encrypted, and none of the keys it is encrypted to is available", e.SetID)
+}
+
+// Passphrases and identities are secrets, so they are never written to the
+// state but only kept in memory, as data of the tasks that use them. They
+// are dropped once the change of those tasks is ready, and lost if snapd
+// restarts, in which case the tasks fail unless the keys can be found on the
+// device.
+
+type snapshotKeysKey struct {
+	taskID string
+}
+
+type snapshotKeys struct {
+	passphrase string
+	identities []backend.Identity
+}
+
+func taskSnapshotKeys(t *state.Task) *snapshotKeys {
+	keys, _ := t.State().Cached(snapshotKeysKey{t.ID()}).(*snapshotKeys)
+	return keys
+}
+
+func setTaskSnapshotKeys(t *state.Task, keys *snapshotKeys) {
+	t.State().Cache(snapshotKeysKey{t.ID()}, keys)
+}
+
+// dropSnapshotKeys drops the keys kept for the tasks of the given change
+// once it is ready, whether it succeeded or not.
+func dropSnapshotKeys(chg *state.Change, old, new state.Status) {
+	if !new.Ready() {
+		return
+	}
+	st := chg.State()
+	for _, t := range chg.Tasks() {
+		st.Cache(snapshotKeysKey{t.ID()}, nil)
+	}
+}
+
+// recipients parses the recipients to encrypt to.
+func (enc *Encryption) recipients() ([]backend.Recipient, error) {
+	var recipients []backend.Recipient
+	for _, s := range enc.Recipients {
+		r, err := backend.ParseRecipient(s)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+	if enc.Passphrase != "" {
+		if len(recipients) > 0 {
+			return nil, fmt.Errorf("cannot encrypt snapshots with both a passphrase and recipients")
+		}
+		recipients = append(recipients, backend.Passphrase(enc.Passphrase))
+	}
+	return recipients, nil
+}
+
+// identities parses the identities to decrypt with.
+func (enc *Encryption) identities() ([]backend.Identity, error) {
+	var identities []backend.Identity
+	for _, data := range enc.Identities {
+		ids, err := backend.ParseIdentities([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, ids...)
+	}
+	if enc.Passphrase != "" {
+		identities = append(identities, backend.Passphrase(enc.Passphrase))
+	}
+	return identities, nil
+}
+
+// prepareDecryption makes sure the encrypted snapshots among the given ones
+// can be decrypted, and returns the given keys for the tasks to use.
+func prepareDecryption(setID uint64, summaries snapshotSnapSummaries, enc *Encryption) (*snapshotKeys, error) {
+	var identities []backend.Identity
+	if enc != nil {
+		var err error
+		identities, err = enc.identities()
+		if err != nil {
+			return nil, err
+		}
+	}
+	var fingerprints []string
+	for _, summary := range summaries {
+		ok, err := backendCanDecrypt(summary.keyFingerprints, identities)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			for _, fp := range summary.keyFingerprints {
+				if !strutil.ListContains(fingerprints, fp) {
+					fingerprints = append(fingerprints, fp)
+				}
+			}
+		}
+	}
+	if len(fingerprints) > 0 {
+		return nil, &DecryptionKeyRequiredError{SetID: setID, Fingerprints: fingerprints}
+	}
+	if len(identities) == 0 {
+		return nil, nil
+	}
+	return &snapshotKeys{identities: identities}, nil
+}
+
+// snapshotIdentities returns the identities given to the task to decrypt
+// its snapshot with.
+func snapshotIdentities(t *state.Task) []backend.Identity {
+	if keys := taskSnapshotKeys(t); keys != nil {
+		return keys.identities
+	}
+	return nil
+}
+
+// snapshotRecipients returns the recipients the task is to encrypt the
+// snapshot of the given setup to.
+func snapshotRecipients(t *state.Task, snapshot *snapshotSetup) ([]backend.Recipient, error) {
+	enc := &Encryption{Recipients: snapshot.EncryptTo}
+	if snapshot.EncryptWithPassphrase {
+		keys := taskSnapshotKeys(t)
+		if keys == nil || keys.passphrase == "" {
+			return nil, fmt.Errorf("cannot encrypt snapshot: passphrase is not available anymore")
+		}
+		enc.Passphrase = keys.passphrase
+	}
+	return enc.recipients()
+}
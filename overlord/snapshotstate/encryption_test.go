@@ -0,0 +1,247 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapshotstate_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapshotstate"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+func mockEncryptedSet(c *check.C, fingerprints []string) (restore func()) {
+	shotfile, err := os.Create(filepath.Join(c.MkDir(), "yadda.zip"))
+	c.Assert(err, check.IsNil)
+	restoreIter := snapshotstate.MockBackendIter(func(_ context.Context, f func(*backend.Reader) error) error {
+		return f(&backend.Reader{
+			Snapshot: client.Snapshot{SetID: 42, Snap: "a-snap", KeyFingerprints: fingerprints},
+			File:     shotfile,
+		})
+	})
+	return func() {
+		restoreIter()
+		shotfile.Close()
+	}
+}
+
+func (snapshotSuite) TestSaveEncrypted(c *check.C) {
+	defer snapshotstate.MockSnapstateAll(func(*state.State) (map[string]*snapstate.SnapState, error) {
+		return map[string]*snapstate.SnapState{"a-snap": {Active: true}}, nil
+	})()
+	_, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, _, taskset, err := snapshotstate.Save(st, nil, nil, nil, &snapshotstate.Encryption{Recipients: []string{fmt.Sprint(recipient)}})
+	c.Assert(err, check.IsNil)
+	var snapshot map[string]any
+	c.Assert(taskset.Tasks()[0].Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot["encrypt-to"], check.DeepEquals, []any{fmt.Sprint(recipient)})
+
+	_, _, taskset, err = snapshotstate.Save(st, nil, nil, nil, &snapshotstate.Encryption{Passphrase: "secret"})
+	c.Assert(err, check.IsNil)
+	snapshot = nil
+	c.Assert(taskset.Tasks()[0].Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot["encrypt-with-passphrase"], check.Equals, true)
+	// the passphrase is not written to the state
+	data, err := json.Marshal(st)
+	c.Assert(err, check.IsNil)
+	c.Check(string(data), check.Not(check.Matches), "(?s).*secret.*")
+}
+
+func (snapshotSuite) TestSaveEncryptedBadKeys(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, _, _, err := snapshotstate.Save(st, nil, nil, nil, &snapshotstate.Encryption{Recipients: []string{"age1foo"}})
+	c.Check(err, check.ErrorMatches, `cannot parse recipient: malformed recipient "age1foo": .*`)
+
+	_, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+	_, _, _, err = snapshotstate.Save(st, nil, nil, nil, &snapshotstate.Encryption{Recipients: []string{fmt.Sprint(recipient)}, Passphrase: "secret"})
+	c.Check(err, check.ErrorMatches, "cannot encrypt snapshots with both a passphrase and recipients")
+}
+
+func (snapshotSuite) TestRestoreEncryptedRequiresKey(c *check.C) {
+	defer mockEncryptedSet(c, []string{backend.PassphraseFingerprint})()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, _, err := snapshotstate.Restore(st, 42, nil, nil, nil, nil)
+	c.Assert(err, check.FitsTypeOf, &snapshotstate.DecryptionKeyRequiredError{})
+	c.Check(err, check.ErrorMatches, "snapshot set #42 is encrypted, and none of the keys it is encrypted to is available")
+	c.Check(err.(*snapshotstate.DecryptionKeyRequiredError).Fingerprints, check.DeepEquals, []string{backend.PassphraseFingerprint})
+
+	_, _, err = snapshotstate.Check(st, 42, nil, nil, nil)
+	c.Assert(err, check.FitsTypeOf, &snapshotstate.DecryptionKeyRequiredError{})
+
+	_, ts, err := snapshotstate.Restore(st, 42, nil, nil, nil, &snapshotstate.Encryption{Passphrase: "secret"})
+	c.Assert(err, check.IsNil)
+	c.Check(ts.Tasks(), check.HasLen, 2)
+}
+
+func (snapshotSuite) TestCheckEncryptedWithIdentity(c *check.C) {
+	id, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+	defer mockEncryptedSet(c, []string{recipient.Fingerprint()})()
+
+	var identities []backend.Identity
+	defer snapshotstate.MockBackendOpen(func(string, uint64) (*backend.Reader, error) {
+		return &backend.Reader{}, nil
+	})()
+	defer snapshotstate.MockBackendCheck(func(r *backend.Reader, _ context.Context, _ []string) error {
+		identities = r.Identities
+		return nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	_, _, err = snapshotstate.Check(st, 42, nil, nil, &snapshotstate.Encryption{Identities: []string{"AGE-SECRET-KEY-1"}})
+	c.Check(err, check.ErrorMatches, "cannot parse identities: error at line 1: .*")
+
+	_, ts, err := snapshotstate.Check(st, 42, nil, nil, &snapshotstate.Encryption{Identities: []string{fmt.Sprintln(id)}})
+	c.Assert(err, check.IsNil)
+	task := ts.Tasks()[0]
+	st.Unlock()
+
+	c.Assert(snapshotstate.DoCheck(task, &tomb.Tomb{}), check.IsNil)
+	c.Assert(identities, check.HasLen, 1)
+	c.Check(identities[0].Fingerprint(), check.Equals, recipient.Fingerprint())
+}
+
+func (snapshotSuite) TestCheckEncryptedWithLocalIdentity(c *check.C) {
+	id, recipient, err := backend.GenerateIdentity()
+	c.Assert(err, check.IsNil)
+	defer mockEncryptedSet(c, []string{recipient.Fingerprint()})()
+
+	keysDir := filepath.Join(dirs.SnapshotsDir, "keys")
+	c.Assert(os.MkdirAll(keysDir, 0700), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(keysDir, "device.key"), []byte(fmt.Sprintln(id)), 0600), check.IsNil)
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, _, err = snapshotstate.Check(st, 42, nil, nil, nil)
+	c.Check(err, check.IsNil)
+}
+
+func (snapshotSuite) TestDoSaveEncrypted(c *check.C) {
+	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
+		return &snap.Info{SideInfo: snap.SideInfo{RealName: "a-snap", Revision: snap.R(1)}, Version: "1.33"}, nil
+	})()
+	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
+	defer snapshotstate.MockSnapstateAll(func(*state.State) (map[string]*snapstate.SnapState, error) {
+		return map[string]*snapstate.SnapState{"a-snap": {Active: true}}, nil
+	})()
+
+	var flags []*backend.SaveFlags
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, _ *dirs.SnapDirOptions, f *backend.SaveFlags) (*client.Snapshot, error) {
+		flags = append(flags, f)
+		return nil, nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	// encrypted snapshots are never chunked
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.deduplicate", true)
+	tr.Commit()
+	_, _, ts, err := snapshotstate.Save(st, nil, nil, nil, &snapshotstate.Encryption{Passphrase: "secret"})
+	c.Assert(err, check.IsNil)
+	task := ts.Tasks()[0]
+	st.Unlock()
+
+	c.Assert(snapshotstate.DoSave(task, &tomb.Tomb{}), check.IsNil)
+	c.Assert(flags, check.HasLen, 1)
+	c.Check(flags[0].Chunked, check.Equals, false)
+	c.Check(flags[0].Recipients, check.DeepEquals, []backend.Recipient{backend.Passphrase("secret")})
+
+	// the passphrase is lost if snapd restarts
+	st.Lock()
+	task = st.NewTask("save-snapshot", "...")
+	task.Set("snapshot-setup", map[string]any{
+		"set-id":                  43,
+		"snap":                    "a-snap",
+		"encrypt-with-passphrase": true,
+	})
+	st.Unlock()
+	c.Check(snapshotstate.DoSave(task, &tomb.Tomb{}), check.ErrorMatches, "cannot encrypt snapshot: passphrase is not available anymore")
+	c.Check(flags, check.HasLen, 1)
+}
+
+func (snapshotSuite) TestSnapshotKeysDroppedWhenChangeReady(c *check.C) {
+	defer snapshotstate.MockSnapstateAll(func(*state.State) (map[string]*snapstate.SnapState, error) {
+		return map[string]*snapstate.SnapState{"a-snap": {Active: true}}, nil
+	})()
+	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
+		return &snap.Info{SideInfo: snap.SideInfo{RealName: "a-snap", Revision: snap.R(1)}, Version: "1.33"}, nil
+	})()
+	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
+	var flags []*backend.SaveFlags
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, _ *dirs.SnapDirOptions, f *backend.SaveFlags) (*client.Snapshot, error) {
+		flags = append(flags, f)
+		return nil, nil
+	})()
+
+	st := state.New(nil)
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+	c.Assert(mgr.StartUp(), check.IsNil)
+	defer mgr.Stop()
+
+	st.Lock()
+	_, _, ts, err := snapshotstate.Save(st, nil, nil, nil, &snapshotstate.Encryption{Passphrase: "secret"})
+	c.Assert(err, check.IsNil)
+	chg := st.NewChange("save-snapshot", "...")
+	chg.AddAll(ts)
+	task := ts.Tasks()[0]
+	st.Unlock()
+
+	c.Assert(snapshotstate.DoSave(task, &tomb.Tomb{}), check.IsNil)
+	c.Assert(flags, check.HasLen, 1)
+
+	// the change failing makes it ready, and the passphrase is dropped
+	st.Lock()
+	task.SetStatus(state.ErrorStatus)
+	c.Assert(chg.Status().Ready(), check.Equals, true)
+	st.Unlock()
+
+	c.Check(snapshotstate.DoSave(task, &tomb.Tomb{}), check.ErrorMatches, "cannot encrypt snapshot: passphrase is not available anymore")
+	c.Check(flags, check.HasLen, 1)
+}
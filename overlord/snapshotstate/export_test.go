@@ -23,6 +23,7 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"net/http"
 	"time"
 
 	"github.com/snapcore/snapd/client"
@@ -45,7 +46,11 @@ var (
 	CleanupRestore             = cleanupRestore
 	DoCheck                    = doCheck
 	DoForget                   = doForget
+	DoUpload                   = doUpload
+	DoRemoveUploaded           = doRemoveUploaded
 	SaveExpiration             = saveExpiration
+	SaveScheduled              = saveScheduled
+	MarkSnapshotSetUploaded    = markSnapshotSetUploaded
 	ExpiredSnapshotSets        = expiredSnapshotSets
 	RemoveSnapshotState        = removeSnapshotState
 
@@ -171,6 +176,14 @@ func MockBackendCleanupAbandonedImports(f func() (int, error)) (restore func())
 	}
 }
 
+func MockBackendCleanupUnusedChunks(f func(context.Context) (int, error)) (restore func()) {
+	old := backendCleanupUnusedChunks
+	backendCleanupUnusedChunks = f
+	return func() {
+		backendCleanupUnusedChunks = old
+	}
+}
+
 func MockBackendEstimateSnapshotSize(f func(*snap.Info, []string, *dirs.SnapDirOptions) (uint64, error)) (restore func()) {
 	old := backendEstimateSnapshotSize
 	backendEstimateSnapshotSize = f
@@ -187,6 +200,51 @@ func MockBackendNewSnapshotExport(f func(ctx context.Context, setID uint64) (se
 	}
 }
 
+func MockBackendNewTarget(f func(rawURL string, client *http.Client) (backend.Target, error)) (restore func()) {
+	old := backendNewTarget
+	backendNewTarget = f
+	return func() {
+		backendNewTarget = old
+	}
+}
+
+func MockBackendUpload(f func(ctx context.Context, target backend.Target, se *SnapshotExport) error) (restore func()) {
+	old := backendUpload
+	backendUpload = f
+	return func() {
+		backendUpload = old
+	}
+}
+
+func MockBackendDownload(f func(ctx context.Context, target backend.Target, setID uint64) ([]string, error)) (restore func()) {
+	old := backendDownload
+	backendDownload = f
+	return func() {
+		backendDownload = old
+	}
+}
+
+func MockBackendRemove(f func(ctx context.Context, target backend.Target, setID uint64) error) (restore func()) {
+	old := backendRemove
+	backendRemove = f
+	return func() {
+		backendRemove = old
+	}
+}
+
+func MockTimeNow(f func() time.Time) (restore func()) {
+	old := timeNow
+	timeNow = f
+	return func() {
+		timeNow = old
+	}
+}
+
+func RetentionDiscarded(keepLast, keepDaily, keepWeekly int, taken map[uint64]time.Time) []uint64 {
+	p := &retentionPolicy{keepLast: keepLast, keepDaily: keepDaily, keepWeekly: keepWeekly}
+	return p.discarded(taken)
+}
+
 func MockConfigGetSnapConfig(f func(*state.State, string) (*json.RawMessage, error)) (restore func()) {
 	old := configGetSnapConfig
 	configGetSnapConfig = f
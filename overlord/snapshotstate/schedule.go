@@ -0,0 +1,360 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapshotstate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
+)
+
+var scheduledSnapshotChangeKind = swfeats.RegisterChangeKind("scheduled-snapshot")
+
+func init() {
+	swfeats.RegisterEnsure("SnapshotManager", "ensureScheduledSnapshots")
+	swfeats.RegisterEnsure("SnapshotManager", "ensureScheduledSnapshotsRetention")
+}
+
+var (
+	timeNow = time.Now
+
+	// upper bound of the time between two scheduled snapshot sets,
+	// whatever the schedule
+	maxScheduledSnapshotDelay = 6 * 7 * 24 * time.Hour
+
+	// interval between applications of the retention policy
+	scheduledSnapshotsRetentionInterval = time.Hour
+
+	// number of scheduled sets kept when no retention policy is configured
+	defaultScheduledSnapshotsKeepLast = 7
+)
+
+// retentionPolicy tells which scheduled snapshot sets to keep. A set is
+// kept if it is among the keepLast most recent ones, or the most recent of
+// one of the keepDaily most recent days or keepWeekly most recent weeks
+// with sets.
+type retentionPolicy struct {
+	keepLast   int
+	keepDaily  int
+	keepWeekly int
+}
+
+// discarded returns, in ascending order, the IDs of the sets not kept by
+// the policy, given the time each set was taken.
+func (p *retentionPolicy) discarded(taken map[uint64]time.Time) []uint64 {
+	ids := make([]uint64, 0, len(taken))
+	for id := range taken {
+		ids = append(ids, id)
+	}
+	// most recent first
+	sort.Slice(ids, func(i, j int) bool {
+		ti, tj := taken[ids[i]], taken[ids[j]]
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return ids[i] > ids[j]
+	})
+
+	keep := make(map[uint64]bool, len(ids))
+	for i, id := range ids {
+		if i >= p.keepLast {
+			break
+		}
+		keep[id] = true
+	}
+	keepOnePer := func(n int, period func(t time.Time) string) {
+		seen := make(map[string]bool, n)
+		for _, id := range ids {
+			key := period(taken[id])
+			if seen[key] {
+				continue
+			}
+			if len(seen) >= n {
+				break
+			}
+			seen[key] = true
+			keep[id] = true
+		}
+	}
+	keepOnePer(p.keepDaily, func(t time.Time) string {
+		return t.Local().Format("2006-01-02")
+	})
+	keepOnePer(p.keepWeekly, func(t time.Time) string {
+		year, week := t.Local().ISOWeek()
+		return fmt.Sprintf("%d-%d", year, week)
+	})
+
+	var discarded []uint64
+	for _, id := range ids {
+		if !keep[id] {
+			discarded = append(discarded, id)
+		}
+	}
+	sort.Slice(discarded, func(i, j int) bool { return discarded[i] < discarded[j] })
+	return discarded
+}
+
+type scheduledSnapshotsConfig struct {
+	timer     string
+	snapNames []string
+	retention retentionPolicy
+}
+
+func configInt(tr *config.Transaction, key string) (int, error) {
+	var v any
+	if err := tr.Get("core", key, &v); err != nil && !config.IsNoOption(err) {
+		return 0, err
+	}
+	if v == nil {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(fmt.Sprint(v))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative number, not %q", key, fmt.Sprint(v))
+	}
+	return n, nil
+}
+
+// getScheduledSnapshotsConfig reads the snapshots.schedule and
+// snapshots.scheduled.* options. The state must be locked by the caller.
+func getScheduledSnapshotsConfig(st *state.State) (*scheduledSnapshotsConfig, error) {
+	tr := config.NewTransaction(st)
+	var conf scheduledSnapshotsConfig
+	if err := tr.Get("core", "snapshots.schedule", &conf.timer); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	var snaps string
+	if err := tr.Get("core", "snapshots.scheduled.snaps", &snaps); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	conf.snapNames = strutil.CommaSeparatedList(snaps)
+
+	var err error
+	if conf.retention.keepLast, err = configInt(tr, "snapshots.scheduled.keep-last"); err != nil {
+		return nil, err
+	}
+	if conf.retention.keepDaily, err = configInt(tr, "snapshots.scheduled.keep-daily"); err != nil {
+		return nil, err
+	}
+	if conf.retention.keepWeekly, err = configInt(tr, "snapshots.scheduled.keep-weekly"); err != nil {
+		return nil, err
+	}
+	if conf.retention == (retentionPolicy{}) {
+		conf.retention.keepLast = defaultScheduledSnapshotsKeepLast
+	}
+	return &conf, nil
+}
+
+// scheduledSnapNames returns the snaps to include in a scheduled snapshot
+// set, that is the active ones among the configured ones, or all active ones
+// if none are configured.
+func scheduledSnapNames(st *state.State, configured []string) ([]string, error) {
+	active, err := allActiveSnapNames(st)
+	if err != nil {
+		return nil, err
+	}
+	if len(configured) == 0 {
+		return active, nil
+	}
+	var names []string
+	for _, name := range configured {
+		if !strutil.SortedListContains(active, name) {
+			logger.Noticef("Not including snap %q in scheduled snapshot: snap is not installed or not active.", name)
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// ensureScheduledSnapshots creates a change saving a new snapshot set when
+// one is due as per the snapshots.schedule option.
+func (mgr *SnapshotManager) ensureScheduledSnapshots() error {
+	st := mgr.state
+	st.Lock()
+	defer st.Unlock()
+
+	conf, err := getScheduledSnapshotsConfig(st)
+	if err != nil {
+		return err
+	}
+	if conf.timer == "" {
+		mgr.nextScheduledSnapshot = time.Time{}
+		mgr.scheduledSnapshotTimer = ""
+		return nil
+	}
+	schedule, err := timeutil.ParseSchedule(conf.timer)
+	if err != nil {
+		logger.Noticef("cannot use snapshots.schedule configuration: %v", err)
+		return nil
+	}
+
+	var seeded bool
+	if err := st.Get("seeded", &seeded); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if !seeded {
+		return nil
+	}
+
+	logger.Trace("ensure", "manager", "SnapshotManager", "func", "ensureScheduledSnapshots")
+
+	now := timeNow()
+	var last time.Time
+	if err := st.Get("last-scheduled-snapshot", &last); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if last.IsZero() {
+		// do not take a set as soon as a schedule is configured, but
+		// only from its next window
+		last = now
+		st.Set("last-scheduled-snapshot", last)
+	}
+	if mgr.nextScheduledSnapshot.IsZero() || mgr.scheduledSnapshotTimer != conf.timer {
+		mgr.nextScheduledSnapshot = now.Add(timeutil.Next(schedule, last, maxScheduledSnapshotDelay))
+		mgr.scheduledSnapshotTimer = conf.timer
+		logger.Debugf("Next scheduled snapshot set for %s.", mgr.nextScheduledSnapshot.Format(time.RFC3339))
+	}
+	if mgr.nextScheduledSnapshot.After(now) {
+		return nil
+	}
+
+	for _, chg := range st.Changes() {
+		if chg.Kind() == scheduledSnapshotChangeKind && !chg.IsReady() {
+			// wait for the previous one to finish
+			return nil
+		}
+	}
+
+	names, err := scheduledSnapNames(st, conf.snapNames)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		logger.Noticef("Skipping scheduled snapshot: no snaps to save.")
+	} else {
+		setID, saved, ts, err := save(st, names, nil, nil, nil, true)
+		if err != nil {
+			var conflictErr *snapstate.ChangeConflictError
+			if errors.As(err, &conflictErr) {
+				// try again on next Ensure
+				logger.Debugf("Postponing scheduled snapshot: %v", err)
+				return nil
+			}
+			return err
+		}
+		msg := fmt.Sprintf("Save snapshot set #%d of snaps %s as scheduled", setID, strutil.Quoted(saved))
+		chg := st.NewChange(scheduledSnapshotChangeKind, msg)
+		chg.AddAll(ts)
+		chg.Set("api-data", map[string]any{"snap-names": saved})
+		st.EnsureBefore(0)
+	}
+
+	st.Set("last-scheduled-snapshot", now)
+	// computed again from the new last time on next Ensure
+	mgr.nextScheduledSnapshot = time.Time{}
+	return nil
+}
+
+// ensureScheduledSnapshotsRetention forgets the scheduled snapshot sets that
+// are not kept by the configured retention policy. The policy is only
+// enforced while snapshots.schedule is set.
+func (mgr *SnapshotManager) ensureScheduledSnapshotsRetention() error {
+	now := timeNow()
+	if now.Before(mgr.lastRetentionTime.Add(scheduledSnapshotsRetentionInterval)) {
+		return nil
+	}
+
+	st := mgr.state
+	st.Lock()
+	defer st.Unlock()
+
+	conf, err := getScheduledSnapshotsConfig(st)
+	if err != nil {
+		return err
+	}
+	if conf.timer == "" {
+		return nil
+	}
+
+	logger.Trace("ensure", "manager", "SnapshotManager", "func", "ensureScheduledSnapshotsRetention")
+
+	var snapshots map[uint64]*snapshotState
+	if err := st.Get("snapshots", &snapshots); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	taken := make(map[uint64]time.Time)
+	filenames := make(map[uint64][]string)
+	err = backendIter(context.TODO(), func(r *backend.Reader) error {
+		if snapshotSt, ok := snapshots[r.SetID]; !ok || !snapshotSt.Scheduled {
+			return nil
+		}
+		// sets are timestamped with their oldest snapshot
+		if t, ok := taken[r.SetID]; !ok || r.Time.Before(t) {
+			taken[r.SetID] = r.Time
+		}
+		filenames[r.SetID] = append(filenames[r.SetID], r.Name())
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot apply scheduled snapshots retention policy: %v", err)
+	}
+
+	var uploaded []uint64
+	for _, setID := range conf.retention.discarded(taken) {
+		// same conflicts as forget
+		if err := checkSnapshotConflict(st, setID, "export-snapshot",
+			"check-snapshot", "restore-snapshot", "upload-snapshot"); err != nil {
+			// retried on next run
+			continue
+		}
+		if snapshots[setID].Uploaded {
+			uploaded = append(uploaded, setID)
+		}
+		// see forgetExpiredSnapshots about the ordering
+		if err := removeSnapshotState(st, setID); err != nil {
+			return fmt.Errorf("internal error: cannot remove state of snapshot set %d: %v", setID, err)
+		}
+		for _, filename := range filenames[setID] {
+			if err := osRemove(filename); err != nil {
+				return fmt.Errorf("cannot remove snapshot file %q: %v", filename, err)
+			}
+		}
+		logger.Noticef("Forgot scheduled snapshot set #%d as per the retention policy.", setID)
+	}
+	if err := queueUploadedSnapshotSetsRemoval(st, uploaded); err != nil {
+		return err
+	}
+
+	mgr.lastRetentionTime = now
+	return nil
+}
@@ -0,0 +1,343 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapshotstate_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapshotstate"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/timeutil"
+)
+
+type scheduleSuite struct {
+	snapshotSuite
+
+	st  *state.State
+	mgr *snapshotstate.SnapshotManager
+	now time.Time
+}
+
+var _ = check.Suite(&scheduleSuite{})
+
+func (s *scheduleSuite) SetUpTest(c *check.C) {
+	s.snapshotSuite.SetUpTest(c)
+
+	s.now = time.Date(2026, 3, 2, 12, 0, 0, 0, time.Local)
+	now := func() time.Time { return s.now }
+	s.AddCleanup(snapshotstate.MockTimeNow(now))
+	s.AddCleanup(timeutil.MockTimeNow(now))
+
+	s.AddCleanup(snapshotstate.MockBackendIter(func(context.Context, func(*backend.Reader) error) error {
+		return nil
+	}))
+
+	s.st = state.New(nil)
+	s.mgr = snapshotstate.Manager(s.st, state.NewTaskRunner(s.st))
+
+	s.st.Lock()
+	defer s.st.Unlock()
+	s.st.Set("seeded", true)
+	for i, name := range []string{"a-snap", "b-snap", "c-snap"} {
+		sideInfo := &snap.SideInfo{RealName: name, Revision: snap.R(i + 1)}
+		snapstate.Set(s.st, name, &snapstate.SnapState{
+			// c-snap is disabled
+			Active:   name != "c-snap",
+			Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{sideInfo}),
+			Current:  sideInfo.Revision,
+		})
+	}
+}
+
+func (s *scheduleSuite) setConfig(c *check.C, conf map[string]any) {
+	s.st.Lock()
+	defer s.st.Unlock()
+	tr := config.NewTransaction(s.st)
+	for k, v := range conf {
+		c.Assert(tr.Set("core", k, v), check.IsNil)
+	}
+	tr.Commit()
+}
+
+func (s *scheduleSuite) scheduledChanges() []*state.Change {
+	var chgs []*state.Change
+	for _, chg := range s.st.Changes() {
+		if chg.Kind() == "scheduled-snapshot" {
+			chgs = append(chgs, chg)
+		}
+	}
+	return chgs
+}
+
+func (s *scheduleSuite) TestNoSchedule(c *check.C) {
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+	c.Check(s.scheduledChanges(), check.HasLen, 0)
+	c.Check(s.st.Has("last-scheduled-snapshot"), check.Equals, false)
+}
+
+func (s *scheduleSuite) TestNotSeeded(c *check.C) {
+	s.setConfig(c, map[string]any{"snapshots.schedule": "0:00-24:00"})
+	s.st.Lock()
+	s.st.Set("seeded", nil)
+	s.st.Unlock()
+
+	s.now = s.now.Add(48 * time.Hour)
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+	c.Check(s.scheduledChanges(), check.HasLen, 0)
+}
+
+func (s *scheduleSuite) TestScheduledSnapshot(c *check.C) {
+	s.setConfig(c, map[string]any{"snapshots.schedule": "13:00"})
+
+	// the first run only anchors the schedule
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+	s.st.Lock()
+	c.Check(s.scheduledChanges(), check.HasLen, 0)
+	var last time.Time
+	c.Assert(s.st.Get("last-scheduled-snapshot", &last), check.IsNil)
+	c.Check(last.Equal(s.now), check.Equals, true)
+	s.st.Unlock()
+
+	// not due yet
+	s.now = s.now.Add(30 * time.Minute)
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+	s.st.Lock()
+	c.Check(s.scheduledChanges(), check.HasLen, 0)
+	s.st.Unlock()
+
+	s.now = s.now.Add(time.Hour)
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+
+	s.st.Lock()
+	chgs := s.scheduledChanges()
+	c.Assert(chgs, check.HasLen, 1)
+	chg := chgs[0]
+	c.Check(chg.Summary(), check.Equals, `Save snapshot set #1 of snaps "a-snap", "b-snap" as scheduled`)
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 2)
+	for i, name := range []string{"a-snap", "b-snap"} {
+		c.Check(tasks[i].Kind(), check.Equals, "save-snapshot")
+		var snapshot map[string]any
+		c.Assert(tasks[i].Get("snapshot-setup", &snapshot), check.IsNil)
+		c.Check(snapshot, check.DeepEquals, map[string]any{
+			"set-id":    1.,
+			"snap":      name,
+			"current":   "unset",
+			"scheduled": true,
+		})
+	}
+	c.Assert(s.st.Get("last-scheduled-snapshot", &last), check.IsNil)
+	c.Check(last.Equal(s.now), check.Equals, true)
+	s.st.Unlock()
+
+	// no new set while the previous one is in progress
+	s.now = s.now.Add(48 * time.Hour)
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+	s.st.Lock()
+	c.Check(s.scheduledChanges(), check.HasLen, 1)
+	for _, t := range chg.Tasks() {
+		t.SetStatus(state.DoneStatus)
+	}
+	s.st.Unlock()
+
+	// missed windows are not caught up, the next one is used
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+	s.st.Lock()
+	c.Check(s.scheduledChanges(), check.HasLen, 1)
+	s.st.Unlock()
+
+	s.now = s.now.Add(24 * time.Hour)
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+	s.st.Lock()
+	defer s.st.Unlock()
+	c.Check(s.scheduledChanges(), check.HasLen, 2)
+}
+
+func (s *scheduleSuite) TestScheduledSnapshotSomeSnaps(c *check.C) {
+	s.setConfig(c, map[string]any{
+		"snapshots.schedule":        "13:00",
+		"snapshots.scheduled.snaps": "b-snap,c-snap,d-snap",
+	})
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+
+	s.now = s.now.Add(2 * time.Hour)
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+	chgs := s.scheduledChanges()
+	c.Assert(chgs, check.HasLen, 1)
+	// inactive and missing snaps are left out
+	c.Check(chgs[0].Summary(), check.Equals, `Save snapshot set #1 of snaps "b-snap" as scheduled`)
+}
+
+func (s *scheduleSuite) TestScheduledSnapshotPostponedOnConflict(c *check.C) {
+	s.setConfig(c, map[string]any{"snapshots.schedule": "13:00"})
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+
+	conflict := true
+	defer snapshotstate.MockSnapstateCheckChangeConflictMany(func(*state.State, []string, string) error {
+		if conflict {
+			return &snapstate.ChangeConflictError{Snap: "a-snap", ChangeKind: "refresh-snap"}
+		}
+		return nil
+	})()
+
+	s.now = s.now.Add(2 * time.Hour)
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+	s.st.Lock()
+	c.Check(s.scheduledChanges(), check.HasLen, 0)
+	s.st.Unlock()
+
+	conflict = false
+	s.now = s.now.Add(5 * time.Minute)
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+	s.st.Lock()
+	defer s.st.Unlock()
+	c.Check(s.scheduledChanges(), check.HasLen, 1)
+}
+
+func (s *scheduleSuite) TestRetentionDiscarded(c *check.C) {
+	day := func(d, h int) time.Time {
+		return time.Date(2026, 3, d, h, 0, 0, 0, time.Local)
+	}
+	// 2026-03-02 is a Monday
+	taken := map[uint64]time.Time{
+		1: day(2, 1),
+		2: day(2, 13),
+		3: day(3, 1),
+		4: day(9, 1),
+		5: day(10, 1),
+		6: day(10, 13),
+		7: day(16, 1),
+		8: day(16, 13),
+	}
+
+	for _, t := range []struct {
+		keepLast, keepDaily, keepWeekly int
+		discarded                       []uint64
+	}{
+		{3, 0, 0, []uint64{1, 2, 3, 4, 5}},
+		{0, 3, 0, []uint64{1, 2, 3, 5, 7}},
+		{0, 0, 2, []uint64{1, 2, 3, 4, 5, 7}},
+		{1, 1, 3, []uint64{1, 2, 4, 5, 7}},
+		{0, 0, 10, []uint64{1, 2, 4, 5, 7}},
+		{100, 0, 0, nil},
+	} {
+		discarded := snapshotstate.RetentionDiscarded(t.keepLast, t.keepDaily, t.keepWeekly, taken)
+		c.Check(discarded, check.DeepEquals, t.discarded, check.Commentf("%+v", t))
+	}
+}
+
+func (s *scheduleSuite) TestRetention(c *check.C) {
+	s.setConfig(c, map[string]any{
+		"snapshots.schedule":            "13:00",
+		"snapshots.scheduled.keep-last": 2,
+	})
+
+	dir := c.MkDir()
+	var readers []*backend.Reader
+	for i := 1; i <= 5; i++ {
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("%d_a-snap.zip", i)))
+		c.Assert(err, check.IsNil)
+		defer f.Close()
+		readers = append(readers, &backend.Reader{
+			Snapshot: client.Snapshot{SetID: uint64(i), Snap: "a-snap", Time: s.now.Add(time.Duration(i-10) * time.Hour)},
+			File:     f,
+		})
+	}
+	defer snapshotstate.MockBackendIter(func(_ context.Context, f func(*backend.Reader) error) error {
+		for _, r := range readers {
+			c.Assert(f(r), check.IsNil)
+		}
+		return nil
+	})()
+	var removed []string
+	defer snapshotstate.MockOsRemove(func(name string) error {
+		removed = append(removed, filepath.Base(name))
+		return nil
+	})()
+
+	s.setConfig(c, map[string]any{"snapshots.target": "file:///media/backup"})
+	s.st.Lock()
+	for _, setID := range []uint64{1, 2, 3, 5} {
+		c.Assert(snapshotstate.SaveScheduled(s.st, setID), check.IsNil)
+	}
+	c.Assert(snapshotstate.MarkSnapshotSetUploaded(s.st, 2), check.IsNil)
+	s.st.Set("last-snapshot-set-id", 5)
+	// set 4 is an automatic one, not subject to the retention policy
+	c.Assert(snapshotstate.SaveExpiration(s.st, 4, time.Now().Add(time.Hour)), check.IsNil)
+	// set 1 is in use
+	chg := s.st.NewChange("check-snapshot", "...")
+	tsk := s.st.NewTask("check-snapshot", "...")
+	tsk.Set("snapshot-setup", map[string]int{"set-id": 1})
+	chg.AddTask(tsk)
+	s.st.Unlock()
+
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+	// scheduled sets 5 and 3 are kept, 2 is forgotten and 1 is in use
+	c.Check(removed, check.DeepEquals, []string{"2_a-snap.zip"})
+
+	s.st.Lock()
+	var snapshots map[uint64]any
+	c.Assert(s.st.Get("snapshots", &snapshots), check.IsNil)
+	c.Check(snapshots, check.HasLen, 4)
+	c.Check(snapshots[2], check.IsNil)
+	var pending map[uint64]string
+	c.Assert(s.st.Get("snapshots-to-remove", &pending), check.IsNil)
+	c.Check(pending, check.DeepEquals, map[uint64]string{2: "file:///media/backup"})
+	chg.SetStatus(state.DoneStatus)
+	s.st.Unlock()
+
+	// not applied again right away
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+	c.Check(removed, check.HasLen, 1)
+
+	s.now = s.now.Add(time.Hour)
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+	c.Check(removed, check.DeepEquals, []string{"2_a-snap.zip", "1_a-snap.zip"})
+}
+
+func (s *scheduleSuite) TestRetentionOnlyWithSchedule(c *check.C) {
+	defer snapshotstate.MockBackendIter(func(context.Context, func(*backend.Reader) error) error {
+		c.Fatal("unexpected iteration")
+		return nil
+	})()
+	s.setConfig(c, map[string]any{"snapshots.scheduled.keep-last": 1})
+	c.Assert(s.mgr.Ensure(), check.IsNil)
+}
@@ -24,6 +24,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"time"
 
 	"gopkg.in/tomb.v2"
@@ -36,6 +37,7 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
 )
 
 var (
@@ -52,8 +54,11 @@ var (
 	backendCleanup       = (*backend.RestoreState).Cleanup
 
 	backendCleanupAbandonedImports = backend.CleanupAbandonedImports
+	backendCleanupUnusedChunks     = backend.CleanupUnusedChunks
+	backendCanDecrypt              = backend.CanDecrypt
 
 	autoExpirationInterval = time.Hour * 24 // interval between forgetExpiredSnapshots runs as part of Ensure()
+	chunksCleanupInterval  = time.Hour * 24 // interval between cleanupUnusedChunks runs as part of Ensure()
 
 	getSnapDirOpts = snapstate.GetSnapDirOpts
 )
@@ -63,6 +68,14 @@ type SnapshotManager struct {
 	state *state.State
 
 	lastForgetExpiredSnapshotTime time.Time
+	lastChunksCleanupTime         time.Time
+
+	// nextScheduledSnapshot is computed from scheduledSnapshotTimer
+	nextScheduledSnapshot  time.Time
+	scheduledSnapshotTimer string
+	lastRetentionTime      time.Time
+
+	changeCallbackID int
 }
 
 // Manager returns a new SnapshotManager
@@ -74,6 +87,8 @@ func Manager(st *state.State, runner *state.TaskRunner) *SnapshotManager {
 	runner.AddHandler("check-snapshot", doCheck, nil)
 	runner.AddHandler("restore-snapshot", doRestore, undoRestore)
 	runner.AddHandler("cleanup-after-restore", doCleanupAfterRestore, nil)
+	runner.AddHandler("upload-snapshot", doUpload, nil)
+	runner.AddHandler("remove-uploaded-snapshots", doRemoveUploaded, nil)
 
 	manager := &SnapshotManager{
 		state: st,
@@ -85,9 +100,28 @@ func Manager(st *state.State, runner *state.TaskRunner) *SnapshotManager {
 
 // Ensure is part of the overlord.StateManager interface.
 func (mgr *SnapshotManager) Ensure() error {
+	var forgetErr, chunksErr error
 	// process expired snapshots once a day.
 	if time.Now().After(mgr.lastForgetExpiredSnapshotTime.Add(autoExpirationInterval)) {
-		return mgr.forgetExpiredSnapshots()
+		forgetErr = mgr.forgetExpiredSnapshots()
+	}
+	// and cleanup the chunks forgotten snapshots leave behind
+	if time.Now().After(mgr.lastChunksCleanupTime.Add(chunksCleanupInterval)) {
+		chunksErr = mgr.cleanupUnusedChunks()
+	}
+
+	// do not exit right away on error
+	errs := []error{
+		forgetErr,
+		chunksErr,
+		mgr.ensureScheduledSnapshots(),
+		mgr.ensureScheduledSnapshotsRetention(),
+		mgr.ensureUploadedSnapshotSetsRemoval(),
+	}
+	for _, e := range errs {
+		if e != nil {
+			return e
+		}
 	}
 
 	return nil
@@ -97,9 +131,22 @@ func (mgr *SnapshotManager) StartUp() error {
 	if _, err := backendCleanupAbandonedImports(); err != nil {
 		logger.Noticef("cannot cleanup incomplete imports: %v", err)
 	}
+
+	mgr.state.Lock()
+	defer mgr.state.Unlock()
+	mgr.changeCallbackID = mgr.state.AddChangeStatusChangedHandler(dropSnapshotKeys)
+
 	return nil
 }
 
+// Stop implements StateStopper. It unregisters the change callback handler
+// from state.
+func (mgr *SnapshotManager) Stop() {
+	mgr.state.Lock()
+	defer mgr.state.Unlock()
+	mgr.state.RemoveChangeStatusChangedHandler(mgr.changeCallbackID)
+}
+
 func (mgr *SnapshotManager) forgetExpiredSnapshots() error {
 	mgr.state.Lock()
 	defer mgr.state.Unlock()
@@ -113,15 +160,21 @@ func (mgr *SnapshotManager) forgetExpiredSnapshots() error {
 		return nil
 	}
 
+	var uploaded []uint64
 	err = backendIter(context.TODO(), func(r *backend.Reader) error {
-		// forget needs to conflict with check and restore
+		// forget needs to conflict with check, restore and upload
 		if err := checkSnapshotConflict(mgr.state, r.SetID, "export-snapshot",
-			"check-snapshot", "restore-snapshot"); err != nil {
+			"check-snapshot", "restore-snapshot", "upload-snapshot"); err != nil {
 			// there is a conflict, do nothing and we will retry this set on next Ensure().
 			return nil
 		}
 		if sets[r.SetID] {
 			delete(sets, r.SetID)
+			setUploaded, err := uploadedSnapshotSets(mgr.state, r.SetID)
+			if err != nil {
+				return err
+			}
+			uploaded = append(uploaded, setUploaded...)
 			// remove from state first: in case removeSnapshotState succeeds but osRemove fails we will never attempt
 			// to automatically remove this snapshot again and will leave it on the disk (so the user can still try to remove it manually);
 			// this is better than the other way around where a failing osRemove would be retried forever because snapshot would never
@@ -139,6 +192,9 @@ func (mgr *SnapshotManager) forgetExpiredSnapshots() error {
 	if err != nil {
 		return fmt.Errorf("cannot process expired snapshots: %v", err)
 	}
+	if err := queueUploadedSnapshotSetsRemoval(mgr.state, uploaded); err != nil {
+		return err
+	}
 
 	// only reset time if there are no sets left because of conflicts
 	if len(sets) == 0 {
@@ -148,9 +204,19 @@ func (mgr *SnapshotManager) forgetExpiredSnapshots() error {
 	return nil
 }
 
+// cleanupUnusedChunks removes the chunks of chunked snapshots that are not
+// used by any snapshot anymore.
+func (mgr *SnapshotManager) cleanupUnusedChunks() error {
+	if _, err := backendCleanupUnusedChunks(context.TODO()); err != nil {
+		return err
+	}
+	mgr.lastChunksCleanupTime = time.Now()
+	return nil
+}
+
 func (SnapshotManager) affectedSnaps(t *state.Task) ([]string, error) {
-	if k := t.Kind(); k == "check-snapshot" || k == "forget-snapshot" {
-		// check and forget don't affect snaps
+	if k := t.Kind(); k == "check-snapshot" || k == "forget-snapshot" || k == "upload-snapshot" {
+		// check, forget and upload don't affect snaps
 		// (this could also be written k != save && k != restore, but it's safer this way around)
 		return nil, nil
 	}
@@ -170,6 +236,13 @@ type snapshotSetup struct {
 	Filename string                `json:"filename,omitempty"`
 	Current  snap.Revision         `json:"current"`
 	Auto     bool                  `json:"auto,omitempty"`
+	// Scheduled is set when saving a set as per snapshots.schedule
+	Scheduled bool `json:"scheduled,omitempty"`
+	// EncryptTo are the recipients to encrypt the snapshot to
+	EncryptTo []string `json:"encrypt-to,omitempty"`
+	// EncryptWithPassphrase is set when encrypting the snapshot with
+	// a passphrase, which is only kept in memory
+	EncryptWithPassphrase bool `json:"encrypt-with-passphrase,omitempty"`
 }
 
 func filename(setID uint64, si *snap.Info) string {
@@ -215,6 +288,11 @@ func prepareSave(task *state.Task) (snapshot *snapshotSetup, cur *snap.Info, cfg
 			return nil, nil, nil, err
 		}
 	}
+	if snapshot.Scheduled {
+		if err := saveScheduled(st, snapshot.SetID); err != nil {
+			return nil, nil, nil, err
+		}
+	}
 
 	return snapshot, cur, cfg, nil
 }
@@ -228,12 +306,27 @@ func doSave(task *state.Task, tomb *tomb.Tomb) error {
 
 	st.Lock()
 	opts, err := getSnapDirOpts(st, snapshot.Snap)
+	if err != nil {
+		st.Unlock()
+		return err
+	}
+	chunked, err := deduplicateSnapshots(st)
+	if err != nil {
+		st.Unlock()
+		return err
+	}
+	recipients, err := snapshotRecipients(task, snapshot)
 	st.Unlock()
 	if err != nil {
 		return err
 	}
+	if len(recipients) > 0 {
+		// encrypted archives would not deduplicate anyway
+		chunked = false
+	}
 
-	_, err = backendSave(tomb.Context(nil), snapshot.SetID, cur, cfg, snapshot.Users, snapshot.Options, opts)
+	flags := &backend.SaveFlags{Chunked: chunked, Recipients: recipients}
+	_, err = backendSave(tomb.Context(nil), snapshot.SetID, cur, cfg, snapshot.Users, snapshot.Options, opts, flags)
 	if err != nil {
 		st.Lock()
 		defer st.Unlock()
@@ -263,6 +356,8 @@ func prepareRestore(task *state.Task) (snapshot *snapshotSetup, oldCfg map[strin
 		return nil, nil, nil, fmt.Errorf("cannot open snapshot: %v", err)
 	}
 	// note given the Open succeeded, caller needs to close it when done
+	reader.Identities = snapshotIdentities(task)
+	reader.Paths = snapshot.Options
 
 	return snapshot, oldCfg, reader, nil
 }
@@ -323,18 +418,21 @@ func doRestore(task *state.Task, tomb *tomb.Tomb) error {
 		return err
 	}
 
-	raw, err := marshalSnapConfig(reader.Conf)
-	if err != nil {
-		backendRevert(restoreState)
-		return fmt.Errorf("cannot marshal saved config: %v", err)
-	}
-
 	st.Lock()
 	defer st.Unlock()
 
-	if err := configSetSnapConfig(st, snapshot.Snap, raw); err != nil {
-		backendRevert(restoreState)
-		return fmt.Errorf("cannot set snap config: %v", err)
+	// restoring only some paths leaves the configuration alone
+	if snapshot.Options == nil {
+		raw, err := marshalSnapConfig(reader.Conf)
+		if err != nil {
+			backendRevert(restoreState)
+			return fmt.Errorf("cannot marshal saved config: %v", err)
+		}
+
+		if err := configSetSnapConfig(st, snapshot.Snap, raw); err != nil {
+			backendRevert(restoreState)
+			return fmt.Errorf("cannot set snap config: %v", err)
+		}
 	}
 
 	restoreState.Config = oldCfg
@@ -422,6 +520,7 @@ func doCheck(task *state.Task, tomb *tomb.Tomb) error {
 	st := task.State()
 	st.Lock()
 	err := task.Get("snapshot-setup", &snapshot)
+	identities := snapshotIdentities(task)
 	st.Unlock()
 	if err != nil {
 		return taskGetErrMsg(task, err, "snapshot")
@@ -432,10 +531,49 @@ func doCheck(task *state.Task, tomb *tomb.Tomb) error {
 		return fmt.Errorf("cannot open snapshot: %v", err)
 	}
 	defer reader.Close()
+	reader.Identities = identities
 
 	return backendCheck(reader, tomb.Context(nil), snapshot.Users)
 }
 
+func doUpload(task *state.Task, tomb *tomb.Tomb) error {
+	var snapshot snapshotSetup
+
+	st := task.State()
+	st.Lock()
+	defer st.Unlock()
+
+	if err := task.Get("snapshot-setup", &snapshot); err != nil {
+		return taskGetErrMsg(task, err, "snapshot")
+	}
+	target, err := snapshotsTarget(st)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		task.Logf("snapshots.target was unset, keeping snapshot set #%d only locally", snapshot.SetID)
+		return nil
+	}
+	// the files are opened with the state locked so that they cannot be
+	// forgotten underneath us
+	se, err := backendNewSnapshotExport(tomb.Context(nil), snapshot.SetID)
+	if err != nil {
+		return err
+	}
+	defer se.Close()
+
+	st.Unlock()
+	err = se.Init()
+	if err == nil {
+		err = backendUpload(tomb.Context(nil), target, se)
+	}
+	st.Lock()
+	if err != nil {
+		return err
+	}
+	return markSnapshotSetUploaded(st, snapshot.SetID)
+}
+
 func doForget(task *state.Task, _ *tomb.Tomb) error {
 	// note this is also undoSave
 	st := task.State()
@@ -453,12 +591,108 @@ func doForget(task *state.Task, _ *tomb.Tomb) error {
 		return fmt.Errorf("internal error: task %s (%s) snapshot info is missing the filename", task.ID(), task.Kind())
 	}
 
+	uploaded, err := uploadedSnapshotSets(st, snapshot.SetID)
+	if err != nil {
+		return err
+	}
 	// in case it's an automatic snapshot, remove the set also from the state (automatic snapshots have just one snap per set).
 	if err := removeSnapshotState(st, snapshot.SetID); err != nil {
 		return fmt.Errorf("internal error: cannot remove state of snapshot set %d: %v", snapshot.SetID, err)
 	}
 
-	return osRemove(snapshot.Filename)
+	if err := osRemove(snapshot.Filename); err != nil {
+		return err
+	}
+	// the forget tasks of the other snaps of the set find it gone from the
+	// state already, so this is done once per set
+	return queueUploadedSnapshotSetsRemoval(st, uploaded)
+}
+
+// ensureUploadedSnapshotSetsRemoval starts a change removing the forgotten
+// snapshot sets from the snapshots target, if there are any and no such
+// change is in progress. After a change failed, the next one is only started
+// once removeUploadedRetryInterval has passed.
+func (mgr *SnapshotManager) ensureUploadedSnapshotSetsRemoval() error {
+	st := mgr.state
+	st.Lock()
+	defer st.Unlock()
+
+	pending, err := uploadedSnapshotSetsToRemove(st)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	for _, chg := range st.Changes() {
+		if chg.Kind() != removeUploadedChangeKind {
+			continue
+		}
+		if !chg.IsReady() {
+			return nil
+		}
+		if chg.Status() == state.ErrorStatus && timeNow().Before(chg.ReadyTime().Add(removeUploadedRetryInterval)) {
+			return nil
+		}
+	}
+
+	logger.Trace("ensure", "manager", "SnapshotManager", "func", "ensureUploadedSnapshotSetsRemoval")
+
+	msg := "Remove forgotten snapshot sets from the snapshots target"
+	task := st.NewTask("remove-uploaded-snapshots", msg)
+	chg := st.NewChange(removeUploadedChangeKind, msg)
+	chg.AddTask(task)
+	st.EnsureBefore(0)
+	return nil
+}
+
+func doRemoveUploaded(task *state.Task, tomb *tomb.Tomb) error {
+	st := task.State()
+	st.Lock()
+	defer st.Unlock()
+
+	pending, err := uploadedSnapshotSetsToRemove(st)
+	if err != nil {
+		return err
+	}
+	setIDs := make([]uint64, 0, len(pending))
+	for setID := range pending {
+		setIDs = append(setIDs, setID)
+	}
+	sort.Slice(setIDs, func(i, j int) bool { return setIDs[i] < setIDs[j] })
+	targets := make(map[string]backend.Target)
+	for _, targetURL := range pending {
+		if _, ok := targets[targetURL]; ok {
+			continue
+		}
+		target, err := newSnapshotsTarget(st, targetURL)
+		if err != nil {
+			return err
+		}
+		targets[targetURL] = target
+	}
+
+	// the target is not talked to with the state locked
+	st.Unlock()
+	var removed []uint64
+	var errs []error
+	for _, setID := range setIDs {
+		ctx, cancel := context.WithTimeout(tomb.Context(nil), removeUploadedTimeout)
+		err := backendRemove(ctx, targets[pending[setID]], setID)
+		cancel()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed = append(removed, setID)
+	}
+	st.Lock()
+
+	if err := uploadedSnapshotSetsRemoved(st, removed); err != nil {
+		return err
+	}
+	// the sets that could not be removed are left for the next change
+	return strutil.JoinErrors(errs...)
 }
 
 func delayedCrossMgrInit() {
@@ -466,9 +700,10 @@ func delayedCrossMgrInit() {
 	snapstate.AutomaticSnapshot = AutomaticSnapshot
 	snapstate.AutomaticSnapshotExpiration = AutomaticSnapshotExpiration
 	snapstate.EstimateSnapshotSize = EstimateSnapshotSize
+	snapstate.PruneAutomaticSnapshots = PruneAutomaticSnapshots
 }
 
-func MockBackendSave(f func(context.Context, uint64, *snap.Info, map[string]any, []string, *snap.SnapshotOptions, *dirs.SnapDirOptions) (*client.Snapshot, error)) (restore func()) {
+func MockBackendSave(f func(context.Context, uint64, *snap.Info, map[string]any, []string, *snap.SnapshotOptions, *dirs.SnapDirOptions, *backend.SaveFlags) (*client.Snapshot, error)) (restore func()) {
 	old := backendSave
 	backendSave = f
 	return func() {
@@ -20,12 +20,15 @@
 package snapshotstate_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"gopkg.in/check.v1"
@@ -35,8 +38,10 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/snapshotstate"
 	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
@@ -55,8 +60,10 @@ func (snapshotSuite) TestManager(c *check.C) {
 		"check-snapshot",
 		"cleanup-after-restore",
 		"forget-snapshot",
+		"remove-uploaded-snapshots",
 		"restore-snapshot",
 		"save-snapshot",
+		"upload-snapshot",
 	})
 }
 
@@ -116,6 +123,157 @@ func (snapshotSuite) TestEnsureForgetsSnapshots(c *check.C) {
 	c.Check(removedSnapshot, check.Matches, ".*/foo.zip")
 }
 
+func (snapshotSuite) TestEnsureForgetsSnapshotsRemovesUploaded(c *check.C) {
+	defer snapshotstate.MockOsRemove(func(string) error { return nil })()
+	defer mockFakeSnapshot(c)()
+
+	st := state.New(nil)
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+
+	defer snapshotstate.MockBackendRemove(func(ctx context.Context, target backend.Target, setID uint64) error {
+		c.Fatalf("unexpected removal of snapshot set %d from the target", setID)
+		return nil
+	})()
+
+	st.Lock()
+	setSnapshotsTarget(c, st, "file:///media/backup")
+	st.Set("snapshots", map[uint64]any{
+		1: map[string]any{"expiry-time": "2001-03-11T11:24:00Z", "uploaded": true},
+	})
+	st.Unlock()
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	// the removal from the target is left to a change of its own
+	st.Lock()
+	defer st.Unlock()
+	var pending map[uint64]string
+	c.Assert(st.Get("snapshots-to-remove", &pending), check.IsNil)
+	c.Check(pending, check.DeepEquals, map[uint64]string{1: "file:///media/backup"})
+	chgs := st.Changes()
+	c.Assert(chgs, check.HasLen, 1)
+	c.Check(chgs[0].Kind(), check.Equals, "remove-uploaded-snapshots")
+	c.Assert(chgs[0].Tasks(), check.HasLen, 1)
+	c.Check(chgs[0].Tasks()[0].Kind(), check.Equals, "remove-uploaded-snapshots")
+}
+
+func (snapshotSuite) TestEnsureRemovesUploadedRetries(c *check.C) {
+	now := time.Now()
+	defer snapshotstate.MockTimeNow(func() time.Time { return now })()
+
+	st := state.New(nil)
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+
+	// nothing to remove
+	c.Assert(mgr.Ensure(), check.IsNil)
+	st.Lock()
+	c.Check(st.Changes(), check.HasLen, 0)
+	st.Set("snapshots-to-remove", map[uint64]string{1: "file:///media/backup"})
+	st.Unlock()
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+	st.Lock()
+	c.Assert(st.Changes(), check.HasLen, 1)
+	chg := st.Changes()[0]
+	st.Unlock()
+
+	// not again while in progress
+	c.Assert(mgr.Ensure(), check.IsNil)
+	st.Lock()
+	c.Check(st.Changes(), check.HasLen, 1)
+
+	// nor right after it failed
+	chg.Tasks()[0].SetStatus(state.ErrorStatus)
+	c.Assert(chg.IsReady(), check.Equals, true)
+	st.Unlock()
+	c.Assert(mgr.Ensure(), check.IsNil)
+	st.Lock()
+	c.Check(st.Changes(), check.HasLen, 1)
+	st.Unlock()
+
+	// but once the retry interval passed
+	now = now.Add(time.Hour + time.Minute)
+	c.Assert(mgr.Ensure(), check.IsNil)
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 2)
+}
+
+func (snapshotSuite) TestDoRemoveUploaded(c *check.C) {
+	st := state.New(nil)
+
+	var removed []string
+	defer snapshotstate.MockBackendRemove(func(ctx context.Context, target backend.Target, setID uint64) error {
+		// the state is not locked while talking to the target
+		st.Lock()
+		st.Unlock()
+		c.Check(target, check.NotNil)
+		removed = append(removed, fmt.Sprintf("%d", setID))
+		if setID == 2 {
+			return errors.New("target is down")
+		}
+		return nil
+	})()
+
+	st.Lock()
+	// the target the sets were uploaded to is used, not the current one
+	setSnapshotsTarget(c, st, "file:///media/other")
+	st.Set("snapshots-to-remove", map[uint64]string{
+		1: "file:///media/backup",
+		2: "file:///media/backup",
+		3: "file:///media/old",
+	})
+	task := st.NewTask("remove-uploaded-snapshots", "...")
+	st.Unlock()
+
+	err := snapshotstate.DoRemoveUploaded(task, &tomb.Tomb{})
+	c.Check(err, check.ErrorMatches, "target is down")
+	c.Check(removed, check.DeepEquals, []string{"1", "2", "3"})
+
+	// the set which could not be removed is kept for the next change
+	st.Lock()
+	var pending map[uint64]string
+	c.Assert(st.Get("snapshots-to-remove", &pending), check.IsNil)
+	c.Check(pending, check.DeepEquals, map[uint64]string{2: "file:///media/backup"})
+	st.Unlock()
+
+	removed = nil
+	defer snapshotstate.MockBackendRemove(func(ctx context.Context, target backend.Target, setID uint64) error {
+		removed = append(removed, fmt.Sprintf("%d", setID))
+		return nil
+	})()
+	c.Assert(snapshotstate.DoRemoveUploaded(task, &tomb.Tomb{}), check.IsNil)
+	c.Check(removed, check.DeepEquals, []string{"2"})
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Get("snapshots-to-remove", &pending), testutil.ErrorIs, state.ErrNoState)
+}
+
+func (snapshotSuite) TestEnsureCleansUpUnusedChunksRegularly(c *check.C) {
+	var calls int
+	defer snapshotstate.MockBackendCleanupUnusedChunks(func(context.Context) (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errors.New("bzzt")
+		}
+		return 1, nil
+	})()
+
+	st := state.New(nil)
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+
+	// retried on next Ensure after an error
+	c.Check(mgr.Ensure(), check.ErrorMatches, "bzzt")
+	c.Check(calls, check.Equals, 1)
+	c.Check(mgr.Ensure(), check.IsNil)
+	c.Check(calls, check.Equals, 2)
+
+	// but then only once a day
+	c.Check(mgr.Ensure(), check.IsNil)
+	c.Check(calls, check.Equals, 2)
+}
+
 func (snapshotSuite) TestEnsureForgetsSnapshotsRunsRegularly(c *check.C) {
 	var backendIterCalls int
 	shotfile, err := os.Create(filepath.Join(c.MkDir(), "foo.zip"))
@@ -249,6 +407,10 @@ func (s *snapshotSuite) TestEnsureForgetSnapshotsConflictWithExportSnapshot(c *c
 	s.testEnsureForgetSnapshotsConflict(c, "export-snapshot")
 }
 
+func (s *snapshotSuite) TestEnsureForgetSnapshotsConflictWithUploadSnapshot(c *check.C) {
+	s.testEnsureForgetSnapshotsConflict(c, "upload-snapshot")
+}
+
 func (snapshotSuite) TestFilename(c *check.C) {
 	si := &snap.Info{
 		SideInfo: snap.SideInfo{
@@ -282,7 +444,7 @@ func (snapshotSuite) TestDoSave(c *check.C) {
 
 	expectedOptions := &snap.SnapshotOptions{}
 	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string,
-		options *snap.SnapshotOptions, _ *dirs.SnapDirOptions) (*client.Snapshot, error) {
+		options *snap.SnapshotOptions, _ *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		c.Check(id, check.Equals, uint64(42))
 		c.Check(si, check.DeepEquals, &snapInfo)
 		c.Check(cfg, check.DeepEquals, map[string]any{"hello": "there"})
@@ -338,7 +500,7 @@ func (snapshotSuite) TestDoSaveGetsSnapDirOpts(c *check.C) {
 	})()
 
 	var checkOpts bool
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, opts *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, opts *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		c.Check(opts.HiddenSnapDataDir, check.Equals, true)
 		checkOpts = true
 		return nil, nil
@@ -357,12 +519,43 @@ func (snapshotSuite) TestDoSaveGetsSnapDirOpts(c *check.C) {
 	c.Check(checkOpts, check.Equals, true)
 }
 
+func (snapshotSuite) TestDoSaveChunked(c *check.C) {
+	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
+		return &snap.Info{SideInfo: snap.SideInfo{RealName: "a-snap", Revision: snap.R(1)}, Version: "1.33"}, nil
+	})()
+	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
+
+	var chunked []bool
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, _ *dirs.SnapDirOptions, flags *backend.SaveFlags) (*client.Snapshot, error) {
+		c.Assert(flags, check.NotNil)
+		chunked = append(chunked, flags.Chunked)
+		return nil, nil
+	})()
+
+	st := state.New(nil)
+	for _, deduplicate := range []any{nil, false, true} {
+		st.Lock()
+		tr := config.NewTransaction(st)
+		tr.Set("core", "snapshots.deduplicate", deduplicate)
+		tr.Commit()
+		task := st.NewTask("save-snapshot", "...")
+		task.Set("snapshot-setup", map[string]any{
+			"set-id": 42,
+			"snap":   "a-snap",
+		})
+		st.Unlock()
+
+		c.Assert(snapshotstate.DoSave(task, &tomb.Tomb{}), check.IsNil)
+	}
+	c.Check(chunked, check.DeepEquals, []bool{false, false, true})
+}
+
 func (snapshotSuite) TestDoSaveFailsWithNoSnap(c *check.C) {
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
 		return nil, errors.New("bzzt")
 	})()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -389,7 +582,7 @@ func (snapshotSuite) TestDoSaveFailsWithNoSnapshot(c *check.C) {
 	}
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) { return &snapInfo, nil })()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -413,7 +606,7 @@ func (snapshotSuite) TestDoSaveFailsBackendError(c *check.C) {
 	}
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) { return &snapInfo, nil })()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, errors.New("bzzt")
 	})()
 
@@ -442,7 +635,7 @@ func (snapshotSuite) TestDoSaveFailsConfigError(c *check.C) {
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) {
 		return nil, errors.New("bzzt")
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -473,7 +666,7 @@ func (snapshotSuite) TestDoSaveFailsBadConfig(c *check.C) {
 		buf := json.RawMessage(`"hello-there"`)
 		return &buf, nil
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -506,7 +699,7 @@ func (snapshotSuite) TestDoSaveFailureRemovesStateEntry(c *check.C) {
 	defer snapshotstate.MockConfigGetSnapConfig(func(_ *state.State, snapname string) (*json.RawMessage, error) {
 		return nil, nil
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
 		var expirations map[uint64]any
 		st.Lock()
 		defer st.Unlock()
@@ -639,6 +832,28 @@ func (rs *readerSuite) TestDoRestore(c *check.C) {
 	c.Check(v, check.DeepEquals, map[string]any{"config": map[string]any{"old": "conf"}})
 }
 
+func (rs *readerSuite) TestDoRestorePaths(c *check.C) {
+	st := rs.task.State()
+	st.Lock()
+	rs.task.Set("snapshot-setup", map[string]any{
+		"snap":     "a-snap",
+		"filename": "/some/1_file.zip",
+		"options":  map[string]any{"include": []string{"$SNAP_USER_DATA/.config"}},
+	})
+	st.Unlock()
+
+	defer snapshotstate.MockBackendRestore(func(r *backend.Reader, _ context.Context, _ snap.Revision, _ []string, _ backend.Logf, _ *dirs.SnapDirOptions) (*backend.RestoreState, error) {
+		rs.calls = append(rs.calls, "restore")
+		c.Check(r.Paths, check.DeepEquals, &snap.SnapshotOptions{Include: []string{"$SNAP_USER_DATA/.config"}})
+		return &backend.RestoreState{}, nil
+	})()
+
+	err := snapshotstate.DoRestore(rs.task, &tomb.Tomb{})
+	c.Assert(err, check.IsNil)
+	// the configuration is left alone
+	c.Check(rs.calls, check.DeepEquals, []string{"get config", "open", "restore"})
+}
+
 func (rs *readerSuite) TestDoRestoreNoConfig(c *check.C) {
 	defer snapshotstate.MockConfigGetSnapConfig(func(_ *state.State, snapname string) (*json.RawMessage, error) {
 		rs.calls = append(rs.calls, "get config")
@@ -886,6 +1101,76 @@ func (rs *readerSuite) TestDoForgetRemovesAutomaticSnapshotExpiry(c *check.C) {
 		}})
 }
 
+func (rs *readerSuite) TestDoForgetRemovesUploadedSet(c *check.C) {
+	defer snapshotstate.MockOsRemove(func(filename string) error {
+		rs.calls = append(rs.calls, "remove")
+		return nil
+	})()
+	defer snapshotstate.MockBackendRemove(func(ctx context.Context, target backend.Target, setID uint64) error {
+		c.Fatalf("unexpected removal of snapshot set %d from the target", setID)
+		return nil
+	})()
+
+	st := rs.task.State()
+	st.Lock()
+	setSnapshotsTarget(c, st, "file:///media/backup")
+	rs.task.Set("snapshot-setup", map[string]any{
+		"set-id":   1,
+		"snap":     "a-snap",
+		"filename": "/some/1_file.zip",
+	})
+	st.Set("snapshots", map[uint64]any{
+		1: map[string]any{"uploaded": true},
+	})
+	st.Unlock()
+
+	// the removal from the target is only recorded
+	c.Assert(snapshotstate.DoForget(rs.task, &tomb.Tomb{}), check.IsNil)
+	c.Check(rs.calls, check.DeepEquals, []string{"remove"})
+
+	st.Lock()
+	var pending map[uint64]string
+	c.Assert(st.Get("snapshots-to-remove", &pending), check.IsNil)
+	c.Check(pending, check.DeepEquals, map[uint64]string{1: "file:///media/backup"})
+	st.Unlock()
+
+	// the other tasks of the set do not record it again
+	rs.calls = nil
+	st.Lock()
+	st.Set("snapshots-to-remove", nil)
+	st.Unlock()
+	c.Assert(snapshotstate.DoForget(rs.task, &tomb.Tomb{}), check.IsNil)
+	c.Check(rs.calls, check.DeepEquals, []string{"remove"})
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Get("snapshots-to-remove", &pending), testutil.ErrorIs, state.ErrNoState)
+}
+
+func (rs *readerSuite) TestDoForgetUploadedSetTargetUnset(c *check.C) {
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	st := rs.task.State()
+	st.Lock()
+	rs.task.Set("snapshot-setup", map[string]any{
+		"set-id":   1,
+		"snap":     "a-snap",
+		"filename": "/some/1_file.zip",
+	})
+	st.Set("snapshots", map[uint64]any{
+		1: map[string]any{"uploaded": true},
+	})
+	st.Unlock()
+
+	c.Assert(snapshotstate.DoForget(rs.task, &tomb.Tomb{}), check.IsNil)
+	c.Check(logbuf.String(), testutil.Contains, "snapshots.target was unset, keeping forgotten snapshot sets [1] in the previous target")
+
+	st.Lock()
+	defer st.Unlock()
+	var pending map[uint64]string
+	c.Check(st.Get("snapshots-to-remove", &pending), testutil.ErrorIs, state.ErrNoState)
+}
+
 func (snapshotSuite) TestManagerRunCleanupAbandonedImportsAtStartup(c *check.C) {
 	n := 0
 	restore := snapshotstate.MockBackendCleanupAbandonedImports(func() (int, error) {
@@ -927,3 +1212,133 @@ func (snapshotSuite) TestManagerRunCleanupAbandonedImportsAtStartupErrorLogged(c
 	c.Check(n, check.Equals, 1)
 	c.Check(logbuf.String(), testutil.Contains, "cannot cleanup incomplete imports: some error\n")
 }
+
+func (snapshotSuite) TestDoUpload(c *check.C) {
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "a-snap", Revision: snap.R(1)}, Version: "v1"}
+	c.Assert(os.MkdirAll(info.DataDir(), 0755), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(info.DataDir(), "canary"), []byte("hello"), 0644), check.IsNil)
+	_, err := backend.Save(context.TODO(), 12, info, nil, nil, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	st := state.New(nil)
+	st.Lock()
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "snapshots.target", "file:///media/backup"), check.IsNil)
+	tr.Commit()
+	task := st.NewTask("upload-snapshot", "...")
+	task.Set("snapshot-setup", map[string]any{"set-id": 12})
+	st.Unlock()
+
+	var uploaded int
+	defer snapshotstate.MockBackendUpload(func(ctx context.Context, target backend.Target, se *snapshotstate.SnapshotExport) error {
+		uploaded++
+		// the state is not locked while uploading
+		st.Lock()
+		st.Unlock()
+		c.Check(target, check.NotNil)
+		c.Check(se.Size() > 0, check.Equals, true)
+		var buf bytes.Buffer
+		c.Check(se.StreamTo(&buf), check.IsNil)
+		c.Check(buf.Len(), check.Equals, int(se.Size()))
+		return nil
+	})()
+
+	c.Assert(snapshotstate.DoUpload(task, &tomb.Tomb{}), check.IsNil)
+	c.Check(uploaded, check.Equals, 1)
+
+	// the set is known to be uploaded
+	st.Lock()
+	defer st.Unlock()
+	var snapshots map[uint64]any
+	c.Assert(st.Get("snapshots", &snapshots), check.IsNil)
+	c.Check(snapshots, check.DeepEquals, map[uint64]any{
+		12: map[string]any{"expiry-time": "0001-01-01T00:00:00Z", "uploaded": true},
+	})
+}
+
+func (snapshotSuite) TestUploadFailureKeepsLocalSet(c *check.C) {
+	defer snapshotstate.MockSnapstateAll(func(*state.State) (map[string]*snapstate.SnapState, error) {
+		return map[string]*snapstate.SnapState{"a-snap": {Active: true}}, nil
+	})()
+	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
+		return &snap.Info{SideInfo: snap.SideInfo{RealName: "a-snap", Revision: snap.R(1)}, Version: "v1"}, nil
+	})()
+	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]any, usernames []string, _ *snap.SnapshotOptions, _ *dirs.SnapDirOptions, _ *backend.SaveFlags) (*client.Snapshot, error) {
+		return &client.Snapshot{SetID: id, Snap: si.InstanceName()}, nil
+	})()
+	defer snapshotstate.MockBackendNewSnapshotExport(func(ctx context.Context, setID uint64) (*snapshotstate.SnapshotExport, error) {
+		return nil, errors.New("target is down")
+	})()
+	var removed []string
+	defer snapshotstate.MockOsRemove(func(filename string) error {
+		removed = append(removed, filename)
+		return nil
+	})()
+
+	o := overlord.Mock()
+	st := o.State()
+	o.AddManager(snapshotstate.Manager(st, o.TaskRunner()))
+	o.AddManager(o.TaskRunner())
+
+	st.Lock()
+	defer st.Unlock()
+	setSnapshotsTarget(c, st, "file:///media/backup")
+	_, _, ts, err := snapshotstate.Save(st, nil, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+	chg := st.NewChange("save-snapshot", "...")
+	chg.AddAll(ts)
+
+	st.Unlock()
+	c.Assert(o.Settle(5*time.Second), check.IsNil)
+	st.Lock()
+
+	c.Check(chg.Err(), check.ErrorMatches, `(?s).*target is down.*`)
+	for _, t := range chg.Tasks() {
+		switch t.Kind() {
+		case "save-snapshot":
+			c.Check(t.Status(), check.Equals, state.DoneStatus)
+		case "upload-snapshot":
+			c.Check(t.Status(), check.Equals, state.ErrorStatus)
+		}
+	}
+	// the local snapshot was not forgotten
+	c.Check(removed, check.HasLen, 0)
+}
+
+func (snapshotSuite) TestDoUploadTargetUnset(c *check.C) {
+	defer snapshotstate.MockBackendUpload(func(context.Context, backend.Target, *snapshotstate.SnapshotExport) error {
+		c.Fatal("unexpected upload")
+		return nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+	task := st.NewTask("upload-snapshot", "...")
+	task.Set("snapshot-setup", map[string]any{"set-id": 12})
+
+	st.Unlock()
+	err := snapshotstate.DoUpload(task, &tomb.Tomb{})
+	st.Lock()
+	c.Assert(err, check.IsNil)
+	c.Check(strings.Join(task.Log(), "\n"), testutil.Contains, "snapshots.target was unset, keeping snapshot set #12 only locally")
+}
+
+func (snapshotSuite) TestDoUploadFailsExportError(c *check.C) {
+	defer snapshotstate.MockBackendNewSnapshotExport(func(ctx context.Context, setID uint64) (*snapshotstate.SnapshotExport, error) {
+		return nil, errors.New("bzzt")
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "snapshots.target", "file:///media/backup"), check.IsNil)
+	tr.Commit()
+	task := st.NewTask("upload-snapshot", "...")
+	task.Set("snapshot-setup", map[string]any{"set-id": 12})
+	st.Unlock()
+
+	err := snapshotstate.DoUpload(task, &tomb.Tomb{})
+	c.Assert(err, check.ErrorMatches, "bzzt")
+}
@@ -25,17 +25,21 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"sort"
 	"time"
 
 	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/httputil"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/configstate/proxyconf"
 	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snapdenv"
 	"github.com/snapcore/snapd/strutil"
 )
 
@@ -46,6 +50,11 @@ var (
 	backendEstimateSnapshotSize      = backend.EstimateSnapshotSize
 	backendList                      = backend.List
 	backendNewSnapshotExport         = backend.NewSnapshotExport
+	backendNewTarget                 = backend.NewTarget
+	backendUpload                    = backend.Upload
+	backendDownload                  = backend.Download
+	backendRemove                    = backend.Remove
+	httputilNewHTTPClient            = httputil.NewHTTPClient
 
 	// Default expiration time for automatic snapshots, if not set by the user
 	defaultAutomaticSnapshotExpiration = time.Hour * 24 * 31
@@ -53,8 +62,26 @@ var (
 
 type snapshotState struct {
 	ExpiryTime time.Time `json:"expiry-time"`
+	// Scheduled is set for sets taken as per snapshots.schedule, which
+	// are subject to the scheduled snapshots retention policy.
+	Scheduled bool `json:"scheduled,omitempty"`
+	// Uploaded is set for sets that were uploaded to the snapshots target,
+	// and need to be removed from there as well when forgotten.
+	Uploaded bool `json:"uploaded,omitempty"`
 }
 
+const removeUploadedChangeKind = "remove-uploaded-snapshots"
+
+var (
+	// removeUploadedTimeout bounds the time spent removing each forgotten
+	// snapshot set from the snapshots target.
+	removeUploadedTimeout = 30 * time.Second
+	// removeUploadedRetryInterval is the time to wait before trying again
+	// to remove forgotten snapshot sets from the snapshots target, after
+	// failing to.
+	removeUploadedRetryInterval = time.Hour
+)
+
 func newSnapshotSetID(st *state.State) (uint64, error) {
 	var lastDiskSetID, lastStateSetID uint64
 
@@ -151,9 +178,132 @@ func AutomaticSnapshotExpiration(st *state.State) (time.Duration, error) {
 	return defaultAutomaticSnapshotExpiration, nil
 }
 
+// PruneAutomaticSnapshots forgets automatic snapshot sets, oldest first, until
+// at least target bytes were freed. Sets used by other snapshot operations are
+// skipped. It returns the number of sets forgotten and the bytes freed.
+// The state must be locked by the caller.
+func PruneAutomaticSnapshots(st *state.State, target uint64) (sets int, freed uint64, err error) {
+	var snapshots map[uint64]*snapshotState
+	if err := st.Get("snapshots", &snapshots); err != nil && !errors.Is(err, state.ErrNoState) {
+		return 0, 0, err
+	}
+
+	taken := make(map[uint64]time.Time)
+	sizes := make(map[uint64]uint64)
+	filenames := make(map[uint64][]string)
+	err = backendIter(context.TODO(), func(r *backend.Reader) error {
+		if snapshotSt, ok := snapshots[r.SetID]; !ok || snapshotSt.ExpiryTime.IsZero() {
+			// not an automatic snapshot
+			return nil
+		}
+		if t, ok := taken[r.SetID]; !ok || r.Time.Before(t) {
+			taken[r.SetID] = r.Time
+		}
+		sizes[r.SetID] += uint64(r.Size)
+		filenames[r.SetID] = append(filenames[r.SetID], r.Name())
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot prune automatic snapshots: %v", err)
+	}
+
+	setIDs := make([]uint64, 0, len(taken))
+	for setID := range taken {
+		setIDs = append(setIDs, setID)
+	}
+	sort.Slice(setIDs, func(i, j int) bool {
+		ti, tj := taken[setIDs[i]], taken[setIDs[j]]
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return setIDs[i] < setIDs[j]
+	})
+
+	for _, setID := range setIDs {
+		if freed >= target {
+			break
+		}
+		// same conflicts as forget
+		if err := checkSnapshotConflict(st, setID, "export-snapshot",
+			"check-snapshot", "restore-snapshot", "upload-snapshot"); err != nil {
+			continue
+		}
+		// see forgetExpiredSnapshots about the ordering
+		if err := removeSnapshotState(st, setID); err != nil {
+			return sets, freed, fmt.Errorf("internal error: cannot remove state of snapshot set %d: %v", setID, err)
+		}
+		for _, filename := range filenames[setID] {
+			if err := osRemove(filename); err != nil {
+				return sets, freed, fmt.Errorf("cannot remove snapshot file %q: %v", filename, err)
+			}
+		}
+		logger.Noticef("Forgot automatic snapshot set #%d to free disk space.", setID)
+		sets++
+		freed += sizes[setID]
+	}
+	return sets, freed, nil
+}
+
+// deduplicateSnapshots returns whether snapshots are to be saved in the chunk
+// store, as per the snapshots.deduplicate option.
+func deduplicateSnapshots(st *state.State) (bool, error) {
+	var deduplicate bool
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "snapshots.deduplicate", &deduplicate); err != nil && !config.IsNoOption(err) {
+		return false, err
+	}
+	return deduplicate, nil
+}
+
+// snapshotsTarget returns the target configured via snapshots.target, or nil
+// if snapshot sets are only kept locally.
+// The state must be locked by the caller.
+func snapshotsTarget(st *state.State) (backend.Target, error) {
+	targetURL, err := snapshotsTargetURL(st)
+	if err != nil || targetURL == "" {
+		return nil, err
+	}
+	return newSnapshotsTarget(st, targetURL)
+}
+
+// snapshotsTargetURL returns the URL of the target configured via
+// snapshots.target, or an empty string if snapshot sets are only kept
+// locally.
+// The state must be locked by the caller.
+func snapshotsTargetURL(st *state.State) (string, error) {
+	var targetURL string
+	tr := config.NewTransaction(st)
+	err := tr.Get("core", "snapshots.target", &targetURL)
+	if err != nil && !config.IsNoOption(err) {
+		return "", err
+	}
+	return targetURL, nil
+}
+
+// newSnapshotsTarget returns the target with the given URL.
+// The state must be locked by the caller.
+func newSnapshotsTarget(st *state.State, targetURL string) (backend.Target, error) {
+	proxyConf := proxyconf.New(st)
+	client := httputilNewHTTPClient(&httputil.ClientOptions{
+		Proxy:              proxyConf.Conf,
+		ProxyConnectHeader: http.Header{"User-Agent": []string{snapdenv.UserAgent()}},
+	})
+	return backendNewTarget(backend.TargetURLWithCredentials(targetURL), client)
+}
+
 // saveExpiration saves expiration date of the given snapshot set, in the state.
 // The state needs to be locked by the caller.
 func saveExpiration(st *state.State, setID uint64, expiryTime time.Time) error {
+	return saveSnapshotState(st, setID, &snapshotState{ExpiryTime: expiryTime})
+}
+
+// saveScheduled records the given snapshot set as a scheduled one, in the
+// state. The state needs to be locked by the caller.
+func saveScheduled(st *state.State, setID uint64) error {
+	return saveSnapshotState(st, setID, &snapshotState{Scheduled: true})
+}
+
+func saveSnapshotState(st *state.State, setID uint64, snapshotSt *snapshotState) error {
 	var snapshots map[uint64]*json.RawMessage
 	err := st.Get("snapshots", &snapshots)
 	if err != nil && !errors.Is(err, state.ErrNoState) {
@@ -162,9 +312,7 @@ func saveExpiration(st *state.State, setID uint64, expiryTime time.Time) error {
 	if snapshots == nil {
 		snapshots = make(map[uint64]*json.RawMessage)
 	}
-	data, err := json.Marshal(&snapshotState{
-		ExpiryTime: expiryTime,
-	})
+	data, err := json.Marshal(snapshotSt)
 	if err != nil {
 		return err
 	}
@@ -174,6 +322,103 @@ func saveExpiration(st *state.State, setID uint64, expiryTime time.Time) error {
 	return nil
 }
 
+// markSnapshotSetUploaded records in the state that the given snapshot set was
+// uploaded to the snapshots target.
+// The state needs to be locked by the caller.
+func markSnapshotSetUploaded(st *state.State, setID uint64) error {
+	var snapshots map[uint64]*snapshotState
+	if err := st.Get("snapshots", &snapshots); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	snapshotSt := snapshots[setID]
+	if snapshotSt == nil {
+		snapshotSt = &snapshotState{}
+	}
+	snapshotSt.Uploaded = true
+	return saveSnapshotState(st, setID, snapshotSt)
+}
+
+// uploadedSnapshotSets returns which of the given snapshot sets were uploaded
+// to the snapshots target.
+// The state needs to be locked by the caller.
+func uploadedSnapshotSets(st *state.State, setIDs ...uint64) ([]uint64, error) {
+	var snapshots map[uint64]*snapshotState
+	if err := st.Get("snapshots", &snapshots); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	var uploaded []uint64
+	for _, setID := range setIDs {
+		if snapshotSt := snapshots[setID]; snapshotSt != nil && snapshotSt.Uploaded {
+			uploaded = append(uploaded, setID)
+		}
+	}
+	return uploaded, nil
+}
+
+// queueUploadedSnapshotSetsRemoval records in the state that the given
+// forgotten snapshot sets need to be removed from the snapshots target. This
+// is done by a change of its own, see ensureUploadedSnapshotSetsRemoval, so
+// that a slow or unreachable target does not hold up forgetting snapshots
+// and failed removals are retried.
+// The state needs to be locked by the caller.
+func queueUploadedSnapshotSetsRemoval(st *state.State, setIDs []uint64) error {
+	if len(setIDs) == 0 {
+		return nil
+	}
+	targetURL, err := snapshotsTargetURL(st)
+	if err != nil {
+		return err
+	}
+	if targetURL == "" {
+		logger.Noticef("snapshots.target was unset, keeping forgotten snapshot sets %v in the previous target", setIDs)
+		return nil
+	}
+	pending, err := uploadedSnapshotSetsToRemove(st)
+	if err != nil {
+		return err
+	}
+	if pending == nil {
+		pending = make(map[uint64]string, len(setIDs))
+	}
+	for _, setID := range setIDs {
+		pending[setID] = targetURL
+	}
+	st.Set("snapshots-to-remove", pending)
+	st.EnsureBefore(0)
+	return nil
+}
+
+// uploadedSnapshotSetsToRemove returns the forgotten snapshot sets that still
+// need to be removed from the snapshots target, with the URL of the target
+// they were uploaded to.
+// The state needs to be locked by the caller.
+func uploadedSnapshotSetsToRemove(st *state.State) (map[uint64]string, error) {
+	var pending map[uint64]string
+	if err := st.Get("snapshots-to-remove", &pending); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// uploadedSnapshotSetsRemoved records in the state that the given snapshot
+// sets were removed from the snapshots target.
+// The state needs to be locked by the caller.
+func uploadedSnapshotSetsRemoved(st *state.State, setIDs []uint64) error {
+	pending, err := uploadedSnapshotSetsToRemove(st)
+	if err != nil {
+		return err
+	}
+	for _, setID := range setIDs {
+		delete(pending, setID)
+	}
+	if len(pending) == 0 {
+		st.Set("snapshots-to-remove", nil)
+		return nil
+	}
+	st.Set("snapshots-to-remove", pending)
+	return nil
+}
+
 // removeSnapshotState removes given set IDs from the state.
 func removeSnapshotState(st *state.State, setIDs ...uint64) error {
 	var snapshots map[uint64]*json.RawMessage
@@ -207,6 +452,10 @@ func expiredSnapshotSets(st *state.State, cutoffTime time.Time) (map[uint64]bool
 
 	expired := make(map[uint64]bool)
 	for setID, snapshotSet := range snapshots {
+		if snapshotSet.ExpiryTime.IsZero() {
+			// not an automatic snapshot
+			continue
+		}
 		if snapshotSet.ExpiryTime.Before(cutoffTime) {
 			expired[setID] = true
 		}
@@ -228,10 +477,11 @@ func (summaries snapshotSnapSummaries) snapNames() []string {
 }
 
 type snapshotSnapSummary struct {
-	snap     string
-	snapID   string
-	filename string
-	epoch    snap.Epoch
+	snap            string
+	snapID          string
+	filename        string
+	epoch           snap.Epoch
+	keyFingerprints []string
 }
 
 // snapSummariesInSnapshotSet goes looking for the requested snaps in the
@@ -244,10 +494,11 @@ func snapSummariesInSnapshotSet(setID uint64, requested []string) (summaries sna
 			found = true
 			if len(requested) == 0 || strutil.SortedListContains(requested, r.Snap) {
 				summaries = append(summaries, &snapshotSnapSummary{
-					filename: r.Name(),
-					snap:     r.Snap,
-					snapID:   r.SnapID,
-					epoch:    r.Epoch,
+					filename:        r.Name(),
+					snap:            r.Snap,
+					snapID:          r.SnapID,
+					epoch:           r.Epoch,
+					keyFingerprints: r.KeyFingerprints,
 				})
 			}
 		}
@@ -364,10 +615,12 @@ func Import(ctx context.Context, st *state.State, r io.Reader) (setID uint64, sn
 			}
 
 			// trying to import identical snapshot; instead return set ID of
-			// the existing one and reset its expiry time.
-			// XXX: at the moment expiry-time is the only attribute so we can
-			// just remove the record. If we ever add more attributes this needs
-			// to reset expiry-time only.
+			// the existing one and reset its expiry time. This also
+			// exempts a scheduled set from the retention policy, which
+			// is fine as it was explicitly imported.
+			// XXX: the record only carries attributes that an explicit
+			// import should reset, so we can just remove it. If we ever
+			// add more attributes this needs to reset those only.
 			if err := removeSnapshotState(st, dupErr.SetID); err != nil {
 				return 0, nil, err
 			}
@@ -380,7 +633,20 @@ func Import(ctx context.Context, st *state.State, r io.Reader) (setID uint64, sn
 
 // Save creates a taskset for taking snapshots of snaps' data.
 // Note that the state must be locked by the caller.
-func Save(st *state.State, instanceNames []string, users []string, options map[string]*snap.SnapshotOptions) (setID uint64, snapsSaved []string, ts *state.TaskSet, err error) {
+// If enc is not nil, the snapshots are encrypted to its recipients or
+// passphrase.
+func Save(st *state.State, instanceNames []string, users []string, options map[string]*snap.SnapshotOptions, enc *Encryption) (setID uint64, snapsSaved []string, ts *state.TaskSet, err error) {
+	return save(st, instanceNames, users, options, enc, false)
+}
+
+func save(st *state.State, instanceNames []string, users []string, options map[string]*snap.SnapshotOptions, enc *Encryption, scheduled bool) (setID uint64, snapsSaved []string, ts *state.TaskSet, err error) {
+	if enc != nil {
+		// fail early on bad keys
+		if _, err := enc.recipients(); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
 	if len(instanceNames) == 0 {
 		instanceNames, err = allActiveSnapNames(st)
 		if err != nil {
@@ -409,6 +675,11 @@ func Save(st *state.State, instanceNames []string, users []string, options map[s
 		return 0, nil, nil, err
 	}
 
+	var keys *snapshotKeys
+	if enc != nil && enc.Passphrase != "" {
+		keys = &snapshotKeys{passphrase: enc.Passphrase}
+	}
+
 	ts = state.NewTaskSet()
 
 	for _, name := range instanceNames {
@@ -416,10 +687,18 @@ func Save(st *state.State, instanceNames []string, users []string, options map[s
 		task := st.NewTask("save-snapshot", desc)
 
 		snapshot := snapshotSetup{
-			SetID:   setID,
-			Snap:    name,
-			Users:   users,
-			Options: options[name],
+			SetID:     setID,
+			Snap:      name,
+			Users:     users,
+			Options:   options[name],
+			Scheduled: scheduled,
+		}
+		if enc != nil {
+			snapshot.EncryptTo = enc.Recipients
+			snapshot.EncryptWithPassphrase = enc.Passphrase != ""
+		}
+		if keys != nil {
+			setTaskSnapshotKeys(task, keys)
 		}
 
 		task.Set("snapshot-setup", &snapshot)
@@ -437,6 +716,21 @@ func Save(st *state.State, instanceNames []string, users []string, options map[s
 		ts.AddTask(task)
 	}
 
+	target, err := snapshotsTarget(st)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if target != nil && len(instanceNames) > 0 {
+		desc := fmt.Sprintf("Upload snapshot set #%d", setID)
+		task := st.NewTask("upload-snapshot", desc)
+		task.Set("snapshot-setup", &snapshotSetup{SetID: setID})
+		task.WaitAll(ts)
+		// the upload is in a lane of its own so that failing to reach
+		// the target does not undo the saves, the local copy is kept
+		task.JoinLane(st.NewLane())
+		ts.AddTask(task)
+	}
+
 	return setID, instanceNames, ts, nil
 }
 
@@ -469,7 +763,22 @@ func AutomaticSnapshot(st *state.State, snapName string) (ts *state.TaskSet, err
 
 // Restore creates a taskset for restoring a snapshot's data.
 // Note that the state must be locked by the caller.
-func Restore(st *state.State, setID uint64, snapNames []string, users []string) (snapsFound []string, ts *state.TaskSet, err error) {
+//
+// If options include or exclude paths, only the matching data is restored,
+// on top of the current one, and the snap configuration is left alone.
+//
+// Encrypted snapshots need one of the keys they are encrypted to, either
+// given via enc or kept on the device; DecryptionKeyRequiredError is
+// returned otherwise.
+func Restore(st *state.State, setID uint64, snapNames []string, users []string, options *snap.SnapshotOptions, enc *Encryption) (snapsFound []string, ts *state.TaskSet, err error) {
+	if options != nil {
+		if err := options.Validate(); err != nil {
+			return nil, nil, err
+		}
+		if options.Unset() {
+			options = nil
+		}
+	}
 	summaries, err := snapSummariesInSnapshotSet(setID, snapNames)
 	if err != nil {
 		return nil, nil, err
@@ -490,6 +799,11 @@ func Restore(st *state.State, setID uint64, snapNames []string, users []string)
 		return nil, nil, err
 	}
 
+	keys, err := prepareDecryption(setID, summaries, enc)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	ts = state.NewTaskSet()
 
 	for _, summary := range summaries {
@@ -517,10 +831,14 @@ func Restore(st *state.State, setID uint64, snapNames []string, users []string)
 			SetID:    setID,
 			Snap:     summary.snap,
 			Users:    users,
+			Options:  options,
 			Filename: summary.filename,
 			Current:  current,
 		}
 		task.Set("snapshot-setup", &snapshot)
+		if keys != nil {
+			setTaskSnapshotKeys(task, keys)
+		}
 		// see the note about snapshots not using lanes, above.
 		ts.AddTask(task)
 	}
@@ -540,7 +858,9 @@ func Restore(st *state.State, setID uint64, snapNames []string, users []string)
 
 // Check creates a taskset for checking a snapshot's data.
 // Note that the state must be locked by the caller.
-func Check(st *state.State, setID uint64, snapNames []string, users []string) (snapsFound []string, ts *state.TaskSet, err error) {
+//
+// As for Restore, encrypted snapshots need one of their keys.
+func Check(st *state.State, setID uint64, snapNames []string, users []string, enc *Encryption) (snapsFound []string, ts *state.TaskSet, err error) {
 	// check needs to conflict with forget of itself
 	if err := checkSnapshotConflict(st, setID, "forget-snapshot"); err != nil {
 		return nil, nil, err
@@ -551,6 +871,11 @@ func Check(st *state.State, setID uint64, snapNames []string, users []string) (s
 		return nil, nil, err
 	}
 
+	keys, err := prepareDecryption(setID, summaries, enc)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	ts = state.NewTaskSet()
 
 	for _, summary := range summaries {
@@ -563,6 +888,9 @@ func Check(st *state.State, setID uint64, snapNames []string, users []string) (s
 			Filename: summary.filename,
 		}
 		task.Set("snapshot-setup", &snapshot)
+		if keys != nil {
+			setTaskSnapshotKeys(task, keys)
+		}
 		ts.AddTask(task)
 	}
 
@@ -572,9 +900,9 @@ func Check(st *state.State, setID uint64, snapNames []string, users []string) (s
 // Forget creates a taskset for deletinig a snapshot.
 // Note that the state must be locked by the caller.
 func Forget(st *state.State, setID uint64, snapNames []string) (snapsFound []string, ts *state.TaskSet, err error) {
-	// forget needs to conflict with check, restore, import and export.
+	// forget needs to conflict with check, restore, import, export and upload.
 	if err := checkSnapshotConflict(st, setID, "export-snapshot",
-		"check-snapshot", "restore-snapshot"); err != nil {
+		"check-snapshot", "restore-snapshot", "upload-snapshot"); err != nil {
 		return nil, nil, err
 	}
 
@@ -645,3 +973,38 @@ func Export(ctx context.Context, st *state.State, setID uint64) (se *backend.Sna
 
 // SnapshotExport provides a snapshot export that can be streamed out
 type SnapshotExport = backend.SnapshotExport
+
+// Fetch retrieves the given snapshot set from the configured snapshots
+// target and stores it locally under the same set ID, so that it can then be
+// restored. It returns client.ErrSnapshotSetNotFound if no target is
+// configured or if the target does not have the set.
+// Note that the state must not be locked by the caller.
+func Fetch(ctx context.Context, st *state.State, setID uint64) (snapNames []string, err error) {
+	st.Lock()
+	target, err := snapshotsTarget(st)
+	if err == nil && target == nil {
+		err = client.ErrSnapshotSetNotFound
+	}
+	if err == nil {
+		// two fetches of the same set would step on each other
+		err = checkSnapshotConflict(st, setID, "fetch-snapshot")
+	}
+	if err != nil {
+		st.Unlock()
+		return nil, err
+	}
+	setSnapshotOpInProgress(st, setID, "fetch-snapshot")
+	st.Unlock()
+
+	defer func() {
+		st.Lock()
+		defer st.Unlock()
+		UnsetSnapshotOpInProgress(st, setID)
+	}()
+
+	snapNames, err = backendDownload(ctx, target, setID)
+	if errors.Is(err, backend.ErrTargetNotFound) {
+		return nil, client.ErrSnapshotSetNotFound
+	}
+	return snapNames, err
+}
@@ -360,7 +360,7 @@ func (snapshotSuite) TestSaveChecksSnapnamesError(c *check.C) {
 	st := state.New(nil)
 	st.Lock()
 	defer st.Unlock()
-	_, _, _, err := snapshotstate.Save(st, nil, nil, nil)
+	_, _, _, err := snapshotstate.Save(st, nil, nil, nil, nil)
 	c.Check(err, check.ErrorMatches, "bzzt")
 }
 
@@ -425,7 +425,7 @@ func (s snapshotSuite) TestSaveChecksSnapstateConflict(c *check.C) {
 	st, restore := s.createConflictingChange(c)
 	defer restore()
 
-	_, _, _, err := snapshotstate.Save(st, []string{"foo"}, nil, nil)
+	_, _, _, err := snapshotstate.Save(st, []string{"foo"}, nil, nil, nil)
 	c.Assert(err, check.NotNil)
 	c.Check(err, check.FitsTypeOf, &snapstate.ChangeConflictError{})
 }
@@ -461,7 +461,7 @@ func (snapshotSuite) TestSaveConflictsWithSnapstate(c *check.C) {
 	})
 
 	chg := st.NewChange("snapshot-save", "...")
-	_, _, saveTasks, err := snapshotstate.Save(st, nil, nil, nil)
+	_, _, saveTasks, err := snapshotstate.Save(st, nil, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	chg.AddAll(saveTasks)
 
@@ -477,7 +477,7 @@ func (snapshotSuite) TestSaveChecksSnapstateConflictError(c *check.C) {
 	st := state.New(nil)
 	st.Lock()
 	defer st.Unlock()
-	_, _, _, err := snapshotstate.Save(st, nil, nil, nil)
+	_, _, _, err := snapshotstate.Save(st, nil, nil, nil, nil)
 	c.Check(err, check.ErrorMatches, "bzzt")
 }
 
@@ -488,7 +488,7 @@ func (snapshotSuite) TestSaveChecksSetIDError(c *check.C) {
 
 	st.Set("last-snapshot-set-id", "3/4")
 
-	_, _, _, err := snapshotstate.Save(st, nil, nil, nil)
+	_, _, _, err := snapshotstate.Save(st, nil, nil, nil, nil)
 	c.Check(err, check.ErrorMatches, ".* could not unmarshal .*")
 }
 
@@ -497,7 +497,7 @@ func (snapshotSuite) TestSaveNoSnapsInState(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	setID, saved, taskset, err := snapshotstate.Save(st, nil, nil, nil)
+	setID, saved, taskset, err := snapshotstate.Save(st, nil, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(setID, check.Equals, uint64(1))
 	c.Check(saved, check.HasLen, 0)
@@ -509,7 +509,7 @@ func (snapshotSuite) TestSaveSnapNotInstalled(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	setID, saved, taskset, err := snapshotstate.Save(st, []string{"foo"}, nil, nil)
+	setID, saved, taskset, err := snapshotstate.Save(st, []string{"foo"}, nil, nil, nil)
 	c.Assert(err, check.ErrorMatches, `snap "foo" is not installed`)
 	c.Check(setID, check.Equals, uint64(0))
 	c.Check(saved, check.HasLen, 0)
@@ -535,7 +535,7 @@ func (snapshotSuite) TestSaveSomeSnaps(c *check.C) {
 		"a-snap": {Exclude: []string{"$SNAP_COMMON/exclude", "$SNAP_DATA/exclude"}},
 	}
 
-	setID, saved, taskset, err := snapshotstate.Save(st, nil, nil, snapshotOptions)
+	setID, saved, taskset, err := snapshotstate.Save(st, nil, nil, snapshotOptions, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(setID, check.Equals, uint64(1))
 	c.Check(saved, check.DeepEquals, []string{"a-snap", "c-snap"})
@@ -581,7 +581,7 @@ func (s snapshotSuite) TestSaveOneSnap(c *check.C) {
 		Current: snap.R(1),
 	})
 
-	setID, saved, taskset, err := snapshotstate.Save(st, []string{"a-snap"}, []string{"a-user"}, nil)
+	setID, saved, taskset, err := snapshotstate.Save(st, []string{"a-snap"}, []string{"a-user"}, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(setID, check.Equals, uint64(1))
 	c.Check(saved, check.DeepEquals, []string{"a-snap"})
@@ -660,7 +660,7 @@ func (snapshotSuite) TestSaveIntegration(c *check.C) {
 		}
 	}
 
-	setID, saved, taskset, err := snapshotstate.Save(st, nil, []string{"a-user"}, snapshotOptions)
+	setID, saved, taskset, err := snapshotstate.Save(st, nil, []string{"a-user"}, snapshotOptions, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(setID, check.Equals, uint64(1))
 	c.Check(saved, check.DeepEquals, []string{"one-snap", "too-snap", "tri-snap"})
@@ -767,7 +767,7 @@ exec /bin/tar "$@"
 		c.Assert(os.Mkdir(filepath.Join(homedir, "snap", name, "common", "common-"+name), mode), check.IsNil)
 	}
 
-	setID, saved, taskset, err := snapshotstate.Save(st, nil, []string{"a-user"}, nil)
+	setID, saved, taskset, err := snapshotstate.Save(st, nil, []string{"a-user"}, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(setID, check.Equals, uint64(1))
 	c.Check(saved, check.DeepEquals, []string{"one-snap", "too-snap", "tri-snap"})
@@ -867,7 +867,7 @@ exec /bin/tar "$@"
 	// these dir permissions (000) make tar unhappy
 	c.Assert(os.Mkdir(filepath.Join(homedir, "snap/tar-fail-snap/common/common-tar-fail-snap"), 00), check.IsNil)
 
-	setID, saved, taskset, err := snapshotstate.Save(st, nil, []string{"a-user"}, nil)
+	setID, saved, taskset, err := snapshotstate.Save(st, nil, []string{"a-user"}, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(setID, check.Equals, uint64(1))
 	c.Check(saved, check.DeepEquals, []string{"tar-fail-snap"})
@@ -930,7 +930,7 @@ func (snapshotSuite) TestRestoreChecksIterError(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	_, _, err := snapshotstate.Restore(st, 42, nil, nil)
+	_, _, err := snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.ErrorMatches, "bzzt")
 }
 
@@ -938,7 +938,7 @@ func (s snapshotSuite) TestRestoreChecksSnapstateConflicts(c *check.C) {
 	st, restore := s.createConflictingChange(c)
 	defer restore()
 
-	_, _, err := snapshotstate.Restore(st, 42, nil, nil)
+	_, _, err := snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.NotNil)
 	c.Check(err, check.FitsTypeOf, &snapstate.ChangeConflictError{})
 
@@ -995,7 +995,7 @@ func (snapshotSuite) TestRestoreConflictsWithSnapstate(c *check.C) {
 	})
 
 	chg := st.NewChange("snapshot-restore", "...")
-	_, restoreTasks, err := snapshotstate.Restore(st, 42, nil, nil)
+	_, restoreTasks, err := snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	chg.AddAll(restoreTasks)
 
@@ -1027,7 +1027,7 @@ func (snapshotSuite) TestRestoreChecksForgetConflicts(c *check.C) {
 	tsk.Set("snapshot-setup", map[string]int{"set-id": 42})
 	chg.AddTask(tsk)
 
-	_, _, err = snapshotstate.Restore(st, 42, nil, nil)
+	_, _, err = snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.ErrorMatches, `cannot operate on snapshot set #42 while change \"1\" is in progress`)
 }
 
@@ -1062,7 +1062,7 @@ func (snapshotSuite) TestRestoreChecksChangesToSnapID(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	_, _, err = snapshotstate.Restore(st, 42, nil, nil)
+	_, _, err = snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.ErrorMatches, `cannot restore snapshot for "a-snap": current snap \(ID 1234567…\) does not match snapshot \(ID 0987654…\)`)
 }
 
@@ -1103,7 +1103,7 @@ func (snapshotSuite) TestRestoreChecksChangesToEpoch(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	_, _, err = snapshotstate.Restore(st, 42, nil, nil)
+	_, _, err = snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.ErrorMatches, `cannot restore snapshot for "a-snap": current snap \(epoch 17\) cannot read snapshot data \(epoch 42\)`)
 }
 
@@ -1144,7 +1144,7 @@ func (snapshotSuite) TestRestoreWorksWithCompatibleEpoch(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	found, taskset, err := snapshotstate.Restore(st, 42, nil, nil)
+	found, taskset, err := snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(found, check.DeepEquals, []string{"a-snap"})
 	tasks := taskset.Tasks()
@@ -1181,7 +1181,7 @@ func (snapshotSuite) TestRestore(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	found, taskset, err := snapshotstate.Restore(st, 42, []string{"a-snap", "b-snap"}, []string{"a-user"})
+	found, taskset, err := snapshotstate.Restore(st, 42, []string{"a-snap", "b-snap"}, []string{"a-user"}, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(found, check.DeepEquals, []string{"a-snap"})
 	tasks := taskset.Tasks()
@@ -1200,6 +1200,45 @@ func (snapshotSuite) TestRestore(c *check.C) {
 	})
 }
 
+func (snapshotSuite) TestRestorePaths(c *check.C) {
+	shotfile, err := os.Create(filepath.Join(c.MkDir(), "foo.zip"))
+	c.Assert(err, check.IsNil)
+	defer shotfile.Close()
+	defer snapshotstate.MockBackendIter(func(_ context.Context, f func(*backend.Reader) error) error {
+		return f(&backend.Reader{
+			Snapshot: client.Snapshot{SetID: 42, Snap: "a-snap"},
+			File:     shotfile,
+		})
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, _, err = snapshotstate.Restore(st, 42, nil, nil, &snap.SnapshotOptions{Include: []string{"/etc"}}, nil)
+	c.Check(err, check.ErrorMatches, `snapshot include path must start with one of .*`)
+
+	options := &snap.SnapshotOptions{
+		Include: []string{"$SNAP_USER_DATA/.config"},
+		Exclude: []string{"$SNAP_USER_DATA/.config/cache"},
+	}
+	_, taskset, err := snapshotstate.Restore(st, 42, nil, nil, options, nil)
+	c.Assert(err, check.IsNil)
+	var snapshot map[string]any
+	c.Check(taskset.Tasks()[0].Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot["options"], check.DeepEquals, map[string]any{
+		"include": []any{"$SNAP_USER_DATA/.config"},
+		"exclude": []any{"$SNAP_USER_DATA/.config/cache"},
+	})
+
+	// empty options are no options
+	_, taskset, err = snapshotstate.Restore(st, 42, nil, nil, &snap.SnapshotOptions{}, nil)
+	c.Assert(err, check.IsNil)
+	snapshot = nil
+	c.Check(taskset.Tasks()[0].Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot["options"], check.IsNil)
+}
+
 func (snapshotSuite) TestRestoreIntegration(c *check.C) {
 	testRestoreIntegration(c, dirs.UserHomeSnapDir, nil)
 }
@@ -1263,7 +1302,7 @@ func testRestoreIntegration(c *check.C, snapDataDir string, opts *dirs.SnapDirOp
 			c.Assert(os.MkdirAll(filepath.Join(home, snapDataDir, name, "common", "common-"+name), 0755), check.IsNil)
 		}
 
-		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user", "b-user"}, nil, opts)
+		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user", "b-user"}, nil, opts, nil)
 		c.Assert(err, check.IsNil)
 	}
 
@@ -1272,7 +1311,7 @@ func testRestoreIntegration(c *check.C, snapDataDir string, opts *dirs.SnapDirOp
 	// remove b-user's home
 	c.Assert(os.RemoveAll(homedirB), check.IsNil)
 
-	found, taskset, err := snapshotstate.Restore(st, 42, nil, []string{"a-user", "b-user"})
+	found, taskset, err := snapshotstate.Restore(st, 42, nil, []string{"a-user", "b-user"}, nil, nil)
 	c.Assert(err, check.IsNil)
 	sort.Strings(found)
 	c.Check(found, check.DeepEquals, []string{"one-snap", "too-snap", "tri-snap"})
@@ -1342,7 +1381,7 @@ func (snapshotSuite) TestRestoreIntegrationFails(c *check.C) {
 		c.Assert(os.MkdirAll(filepath.Join(homedir, "snap", name, fmt.Sprint(i+1), "canary-"+name), 0755), check.IsNil)
 		c.Assert(os.MkdirAll(filepath.Join(homedir, "snap", name, "common", "common-"+name), 0755), check.IsNil)
 
-		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user"}, nil, nil)
+		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user"}, nil, nil, nil)
 		c.Assert(err, check.IsNil)
 	}
 
@@ -1352,7 +1391,7 @@ func (snapshotSuite) TestRestoreIntegrationFails(c *check.C) {
 	c.Assert(os.MkdirAll(filepath.Join(homedir, "snap"), 0755), check.IsNil)
 	c.Assert(os.MkdirAll(filepath.Join(homedir, "snap", "too-snap"), 0), check.IsNil)
 
-	found, taskset, err := snapshotstate.Restore(st, 42, nil, []string{"a-user"})
+	found, taskset, err := snapshotstate.Restore(st, 42, nil, []string{"a-user"}, nil, nil)
 	c.Assert(err, check.IsNil)
 	sort.Strings(found)
 	c.Check(found, check.DeepEquals, []string{"one-snap", "too-snap", "tri-snap"})
@@ -1406,7 +1445,7 @@ func (snapshotSuite) TestCheckChecksIterError(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	_, _, err := snapshotstate.Check(st, 42, nil, nil)
+	_, _, err := snapshotstate.Check(st, 42, nil, nil, nil)
 	c.Assert(err, check.ErrorMatches, "bzzt")
 }
 
@@ -1414,7 +1453,7 @@ func (s snapshotSuite) TestCheckDoesNotTriggerSnapstateConflict(c *check.C) {
 	st, restore := s.createConflictingChange(c)
 	defer restore()
 
-	_, _, err := snapshotstate.Check(st, 42, nil, nil)
+	_, _, err := snapshotstate.Check(st, 42, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 }
 
@@ -1442,7 +1481,7 @@ func (snapshotSuite) TestCheckChecksForgetConflicts(c *check.C) {
 	tsk.Set("snapshot-setup", map[string]int{"set-id": 42})
 	chg.AddTask(tsk)
 
-	_, _, err = snapshotstate.Check(st, 42, nil, nil)
+	_, _, err = snapshotstate.Check(st, 42, nil, nil, nil)
 	c.Assert(err, check.ErrorMatches, `cannot operate on snapshot set #42 while change \"1\" is in progress`)
 }
 
@@ -1465,7 +1504,7 @@ func (snapshotSuite) TestCheck(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	found, taskset, err := snapshotstate.Check(st, 42, []string{"a-snap", "b-snap"}, []string{"a-user"})
+	found, taskset, err := snapshotstate.Check(st, 42, []string{"a-snap", "b-snap"}, []string{"a-user"}, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(found, check.DeepEquals, []string{"a-snap"})
 	tasks := taskset.Tasks()
@@ -1750,6 +1789,54 @@ func (snapshotSuite) TestAutomaticSnapshotDefaultUbuntuCore(c *check.C) {
 	c.Assert(du, check.Equals, time.Duration(0))
 }
 
+func (snapshotSuite) TestPruneAutomaticSnapshots(c *check.C) {
+	var removed []string
+	defer snapshotstate.MockOsRemove(func(name string) error {
+		removed = append(removed, name)
+		return nil
+	})()
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer snapshotstate.MockBackendIter(func(_ context.Context, f func(*backend.Reader) error) error {
+		for _, shot := range []client.Snapshot{
+			{SetID: 1, Snap: "a-snap", Time: t0.Add(time.Hour), Size: 100},
+			{SetID: 2, Snap: "b-snap", Time: t0, Size: 200},
+			{SetID: 3, Snap: "c-snap", Time: t0.Add(2 * time.Hour), Size: 300},
+			// not automatic
+			{SetID: 4, Snap: "d-snap", Time: t0.Add(-time.Hour), Size: 400},
+		} {
+			r := &backend.Reader{Snapshot: shot, File: os.NewFile(0, fmt.Sprintf("set-%d.zip", shot.SetID))}
+			if err := f(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	st.Set("snapshots", map[uint64]any{
+		1: map[string]any{"expiry-time": "2037-02-12T12:50:00Z"},
+		2: map[string]any{"expiry-time": "2037-02-12T12:50:00Z"},
+		3: map[string]any{"expiry-time": "2037-02-12T12:50:00Z"},
+		4: map[string]any{"scheduled": true},
+	})
+
+	// oldest sets go first, until the target is met
+	sets, freed, err := snapshotstate.PruneAutomaticSnapshots(st, 250)
+	c.Assert(err, check.IsNil)
+	c.Check(sets, check.Equals, 2)
+	c.Check(freed, check.Equals, uint64(300))
+	c.Check(removed, check.DeepEquals, []string{"set-2.zip", "set-1.zip"})
+
+	var snapshots map[uint64]any
+	c.Assert(st.Get("snapshots", &snapshots), check.IsNil)
+	c.Check(snapshots, check.HasLen, 2)
+	c.Check(snapshots[3], check.NotNil)
+	c.Check(snapshots[4], check.NotNil)
+}
+
 func (snapshotSuite) TestListError(c *check.C) {
 	restore := snapshotstate.MockBackendList(func(context.Context, uint64, []string) ([]client.SnapshotSet, error) {
 		return nil, fmt.Errorf("boom")
@@ -2119,5 +2206,132 @@ func (snapshotSuite) TestSetSnapshotOpInProgress(c *check.C) {
 }
 
 func (s *snapshotSuite) TestEnsureLoopLogging(c *check.C) {
-	testutil.CheckEnsureLoopLogging("snapshotmgr.go", c, false)
+	testutil.CheckEnsureLoopLogging("snapshotmgr.go", c, true)
+}
+
+func setSnapshotsTarget(c *check.C, st *state.State, target string) {
+	tr := config.NewTransaction(st)
+	c.Assert(tr.Set("core", "snapshots.target", target), check.IsNil)
+	tr.Commit()
+}
+
+func (snapshotSuite) TestSaveWithTargetAddsUpload(c *check.C) {
+	defer snapshotstate.MockSnapstateAll(func(*state.State) (map[string]*snapstate.SnapState, error) {
+		return map[string]*snapstate.SnapState{
+			"a-snap": {Active: true},
+			"b-snap": {Active: true},
+		}, nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+	setSnapshotsTarget(c, st, "file:///media/backup")
+
+	setID, saved, taskset, err := snapshotstate.Save(st, nil, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(saved, check.DeepEquals, []string{"a-snap", "b-snap"})
+	tasks := taskset.Tasks()
+	c.Assert(tasks, check.HasLen, 3)
+	upload := tasks[2]
+	c.Check(upload.Kind(), check.Equals, "upload-snapshot")
+	c.Check(upload.Summary(), check.Equals, fmt.Sprintf("Upload snapshot set #%d", setID))
+	c.Check(upload.WaitTasks(), check.DeepEquals, tasks[:2])
+	// the upload failing does not undo the saves
+	c.Check(upload.Lanes(), check.HasLen, 1)
+	c.Check(tasks[0].Lanes(), check.DeepEquals, []int{0})
+	c.Check(tasks[1].Lanes(), check.DeepEquals, []int{0})
+	var snapshot map[string]any
+	c.Assert(upload.Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot, check.DeepEquals, map[string]any{
+		"set-id":  float64(setID),
+		"snap":    "",
+		"current": "unset",
+	})
+}
+
+func (snapshotSuite) TestSaveWithBadTarget(c *check.C) {
+	defer snapshotstate.MockSnapstateAll(func(*state.State) (map[string]*snapstate.SnapState, error) {
+		return map[string]*snapstate.SnapState{"a-snap": {Active: true}}, nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+	setSnapshotsTarget(c, st, "ftp://example.com")
+
+	_, _, _, err := snapshotstate.Save(st, nil, nil, nil, nil)
+	c.Assert(err, check.ErrorMatches, `cannot use snapshots target "ftp://example.com": unsupported scheme "ftp"`)
+}
+
+func (snapshotSuite) TestForgetChecksUploadConflicts(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+	chg := st.NewChange("save-snapshot-change", "...")
+	tsk := st.NewTask("upload-snapshot", "...")
+	tsk.SetStatus(state.DoingStatus)
+	tsk.Set("snapshot-setup", map[string]int{"set-id": 42})
+	chg.AddTask(tsk)
+
+	_, _, err := snapshotstate.Forget(st, 42, nil)
+	c.Assert(err, check.ErrorMatches, `cannot operate on snapshot set #42 while change \"1\" is in progress`)
+}
+
+func (snapshotSuite) TestFetchNoTarget(c *check.C) {
+	defer snapshotstate.MockBackendDownload(func(context.Context, backend.Target, uint64) ([]string, error) {
+		c.Fatal("unexpected download")
+		return nil, nil
+	})()
+
+	st := state.New(nil)
+	_, err := snapshotstate.Fetch(context.TODO(), st, 42)
+	c.Check(err, check.Equals, client.ErrSnapshotSetNotFound)
+}
+
+func (snapshotSuite) TestFetch(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	setSnapshotsTarget(c, st, "file:///media/backup")
+	st.Unlock()
+
+	calls := 0
+	defer snapshotstate.MockBackendDownload(func(ctx context.Context, target backend.Target, setID uint64) ([]string, error) {
+		calls++
+		c.Check(target, check.NotNil)
+		c.Check(setID, check.Equals, uint64(42))
+
+		// the set is marked as being fetched in the meantime
+		st.Lock()
+		defer st.Unlock()
+		c.Check(snapshotstate.CheckSnapshotConflict(st, 42, "fetch-snapshot"), check.ErrorMatches,
+			`cannot operate on snapshot set #42 while operation fetch-snapshot is in progress`)
+		if calls == 2 {
+			return nil, backend.ErrTargetNotFound
+		}
+		return []string{"a-snap"}, nil
+	})()
+
+	names, err := snapshotstate.Fetch(context.TODO(), st, 42)
+	c.Assert(err, check.IsNil)
+	c.Check(names, check.DeepEquals, []string{"a-snap"})
+
+	st.Lock()
+	c.Check(snapshotstate.CheckSnapshotConflict(st, 42, "fetch-snapshot"), check.IsNil)
+	st.Unlock()
+
+	_, err = snapshotstate.Fetch(context.TODO(), st, 42)
+	c.Check(err, check.Equals, client.ErrSnapshotSetNotFound)
+	c.Check(calls, check.Equals, 2)
+}
+
+func (snapshotSuite) TestFetchConflict(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	setSnapshotsTarget(c, st, "file:///media/backup")
+	snapshotstate.SetSnapshotOpInProgress(st, 42, "fetch-snapshot")
+	st.Unlock()
+
+	_, err := snapshotstate.Fetch(context.TODO(), st, 42)
+	c.Check(err, check.ErrorMatches, `cannot operate on snapshot set #42 while operation fetch-snapshot is in progress`)
 }
@@ -65,6 +65,9 @@ const defaultMaxInhibitionDays = 14
 var (
 	CanAutoRefresh        func(st *state.State) (bool, error)
 	IsOnMeteredConnection func() (bool, error)
+	// DeviceIdleSince returns since when the device has been idle, or
+	// the zero time if it is not.
+	DeviceIdleSince func() (time.Time, error)
 
 	defaultRefreshSchedule = func() []*timeutil.Schedule {
 		refreshSchedule, err := timeutil.ParseSchedule(defaultRefreshScheduleStr)
@@ -96,6 +99,12 @@ type refreshCandidate struct {
 	// Monitored signals whether this snap is currently being monitored for closure
 	// so its auto-refresh can be continued.
 	Monitored bool `json:"monitored,omitempty"`
+	// RolloutDue is when the staged rollout allows auto-refreshing to the
+	// revision on this device, if it is delayed.
+	RolloutDue *time.Time `json:"rollout-due,omitempty"`
+	// Changelog holds the release notes of the revision, as published in the
+	// store.
+	Changelog string `json:"changelog,omitempty"`
 }
 
 func (rc *refreshCandidate) Type() snap.Type {
@@ -291,6 +300,81 @@ func (m *autoRefresh) canRefreshRespectingMetered(now, lastRefresh time.Time) (c
 	return false, nil
 }
 
+// refreshBlackout returns whether auto-refreshes are blacked out at the given
+// time according to refresh.blackout, and if so until when.
+func refreshBlackout(st *state.State, now time.Time) (bool, time.Time, error) {
+	tr := config.NewTransaction(st)
+	var blackoutStr string
+	if err := tr.GetMaybe("core", "refresh.blackout", &blackoutStr); err != nil {
+		return false, time.Time{}, err
+	}
+	if blackoutStr == "" {
+		return false, time.Time{}, nil
+	}
+	blackout, err := timeutil.ParseDateRanges(blackoutStr)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid refresh.blackout: %v", err)
+	}
+	in, end := timeutil.InDateRanges(blackout, now)
+	return in, end, nil
+}
+
+// refreshIdleTime returns how long the device needs to have been idle before
+// an auto-refresh, zero if it does not need to be.
+func refreshIdleTime(st *state.State) (time.Duration, error) {
+	tr := config.NewTransaction(st)
+	var idleTimeStr string
+	if err := tr.GetMaybe("core", "refresh.idle-time", &idleTimeStr); err != nil {
+		return 0, err
+	}
+	if idleTimeStr == "" {
+		return 0, nil
+	}
+	idleTime, err := time.ParseDuration(idleTimeStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid refresh.idle-time: %v", err)
+	}
+	return idleTime, nil
+}
+
+// canRefreshInMaintenanceWindow checks that the auto-refresh is neither in
+// a blackout date range nor happening while the device is in use, unless it
+// has been pending for too long.
+func (m *autoRefresh) canRefreshInMaintenanceWindow(now, lastRefresh time.Time) (bool, error) {
+	if now.Sub(lastRefresh) >= maxPostponement {
+		// the maintenance window cannot postpone refreshes forever
+		return true, nil
+	}
+
+	blackout, end, err := refreshBlackout(m.state, now)
+	if err != nil {
+		return false, err
+	}
+	if blackout {
+		logger.Debugf("Auto refresh disabled by refresh.blackout until %s", end.Format(time.RFC3339))
+		return false, nil
+	}
+
+	idleTime, err := refreshIdleTime(m.state)
+	if err != nil {
+		return false, err
+	}
+	if idleTime == 0 || DeviceIdleSince == nil {
+		return true, nil
+	}
+	idleSince, err := DeviceIdleSince()
+	if err != nil {
+		// do not hold refreshes on devices where idleness cannot be told
+		logger.Debugf("Cannot check if the device is idle, not waiting for it: %v", err)
+		return true, nil
+	}
+	if idleSince.IsZero() || now.Sub(idleSince) < idleTime {
+		logger.Debugf("Auto refresh postponed until the device has been idle for %s", idleTime)
+		return false, nil
+	}
+	return true, nil
+}
+
 func isStoreOnline(s *state.State) (bool, error) {
 	tr := config.NewTransaction(s)
 
@@ -408,6 +492,17 @@ func (m *autoRefresh) Ensure() (err error) {
 				return nil
 			}
 
+			can, err = m.canRefreshInMaintenanceWindow(now, lastRefresh)
+			if err != nil {
+				return err
+			}
+			if !can {
+				// keep nextRefresh so that a later Ensure tries again
+				// as soon as the maintenance window allows it, rather
+				// than skipping to the next scheduled window
+				return nil
+			}
+
 			err = m.launchAutoRefresh()
 			if _, ok := err.(*httputil.PersistentNetworkError); ok {
 				// refresh will be retried after refreshRetryDelay
@@ -750,6 +845,9 @@ func maybeAsyncPendingRefreshNotification(ctx context.Context, st *state.State,
 	if !sendNotification {
 		return
 	}
+	if refreshInfo.Changelog == "" {
+		refreshInfo.Changelog = refreshCandidateChangelog(st, refreshInfo.InstanceName)
+	}
 	asyncPendingRefreshNotification(ctx, refreshInfo)
 }
 
@@ -2064,6 +2064,7 @@ func (s *snapmgrTestSuite) TestAutoRefreshPhase2(c *C) {
 		"setup-aliases",
 		"run-hook [base-snap-b;post-refresh]",
 		"start-snap-services",
+		"run-hook [base-snap-b;post-refresh-check]",
 		"cleanup",
 		"run-hook [base-snap-b;check-health]",
 		"prerequisites",
@@ -2082,6 +2083,7 @@ func (s *snapmgrTestSuite) TestAutoRefreshPhase2(c *C) {
 		"setup-aliases",
 		"run-hook [snap-a;post-refresh]",
 		"start-snap-services",
+		"run-hook [snap-a;post-refresh-check]",
 		"cleanup",
 		"run-hook [snap-a;configure]",
 		"run-hook [snap-a;check-health]",
@@ -2142,6 +2144,7 @@ func (s *snapmgrTestSuite) TestAutoRefreshPhase2Held(c *C) {
 		"setup-aliases",
 		"run-hook [snap-a;post-refresh]",
 		"start-snap-services",
+		"run-hook [snap-a;post-refresh-check]",
 		"cleanup",
 		"run-hook [snap-a;configure]",
 		"run-hook [snap-a;check-health]",
@@ -2197,6 +2200,7 @@ func (s *snapmgrTestSuite) TestAutoRefreshPhase2Proceed(c *C) {
 		"setup-aliases",
 		"run-hook [snap-a;post-refresh]",
 		"start-snap-services",
+		"run-hook [snap-a;post-refresh-check]",
 		"cleanup",
 		"run-hook [snap-a;configure]",
 		"run-hook [snap-a;check-health]",
@@ -2425,6 +2429,7 @@ func (s *snapmgrTestSuite) TestAutoRefreshPhase2Conflict(c *C) {
 		"setup-aliases",
 		"run-hook [base-snap-b;post-refresh]",
 		"start-snap-services",
+		"run-hook [base-snap-b;post-refresh-check]",
 		"cleanup",
 		"run-hook [base-snap-b;check-health]",
 		"check-rerefresh",
@@ -2589,6 +2594,7 @@ func (s *snapmgrTestSuite) TestAutoRefreshPhase2GatedSnaps(c *C) {
 		"setup-aliases",
 		"run-hook [base-snap-b;post-refresh]",
 		"start-snap-services",
+		"run-hook [base-snap-b;post-refresh-check]",
 		"cleanup",
 		"run-hook [base-snap-b;check-health]",
 		"check-rerefresh",
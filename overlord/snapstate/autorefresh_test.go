@@ -953,6 +953,171 @@ func (s *autoRefreshTestSuite) TestRefreshOnMeteredConnNotMetered(c *C) {
 	c.Check(s.store.ops, DeepEquals, []string{"list-refresh"})
 }
 
+func (s *autoRefreshTestSuite) TestRefreshBlackout(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	today := time.Now().Format("2006-01-02")
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.blackout", "2020-01-01.."+today)
+	tr.Commit()
+
+	af := snapstate.NewAutoRefresh(s.state)
+
+	s.state.Set("last-refresh", time.Now().Add(-5*24*time.Hour))
+	s.state.Unlock()
+	err := af.Ensure()
+	s.state.Lock()
+	c.Check(err, IsNil)
+	// no refresh
+	c.Check(s.store.ops, HasLen, 0)
+	// the refresh is still due
+	c.Check(af.NextRefresh().After(time.Now()), Equals, false)
+	c.Check(af.NextRefresh().IsZero(), Equals, false)
+
+	// blackout dates are over
+	tr = config.NewTransaction(s.state)
+	tr.Set("core", "refresh.blackout", "2020-01-01..2020-01-31")
+	tr.Commit()
+
+	s.state.Unlock()
+	err = af.Ensure()
+	s.state.Lock()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, DeepEquals, []string{"list-refresh"})
+}
+
+func (s *autoRefreshTestSuite) TestRefreshBlackoutPendingForTooLong(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	today := time.Now().Format("2006-01-02")
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.blackout", "2020-01-01.."+today)
+	tr.Commit()
+
+	af := snapstate.NewAutoRefresh(s.state)
+
+	// last refresh over 96 days ago, a new one is launched regardless of
+	// the blackout
+	s.state.Set("last-refresh", time.Now().Add(-96*24*time.Hour))
+	s.state.Unlock()
+	err := af.Ensure()
+	s.state.Lock()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, DeepEquals, []string{"list-refresh"})
+}
+
+func (s *autoRefreshTestSuite) TestRefreshWaitsForIdleDevice(c *C) {
+	var idleSince time.Time
+	restore := snapstate.MockDeviceIdleSince(func() (time.Time, error) {
+		return idleSince, nil
+	})
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.idle-time", "30m")
+	tr.Commit()
+
+	af := snapstate.NewAutoRefresh(s.state)
+	s.state.Set("last-refresh", time.Now().Add(-5*24*time.Hour))
+
+	// not idle
+	s.state.Unlock()
+	err := af.Ensure()
+	s.state.Lock()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, HasLen, 0)
+
+	// not idle for long enough
+	idleSince = time.Now().Add(-10 * time.Minute)
+	s.state.Unlock()
+	err = af.Ensure()
+	s.state.Lock()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, HasLen, 0)
+
+	idleSince = time.Now().Add(-time.Hour)
+	s.state.Unlock()
+	err = af.Ensure()
+	s.state.Lock()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, DeepEquals, []string{"list-refresh"})
+}
+
+func (s *autoRefreshTestSuite) TestRefreshWaitsForIdleDeviceWithinWindow(c *C) {
+	var idleSince time.Time
+	restore := snapstate.MockDeviceIdleSince(func() (time.Time, error) {
+		return idleSince, nil
+	})
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.timer", "00:00-23:59")
+	tr.Set("core", "refresh.idle-time", "30m")
+	tr.Commit()
+
+	// the last refresh was recent enough that the next window computed from
+	// it is in the future
+	s.state.Set("last-refresh", time.Now().Add(-time.Minute))
+	af := snapstate.NewAutoRefresh(s.state)
+	s.state.Unlock()
+	err := af.Ensure()
+	s.state.Lock()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, HasLen, 0)
+	c.Assert(af.NextRefresh().After(time.Now()), Equals, true)
+
+	// the refresh is due but the device is not idle
+	nextRefresh := time.Now().Add(-time.Minute)
+	snapstate.MockNextRefresh(af, nextRefresh)
+	s.state.Unlock()
+	err = af.Ensure()
+	s.state.Lock()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, HasLen, 0)
+	c.Check(af.NextRefresh().Equal(nextRefresh), Equals, true)
+
+	// the refresh happens as soon as the device is idle, without waiting
+	// for another window
+	idleSince = time.Now().Add(-time.Hour)
+	s.state.Unlock()
+	err = af.Ensure()
+	s.state.Lock()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, DeepEquals, []string{"list-refresh"})
+}
+
+func (s *autoRefreshTestSuite) TestRefreshIdleUnknown(c *C) {
+	restore := snapstate.MockDeviceIdleSince(func() (time.Time, error) {
+		return time.Time{}, errors.New("no logind")
+	})
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.idle-time", "30m")
+	tr.Commit()
+
+	af := snapstate.NewAutoRefresh(s.state)
+	s.state.Set("last-refresh", time.Now().Add(-5*24*time.Hour))
+
+	// refreshes are not held when idleness cannot be told
+	s.state.Unlock()
+	err := af.Ensure()
+	s.state.Lock()
+	c.Check(err, IsNil)
+	c.Check(s.store.ops, DeepEquals, []string{"list-refresh"})
+}
+
 func (s *autoRefreshTestSuite) TestInitialInhibitRefreshWithinInhibitWindow(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -1064,6 +1229,47 @@ func (s *autoRefreshTestSuite) TestInhibitRefreshRefreshesWhenOverdue(c *C) {
 	c.Check(notificationCount, Equals, 1)
 }
 
+func (s *autoRefreshTestSuite) TestInhibitRefreshNotificationHasChangelog(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.state.Set("refresh-candidates", map[string]*snapstate.RefreshCandidate{
+		"pkg": {
+			SnapSetup: snapstate.SnapSetup{SideInfo: &snap.SideInfo{RealName: "pkg", Revision: snap.R(2)}},
+			Changelog: "* fixed all the bugs",
+		},
+	})
+
+	notificationCount := 0
+	restore := snapstate.MockAsyncPendingRefreshNotification(func(ctx context.Context, refreshInfo *userclient.PendingSnapRefreshInfo) {
+		notificationCount++
+		c.Check(refreshInfo.InstanceName, Equals, "pkg")
+		c.Check(refreshInfo.Changelog, Equals, "* fixed all the bugs")
+	})
+	defer restore()
+
+	pastInstant := time.Now().Add(-snapstate.MaxInhibitionDuration(s.state) * 2)
+
+	si := &snap.SideInfo{RealName: "pkg", Revision: snap.R(1)}
+	info := &snap.Info{SideInfo: *si}
+	snapst := &snapstate.SnapState{
+		Sequence:             snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:              si.Revision,
+		RefreshInhibitedTime: &pastInstant,
+	}
+	snapsup := &snapstate.SnapSetup{Flags: snapstate.Flags{IsAutoRefresh: true}}
+
+	restore = snapstate.MockRefreshAppsCheck(func(si *snap.Info) error {
+		return &snapstate.BusySnapError{SnapInfo: si}
+	})
+	defer restore()
+
+	inhibitionTimeout, err := snapstate.InhibitRefresh(s.state, snapst, snapsup, info)
+	c.Assert(err == nil, Equals, true)
+	c.Check(inhibitionTimeout, Equals, true)
+	c.Check(notificationCount, Equals, 1)
+}
+
 func (s *autoRefreshTestSuite) TestInhibitNoNotificationOnManualRefresh(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -76,6 +76,10 @@ type StoreService interface {
 	// the downloads cache. Can return store.ErrCleanupBusy when the cache was
 	// busy and the cleanup could not run.
 	CleanDownloadsCache() error
+	// PruneDownloadsCache removes unreferenced snaps from the downloads
+	// cache, oldest first, until at least target bytes were freed, or all of
+	// them with a zero target. Can return store.ErrCleanupBusy.
+	PruneDownloadsCache(target uint64) (uint64, error)
 
 	ExchangeMessages(ctx context.Context, req *store.MessageExchangeRequest) (*store.MessageExchangeResponse, error)
 }
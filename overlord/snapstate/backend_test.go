@@ -944,7 +944,7 @@ func (f *fakeStore) Download(ctx context.Context, name, targetFn string, snapInf
 		macaroon = user.StoreMacaroon
 	}
 	// only add the options if they contain anything interesting
-	if dlOpts != nil && *dlOpts == (store.DownloadOptions{}) {
+	if dlOpts != nil && dlOpts.RateLimit == 0 && !dlOpts.Scheduled && !dlOpts.LeavePartialOnError && dlOpts.RateLimitRecheck == nil {
 		dlOpts = nil
 	}
 	f.appendDownload(&fakeDownload{
@@ -1030,8 +1030,6 @@ type fakeSnappyBackend struct {
 	servicesCurrentlyDisabled     []string
 	userServicesCurrentlyDisabled map[int][]string
 
-	lockDir string
-
 	// TODO cleanup triggers above
 	maybeInjectErr func(*fakeOp) error
 
@@ -0,0 +1,132 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// maxChangelogSize is the size at which changelogs shipped in snaps or
+// published in the store are cut.
+const maxChangelogSize = 64 * 1024
+
+// truncateChangelog cuts the given changelog to maxChangelogSize, without
+// splitting a character.
+func truncateChangelog(changelog string) string {
+	if len(changelog) <= maxChangelogSize {
+		return changelog
+	}
+	cut := maxChangelogSize
+	// do not cut in the middle of a multi-byte character
+	for cut > 0 && !utf8.RuneStart(changelog[cut]) {
+		cut--
+	}
+	return changelog[:cut]
+}
+
+// SnapChangelog holds the changelog of a revision of a snap.
+type SnapChangelog struct {
+	Revision  snap.Revision
+	Version   string
+	Changelog string
+	// Candidate is set if the revision is the one the snap would be
+	// refreshed to.
+	Candidate bool
+}
+
+// snapChangelog returns the changelog shipped by the snap in meta/changelog,
+// if any.
+func snapChangelog(info *snap.Info) (string, error) {
+	f, err := os.Open(filepath.Join(info.MountDir(), "meta", "changelog"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxChangelogSize))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// refreshCandidateChangelog returns the changelog of the revision the given
+// snap would be refreshed to, if known.
+func refreshCandidateChangelog(st *state.State, instanceName string) string {
+	var candidates map[string]*refreshCandidate
+	if err := st.Get("refresh-candidates", &candidates); err != nil {
+		if !errors.Is(err, state.ErrNoState) {
+			logger.Noticef("cannot get refresh candidates: %v", err)
+		}
+		return ""
+	}
+	if cand := candidates[instanceName]; cand != nil {
+		return cand.Changelog
+	}
+	return ""
+}
+
+// Changelogs returns the changelog of the current revision of the given snap,
+// as shipped in the snap, followed by the one of the revision it would be
+// refreshed to, as published in the store, if there is a refresh candidate
+// for it.
+// The state must be locked by the caller.
+func Changelogs(st *state.State, instanceName string) ([]*SnapChangelog, error) {
+	info, err := CurrentInfo(st, instanceName)
+	if err != nil {
+		return nil, err
+	}
+	changelog, err := snapChangelog(info)
+	if err != nil {
+		return nil, err
+	}
+	changelogs := []*SnapChangelog{{
+		Revision:  info.Revision,
+		Version:   info.Version,
+		Changelog: changelog,
+	}}
+
+	var candidates map[string]*refreshCandidate
+	if err := st.Get("refresh-candidates", &candidates); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	if cand := candidates[instanceName]; cand != nil && cand.Revision() != info.Revision {
+		changelogs = append(changelogs, &SnapChangelog{
+			Revision:  cand.Revision(),
+			Version:   cand.Version,
+			Changelog: cand.Changelog,
+			Candidate: true,
+		})
+	}
+	return changelogs, nil
+}
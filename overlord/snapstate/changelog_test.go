@@ -0,0 +1,91 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/snap"
+)
+
+const changelogSnapYaml = `name: snap-a
+type: app
+version: 1
+`
+
+func (s *autorefreshGatingSuite) TestChangelogs(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	info := mockInstalledSnap(c, st, changelogSnapYaml, noHook)
+	c.Assert(os.WriteFile(filepath.Join(info.MountDir(), "meta", "changelog"), []byte("* first release\n"), 0644), IsNil)
+
+	st.Set("refresh-candidates", map[string]*snapstate.RefreshCandidate{
+		"snap-a": {
+			SnapSetup: snapstate.SnapSetup{
+				Version:  "2",
+				SideInfo: &snap.SideInfo{RealName: "snap-a", Revision: snap.R(2)},
+			},
+			Changelog: "* second release",
+		},
+	})
+
+	changelogs, err := snapstate.Changelogs(st, "snap-a")
+	c.Assert(err, IsNil)
+	c.Check(changelogs, DeepEquals, []*snapstate.SnapChangelog{{
+		Revision:  snap.R(1),
+		Version:   "1",
+		Changelog: "* first release",
+	}, {
+		Revision:  snap.R(2),
+		Version:   "2",
+		Changelog: "* second release",
+		Candidate: true,
+	}})
+}
+
+func (s *autorefreshGatingSuite) TestChangelogsNoCandidate(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	mockInstalledSnap(c, st, changelogSnapYaml, noHook)
+
+	changelogs, err := snapstate.Changelogs(st, "snap-a")
+	c.Assert(err, IsNil)
+	c.Check(changelogs, DeepEquals, []*snapstate.SnapChangelog{{
+		Revision: snap.R(1),
+		Version:  "1",
+	}})
+}
+
+func (s *autorefreshGatingSuite) TestChangelogsNotInstalled(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	_, err := snapstate.Changelogs(st, "snap-a")
+	c.Assert(err, ErrorMatches, `snap "snap-a" is not installed`)
+}
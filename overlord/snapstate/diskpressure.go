@@ -0,0 +1,298 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/boot"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/overlord/swfeats"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var reclaimDiskSpaceChangeKind = swfeats.RegisterChangeKind("reclaim-disk-space")
+
+func init() {
+	swfeats.RegisterEnsure("SnapManager", "ensureDiskSpaceReclaimed")
+}
+
+// diskPressureCheckInterval is the interval between checks of the free space
+// in the snapd state directory.
+const diskPressureCheckInterval = 10 * time.Minute
+
+var osutilDiskFree = osutil.DiskFree
+
+// minFreeSpace returns the refresh.min-free-space threshold or 0 if snapd
+// should not reclaim disk space on its own.
+func minFreeSpace(st *state.State) uint64 {
+	var minFree string
+	if err := config.NewTransaction(st).Get("core", "refresh.min-free-space", &minFree); err != nil {
+		if !config.IsNoOption(err) {
+			logger.Noticef("cannot get refresh.min-free-space: %v", err)
+		}
+		return 0
+	}
+	if minFree == "" {
+		return 0
+	}
+	val, err := strutil.ParseByteSize(minFree)
+	if err != nil || val <= 0 {
+		logger.Noticef("invalid refresh.min-free-space: %q", minFree)
+		return 0
+	}
+	return uint64(val)
+}
+
+// prunableRevision is an inactive revision of a snap that can be removed to
+// reclaim disk space.
+type prunableRevision struct {
+	instanceName string
+	snapst       *SnapState
+	sideInfo     *snap.SideInfo
+	size         uint64
+}
+
+// prunableRevisions returns the inactive revisions of the installed snaps,
+// largest first. For every snap the revision before the current one, or the
+// latest one if there is none before it, is kept so that the snap can still be
+// reverted. Revisions used for booting and snaps with changes in progress are
+// left alone.
+func prunableRevisions(st *state.State) ([]*prunableRevision, error) {
+	snapStates, err := All(st)
+	if err != nil {
+		return nil, err
+	}
+	deviceCtx, err := DeviceCtxFromState(st, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var prunable []*prunableRevision
+	for name, snapst := range snapStates {
+		seq := snapst.Sequence.SideInfos()
+		if len(seq) <= 2 {
+			continue
+		}
+		if err := CheckChangeConflict(st, name, nil); err != nil {
+			var conflictErr *ChangeConflictError
+			if errors.As(err, &conflictErr) {
+				continue
+			}
+			return nil, err
+		}
+		typ, err := snapst.Type()
+		if err != nil {
+			return nil, err
+		}
+		inUse, err := boot.InUse(typ, deviceCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		currentIndex := snapst.LastIndex(snapst.Current)
+		revertIndex := currentIndex - 1
+		if revertIndex < 0 {
+			revertIndex = len(seq) - 1
+		}
+		for i, si := range seq {
+			if i == currentIndex || i == revertIndex || inUse(name, si.Revision) {
+				continue
+			}
+			var size uint64
+			if fi, err := os.Stat(snap.MountFile(name, si.Revision)); err == nil {
+				size = uint64(fi.Size())
+			}
+			prunable = append(prunable, &prunableRevision{
+				instanceName: name,
+				snapst:       snapst,
+				sideInfo:     si,
+				size:         size,
+			})
+		}
+	}
+
+	sort.Slice(prunable, func(i, j int) bool {
+		pi, pj := prunable[i], prunable[j]
+		if pi.size != pj.size {
+			return pi.size > pj.size
+		}
+		if pi.instanceName != pj.instanceName {
+			return pi.instanceName < pj.instanceName
+		}
+		return pi.sideInfo.Revision.N < pj.sideInfo.Revision.N
+	})
+	return prunable, nil
+}
+
+// pruneRevisions queues the removal of inactive revisions, largest first, until
+// at least target bytes would be freed. It returns the change doing so, if
+// any, along with the removed revisions and their size.
+func pruneRevisions(st *state.State, target uint64) (chg *state.Change, removed []string, size uint64, err error) {
+	prunable, err := prunableRevisions(st)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	var tss []*state.TaskSet
+	prev := make(map[string]*state.TaskSet)
+	for _, rev := range prunable {
+		if size >= target {
+			break
+		}
+		typ, err := rev.snapst.Type()
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		ts, err := removeInactiveRevision(st, rev.snapst, rev.instanceName, rev.sideInfo.SnapID, rev.sideInfo.Revision, typ)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		// revisions of the same snap are removed one after the other
+		if p := prev[rev.instanceName]; p != nil {
+			ts.WaitAll(p)
+		}
+		prev[rev.instanceName] = ts
+		tss = append(tss, ts)
+		removed = append(removed, fmt.Sprintf("%s/%s", rev.instanceName, rev.sideInfo.Revision))
+		size += rev.size
+	}
+	if len(tss) == 0 {
+		return nil, nil, 0, nil
+	}
+
+	chg = st.NewChange(reclaimDiskSpaceChangeKind, i18n.G("Remove old snap revisions to reclaim disk space"))
+	for _, ts := range tss {
+		chg.AddAll(ts)
+	}
+	return chg, removed, size, nil
+}
+
+// ensureDiskSpaceReclaimed frees disk space when the free space in the snapd
+// state directory falls below refresh.min-free-space. Unused snaps in the
+// downloads cache are removed first, then automatic snapshots and finally
+// inactive revisions of snaps, always keeping one revision to revert to. What
+// was freed is reported with a disk-space-reclaimed notice.
+func (m *SnapManager) ensureDiskSpaceReclaimed() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	now := timeNow()
+	if now.Before(m.diskPressureCheckNext) {
+		return nil
+	}
+
+	// only run after we are seeded
+	seeded, err := isSeeded(m.state)
+	if err != nil {
+		return err
+	}
+	if !seeded {
+		return nil
+	}
+	m.diskPressureCheckNext = now.Add(diskPressureCheckInterval)
+
+	minFree := minFreeSpace(m.state)
+	if minFree == 0 {
+		return nil
+	}
+	path := dirs.SnapdStateDir(dirs.GlobalRootDir)
+	free, err := osutilDiskFree(path)
+	if err != nil {
+		return fmt.Errorf("cannot check free space in %q: %v", path, err)
+	}
+	if free >= minFree {
+		return nil
+	}
+
+	logger.Trace("ensure", "manager", "SnapManager", "func", "ensureDiskSpaceReclaimed")
+	logger.Noticef("free space in %q is below %s, reclaiming disk space", path, strutil.SizeToStr(int64(minFree)))
+
+	target := minFree - free
+	var freed uint64
+	data := map[string]string{
+		"free-space":     strconv.FormatUint(free, 10),
+		"min-free-space": strconv.FormatUint(minFree, 10),
+	}
+
+	if sto := Store(m.state, nil); sto != nil {
+		cacheFreed, err := func() (uint64, error) {
+			m.state.Unlock()
+			defer m.state.Lock()
+			return sto.PruneDownloadsCache(target)
+		}()
+		// not fatal, other items may still be removed
+		if err != nil {
+			logger.Noticef("cannot prune store downloads cache: %v", err)
+		}
+		if cacheFreed > 0 {
+			data["downloads-cache"] = strconv.FormatUint(cacheFreed, 10)
+			freed += cacheFreed
+		}
+	}
+
+	if freed < target && PruneAutomaticSnapshots != nil {
+		sets, snapshotsFreed, err := PruneAutomaticSnapshots(m.state, target-freed)
+		if err != nil {
+			logger.Noticef("cannot prune automatic snapshots: %v", err)
+		}
+		if sets > 0 {
+			data["snapshot-sets"] = strconv.Itoa(sets)
+			data["snapshots"] = strconv.FormatUint(snapshotsFreed, 10)
+			freed += snapshotsFreed
+		}
+	}
+
+	if freed < target {
+		chg, removed, revisionsSize, err := pruneRevisions(m.state, target-freed)
+		if err != nil {
+			return fmt.Errorf("cannot prune old snap revisions: %v", err)
+		}
+		if chg != nil {
+			data["revisions"] = strings.Join(removed, ",")
+			data["revisions-size"] = strconv.FormatUint(revisionsSize, 10)
+			data["change-id"] = chg.ID()
+			freed += revisionsSize
+			m.state.EnsureBefore(0)
+		}
+	}
+
+	if len(data) == 2 {
+		logger.Noticef("cannot reclaim disk space in %q: nothing left to remove", path)
+		return nil
+	}
+	data["freed"] = strconv.FormatUint(freed, 10)
+	_, err = m.state.AddNotice(nil, state.DiskSpaceReclaimedNotice, path, &state.AddNoticeOptions{
+		Data: data,
+	})
+	return err
+}
@@ -0,0 +1,252 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+type pruningFakeStore struct {
+	fakeStore
+
+	pruneTargets []uint64
+	pruneFreed   uint64
+}
+
+func (f *pruningFakeStore) PruneDownloadsCache(target uint64) (uint64, error) {
+	f.pruneTargets = append(f.pruneTargets, target)
+	return f.pruneFreed, nil
+}
+
+func (s *snapmgrTestSuite) mockDiskPressure(c *C, minFreeSpace string, free uint64) (*pruningFakeStore, *int) {
+	sto := &pruningFakeStore{}
+	snapstate.ReplaceStore(s.state, sto)
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.min-free-space", minFreeSpace)
+	tr.Commit()
+
+	diskFreeCalls := 0
+	s.AddCleanup(snapstate.MockOsutilDiskFree(func(path string) (uint64, error) {
+		c.Check(path, Equals, dirs.SnapdStateDir(dirs.GlobalRootDir))
+		diskFreeCalls++
+		return free, nil
+	}))
+	return sto, &diskFreeCalls
+}
+
+func (s *snapmgrTestSuite) mockSnapWithRevisions(c *C, name string, current snap.Revision, sizes map[snap.Revision]int) {
+	var sis []*snap.SideInfo
+	for _, rev := range []snap.Revision{snap.R(1), snap.R(2), snap.R(3), snap.R(4)} {
+		sis = append(sis, &snap.SideInfo{RealName: name, SnapID: name + "-id", Revision: rev})
+		c.Assert(os.MkdirAll(dirs.SnapBlobDir, 0755), IsNil)
+		data := strings.Repeat("x", sizes[rev])
+		c.Assert(os.WriteFile(snap.MountFile(name, rev), []byte(data), 0644), IsNil)
+	}
+	snapstate.Set(s.state, name, &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos(sis),
+		Current:  current,
+		SnapType: "app",
+	})
+}
+
+func (s *snapmgrTestSuite) diskSpaceReclaimedNotices() []*state.Notice {
+	return s.state.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.DiskSpaceReclaimedNotice}})
+}
+
+func (s *snapmgrTestSuite) TestEnsureDiskSpaceReclaimedDisabled(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, diskFreeCalls := s.mockDiskPressure(c, "", 0)
+
+	s.state.Unlock()
+	err := s.snapmgr.EnsureDiskSpaceReclaimed()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(*diskFreeCalls, Equals, 0)
+	c.Check(s.diskSpaceReclaimedNotices(), HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) TestEnsureDiskSpaceReclaimedEnoughSpace(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	sto, diskFreeCalls := s.mockDiskPressure(c, "1000B", 1000)
+
+	s.state.Unlock()
+	err := s.snapmgr.EnsureDiskSpaceReclaimed()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(*diskFreeCalls, Equals, 1)
+	c.Check(sto.pruneTargets, HasLen, 0)
+	c.Check(s.diskSpaceReclaimedNotices(), HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) TestEnsureDiskSpaceReclaimed(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	now := time.Now()
+	defer snapstate.MockTimeNow(func() time.Time { return now })()
+
+	sto, diskFreeCalls := s.mockDiskPressure(c, "1000B", 100)
+	sto.pruneFreed = 300
+
+	var snapshotTargets []uint64
+	oldPruneAutomaticSnapshots := snapstate.PruneAutomaticSnapshots
+	snapstate.PruneAutomaticSnapshots = func(st *state.State, target uint64) (int, uint64, error) {
+		snapshotTargets = append(snapshotTargets, target)
+		return 1, 200, nil
+	}
+	defer func() { snapstate.PruneAutomaticSnapshots = oldPruneAutomaticSnapshots }()
+
+	// revisions 4 and 3 are kept, as the current revision and the one to
+	// revert to, revision 2 is the largest of the others
+	s.mockSnapWithRevisions(c, "some-snap", snap.R(4), map[snap.Revision]int{
+		snap.R(1): 250,
+		snap.R(2): 500,
+		snap.R(3): 1000,
+		snap.R(4): 1000,
+	})
+
+	s.state.Unlock()
+	err := s.snapmgr.EnsureDiskSpaceReclaimed()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(*diskFreeCalls, Equals, 1)
+	c.Check(sto.pruneTargets, DeepEquals, []uint64{900})
+	c.Check(snapshotTargets, DeepEquals, []uint64{600})
+
+	var chg *state.Change
+	for _, ch := range s.state.Changes() {
+		if ch.Kind() == "reclaim-disk-space" {
+			chg = ch
+		}
+	}
+	c.Assert(chg, NotNil)
+	c.Check(chg.Summary(), Equals, "Remove old snap revisions to reclaim disk space")
+	var kinds []string
+	for _, t := range chg.Tasks() {
+		kinds = append(kinds, t.Kind())
+		snapsup, err := snapstate.TaskSnapSetup(t)
+		c.Assert(err, IsNil)
+		c.Check(snapsup.Revision(), Equals, snap.R(2))
+	}
+	c.Check(kinds, DeepEquals, []string{"clear-snap", "discard-snap"})
+
+	notices := s.diskSpaceReclaimedNotices()
+	c.Assert(notices, HasLen, 1)
+	n := noticeToMap(c, notices[0])
+	c.Check(n["key"], Equals, dirs.SnapdStateDir(dirs.GlobalRootDir))
+	c.Check(n["last-data"], DeepEquals, map[string]any{
+		"free-space":      "100",
+		"min-free-space":  "1000",
+		"downloads-cache": "300",
+		"snapshot-sets":   "1",
+		"snapshots":       "200",
+		"revisions":       "some-snap/2",
+		"revisions-size":  "500",
+		"change-id":       chg.ID(),
+		"freed":           "1000",
+	})
+
+	// not checked again until the next interval
+	s.state.Unlock()
+	err = s.snapmgr.EnsureDiskSpaceReclaimed()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(*diskFreeCalls, Equals, 1)
+
+	// snaps with removals in progress are left alone
+	now = now.Add(11 * time.Minute)
+	s.state.Unlock()
+	err = s.snapmgr.EnsureDiskSpaceReclaimed()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(*diskFreeCalls, Equals, 2)
+	c.Check(s.state.Changes(), HasLen, 1)
+}
+
+func (s *snapmgrTestSuite) TestEnsureDiskSpaceReclaimedKeepsRevertTarget(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.mockDiskPressure(c, "1GB", 0)
+
+	oldPruneAutomaticSnapshots := snapstate.PruneAutomaticSnapshots
+	snapstate.PruneAutomaticSnapshots = nil
+	defer func() { snapstate.PruneAutomaticSnapshots = oldPruneAutomaticSnapshots }()
+
+	// reverted to the first revision, the latest one is the revert target
+	s.mockSnapWithRevisions(c, "some-snap", snap.R(1), nil)
+
+	s.state.Unlock()
+	err := s.snapmgr.EnsureDiskSpaceReclaimed()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+
+	chgs := s.state.Changes()
+	c.Assert(chgs, HasLen, 1)
+	var removed []snap.Revision
+	for _, t := range chgs[0].Tasks() {
+		if t.Kind() != "discard-snap" {
+			continue
+		}
+		snapsup, err := snapstate.TaskSnapSetup(t)
+		c.Assert(err, IsNil)
+		removed = append(removed, snapsup.Revision())
+	}
+	c.Check(removed, DeepEquals, []snap.Revision{snap.R(2), snap.R(3)})
+
+	notices := s.diskSpaceReclaimedNotices()
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0].LastData()["revisions"], Equals, "some-snap/2,some-snap/3")
+}
+
+func (s *snapmgrTestSuite) TestEnsureDiskSpaceReclaimedNothingToRemove(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	s.mockDiskPressure(c, "1GB", 0)
+
+	oldPruneAutomaticSnapshots := snapstate.PruneAutomaticSnapshots
+	snapstate.PruneAutomaticSnapshots = nil
+	defer func() { snapstate.PruneAutomaticSnapshots = oldPruneAutomaticSnapshots }()
+
+	s.state.Unlock()
+	err := s.snapmgr.EnsureDiskSpaceReclaimed()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(s.state.Changes(), HasLen, 0)
+	c.Check(s.diskSpaceReclaimedNotices(), HasLen, 0)
+}
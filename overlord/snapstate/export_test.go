@@ -64,6 +64,8 @@ const (
 	RevertFull   = revertFull
 )
 
+const MaxChangelogSize = maxChangelogSize
+
 func SetSnapManagerBackend(s *SnapManager, b ManagerBackend) {
 	s.backend = b
 }
@@ -274,6 +276,14 @@ func MockIsOnMeteredConnection(mock func() (bool, error)) func() {
 	}
 }
 
+func MockDeviceIdleSince(mock func() (time.Time, error)) func() {
+	old := DeviceIdleSince
+	DeviceIdleSince = mock
+	return func() {
+		DeviceIdleSince = old
+	}
+}
+
 func MockLocalInstallCleanupWait(d time.Duration) (restore func()) {
 	old := localInstallCleanupWait
 	localInstallCleanupWait = d
@@ -618,3 +628,35 @@ type CustomInstallGoal struct {
 func (c *CustomInstallGoal) toInstall(ctx context.Context, st *state.State, opts Options) ([]Target, error) {
 	return c.ToInstall(ctx, st, opts)
 }
+
+var RolloutDelay = rolloutDelay
+
+type (
+	PeerServer = peerServer
+	PeerClient = peerClient
+)
+
+var (
+	ErrPeerToPeerDisabled = errPeerToPeerDisabled
+	RecordPeerShareable   = recordPeerShareable
+)
+
+func MockNewPeerServer(f func(cacheDir string, ifaces []string, shareable func(sha3_384 string) bool) PeerServer) (restore func()) {
+	return testutil.Mock(&newPeerServer, f)
+}
+
+func MockNewPeerClient(f func() PeerClient) (restore func()) {
+	return testutil.Mock(&newPeerClient, f)
+}
+
+func (m *SnapManager) EnsurePeerDistribution() error {
+	return m.ensurePeerDistribution()
+}
+
+func MockOsutilDiskFree(f func(path string) (uint64, error)) (restore func()) {
+	return testutil.Mock(&osutilDiskFree, f)
+}
+
+func (m *SnapManager) EnsureDiskSpaceReclaimed() error {
+	return m.ensureDiskSpaceReclaimed()
+}
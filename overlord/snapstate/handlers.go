@@ -63,6 +63,7 @@ import (
 	"github.com/snapcore/snapd/snapdtool"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
 	"github.com/snapcore/snapd/timings"
 	userclient "github.com/snapcore/snapd/usersession/client"
 	"github.com/snapcore/snapd/wrappers"
@@ -77,6 +78,11 @@ var EnsureSnapAbsentFromQuotaGroup = func(st *state.State, snap string) error {
 	panic("internal error: snapstate.EnsureSnapAbsentFromQuotaGroup is unset")
 }
 
+// DiscardSnapHealth is a hook set by healthstate.
+var DiscardSnapHealth = func(st *state.State, snapName string) error {
+	panic("internal error: snapstate.DiscardSnapHealth is unset")
+}
+
 var SecurityProfilesRemoveLate = func(snapName string, rev snap.Revision, typ snap.Type) error {
 	panic("internal error: snapstate.SecurityProfilesRemoveLate is unset")
 }
@@ -709,10 +715,16 @@ func sendOneInstallActionUnlocked(ctx context.Context, st *state.State, snaps St
 // autoRefreshRateLimited returns the rate limit of auto-refreshes or 0 if
 // there is no limit.
 func autoRefreshRateLimited(st *state.State) (rate int64) {
+	return configuredRateLimit(st, "refresh.rate-limit")
+}
+
+// configuredRateLimit returns the rate limit in bytes/sec set in the given
+// core option or 0 if there is no limit.
+func configuredRateLimit(st *state.State, option string) (rate int64) {
 	tr := config.NewTransaction(st)
 
 	var rateLimit string
-	err := tr.Get("core", "refresh.rate-limit", &rateLimit)
+	err := tr.Get("core", option, &rateLimit)
 	if err != nil {
 		return 0
 	}
@@ -724,6 +736,57 @@ func autoRefreshRateLimited(st *state.State) (rate int64) {
 	return val
 }
 
+// downloadRateLimited returns the rate limit to apply to a download starting
+// now or 0 if there is no limit. The store.download-rate-limit applies to all
+// downloads, restricted to the windows of store.download-rate-limit-schedule
+// if that is set, while auto-refreshes are further limited by
+// refresh.rate-limit. The limit is chosen when the download starts, and
+// checked again during the download with downloadRateLimitRecheck.
+func downloadRateLimited(st *state.State, autoRefresh bool) (rate int64) {
+	rate = configuredRateLimit(st, "store.download-rate-limit")
+	if rate > 0 && !inDownloadRateLimitSchedule(st, timeNow()) {
+		rate = 0
+	}
+	if autoRefresh {
+		if autoRate := autoRefreshRateLimited(st); autoRate > 0 && (rate == 0 || autoRate < rate) {
+			rate = autoRate
+		}
+	}
+	return rate
+}
+
+// downloadRateLimitRecheck returns the function checking again the rate
+// limit of a running download, which store.download-rate-limit-schedule can
+// move in or out of a limited window, or nil if there is no schedule.
+func downloadRateLimitRecheck(st *state.State, autoRefresh bool) func() int64 {
+	tr := config.NewTransaction(st)
+	var scheduleStr string
+	if err := tr.GetMaybe("core", "store.download-rate-limit-schedule", &scheduleStr); err != nil || scheduleStr == "" {
+		return nil
+	}
+	return func() int64 {
+		st.Lock()
+		defer st.Unlock()
+		return downloadRateLimited(st, autoRefresh)
+	}
+}
+
+func inDownloadRateLimitSchedule(st *state.State, now time.Time) bool {
+	tr := config.NewTransaction(st)
+
+	var scheduleStr string
+	if err := tr.Get("core", "store.download-rate-limit-schedule", &scheduleStr); err != nil || scheduleStr == "" {
+		// no schedule, always limited
+		return true
+	}
+	schedule, err := timeutil.ParseSchedule(scheduleStr)
+	if err != nil {
+		logger.Noticef("cannot parse store.download-rate-limit-schedule %q, limiting downloads at all times: %v", scheduleStr, err)
+		return true
+	}
+	return timeutil.Includes(schedule, now)
+}
+
 func downloadSnapParams(st *state.State, t *state.Task) (*SnapSetup, StoreService, *auth.UserState, error) {
 	snapsup, err := TaskSnapSetup(t)
 	if err != nil {
@@ -759,14 +822,16 @@ func maybeCloudName(st *state.State) (name string, err error) {
 func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	st := t.State()
 	var rate int64
+	var rateRecheck func() int64
 	var cloud string
 
 	st.Lock()
 	perfTimings := state.TimingsForTask(t)
 	snapsup, theStore, user, err := downloadSnapParams(st, t)
-	if snapsup != nil && snapsup.IsAutoRefresh {
+	if snapsup != nil {
 		// NOTE rate is never negative
-		rate = autoRefreshRateLimited(st)
+		rate = downloadRateLimited(st, snapsup.IsAutoRefresh)
+		rateRecheck = downloadRateLimitRecheck(st, snapsup.IsAutoRefresh)
 	}
 
 	if err == nil {
@@ -788,8 +853,9 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	iconURL := snapsup.Media.IconURL()
 
 	dlOpts := &store.DownloadOptions{
-		Scheduled: snapsup.IsAutoRefresh,
-		RateLimit: rate,
+		Scheduled:        snapsup.IsAutoRefresh,
+		RateLimit:        rate,
+		RateLimitRecheck: rateRecheck,
 	}
 	if snapsup.DownloadInfo == nil {
 		vsets, err := EnforcedValidationSets(st)
@@ -850,10 +916,13 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 
 	// update the snap setup for the follow up tasks
 	st.Lock()
+	defer st.Unlock()
 	t.Set("snap-setup", snapsup)
 	perfTimings.Save(st)
-	st.Unlock()
 
+	if snapsup.DownloadInfo != nil {
+		return recordPeerShareable(st, snapsup.SideInfo, snapsup.DownloadInfo.Sha3_384)
+	}
 	return nil
 }
 
@@ -904,8 +973,9 @@ func (m *SnapManager) doPreDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	targetFn := snapsup.BlobPath()
 	dlOpts := &store.DownloadOptions{
 		// pre-downloads are only triggered in auto-refreshes
-		Scheduled: true,
-		RateLimit: autoRefreshRateLimited(st),
+		Scheduled:        true,
+		RateLimit:        downloadRateLimited(st, true),
+		RateLimitRecheck: downloadRateLimitRecheck(st, true),
 	}
 
 	perfTimings := state.TimingsForTask(t)
@@ -919,6 +989,12 @@ func (m *SnapManager) doPreDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 	}
 	perfTimings.Save(st)
 
+	if snapsup.DownloadInfo != nil {
+		if err := recordPeerShareable(st, snapsup.SideInfo, snapsup.DownloadInfo.Sha3_384); err != nil {
+			return err
+		}
+	}
+
 	var waitingTasks []string
 	if err := t.Get("waiting-tasks", &waitingTasks); err != nil && !errors.Is(err, &state.NoStateError{}) {
 		return err
@@ -3963,6 +4039,10 @@ func (m *SnapManager) doDiscardSnap(t *state.Task, _ *tomb.Tomb) error {
 		if err := EnsureSnapAbsentFromQuotaGroup(st, snapsup.InstanceName()); err != nil {
 			return err
 		}
+
+		if err := DiscardSnapHealth(st, snapsup.InstanceName()); err != nil {
+			return err
+		}
 	}
 	if err = config.DiscardRevisionConfig(st, snapsup.InstanceName(), snapsup.Revision()); err != nil {
 		return err
@@ -186,10 +186,8 @@ func (m *SnapManager) doDownloadComponent(t *state.Task, tomb *tomb.Tomb) error
 		return fmt.Errorf("cannot get user for user ID %d: %w", snapsup.UserID, err)
 	}
 
-	var rate int64
-	if snapsup.IsAutoRefresh {
-		rate = autoRefreshRateLimited(st)
-	}
+	rate := downloadRateLimited(st, snapsup.IsAutoRefresh)
+	rateRecheck := downloadRateLimitRecheck(st, snapsup.IsAutoRefresh)
 
 	target := compsup.BlobPath(snapsup.InstanceName())
 
@@ -201,8 +199,9 @@ func (m *SnapManager) doDownloadComponent(t *state.Task, tomb *tomb.Tomb) error
 	timings.Run(perf, "download", fmt.Sprintf("download component %q", compsup.ComponentName()), func(timings.Measurer) {
 		compRef := compsup.CompSideInfo.Component.String()
 		opts := &store.DownloadOptions{
-			Scheduled: snapsup.IsAutoRefresh,
-			RateLimit: rate,
+			Scheduled:        snapsup.IsAutoRefresh,
+			RateLimit:        rate,
+			RateLimitRecheck: rateRecheck,
 		}
 
 		err = sto.Download(tomb.Context(nil), compRef, target, compsup.DownloadInfo, meter, user, opts)
@@ -56,6 +56,19 @@ func (s *downloadComponentSuite) TestDoDownloadComponentAutoRefresh(c *C) {
 	})
 }
 
+func (s *downloadComponentSuite) TestDoDownloadComponentStoreRateLimited(c *C) {
+	s.testDoDownloadComponent(c, testDoDownloadComponentOpts{
+		storeRateLimit: "1000B",
+	})
+}
+
+func (s *downloadComponentSuite) TestDoDownloadComponentAutoRefreshStoreRateLimited(c *C) {
+	s.testDoDownloadComponent(c, testDoDownloadComponentOpts{
+		autoRefresh:    true,
+		storeRateLimit: "1000B",
+	})
+}
+
 func (s *downloadComponentSuite) TestDoDownloadComponentCustomBlobDir(c *C) {
 	s.testDoDownloadComponent(c, testDoDownloadComponentOpts{
 		blobDir: c.MkDir(),
@@ -63,8 +76,9 @@ func (s *downloadComponentSuite) TestDoDownloadComponentCustomBlobDir(c *C) {
 }
 
 type testDoDownloadComponentOpts struct {
-	autoRefresh bool
-	blobDir     string
+	autoRefresh    bool
+	blobDir        string
+	storeRateLimit string
 }
 
 func (s *downloadComponentSuite) testDoDownloadComponent(c *C, opts testDoDownloadComponentOpts) {
@@ -74,6 +88,7 @@ func (s *downloadComponentSuite) testDoDownloadComponent(c *C, opts testDoDownlo
 	// set auto-refresh rate-limit
 	tr := config.NewTransaction(s.state)
 	tr.Set("core", "refresh.rate-limit", "1234B")
+	tr.Set("core", "store.download-rate-limit", opts.storeRateLimit)
 	tr.Commit()
 
 	si := &snap.SideInfo{
@@ -137,7 +152,13 @@ func (s *downloadComponentSuite) testDoDownloadComponent(c *C, opts testDoDownlo
 	c.Check(t.Status(), Equals, state.DoneStatus)
 
 	var downloadOpts *store.DownloadOptions
-	if opts.autoRefresh {
+	switch {
+	case opts.storeRateLimit != "":
+		downloadOpts = &store.DownloadOptions{
+			RateLimit: 1000,
+			Scheduled: opts.autoRefresh,
+		}
+	case opts.autoRefresh:
 		downloadOpts = &store.DownloadOptions{
 			RateLimit: 1234,
 			Scheduled: true,
@@ -393,6 +393,40 @@ func (s *downloadSnapSuite) TestDoDownloadSnapNormal(c *C) {
 	})
 }
 
+func (s *downloadSnapSuite) TestDoDownloadSnapRecordsPeerShareable(c *C) {
+	s.state.Lock()
+
+	for _, si := range []*snap.SideInfo{
+		{RealName: "foo", SnapID: "fooID", Revision: snap.R(11)},
+		{RealName: "bar", SnapID: "barID", Revision: snap.R(12), Private: true},
+		{RealName: "baz", SnapID: "bazID", Revision: snap.R(13), Paid: true},
+	} {
+		t := s.state.NewTask("download-snap", "test")
+		t.Set("snap-setup", &snapstate.SnapSetup{
+			SideInfo: si,
+			DownloadInfo: &snap.DownloadInfo{
+				DownloadURL: "http://some-url.com/" + si.RealName,
+				Sha3_384:    si.RealName + "-sha3",
+			},
+		})
+		chg := s.state.NewChange("sample", "...")
+		chg.AddTask(t)
+	}
+
+	s.state.Unlock()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// only public snaps that are free are shared with peers
+	var shareable map[string]bool
+	c.Assert(s.state.Get("peer-shareable", &shareable), IsNil)
+	c.Check(shareable, DeepEquals, map[string]bool{"foo-sha3": true})
+}
+
 func (s *downloadSnapSuite) TestDoDownloadSnapWithDeviceContext(c *C) {
 	s.state.Lock()
 
@@ -521,3 +555,121 @@ func (s *downloadSnapSuite) TestDoDownloadRateLimitedIntegration(c *C) {
 	})
 
 }
+
+func (s *downloadSnapSuite) TestDoDownloadStoreRateLimited(c *C) {
+	// 10:00 on a Wednesday
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.Local)
+	restore := snapstate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	for _, tc := range []struct {
+		storeLimit  string
+		schedule    string
+		refreshRate string
+		autoRefresh bool
+		rate        int64
+	}{
+		// not limited
+		{rate: 0},
+		{refreshRate: "1234B", rate: 0},
+		// limited at all times
+		{storeLimit: "2000B", rate: 2000},
+		// limited within the schedule
+		{storeLimit: "2000B", schedule: "9:00-17:00", rate: 2000},
+		{storeLimit: "2000B", schedule: "mon-fri,9:00-17:00", rate: 2000},
+		{storeLimit: "2000B", schedule: "18:00-23:00", rate: 0},
+		{storeLimit: "2000B", schedule: "sat-sun,9:00-17:00", rate: 0},
+		// auto-refreshes get the lowest limit
+		{storeLimit: "2000B", refreshRate: "1234B", autoRefresh: true, rate: 1234},
+		{storeLimit: "1000B", refreshRate: "1234B", autoRefresh: true, rate: 1000},
+		{storeLimit: "1000B", schedule: "18:00-23:00", refreshRate: "1234B", autoRefresh: true, rate: 1234},
+	} {
+		s.fakeStore.downloads = nil
+
+		s.state.Lock()
+		tr := config.NewTransaction(s.state)
+		tr.Set("core", "store.download-rate-limit", tc.storeLimit)
+		tr.Set("core", "store.download-rate-limit-schedule", tc.schedule)
+		tr.Set("core", "refresh.rate-limit", tc.refreshRate)
+		tr.Commit()
+
+		si := &snap.SideInfo{
+			RealName: "foo",
+			SnapID:   "foo-id",
+			Revision: snap.R(11),
+		}
+		t := s.state.NewTask("download-snap", "test")
+		t.Set("snap-setup", &snapstate.SnapSetup{
+			SideInfo: si,
+			DownloadInfo: &snap.DownloadInfo{
+				DownloadURL: "http://some-url.com/snap",
+			},
+			Flags: snapstate.Flags{
+				IsAutoRefresh: tc.autoRefresh,
+			},
+		})
+		s.state.NewChange("sample", "...").AddTask(t)
+		s.state.Unlock()
+
+		s.se.Ensure()
+		s.se.Wait()
+
+		c.Assert(s.fakeStore.downloads, HasLen, 1, Commentf("%+v", tc))
+		var rate int64
+		if opts := s.fakeStore.downloads[0].opts; opts != nil {
+			rate = opts.RateLimit
+		}
+		c.Check(rate, Equals, tc.rate, Commentf("%+v", tc))
+	}
+}
+
+func (s *downloadSnapSuite) TestDoDownloadRateLimitRechecked(c *C) {
+	// 10:00 on a Wednesday
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.Local)
+	restore := snapstate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	for _, schedule := range []string{"", "9:00-17:00"} {
+		s.fakeStore.downloads = nil
+
+		s.state.Lock()
+		tr := config.NewTransaction(s.state)
+		tr.Set("core", "store.download-rate-limit", "2000B")
+		tr.Set("core", "store.download-rate-limit-schedule", schedule)
+		tr.Commit()
+
+		t := s.state.NewTask("download-snap", "test")
+		t.Set("snap-setup", &snapstate.SnapSetup{
+			SideInfo: &snap.SideInfo{
+				RealName: "foo",
+				SnapID:   "foo-id",
+				Revision: snap.R(11),
+			},
+			DownloadInfo: &snap.DownloadInfo{
+				DownloadURL: "http://some-url.com/snap",
+			},
+		})
+		s.state.NewChange("sample", "...").AddTask(t)
+		s.state.Unlock()
+
+		s.se.Ensure()
+		s.se.Wait()
+
+		c.Assert(s.fakeStore.downloads, HasLen, 1)
+		opts := s.fakeStore.downloads[0].opts
+		c.Assert(opts, NotNil)
+		c.Check(opts.RateLimit, Equals, int64(2000))
+		if schedule == "" {
+			// the limit does not depend on the time
+			c.Check(opts.RateLimitRecheck, IsNil)
+			continue
+		}
+
+		// the download goes on past the end of the window
+		c.Assert(opts.RateLimitRecheck, NotNil)
+		now = time.Date(2026, 10, 14, 17, 30, 0, 0, time.Local)
+		c.Check(opts.RateLimitRecheck(), Equals, int64(0))
+		now = time.Date(2026, 10, 15, 9, 30, 0, 0, time.Local)
+		c.Check(opts.RateLimitRecheck(), Equals, int64(2000))
+	}
+}
@@ -818,7 +818,7 @@ func (s *linkSnapSuite) TestDoUnlinkCurrentSnapSnapLockUnlocked(c *C) {
 	c.Check(appCheckCalled, Equals, 1)
 
 	// snap lock should be unlocked
-	lock, err := osutil.NewFileLock(filepath.Join(s.fakeBackend.lockDir, "pkg.lock"))
+	lock, err := osutil.NewFileLock(filepath.Join(dirs.SnapRunLockDir, "pkg.lock"))
 	c.Assert(err, IsNil)
 	defer lock.Close()
 	c.Assert(lock.TryLock(), IsNil)
@@ -0,0 +1,89 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/store/mirror"
+)
+
+var newMirrorStore = func(dir string) StoreService {
+	return mirror.New(dir)
+}
+
+// the store mirror implementation has the interface consumed here
+var _ StoreService = (*mirror.Store)(nil)
+
+type mirrorStoreKey struct{}
+
+type cachedMirrorStore struct {
+	dir string
+	sto StoreService
+}
+
+// mirrorStore returns the store serving snaps and assertions from the
+// directory set with store.mirror, or nil if it is unset or the directory is
+// not available, e.g. because the removable media holding it is unplugged.
+// The option is only read the first time, and then kept up to date by
+// SetStoreMirror.
+func mirrorStore(st *state.State) StoreService {
+	cached, ok := st.Cached(mirrorStoreKey{}).(*cachedMirrorStore)
+	if !ok {
+		tr := config.NewTransaction(st)
+		var dir string
+		if err := tr.GetMaybe("core", "store.mirror", &dir); err != nil {
+			logger.Noticef("cannot get store mirror configuration: %v", err)
+			return nil
+		}
+		cached = setStoreMirror(st, dir)
+	}
+	if cached.sto == nil {
+		return nil
+	}
+	if !osutil.IsDirectory(cached.dir) {
+		logger.Debugf("store mirror %q is not available, using the store", cached.dir)
+		return nil
+	}
+	return cached.sto
+}
+
+// SetStoreMirror sets the directory of the store mirror used instead of the
+// default store, as set with store.mirror. An empty directory means no
+// mirror is used.
+func SetStoreMirror(st *state.State, dir string) {
+	setStoreMirror(st, dir)
+}
+
+func setStoreMirror(st *state.State, dir string) *cachedMirrorStore {
+	// keep the same store while the directory is the same, as it caches
+	// the content of the directory
+	if cached, ok := st.Cached(mirrorStoreKey{}).(*cachedMirrorStore); ok && cached.dir == dir {
+		return cached
+	}
+	cached := &cachedMirrorStore{dir: dir}
+	if dir != "" {
+		cached.sto = newMirrorStore(dir)
+	}
+	st.Cache(mirrorStoreKey{}, cached)
+	return cached
+}
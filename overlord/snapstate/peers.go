@@ -0,0 +1,269 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/store/p2p"
+)
+
+type peerServer interface {
+	Start() error
+	Stop() error
+}
+
+type peerClient interface {
+	Fetch(ctx context.Context, name, sha3_384, targetPath string, size int64, pbar progress.Meter) error
+}
+
+var (
+	newPeerServer = func(cacheDir string, ifaces []string, shareable func(sha3_384 string) bool) peerServer {
+		cfg := p2p.DefaultConfig()
+		cfg.Interfaces = ifaces
+		return p2p.NewServer(cacheDir, cfg, shareable)
+	}
+	newPeerClient = func() peerClient {
+		return p2p.NewClient(nil)
+	}
+)
+
+var errPeerToPeerDisabled = errors.New("peer-to-peer distribution is disabled")
+
+// peerDistribution fetches snaps from peers on the local network when
+// store.peer-to-peer is enabled, and then also shares the snaps in the
+// download cache with the peers on the network interfaces listed in
+// store.peer-to-peer-interfaces.
+type peerDistribution struct {
+	mu      sync.Mutex
+	enabled bool
+	server  peerServer
+	// serverIfaces are the network interfaces the server shares on
+	serverIfaces string
+	// failedIfaces are the network interfaces the server last failed to
+	// start sharing on
+	failedIfaces string
+	client       peerClient
+
+	// shareableMu protects shareable on its own, as it is used by the
+	// server which is stopped with mu held
+	shareableMu sync.RWMutex
+	// shareable is the in-memory copy of the blobs recorded as shareable
+	// in the state, so that queries from peers do not need the state lock
+	shareable map[string]bool
+}
+
+func peerToPeerConfig(st *state.State) (enabled bool, ifaces string, err error) {
+	tr := config.NewTransaction(st)
+	if err := tr.GetMaybe("core", "store.peer-to-peer", &enabled); err != nil {
+		return false, "", err
+	}
+	if err := tr.GetMaybe("core", "store.peer-to-peer-interfaces", &ifaces); err != nil {
+		return false, "", err
+	}
+	return enabled, ifaces, nil
+}
+
+// recordPeerShareable records that the blob with the given digest, of the
+// snap with the given side info, can be shared with peers, which is only
+// the case for public snaps that are free.
+func recordPeerShareable(st *state.State, si *snap.SideInfo, sha3_384 string) error {
+	if sha3_384 == "" || si == nil || si.Private || si.Paid {
+		return nil
+	}
+	var shareable map[string]bool
+	if err := st.Get("peer-shareable", &shareable); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if shareable[sha3_384] {
+		return nil
+	}
+	if shareable == nil {
+		shareable = make(map[string]bool)
+	}
+	shareable[sha3_384] = true
+	st.Set("peer-shareable", shareable)
+	return nil
+}
+
+// prunePeerShareable forgets about the blobs that are not in the download
+// cache anymore.
+func prunePeerShareable(st *state.State) error {
+	var shareable map[string]bool
+	if err := st.Get("peer-shareable", &shareable); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	pruned := false
+	for sha3_384 := range shareable {
+		if !osutil.FileExists(filepath.Join(dirs.SnapDownloadCacheDir, sha3_384)) {
+			delete(shareable, sha3_384)
+			pruned = true
+		}
+	}
+	if pruned {
+		st.Set("peer-shareable", shareable)
+	}
+	return nil
+}
+
+// loadPeerShareable returns the blobs recorded as shareable with peers.
+func loadPeerShareable(st *state.State) (map[string]bool, error) {
+	var shareable map[string]bool
+	if err := st.Get("peer-shareable", &shareable); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return shareable, nil
+}
+
+// peerShareable returns whether the blob with the given digest can be
+// shared with peers. It is called by the peer server for every query from
+// the network, so it only looks at the in-memory copy refreshed by
+// ensurePeerDistribution.
+func (p *peerDistribution) peerShareable(sha3_384 string) bool {
+	p.shareableMu.RLock()
+	defer p.shareableMu.RUnlock()
+	return p.shareable[sha3_384]
+}
+
+func (p *peerDistribution) setShareable(shareable map[string]bool) {
+	p.shareableMu.Lock()
+	defer p.shareableMu.Unlock()
+	p.shareable = shareable
+}
+
+// Fetch implements store.PeerFetcher. It does not take the state lock, as
+// the store is used without it.
+func (p *peerDistribution) Fetch(ctx context.Context, name, sha3_384, targetPath string, size int64, pbar progress.Meter) error {
+	p.mu.Lock()
+	enabled := p.enabled
+	if enabled && p.client == nil {
+		p.client = newPeerClient()
+	}
+	client := p.client
+	p.mu.Unlock()
+
+	if !enabled {
+		return errPeerToPeerDisabled
+	}
+	return client.Fetch(ctx, name, sha3_384, targetPath, size, pbar)
+}
+
+// PeerFetcher returns the store.PeerFetcher fetching snaps from peers on
+// the local network, if store.peer-to-peer is enabled.
+func (m *SnapManager) PeerFetcher() store.PeerFetcher {
+	return m.peers
+}
+
+// ensurePeerDistribution enables fetching snaps from peers, and starts or
+// stops sharing the download cache with them, according to
+// store.peer-to-peer and store.peer-to-peer-interfaces.
+func (m *SnapManager) ensurePeerDistribution() error {
+	var shareable map[string]bool
+	m.state.Lock()
+	enabled, ifaces, err := peerToPeerConfig(m.state)
+	if err == nil && enabled {
+		err = prunePeerShareable(m.state)
+	}
+	if err == nil && enabled {
+		shareable, err = loadPeerShareable(m.state)
+	}
+	m.state.Unlock()
+	if err != nil {
+		return err
+	}
+
+	p := m.peers
+	p.setShareable(shareable)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.enabled = enabled
+	if !enabled {
+		ifaces = ""
+	}
+	if ifaces == "" {
+		p.failedIfaces = ""
+	}
+	if p.server != nil && ifaces == p.serverIfaces {
+		return nil
+	}
+	if p.server == nil && ifaces == "" {
+		return nil
+	}
+
+	logger.Trace("ensure", "manager", "SnapManager", "func", "ensurePeerDistribution")
+
+	if p.server != nil {
+		err := p.server.Stop()
+		p.server = nil
+		p.serverIfaces = ""
+		if err != nil {
+			return err
+		}
+	}
+	if ifaces == "" {
+		return nil
+	}
+
+	srv := newPeerServer(dirs.SnapDownloadCacheDir, strings.Split(ifaces, ","), p.peerShareable)
+	if err := srv.Start(); err != nil {
+		// not fatal, try again on the next Ensure, but only tell about it
+		// once for the same interfaces
+		if ifaces != p.failedIfaces {
+			logger.Noticef("cannot share snaps with peers: %v", err)
+			p.failedIfaces = ifaces
+		} else {
+			logger.Debugf("cannot share snaps with peers: %v", err)
+		}
+		return nil
+	}
+	p.server = srv
+	p.serverIfaces = ifaces
+	p.failedIfaces = ""
+	return nil
+}
+
+func (m *SnapManager) stopPeerDistribution() {
+	p := m.peers
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.server != nil {
+		if err := p.server.Stop(); err != nil {
+			logger.Noticef("cannot stop sharing snaps with peers: %v", err)
+		}
+		p.server = nil
+		p.serverIfaces = ""
+	}
+	p.enabled = false
+}
@@ -0,0 +1,211 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+)
+
+type fakePeerServer struct {
+	cacheDir  string
+	ifaces    []string
+	shareable func(sha3_384 string) bool
+	startErr  error
+	started   bool
+	stopped   bool
+}
+
+func (s *fakePeerServer) Start() error {
+	if s.startErr != nil {
+		return s.startErr
+	}
+	s.started = true
+	return nil
+}
+
+func (s *fakePeerServer) Stop() error {
+	s.stopped = true
+	return nil
+}
+
+type fakePeerClient struct {
+	fetched []string
+}
+
+func (c *fakePeerClient) Fetch(ctx context.Context, name, sha3_384, targetPath string, size int64, pbar progress.Meter) error {
+	c.fetched = append(c.fetched, name)
+	return nil
+}
+
+func (s *snapmgrTestSuite) setPeerToPeer(c *C, enabled bool, ifaces string) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "store.peer-to-peer", enabled), IsNil)
+	c.Assert(tr.Set("core", "store.peer-to-peer-interfaces", ifaces), IsNil)
+	tr.Commit()
+}
+
+func (s *snapmgrTestSuite) TestPeerDistribution(c *C) {
+	var servers []*fakePeerServer
+	s.AddCleanup(snapstate.MockNewPeerServer(func(cacheDir string, ifaces []string, shareable func(string) bool) snapstate.PeerServer {
+		srv := &fakePeerServer{cacheDir: cacheDir, ifaces: ifaces, shareable: shareable}
+		servers = append(servers, srv)
+		return srv
+	}))
+	cli := &fakePeerClient{}
+	s.AddCleanup(snapstate.MockNewPeerClient(func() snapstate.PeerClient {
+		return cli
+	}))
+	fetcher := s.snapmgr.PeerFetcher()
+
+	// disabled by default
+	c.Assert(s.snapmgr.EnsurePeerDistribution(), IsNil)
+	c.Check(servers, HasLen, 0)
+	err := fetcher.Fetch(context.Background(), "foo", "sha3", "target", 1234, nil)
+	c.Check(err, Equals, snapstate.ErrPeerToPeerDisabled)
+
+	// snaps are fetched from peers but not shared without interfaces
+	s.setPeerToPeer(c, true, "")
+	c.Assert(s.snapmgr.EnsurePeerDistribution(), IsNil)
+	c.Check(servers, HasLen, 0)
+	c.Assert(fetcher.Fetch(context.Background(), "foo", "sha3", "target", 1234, nil), IsNil)
+	c.Check(cli.fetched, DeepEquals, []string{"foo"})
+
+	s.setPeerToPeer(c, true, "eth0,wlan0")
+	c.Assert(s.snapmgr.EnsurePeerDistribution(), IsNil)
+	c.Assert(servers, HasLen, 1)
+	c.Check(servers[0].cacheDir, Equals, dirs.SnapDownloadCacheDir)
+	c.Check(servers[0].ifaces, DeepEquals, []string{"eth0", "wlan0"})
+	c.Check(servers[0].started, Equals, true)
+
+	// nothing changes while the options stay the same
+	c.Assert(s.snapmgr.EnsurePeerDistribution(), IsNil)
+	c.Check(servers, HasLen, 1)
+
+	// the server is restarted on the new interfaces
+	s.setPeerToPeer(c, true, "eth1")
+	c.Assert(s.snapmgr.EnsurePeerDistribution(), IsNil)
+	c.Assert(servers, HasLen, 2)
+	c.Check(servers[0].stopped, Equals, true)
+	c.Check(servers[1].ifaces, DeepEquals, []string{"eth1"})
+	c.Check(servers[1].started, Equals, true)
+
+	s.setPeerToPeer(c, false, "eth1")
+	c.Assert(s.snapmgr.EnsurePeerDistribution(), IsNil)
+	c.Check(servers[1].stopped, Equals, true)
+	err = fetcher.Fetch(context.Background(), "bar", "sha3", "target", 1234, nil)
+	c.Check(err, Equals, snapstate.ErrPeerToPeerDisabled)
+	c.Check(cli.fetched, DeepEquals, []string{"foo"})
+}
+
+func (s *snapmgrTestSuite) TestPeerDistributionStartErrorRetried(c *C) {
+	startErr := errors.New("boom")
+	var servers []*fakePeerServer
+	s.AddCleanup(snapstate.MockNewPeerServer(func(cacheDir string, ifaces []string, shareable func(string) bool) snapstate.PeerServer {
+		srv := &fakePeerServer{cacheDir: cacheDir, startErr: startErr}
+		servers = append(servers, srv)
+		return srv
+	}))
+	s.AddCleanup(snapstate.MockNewPeerClient(func() snapstate.PeerClient {
+		return &fakePeerClient{}
+	}))
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	s.setPeerToPeer(c, true, "eth0")
+	c.Assert(s.snapmgr.EnsurePeerDistribution(), IsNil)
+	c.Assert(servers, HasLen, 1)
+	c.Check(servers[0].started, Equals, false)
+	c.Check(strings.Count(logbuf.String(), "cannot share snaps with peers: boom"), Equals, 1)
+	// fetching from peers does not depend on sharing
+	err := s.snapmgr.PeerFetcher().Fetch(context.Background(), "foo", "sha3", "target", 1234, nil)
+	c.Check(err, IsNil)
+
+	// tried again on the next ensure, without telling about it again
+	c.Assert(s.snapmgr.EnsurePeerDistribution(), IsNil)
+	c.Assert(servers, HasLen, 2)
+	c.Check(servers[1].started, Equals, false)
+	c.Check(strings.Count(logbuf.String(), "cannot share snaps with peers: boom"), Equals, 1)
+
+	startErr = nil
+	c.Assert(s.snapmgr.EnsurePeerDistribution(), IsNil)
+	c.Assert(servers, HasLen, 3)
+	c.Check(servers[2].started, Equals, true)
+}
+
+func (s *snapmgrTestSuite) TestPeerShareable(c *C) {
+	var shareable func(string) bool
+	s.AddCleanup(snapstate.MockNewPeerServer(func(cacheDir string, ifaces []string, f func(string) bool) snapstate.PeerServer {
+		shareable = f
+		return &fakePeerServer{}
+	}))
+	c.Assert(os.MkdirAll(dirs.SnapDownloadCacheDir, 0755), IsNil)
+	for _, digest := range []string{"public", "private", "paid"} {
+		c.Assert(os.WriteFile(filepath.Join(dirs.SnapDownloadCacheDir, digest), nil, 0644), IsNil)
+	}
+
+	s.state.Lock()
+	c.Assert(snapstate.RecordPeerShareable(s.state, &snap.SideInfo{RealName: "foo"}, "public"), IsNil)
+	c.Assert(snapstate.RecordPeerShareable(s.state, &snap.SideInfo{RealName: "foo", Private: true}, "private"), IsNil)
+	c.Assert(snapstate.RecordPeerShareable(s.state, &snap.SideInfo{RealName: "foo", Paid: true}, "paid"), IsNil)
+	c.Assert(snapstate.RecordPeerShareable(s.state, &snap.SideInfo{RealName: "foo"}, "gone"), IsNil)
+	s.state.Unlock()
+
+	s.setPeerToPeer(c, true, "eth0")
+	c.Assert(s.snapmgr.EnsurePeerDistribution(), IsNil)
+	c.Assert(shareable, NotNil)
+
+	c.Check(shareable("public"), Equals, true)
+	c.Check(shareable("private"), Equals, false)
+	c.Check(shareable("paid"), Equals, false)
+	c.Check(shareable("unknown"), Equals, false)
+
+	// blobs gone from the cache are forgotten
+	s.state.Lock()
+	var recorded map[string]bool
+	c.Assert(s.state.Get("peer-shareable", &recorded), IsNil)
+	c.Check(recorded, DeepEquals, map[string]bool{"public": true})
+
+	// queries from peers do not need the state lock
+	c.Check(shareable("public"), Equals, true)
+
+	// newly recorded blobs are shared from the next ensure
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapDownloadCacheDir, "new"), nil, 0644), IsNil)
+	c.Assert(snapstate.RecordPeerShareable(s.state, &snap.SideInfo{RealName: "foo"}, "new"), IsNil)
+	s.state.Unlock()
+	c.Check(shareable("new"), Equals, false)
+	c.Assert(s.snapmgr.EnsurePeerDistribution(), IsNil)
+	c.Check(shareable("new"), Equals, true)
+}
@@ -0,0 +1,252 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"context"
+	"sort"
+
+	"github.com/snapcore/snapd/features"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// RefreshImpact describes what refreshing a snap would do.
+type RefreshImpact struct {
+	InstanceName    string
+	CurrentRevision snap.Revision
+	Revision        snap.Revision
+	Version         string
+	Channel         string
+
+	// DownloadSize is the size of the snap and of its components.
+	DownloadSize int64
+	// DiskSpace is the disk space needed for the snap and the
+	// prerequisites that would be installed with it.
+	DiskSpace uint64
+
+	// Base is the base of the new revision and PreviousBase the base of the
+	// current one, the latter is only set if the base changes.
+	Base         string
+	PreviousBase string
+	// Prerequisites are the base and default content providers that would
+	// be installed with the snap.
+	Prerequisites []string
+
+	// NewPlugs and NewSlots are the plugs and slots that the new revision
+	// adds, RemovedPlugs and RemovedSlots the ones it drops.
+	NewPlugs     []string
+	NewSlots     []string
+	RemovedPlugs []string
+	RemovedSlots []string
+	// Disconnected are the connections of the removed plugs and slots.
+	Disconnected []string
+
+	// RestartServices are the services that are stopped and started again.
+	RestartServices []string
+	// GateAutoRefreshHooks are the snaps whose gate-auto-refresh hook would
+	// be run before an auto-refresh of the snap.
+	GateAutoRefreshHooks []string
+}
+
+// RefreshDryRun reports what refreshing the given snaps, or all snaps if
+// none are given, would do. It runs the same update planning as an actual
+// refresh but does not queue any tasks.
+// The state must be locked by the caller.
+func RefreshDryRun(ctx context.Context, st *state.State, names []string, opts Options) ([]*RefreshImpact, error) {
+	if err := setDefaultSnapstateOptions(st, &opts); err != nil {
+		return nil, err
+	}
+
+	updates := make([]StoreUpdate, 0, len(names))
+	for _, name := range names {
+		updates = append(updates, StoreUpdate{InstanceName: name})
+	}
+	goal := StoreUpdateGoal(updates...)
+	plan, err := goal.toUpdate(ctx, st, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := plan.filterHeldSnaps(st, opts); err != nil {
+		return nil, err
+	}
+	if err := goal.filterGatedSnaps(st, &plan, opts); err != nil {
+		return nil, err
+	}
+
+	allSnaps, err := All(st)
+	if err != nil {
+		return nil, err
+	}
+
+	impacts := make(map[string]*RefreshImpact, len(plan.targets))
+	updated := make([]string, 0, len(plan.targets))
+	for _, t := range plan.targets {
+		if t.snapst.IsInstalled() && t.snapst.Current == t.info.Revision {
+			continue
+		}
+		impact, err := refreshImpact(st, t, allSnaps, opts)
+		if err != nil {
+			return nil, err
+		}
+		impacts[impact.InstanceName] = impact
+		updated = append(updated, impact.InstanceName)
+	}
+
+	tr := config.NewTransaction(st)
+	gateAutoRefreshHook, err := features.Flag(tr, features.GateAutoRefreshHook)
+	if err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	if gateAutoRefreshHook && len(updated) > 0 {
+		affected, err := affectedByRefresh(st, updated)
+		if err != nil {
+			return nil, err
+		}
+		for gatingSnap, affectedInfo := range affected {
+			for affecting := range affectedInfo.AffectingSnaps {
+				if impact := impacts[affecting]; impact != nil {
+					impact.GateAutoRefreshHooks = append(impact.GateAutoRefreshHooks, gatingSnap)
+				}
+			}
+		}
+	}
+
+	sort.Strings(updated)
+	report := make([]*RefreshImpact, 0, len(updated))
+	for _, name := range updated {
+		impact := impacts[name]
+		sort.Strings(impact.GateAutoRefreshHooks)
+		report = append(report, impact)
+	}
+	return report, nil
+}
+
+// effectiveBase returns the base of the snap, taking into account that apps
+// without an explicit base use core.
+func effectiveBase(info *snap.Info) string {
+	if info.Type() == snap.TypeApp && info.Base == "" {
+		return defaultCoreSnapName
+	}
+	return info.Base
+}
+
+func refreshImpact(st *state.State, t target, allSnaps map[string]*SnapState, opts Options) (*RefreshImpact, error) {
+	info := t.info
+	impact := &RefreshImpact{
+		InstanceName: info.InstanceName(),
+		Revision:     info.Revision,
+		Version:      info.Version,
+		Channel:      t.setup.Channel,
+		DownloadSize: info.Size,
+		Base:         effectiveBase(info),
+	}
+	for _, comp := range t.components {
+		if comp.DownloadInfo != nil {
+			impact.DownloadSize += comp.DownloadInfo.Size
+		}
+	}
+
+	if info.Size > 0 {
+		size, err := installSize(st, []minimalInstallInfo{installSnapInfo{info}}, opts.UserID, nil)
+		if err != nil {
+			return nil, err
+		}
+		impact.DiskSpace = safetyMarginDiskSpace(size)
+	}
+
+	if info.Type() == snap.TypeApp && impact.Base != "none" && allSnaps[impact.Base] == nil {
+		impact.Prerequisites = append(impact.Prerequisites, impact.Base)
+	}
+	for provider := range defaultProviderContentAttrs(st, info, nil) {
+		if allSnaps[provider] == nil {
+			impact.Prerequisites = append(impact.Prerequisites, provider)
+		}
+	}
+	sort.Strings(impact.Prerequisites)
+
+	if !t.snapst.IsInstalled() {
+		return impact, nil
+	}
+	curInfo, err := t.snapst.CurrentInfo()
+	if err != nil {
+		return nil, err
+	}
+	impact.CurrentRevision = curInfo.Revision
+	if prevBase := effectiveBase(curInfo); prevBase != impact.Base {
+		impact.PreviousBase = prevBase
+	}
+
+	for name := range info.Plugs {
+		if curInfo.Plugs[name] == nil {
+			impact.NewPlugs = append(impact.NewPlugs, name)
+		}
+	}
+	for name := range info.Slots {
+		if curInfo.Slots[name] == nil {
+			impact.NewSlots = append(impact.NewSlots, name)
+		}
+	}
+	repo := ifacerepo.Get(st)
+	for name := range curInfo.Plugs {
+		if info.Plugs[name] != nil {
+			continue
+		}
+		impact.RemovedPlugs = append(impact.RemovedPlugs, name)
+		conns, err := repo.Connected(curInfo.InstanceName(), name)
+		if err != nil {
+			return nil, err
+		}
+		for _, cref := range conns {
+			impact.Disconnected = append(impact.Disconnected, cref.ID())
+		}
+	}
+	for name := range curInfo.Slots {
+		if info.Slots[name] != nil {
+			continue
+		}
+		impact.RemovedSlots = append(impact.RemovedSlots, name)
+		conns, err := repo.Connected(curInfo.InstanceName(), name)
+		if err != nil {
+			return nil, err
+		}
+		for _, cref := range conns {
+			impact.Disconnected = append(impact.Disconnected, cref.ID())
+		}
+	}
+	sort.Strings(impact.NewPlugs)
+	sort.Strings(impact.NewSlots)
+	sort.Strings(impact.RemovedPlugs)
+	sort.Strings(impact.RemovedSlots)
+	sort.Strings(impact.Disconnected)
+
+	for _, app := range curInfo.Services() {
+		// services with endure refresh-mode are kept running
+		if app.RefreshMode == "endure" {
+			continue
+		}
+		impact.RestartServices = append(impact.RestartServices, app.Name)
+	}
+	sort.Strings(impact.RestartServices)
+
+	return impact, nil
+}
@@ -0,0 +1,153 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"context"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+const dryRunSnapYaml = `name: snap-d
+type: app
+version: 1
+slots:
+    slot: desktop
+apps:
+    svc:
+        daemon: simple
+    enduring:
+        daemon: simple
+        refresh-mode: endure
+    cmd:
+`
+
+const dryRunSnapNewYaml = `name: snap-d
+type: app
+version: 2
+base: core22
+plugs:
+    net: network
+apps:
+    svc:
+        daemon: simple
+`
+
+func (s *autorefreshGatingSuite) mockRefreshDryRun(c *C) {
+	s.state.Set("seeded", true)
+	s.AddCleanup(snapstatetest.MockDeviceModel(DefaultModel()))
+	s.AddCleanup(snapstate.MockInstallSize(func(st *state.State, snaps []snapstate.MinimalInstallInfo, userID int, prqt snapstate.PrereqTracker) (uint64, error) {
+		c.Assert(snaps, HasLen, 1)
+		return uint64(snaps[0].DownloadSize()) + 2000, nil
+	}))
+
+	snapD := mockInstalledSnap(c, s.state, dryRunSnapYaml, noHook)
+	snapE := mockInstalledSnap(c, s.state, snapEyaml, useHook)
+	for _, info := range []*snap.Info{snapD, snapE} {
+		appSet, err := interfaces.NewSnapAppSet(info, nil)
+		c.Assert(err, IsNil)
+		c.Assert(s.repo.AddAppSet(appSet), IsNil)
+	}
+	cref := &interfaces.ConnRef{PlugRef: interfaces.PlugRef{Snap: "snap-e", Name: "plug"}, SlotRef: interfaces.SlotRef{Snap: "snap-d", Name: "slot"}}
+	_, err := s.repo.Connect(cref, nil, nil, nil, nil, nil)
+	c.Assert(err, IsNil)
+
+	newInfo := snaptest.MockInfo(c, dryRunSnapNewYaml, &snap.SideInfo{
+		RealName: "snap-d",
+		SnapID:   "snap-d-id",
+		Revision: snap.R(5),
+	})
+	newInfo.Architectures = []string{"all"}
+	newInfo.Size = 1000
+	s.store.refreshedSnaps = []*snap.Info{newInfo}
+}
+
+func (s *autorefreshGatingSuite) TestRefreshDryRun(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	s.mockRefreshDryRun(c)
+
+	tr := config.NewTransaction(st)
+	tr.Set("core", "experimental.gate-auto-refresh-hook", true)
+	tr.Commit()
+
+	report, err := snapstate.RefreshDryRun(context.Background(), st, nil, snapstate.Options{})
+	c.Assert(err, IsNil)
+	c.Check(report, DeepEquals, []*snapstate.RefreshImpact{{
+		InstanceName:         "snap-d",
+		CurrentRevision:      snap.R(1),
+		Revision:             snap.R(5),
+		Version:              "2",
+		DownloadSize:         1000,
+		DiskSpace:            3000 + 5*1024*1024,
+		Base:                 "core22",
+		PreviousBase:         "core",
+		Prerequisites:        []string{"core22"},
+		NewPlugs:             []string{"net"},
+		RemovedSlots:         []string{"slot"},
+		Disconnected:         []string{"snap-e:plug snap-d:slot"},
+		RestartServices:      []string{"svc"},
+		GateAutoRefreshHooks: []string{"snap-e"},
+	}})
+
+	// nothing was queued
+	c.Check(st.Changes(), HasLen, 0)
+	c.Check(st.TaskCount(), Equals, 0)
+	var candidates map[string]any
+	c.Check(st.Get("refresh-candidates", &candidates), testutil.ErrorIs, state.ErrNoState)
+}
+
+func (s *autorefreshGatingSuite) TestRefreshDryRunNoGateAutoRefreshHook(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	s.mockRefreshDryRun(c)
+
+	report, err := snapstate.RefreshDryRun(context.Background(), st, nil, snapstate.Options{})
+	c.Assert(err, IsNil)
+	c.Assert(report, HasLen, 1)
+	c.Check(report[0].InstanceName, Equals, "snap-d")
+	c.Check(report[0].GateAutoRefreshHooks, HasLen, 0)
+}
+
+func (s *autorefreshGatingSuite) TestRefreshDryRunNoUpdates(c *C) {
+	st := s.state
+	st.Lock()
+	defer st.Unlock()
+
+	s.mockRefreshDryRun(c)
+	s.store.refreshedSnaps = nil
+
+	report, err := snapstate.RefreshDryRun(context.Background(), st, nil, snapstate.Options{})
+	c.Assert(err, IsNil)
+	c.Check(report, HasLen, 0)
+}
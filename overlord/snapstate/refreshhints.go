@@ -32,6 +32,7 @@ import (
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/overlord/swfeats"
 	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/store"
 	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/timings"
@@ -91,7 +92,7 @@ func (r *refreshHints) refresh() error {
 	var plan updatePlan
 	timings.Run(perfTimings, "refresh-candidates", "query store for refresh candidates", func(tm timings.Measurer) {
 		plan, err = storeUpdatePlan(auth.EnsureContextTODO(),
-			r.state, allSnaps, nil, nil, &store.RefreshOptions{RefreshManaged: refreshManaged}, Options{})
+			r.state, allSnaps, nil, nil, &store.RefreshOptions{RefreshManaged: refreshManaged, IncludeChangelog: true}, Options{})
 	})
 	// TODO: we currently set last-refresh-hints even when there was an
 	// error. In the future we may retry with a backoff.
@@ -184,6 +185,7 @@ func refreshHintsFromUpdatePlan(st *state.State, plan updatePlan, deviceCtx Devi
 	}
 
 	hints := make(map[string]*refreshCandidate, len(plan.targets))
+	infos := make([]*snap.Info, 0, len(plan.targets))
 	for _, t := range plan.targets {
 		info := t.info
 		var snapst SnapState
@@ -212,6 +214,21 @@ func refreshHintsFromUpdatePlan(st *state.State, plan updatePlan, deviceCtx Devi
 			SnapSetup:  snapsup,
 			Components: compsups,
 			Monitored:  IsSnapMonitored(st, info.InstanceName()),
+			Changelog:  truncateChangelog(info.Changelog),
+		}
+		infos = append(infos, info)
+	}
+
+	// record the delay of the staged rollout, if any, to tell why a
+	// candidate is not refreshed yet
+	rolloutDue, err := recordRolloutCandidates(st, infos, false)
+	if err != nil {
+		return nil, err
+	}
+	now := timeNow()
+	for name, hint := range hints {
+		if due, ok := rolloutDue[name]; ok && due.After(now) {
+			hint.RolloutDue = &due
 		}
 	}
 	return hints, nil
@@ -22,6 +22,7 @@ package snapstate_test
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	. "gopkg.in/check.v1"
@@ -154,7 +155,7 @@ func (s *refreshHintsTestSuite) TestListRefresh(c *C) {
 	c.Check(err, IsNil)
 	c.Check(s.store.ops, DeepEquals, []string{"list-refresh"})
 	c.Check(s.store.opOpts, DeepEquals, []store.RefreshOptions{
-		{PrivacyKey: "privacy-key"},
+		{PrivacyKey: "privacy-key", IncludeChangelog: true},
 	})
 }
 
@@ -174,7 +175,7 @@ func (s *refreshHintsTestSuite) TestListRefreshReportsManaged(c *C) {
 	c.Check(err, IsNil)
 	c.Check(s.store.ops, DeepEquals, []string{"list-refresh"})
 	c.Check(s.store.opOpts, DeepEquals, []store.RefreshOptions{
-		{RefreshManaged: true, PrivacyKey: "privacy-key"},
+		{RefreshManaged: true, PrivacyKey: "privacy-key", IncludeChangelog: true},
 	})
 }
 
@@ -300,6 +301,8 @@ func (s *refreshHintsTestSuite) TestRefreshHintsStoresRefreshCandidates(c *C) {
 		},
 	}
 	info2.Plugs = plugs
+	// changelogs from the store are cut as the ones shipped in snaps
+	info2.Changelog = strings.Repeat("€", snapstate.MaxChangelogSize)
 
 	s.store.refreshedSnaps = []*snap.Info{{
 		Version:       "2",
@@ -314,6 +317,7 @@ func (s *refreshHintsTestSuite) TestRefreshHintsStoresRefreshCandidates(c *C) {
 		DownloadInfo: snap.DownloadInfo{
 			Size: int64(99),
 		},
+		Changelog: "* new and improved",
 	}, info2}
 
 	restore := snapstate.MockReadComponentInfo(func(compMntDir string, info *snap.Info, csi *snap.ComponentSideInfo) (*snap.ComponentInfo, error) {
@@ -347,6 +351,7 @@ func (s *refreshHintsTestSuite) TestRefreshHintsStoresRefreshCandidates(c *C) {
 	c.Check(cand1.Type(), Equals, snap.TypeApp)
 	c.Check(cand1.DownloadSize(), Equals, int64(99))
 	c.Check(cand1.Version, Equals, "2")
+	c.Check(cand1.Changelog, Equals, "* new and improved")
 
 	cand2 := candidates["other-snap"]
 	c.Assert(cand2, NotNil)
@@ -355,6 +360,7 @@ func (s *refreshHintsTestSuite) TestRefreshHintsStoresRefreshCandidates(c *C) {
 	c.Check(cand2.Type(), Equals, snap.TypeApp)
 	c.Check(cand2.DownloadSize(), Equals, int64(88))
 	c.Check(cand2.Version, Equals, "v1")
+	c.Check(cand2.Changelog, Equals, strings.Repeat("€", snapstate.MaxChangelogSize/3))
 	c.Check(cand2.Components, HasLen, 1)
 	c.Check(cand2.Components[0].CompSideInfo.Component, Equals, naming.NewComponentRef("other-snap", "comp1"))
 	c.Check(cand2.Components[0].CompSideInfo.Revision, Equals, snap.R(2))
@@ -642,6 +648,47 @@ func (s *refreshHintsTestSuite) TestRefreshHintsAbortsMonitoringForRemovedCandid
 	}
 }
 
+func (s *refreshHintsTestSuite) TestRefreshHintsRecordRolloutDelay(c *C) {
+	now := time.Now()
+	defer snapstate.MockTimeNow(func() time.Time { return now })()
+	snapstate.DeviceSerial = func(*state.State) (string, error) { return "serial-1", nil }
+	defer func() { snapstate.DeviceSerial = nil }()
+
+	s.state.Lock()
+	ifacerepo.Replace(s.state, interfaces.NewRepository())
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.rollout-window", "24h")
+	tr.Commit()
+	s.state.Unlock()
+
+	s.store.refreshedSnaps = []*snap.Info{{
+		Architectures: []string{"all"},
+		SnapType:      snap.TypeApp,
+		SideInfo: snap.SideInfo{
+			RealName: "some-snap",
+			Revision: snap.R(6),
+			SnapID:   "some-snap-id",
+		},
+	}}
+
+	rh := snapstate.NewRefreshHints(s.state)
+	c.Assert(rh.Ensure(), IsNil)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var candidates map[string]*snapstate.RefreshCandidate
+	c.Assert(s.state.Get("refresh-candidates", &candidates), IsNil)
+	c.Assert(candidates["some-snap"], NotNil)
+	expectedDue := now.Add(snapstate.RolloutDelay("serial-1", "some-snap", snap.R(6), 24*time.Hour))
+	c.Assert(candidates["some-snap"].RolloutDue, NotNil)
+	c.Check(candidates["some-snap"].RolloutDue.Equal(expectedDue), Equals, true)
+
+	due, err := snapstate.RefreshRolloutDue(s.state, "some-snap", snap.R(6))
+	c.Assert(err, IsNil)
+	c.Check(due.Equal(expectedDue), Equals, true)
+}
+
 func (s *refreshHintsTestSuite) TestRefreshHintsNotApplicableWrongEpoch(c *C) {
 	s.state.Lock()
 	repo := interfaces.NewRepository()
@@ -0,0 +1,184 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// DeviceSerial is a hook setup by devicestate returning the serial of the
+// device, or an empty string if it has none yet.
+var DeviceSerial func(st *state.State) (string, error)
+
+// rolloutState tracks when a revision of a snap was first seen as a refresh
+// candidate, which is when the staged rollout of the revision starts on this
+// device.
+type rolloutState struct {
+	Revision  snap.Revision `json:"revision"`
+	FirstSeen time.Time     `json:"first-seen"`
+}
+
+// refreshRolloutWindow returns the window over which auto-refreshes of new
+// revisions are spread across devices, zero if they are not delayed.
+func refreshRolloutWindow(st *state.State) (time.Duration, error) {
+	tr := config.NewTransaction(st)
+	var window string
+	if err := tr.Get("core", "refresh.rollout-window", &window); err != nil && !config.IsNoOption(err) {
+		return 0, err
+	}
+	if window == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil {
+		return 0, fmt.Errorf("invalid refresh.rollout-window: %v", err)
+	}
+	return d, nil
+}
+
+// rolloutDelay returns how long the auto-refresh of the given revision is
+// delayed on this device, within the given window. The delay is derived from
+// the device serial so that it is stable for the device, but spread evenly
+// across devices.
+func rolloutDelay(serial, instanceName string, rev snap.Revision, window time.Duration) time.Duration {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s", serial, instanceName, rev)))
+	fraction := float64(binary.BigEndian.Uint64(h[:8])) / (1 << 64)
+	return time.Duration(fraction * float64(window))
+}
+
+func rolloutStates(st *state.State) (map[string]*rolloutState, error) {
+	var rollouts map[string]*rolloutState
+	if err := st.Get("refresh-rollout", &rollouts); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	return rollouts, nil
+}
+
+// rolloutDue returns when the auto-refresh of the given revision can happen
+// according to the staged rollout, given when the revision was first seen.
+// The zero time means it is not delayed.
+func rolloutDue(st *state.State, instanceName string, rev snap.Revision, firstSeen time.Time) (time.Time, error) {
+	window, err := refreshRolloutWindow(st)
+	if err != nil || window == 0 {
+		return time.Time{}, err
+	}
+	if DeviceSerial == nil {
+		return time.Time{}, nil
+	}
+	serial, err := DeviceSerial(st)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if serial == "" {
+		// without a serial there is nothing to derive a stable delay from
+		return time.Time{}, nil
+	}
+	return firstSeen.Add(rolloutDelay(serial, instanceName, rev, window)), nil
+}
+
+// recordRolloutCandidates records when the revisions of the given snaps were
+// first seen as refresh candidates, and returns when their auto-refresh is
+// due. When dropOthers is set, the records of other snaps are dropped.
+func recordRolloutCandidates(st *state.State, infos []*snap.Info, dropOthers bool) (map[string]time.Time, error) {
+	rollouts, err := rolloutStates(st)
+	if err != nil {
+		return nil, err
+	}
+	now := timeNow()
+	seen := make(map[string]*rolloutState, len(infos))
+	due := make(map[string]time.Time, len(infos))
+	for _, info := range infos {
+		name := info.InstanceName()
+		rollout := rollouts[name]
+		if rollout == nil || rollout.Revision != info.Revision {
+			rollout = &rolloutState{Revision: info.Revision, FirstSeen: now}
+		}
+		seen[name] = rollout
+		t, err := rolloutDue(st, name, info.Revision, rollout.FirstSeen)
+		if err != nil {
+			return nil, err
+		}
+		due[name] = t
+	}
+	if !dropOthers {
+		for name, rollout := range rollouts {
+			if _, ok := seen[name]; !ok {
+				seen[name] = rollout
+			}
+		}
+	}
+	if len(seen) == 0 {
+		st.Set("refresh-rollout", nil)
+	} else {
+		st.Set("refresh-rollout", seen)
+	}
+	return due, nil
+}
+
+// RefreshRolloutDue returns until when the auto-refresh of the given revision
+// of the snap is delayed by the staged rollout, or the zero time if it is not.
+func RefreshRolloutDue(st *state.State, instanceName string, rev snap.Revision) (time.Time, error) {
+	rollouts, err := rolloutStates(st)
+	if err != nil {
+		return time.Time{}, err
+	}
+	rollout := rollouts[instanceName]
+	if rollout == nil || rollout.Revision != rev {
+		return time.Time{}, nil
+	}
+	due, err := rolloutDue(st, instanceName, rev, rollout.FirstSeen)
+	if err != nil || !due.After(timeNow()) {
+		return time.Time{}, err
+	}
+	return due, nil
+}
+
+// filterRolloutDelayedSnaps removes the targets from the update plan whose
+// auto-refresh is still delayed by the staged rollout. It only applies to
+// auto-refreshes.
+func (p *updatePlan) filterRolloutDelayedSnaps(st *state.State, opts Options) error {
+	if !opts.Flags.IsAutoRefresh {
+		return nil
+	}
+
+	due, err := recordRolloutCandidates(st, p.targetInfos(), p.refreshAll())
+	if err != nil {
+		return err
+	}
+
+	now := timeNow()
+	return p.filter(func(t target) (bool, error) {
+		name := t.info.InstanceName()
+		if due[name].After(now) {
+			logger.Noticef("auto-refresh of %q to revision %s is delayed by the staged rollout until %s", name, t.info.Revision, due[name].Format(time.RFC3339))
+			return false, nil
+		}
+		return true, nil
+	})
+}
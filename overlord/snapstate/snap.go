@@ -409,6 +409,14 @@ func (sc *snapInstallChoreographer) AfterLinkSnapAndPostReboot(st *state.State,
 	s.Append(startSnapServices)
 	s.UpdateEdge(startSnapServices, EndEdge)
 
+	// verify the refreshed snap now that its services run and its
+	// connections are in place, a failure reverts the refresh
+	if sc.runRefreshHooks() {
+		check := SetupPostRefreshCheckHook(st, sc.snapsup.InstanceName())
+		s.Append(check)
+		s.UpdateEdge(check, EndEdge)
+	}
+
 	for _, t := range sc.componentTSS.discardTasks {
 		s.Append(t)
 		s.UpdateEdge(t, EndEdge)
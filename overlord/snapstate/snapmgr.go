@@ -67,6 +67,7 @@ func init() {
 	swfeats.RegisterEnsure("SnapManager", "ensureDesktopFilesUpdated")
 	swfeats.RegisterEnsure("SnapManager", "ensureDownloadsCleaned")
 	swfeats.RegisterEnsure("SnapManager", "ensureStoreDownloadsCacheCleaned")
+	swfeats.RegisterEnsure("SnapManager", "ensurePeerDistribution")
 
 	RegisterResealingTaskKind("prepare-kernel-modules-components")
 	// TODO: consider registering these on classic only if the system is an hybrid system
@@ -83,6 +84,7 @@ type SnapManager struct {
 	autoRefresh    *autoRefresh
 	refreshHints   *refreshHints
 	catalogRefresh *catalogRefresh
+	peers          *peerDistribution
 
 	preseed bool
 
@@ -90,6 +92,7 @@ type SnapManager struct {
 	ensuredDesktopFilesUpdated bool
 	ensuredDownloadsCleaned    bool
 	ensureStoreCacheCleanNext  time.Time
+	diskPressureCheckNext      time.Time
 
 	changeCallbackID int
 }
@@ -632,6 +635,10 @@ var AutomaticSnapshot func(st *state.State, instanceName string) (ts *state.Task
 var AutomaticSnapshotExpiration func(st *state.State) (time.Duration, error)
 var EstimateSnapshotSize func(st *state.State, instanceName string, users []string) (uint64, error)
 
+// PruneAutomaticSnapshots allows to hook snapshot manager's
+// PruneAutomaticSnapshots, used to free disk space.
+var PruneAutomaticSnapshots func(st *state.State, target uint64) (sets int, freed uint64, err error)
+
 func readInfo(name string, si *snap.SideInfo, flags int) (*snap.Info, error) {
 	info, err := snapReadInfo(name, si)
 	if err != nil && flags&errorOnBroken != 0 {
@@ -804,7 +811,8 @@ var _ StoreService = (*store.Store)(nil)
 
 // Store returns the store service provided by the optional device context or
 // the one used by the snapstate package if the former has no
-// override.
+// override. When store.mirror is set, the store mirror in that directory is
+// used instead of the latter, while it is available.
 func Store(st *state.State, deviceCtx DeviceContext) StoreService {
 	if deviceCtx != nil {
 		sto := deviceCtx.Store()
@@ -812,6 +820,9 @@ func Store(st *state.State, deviceCtx DeviceContext) StoreService {
 			return sto
 		}
 	}
+	if sto := mirrorStore(st); sto != nil {
+		return sto
+	}
 	if cachedStore := cachedStore(st); cachedStore != nil {
 		return cachedStore
 	}
@@ -826,6 +837,7 @@ func Manager(st *state.State, runner *state.TaskRunner) (*SnapManager, error) {
 		autoRefresh:                newAutoRefresh(st),
 		refreshHints:               newRefreshHints(st),
 		catalogRefresh:             newCatalogRefresh(st),
+		peers:                      &peerDistribution{},
 		preseed:                    preseed,
 		ensuredMountsUpdated:       false,
 		ensuredDesktopFilesUpdated: false,
@@ -960,6 +972,8 @@ func (m *SnapManager) Stop() {
 	defer st.Unlock()
 
 	st.RemoveChangeStatusChangedHandler(m.changeCallbackID)
+
+	m.stopPeerDistribution()
 }
 
 func (m *SnapManager) CanStandby() bool {
@@ -1708,6 +1722,8 @@ func (m *SnapManager) Ensure() error {
 		m.ensureDesktopFilesUpdated(),
 		m.ensureDownloadsCleaned(),
 		m.ensureStoreDownloadsCacheCleaned(),
+		m.ensureDiskSpaceReclaimed(),
+		m.ensurePeerDistribution(),
 	}
 
 	//FIXME: use firstErr helper
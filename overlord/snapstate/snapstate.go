@@ -224,6 +224,10 @@ var SetupPostRefreshHook = func(st *state.State, snapName string) *state.Task {
 	panic("internal error: snapstate.SetupPostRefreshHook is unset")
 }
 
+var SetupPostRefreshCheckHook = func(st *state.State, snapName string) *state.Task {
+	panic("internal error: snapstate.SetupPostRefreshCheckHook is unset")
+}
+
 var SetupRemoveHook = func(st *state.State, snapName string) *state.Task {
 	panic("internal error: snapstate.SetupRemoveHook is unset")
 }
@@ -2372,7 +2376,7 @@ func autoRefreshPhase1(ctx context.Context, st *state.State, forGatingSnap strin
 		return nil, nil, err
 	}
 
-	refreshOpts := &store.RefreshOptions{Scheduled: true}
+	refreshOpts := &store.RefreshOptions{Scheduled: true, IncludeChangelog: true}
 	// XXX: should we skip refreshCandidates if forGatingSnap isn't empty (meaning we're handling proceed from a snap)?
 	plan, err := storeUpdatePlan(ctx, st, allSnaps, nil, user, refreshOpts, Options{})
 	if err != nil {
@@ -2380,6 +2384,9 @@ func autoRefreshPhase1(ctx context.Context, st *state.State, forGatingSnap strin
 		// of errors?
 		return nil, nil, err
 	}
+	if err := plan.filterRolloutDelayedSnaps(st, Options{Flags: Flags{IsAutoRefresh: true}}); err != nil {
+		return nil, nil, err
+	}
 	deviceCtx, err := DeviceCtxFromState(st, nil)
 	if err != nil {
 		return nil, nil, err
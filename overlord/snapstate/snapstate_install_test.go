@@ -182,6 +182,9 @@ func expectedDoInstallTasks(typ snap.Type, opts, compOpts, discards int, startTa
 	expected = append(expected, tasksBeforeDiscard...)
 
 	expected = append(expected, "start-snap-services")
+	if opts&unlinkBefore != 0 {
+		expected = append(expected, "run-hook[post-refresh-check]")
+	}
 	for i := 0; i < discards; i++ {
 		expected = append(expected,
 			"clear-snap",
@@ -71,6 +71,7 @@ import (
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/snapdenv"
 	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/store/mirror"
 	"github.com/snapcore/snapd/systemd"
 	"github.com/snapcore/snapd/testutil"
 	"github.com/snapcore/snapd/timeutil"
@@ -201,6 +202,7 @@ func (s *snapmgrBaseTest) SetUpTest(c *C) {
 	oldSetupRemoveComponentHook := snapstate.SetupRemoveComponentHook
 	oldSetupPreRefreshHook := snapstate.SetupPreRefreshHook
 	oldSetupPostRefreshHook := snapstate.SetupPostRefreshHook
+	oldSetupPostRefreshCheckHook := snapstate.SetupPostRefreshCheckHook
 	oldSetupRemoveHook := snapstate.SetupRemoveHook
 	oldSnapServiceOptions := snapstate.SnapServiceOptions
 	oldEnsureSnapAbsentFromQuotaGroup := snapstate.EnsureSnapAbsentFromQuotaGroup
@@ -210,6 +212,7 @@ func (s *snapmgrBaseTest) SetUpTest(c *C) {
 	snapstate.SetupPreRefreshComponentHook = hookstate.SetupPreRefreshComponentHook
 	snapstate.SetupPreRefreshHook = hookstate.SetupPreRefreshHook
 	snapstate.SetupPostRefreshHook = hookstate.SetupPostRefreshHook
+	snapstate.SetupPostRefreshCheckHook = hookstate.SetupPostRefreshCheckHook
 	snapstate.SetupRemoveHook = hookstate.SetupRemoveHook
 	snapstate.SnapServiceOptions = servicestate.SnapServiceOptions
 	snapstate.EnsureSnapAbsentFromQuotaGroup = servicestate.EnsureSnapAbsentFromQuota
@@ -253,6 +256,7 @@ func (s *snapmgrBaseTest) SetUpTest(c *C) {
 		snapstate.SetupRemoveComponentHook = oldSetupRemoveComponentHook
 		snapstate.SetupPreRefreshHook = oldSetupPreRefreshHook
 		snapstate.SetupPostRefreshHook = oldSetupPostRefreshHook
+		snapstate.SetupPostRefreshCheckHook = oldSetupPostRefreshCheckHook
 		snapstate.SetupRemoveHook = oldSetupRemoveHook
 		snapstate.SnapServiceOptions = oldSnapServiceOptions
 		snapstate.EnsureSnapAbsentFromQuotaGroup = oldEnsureSnapAbsentFromQuotaGroup
@@ -511,6 +515,61 @@ func (s *snapmgrTestSuite) TestStoreWithDeviceContext(c *C) {
 	c.Check(store3, Equals, stoB)
 }
 
+func (s *snapmgrTestSuite) TestStoreMirror(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	sto := &store.Store{}
+	snapstate.ReplaceStore(s.state, sto)
+
+	dir1 := c.MkDir()
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "store.mirror", dir1), IsNil)
+	tr.Commit()
+
+	// the option is read the first time
+	store1 := snapstate.Store(s.state, nil)
+	c.Assert(store1, FitsTypeOf, &mirror.Store{})
+	c.Check(store1.(*mirror.Store).Dir(), Equals, dir1)
+
+	// cached
+	store2 := snapstate.Store(s.state, nil)
+	c.Check(store2, Equals, store1)
+
+	// the store of the device context is not overridden
+	ctxStore := &store.Store{}
+	c.Check(snapstate.Store(s.state, &snapstatetest.TrivialDeviceContext{CtxStore: ctxStore}), Equals, ctxStore)
+
+	// then kept up to date when the option changes
+	dir2 := c.MkDir()
+	snapstate.SetStoreMirror(s.state, dir2)
+	store3 := snapstate.Store(s.state, nil)
+	c.Assert(store3, FitsTypeOf, &mirror.Store{})
+	c.Check(store3.(*mirror.Store).Dir(), Equals, dir2)
+
+	snapstate.SetStoreMirror(s.state, "")
+	c.Check(snapstate.Store(s.state, nil), Equals, sto)
+}
+
+func (s *snapmgrTestSuite) TestStoreMirrorUnavailable(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	sto := &store.Store{}
+	snapstate.ReplaceStore(s.state, sto)
+
+	dir := filepath.Join(c.MkDir(), "usb")
+	snapstate.SetStoreMirror(s.state, dir)
+
+	// the store is used while the mirror is unplugged
+	c.Check(snapstate.Store(s.state, nil), Equals, sto)
+
+	c.Assert(os.Mkdir(dir, 0755), IsNil)
+	mirrorStore := snapstate.Store(s.state, nil)
+	c.Assert(mirrorStore, FitsTypeOf, &mirror.Store{})
+	c.Check(mirrorStore.(*mirror.Store).Dir(), Equals, dir)
+}
+
 func (s *snapmgrTestSuite) TestUserFromUserID(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -10320,6 +10379,98 @@ func (s *snapmgrTestSuite) TestSaveRefreshCandidatesOnAutoRefresh(c *C) {
 	c.Check(cands["some-other-snap"], NotNil)
 }
 
+func (s *snapmgrTestSuite) TestAutoRefreshStagedRollout(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := t0
+	defer snapstate.MockTimeNow(func() time.Time { return now })()
+	snapstate.DeviceSerial = func(*state.State) (string, error) { return "serial-1", nil }
+	defer func() { snapstate.DeviceSerial = nil }()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.rollout-window", "72h")
+	tr.Commit()
+
+	for _, name := range []string{"some-snap", "some-other-snap"} {
+		snapstate.Set(s.state, name, &snapstate.SnapState{
+			Active: true,
+			Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+				{RealName: name, SnapID: name + "-id", Revision: snap.R(1)},
+			}),
+			Current:  snap.R(1),
+			SnapType: "app",
+		})
+	}
+
+	// the delays are stable for the device, and within the window
+	delay := snapstate.RolloutDelay("serial-1", "some-snap", snap.R(11), 72*time.Hour)
+	otherDelay := snapstate.RolloutDelay("serial-1", "some-other-snap", snap.R(11), 72*time.Hour)
+	c.Check(delay, Equals, snapstate.RolloutDelay("serial-1", "some-snap", snap.R(11), 72*time.Hour))
+	c.Check(delay, Not(Equals), snapstate.RolloutDelay("serial-2", "some-snap", snap.R(11), 72*time.Hour))
+	c.Check(delay > 0 && delay < 72*time.Hour, Equals, true)
+	c.Check(otherDelay > 0 && otherDelay < 72*time.Hour, Equals, true)
+	first, second := "some-snap", "some-other-snap"
+	if otherDelay < delay {
+		first, second = second, first
+		delay, otherDelay = otherDelay, delay
+	}
+
+	names, _, err := snapstate.AutoRefresh(context.Background(), s.state)
+	c.Assert(err, IsNil)
+	c.Check(names, HasLen, 0)
+
+	due, err := snapstate.RefreshRolloutDue(s.state, first, snap.R(11))
+	c.Assert(err, IsNil)
+	c.Check(due, Equals, t0.Add(delay))
+	// only revisions that are candidates are delayed
+	due, err = snapstate.RefreshRolloutDue(s.state, first, snap.R(12))
+	c.Assert(err, IsNil)
+	c.Check(due.IsZero(), Equals, true)
+
+	// the delay counts from when the revision was first seen
+	now = t0.Add(delay)
+	names, _, err = snapstate.AutoRefresh(context.Background(), s.state)
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, []string{first})
+
+	due, err = snapstate.RefreshRolloutDue(s.state, second, snap.R(11))
+	c.Assert(err, IsNil)
+	c.Check(due, Equals, t0.Add(otherDelay))
+
+	now = t0.Add(otherDelay)
+	names, _, err = snapstate.AutoRefresh(context.Background(), s.state)
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, []string{"some-other-snap", "some-snap"})
+}
+
+func (s *snapmgrTestSuite) TestAutoRefreshStagedRolloutNoSerial(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.DeviceSerial = func(*state.State) (string, error) { return "", nil }
+	defer func() { snapstate.DeviceSerial = nil }()
+
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "refresh.rollout-window", "72h")
+	tr.Commit()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(1)},
+		}),
+		Current:  snap.R(1),
+		SnapType: "app",
+	})
+
+	// there is nothing to derive the delay from
+	names, _, err := snapstate.AutoRefresh(context.Background(), s.state)
+	c.Assert(err, IsNil)
+	c.Check(names, DeepEquals, []string{"some-snap"})
+}
+
 func (s *snapmgrTestSuite) testBackoffOnAutoRefresh(c *C, afterReboot bool) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -2699,6 +2699,69 @@ func (s *snapmgrTestSuite) TestUpdateUndoRestoresRevisionConfig(c *C) {
 	c.Check(val, Equals, "revision 7 value")
 }
 
+func (s *snapmgrTestSuite) TestUpdatePostRefreshCheckFailureReverts(c *C) {
+	var checked bool
+	s.o.TaskRunner().AddHandler("run-hook", func(task *state.Task, _ *tomb.Tomb) error {
+		st := task.State()
+		st.Lock()
+		defer st.Unlock()
+
+		var hooksup hookstate.HookSetup
+		c.Assert(task.Get("hook-setup", &hooksup), IsNil)
+		if hooksup.Hook != "post-refresh-check" {
+			return nil
+		}
+		c.Check(hooksup.Timeout, Equals, 5*time.Minute)
+		checked = true
+
+		// the new revision is linked and its services are running
+		var snapst snapstate.SnapState
+		c.Assert(snapstate.Get(st, "some-snap", &snapst), IsNil)
+		c.Check(snapst.Current, Equals, snap.R(11))
+		for _, t := range task.Change().Tasks() {
+			if t.Kind() == "start-snap-services" {
+				c.Check(t.Status(), Equals, state.DoneStatus)
+			}
+		}
+		return errors.New("service does not answer")
+	}, nil)
+
+	si := snap.SideInfo{
+		RealName: "some-snap",
+		SnapID:   "some-snap-id",
+		Revision: snap.R(7),
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:          true,
+		Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{&si}),
+		TrackingChannel: "latest/stable",
+		Current:         si.Revision,
+		SnapType:        "app",
+	})
+
+	chg := s.state.NewChange("refresh", "refresh a snap")
+	ts, err := snapstate.Update(s.state, "some-snap", &snapstate.RevisionOptions{Channel: "some-channel"}, s.user.ID, snapstate.Flags{})
+	c.Assert(err, IsNil)
+	chg.AddAll(ts)
+
+	s.settle(c)
+
+	c.Check(checked, Equals, true)
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(chg.Err(), ErrorMatches, `(?s).*service does not answer.*`)
+
+	// the refresh was reverted
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Check(snapst.Current, Equals, snap.R(7))
+	c.Check(snapst.Sequence.Revisions, HasLen, 1)
+	c.Check(s.fakeBackend.ops.First("undo-copy-snap-data"), NotNil)
+}
+
 func (s *snapmgrTestSuite) TestUpdateMakesConfigSnapshot(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -4471,7 +4534,7 @@ func (s *snapmgrTestSuite) TestUpdateOneAutoAliasesScenarios(c *C) {
 		}
 		if scenario.update {
 			first := tasks[j]
-			j += 19
+			j += 20
 			c.Check(first.Kind(), Equals, "prerequisites")
 			wait := false
 			if expectedPruned["other-snap"]["aliasA"] {
@@ -12370,7 +12433,7 @@ func (s *snapmgrTestSuite) TestRefreshForcedOnRefreshInhibitionTimeoutError(c *C
 	c.Assert(chg.Status(), Equals, state.ErrorStatus)
 	c.Assert(checkAppRunning, Equals, 2)
 
-	lock, err := osutil.NewFileLock(filepath.Join(s.fakeBackend.lockDir, "some-snap.lock"))
+	lock, err := osutil.NewFileLock(filepath.Join(dirs.SnapRunLockDir, "some-snap.lock"))
 	c.Assert(err, IsNil)
 	defer lock.Close()
 	c.Assert(lock.TryLock(), IsNil)
@@ -17219,6 +17282,7 @@ func (s *snapmgrTestSuite) TestUpdateTasksWithComponentsRemoved(c *C) {
 		"setup-aliases",
 		"run-hook[post-refresh]",
 		"start-snap-services",
+		"run-hook[post-refresh-check]",
 		"clear-snap",
 		"unlink-component",
 		"discard-component",
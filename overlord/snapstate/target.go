@@ -1121,6 +1121,10 @@ func UpdateWithGoal(ctx context.Context, st *state.State, goal UpdateGoal, filte
 		return nil, nil, err
 	}
 
+	if err := plan.filterRolloutDelayedSnaps(st, opts); err != nil {
+		return nil, nil, err
+	}
+
 	// save the candidates so the auto-refresh can be continued if it's inhibited
 	// by a running snap.
 	if opts.Flags.IsAutoRefresh {
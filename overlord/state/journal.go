@@ -0,0 +1,483 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+)
+
+// A JournalBackend is a Backend able to persist the state incrementally.
+//
+// When the State is initialized with a JournalBackend, the first checkpoint
+// after loading goes through Checkpoint with the whole state, while following
+// checkpoints go through CheckpointDelta with only the entries modified since
+// the previous successful checkpoint.
+type JournalBackend interface {
+	Backend
+	// CheckpointDelta persists the given delta on top of what was
+	// previously checkpointed. The full function returns the
+	// serialization of the whole state, for backends wanting to compact
+	// their journal instead of appending to it.
+	CheckpointDelta(delta *Delta, full func() []byte) error
+}
+
+// DeltaSection holds the entries of one section of the state that were set or
+// removed since the previous checkpoint.
+type DeltaSection struct {
+	Set     map[string]json.RawMessage `json:"set,omitempty"`
+	Removed []string                   `json:"removed,omitempty"`
+}
+
+func (ds *DeltaSection) apply(entries map[string]json.RawMessage) {
+	if ds == nil {
+		return
+	}
+	for _, key := range ds.Removed {
+		delete(entries, key)
+	}
+	for key, value := range ds.Set {
+		entries[key] = value
+	}
+}
+
+// Delta describes the modifications of the state since the previous
+// checkpoint. Data entries are keyed by their key, changes and tasks by
+// their ID, warnings by their message and notices by their ID.
+type Delta struct {
+	Data     *DeltaSection `json:"data,omitempty"`
+	Changes  *DeltaSection `json:"changes,omitempty"`
+	Tasks    *DeltaSection `json:"tasks,omitempty"`
+	Warnings *DeltaSection `json:"warnings,omitempty"`
+	Notices  *DeltaSection `json:"notices,omitempty"`
+
+	deltaCounters
+}
+
+type deltaCounters struct {
+	LastChangeId int `json:"last-change-id"`
+	LastTaskId   int `json:"last-task-id"`
+	LastLaneId   int `json:"last-lane-id"`
+	LastNoticeId int `json:"last-notice-id"`
+
+	LastNoticeTimestamp time.Time `json:"last-notice-timestamp,omitzero"`
+}
+
+// checkpointEntries holds the serialized entries of the state as they were
+// (or are about to be) checkpointed.
+type checkpointEntries struct {
+	data     map[string]json.RawMessage
+	changes  map[string]json.RawMessage
+	tasks    map[string]json.RawMessage
+	warnings map[string]json.RawMessage
+	notices  map[string]json.RawMessage
+
+	counters deltaCounters
+}
+
+func mustMarshalEntry(kind, key string, v any) json.RawMessage {
+	serialized, err := json.Marshal(v)
+	if err != nil {
+		// this shouldn't happen, because the actual delicate serializing happens at various Set()s
+		logger.Panicf("internal error: could not marshal %s %q for checkpointing: %v", kind, key, err)
+	}
+	return serialized
+}
+
+// currentEntries serializes the state entry by entry.
+func (s *State) currentEntries() *checkpointEntries {
+	s.reading()
+	entries := &checkpointEntries{
+		data:     make(map[string]json.RawMessage, len(s.data)),
+		changes:  make(map[string]json.RawMessage, len(s.changes)),
+		tasks:    make(map[string]json.RawMessage, len(s.tasks)),
+		warnings: make(map[string]json.RawMessage),
+		notices:  make(map[string]json.RawMessage),
+		counters: deltaCounters{
+			LastChangeId:        s.lastChangeId,
+			LastTaskId:          s.lastTaskId,
+			LastLaneId:          s.lastLaneId,
+			LastNoticeId:        s.lastNoticeId,
+			LastNoticeTimestamp: s.getLastNoticeTimestamp(),
+		},
+	}
+	for key, value := range s.data {
+		entries.data[key] = *value
+	}
+	for id, chg := range s.changes {
+		entries.changes[id] = mustMarshalEntry("change", id, chg)
+	}
+	for id, t := range s.tasks {
+		entries.tasks[id] = mustMarshalEntry("task", id, t)
+	}
+	for _, w := range s.flattenWarnings() {
+		entries.warnings[w.message] = mustMarshalEntry("warning", w.message, w)
+	}
+	for _, n := range s.flattenNotices() {
+		entries.notices[n.id] = mustMarshalEntry("notice", n.id, n)
+	}
+	return entries
+}
+
+func (ce *checkpointEntries) apply(delta *Delta) {
+	delta.Data.apply(ce.data)
+	delta.Changes.apply(ce.changes)
+	delta.Tasks.apply(ce.tasks)
+	delta.Warnings.apply(ce.warnings)
+	delta.Notices.apply(ce.notices)
+	ce.counters = delta.deltaCounters
+}
+
+// rawState mirrors marshalledState while keeping all entries serialized.
+type rawState struct {
+	Data     map[string]json.RawMessage `json:"data"`
+	Changes  map[string]json.RawMessage `json:"changes"`
+	Tasks    map[string]json.RawMessage `json:"tasks"`
+	Warnings []json.RawMessage          `json:"warnings,omitempty"`
+	Notices  []json.RawMessage          `json:"notices,omitempty"`
+
+	deltaCounters
+}
+
+func sortedValues(entries map[string]json.RawMessage) []json.RawMessage {
+	if len(entries) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]json.RawMessage, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, entries[key])
+	}
+	return values
+}
+
+// marshal returns the serialization of the whole state, in the same format
+// as produced by State.MarshalJSON.
+func (ce *checkpointEntries) marshal() []byte {
+	data, err := json.Marshal(rawState{
+		Data:     ce.data,
+		Changes:  ce.changes,
+		Tasks:    ce.tasks,
+		Warnings: sortedValues(ce.warnings),
+		Notices:  sortedValues(ce.notices),
+
+		deltaCounters: ce.counters,
+	})
+	if err != nil {
+		logger.Panicf("internal error: could not marshal state for checkpointing: %v", err)
+	}
+	return data
+}
+
+func entriesFromRaw(raw *rawState) (*checkpointEntries, error) {
+	ce := &checkpointEntries{
+		data:     raw.Data,
+		changes:  raw.Changes,
+		tasks:    raw.Tasks,
+		warnings: make(map[string]json.RawMessage, len(raw.Warnings)),
+		notices:  make(map[string]json.RawMessage, len(raw.Notices)),
+		counters: raw.deltaCounters,
+	}
+	if ce.data == nil {
+		ce.data = make(map[string]json.RawMessage)
+	}
+	if ce.changes == nil {
+		ce.changes = make(map[string]json.RawMessage)
+	}
+	if ce.tasks == nil {
+		ce.tasks = make(map[string]json.RawMessage)
+	}
+	for _, w := range raw.Warnings {
+		var key struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(w, &key); err != nil {
+			return nil, err
+		}
+		ce.warnings[key.Message] = w
+	}
+	for _, n := range raw.Notices {
+		var key struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(n, &key); err != nil {
+			return nil, err
+		}
+		ce.notices[key.ID] = n
+	}
+	return ce, nil
+}
+
+// ReplayDeltas applies the given deltas, in order, on top of the serialized
+// state in base and returns the serialization of the resulting state, ready
+// to be read with ReadState.
+func ReplayDeltas(base []byte, deltas []*Delta) ([]byte, error) {
+	var raw rawState
+	if err := json.Unmarshal(base, &raw); err != nil {
+		return nil, fmt.Errorf("cannot read state: %v", err)
+	}
+	ce, err := entriesFromRaw(&raw)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read state: %v", err)
+	}
+	for _, delta := range deltas {
+		ce.apply(delta)
+	}
+	return ce.marshal(), nil
+}
+
+// dirtyEntries holds the keys of the entries modified since the last
+// checkpoint, so that deltas are computed without serializing the whole
+// state.
+type dirtyEntries struct {
+	data     map[string]bool
+	changes  map[string]bool
+	tasks    map[string]bool
+	warnings map[string]bool
+	notices  map[string]bool
+}
+
+func newDirtyEntries() *dirtyEntries {
+	return &dirtyEntries{
+		data:     make(map[string]bool),
+		changes:  make(map[string]bool),
+		tasks:    make(map[string]bool),
+		warnings: make(map[string]bool),
+		notices:  make(map[string]bool),
+	}
+}
+
+// The following record modified entries, and are no-ops unless the state
+// was checkpointed through a JournalBackend.
+
+func (s *State) dataModified(key string) {
+	if s.dirty != nil {
+		s.dirty.data[key] = true
+	}
+}
+
+func (s *State) changeModified(id string) {
+	if s.dirty != nil {
+		s.dirty.changes[id] = true
+	}
+}
+
+// taskModified also records the change of the task as modified, as the
+// status of a change follows the ones of its tasks.
+func (s *State) taskModified(t *Task) {
+	if s.dirty != nil {
+		s.dirty.tasks[t.id] = true
+		if t.change != "" {
+			s.dirty.changes[t.change] = true
+		}
+	}
+}
+
+func (s *State) warningModified(message string) {
+	if s.dirty != nil {
+		s.dirty.warnings[message] = true
+	}
+}
+
+func (s *State) noticeModified(id string) {
+	if s.dirty != nil {
+		s.dirty.notices[id] = true
+	}
+}
+
+// diffDirtySection returns the delta of the entries with the given keys
+// between what was checkpointed and their current serialization, as returned
+// by current.
+func diffDirtySection(keys map[string]bool, checkpointed map[string]json.RawMessage, current func(key string) (json.RawMessage, bool)) *DeltaSection {
+	var ds DeltaSection
+	for key := range keys {
+		value, ok := current(key)
+		if !ok {
+			if _, ok := checkpointed[key]; ok {
+				ds.Removed = append(ds.Removed, key)
+			}
+			continue
+		}
+		if oldValue, ok := checkpointed[key]; ok && bytes.Equal(oldValue, value) {
+			continue
+		}
+		if ds.Set == nil {
+			ds.Set = make(map[string]json.RawMessage)
+		}
+		ds.Set[key] = value
+	}
+	if ds.Set == nil && ds.Removed == nil {
+		return nil
+	}
+	sort.Strings(ds.Removed)
+	return &ds
+}
+
+// dirtyDelta returns the delta going from what was checkpointed to the
+// current state, serializing only the entries modified in between, or nil if
+// there are no differences.
+func (s *State) dirtyDelta() *Delta {
+	s.reading()
+	ce := s.checkpointed
+	counters := deltaCounters{
+		LastChangeId:        s.lastChangeId,
+		LastTaskId:          s.lastTaskId,
+		LastLaneId:          s.lastLaneId,
+		LastNoticeId:        s.lastNoticeId,
+		LastNoticeTimestamp: s.getLastNoticeTimestamp(),
+	}
+
+	var warnings map[string]*Warning
+	if len(s.dirty.warnings) != 0 {
+		warnings = make(map[string]*Warning)
+		for _, w := range s.flattenWarnings() {
+			warnings[w.message] = w
+		}
+	}
+	var notices map[string]*Notice
+	if len(s.dirty.notices) != 0 {
+		notices = make(map[string]*Notice)
+		for _, n := range s.flattenNotices() {
+			notices[n.id] = n
+		}
+	}
+
+	delta := &Delta{
+		Data: diffDirtySection(s.dirty.data, ce.data, func(key string) (json.RawMessage, bool) {
+			value, ok := s.data[key]
+			if !ok {
+				return nil, false
+			}
+			return *value, true
+		}),
+		Changes: diffDirtySection(s.dirty.changes, ce.changes, func(id string) (json.RawMessage, bool) {
+			chg, ok := s.changes[id]
+			if !ok {
+				return nil, false
+			}
+			return mustMarshalEntry("change", id, chg), true
+		}),
+		Tasks: diffDirtySection(s.dirty.tasks, ce.tasks, func(id string) (json.RawMessage, bool) {
+			t, ok := s.tasks[id]
+			if !ok {
+				return nil, false
+			}
+			return mustMarshalEntry("task", id, t), true
+		}),
+		Warnings: diffDirtySection(s.dirty.warnings, ce.warnings, func(message string) (json.RawMessage, bool) {
+			w, ok := warnings[message]
+			if !ok {
+				return nil, false
+			}
+			return mustMarshalEntry("warning", message, w), true
+		}),
+		Notices: diffDirtySection(s.dirty.notices, ce.notices, func(id string) (json.RawMessage, bool) {
+			n, ok := notices[id]
+			if !ok {
+				return nil, false
+			}
+			return mustMarshalEntry("notice", id, n), true
+		}),
+
+		deltaCounters: counters,
+	}
+	if delta.Data == nil && delta.Changes == nil && delta.Tasks == nil &&
+		delta.Warnings == nil && delta.Notices == nil &&
+		ce.counters == counters {
+		return nil
+	}
+	return delta
+}
+
+// copy returns a copy of ce, sharing the serialized entries.
+func (ce *checkpointEntries) copy() *checkpointEntries {
+	return &checkpointEntries{
+		data:     copyEntries(ce.data),
+		changes:  copyEntries(ce.changes),
+		tasks:    copyEntries(ce.tasks),
+		warnings: copyEntries(ce.warnings),
+		notices:  copyEntries(ce.notices),
+		counters: ce.counters,
+	}
+}
+
+func copyEntries(entries map[string]json.RawMessage) map[string]json.RawMessage {
+	if entries == nil {
+		return nil
+	}
+	cpy := make(map[string]json.RawMessage, len(entries))
+	for k, v := range entries {
+		cpy[k] = v
+	}
+	return cpy
+}
+
+// journalCheckpointer returns a function persisting the current state
+// through the given backend, and recording what was persisted on success.
+// It returns nil if there is nothing to persist.
+func (s *State) journalCheckpointer(backend JournalBackend) func() error {
+	if s.checkpointed == nil {
+		next := s.currentEntries()
+		data := next.marshal()
+		return func() error {
+			if err := backend.Checkpoint(data); err != nil {
+				return err
+			}
+			s.checkpointed = next
+			s.dirty = newDirtyEntries()
+			return nil
+		}
+	}
+	delta := s.dirtyDelta()
+	if delta == nil {
+		s.dirty = newDirtyEntries()
+		return nil
+	}
+	full := func() []byte {
+		next := s.checkpointed.copy()
+		next.apply(delta)
+		return next.marshal()
+	}
+	return func() error {
+		if err := backend.CheckpointDelta(delta, full); err != nil {
+			return err
+		}
+		s.checkpointed.apply(delta)
+		s.dirty = newDirtyEntries()
+		return nil
+	}
+}
+
+// RequestFullCheckpoint makes the next unlock persist the whole state even
+// when the state backend supports incremental checkpoints.
+func (s *State) RequestFullCheckpoint() {
+	s.writing()
+	s.checkpointed = nil
+	s.dirty = nil
+}
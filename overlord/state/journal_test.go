@@ -0,0 +1,361 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type journalSuite struct{}
+
+var _ = Suite(&journalSuite{})
+
+type fakeJournalBackend struct {
+	fakeStateBackend
+	deltas      []*state.Delta
+	deltaError  func() error
+	compactNext bool
+}
+
+func (b *fakeJournalBackend) CheckpointDelta(delta *state.Delta, full func() []byte) error {
+	if b.deltaError != nil {
+		if err := b.deltaError(); err != nil {
+			return err
+		}
+	}
+	if b.compactNext {
+		b.compactNext = false
+		return b.Checkpoint(full())
+	}
+	// round-trip through JSON like a real journal would
+	serialized, err := json.Marshal(delta)
+	if err != nil {
+		return err
+	}
+	var stored state.Delta
+	if err := json.Unmarshal(serialized, &stored); err != nil {
+		return err
+	}
+	b.deltas = append(b.deltas, &stored)
+	return nil
+}
+
+// replay returns the state as it would be read back from the last
+// checkpoint and the deltas appended since.
+func (b *fakeJournalBackend) replay(c *C) *state.State {
+	c.Assert(b.checkpoints, Not(HasLen), 0)
+	data, err := state.ReplayDeltas(b.checkpoints[len(b.checkpoints)-1], b.deltas)
+	c.Assert(err, IsNil)
+	st, err := state.ReadState(nil, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	return st
+}
+
+func (b *fakeJournalBackend) Checkpoint(data []byte) error {
+	b.deltas = nil
+	return b.fakeStateBackend.Checkpoint(data)
+}
+
+func (js *journalSuite) TestFirstCheckpointIsFull(c *C) {
+	b := &fakeJournalBackend{}
+	st := state.New(b)
+	st.Lock()
+	st.Set("v", 1)
+	st.Unlock()
+
+	c.Check(b.checkpoints, HasLen, 1)
+	c.Check(b.deltas, HasLen, 0)
+
+	st2 := b.replay(c)
+	st2.Lock()
+	defer st2.Unlock()
+	var v int
+	c.Assert(st2.Get("v", &v), IsNil)
+	c.Check(v, Equals, 1)
+}
+
+func (js *journalSuite) TestDeltasOnlyCarryModifiedEntries(c *C) {
+	b := &fakeJournalBackend{}
+	st := state.New(b)
+	st.Lock()
+	st.Set("a", 1)
+	st.Set("b", "foo")
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("download", "1...")
+	t2 := st.NewTask("install", "2...")
+	chg.AddTask(t1)
+	chg.AddTask(t2)
+	st.Unlock()
+	c.Assert(b.checkpoints, HasLen, 1)
+
+	st.Lock()
+	st.Set("a", 2)
+	st.Set("b", nil)
+	t2.SetStatus(state.DoingStatus)
+	st.Unlock()
+
+	c.Assert(b.checkpoints, HasLen, 1)
+	c.Assert(b.deltas, HasLen, 1)
+	delta := b.deltas[0]
+	c.Assert(delta.Data, NotNil)
+	c.Check(delta.Data.Set, HasLen, 1)
+	c.Check(string(delta.Data.Set["a"]), Equals, "2")
+	c.Check(delta.Data.Removed, DeepEquals, []string{"b"})
+	c.Assert(delta.Tasks, NotNil)
+	c.Check(delta.Tasks.Set, HasLen, 1)
+	c.Check(delta.Tasks.Set[t2.ID()], NotNil)
+	c.Check(delta.Tasks.Removed, HasLen, 0)
+	// the change status moved with the task
+	c.Assert(delta.Changes, NotNil)
+	c.Check(delta.Changes.Set, HasLen, 1)
+	c.Check(delta.LastTaskId, Equals, 2)
+	c.Check(delta.LastChangeId, Equals, 1)
+
+	st2 := b.replay(c)
+	st2.Lock()
+	defer st2.Unlock()
+	var a int
+	c.Assert(st2.Get("a", &a), IsNil)
+	c.Check(a, Equals, 2)
+	c.Check(st2.Has("b"), Equals, false)
+	c.Check(st2.Task(t2.ID()).Status(), Equals, state.DoingStatus)
+	c.Check(st2.Task(t1.ID()).Status(), Equals, state.DoStatus)
+	c.Check(st2.Change(chg.ID()).Tasks(), HasLen, 2)
+}
+
+func (js *journalSuite) TestNoDeltaWithoutActualChanges(c *C) {
+	b := &fakeJournalBackend{}
+	st := state.New(b)
+	st.Lock()
+	st.Set("a", 1)
+	st.Unlock()
+
+	st.Lock()
+	st.Set("a", 1)
+	st.Unlock()
+
+	c.Check(b.checkpoints, HasLen, 1)
+	c.Check(b.deltas, HasLen, 0)
+	c.Check(st.Modified(), Equals, false)
+}
+
+func (js *journalSuite) TestPrunedChangesAreRemoved(c *C) {
+	b := &fakeJournalBackend{}
+	st := state.New(b)
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t := st.NewTask("download", "1...")
+	chg.AddTask(t)
+	t.SetStatus(state.DoneStatus)
+	st.Unlock()
+
+	st.Lock()
+	state.MockChangeTimes(chg, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour))
+	st.Prune(time.Now(), time.Hour, time.Hour, 100)
+	st.Unlock()
+
+	c.Assert(b.deltas, HasLen, 1)
+	c.Check(b.deltas[0].Changes.Removed, DeepEquals, []string{chg.ID()})
+	c.Check(b.deltas[0].Tasks.Removed, DeepEquals, []string{t.ID()})
+
+	st2 := b.replay(c)
+	st2.Lock()
+	defer st2.Unlock()
+	c.Check(st2.Changes(), HasLen, 0)
+	c.Check(st2.Tasks(), HasLen, 0)
+}
+
+func (js *journalSuite) TestWarningsAndNotices(c *C) {
+	b := &fakeJournalBackend{}
+	st := state.New(b)
+	st.Lock()
+	st.Warnf("hello")
+	st.Unlock()
+
+	st.Lock()
+	st.Warnf("world")
+	_, err := st.AddNotice(nil, state.SnapRunInhibitNotice, "snap-name", nil)
+	c.Assert(err, IsNil)
+	st.Unlock()
+
+	c.Assert(b.deltas, HasLen, 1)
+	c.Assert(b.deltas[0].Warnings, NotNil)
+	c.Check(b.deltas[0].Warnings.Set, HasLen, 1)
+	c.Check(b.deltas[0].Warnings.Set["world"], NotNil)
+	c.Assert(b.deltas[0].Notices, NotNil)
+	c.Check(b.deltas[0].Notices.Set, HasLen, 1)
+
+	st2 := b.replay(c)
+	st2.Lock()
+	defer st2.Unlock()
+	c.Check(st2.AllWarnings(), HasLen, 2)
+	c.Check(st2.Notices(nil), HasLen, 1)
+}
+
+func (js *journalSuite) TestDeltasTrackEntriesModifiedIndirectly(c *C) {
+	b := &fakeJournalBackend{}
+	st := state.New(b)
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("download", "1...")
+	t2 := st.NewTask("install", "2...")
+	chg.AddTask(t1)
+	chg.AddTask(t2)
+	st.Warnf("hello")
+	_, err := st.AddNotice(nil, state.SnapRunInhibitNotice, "snap-name", nil)
+	c.Assert(err, IsNil)
+	st.Unlock()
+	c.Assert(b.checkpoints, HasLen, 1)
+
+	st.Lock()
+	// waiting for t1 modifies t1 as well
+	t2.WaitFor(t1)
+	st.OkayWarnings(time.Now())
+	st.DrainNotices(nil)
+	st.Unlock()
+
+	c.Assert(b.deltas, HasLen, 1)
+	delta := b.deltas[0]
+	c.Assert(delta.Tasks, NotNil)
+	c.Check(delta.Tasks.Set, HasLen, 2)
+	c.Assert(delta.Warnings, NotNil)
+	c.Check(delta.Warnings.Set, HasLen, 1)
+	c.Assert(delta.Notices, NotNil)
+	// the notice and the change-update one
+	c.Check(delta.Notices.Removed, HasLen, 2)
+
+	// replaying the deltas gives the same state as a full checkpoint
+	st.Lock()
+	full, err := json.Marshal(st)
+	st.Unlock()
+	c.Assert(err, IsNil)
+	st1, err := state.ReadState(nil, bytes.NewReader(full))
+	c.Assert(err, IsNil)
+	st2 := b.replay(c)
+	st1.Lock()
+	expected, err := json.Marshal(st1)
+	st1.Unlock()
+	c.Assert(err, IsNil)
+	st2.Lock()
+	replayed, err := json.Marshal(st2)
+	st2.Unlock()
+	c.Assert(err, IsNil)
+	c.Check(string(replayed), Equals, string(expected))
+}
+
+func (js *journalSuite) TestBackendCompaction(c *C) {
+	b := &fakeJournalBackend{}
+	st := state.New(b)
+	st.Lock()
+	st.Set("a", 1)
+	st.Unlock()
+
+	b.compactNext = true
+	st.Lock()
+	st.Set("a", 2)
+	st.Unlock()
+
+	c.Check(b.checkpoints, HasLen, 2)
+	c.Check(b.deltas, HasLen, 0)
+
+	st.Lock()
+	st.Set("a", 3)
+	st.Unlock()
+
+	c.Check(b.checkpoints, HasLen, 2)
+	c.Assert(b.deltas, HasLen, 1)
+	c.Check(string(b.deltas[0].Data.Set["a"]), Equals, "3")
+}
+
+func (js *journalSuite) TestRequestFullCheckpoint(c *C) {
+	b := &fakeJournalBackend{}
+	st := state.New(b)
+	st.Lock()
+	st.Set("a", 1)
+	st.Unlock()
+
+	st.Lock()
+	st.RequestFullCheckpoint()
+	st.Unlock()
+
+	c.Check(b.checkpoints, HasLen, 2)
+	c.Check(b.deltas, HasLen, 0)
+}
+
+func (js *journalSuite) TestCheckpointDeltaRetry(c *C) {
+	restore := state.MockCheckpointRetryDelay(2*time.Millisecond, 1*time.Second)
+	defer restore()
+
+	b := &fakeJournalBackend{}
+	st := state.New(b)
+	st.Lock()
+	st.Set("a", 1)
+	st.Unlock()
+
+	retries := 0
+	b.deltaError = func() error {
+		retries++
+		if retries == 2 {
+			return nil
+		}
+		return errors.New("boom")
+	}
+	st.Lock()
+	st.Set("a", 2)
+	st.Unlock()
+
+	c.Check(retries, Equals, 2)
+	c.Assert(b.deltas, HasLen, 1)
+	c.Check(string(b.deltas[0].Data.Set["a"]), Equals, "2")
+}
+
+func (js *journalSuite) TestReplayDeltasOnLegacyState(c *C) {
+	base := []byte(`{"data":{"a":1,"b":2},"changes":{},"tasks":{},"warnings":[{"message":"hello","first-added":"2026-01-01T00:00:00Z","last-added":"2026-01-01T00:00:00Z","expire-after":"672h0m0s","repeat-after":"24h0m0s"}],"last-change-id":0,"last-task-id":0,"last-lane-id":0,"last-notice-id":0}`)
+	deltas := []*state.Delta{{
+		Data: &state.DeltaSection{
+			Set:     map[string]json.RawMessage{"a": json.RawMessage(`"x"`)},
+			Removed: []string{"b"},
+		},
+		Warnings: &state.DeltaSection{
+			Removed: []string{"hello"},
+		},
+	}, {
+		Data: &state.DeltaSection{
+			Set: map[string]json.RawMessage{"c": json.RawMessage(`true`)},
+		},
+	}}
+	deltas[1].LastLaneId = 3
+
+	data, err := state.ReplayDeltas(base, deltas)
+	c.Assert(err, IsNil)
+	c.Check(string(data), Equals, `{"data":{"a":"x","c":true},"changes":{},"tasks":{},"last-change-id":0,"last-task-id":0,"last-lane-id":3,"last-notice-id":0}`)
+}
+
+func (js *journalSuite) TestReplayDeltasBadBase(c *C) {
+	_, err := state.ReplayDeltas([]byte(`{`), nil)
+	c.Check(err, ErrorMatches, "cannot read state: .*")
+}
@@ -307,11 +307,22 @@ const (
 	// expired. The key for interfaces-requests-rule-update notices is the
 	// rule ID.
 	InterfacesRequestsRuleUpdateNotice NoticeType = "interfaces-requests-rule-update"
+
+	// Recorded whenever the usage of a resource limited by a quota group
+	// stays above the configured alert threshold for a while. The key for
+	// quota-usage notices is the quota group name, and they are only visible
+	// to admins.
+	QuotaUsageNotice NoticeType = "quota-usage"
+
+	// Recorded whenever snapd frees disk space because free space fell below
+	// the refresh.min-free-space threshold. The key for disk-space-reclaimed
+	// notices is the directory whose free space is watched.
+	DiskSpaceReclaimedNotice NoticeType = "disk-space-reclaimed"
 )
 
 func (t NoticeType) Valid() bool {
 	switch t {
-	case ChangeUpdateNotice, WarningNotice, RefreshInhibitNotice, SnapRunInhibitNotice, InterfacesRequestsPromptNotice, InterfacesRequestsRuleUpdateNotice:
+	case ChangeUpdateNotice, WarningNotice, RefreshInhibitNotice, SnapRunInhibitNotice, InterfacesRequestsPromptNotice, InterfacesRequestsRuleUpdateNotice, QuotaUsageNotice, DiskSpaceReclaimedNotice:
 		return true
 	}
 	return false
@@ -414,6 +425,7 @@ func (s *State) AddNotice(userID *uint32, noticeType NoticeType, key string, opt
 	if newOrRepeated {
 		s.noticeCond.Broadcast()
 	}
+	s.noticeModified(notice.id)
 
 	return notice.id, nil
 }
@@ -541,6 +553,7 @@ func (s *State) DrainNotices(filter *NoticeFilter) []*Notice {
 		notices = append(notices, n)
 	}
 	for _, k := range toRemove {
+		s.noticeModified(s.notices[k].id)
 		delete(s.notices, k)
 	}
 	SortNotices(notices)
@@ -121,6 +121,13 @@ type State struct {
 
 	modified bool
 
+	// checkpointed holds what was last persisted through a
+	// JournalBackend, nil until a full checkpoint happened
+	checkpointed *checkpointEntries
+	// dirty holds the entries modified since the last checkpoint through
+	// a JournalBackend, nil until a full checkpoint happened
+	dirty *dirtyEntries
+
 	cache map[any]any
 
 	pendingChangeByAttr map[string]func(*Change) bool
@@ -297,11 +304,23 @@ func (s *State) Unlock() {
 		return
 	}
 
-	data := s.checkpointData()
+	var checkpoint func() error
+	if backend, ok := s.backend.(JournalBackend); ok {
+		checkpoint = s.journalCheckpointer(backend)
+		if checkpoint == nil {
+			// nothing actually changed since the last checkpoint
+			s.modified = false
+			return
+		}
+	} else {
+		data := s.checkpointData()
+		checkpoint = func() error { return s.backend.Checkpoint(data) }
+	}
+
 	var err error
 	start := time.Now()
 	for time.Since(start) <= unlockCheckpointRetryMaxTime {
-		if err = s.backend.Checkpoint(data); err == nil {
+		if err = checkpoint(); err == nil {
 			s.modified = false
 			return
 		}
@@ -361,6 +380,7 @@ func (s *State) Has(key string) bool {
 func (s *State) Set(key string, value any) {
 	s.writing()
 	s.data.set(key, value)
+	s.dataModified(key)
 }
 
 // Cached returns the cached value associated with the provided key.
@@ -388,6 +408,7 @@ func (s *State) NewChange(kind, summary string) *Change {
 	id := strconv.Itoa(s.lastChangeId)
 	chg := newChange(s, id, kind, summary)
 	s.changes[id] = chg
+	s.changeModified(id)
 	// Add change-update notice for newly spawned change
 	// NOTE: Implies State.writing()
 	if err := chg.addNotice(); err != nil {
@@ -428,6 +449,7 @@ func (s *State) NewTask(kind, summary string) *Task {
 	id := strconv.Itoa(s.lastTaskId)
 	t := newTask(s, id, kind, summary)
 	s.tasks[id] = t
+	s.taskModified(t)
 	return t
 }
 
@@ -523,6 +545,7 @@ NextChange:
 			if spawnTime.Before(pruneLimit) && len(chg.Tasks()) == 0 {
 				chg.Abort()
 				delete(s.changes, chg.ID())
+				s.changeModified(chg.ID())
 			} else if spawnTime.Before(abortLimit) {
 				for attr, pending := range s.pendingChangeByAttr {
 					if chg.Has(attr) && pending(chg) {
@@ -538,8 +561,10 @@ NextChange:
 			s.writing()
 			for _, t := range chg.Tasks() {
 				delete(s.tasks, t.ID())
+				s.taskModified(t)
 			}
 			delete(s.changes, chg.ID())
+			s.changeModified(chg.ID())
 			readyChangesCount--
 		}
 	}
@@ -549,6 +574,7 @@ NextChange:
 		if t.Change() == nil && t.SpawnTime().Before(pruneLimit) {
 			s.writing()
 			delete(s.tasks, tid)
+			s.taskModified(t)
 		}
 	}
 }
@@ -559,6 +585,7 @@ func (s *State) pruneWarnings(now time.Time) {
 	for k, w := range s.warnings {
 		if w.ExpiredBefore(now) {
 			delete(s.warnings, k)
+			s.warningModified(w.message)
 		}
 	}
 }
@@ -569,6 +596,7 @@ func (s *State) pruneNotices(now time.Time) {
 	for k, n := range s.notices {
 		if n.Expired(now) {
 			delete(s.notices, k)
+			s.noticeModified(n.id)
 		}
 	}
 }
@@ -236,6 +236,7 @@ func (s *State) AddWarning(message string, options *AddWarningOptions) {
 
 	warning.lastAdded = now
 	warning.repeatAfter = options.RepeatAfter
+	s.warningModified(message)
 }
 
 // RemoveWarning removes a warning given its message.
@@ -251,6 +252,7 @@ func (s *State) RemoveWarning(message string) error {
 	}
 
 	delete(s.warnings, message)
+	s.warningModified(message)
 	return nil
 }
 
@@ -284,6 +286,7 @@ func (s *State) OkayWarnings(t time.Time) int {
 	for _, w := range s.warnings {
 		if w.ShowAfter(t) {
 			w.lastShown = t
+			s.warningModified(w.message)
 			n++
 		}
 	}
@@ -350,5 +353,6 @@ func (s *State) UnshowAllWarnings() {
 	defer s.warningsMu.Unlock()
 	for _, w := range s.warnings {
 		w.lastShown = time.Time{}
+		s.warningModified(w.message)
 	}
 }
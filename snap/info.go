@@ -429,6 +429,10 @@ type Info struct {
 
 	StoreURL string
 
+	// Changelog holds the release notes of the revision, as published in
+	// the store.
+	Changelog string
+
 	// The flattended channel map with $track/$risk
 	Channels map[string]*ChannelSnapInfo
 
@@ -1421,6 +1425,10 @@ type HookInfo struct {
 	Environment  strutil.OrderedMap
 	CommandChain []string
 
+	// CheckInterval is the interval at which the check-health hook is
+	// run periodically, zero if it only runs after changes to the snap.
+	CheckInterval timeout.Timeout
+
 	Explicit bool
 }
 
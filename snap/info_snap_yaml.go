@@ -113,10 +113,11 @@ type appYaml struct {
 }
 
 type hookYaml struct {
-	PlugNames    []string           `yaml:"plugs,omitempty"`
-	SlotNames    []string           `yaml:"slots,omitempty"`
-	Environment  strutil.OrderedMap `yaml:"environment,omitempty"`
-	CommandChain []string           `yaml:"command-chain,omitempty"`
+	PlugNames     []string           `yaml:"plugs,omitempty"`
+	SlotNames     []string           `yaml:"slots,omitempty"`
+	Environment   strutil.OrderedMap `yaml:"environment,omitempty"`
+	CommandChain  []string           `yaml:"command-chain,omitempty"`
+	CheckInterval timeout.Timeout    `yaml:"check-interval,omitempty"`
 }
 
 type componentYaml struct {
@@ -564,6 +565,8 @@ func setHooksFromSnapYaml(y snapYaml, snap *Info, strk *scopedTracker) {
 			Environment:  yHook.Environment,
 			CommandChain: yHook.CommandChain,
 			Explicit:     true,
+
+			CheckInterval: yHook.CheckInterval,
 		}
 		if len(y.Plugs) > 0 || len(yHook.PlugNames) > 0 {
 			hook.Plugs = make(map[string]*PlugInfo)
@@ -1743,6 +1743,19 @@ hooks:
 	c.Assert(info.Hooks["foo"].Environment, DeepEquals, *strutil.NewOrderedMap("k1", "v1", "k2", "v2"))
 }
 
+func (s *YamlSuite) TestSnapYamlHookCheckInterval(c *C) {
+	y := []byte(`
+name: foo
+version: 1.0
+hooks:
+ check-health:
+  check-interval: 15m
+`)
+	info, err := snap.InfoFromSnapYaml(y)
+	c.Assert(err, IsNil)
+	c.Assert(info.Hooks["check-health"].CheckInterval, Equals, timeout.Timeout(15*time.Minute))
+}
+
 // classic confinement
 func (s *YamlSuite) TestClassicConfinement(c *C) {
 	y := []byte(`
@@ -83,6 +83,20 @@ type GroupQuotaJournal struct {
 	RatePeriod time.Duration `json:"rate-period,omitempty"`
 }
 
+// GroupQuotaIO contains the block I/O limits of a group. The bandwidth limits
+// apply to the block device backing the snap data directories.
+type GroupQuotaIO struct {
+	// ReadBandwidth is the maximum number of bytes per second the group
+	// can read from the device. A value of 0 means no limit is present.
+	ReadBandwidth quantity.Size `json:"read-bandwidth,omitempty"`
+	// WriteBandwidth is the maximum number of bytes per second the group
+	// can write to the device. A value of 0 means no limit is present.
+	WriteBandwidth quantity.Size `json:"write-bandwidth,omitempty"`
+	// Weight is the relative share of the I/O time of the group, between 1
+	// and 10000. A value of 0 means the systemd default of 100 is used.
+	Weight int `json:"weight,omitempty"`
+}
+
 // Group is a quota group of snaps, services or sub-groups that are all subject
 // to specific resource quotas. The only quota resource types currently
 // supported is memory, but this can be expanded in the future.
@@ -109,6 +123,15 @@ type Group struct {
 	// ExhaustionBehavior. MemoryLimit is expressed in bytes.
 	MemoryLimit quantity.Size `json:"memory-limit,omitempty"`
 
+	// MemoryHigh is the soft limit of memory of the group, above which the
+	// processes are throttled and their memory reclaimed aggressively rather
+	// than killed. MemoryLow is the amount of memory of the group protected
+	// from reclaim. MemorySwapMax is the limit of swap the group may use. All
+	// are expressed in bytes.
+	MemoryHigh    quantity.Size `json:"memory-high,omitempty"`
+	MemoryLow     quantity.Size `json:"memory-low,omitempty"`
+	MemorySwapMax quantity.Size `json:"memory-swap-max,omitempty"`
+
 	// CPULimit is the quotas for the cpu and consists of a couple of nubs.
 	// It is possible to control the percentage of the cpu available for the group
 	// and which cores (requires cgroupsv2) are allowed to be used.
@@ -124,6 +147,17 @@ type Group struct {
 	// journald.
 	JournalLimit *GroupQuotaJournal `json:"journal-limit,omitempty"`
 
+	// IOLimit is the block I/O bandwidth limits and weight of the group.
+	// Unlike the memory and thread limits, I/O limits of sub-groups are not
+	// accounted against the ones of their parent, as the kernel enforces the
+	// smallest limit along the hierarchy anyway.
+	IOLimit *GroupQuotaIO `json:"io-limit,omitempty"`
+
+	// NetworkAccounting is whether IP accounting is enabled for the group, so
+	// that its network traffic can be reported. It is off by default as it
+	// comes with a cost for every packet sent or received by the group.
+	NetworkAccounting bool `json:"network-accounting,omitempty"`
+
 	// ParentGroup is the the parent group that this group is a child of. If it
 	// is empty, then this is a "root" quota group.
 	ParentGroup string `json:"parent-group,omitempty"`
@@ -165,6 +199,15 @@ func (grp *Group) GetQuotaResources() Resources {
 	if grp.MemoryLimit != 0 {
 		resourcesBuilder.WithMemoryLimit(grp.MemoryLimit)
 	}
+	if grp.MemoryHigh != 0 {
+		resourcesBuilder.WithMemoryHigh(grp.MemoryHigh)
+	}
+	if grp.MemoryLow != 0 {
+		resourcesBuilder.WithMemoryLow(grp.MemoryLow)
+	}
+	if grp.MemorySwapMax != 0 {
+		resourcesBuilder.WithMemorySwapMax(grp.MemorySwapMax)
+	}
 	if grp.CPULimit != nil {
 		if grp.CPULimit.Count != 0 {
 			resourcesBuilder.WithCPUCount(grp.CPULimit.Count)
@@ -192,6 +235,20 @@ func (grp *Group) GetQuotaResources() Resources {
 			resourcesBuilder.WithJournalRate(grp.JournalLimit.RateCount, grp.JournalLimit.RatePeriod)
 		}
 	}
+	if grp.IOLimit != nil {
+		if grp.IOLimit.ReadBandwidth != 0 {
+			resourcesBuilder.WithIOReadBandwidth(grp.IOLimit.ReadBandwidth)
+		}
+		if grp.IOLimit.WriteBandwidth != 0 {
+			resourcesBuilder.WithIOWriteBandwidth(grp.IOLimit.WriteBandwidth)
+		}
+		if grp.IOLimit.Weight != 0 {
+			resourcesBuilder.WithIOWeight(grp.IOLimit.Weight)
+		}
+	}
+	if grp.NetworkAccounting {
+		resourcesBuilder.WithNetworkAccounting(true)
+	}
 	return resourcesBuilder.Build()
 }
 
@@ -244,6 +301,27 @@ func (grp *Group) CurrentTaskUsage() (int, error) {
 	return int(count), nil
 }
 
+// CurrentNetworkUsage returns the number of bytes received and sent over IP by
+// the quota group. For quota groups which do not yet have a backing systemd
+// slice on the system (i.e. quota groups without any snaps in them), the
+// network usage is reported as 0. The traffic is only accounted for groups
+// with NetworkAccounting enabled.
+func (grp *Group) CurrentNetworkUsage() (ingress, egress quantity.Size, err error) {
+	sysd := systemd.New(systemd.SystemMode, progress.Null)
+
+	// check if this group is actually active, it could not physically exist yet
+	// since it has no snaps in it
+	isActive, err := sysd.IsActive(grp.SliceFileName())
+	if err != nil {
+		return 0, 0, err
+	}
+	if !isActive {
+		return 0, 0, nil
+	}
+
+	return sysd.CurrentNetworkUsage(grp.SliceFileName())
+}
+
 // SliceFileName returns the name of the slice file that should be used for this
 // quota group. This name will include all of the group's parents in the name.
 // For example, a group named "bar" that is a child of the "foo" group will have
@@ -363,6 +441,16 @@ type groupQuotaAllocations struct {
 	MemoryLimit              quantity.Size
 	MemoryReservedByChildren quantity.Size
 
+	// Memory protection is distributed among the sub-groups like the hard
+	// limit, while the soft and swap limits are not reserved in the parent
+	// group, so only the largest ones among the sub-groups are tracked.
+	MemoryLowLimit              quantity.Size
+	MemoryLowReservedByChildren quantity.Size
+	MemoryHighLimit             quantity.Size
+	MemoryHighOfChildren        quantity.Size
+	MemorySwapMaxLimit          quantity.Size
+	MemorySwapMaxOfChildren     quantity.Size
+
 	CPULimit              int
 	CPUReservedByChildren int
 
@@ -450,10 +538,13 @@ func (grp *Group) getCurrentCPUAllocation() int {
 // tree and store them in the allQuotas paramater
 func (grp *Group) getQuotaAllocations(allQuotas map[string]*groupQuotaAllocations) *groupQuotaAllocations {
 	limits := &groupQuotaAllocations{
-		MemoryLimit:  grp.MemoryLimit,
-		CPULimit:     grp.getCurrentCPUAllocation(),
-		ThreadsLimit: grp.ThreadLimit,
-		CPUSetLimit:  grp.GetLocalCPUSetQuota(),
+		MemoryLimit:        grp.MemoryLimit,
+		MemoryLowLimit:     grp.MemoryLow,
+		MemoryHighLimit:    grp.MemoryHigh,
+		MemorySwapMaxLimit: grp.MemorySwapMax,
+		CPULimit:           grp.getCurrentCPUAllocation(),
+		ThreadsLimit:       grp.ThreadLimit,
+		CPUSetLimit:        grp.GetLocalCPUSetQuota(),
 	}
 
 	// sliceUniqueAndSort sorts an array of ints in ascending order and removes duplicates
@@ -480,6 +571,9 @@ func (grp *Group) getQuotaAllocations(allQuotas map[string]*groupQuotaAllocation
 		// is because if the sub-group doesn't have any limit set for a quota, but the sub-group has sub-groups
 		// itself that do have limits, then we must use that value instead. Hence the max* functions.
 		limits.MemoryReservedByChildren += maxq(subGroupLimits.MemoryLimit, subGroupLimits.MemoryReservedByChildren)
+		limits.MemoryLowReservedByChildren += maxq(subGroupLimits.MemoryLowLimit, subGroupLimits.MemoryLowReservedByChildren)
+		limits.MemoryHighOfChildren = maxq(limits.MemoryHighOfChildren, maxq(subGroupLimits.MemoryHighLimit, subGroupLimits.MemoryHighOfChildren))
+		limits.MemorySwapMaxOfChildren = maxq(limits.MemorySwapMaxOfChildren, maxq(subGroupLimits.MemorySwapMaxLimit, subGroupLimits.MemorySwapMaxOfChildren))
 		limits.CPUReservedByChildren += max(subGroupLimits.CPULimit, subGroupLimits.CPUReservedByChildren)
 		limits.ThreadsReservedByChildren += max(subGroupLimits.ThreadsLimit, subGroupLimits.ThreadsReservedByChildren)
 
@@ -547,6 +641,79 @@ func (grp *Group) validateMemoryResourceFit(allQuotas map[string]*groupQuotaAllo
 	return nil
 }
 
+// validateMemorySoftLimitsFit verifies that the new memory high limit, memory
+// low protection and swap limit of the group are consistent with the ones of
+// its sub-groups and parent groups. Sub-groups cannot have a larger soft or
+// swap limit than their parent groups, nor a soft limit larger than the hard
+// limit of a parent group, while the memory protections of the sub-groups
+// must fit inside the protection of the nearest parent group that has one,
+// as they would otherwise be scaled down by the kernel.
+func (grp *Group) validateMemorySoftLimitsFit(allQuotas map[string]*groupQuotaAllocations, memory *ResourceMemory) error {
+	currentLimits := allQuotas[grp.Name]
+	if currentLimits == nil {
+		currentLimits = &groupQuotaAllocations{}
+	}
+
+	memoryLimit := grp.MemoryLimit
+	if memory.Limit != 0 {
+		memoryLimit = memory.Limit
+	}
+	if memoryLimit != 0 && currentLimits.MemoryHighOfChildren > memoryLimit {
+		return fmt.Errorf("group memory limit of %s is too small to fit current subgroup memory high limit of %s",
+			memoryLimit.IECString(), currentLimits.MemoryHighOfChildren.IECString())
+	}
+
+	if high := memory.high(); high != 0 {
+		if currentLimits.MemoryHighOfChildren > high {
+			return fmt.Errorf("group memory high limit of %s is too small to fit current subgroup memory high limit of %s",
+				high.IECString(), currentLimits.MemoryHighOfChildren.IECString())
+		}
+		for parent := grp.parentGroup; parent != nil; parent = parent.parentGroup {
+			for _, parentLimit := range []quantity.Size{parent.MemoryHigh, parent.MemoryLimit} {
+				if parentLimit != 0 && high > parentLimit {
+					return fmt.Errorf("sub-group memory high limit of %s is too large to fit inside group %q memory limits",
+						high.IECString(), parent.Name)
+				}
+			}
+		}
+	}
+
+	if low := memory.low(); low != 0 {
+		if currentLimits.MemoryLowReservedByChildren > low {
+			return fmt.Errorf("group memory low protection of %s is too small to fit current subgroup protection of %s",
+				low.IECString(), currentLimits.MemoryLowReservedByChildren.IECString())
+		}
+		lowReserved := maxq(grp.MemoryLow, currentLimits.MemoryLowReservedByChildren)
+		for parent := grp.parentGroup; parent != nil; parent = parent.parentGroup {
+			limits := allQuotas[parent.Name]
+			if limits != nil && limits.MemoryLowLimit != 0 {
+				// as for the hard limit, we might already account for
+				// some of the protection of the parent
+				lowAvailable := limits.MemoryLowLimit - (limits.MemoryLowReservedByChildren - lowReserved)
+				if low > lowAvailable {
+					return fmt.Errorf("sub-group memory low protection of %s is too large to fit inside group %q remaining protection %s",
+						low.IECString(), parent.Name, lowAvailable.IECString())
+				}
+				break
+			}
+		}
+	}
+
+	if swapMax := memory.swapMax(); swapMax != 0 {
+		if currentLimits.MemorySwapMaxOfChildren > swapMax {
+			return fmt.Errorf("group swap limit of %s is too small to fit current subgroup swap limit of %s",
+				swapMax.IECString(), currentLimits.MemorySwapMaxOfChildren.IECString())
+		}
+		for parent := grp.parentGroup; parent != nil; parent = parent.parentGroup {
+			if parent.MemorySwapMax != 0 && swapMax > parent.MemorySwapMax {
+				return fmt.Errorf("sub-group swap limit of %s is too large to fit inside group %q swap limit %s",
+					swapMax.IECString(), parent.Name, parent.MemorySwapMax.IECString())
+			}
+		}
+	}
+	return nil
+}
+
 // validateCPUResourceFit verifies that the new cpu limit doesn't conflict with the current reserved cpu
 // limit of the group, and if not locates the nearest parent group that has a cpu quota, and then verifies
 // if that group has any space available by checking its 'cpuReserved'. The 'cpuReserved' tells us how much
@@ -737,7 +904,12 @@ func (grp *Group) validateQuotasFit(resourceLimits Resources) error {
 	// for each limit we want to set, we need to find the closes parent
 	// limit that matches it, and then verify against it's usage if we have room
 	if resourceLimits.Memory != nil {
-		if err := grp.validateMemoryResourceFit(allQuotas, resourceLimits.Memory.Limit); err != nil {
+		if resourceLimits.Memory.Limit != 0 {
+			if err := grp.validateMemoryResourceFit(allQuotas, resourceLimits.Memory.Limit); err != nil {
+				return err
+			}
+		}
+		if err := grp.validateMemorySoftLimitsFit(allQuotas, resourceLimits.Memory); err != nil {
 			return err
 		}
 	}
@@ -774,7 +946,20 @@ func (grp *Group) UpdateQuotaLimits(resourceLimits Resources) error {
 	}
 
 	if resourceLimits.Memory != nil {
-		grp.MemoryLimit = resourceLimits.Memory.Limit
+		if resourceLimits.Memory.Limit != 0 {
+			grp.MemoryLimit = resourceLimits.Memory.Limit
+		}
+		// a memory high or swap limit or protection of 0 removes it from
+		// the group
+		if resourceLimits.Memory.High != nil {
+			grp.MemoryHigh = *resourceLimits.Memory.High
+		}
+		if resourceLimits.Memory.Low != nil {
+			grp.MemoryLow = *resourceLimits.Memory.Low
+		}
+		if resourceLimits.Memory.SwapMax != nil {
+			grp.MemorySwapMax = *resourceLimits.Memory.SwapMax
+		}
 	}
 	if resourceLimits.CPU != nil {
 		grp.CPULimit = &GroupQuotaCPU{
@@ -804,6 +989,27 @@ func (grp *Group) UpdateQuotaLimits(resourceLimits Resources) error {
 			grp.JournalLimit.RatePeriod = resourceLimits.Journal.Rate.Period
 		}
 	}
+	if resourceLimits.IO != nil {
+		if grp.IOLimit == nil {
+			grp.IOLimit = &GroupQuotaIO{}
+		}
+		// a limit of 0 removes it from the group
+		if resourceLimits.IO.ReadBandwidth != nil {
+			grp.IOLimit.ReadBandwidth = *resourceLimits.IO.ReadBandwidth
+		}
+		if resourceLimits.IO.WriteBandwidth != nil {
+			grp.IOLimit.WriteBandwidth = *resourceLimits.IO.WriteBandwidth
+		}
+		if resourceLimits.IO.Weight != nil {
+			grp.IOLimit.Weight = *resourceLimits.IO.Weight
+		}
+		if *grp.IOLimit == (GroupQuotaIO{}) {
+			grp.IOLimit = nil
+		}
+	}
+	if resourceLimits.Network != nil {
+		grp.NetworkAccounting = resourceLimits.Network.Accounting
+	}
 	return nil
 }
 
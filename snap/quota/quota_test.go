@@ -1575,6 +1575,197 @@ func (ts *quotaTestSuite) TestJournalQuotasUpdatesCorrectly(c *C) {
 	c.Check(grp1.JournalLimit.RatePeriod, Equals, time.Microsecond*5)
 }
 
+func (ts *quotaTestSuite) TestIOQuotasUpdatesCorrectly(c *C) {
+	grp1, err := quota.NewGroup("groot1", quota.NewResourcesBuilder().WithIOReadBandwidth(quantity.SizeMiB).Build())
+	c.Assert(err, IsNil)
+	c.Assert(grp1.IOLimit, DeepEquals, &quota.GroupQuotaIO{ReadBandwidth: quantity.SizeMiB})
+
+	err = grp1.UpdateQuotaLimits(quota.NewResourcesBuilder().WithIOWriteBandwidth(2 * quantity.SizeMiB).WithIOWeight(200).Build())
+	c.Assert(err, IsNil)
+	c.Check(grp1.IOLimit, DeepEquals, &quota.GroupQuotaIO{
+		ReadBandwidth:  quantity.SizeMiB,
+		WriteBandwidth: 2 * quantity.SizeMiB,
+		Weight:         200,
+	})
+	c.Check(grp1.GetQuotaResources(), DeepEquals, quota.NewResourcesBuilder().
+		WithIOReadBandwidth(quantity.SizeMiB).
+		WithIOWriteBandwidth(2*quantity.SizeMiB).
+		WithIOWeight(200).
+		Build())
+}
+
+func (ts *quotaTestSuite) TestIOQuotasRemovedCorrectly(c *C) {
+	grp1, err := quota.NewGroup("groot1", quota.NewResourcesBuilder().
+		WithIOReadBandwidth(quantity.SizeMiB).
+		WithIOWeight(200).
+		Build())
+	c.Assert(err, IsNil)
+
+	// a limit of 0 removes it
+	err = grp1.UpdateQuotaLimits(quota.NewResourcesBuilder().WithIOWeight(0).Build())
+	c.Assert(err, IsNil)
+	c.Check(grp1.IOLimit, DeepEquals, &quota.GroupQuotaIO{ReadBandwidth: quantity.SizeMiB})
+	c.Check(grp1.GetQuotaResources(), DeepEquals, quota.NewResourcesBuilder().
+		WithIOReadBandwidth(quantity.SizeMiB).
+		Build())
+
+	// and the io limits are gone once all of them are removed
+	err = grp1.UpdateQuotaLimits(quota.NewResourcesBuilder().WithIOReadBandwidth(0).Build())
+	c.Assert(err, IsNil)
+	c.Check(grp1.IOLimit, IsNil)
+}
+
+func (ts *quotaTestSuite) TestNetworkAccountingUpdatesCorrectly(c *C) {
+	grp1, err := quota.NewGroup("groot1", quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build())
+	c.Assert(err, IsNil)
+	c.Check(grp1.NetworkAccounting, Equals, false)
+
+	err = grp1.UpdateQuotaLimits(quota.NewResourcesBuilder().WithNetworkAccounting(true).Build())
+	c.Assert(err, IsNil)
+	c.Check(grp1.NetworkAccounting, Equals, true)
+	c.Check(grp1.GetQuotaResources(), DeepEquals, quota.NewResourcesBuilder().
+		WithMemoryLimit(quantity.SizeGiB).
+		WithNetworkAccounting(true).
+		Build())
+
+	err = grp1.UpdateQuotaLimits(quota.NewResourcesBuilder().WithNetworkAccounting(false).Build())
+	c.Assert(err, IsNil)
+	c.Check(grp1.NetworkAccounting, Equals, false)
+	c.Check(grp1.GetQuotaResources(), DeepEquals, quota.NewResourcesBuilder().
+		WithMemoryLimit(quantity.SizeGiB).
+		Build())
+}
+
+func (ts *quotaTestSuite) TestMemorySoftLimitsUpdatesCorrectly(c *C) {
+	grp1, err := quota.NewGroup("groot1", quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build())
+	c.Assert(err, IsNil)
+
+	// the soft limits are set without touching the hard limit
+	err = grp1.UpdateQuotaLimits(quota.NewResourcesBuilder().WithMemoryHigh(768 * quantity.SizeMiB).WithMemorySwapMax(256 * quantity.SizeMiB).Build())
+	c.Assert(err, IsNil)
+	err = grp1.UpdateQuotaLimits(quota.NewResourcesBuilder().WithMemoryLow(128 * quantity.SizeMiB).Build())
+	c.Assert(err, IsNil)
+	c.Check(grp1.MemoryLimit, Equals, quantity.SizeGiB)
+	c.Check(grp1.MemoryHigh, Equals, 768*quantity.SizeMiB)
+	c.Check(grp1.MemoryLow, Equals, 128*quantity.SizeMiB)
+	c.Check(grp1.MemorySwapMax, Equals, 256*quantity.SizeMiB)
+	c.Check(grp1.GetQuotaResources(), DeepEquals, quota.NewResourcesBuilder().
+		WithMemoryLimit(quantity.SizeGiB).
+		WithMemoryHigh(768*quantity.SizeMiB).
+		WithMemoryLow(128*quantity.SizeMiB).
+		WithMemorySwapMax(256*quantity.SizeMiB).
+		Build())
+
+	// the soft and swap limits and protection are removed with a value of 0
+	err = grp1.UpdateQuotaLimits(quota.NewResourcesBuilder().WithMemoryHigh(0).WithMemoryLow(0).WithMemorySwapMax(0).Build())
+	c.Assert(err, IsNil)
+	c.Check(grp1.MemoryLimit, Equals, quantity.SizeGiB)
+	c.Check(grp1.MemoryHigh, Equals, quantity.Size(0))
+	c.Check(grp1.MemoryLow, Equals, quantity.Size(0))
+	c.Check(grp1.MemorySwapMax, Equals, quantity.Size(0))
+	c.Check(grp1.GetQuotaResources(), DeepEquals, quota.NewResourcesBuilder().
+		WithMemoryLimit(quantity.SizeGiB).
+		Build())
+
+	// a group can have a soft limit only
+	grp2, err := quota.NewGroup("groot2", quota.NewResourcesBuilder().WithMemoryHigh(quantity.SizeGiB).Build())
+	c.Assert(err, IsNil)
+	c.Check(grp2.MemoryLimit, Equals, quantity.Size(0))
+	c.Check(grp2.MemoryHigh, Equals, quantity.SizeGiB)
+	c.Check(grp2.MemorySwapMax, Equals, quantity.Size(0))
+}
+
+func (ts *quotaTestSuite) TestNestingOfMemorySoftLimits(c *C) {
+	grp1, err := quota.NewGroup("groot", quota.NewResourcesBuilder().
+		WithMemoryLimit(quantity.SizeGiB).
+		WithMemoryHigh(768*quantity.SizeMiB).
+		WithMemoryLow(256*quantity.SizeMiB).
+		WithMemorySwapMax(512*quantity.SizeMiB).
+		Build())
+	c.Assert(err, IsNil)
+
+	subgrp1, err := grp1.NewSubGroup("cpu-sub", quota.NewResourcesBuilder().WithCPUCount(2).WithCPUPercentage(50).Build())
+	c.Assert(err, IsNil)
+
+	// soft limits are checked against all the parents
+	_, err = subgrp1.NewSubGroup("mem-sub", quota.NewResourcesBuilder().WithMemoryHigh(900*quantity.SizeMiB).Build())
+	c.Check(err, ErrorMatches, `sub-group memory high limit of 900 MiB is too large to fit inside group "groot" memory limits`)
+	_, err = subgrp1.NewSubGroup("mem-sub", quota.NewResourcesBuilder().WithMemorySwapMax(quantity.SizeGiB).Build())
+	c.Check(err, ErrorMatches, `sub-group swap limit of 1 GiB is too large to fit inside group "groot" swap limit 512 MiB`)
+
+	// but are not reserved in the parent, unlike the protection
+	memgrp1, err := subgrp1.NewSubGroup("mem-sub1", quota.NewResourcesBuilder().
+		WithMemoryHigh(768*quantity.SizeMiB).
+		WithMemoryLow(192*quantity.SizeMiB).
+		Build())
+	c.Assert(err, IsNil)
+	_, err = grp1.NewSubGroup("mem-sub2", quota.NewResourcesBuilder().
+		WithMemoryHigh(768*quantity.SizeMiB).
+		WithMemoryLow(128*quantity.SizeMiB).
+		Build())
+	c.Check(err, ErrorMatches, `sub-group memory low protection of 128 MiB is too large to fit inside group "groot" remaining protection 64 MiB`)
+	_, err = grp1.NewSubGroup("mem-sub2", quota.NewResourcesBuilder().
+		WithMemoryHigh(768*quantity.SizeMiB).
+		WithMemoryLow(64*quantity.SizeMiB).
+		WithMemorySwapMax(512*quantity.SizeMiB).
+		Build())
+	c.Check(err, IsNil)
+
+	// a sub-group can grow its protection within the one of the parent
+	err = memgrp1.UpdateQuotaLimits(quota.NewResourcesBuilder().WithMemoryLow(256 * quantity.SizeMiB).Build())
+	c.Check(err, ErrorMatches, `sub-group memory low protection of 256 MiB is too large to fit inside group "groot" remaining protection 192 MiB`)
+
+	// and the limits of the parent cannot go below the ones of the sub-groups
+	err = grp1.QuotaUpdateCheck(quota.NewResourcesBuilder().WithMemoryHigh(512 * quantity.SizeMiB).Build())
+	c.Check(err, ErrorMatches, `group memory high limit of 512 MiB is too small to fit current subgroup memory high limit of 768 MiB`)
+	err = grp1.QuotaUpdateCheck(quota.NewResourcesBuilder().WithMemoryLow(128 * quantity.SizeMiB).Build())
+	c.Check(err, ErrorMatches, `group memory low protection of 128 MiB is too small to fit current subgroup protection of 256 MiB`)
+	err = grp1.QuotaUpdateCheck(quota.NewResourcesBuilder().WithMemorySwapMax(256 * quantity.SizeMiB).Build())
+	c.Check(err, ErrorMatches, `group swap limit of 256 MiB is too small to fit current subgroup swap limit of 512 MiB`)
+}
+
+func (ts *quotaTestSuite) TestCurrentNetworkUsage(c *C) {
+	systemctlCalls := 0
+	r := systemd.MockSystemctl(func(args ...string) ([]byte, error) {
+		systemctlCalls++
+		switch systemctlCalls {
+
+		// inactive case, network usage is 0
+		case 1:
+			c.Assert(args, DeepEquals, []string{"is-active", "snap.group.slice"})
+			return []byte("inactive"), systemctlInactiveServiceError{}
+
+		case 2:
+			c.Assert(args, DeepEquals, []string{"is-active", "snap.group.slice"})
+			return []byte("active"), nil
+		case 3:
+			c.Assert(args, DeepEquals, []string{"show", "--property", "IPIngressBytes", "snap.group.slice"})
+			return []byte("IPIngressBytes=4096"), nil
+		case 4:
+			c.Assert(args, DeepEquals, []string{"show", "--property", "IPEgressBytes", "snap.group.slice"})
+			return []byte("IPEgressBytes=1024"), nil
+
+		default:
+			c.Errorf("too many systemctl calls (%d) (current call is %+v)", systemctlCalls, args)
+			return []byte("broken test"), fmt.Errorf("broken test")
+		}
+	})
+	defer r()
+
+	grp1, err := quota.NewGroup("group", quota.NewResourcesBuilder().WithIOWeight(100).Build())
+	c.Assert(err, IsNil)
+
+	ingress, egress, err := grp1.CurrentNetworkUsage()
+	c.Assert(err, IsNil)
+	c.Check(ingress, Equals, quantity.Size(0))
+	c.Check(egress, Equals, quantity.Size(0))
+
+	ingress, egress, err = grp1.CurrentNetworkUsage()
+	c.Assert(err, IsNil)
+	c.Check(ingress, Equals, 4*quantity.SizeKiB)
+	c.Check(egress, Equals, quantity.SizeKiB)
+}
+
 func (ts *quotaTestSuite) TestServiceMapEmptyOnEmptyGroup(c *C) {
 	rootGrp, err := quota.NewGroup("myroot", quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build())
 	c.Assert(err, IsNil)
@@ -39,8 +39,49 @@ func init() {
 	cgroupCheckMemoryCgroupErr = cgroup.CheckMemoryCgroup()
 }
 
+// ResourceMemory represents the memory quotas. Limit is the hard limit, above
+// which the oom-killer is invoked, while High is a soft limit above which the
+// processes are throttled and put under heavy reclaim pressure. Low is the
+// amount of memory protected from reclaim, and SwapMax limits the swap usage.
+// When changing the limits of a group, a High, Low or SwapMax of 0 removes
+// them.
 type ResourceMemory struct {
-	Limit quantity.Size `json:"limit"`
+	Limit   quantity.Size  `json:"limit"`
+	High    *quantity.Size `json:"high,omitempty"`
+	Low     *quantity.Size `json:"low,omitempty"`
+	SwapMax *quantity.Size `json:"swap-max,omitempty"`
+}
+
+// unset returns whether no memory quota is given at all.
+func (m *ResourceMemory) unset() bool {
+	return m.Limit == 0 && m.High == nil && m.Low == nil && m.SwapMax == nil
+}
+
+// limited returns whether any memory quota is set, as opposed to only being
+// removed.
+func (m *ResourceMemory) limited() bool {
+	return m.Limit != 0 || m.high() != 0 || m.low() != 0 || m.swapMax() != 0
+}
+
+func (m *ResourceMemory) high() quantity.Size {
+	if m.High == nil {
+		return 0
+	}
+	return *m.High
+}
+
+func (m *ResourceMemory) low() quantity.Size {
+	if m.Low == nil {
+		return 0
+	}
+	return *m.Low
+}
+
+func (m *ResourceMemory) swapMax() quantity.Size {
+	if m.SwapMax == nil {
+		return 0
+	}
+	return *m.SwapMax
 }
 
 type ResourceCPU struct {
@@ -75,6 +116,24 @@ type ResourceJournal struct {
 	Rate *ResourceJournalRate `json:"rate,omitempty"`
 }
 
+// ResourceIO represents the block I/O quotas. The bandwidth limits are
+// expressed in bytes per second and only apply to the single block device
+// backing the snap data directories, the weight is relative to the other
+// groups and services and ranges from 1 to 10000. Each of them is a pointer
+// so that a limit can be removed again by explicitly setting it to 0.
+type ResourceIO struct {
+	ReadBandwidth  *quantity.Size `json:"read-bandwidth,omitempty"`
+	WriteBandwidth *quantity.Size `json:"write-bandwidth,omitempty"`
+	Weight         *int           `json:"weight,omitempty"`
+}
+
+// ResourceNetwork represents the network settings of a quota group. Network
+// traffic is not limited, but IP accounting can be enabled to report the
+// traffic of the group.
+type ResourceNetwork struct {
+	Accounting bool `json:"accounting"`
+}
+
 // Resources are built up of multiple quota limits. Each quota limit is a pointer
 // value to indicate that their presence may be optional, and because we want to detect
 // whenever someone changes a limit to '0' explicitly.
@@ -84,6 +143,8 @@ type Resources struct {
 	CPUSet  *ResourceCPUSet  `json:"cpu-set,omitempty"`
 	Threads *ResourceThreads `json:"thread,omitempty"`
 	Journal *ResourceJournal `json:"journal,omitempty"`
+	IO      *ResourceIO      `json:"io,omitempty"`
+	Network *ResourceNetwork `json:"network,omitempty"`
 }
 
 const (
@@ -99,13 +160,29 @@ const (
 	// usage, but we have selected 64kB to protect against ridiculously small values.
 	journalLimitMin = 64 * quantity.SizeKiB
 	journalLimitMax = 4 * quantity.SizeGiB
+
+	// The range of the IOWeight setting of systemd.
+	ioWeightMin = 1
+	ioWeightMax = 10000
 )
 
 func (qr *Resources) validateMemoryQuota() error {
-	// make sure the memory limit is not zero
-	if qr.Memory.Limit == 0 {
+	// make sure some memory limit is set
+	if !qr.Memory.limited() {
 		return fmt.Errorf("memory quota must have a limit set")
 	}
+	high, low := qr.Memory.high(), qr.Memory.low()
+	if qr.Memory.Limit != 0 && high > qr.Memory.Limit {
+		return fmt.Errorf("memory high limit %s cannot be larger than the memory limit %s",
+			high.IECString(), qr.Memory.Limit.IECString())
+	}
+	// the protection is meaningless when above any of the limits
+	for _, limit := range []quantity.Size{high, qr.Memory.Limit} {
+		if limit != 0 && low > limit {
+			return fmt.Errorf("memory low protection %s cannot be larger than the memory limits",
+				low.IECString())
+		}
+	}
 	return nil
 }
 
@@ -186,6 +263,17 @@ func (qr *Resources) validateJournalQuota() error {
 	return nil
 }
 
+func (qr *Resources) validateIOQuota() error {
+	if qr.IO.ReadBandwidth == nil && qr.IO.WriteBandwidth == nil && qr.IO.Weight == nil {
+		return fmt.Errorf("io quota must have a bandwidth limit or a weight set")
+	}
+	// a weight of 0 removes the weight of the group
+	if qr.IO.Weight != nil && *qr.IO.Weight != 0 && (*qr.IO.Weight < ioWeightMin || *qr.IO.Weight > ioWeightMax) {
+		return fmt.Errorf("invalid io weight %d: weight must be between %d and %d", *qr.IO.Weight, ioWeightMin, ioWeightMax)
+	}
+	return nil
+}
+
 // CheckFeatureRequirements checks if the current system meets the
 // requirements for the given resource request.
 //
@@ -199,6 +287,22 @@ func (qr *Resources) CheckFeatureRequirements() error {
 			return fmt.Errorf("cannot use CPU set with cgroup version %d", cgroupVer)
 		}
 	}
+	if qr.IO != nil {
+		if cgroupVerErr != nil {
+			return cgroupVerErr
+		}
+		if cgroupVer < 2 {
+			return fmt.Errorf("cannot use IO quota with cgroup version %d", cgroupVer)
+		}
+	}
+	if qr.Memory != nil && (qr.Memory.high() != 0 || qr.Memory.low() != 0 || qr.Memory.swapMax() != 0) {
+		if cgroupVerErr != nil {
+			return cgroupVerErr
+		}
+		if cgroupVer < 2 {
+			return fmt.Errorf("cannot use memory high, low or swap limits with cgroup version %d", cgroupVer)
+		}
+	}
 	if qr.Memory != nil && cgroupCheckMemoryCgroupErr != nil {
 		return fmt.Errorf("cannot use memory quota: %v", cgroupCheckMemoryCgroupErr)
 	}
@@ -251,6 +355,12 @@ func (qr *Resources) Validate() error {
 			return err
 		}
 	}
+
+	if qr.IO != nil {
+		if err := qr.validateIOQuota(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -260,22 +370,29 @@ func (qr *Resources) Validate() error {
 // or to make sure that certain/all limits are not removed.
 // We also require memory limits are above 640kB.
 func (qr *Resources) ValidateChange(newLimits Resources) error {
-	// Check that the memory limit is not being decreased
+	// Check that the memory limit is not being decreased. The memory limits
+	// are changed independently of each other, so a zero limit leaves the
+	// current one untouched.
 	if newLimits.Memory != nil {
-		if qr.Memory != nil && newLimits.Memory.Limit == 0 {
+		if qr.Memory != nil && newLimits.Memory.unset() {
 			return fmt.Errorf("cannot remove memory limit from quota group")
 		}
 
-		if newLimits.Memory.Limit <= memoryLimitMin {
+		if newLimits.Memory.Limit != 0 && newLimits.Memory.Limit <= memoryLimitMin {
 			return fmt.Errorf("memory limit %d is too small: size must be larger than %s",
 				newLimits.Memory.Limit, memoryLimitMin.IECString())
 		}
+		// the soft limit needs the same room as the hard one for the slice
+		if high := newLimits.Memory.high(); high != 0 && high <= memoryLimitMin {
+			return fmt.Errorf("memory high limit %d is too small: size must be larger than %s",
+				high, memoryLimitMin.IECString())
+		}
 
 		// we disallow decreasing the memory limit because it is difficult to do
 		// so correctly with the current state of our code in
 		// EnsureSnapServices, see comment in ensureSnapServicesForGroup for
 		// full details
-		if qr.Memory != nil && newLimits.Memory.Limit < qr.Memory.Limit {
+		if qr.Memory != nil && newLimits.Memory.Limit != 0 && newLimits.Memory.Limit < qr.Memory.Limit {
 			return fmt.Errorf("cannot decrease memory limit, remove and re-create it to decrease the limit")
 		}
 	}
@@ -354,7 +471,20 @@ func (qr *Resources) ValidateChange(newLimits Resources) error {
 func (qr *Resources) clone() Resources {
 	var resourcesCopy Resources
 	if qr.Memory != nil {
-		resourcesCopy.Memory = &ResourceMemory{Limit: qr.Memory.Limit}
+		memoryCopy := *qr.Memory
+		if qr.Memory.High != nil {
+			high := *qr.Memory.High
+			memoryCopy.High = &high
+		}
+		if qr.Memory.Low != nil {
+			low := *qr.Memory.Low
+			memoryCopy.Low = &low
+		}
+		if qr.Memory.SwapMax != nil {
+			swapMax := *qr.Memory.SwapMax
+			memoryCopy.SwapMax = &swapMax
+		}
+		resourcesCopy.Memory = &memoryCopy
 	}
 	if qr.CPU != nil {
 		resourcesCopy.CPU = &ResourceCPU{Count: qr.CPU.Count, Percentage: qr.CPU.Percentage}
@@ -374,13 +504,59 @@ func (qr *Resources) clone() Resources {
 			resourcesCopy.Journal.Rate = &ResourceJournalRate{Count: qr.Journal.Rate.Count, Period: qr.Journal.Rate.Period}
 		}
 	}
+	if qr.IO != nil {
+		resourcesCopy.IO = &ResourceIO{}
+		if qr.IO.ReadBandwidth != nil {
+			readBandwidth := *qr.IO.ReadBandwidth
+			resourcesCopy.IO.ReadBandwidth = &readBandwidth
+		}
+		if qr.IO.WriteBandwidth != nil {
+			writeBandwidth := *qr.IO.WriteBandwidth
+			resourcesCopy.IO.WriteBandwidth = &writeBandwidth
+		}
+		if qr.IO.Weight != nil {
+			weight := *qr.IO.Weight
+			resourcesCopy.IO.Weight = &weight
+		}
+	}
+	if qr.Network != nil {
+		resourcesCopy.Network = &ResourceNetwork{Accounting: qr.Network.Accounting}
+	}
 	return resourcesCopy
 }
 
 // changeInternal applies each new limit provided
 func (qr *Resources) changeInternal(newLimits Resources) {
 	if newLimits.Memory != nil {
-		qr.Memory = newLimits.Memory
+		if qr.Memory == nil {
+			qr.Memory = &ResourceMemory{}
+		}
+		if newLimits.Memory.Limit != 0 {
+			qr.Memory.Limit = newLimits.Memory.Limit
+		}
+		// the memory high and swap limits and protection are removed with
+		// a value of 0
+		if newLimits.Memory.High != nil {
+			qr.Memory.High = nil
+			if high := *newLimits.Memory.High; high != 0 {
+				qr.Memory.High = &high
+			}
+		}
+		if newLimits.Memory.Low != nil {
+			qr.Memory.Low = nil
+			if low := *newLimits.Memory.Low; low != 0 {
+				qr.Memory.Low = &low
+			}
+		}
+		if newLimits.Memory.SwapMax != nil {
+			qr.Memory.SwapMax = nil
+			if swapMax := *newLimits.Memory.SwapMax; swapMax != 0 {
+				qr.Memory.SwapMax = &swapMax
+			}
+		}
+		if qr.Memory.unset() {
+			qr.Memory = nil
+		}
 	}
 	if newLimits.CPU != nil {
 		qr.CPU = newLimits.CPU
@@ -402,6 +578,25 @@ func (qr *Resources) changeInternal(newLimits Resources) {
 			qr.Journal.Rate = newLimits.Journal.Rate
 		}
 	}
+	// io limits are set independently of each other, so only the ones
+	// given are changed, a value of 0 removes the limit
+	if newLimits.IO != nil {
+		if qr.IO == nil {
+			qr.IO = &ResourceIO{}
+		}
+		if newLimits.IO.ReadBandwidth != nil {
+			qr.IO.ReadBandwidth = newLimits.IO.ReadBandwidth
+		}
+		if newLimits.IO.WriteBandwidth != nil {
+			qr.IO.WriteBandwidth = newLimits.IO.WriteBandwidth
+		}
+		if newLimits.IO.Weight != nil {
+			qr.IO.Weight = newLimits.IO.Weight
+		}
+	}
+	if newLimits.Network != nil {
+		qr.Network = newLimits.Network
+	}
 }
 
 // Change updates the current quota limits with the new limits. Additional verification
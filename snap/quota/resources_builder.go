@@ -29,6 +29,15 @@ type ResourcesBuilder struct {
 	MemoryLimit    quantity.Size
 	MemoryLimitSet bool
 
+	MemoryHigh    quantity.Size
+	MemoryHighSet bool
+
+	MemoryLow    quantity.Size
+	MemoryLowSet bool
+
+	MemorySwapMax    quantity.Size
+	MemorySwapMaxSet bool
+
 	CPUCount    int
 	CPUCountSet bool
 
@@ -49,6 +58,18 @@ type ResourcesBuilder struct {
 	JournalRateCountLimit  int
 	JournalRatePeriodLimit time.Duration
 	JournalRateSet         bool
+
+	IOReadBandwidthLimit    quantity.Size
+	IOReadBandwidthLimitSet bool
+
+	IOWriteBandwidthLimit    quantity.Size
+	IOWriteBandwidthLimitSet bool
+
+	IOWeight    int
+	IOWeightSet bool
+
+	NetworkAccounting    bool
+	NetworkAccountingSet bool
 }
 
 func (rb *ResourcesBuilder) WithMemoryLimit(limit quantity.Size) *ResourcesBuilder {
@@ -57,6 +78,24 @@ func (rb *ResourcesBuilder) WithMemoryLimit(limit quantity.Size) *ResourcesBuild
 	return rb
 }
 
+func (rb *ResourcesBuilder) WithMemoryHigh(limit quantity.Size) *ResourcesBuilder {
+	rb.MemoryHigh = limit
+	rb.MemoryHighSet = true
+	return rb
+}
+
+func (rb *ResourcesBuilder) WithMemoryLow(protection quantity.Size) *ResourcesBuilder {
+	rb.MemoryLow = protection
+	rb.MemoryLowSet = true
+	return rb
+}
+
+func (rb *ResourcesBuilder) WithMemorySwapMax(limit quantity.Size) *ResourcesBuilder {
+	rb.MemorySwapMax = limit
+	rb.MemorySwapMaxSet = true
+	return rb
+}
+
 func (rb *ResourcesBuilder) WithCPUCount(count int) *ResourcesBuilder {
 	rb.CPUCount = count
 	rb.CPUCountSet = true
@@ -99,12 +138,48 @@ func (rb *ResourcesBuilder) WithJournalRate(count int, period time.Duration) *Re
 	return rb
 }
 
+func (rb *ResourcesBuilder) WithIOReadBandwidth(limit quantity.Size) *ResourcesBuilder {
+	rb.IOReadBandwidthLimit = limit
+	rb.IOReadBandwidthLimitSet = true
+	return rb
+}
+
+func (rb *ResourcesBuilder) WithIOWriteBandwidth(limit quantity.Size) *ResourcesBuilder {
+	rb.IOWriteBandwidthLimit = limit
+	rb.IOWriteBandwidthLimitSet = true
+	return rb
+}
+
+func (rb *ResourcesBuilder) WithIOWeight(weight int) *ResourcesBuilder {
+	rb.IOWeight = weight
+	rb.IOWeightSet = true
+	return rb
+}
+
+func (rb *ResourcesBuilder) WithNetworkAccounting(enabled bool) *ResourcesBuilder {
+	rb.NetworkAccounting = enabled
+	rb.NetworkAccountingSet = true
+	return rb
+}
+
 func (rb *ResourcesBuilder) Build() Resources {
 	var quotaResources Resources
-	if rb.MemoryLimitSet {
+	if rb.MemoryLimitSet || rb.MemoryHighSet || rb.MemoryLowSet || rb.MemorySwapMaxSet {
 		quotaResources.Memory = &ResourceMemory{
 			Limit: rb.MemoryLimit,
 		}
+		if rb.MemoryHighSet {
+			high := rb.MemoryHigh
+			quotaResources.Memory.High = &high
+		}
+		if rb.MemoryLowSet {
+			low := rb.MemoryLow
+			quotaResources.Memory.Low = &low
+		}
+		if rb.MemorySwapMaxSet {
+			swapMax := rb.MemorySwapMax
+			quotaResources.Memory.SwapMax = &swapMax
+		}
 	}
 	if rb.CPUCountSet || rb.CPUPercentageSet {
 		quotaResources.CPU = &ResourceCPU{
@@ -136,6 +211,26 @@ func (rb *ResourcesBuilder) Build() Resources {
 			}
 		}
 	}
+	if rb.IOReadBandwidthLimitSet || rb.IOWriteBandwidthLimitSet || rb.IOWeightSet {
+		quotaResources.IO = &ResourceIO{}
+		if rb.IOReadBandwidthLimitSet {
+			readBandwidth := rb.IOReadBandwidthLimit
+			quotaResources.IO.ReadBandwidth = &readBandwidth
+		}
+		if rb.IOWriteBandwidthLimitSet {
+			writeBandwidth := rb.IOWriteBandwidthLimit
+			quotaResources.IO.WriteBandwidth = &writeBandwidth
+		}
+		if rb.IOWeightSet {
+			weight := rb.IOWeight
+			quotaResources.IO.Weight = &weight
+		}
+	}
+	if rb.NetworkAccountingSet {
+		quotaResources.Network = &ResourceNetwork{
+			Accounting: rb.NetworkAccounting,
+		}
+	}
 	return quotaResources
 }
 
@@ -50,6 +50,12 @@ func (s *resourcesTestSuite) TestQuotaValidationFails(c *C) {
 		{quota.NewResourcesBuilder().WithJournalRate(0, 1).Build(), `journal quota must have a period of at least 1 microsecond \(minimum resolution\)`},
 		{quota.NewResourcesBuilder().WithJournalRate(1, time.Nanosecond).Build(), `journal quota must have a period of at least 1 microsecond \(minimum resolution\)`},
 		{quota.NewResourcesBuilder().WithJournalSize(0).Build(), `journal size quota must have a limit set`},
+		{quota.NewResourcesBuilder().WithMemoryHigh(0).WithMemoryLow(0).WithMemorySwapMax(0).Build(), `memory quota must have a limit set`},
+		{quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeMiB).WithMemoryHigh(2 * quantity.SizeMiB).Build(), `memory high limit 2 MiB cannot be larger than the memory limit 1 MiB`},
+		{quota.NewResourcesBuilder().WithMemoryHigh(quantity.SizeMiB).WithMemoryLow(2 * quantity.SizeMiB).Build(), `memory low protection 2 MiB cannot be larger than the memory limits`},
+		{quota.Resources{IO: &quota.ResourceIO{}}, `io quota must have a bandwidth limit or a weight set`},
+		{quota.NewResourcesBuilder().WithIOWeight(10001).Build(), `invalid io weight 10001: weight must be between 1 and 10000`},
+		{quota.NewResourcesBuilder().WithIOReadBandwidth(quantity.SizeMiB).WithIOWeight(-1).Build(), `invalid io weight -1: weight must be between 1 and 10000`},
 	}
 
 	for _, t := range tests {
@@ -69,6 +75,19 @@ func (s *resourcesTestSuite) TestResourceCheckFeatureRequirementsCgroupv1(c *C)
 	// cpu set with cgroup v1 is not supported
 	bad := quota.NewResourcesBuilder().WithCPUSet([]int{0, 1}).Build()
 	c.Check(bad.CheckFeatureRequirements(), ErrorMatches, "cannot use CPU set with cgroup version 1")
+
+	// neither are io quotas
+	bad = quota.NewResourcesBuilder().WithIOWeight(100).Build()
+	c.Check(bad.CheckFeatureRequirements(), ErrorMatches, "cannot use IO quota with cgroup version 1")
+
+	// nor memory soft and swap limits, unlike the hard memory limit
+	for _, bad := range []quota.Resources{
+		quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).WithMemoryHigh(quantity.SizeMiB).Build(),
+		quota.NewResourcesBuilder().WithMemoryLow(quantity.SizeMiB).Build(),
+		quota.NewResourcesBuilder().WithMemorySwapMax(quantity.SizeMiB).Build(),
+	} {
+		c.Check(bad.CheckFeatureRequirements(), ErrorMatches, "cannot use memory high, low or swap limits with cgroup version 1")
+	}
 }
 
 func (s *resourcesTestSuite) TestResourceCheckFeatureRequirementsCgroupv1Err(c *C) {
@@ -89,12 +108,17 @@ func (s *resourcesTestSuite) TestQuotaValidationPasses(c *C) {
 		limits quota.Resources
 	}{
 		{quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeMiB).Build()},
+		{quota.NewResourcesBuilder().WithMemoryHigh(quantity.SizeMiB).Build()},
+		{quota.NewResourcesBuilder().WithMemoryLimit(2 * quantity.SizeMiB).WithMemoryHigh(quantity.SizeMiB).WithMemoryLow(quantity.SizeMiB).WithMemorySwapMax(quantity.SizeMiB).Build()},
 		{quota.NewResourcesBuilder().WithCPUCount(1).WithCPUPercentage(50).Build()},
 		{quota.NewResourcesBuilder().WithCPUSet([]int{0, 1}).Build()},
 		{quota.NewResourcesBuilder().WithThreadLimit(16).Build()},
 		{quota.NewResourcesBuilder().WithJournalSize(quantity.SizeMiB).Build()},
 		{quota.NewResourcesBuilder().WithJournalRate(1, time.Microsecond).Build()},
 		{quota.NewResourcesBuilder().WithJournalNamespace().Build()},
+		{quota.NewResourcesBuilder().WithIOReadBandwidth(quantity.SizeMiB).Build()},
+		{quota.NewResourcesBuilder().WithIOWriteBandwidth(quantity.SizeMiB).WithIOWeight(10000).Build()},
+		{quota.NewResourcesBuilder().WithNetworkAccounting(true).Build()},
 	}
 
 	for _, t := range tests {
@@ -124,6 +148,11 @@ func (s *resourcesTestSuite) TestQuotaChangeValidationFails(c *C) {
 			quota.NewResourcesBuilder().WithMemoryLimit(800 * quantity.SizeKiB).Build(),
 			`cannot decrease memory limit, remove and re-create it to decrease the limit`,
 		},
+		{
+			quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeMiB).Build(),
+			quota.NewResourcesBuilder().WithMemoryHigh(5 * quantity.SizeKiB).Build(),
+			`memory high limit 5120 is too small: size must be larger than 640 KiB`,
+		},
 		{
 			quota.NewResourcesBuilder().WithThreadLimit(64).Build(),
 			quota.NewResourcesBuilder().WithThreadLimit(0).Build(),
@@ -205,6 +234,12 @@ func (s *resourcesTestSuite) TestQuotaChangeValidationFails(c *C) {
 			quota.NewResourcesBuilder().WithJournalSize(5 * quantity.SizeGiB).Build(),
 			`journal size quota must be smaller than 4 GiB`,
 		},
+		{
+			// the resulting soft limit is checked against the hard one
+			quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build(),
+			quota.NewResourcesBuilder().WithMemoryHigh(2 * quantity.SizeGiB).Build(),
+			`memory high limit 2 GiB cannot be larger than the memory limit 1 GiB`,
+		},
 	}
 
 	for _, t := range tests {
@@ -303,6 +338,44 @@ func (s *resourcesTestSuite) TestQuotaChangeValidationPasses(c *C) {
 			quota.NewResourcesBuilder().WithJournalNamespace().Build(),
 			quota.NewResourcesBuilder().WithCPUCount(4).WithCPUPercentage(25).WithJournalNamespace().Build(),
 		},
+		{
+			// io limits are changed independently of each other, and
+			// can be decreased
+			quota.NewResourcesBuilder().WithIOReadBandwidth(quantity.SizeMiB * 10).WithIOWeight(50).Build(),
+			quota.NewResourcesBuilder().WithIOReadBandwidth(quantity.SizeMiB).WithIOWriteBandwidth(quantity.SizeMiB * 2).Build(),
+			quota.NewResourcesBuilder().WithIOReadBandwidth(quantity.SizeMiB).WithIOWriteBandwidth(quantity.SizeMiB * 2).WithIOWeight(50).Build(),
+		},
+		{
+			// io limits can be removed by setting them to 0
+			quota.NewResourcesBuilder().WithIOReadBandwidth(quantity.SizeMiB * 10).WithIOWeight(50).Build(),
+			quota.NewResourcesBuilder().WithIOWeight(0).Build(),
+			quota.NewResourcesBuilder().WithIOReadBandwidth(quantity.SizeMiB * 10).WithIOWeight(0).Build(),
+		},
+		{
+			// network accounting can be enabled and disabled again
+			quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).WithNetworkAccounting(true).Build(),
+			quota.NewResourcesBuilder().WithNetworkAccounting(false).Build(),
+			quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).WithNetworkAccounting(false).Build(),
+		},
+		{
+			// memory soft limits are changed independently of the hard
+			// one, and can be decreased
+			quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).WithMemoryHigh(quantity.SizeGiB).Build(),
+			quota.NewResourcesBuilder().WithMemoryHigh(quantity.SizeGiB / 2).WithMemorySwapMax(quantity.SizeMiB).Build(),
+			quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).WithMemoryHigh(quantity.SizeGiB / 2).WithMemorySwapMax(quantity.SizeMiB).Build(),
+		},
+		{
+			// and removed with a value of 0
+			quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).WithMemoryHigh(quantity.SizeGiB).WithMemoryLow(quantity.SizeMiB).WithMemorySwapMax(quantity.SizeMiB).Build(),
+			quota.NewResourcesBuilder().WithMemoryHigh(0).WithMemoryLow(0).WithMemorySwapMax(0).Build(),
+			quota.NewResourcesBuilder().WithMemoryLimit(quantity.SizeGiB).Build(),
+		},
+		{
+			// removing the only memory quota removes the memory resource
+			quota.NewResourcesBuilder().WithMemoryHigh(quantity.SizeGiB).WithThreadLimit(16).Build(),
+			quota.NewResourcesBuilder().WithMemoryHigh(0).Build(),
+			quota.NewResourcesBuilder().WithThreadLimit(16).Build(),
+		},
 	}
 
 	for _, t := range tests {
@@ -43,6 +43,10 @@ type SnapshotOptions struct {
 	// character is "*", which stands for any sequence of characters other than
 	// "/".
 	Exclude []string `yaml:"exclude" json:"exclude,omitempty"`
+	// Include, if not empty, is the list of file and directory patterns
+	// that a snapshot or a restore is limited to, using the same syntax
+	// as Exclude. It can only be given dynamically.
+	Include []string `yaml:"-" json:"include,omitempty"`
 }
 
 const (
@@ -54,7 +58,7 @@ const (
 // It can be used, for example, to determine if the SnapshotOptions object should be
 // serialized to metadata.
 func (opts *SnapshotOptions) Unset() bool {
-	return len(opts.Exclude) == 0
+	return len(opts.Exclude) == 0 && len(opts.Include) == 0
 }
 
 // MergeDynamicExcludes combines dynamic excludes with existing excludes.
@@ -75,25 +79,34 @@ func (opts *SnapshotOptions) Validate() error {
 	// even if the manifest specified paths starting with ../ this would not
 	// cause tar to navigate into those directories and pose a security risk.
 	// Still, let's have a minimal validation on them being sensible.
+	if err := validateSnapshotPaths("exclude", opts.Exclude); err != nil {
+		return err
+	}
+	// The include list is matched against the content of the snap data
+	// directories, so the same rules keep it from reaching outside of them.
+	return validateSnapshotPaths("include", opts.Include)
+}
+
+func validateSnapshotPaths(kind string, paths []string) error {
 	validFirstComponents := []string{
 		"$SNAP_DATA", "$SNAP_COMMON", "$SNAP_USER_DATA", "$SNAP_USER_COMMON",
 	}
 	const invalidChars = "[]{}?"
-	for _, excludePath := range opts.Exclude {
-		firstComponent := strings.SplitN(excludePath, "/", 2)[0]
+	for _, path := range paths {
+		firstComponent := strings.SplitN(path, "/", 2)[0]
 		if !strutil.ListContains(validFirstComponents, firstComponent) {
-			return fmt.Errorf("snapshot exclude path must start with one of %q (got: %q)", validFirstComponents, excludePath)
+			return fmt.Errorf("snapshot %s path must start with one of %q (got: %q)", kind, validFirstComponents, path)
 		}
 
-		cleanPath := filepath.Clean(excludePath)
-		if cleanPath != excludePath {
-			return fmt.Errorf("snapshot exclude path not clean: %q", excludePath)
+		cleanPath := filepath.Clean(path)
+		if cleanPath != path {
+			return fmt.Errorf("snapshot %s path not clean: %q", kind, path)
 		}
 
 		// We could use a regexp to do this validation, but an explicit check
 		// is more readable and less error-prone
-		if strings.ContainsAny(excludePath, invalidChars) || strings.Contains(excludePath, "**") {
-			return fmt.Errorf("snapshot exclude path contains invalid characters: %q", excludePath)
+		if strings.ContainsAny(path, invalidChars) || strings.Contains(path, "**") {
+			return fmt.Errorf("snapshot %s path contains invalid characters: %q", kind, path)
 		}
 	}
 
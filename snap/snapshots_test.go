@@ -77,6 +77,9 @@ func (s *snapshotSuite) TestValidateErrors(c *C) {
 		"invalid-chars-2":   {snap.SnapshotOptions{Exclude: []string{"$SNAP_DATA/tree**"}}, pathInvalidCharsError},
 		"invalid-chars-3":   {snap.SnapshotOptions{Exclude: []string{"$SNAP_DATA/foo[12]"}}, pathInvalidCharsError},
 		"invalid-chars-4":   {snap.SnapshotOptions{Exclude: []string{"$SNAP_DATA/bar?"}}, pathInvalidCharsError},
+		"include-1":         {snap.SnapshotOptions{Include: []string{"/home/ubuntu"}}, "snapshot include path must start with one of.*"},
+		"include-2":         {snap.SnapshotOptions{Include: []string{"$SNAP_USER_DATA/../x"}}, "snapshot include path not clean.*"},
+		"include-3":         {snap.SnapshotOptions{Include: []string{"$SNAP_USER_DATA/x?"}}, "snapshot include path contains invalid characters.*"},
 	}
 
 	for name, test := range testMap {
@@ -92,6 +95,7 @@ func (s *snapshotSuite) TestValidateHappy(c *C) {
 	}{
 		"exclude-empty":   {snap.SnapshotOptions{Exclude: []string{}}},
 		"exclude-typical": {snap.SnapshotOptions{Exclude: snapshotHappyExpectedExclude}},
+		"include-typical": {snap.SnapshotOptions{Include: []string{"$SNAP_USER_DATA/.config/app.conf", "$SNAP_COMMON/*.db"}}},
 	}
 
 	for name, test := range testMap {
@@ -134,9 +138,10 @@ func (s *snapshotSuite) TestUnset(c *C) {
 		options *snap.SnapshotOptions
 		isUnset bool
 	}{
-		"exclude-empty":   {options: &snap.SnapshotOptions{[]string{}}, isUnset: true},
+		"exclude-empty":   {options: &snap.SnapshotOptions{Exclude: []string{}}, isUnset: true},
 		"exclude-nil":     {options: &snap.SnapshotOptions{}, isUnset: true},
-		"exclude-typical": {options: &snap.SnapshotOptions{snapshotHappyExpectedExclude}, isUnset: false},
+		"exclude-typical": {options: &snap.SnapshotOptions{Exclude: snapshotHappyExpectedExclude}, isUnset: false},
+		"include-typical": {options: &snap.SnapshotOptions{Include: []string{"$SNAP_USER_DATA/.config"}}, isUnset: false},
 	}
 
 	for name, test := range testMap {
@@ -29,6 +29,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/snapcore/snapd/osutil"
@@ -209,9 +210,22 @@ func ValidateHook(hook *HookInfo) error {
 		}
 	}
 
+	if hook.CheckInterval != 0 {
+		if hook.Name != "check-health" {
+			return fmt.Errorf("hook %q cannot have a check-interval, only check-health can", hook.Name)
+		}
+		if time.Duration(hook.CheckInterval) < minHealthCheckInterval {
+			return fmt.Errorf("check-health hook check-interval %s is too short, must be at least %s", hook.CheckInterval, minHealthCheckInterval)
+		}
+	}
+
 	return nil
 }
 
+// minHealthCheckInterval is the shortest interval at which the check-health
+// hook can be run periodically.
+const minHealthCheckInterval = 5 * time.Minute
+
 // ValidateAlias checks if a string can be used as an alias name.
 func ValidateAlias(alias string) error {
 	return naming.ValidateAlias(alias)
@@ -25,6 +25,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	. "gopkg.in/check.v1"
@@ -34,6 +35,7 @@ import (
 	"github.com/snapcore/snapd/overlord/snapstate"
 	. "github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timeout"
 )
 
 type ValidateSuite struct {
@@ -145,6 +147,7 @@ func (s *ValidateSuite) TestValidateHook(c *C) {
 		{Name: "a-aa"},
 		{Name: "a-b-c"},
 		{Name: "valid", CommandChain: []string{"valid"}},
+		{Name: "check-health", CheckInterval: timeout.Timeout(5 * time.Minute)},
 	}
 	for _, hook := range validHooks {
 		err := ValidateHook(hook)
@@ -173,6 +176,11 @@ func (s *ValidateSuite) TestValidateHook(c *C) {
 		err := ValidateHook(hook)
 		c.Assert(err, ErrorMatches, `hook command-chain contains illegal.*`)
 	}
+
+	err := ValidateHook(&HookInfo{Name: "install", CheckInterval: timeout.Timeout(time.Hour)})
+	c.Check(err, ErrorMatches, `hook "install" cannot have a check-interval, only check-health can`)
+	err = ValidateHook(&HookInfo{Name: "check-health", CheckInterval: timeout.Timeout(time.Minute)})
+	c.Check(err, ErrorMatches, `check-health hook check-interval 1m0s is too short, must be at least 5m0s`)
 }
 
 // ValidateApp
@@ -74,6 +74,10 @@ type downloadCache interface {
 	// when the cache is in use and cleanup should be retried at some later
 	// time.
 	Cleanup() error
+	// Prune removes unreferenced cache items, starting from the oldest ones,
+	// until at least the given number of bytes were freed. Returns
+	// ErrCleanupBusy when the cache is in use.
+	Prune(target uint64) (removedCount int, removedSize uint64, err error)
 }
 
 // nullCache is cache that does not cache
@@ -89,6 +93,8 @@ func (cm *nullCache) Put(cacheKey, sourcePath string) error { return nil }
 
 func (cm *nullCache) Cleanup() error { return nil }
 
+func (cm *nullCache) Prune(target uint64) (int, uint64, error) { return 0, 0, nil }
+
 // entriesByMtime sorts by the mtime of files
 type entriesByMtime []os.FileInfo
 
@@ -246,6 +252,54 @@ func (cm *CacheManager) Cleanup() error {
 	return err
 }
 
+// Prune removes unreferenced items, starting from the oldest ones, until at
+// least target bytes were freed, regardless of the cache policy. A zero target
+// removes all unreferenced items. May return ErrCleanupBusy if the cleanup lock
+// cannot be taken.
+func (cm *CacheManager) Prune(target uint64) (removedCount int, removedSize uint64, err error) {
+	if !cm.cleanupLock.TryLock() {
+		return 0, 0, ErrCleanupBusy
+	}
+	defer cm.cleanupLock.Unlock()
+
+	entries, err := os.ReadDir(cm.cacheDir)
+	if err != nil {
+		return 0, 0, err
+	}
+	candidates := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		fi, err := entry.Info()
+		if err != nil {
+			return 0, 0, err
+		}
+		if cm.cachePolicy.isCandidate(fi) {
+			candidates = append(candidates, fi)
+		}
+	}
+	sort.Sort(entriesByMtime(candidates))
+
+	var lastErr error
+	for _, c := range candidates {
+		if target != 0 && removedSize >= target {
+			break
+		}
+		path := cm.path(c.Name())
+		logger.Debugf("removing %v", path)
+		if err := osRemove(path); err != nil && !os.IsNotExist(err) {
+			logger.Noticef("cannot remove cache entry: %s", err)
+			lastErr = strutil.JoinErrors(lastErr, err)
+			continue
+		}
+		removedCount++
+		removedSize += uint64(c.Size())
+	}
+
+	logger.Noticef("pruned %v entries/%s from downloads cache",
+		removedCount, quantity.FormatAmount(removedSize, -1))
+
+	return removedCount, removedSize, lastErr
+}
+
 // hardLinkCount returns the number of hardlinks for the given path
 func hardLinkCount(fi os.FileInfo) (uint64, error) {
 	if stat, ok := fi.Sys().(*syscall.Stat_t); ok && stat != nil {
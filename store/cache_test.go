@@ -280,6 +280,33 @@ func (s *cacheSuite) TestCleanupBusy(c *C) {
 	c.Check(s.cm.Count(), Equals, s.maxItems)
 }
 
+func (s *cacheSuite) TestPrune(c *C) {
+	cacheKeys, testFiles := s.makeTestFiles(c, 4)
+	// the last entry stays referenced
+	for _, p := range testFiles[:3] {
+		err := os.Remove(p)
+		c.Assert(err, IsNil)
+	}
+
+	// oldest entries are removed first, until the target is met
+	count, size, err := s.cm.Prune(2)
+	c.Assert(err, IsNil)
+	c.Check(count, Equals, 2)
+	c.Check(size, Equals, uint64(2))
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[0])), Equals, false)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[1])), Equals, false)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[2])), Equals, true)
+
+	// without a target all unreferenced entries go
+	count, size, err = s.cm.Prune(0)
+	c.Assert(err, IsNil)
+	c.Check(count, Equals, 1)
+	c.Check(size, Equals, uint64(1))
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[2])), Equals, false)
+	c.Check(osutil.FileExists(filepath.Join(s.cm.CacheDir(), cacheKeys[3])), Equals, true)
+	c.Check(s.cm.Count(), Equals, 1)
+}
+
 func (s *cacheSuite) TestHardLinkCount(c *C) {
 	p := filepath.Join(s.tmp, "foo")
 	err := os.WriteFile(p, nil, 0644)
@@ -37,6 +37,7 @@ import (
 type storeSnap struct {
 	Architectures []string            `json:"architectures"`
 	Base          string              `json:"base"`
+	Changelog     safejson.Paragraph  `json:"changelog"`
 	Confinement   string              `json:"confinement"`
 	Links         map[string][]string `json:"links"`
 	Contact       string              `json:"contact"`
@@ -219,6 +220,9 @@ func copyNonZeroFrom(src, dst *storeSnap) {
 	if src.Base != "" {
 		dst.Base = src.Base
 	}
+	if src.Changelog.Clean() != "" {
+		dst.Changelog = src.Changelog
+	}
 	if src.Confinement != "" {
 		dst.Confinement = src.Confinement
 	}
@@ -320,6 +324,7 @@ func infoFromStoreSnap(d *storeSnap) (*snap.Info, error) {
 
 	info.EditedSummary = d.Summary.Clean()
 	info.EditedDescription = d.Description.Clean()
+	info.Changelog = d.Changelog.Clean()
 	info.Private = d.Private
 	// needs to be set for old snapd
 	info.LegacyEditedContact = d.Contact
@@ -221,6 +221,41 @@ func (s *downloadSuite) TestActualDownloadNonPurchased402(c *C) {
 	c.Check(n, Equals, 1)
 }
 
+func (s *downloadSuite) TestAuthorizeDownload(c *C) {
+	for _, t := range []struct {
+		status int
+		err    string
+	}{
+		{status: 302},
+		{status: 206},
+		{status: 402, err: "please buy foo before installing it"},
+		{status: 404, err: `received an unexpected http response code \(404\) when trying to download .*`},
+	} {
+		n := 0
+		mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n++
+			c.Check(r.Header.Get("Range"), Equals, "bytes=0-0")
+			if t.status == 302 {
+				http.Redirect(w, r, "/cdn/foo", http.StatusFound)
+				return
+			}
+			w.WriteHeader(t.status)
+		}))
+		c.Assert(mockServer, NotNil)
+
+		theStore := store.New(&store.Config{}, nil)
+		err := store.AuthorizeDownload(context.TODO(), "foo", mockServer.URL, nil, theStore, nil)
+		if t.err == "" {
+			c.Check(err, IsNil)
+		} else {
+			c.Check(err, ErrorMatches, t.err)
+		}
+		// the redirect to the CDN is not followed
+		c.Check(n, Equals, 1)
+		mockServer.Close()
+	}
+}
+
 func (s *downloadSuite) TestActualDownload404(c *C) {
 	n := 0
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -705,6 +740,44 @@ func (s *downloadSuite) TestActualDownloadRateLimited(c *C) {
 	c.Check(ratelimitReaderUsed, Equals, true)
 }
 
+func (s *downloadSuite) TestActualDownloadRateLimitRechecked(c *C) {
+	restore := store.MockDownloadRateLimitRecheckInterval(0)
+	defer restore()
+	var buckets []*ratelimit.Bucket
+	restore = store.MockRatelimitReader(func(r io.Reader, bucket *ratelimit.Bucket) io.Reader {
+		buckets = append(buckets, bucket)
+		return r
+	})
+	defer restore()
+
+	canary := "downloaded data"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, canary)
+	}))
+	defer ts.Close()
+
+	// the download starts unlimited, then gets limited
+	rechecks := 0
+	dlOpts := &store.DownloadOptions{
+		RateLimitRecheck: func() int64 {
+			rechecks++
+			return 1000
+		},
+	}
+	theStore := store.New(&store.Config{}, nil)
+	var buf SillyBuffer
+	err := store.Download(context.TODO(), "example-name", "", ts.URL, nil, theStore, &buf, 0, nil, dlOpts)
+	c.Assert(err, IsNil)
+	c.Check(buf.String(), Equals, canary)
+	c.Check(rechecks > 0, Equals, true)
+	c.Assert(len(buckets) > 0, Equals, true)
+	// the same bucket is kept while the limit does not change
+	for _, bucket := range buckets {
+		c.Check(bucket, Equals, buckets[0])
+		c.Check(bucket.Rate(), Equals, float64(1000))
+	}
+}
+
 func (s *downloadSuite) TestActualDownloadIcon(c *C) {
 	n := 0
 	const existingEtag = ""
@@ -24,6 +24,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"os/exec"
 	"time"
 
@@ -42,6 +43,8 @@ var (
 	ApiURL        = apiURL
 	Download      = download
 
+	AuthorizeDownload = authorizeDownload
+
 	DownloadIconImpl = downloadIcon
 	ErrIconUnchanged = errIconUnchanged
 	MaxEtagSize      = maxEtagSize
@@ -142,6 +145,10 @@ func MockOsRemove(f func(name string) error) func() {
 	}
 }
 
+func MockAuthorizeDownload(f func(ctx context.Context, name, downloadURL string, user *auth.UserState, s *Store, dlOpts *DownloadOptions) error) (restore func()) {
+	return testutil.Mock(&authorizeDownload, f)
+}
+
 func MockDownload(f func(ctx context.Context, name, sha3_384, downloadURL string, user *auth.UserState, s *Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *DownloadOptions) error) (restore func()) {
 	origDownload := download
 	download = f
@@ -230,6 +237,10 @@ func (sto *Store) FindFields() []string {
 	return sto.findFields
 }
 
+func (sto *Store) InfoFields() []string {
+	return sto.infoFields
+}
+
 func (sto *Store) UseDeltas() bool {
 	return sto.useDeltas()
 }
@@ -253,6 +264,10 @@ func NewRequestOptions(mth string, url *url.URL) *requestOptions {
 	}
 }
 
+func MockDownloadRateLimitRecheckInterval(d time.Duration) (restore func()) {
+	return testutil.Mock(&downloadRateLimitRecheckInterval, d)
+}
+
 func MockRatelimitReader(f func(r io.Reader, bucket *ratelimit.Bucket) io.Reader) (restore func()) {
 	oldRatelimitReader := ratelimitReader
 	ratelimitReader = f
@@ -275,3 +290,17 @@ type (
 )
 
 var ReportFetchAssertionsError = reportFetchAssertionsError
+
+func MockChunkedDownload(minSize, chunkSize int64, workers int) (restore func()) {
+	restore = testutil.BackupMany(&chunkedDownloadMinSize, &downloadChunkSize, &downloadChunkWorkers)
+	chunkedDownloadMinSize = minSize
+	downloadChunkSize = chunkSize
+	downloadChunkWorkers = workers
+	return restore
+}
+
+func MockFileSync(f func(*os.File) error) (restore func()) {
+	return testutil.Mock(&fileSync, f)
+}
+
+type ChunkMap = chunkMap
@@ -0,0 +1,831 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package mirror implements a store serving snaps and assertions from a
+// local directory, for devices without access to the store, e.g. on
+// air-gapped sites where updates are brought in on removable media or a
+// network share.
+//
+// The directory holds:
+//
+//   - the snap files, as *.snap
+//   - assertion streams, as *.assert, with at least the snap-declaration
+//     and snap-revision assertions of the snaps, and the account
+//     assertions of their publishers
+//   - optionally channels.json, mapping snap names to the revisions
+//     released in their channels, as in
+//     {"foo": {"latest/stable": 10, "latest/edge": 12}}
+//
+// The snaps that are not in channels.json have their highest revision
+// released in all channels. Snap files without a snap-revision assertion
+// are ignored.
+package mirror
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	_ "golang.org/x/crypto/sha3"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/channel"
+	"github.com/snapcore/snapd/snap/naming"
+	"github.com/snapcore/snapd/snap/snapfile"
+	"github.com/snapcore/snapd/store"
+)
+
+// ErrUnsupported is returned for the store operations that a mirror cannot
+// perform, like buying snaps or logging in.
+var ErrUnsupported = errors.New("operation not supported by a store mirror")
+
+const channelsFile = "channels.json"
+
+// racyModTimeWindow is how long after a change of the directory its index
+// is not cached.
+var racyModTimeWindow = time.Second
+
+var readSnapInfo = func(snapPath string, si *snap.SideInfo) (*snap.Info, error) {
+	snapf, err := snapfile.Open(snapPath)
+	if err != nil {
+		return nil, err
+	}
+	return snap.ReadInfoFromSnapFile(snapf, si)
+}
+
+type fileDigest struct {
+	modTime  time.Time
+	size     uint64
+	sha3_384 string
+}
+
+// Store serves snaps and assertions from a local directory.
+type Store struct {
+	dir string
+
+	mu sync.Mutex
+	// digests caches the digests of the snap files, which are expensive to
+	// compute
+	digests map[string]fileDigest
+	// idx caches the index of the directory as of idxModTime
+	idx        *index
+	idxModTime time.Time
+}
+
+// New returns a Store serving the snaps and assertions in the given
+// directory. The directory is read again when its modification time
+// changes, i.e. when files are added, removed or renamed in it, so that
+// updated content is picked up.
+func New(dir string) *Store {
+	return &Store{
+		dir:     dir,
+		digests: make(map[string]fileDigest),
+	}
+}
+
+// Dir returns the directory the snaps and assertions are served from.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+type revisionFile struct {
+	path     string
+	sha3_384 string
+	size     uint64
+}
+
+type mirrorSnap struct {
+	name      string
+	snapID    string
+	publisher snap.StoreAccount
+	revisions map[snap.Revision]*revisionFile
+	// channels maps full channel names to the revisions released in them,
+	// if the snap is in channels.json
+	channels map[string]snap.Revision
+}
+
+type index struct {
+	bs       asserts.Backstore
+	byName   map[string]*mirrorSnap
+	bySnapID map[string]*mirrorSnap
+}
+
+func (s *Store) digest(path string, fi os.FileInfo) (string, uint64, error) {
+	s.mu.Lock()
+	cached, ok := s.digests[path]
+	s.mu.Unlock()
+	if ok && cached.modTime.Equal(fi.ModTime()) && cached.size == uint64(fi.Size()) {
+		return cached.sha3_384, cached.size, nil
+	}
+	sha3_384, size, err := asserts.SnapFileSHA3_384(path)
+	if err != nil {
+		return "", 0, err
+	}
+	s.mu.Lock()
+	s.digests[path] = fileDigest{modTime: fi.ModTime(), size: size, sha3_384: sha3_384}
+	s.mu.Unlock()
+	return sha3_384, size, nil
+}
+
+func loadAssertions(dir string) (asserts.Backstore, error) {
+	bs := asserts.NewMemoryBackstore()
+	files, err := filepath.Glob(filepath.Join(dir, "*.assert"))
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range files {
+		f, err := os.Open(fn)
+		if err != nil {
+			return nil, err
+		}
+		dec := asserts.NewDecoder(f)
+		for {
+			a, err := dec.Decode()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("cannot read assertions from %s: %v", fn, err)
+			}
+			if err := bs.Put(a.Type(), a); err != nil {
+				var revErr *asserts.RevisionError
+				if !errors.As(err, &revErr) {
+					f.Close()
+					return nil, err
+				}
+				// an older revision than one already seen
+			}
+		}
+		f.Close()
+	}
+	return bs, nil
+}
+
+func loadChannels(dir string) (map[string]map[string]snap.Revision, error) {
+	data, err := os.ReadFile(filepath.Join(dir, channelsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]map[string]int
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", channelsFile, err)
+	}
+	channels := make(map[string]map[string]snap.Revision, len(raw))
+	for name, chans := range raw {
+		channels[name] = make(map[string]snap.Revision, len(chans))
+		for ch, rev := range chans {
+			full, err := channel.Full(ch)
+			if err != nil || full == "" {
+				return nil, fmt.Errorf("cannot read %s: invalid channel %q for %q", channelsFile, ch, name)
+			}
+			channels[name][full] = snap.R(rev)
+		}
+	}
+	return channels, nil
+}
+
+// load returns the index of the directory, which is only read again when
+// the directory changed.
+func (s *Store) load() (*index, error) {
+	fi, err := os.Stat(s.dir)
+	if err != nil || !fi.IsDir() {
+		return nil, fmt.Errorf("cannot use store mirror: %q is not a directory", s.dir)
+	}
+	s.mu.Lock()
+	idx, modTime := s.idx, s.idxModTime
+	s.mu.Unlock()
+	if idx != nil && modTime.Equal(fi.ModTime()) {
+		return idx, nil
+	}
+	idx, err = s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	// the modification time has a coarse granularity, so a directory
+	// that was just changed could change again without it moving
+	if time.Since(fi.ModTime()) > racyModTimeWindow {
+		s.mu.Lock()
+		s.idx, s.idxModTime = idx, fi.ModTime()
+		s.mu.Unlock()
+	}
+	return idx, nil
+}
+
+func (s *Store) readIndex() (*index, error) {
+	bs, err := loadAssertions(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	channels, err := loadChannels(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	snapFiles, err := filepath.Glob(filepath.Join(s.dir, "*.snap"))
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &index{
+		bs:       bs,
+		byName:   make(map[string]*mirrorSnap),
+		bySnapID: make(map[string]*mirrorSnap),
+	}
+	for _, fn := range snapFiles {
+		fi, err := os.Stat(fn)
+		if err != nil {
+			return nil, err
+		}
+		sha3_384, size, err := s.digest(fn, fi)
+		if err != nil {
+			return nil, err
+		}
+		a, err := bs.Get(asserts.SnapRevisionType, []string{sha3_384}, asserts.SnapRevisionType.MaxSupportedFormat())
+		if err != nil {
+			logger.Noticef("Ignoring %s in store mirror: no snap-revision assertion", fn)
+			continue
+		}
+		snapRev := a.(*asserts.SnapRevision)
+		ms := idx.bySnapID[snapRev.SnapID()]
+		if ms == nil {
+			a, err := bs.Get(asserts.SnapDeclarationType, []string{release.Series, snapRev.SnapID()}, asserts.SnapDeclarationType.MaxSupportedFormat())
+			if err != nil {
+				logger.Noticef("Ignoring %s in store mirror: no snap-declaration assertion", fn)
+				continue
+			}
+			decl := a.(*asserts.SnapDeclaration)
+			ms = &mirrorSnap{
+				name:      decl.SnapName(),
+				snapID:    decl.SnapID(),
+				publisher: snap.StoreAccount{ID: decl.PublisherID()},
+				revisions: make(map[snap.Revision]*revisionFile),
+				channels:  channels[decl.SnapName()],
+			}
+			if a, err := bs.Get(asserts.AccountType, []string{decl.PublisherID()}, asserts.AccountType.MaxSupportedFormat()); err == nil {
+				acct := a.(*asserts.Account)
+				ms.publisher.Username = acct.Username()
+				ms.publisher.DisplayName = acct.DisplayName()
+				ms.publisher.Validation = acct.Validation()
+			}
+			idx.bySnapID[ms.snapID] = ms
+			idx.byName[ms.name] = ms
+		}
+		ms.revisions[snap.R(snapRev.SnapRevision())] = &revisionFile{
+			path:     fn,
+			sha3_384: sha3_384,
+			size:     size,
+		}
+	}
+	return idx, nil
+}
+
+// riskFallback lists the risks whose releases are followed by a channel of
+// the given risk when it has no release of its own, like closed channels in
+// the store.
+func riskFallback(risk string) []string {
+	risks := []string{"edge", "beta", "candidate", "stable"}
+	for i, r := range risks {
+		if r == risk {
+			return risks[i:]
+		}
+	}
+	return []string{risk}
+}
+
+// resolve returns the revision released in the given channel.
+func (ms *mirrorSnap) resolve(ch string) (snap.Revision, string, error) {
+	if ch == "" {
+		ch = "latest/stable"
+	}
+	full, err := channel.Full(ch)
+	if err != nil {
+		return snap.Revision{}, "", err
+	}
+	if ms.channels == nil {
+		var latest snap.Revision
+		for rev := range ms.revisions {
+			if latest.N < rev.N {
+				latest = rev
+			}
+		}
+		return latest, full, nil
+	}
+	parts := strings.Split(full, "/")
+	track, branch := parts[0], ""
+	if len(parts) == 3 {
+		branch = "/" + parts[2]
+	}
+	for _, risk := range riskFallback(parts[1]) {
+		if rev, ok := ms.channels[track+"/"+risk+branch]; ok {
+			return rev, full, nil
+		}
+		// branches fall back to their channel
+		if rev, ok := ms.channels[track+"/"+risk]; ok && branch != "" {
+			return rev, full, nil
+		}
+	}
+	return snap.Revision{}, full, &store.RevisionNotAvailableError{Channel: ch}
+}
+
+func (idx *index) info(ms *mirrorSnap, rev snap.Revision, ch string) (*snap.Info, error) {
+	rf := ms.revisions[rev]
+	if rf == nil {
+		return nil, &store.RevisionNotAvailableError{Channel: ch}
+	}
+	si := &snap.SideInfo{
+		RealName: ms.name,
+		SnapID:   ms.snapID,
+		Revision: rev,
+		Channel:  ch,
+	}
+	info, err := readSnapInfo(rf.path, si)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read snap %s in store mirror: %v", rf.path, err)
+	}
+	// download infos carry the hex digest, like the ones of the store
+	digest, err := base64.RawURLEncoding.DecodeString(rf.sha3_384)
+	if err != nil {
+		return nil, fmt.Errorf("internal error: cannot decode digest of %s: %v", rf.path, err)
+	}
+	info.Publisher = ms.publisher
+	info.DownloadInfo = snap.DownloadInfo{
+		DownloadURL: rf.path,
+		Size:        int64(rf.size),
+		Sha3_384:    hex.EncodeToString(digest),
+	}
+	return info, nil
+}
+
+func (s *Store) EnsureDeviceSession() error {
+	return nil
+}
+
+func (s *Store) SnapInfo(ctx context.Context, spec store.SnapSpec, user *auth.UserState) (*snap.Info, error) {
+	idx, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	ms := idx.byName[spec.Name]
+	if ms == nil {
+		return nil, store.ErrSnapNotFound
+	}
+	rev, ch, err := ms.resolve("")
+	if err != nil {
+		return nil, store.ErrSnapNotFound
+	}
+	return idx.info(ms, rev, ch)
+}
+
+func (s *Store) SnapExists(ctx context.Context, spec store.SnapSpec, user *auth.UserState) (naming.SnapRef, *channel.Channel, error) {
+	info, err := s.SnapInfo(ctx, spec, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	ch, err := channel.Parse(info.Channel, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	ch = ch.Clean()
+	return naming.NewSnapRef(info.SnapName(), info.SnapID), &ch, nil
+}
+
+func (s *Store) Find(ctx context.Context, search *store.Search, user *auth.UserState) ([]*snap.Info, error) {
+	idx, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	query := strings.TrimSpace(search.Query)
+	names := make([]string, 0, len(idx.byName))
+	for name := range idx.byName {
+		if search.Prefix && !strings.HasPrefix(name, query) {
+			continue
+		}
+		if !search.Prefix && !strings.Contains(name, query) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var infos []*snap.Info
+	for _, name := range names {
+		ms := idx.byName[name]
+		rev, ch, err := ms.resolve("")
+		if err != nil {
+			continue
+		}
+		info, err := idx.info(ms, rev, ch)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// SnapAction resolves install, download and refresh actions against the
+// channels of the snaps in the mirror, and updates the assertions of the
+// given AssertionQuery from the assertions in the mirror.
+func (s *Store) SnapAction(ctx context.Context, currentSnaps []*store.CurrentSnap, actions []*store.SnapAction, assertQuery store.AssertionQuery, user *auth.UserState, opts *store.RefreshOptions) ([]store.SnapActionResult, []store.AssertionResult, error) {
+	idx, err := s.load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var aresults []store.AssertionResult
+	if assertQuery != nil {
+		aresults, err = idx.resolveAssertions(assertQuery)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	curSnaps := make(map[string]*store.CurrentSnap, len(currentSnaps))
+	for _, cur := range currentSnaps {
+		curSnaps[cur.InstanceName] = cur
+	}
+
+	var results []store.SnapActionResult
+	saErr := &store.SnapActionError{}
+	for _, a := range actions {
+		var errs *map[string]error
+		var ms *mirrorSnap
+		var cur *store.CurrentSnap
+		switch a.Action {
+		case "refresh":
+			errs = &saErr.Refresh
+			cur = curSnaps[a.InstanceName]
+			if cur == nil {
+				return nil, nil, fmt.Errorf("internal error: no current snap for %q", a.InstanceName)
+			}
+			ms = idx.bySnapID[a.SnapID]
+		case "install", "download":
+			if a.Action == "install" {
+				errs = &saErr.Install
+			} else {
+				errs = &saErr.Download
+			}
+			ms = idx.byName[snap.InstanceSnap(a.InstanceName)]
+		default:
+			return nil, nil, fmt.Errorf("internal error: unsupported action %q", a.Action)
+		}
+		addErr := func(err error) {
+			if *errs == nil {
+				*errs = make(map[string]error)
+			}
+			(*errs)[a.InstanceName] = err
+		}
+		if ms == nil {
+			addErr(store.ErrSnapNotFound)
+			continue
+		}
+
+		ch := a.Channel
+		if ch == "" && cur != nil {
+			ch = cur.TrackingChannel
+		}
+		rev := a.Revision
+		fullCh, err := channel.Full(ch)
+		if err != nil {
+			addErr(err)
+			continue
+		}
+		if rev.Unset() {
+			rev, fullCh, err = ms.resolve(ch)
+			if err != nil {
+				if rnaErr, ok := err.(*store.RevisionNotAvailableError); ok {
+					rnaErr.Action = a.Action
+				}
+				addErr(err)
+				continue
+			}
+		}
+		if cur != nil && cur.Revision == rev {
+			addErr(store.ErrNoUpdateAvailable)
+			continue
+		}
+		info, err := idx.info(ms, rev, fullCh)
+		if err != nil {
+			if rnaErr, ok := err.(*store.RevisionNotAvailableError); ok {
+				rnaErr.Action = a.Action
+			}
+			addErr(err)
+			continue
+		}
+		_, info.InstanceKey = snap.SplitInstanceName(a.InstanceName)
+		results = append(results, store.SnapActionResult{Info: info})
+	}
+
+	if len(saErr.Refresh) != 0 || len(saErr.Install) != 0 || len(saErr.Download) != 0 {
+		saErr.NoResults = len(results) == 0 && len(aresults) == 0
+		return results, aresults, saErr
+	}
+	if len(actions) != 0 && len(results) == 0 && len(aresults) == 0 {
+		return nil, nil, &store.SnapActionError{NoResults: true}
+	}
+	return results, aresults, nil
+}
+
+// assertionStreamPrefix prefixes the stream "URLs" of the assertions in the
+// mirror returned by SnapAction, that DownloadAssertions understands.
+const assertionStreamPrefix = "mirror:"
+
+func assertionStreamURL(ref *asserts.Ref) string {
+	parts := make([]string, 0, 1+len(ref.PrimaryKey))
+	parts = append(parts, ref.Type.Name)
+	for _, k := range ref.PrimaryKey {
+		parts = append(parts, url.PathEscape(k))
+	}
+	return assertionStreamPrefix + strings.Join(parts, "/")
+}
+
+func (idx *index) resolveAssertions(assertQuery store.AssertionQuery) ([]store.AssertionResult, error) {
+	toResolve, toResolveSeq, err := assertQuery.ToResolve()
+	if err != nil {
+		return nil, err
+	}
+
+	streamURLs := make(map[asserts.Grouping][]string)
+	for grouping, atRevs := range toResolve {
+		for _, at := range atRevs {
+			a, err := idx.bs.Get(at.Type, at.PrimaryKey, at.Type.MaxSupportedFormat())
+			if err != nil {
+				if err := assertQuery.AddError(err, &at.Ref); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if a.Revision() > at.Revision {
+				streamURLs[grouping] = append(streamURLs[grouping], assertionStreamURL(a.Ref()))
+			}
+		}
+	}
+	for grouping, atSeqs := range toResolveSeq {
+		for _, at := range atSeqs {
+			var a asserts.Assertion
+			var err error
+			if at.Pinned {
+				key := append(append([]string(nil), at.SequenceKey...), fmt.Sprint(at.Sequence))
+				a, err = idx.bs.Get(at.Type, key, at.Type.MaxSupportedFormat())
+			} else {
+				a, err = idx.bs.SequenceMemberAfter(at.Type, at.SequenceKey, -1, at.Type.MaxSupportedFormat())
+			}
+			if err != nil {
+				if err := assertQuery.AddSequenceError(err, at); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			seq := a.(asserts.SequenceMember).Sequence()
+			if seq > at.Sequence || (seq == at.Sequence && a.Revision() > at.Revision) {
+				streamURLs[grouping] = append(streamURLs[grouping], assertionStreamURL(a.Ref()))
+			}
+		}
+	}
+
+	aresults := make([]store.AssertionResult, 0, len(streamURLs))
+	for grouping, urls := range streamURLs {
+		aresults = append(aresults, store.AssertionResult{
+			Grouping:   grouping,
+			StreamURLs: urls,
+		})
+	}
+	sort.Slice(aresults, func(i, j int) bool { return aresults[i].Grouping < aresults[j].Grouping })
+	return aresults, nil
+}
+
+// DownloadAssertions adds the assertions of the stream "URLs" returned by
+// SnapAction to the batch.
+func (s *Store) DownloadAssertions(streamURLs []string, b *asserts.Batch, user *auth.UserState) error {
+	idx, err := s.load()
+	if err != nil {
+		return err
+	}
+	for _, u := range streamURLs {
+		if !strings.HasPrefix(u, assertionStreamPrefix) {
+			return fmt.Errorf("cannot download assertions from %q: not in the store mirror", u)
+		}
+		parts := strings.Split(strings.TrimPrefix(u, assertionStreamPrefix), "/")
+		assertType := asserts.Type(parts[0])
+		if assertType == nil {
+			return fmt.Errorf("cannot download assertions from %q: unknown assertion type", u)
+		}
+		key := make([]string, len(parts)-1)
+		for i, k := range parts[1:] {
+			if key[i], err = url.PathUnescape(k); err != nil {
+				return fmt.Errorf("cannot download assertions from %q: %v", u, err)
+			}
+		}
+		a, err := idx.bs.Get(assertType, key, assertType.MaxSupportedFormat())
+		if err != nil {
+			return err
+		}
+		if err := b.Add(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) Assertion(assertType *asserts.AssertionType, primaryKey []string, user *auth.UserState) (asserts.Assertion, error) {
+	idx, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return idx.bs.Get(assertType, asserts.ReducePrimaryKey(assertType, primaryKey), assertType.MaxSupportedFormat())
+}
+
+func (s *Store) SeqFormingAssertion(assertType *asserts.AssertionType, sequenceKey []string, sequence int, user *auth.UserState) (asserts.Assertion, error) {
+	if !assertType.SequenceForming() {
+		return nil, fmt.Errorf("internal error: requested non sequence-forming assertion type %q", assertType.Name)
+	}
+	idx, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if sequence > 0 {
+		key := append(append([]string(nil), sequenceKey...), fmt.Sprint(sequence))
+		return idx.bs.Get(assertType, key, assertType.MaxSupportedFormat())
+	}
+	return idx.bs.SequenceMemberAfter(assertType, sequenceKey, -1, assertType.MaxSupportedFormat())
+}
+
+// Download copies the snap file from the mirror.
+func (s *Store) Download(ctx context.Context, name, targetPath string, downloadInfo *snap.DownloadInfo, pbar progress.Meter, user *auth.UserState, dlOpts *store.DownloadOptions) error {
+	if pbar == nil {
+		pbar = progress.Null
+	}
+	src, err := s.open(downloadInfo)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return err
+	}
+	partialPath := targetPath + ".partial"
+	dst, err := os.OpenFile(partialPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	h := crypto.SHA3_384.New()
+	pbar.Start(name, float64(downloadInfo.Size))
+	_, err = io.Copy(io.MultiWriter(dst, h, pbar), src)
+	pbar.Finished()
+	if err == nil {
+		// the file could have changed since it was matched with its
+		// snap-revision assertion
+		if actual := fmt.Sprintf("%x", h.Sum(nil)); actual != downloadInfo.Sha3_384 {
+			err = fmt.Errorf("sha3-384 mismatch for %q in store mirror: got %s but expected %s", name, actual, downloadInfo.Sha3_384)
+		}
+	}
+	if err == nil {
+		err = dst.Sync()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(partialPath)
+		return err
+	}
+	return os.Rename(partialPath, targetPath)
+}
+
+// open opens the snap file of the download info, which needs to be in the
+// mirror.
+func (s *Store) open(downloadInfo *snap.DownloadInfo) (*os.File, error) {
+	path := filepath.Clean(downloadInfo.DownloadURL)
+	if filepath.Dir(path) != filepath.Clean(s.dir) {
+		return nil, fmt.Errorf("cannot download %q: not in the store mirror", downloadInfo.DownloadURL)
+	}
+	return os.Open(path)
+}
+
+func (s *Store) DownloadStream(ctx context.Context, name string, downloadInfo *snap.DownloadInfo, resume int64, user *auth.UserState) (io.ReadCloser, int, error) {
+	f, err := s.open(downloadInfo)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resume == 0 {
+		return f, 200, nil
+	}
+	if _, err := f.Seek(resume, io.SeekStart); err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, 206, nil
+}
+
+func (s *Store) DownloadIcon(ctx context.Context, name, targetPath, downloadURL string) error {
+	return ErrUnsupported
+}
+
+func (s *Store) Sections(ctx context.Context, user *auth.UserState) ([]string, error) {
+	return nil, nil
+}
+
+func (s *Store) Categories(ctx context.Context, user *auth.UserState) ([]store.CategoryDetails, error) {
+	return nil, nil
+}
+
+// WriteCatalogs writes the names of the snaps in the mirror.
+func (s *Store) WriteCatalogs(ctx context.Context, names io.Writer, adder store.SnapAdder) error {
+	idx, err := s.load()
+	if err != nil {
+		return err
+	}
+	sorted := make([]string, 0, len(idx.byName))
+	for name := range idx.byName {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		if _, err := fmt.Fprintln(names, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) SuggestedCurrency() string {
+	return ""
+}
+
+func (s *Store) Buy(options *client.BuyOptions, user *auth.UserState) (*client.BuyResult, error) {
+	return nil, ErrUnsupported
+}
+
+func (s *Store) ReadyToBuy(user *auth.UserState) error {
+	return ErrUnsupported
+}
+
+// ConnectivityCheck checks that the mirror directory is available.
+func (s *Store) ConnectivityCheck() (map[string]bool, error) {
+	return map[string]bool{s.dir: osutil.IsDirectory(s.dir)}, nil
+}
+
+func (s *Store) CreateCohorts(ctx context.Context, snaps []string) (map[string]string, error) {
+	return nil, ErrUnsupported
+}
+
+func (s *Store) LoginUser(username, password, otp string) (string, string, error) {
+	return "", "", ErrUnsupported
+}
+
+func (s *Store) UserInfo(email string) (*store.User, error) {
+	return nil, ErrUnsupported
+}
+
+// CleanDownloadsCache does nothing, as the mirror has no downloads cache.
+func (s *Store) CleanDownloadsCache() error {
+	return nil
+}
+
+// PruneDownloadsCache does nothing, as the mirror has no downloads cache.
+func (s *Store) PruneDownloadsCache(target uint64) (uint64, error) {
+	return 0, nil
+}
+
+func (s *Store) ExchangeMessages(ctx context.Context, req *store.MessageExchangeRequest) (*store.MessageExchangeResponse, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,468 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package mirror_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/progress/progresstest"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/store/mirror"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type mirrorSuite struct {
+	testutil.BaseTest
+
+	dir          string
+	storeSigning *assertstest.StoreStack
+	devAcct      *asserts.Account
+	sto          *mirror.Store
+}
+
+var _ = Suite(&mirrorSuite{})
+
+func (s *mirrorSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.dir = c.MkDir()
+	s.storeSigning = assertstest.NewStoreStack("can0nical", nil)
+	s.devAcct = assertstest.NewAccount(s.storeSigning, "developer1", map[string]any{
+		"account-id": "developer1-id",
+	}, "")
+	s.sto = mirror.New(s.dir)
+
+	s.AddCleanup(snap.MockSanitizePlugsSlots(func(snapInfo *snap.Info) {}))
+	s.AddCleanup(mirror.MockReadSnapInfo(func(snapPath string, si *snap.SideInfo) (*snap.Info, error) {
+		// the fake snap files hold their version and name
+		data, err := os.ReadFile(snapPath)
+		if err != nil {
+			return nil, err
+		}
+		version := strings.Fields(string(data))[0]
+		info, err := snap.InfoFromSnapYaml([]byte(fmt.Sprintf("name: %s\nversion: %s\n", si.RealName, version)))
+		if err != nil {
+			return nil, err
+		}
+		info.SideInfo = *si
+		return info, nil
+	}))
+}
+
+func (s *mirrorSuite) writeAssertions(c *C, name string, as ...asserts.Assertion) {
+	var buf bytes.Buffer
+	enc := asserts.NewEncoder(&buf)
+	for _, a := range as {
+		c.Assert(enc.Encode(a), IsNil)
+	}
+	c.Assert(os.WriteFile(filepath.Join(s.dir, name), buf.Bytes(), 0644), IsNil)
+}
+
+func (s *mirrorSuite) snapDecl(c *C, name string) *asserts.SnapDeclaration {
+	decl, err := s.storeSigning.Sign(asserts.SnapDeclarationType, map[string]any{
+		"series":       "16",
+		"snap-id":      name + "-id",
+		"snap-name":    name,
+		"publisher-id": s.devAcct.AccountID(),
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	return decl.(*asserts.SnapDeclaration)
+}
+
+// addSnap adds a snap file with the given revision to the mirror, with its
+// snap-revision assertion.
+func (s *mirrorSuite) addSnap(c *C, name string, rev int) string {
+	fn := filepath.Join(s.dir, fmt.Sprintf("%s_%d.snap", name, rev))
+	c.Assert(os.WriteFile(fn, []byte(fmt.Sprintf("%d.0 %s", rev, name)), 0644), IsNil)
+	digest, size, err := asserts.SnapFileSHA3_384(fn)
+	c.Assert(err, IsNil)
+	snapRev, err := s.storeSigning.Sign(asserts.SnapRevisionType, map[string]any{
+		"snap-id":       name + "-id",
+		"snap-sha3-384": digest,
+		"snap-size":     fmt.Sprint(size),
+		"snap-revision": fmt.Sprint(rev),
+		"developer-id":  s.devAcct.AccountID(),
+		"timestamp":     time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	s.writeAssertions(c, fmt.Sprintf("%s_%d.assert", name, rev), snapRev)
+	return fn
+}
+
+func (s *mirrorSuite) mockMirror(c *C) {
+	s.writeAssertions(c, "decls.assert", s.devAcct, s.snapDecl(c, "foo"), s.snapDecl(c, "bar"))
+	s.addSnap(c, "foo", 1)
+	s.addSnap(c, "foo", 2)
+	s.addSnap(c, "foo", 3)
+	s.addSnap(c, "bar", 5)
+	c.Assert(os.WriteFile(filepath.Join(s.dir, "channels.json"), []byte(`{
+  "foo": {"stable": 1, "latest/edge": 2, "2.0/stable": 3}
+}`), 0644), IsNil)
+}
+
+func (s *mirrorSuite) TestSnapActionInstall(c *C) {
+	s.mockMirror(c)
+
+	results, _, err := s.sto.SnapAction(context.Background(), nil, []*store.SnapAction{
+		{Action: "install", InstanceName: "foo"},
+		{Action: "install", InstanceName: "foo_instance", Channel: "edge"},
+		{Action: "download", InstanceName: "bar", Channel: "latest/beta"},
+	}, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(results, HasLen, 3)
+
+	foo := results[0].Info
+	c.Check(foo.SnapName(), Equals, "foo")
+	c.Check(foo.SnapID, Equals, "foo-id")
+	c.Check(foo.Revision, Equals, snap.R(1))
+	c.Check(foo.Version, Equals, "1.0")
+	c.Check(foo.Channel, Equals, "latest/stable")
+	c.Check(foo.InstanceKey, Equals, "")
+	c.Check(foo.Publisher, DeepEquals, snap.StoreAccount{
+		ID:       "developer1-id",
+		Username: "developer1",
+		// as set by assertstest
+		DisplayName: "Developer1",
+		Validation:  "unproven",
+	})
+	c.Check(foo.DownloadURL, Equals, filepath.Join(s.dir, "foo_1.snap"))
+	c.Check(foo.Size, Equals, int64(len("1.0 foo")))
+	c.Check(foo.Sha3_384, Matches, "[0-9a-f]{96}")
+
+	c.Check(results[1].Revision, Equals, snap.R(2))
+	c.Check(results[1].Channel, Equals, "latest/edge")
+	c.Check(results[1].InstanceKey, Equals, "instance")
+
+	// bar is not in channels.json, its highest revision is everywhere
+	c.Check(results[2].SnapName(), Equals, "bar")
+	c.Check(results[2].Revision, Equals, snap.R(5))
+	c.Check(results[2].Channel, Equals, "latest/beta")
+}
+
+func (s *mirrorSuite) TestSnapActionInstallClosedChannels(c *C) {
+	s.mockMirror(c)
+
+	for _, t := range []struct {
+		channel string
+		rev     snap.Revision
+	}{
+		// candidate and beta are closed, they follow stable
+		{"candidate", snap.R(1)},
+		{"latest/beta", snap.R(1)},
+		{"edge", snap.R(2)},
+		{"2.0/edge", snap.R(3)},
+		// branches follow their channel
+		{"latest/stable/hotfix", snap.R(1)},
+	} {
+		results, _, err := s.sto.SnapAction(context.Background(), nil, []*store.SnapAction{
+			{Action: "install", InstanceName: "foo", Channel: t.channel},
+		}, nil, nil, nil)
+		c.Assert(err, IsNil, Commentf(t.channel))
+		c.Assert(results, HasLen, 1)
+		c.Check(results[0].Revision, Equals, t.rev, Commentf(t.channel))
+	}
+
+	_, _, err := s.sto.SnapAction(context.Background(), nil, []*store.SnapAction{
+		{Action: "install", InstanceName: "foo", Channel: "3.0/stable"},
+	}, nil, nil, nil)
+	c.Assert(err, FitsTypeOf, &store.SnapActionError{})
+	saErr := err.(*store.SnapActionError)
+	c.Check(saErr.NoResults, Equals, true)
+	c.Check(saErr.Install["foo"], DeepEquals, &store.RevisionNotAvailableError{
+		Action:  "install",
+		Channel: "3.0/stable",
+	})
+}
+
+func (s *mirrorSuite) TestSnapActionInstallNotFound(c *C) {
+	s.mockMirror(c)
+
+	results, _, err := s.sto.SnapAction(context.Background(), nil, []*store.SnapAction{
+		{Action: "install", InstanceName: "foo"},
+		{Action: "install", InstanceName: "baz"},
+	}, nil, nil, nil)
+	c.Assert(results, HasLen, 1)
+	c.Assert(err, FitsTypeOf, &store.SnapActionError{})
+	saErr := err.(*store.SnapActionError)
+	c.Check(saErr.NoResults, Equals, false)
+	c.Check(saErr.Install, DeepEquals, map[string]error{"baz": store.ErrSnapNotFound})
+}
+
+func (s *mirrorSuite) TestSnapActionRefresh(c *C) {
+	s.mockMirror(c)
+
+	current := []*store.CurrentSnap{
+		{InstanceName: "foo", SnapID: "foo-id", Revision: snap.R(1), TrackingChannel: "latest/edge"},
+		{InstanceName: "bar", SnapID: "bar-id", Revision: snap.R(5), TrackingChannel: "latest/stable"},
+	}
+	results, _, err := s.sto.SnapAction(context.Background(), current, []*store.SnapAction{
+		{Action: "refresh", InstanceName: "foo", SnapID: "foo-id"},
+		{Action: "refresh", InstanceName: "bar", SnapID: "bar-id"},
+	}, nil, nil, nil)
+	c.Assert(results, HasLen, 1)
+	c.Check(results[0].SnapName(), Equals, "foo")
+	c.Check(results[0].Revision, Equals, snap.R(2))
+	c.Check(results[0].Channel, Equals, "latest/edge")
+	c.Assert(err, FitsTypeOf, &store.SnapActionError{})
+	c.Check(err.(*store.SnapActionError).Refresh, DeepEquals, map[string]error{
+		"bar": store.ErrNoUpdateAvailable,
+	})
+
+	// switching channel
+	results, _, err = s.sto.SnapAction(context.Background(), current, []*store.SnapAction{
+		{Action: "refresh", InstanceName: "foo", SnapID: "foo-id", Channel: "2.0"},
+	}, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(results, HasLen, 1)
+	c.Check(results[0].Revision, Equals, snap.R(3))
+	c.Check(results[0].Channel, Equals, "2.0/stable")
+
+	// to a given revision
+	results, _, err = s.sto.SnapAction(context.Background(), current, []*store.SnapAction{
+		{Action: "refresh", InstanceName: "foo", SnapID: "foo-id", Revision: snap.R(3)},
+	}, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(results, HasLen, 1)
+	c.Check(results[0].Revision, Equals, snap.R(3))
+}
+
+func (s *mirrorSuite) TestIgnoresSnapsWithoutAssertions(c *C) {
+	s.mockMirror(c)
+	c.Assert(os.WriteFile(filepath.Join(s.dir, "unknown.snap"), []byte("9.0"), 0644), IsNil)
+	// no snap-declaration for baz
+	s.addSnap(c, "baz", 1)
+
+	infos, err := s.sto.Find(context.Background(), &store.Search{}, nil)
+	c.Assert(err, IsNil)
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.SnapName())
+	}
+	c.Check(names, DeepEquals, []string{"bar", "foo"})
+}
+
+func (s *mirrorSuite) TestSnapInfo(c *C) {
+	s.mockMirror(c)
+
+	info, err := s.sto.SnapInfo(context.Background(), store.SnapSpec{Name: "foo"}, nil)
+	c.Assert(err, IsNil)
+	c.Check(info.Revision, Equals, snap.R(1))
+
+	_, err = s.sto.SnapInfo(context.Background(), store.SnapSpec{Name: "baz"}, nil)
+	c.Check(err, Equals, store.ErrSnapNotFound)
+
+	ref, ch, err := s.sto.SnapExists(context.Background(), store.SnapSpec{Name: "foo"}, nil)
+	c.Assert(err, IsNil)
+	c.Check(ref.ID(), Equals, "foo-id")
+	c.Check(ch.Name, Equals, "stable")
+}
+
+func (s *mirrorSuite) TestNotADirectory(c *C) {
+	sto := mirror.New(filepath.Join(s.dir, "missing"))
+	_, _, err := sto.SnapAction(context.Background(), nil, []*store.SnapAction{
+		{Action: "install", InstanceName: "foo"},
+	}, nil, nil, nil)
+	c.Check(err, ErrorMatches, `cannot use store mirror: ".*/missing" is not a directory`)
+}
+
+func (s *mirrorSuite) TestDownload(c *C) {
+	s.mockMirror(c)
+	results, _, err := s.sto.SnapAction(context.Background(), nil, []*store.SnapAction{
+		{Action: "download", InstanceName: "foo"},
+	}, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(results, HasLen, 1)
+
+	target := filepath.Join(c.MkDir(), "blobs", "foo_1.snap")
+	pbar := &progresstest.Meter{}
+	err = s.sto.Download(context.Background(), "foo", target, &results[0].DownloadInfo, pbar, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(target, testutil.FileEquals, "1.0 foo")
+	c.Check(target+".partial", testutil.FileAbsent)
+	c.Check(pbar.Labels, DeepEquals, []string{"foo"})
+	c.Check(pbar.Finishes, Equals, 1)
+
+	stream, status, err := s.sto.DownloadStream(context.Background(), "foo", &results[0].DownloadInfo, 2, nil)
+	c.Assert(err, IsNil)
+	defer stream.Close()
+	c.Check(status, Equals, 206)
+	data, err := io.ReadAll(stream)
+	c.Assert(err, IsNil)
+	c.Check(string(data), Equals, "0 foo")
+
+	// only files in the mirror can be downloaded
+	err = s.sto.Download(context.Background(), "foo", target, &snap.DownloadInfo{
+		DownloadURL: "/etc/passwd",
+	}, nil, nil, nil)
+	c.Check(err, ErrorMatches, `cannot download "/etc/passwd": not in the store mirror`)
+}
+
+func (s *mirrorSuite) TestDownloadDigestMismatch(c *C) {
+	s.mockMirror(c)
+	results, _, err := s.sto.SnapAction(context.Background(), nil, []*store.SnapAction{
+		{Action: "download", InstanceName: "foo"},
+	}, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(results, HasLen, 1)
+
+	// the file changed after it was matched with its assertion
+	c.Assert(os.WriteFile(filepath.Join(s.dir, "foo_1.snap"), []byte("1.0 evil"), 0644), IsNil)
+
+	target := filepath.Join(c.MkDir(), "foo_1.snap")
+	err = s.sto.Download(context.Background(), "foo", target, &results[0].DownloadInfo, nil, nil, nil)
+	c.Check(err, ErrorMatches, `sha3-384 mismatch for "foo" in store mirror: got [0-9a-f]+ but expected [0-9a-f]+`)
+	c.Check(target, testutil.FileAbsent)
+	c.Check(target+".partial", testutil.FileAbsent)
+}
+
+func (s *mirrorSuite) TestIndexCachedUntilDirectoryChanges(c *C) {
+	s.mockMirror(c)
+	past := time.Now().Add(-time.Hour)
+	c.Assert(os.Chtimes(s.dir, past, past), IsNil)
+
+	_, err := s.sto.SnapInfo(context.Background(), store.SnapSpec{Name: "foo"}, nil)
+	c.Assert(err, IsNil)
+
+	s.writeAssertions(c, "baz.assert", s.snapDecl(c, "baz"))
+	s.addSnap(c, "baz", 1)
+	c.Assert(os.Chtimes(s.dir, past, past), IsNil)
+
+	// the directory looks unchanged
+	_, err = s.sto.SnapInfo(context.Background(), store.SnapSpec{Name: "baz"}, nil)
+	c.Check(err, Equals, store.ErrSnapNotFound)
+
+	later := past.Add(time.Minute)
+	c.Assert(os.Chtimes(s.dir, later, later), IsNil)
+	info, err := s.sto.SnapInfo(context.Background(), store.SnapSpec{Name: "baz"}, nil)
+	c.Assert(err, IsNil)
+	c.Check(info.Revision, Equals, snap.R(1))
+}
+
+func (s *mirrorSuite) TestIndexNotCachedWhileDirectoryChanges(c *C) {
+	s.AddCleanup(mirror.MockRacyModTimeWindow(time.Hour))
+	s.mockMirror(c)
+	past := time.Now().Add(-time.Minute)
+	c.Assert(os.Chtimes(s.dir, past, past), IsNil)
+
+	_, err := s.sto.SnapInfo(context.Background(), store.SnapSpec{Name: "foo"}, nil)
+	c.Assert(err, IsNil)
+
+	s.writeAssertions(c, "baz.assert", s.snapDecl(c, "baz"))
+	s.addSnap(c, "baz", 1)
+	c.Assert(os.Chtimes(s.dir, past, past), IsNil)
+
+	// changed too recently to be trusted
+	info, err := s.sto.SnapInfo(context.Background(), store.SnapSpec{Name: "baz"}, nil)
+	c.Assert(err, IsNil)
+	c.Check(info.Revision, Equals, snap.R(1))
+}
+
+func (s *mirrorSuite) TestAssertion(c *C) {
+	s.mockMirror(c)
+
+	a, err := s.sto.Assertion(asserts.SnapDeclarationType, []string{"16", "foo-id"}, nil)
+	c.Assert(err, IsNil)
+	c.Check(a.(*asserts.SnapDeclaration).SnapName(), Equals, "foo")
+
+	_, err = s.sto.Assertion(asserts.SnapDeclarationType, []string{"16", "baz-id"}, nil)
+	c.Check(err, testutil.ErrorIs, &asserts.NotFoundError{})
+}
+
+type fakeAssertQuery struct {
+	toResolve map[asserts.Grouping][]*asserts.AtRevision
+	errs      []string
+}
+
+func (q *fakeAssertQuery) ToResolve() (map[asserts.Grouping][]*asserts.AtRevision, map[asserts.Grouping][]*asserts.AtSequence, error) {
+	return q.toResolve, nil, nil
+}
+
+func (q *fakeAssertQuery) AddError(e error, ref *asserts.Ref) error {
+	q.errs = append(q.errs, fmt.Sprintf("%s: %v", ref, e))
+	return nil
+}
+
+func (q *fakeAssertQuery) AddSequenceError(e error, atSeq *asserts.AtSequence) error {
+	return nil
+}
+
+func (q *fakeAssertQuery) AddGroupingError(e error, grouping asserts.Grouping) error {
+	return nil
+}
+
+func (s *mirrorSuite) TestSnapActionAssertionQuery(c *C) {
+	s.mockMirror(c)
+
+	query := &fakeAssertQuery{
+		toResolve: map[asserts.Grouping][]*asserts.AtRevision{
+			"g1": {
+				{Ref: asserts.Ref{Type: asserts.SnapDeclarationType, PrimaryKey: []string{"16", "foo-id"}}, Revision: asserts.RevisionNotKnown},
+				// up to date
+				{Ref: asserts.Ref{Type: asserts.SnapDeclarationType, PrimaryKey: []string{"16", "bar-id"}}, Revision: 0},
+			},
+			"g2": {
+				{Ref: asserts.Ref{Type: asserts.SnapDeclarationType, PrimaryKey: []string{"16", "baz-id"}}, Revision: 0},
+			},
+		},
+	}
+	results, aresults, err := s.sto.SnapAction(context.Background(), nil, nil, query, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(results, HasLen, 0)
+	c.Check(aresults, DeepEquals, []store.AssertionResult{{
+		Grouping:   "g1",
+		StreamURLs: []string{"mirror:snap-declaration/16/foo-id"},
+	}})
+	c.Assert(query.errs, HasLen, 1)
+	c.Check(strings.HasPrefix(query.errs[0], "snap-declaration (baz-id; series:16): "), Equals, true, Commentf(query.errs[0]))
+
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore: asserts.NewMemoryBackstore(),
+		Trusted:   s.storeSigning.Trusted,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(db.Add(s.storeSigning.StoreAccountKey("")), IsNil)
+	c.Assert(db.Add(s.devAcct), IsNil)
+
+	b := asserts.NewBatch(nil)
+	c.Assert(s.sto.DownloadAssertions(aresults[0].StreamURLs, b, nil), IsNil)
+	var committed []string
+	c.Assert(b.CommitToAndObserve(db, func(a asserts.Assertion) {
+		committed = append(committed, a.Ref().Unique())
+	}, nil), IsNil)
+	c.Check(committed, DeepEquals, []string{"snap-declaration/16/foo-id"})
+
+	err = s.sto.DownloadAssertions([]string{"https://example.com/assertions"}, b, nil)
+	c.Check(err, ErrorMatches, `cannot download assertions from "https://example.com/assertions": not in the store mirror`)
+}
@@ -0,0 +1,189 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package p2p
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	_ "golang.org/x/crypto/sha3"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/progress"
+)
+
+// ErrNoPeer is returned when no peer has the blob.
+var ErrNoPeer = errors.New("no peer has the blob")
+
+// Client finds peers that have a blob and fetches it from them.
+type Client struct {
+	cfg        *Config
+	httpClient *http.Client
+}
+
+// NewClient returns a new Client.
+func NewClient(cfg *Config) *Client {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	return &Client{
+		cfg: cfg,
+		// peers are on the local network, a peer that is too slow to
+		// answer is given up in favour of the store
+		httpClient: &http.Client{Transport: &http.Transport{
+			DialContext:           (&net.Dialer{Timeout: 5 * time.Second}).DialContext,
+			ResponseHeaderTimeout: 10 * time.Second,
+		}},
+	}
+}
+
+// Lookup queries the local network for peers that have the blob with the
+// given sha3-384 digest, and returns the URL the first peer to answer serves
+// it from. ErrNoPeer is returned if no peer answers in time.
+func (c *Client) Lookup(ctx context.Context, sha3_384 string) (string, error) {
+	if !validDigest(sha3_384) {
+		return "", fmt.Errorf("invalid sha3-384 digest %q", sha3_384)
+	}
+	group, err := groupAddr(c.cfg)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve peer discovery address: %v", err)
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot query peers: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteToUDP(marshalDatagram(&query{Sha3_384: sha3_384}), group); err != nil {
+		return "", fmt.Errorf("cannot query peers: %v", err)
+	}
+
+	deadline := time.Now().Add(c.cfg.LookupTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return "", ErrNoPeer
+			}
+			return "", fmt.Errorf("cannot read peer reply: %v", err)
+		}
+		var rep reply
+		if err := json.Unmarshal(buf[:n], &rep); err != nil || rep.Sha3_384 != sha3_384 || rep.Port <= 0 || rep.Port > 65535 {
+			logger.Debugf("ignoring invalid peer reply from %s", from)
+			continue
+		}
+		return fmt.Sprintf("http://%s%s%s", net.JoinHostPort(from.IP.String(), strconv.Itoa(rep.Port)), blobsPath, sha3_384), nil
+	}
+}
+
+// Fetch fetches the blob with the given sha3-384 digest and size from the
+// first peer that answers for it, into targetPath. ErrNoPeer is returned if
+// no peer could provide it.
+func (c *Client) Fetch(ctx context.Context, name, sha3_384, targetPath string, size int64, pbar progress.Meter) error {
+	if size <= 0 {
+		return fmt.Errorf("invalid size %d of %s", size, name)
+	}
+	u, err := c.Lookup(ctx, sha3_384)
+	if err != nil {
+		return err
+	}
+	if pbar == nil {
+		pbar = progress.Null
+	}
+	if err := c.fetchFrom(ctx, name, u, sha3_384, targetPath, size, pbar); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logger.Noticef("cannot fetch %s from peer: %v", name, err)
+		return ErrNoPeer
+	}
+	logger.Debugf("fetched %s from peer %s", name, u)
+	return nil
+}
+
+func (c *Client) fetchFrom(ctx context.Context, name, url, sha3_384, targetPath string, size int64, pbar progress.Meter) (err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	if resp.ContentLength >= 0 && resp.ContentLength != size {
+		return fmt.Errorf("unexpected size %d from %s, expected %d", resp.ContentLength, url, size)
+	}
+
+	partialPath := targetPath + ".peer"
+	f, err := os.OpenFile(partialPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(partialPath)
+		}
+	}()
+
+	h := crypto.SHA3_384.New()
+	pbar.Start(name, float64(size))
+	// do not let a peer fill the disk, reading one byte more than expected
+	// tells a blob that is too large
+	n, err := io.Copy(io.MultiWriter(f, h, pbar), io.LimitReader(resp.Body, size+1))
+	pbar.Finished()
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("unexpected size of blob from %s, expected %d", url, size)
+	}
+	if actual := fmt.Sprintf("%x", h.Sum(nil)); actual != sha3_384 {
+		return fmt.Errorf("sha3-384 mismatch: got %s but expected %s", actual, sha3_384)
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return os.Rename(partialPath, targetPath)
+}
@@ -0,0 +1,104 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package p2p shares the snap blobs in the download cache with peers on the
+// local network.
+//
+// A peer looking for a blob sends a query datagram with its sha3-384 digest
+// to the discovery group. The peers that have the blob in their download
+// cache reply with the port of the HTTP server they serve it from, at
+// /v1/blobs/<sha3-384>. Blobs fetched from peers are checked against their
+// digest, and like any other download, the snap-revision assertion of the
+// snap needs to be found and checked before it gets installed.
+//
+// Blobs are only shared on the configured network interfaces, with the
+// peers on their subnets, and only the blobs the owner of the server allows,
+// e.g. those of public snaps that are free. Peers are not trusted to check
+// that the snap can be downloaded, which is left to the store.
+package p2p
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"time"
+)
+
+const (
+	// DefaultGroupAddress is the multicast group and port peers are
+	// queried on.
+	DefaultGroupAddress = "239.255.76.67:7878"
+	// DefaultLookupTimeout is how long a reply to a query is waited for.
+	DefaultLookupTimeout = 2 * time.Second
+
+	blobsPath = "/v1/blobs/"
+	// the size of a sha3-384 digest in hex
+	digestLen = 2 * 48
+	// queries and replies are tiny, anything bigger is ignored
+	maxDatagramSize = 512
+)
+
+// Config holds the addresses used to find peers.
+type Config struct {
+	// GroupAddress is the UDP address queries are sent to, usually a
+	// multicast group.
+	GroupAddress string
+	// LookupTimeout is how long replies to a query are waited for.
+	LookupTimeout time.Duration
+	// Interfaces are the names of the network interfaces blobs are
+	// shared on.
+	Interfaces []string
+}
+
+// DefaultConfig returns the default configuration.
+func DefaultConfig() *Config {
+	return &Config{
+		GroupAddress:  DefaultGroupAddress,
+		LookupTimeout: DefaultLookupTimeout,
+	}
+}
+
+// query is sent to the discovery group by peers looking for a blob.
+type query struct {
+	Sha3_384 string `json:"sha3-384"`
+}
+
+// reply is sent back to the querying peer by the peers that have the blob.
+type reply struct {
+	Sha3_384 string `json:"sha3-384"`
+	Port     int    `json:"port"`
+}
+
+func validDigest(digest string) bool {
+	if len(digest) != digestLen {
+		return false
+	}
+	_, err := hex.DecodeString(digest)
+	return err == nil
+}
+
+func groupAddr(cfg *Config) (*net.UDPAddr, error) {
+	return net.ResolveUDPAddr("udp4", cfg.GroupAddress)
+}
+
+func marshalDatagram(v any) []byte {
+	// cannot fail for the simple types above
+	buf, _ := json.Marshal(v)
+	return buf
+}
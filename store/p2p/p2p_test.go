@@ -0,0 +1,265 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package p2p_test
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "golang.org/x/crypto/sha3"
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/progress/progresstest"
+	"github.com/snapcore/snapd/store/p2p"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type p2pSuite struct {
+	testutil.BaseTest
+
+	cacheDir  string
+	cfg       *p2p.Config
+	shareable map[string]bool
+}
+
+var _ = Suite(&p2pSuite{})
+
+func (s *p2pSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.cacheDir = c.MkDir()
+
+	// use a free port on the loopback interface instead of the multicast
+	// group
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	c.Assert(err, IsNil)
+	addr := conn.LocalAddr().String()
+	conn.Close()
+	s.cfg = &p2p.Config{
+		GroupAddress:  addr,
+		LookupTimeout: 200 * time.Millisecond,
+		Interfaces:    []string{"lo"},
+	}
+	s.shareable = make(map[string]bool)
+}
+
+func digestOf(data string) string {
+	h := crypto.SHA3_384.New()
+	h.Write([]byte(data))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func (s *p2pSuite) mockBlob(c *C, data string) string {
+	digest := digestOf(data)
+	c.Assert(os.WriteFile(filepath.Join(s.cacheDir, digest), []byte(data), 0600), IsNil)
+	s.shareable[digest] = true
+	return digest
+}
+
+func (s *p2pSuite) newServer() *p2p.Server {
+	return p2p.NewServer(s.cacheDir, s.cfg, func(sha3_384 string) bool {
+		return s.shareable[sha3_384]
+	})
+}
+
+func (s *p2pSuite) startServer(c *C) *p2p.Server {
+	srv := s.newServer()
+	c.Assert(srv.Start(), IsNil)
+	s.AddCleanup(func() { srv.Stop() })
+	return srv
+}
+
+func (s *p2pSuite) TestServeHTTP(c *C) {
+	digest := s.mockBlob(c, "blob data")
+	private := s.mockBlob(c, "private data")
+	s.shareable[private] = false
+	srv := s.startServer(c)
+
+	for _, t := range []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{"GET", "/v1/blobs/" + digest, 200, "blob data"},
+		{"GET", "/v1/blobs/" + digestOf("other"), 404, ""},
+		{"GET", "/v1/blobs/" + private, 404, ""},
+		{"GET", "/v1/blobs/../../etc/passwd", 404, ""},
+		{"GET", "/v1/blobs/" + strings.Repeat("x", 96), 404, ""},
+		{"GET", "/v2/snaps", 404, ""},
+		{"POST", "/v1/blobs/" + digest, 405, ""},
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(t.method, t.path, nil)
+		req.RemoteAddr = "127.0.0.1:1234"
+		srv.ServeHTTP(rec, req)
+		c.Check(rec.Code, Equals, t.status, Commentf("%s %s", t.method, t.path))
+		if t.body != "" {
+			c.Check(rec.Body.String(), Equals, t.body)
+		}
+	}
+}
+
+func (s *p2pSuite) TestServeHTTPOutsideOfSharedNetworks(c *C) {
+	digest := s.mockBlob(c, "blob data")
+	srv := s.startServer(c)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/v1/blobs/"+digest, nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	srv.ServeHTTP(rec, req)
+	c.Check(rec.Code, Equals, 403)
+}
+
+func (s *p2pSuite) TestStartNoInterfaces(c *C) {
+	s.cfg.Interfaces = nil
+	srv := s.newServer()
+	c.Check(srv.Start(), ErrorMatches, "no network interfaces to share snaps on")
+	c.Check(srv.Port(), Equals, 0)
+
+	s.cfg.Interfaces = []string{"not-an-iface"}
+	srv = s.newServer()
+	c.Check(srv.Start(), ErrorMatches, "cannot share snaps with peers: .*")
+	c.Check(srv.Port(), Equals, 0)
+}
+
+func (s *p2pSuite) TestStartStop(c *C) {
+	srv := s.newServer()
+	c.Check(srv.Port(), Equals, 0)
+	c.Assert(srv.Start(), IsNil)
+	c.Check(srv.Port(), Not(Equals), 0)
+	c.Check(srv.Start(), ErrorMatches, "internal error: peer server already started")
+	c.Assert(srv.Stop(), IsNil)
+	c.Check(srv.Port(), Equals, 0)
+	// stopping again is fine
+	c.Assert(srv.Stop(), IsNil)
+}
+
+func (s *p2pSuite) TestLookup(c *C) {
+	digest := s.mockBlob(c, "blob data")
+	private := s.mockBlob(c, "private data")
+	s.shareable[private] = false
+	srv := s.startServer(c)
+
+	cli := p2p.NewClient(s.cfg)
+	u, err := cli.Lookup(context.Background(), digest)
+	c.Assert(err, IsNil)
+	c.Check(u, Equals, fmt.Sprintf("http://127.0.0.1:%d/v1/blobs/%s", srv.Port(), digest))
+
+	// nobody has this one
+	_, err = cli.Lookup(context.Background(), digestOf("other"))
+	c.Check(err, Equals, p2p.ErrNoPeer)
+
+	// nor can share this one
+	_, err = cli.Lookup(context.Background(), private)
+	c.Check(err, Equals, p2p.ErrNoPeer)
+
+	_, err = cli.Lookup(context.Background(), "not-a-digest")
+	c.Check(err, ErrorMatches, `invalid sha3-384 digest "not-a-digest"`)
+}
+
+func (s *p2pSuite) TestLookupReturnsOnFirstReply(c *C) {
+	digest := s.mockBlob(c, "blob data")
+	s.startServer(c)
+
+	s.cfg.LookupTimeout = time.Minute
+	cli := p2p.NewClient(s.cfg)
+	start := time.Now()
+	_, err := cli.Lookup(context.Background(), digest)
+	c.Assert(err, IsNil)
+	c.Check(time.Since(start) < 10*time.Second, Equals, true)
+}
+
+func (s *p2pSuite) TestFetch(c *C) {
+	digest := s.mockBlob(c, "blob data")
+	s.startServer(c)
+
+	target := filepath.Join(c.MkDir(), "foo_1.snap")
+	pbar := &progresstest.Meter{}
+	cli := p2p.NewClient(s.cfg)
+	err := cli.Fetch(context.Background(), "foo", digest, target, int64(len("blob data")), pbar)
+	c.Assert(err, IsNil)
+	c.Check(target, testutil.FileEquals, "blob data")
+	c.Check(target+".peer", testutil.FileAbsent)
+	c.Check(pbar.Labels, DeepEquals, []string{"foo"})
+	c.Check(pbar.Totals, DeepEquals, []float64{float64(len("blob data"))})
+	c.Check(pbar.Written, DeepEquals, [][]byte{[]byte("blob data")})
+	c.Check(pbar.Finishes, Equals, 1)
+}
+
+func (s *p2pSuite) TestFetchNoPeer(c *C) {
+	s.startServer(c)
+
+	target := filepath.Join(c.MkDir(), "foo_1.snap")
+	cli := p2p.NewClient(s.cfg)
+	err := cli.Fetch(context.Background(), "foo", digestOf("blob data"), target, int64(len("blob data")), nil)
+	c.Check(err, Equals, p2p.ErrNoPeer)
+	c.Check(target, testutil.FileAbsent)
+}
+
+func (s *p2pSuite) TestFetchDigestMismatch(c *C) {
+	digest := s.mockBlob(c, "blob data")
+	s.startServer(c)
+	// the blob got corrupted on the peer
+	c.Assert(os.WriteFile(filepath.Join(s.cacheDir, digest), []byte("corrupted"), 0600), IsNil)
+
+	target := filepath.Join(c.MkDir(), "foo_1.snap")
+	cli := p2p.NewClient(s.cfg)
+	err := cli.Fetch(context.Background(), "foo", digest, target, int64(len("blob data")), nil)
+	c.Check(err, Equals, p2p.ErrNoPeer)
+	c.Check(target, testutil.FileAbsent)
+	c.Check(target+".peer", testutil.FileAbsent)
+}
+
+func (s *p2pSuite) TestFetchSizeMismatch(c *C) {
+	digest := s.mockBlob(c, "blob data")
+	s.startServer(c)
+
+	// the peer has more data than the store said the blob has
+	target := filepath.Join(c.MkDir(), "foo_1.snap")
+	cli := p2p.NewClient(s.cfg)
+	err := cli.Fetch(context.Background(), "foo", digest, target, 4, nil)
+	c.Check(err, Equals, p2p.ErrNoPeer)
+	c.Check(target, testutil.FileAbsent)
+	c.Check(target+".peer", testutil.FileAbsent)
+
+	err = cli.Fetch(context.Background(), "foo", digest, target, 0, nil)
+	c.Check(err, ErrorMatches, `invalid size 0 of foo`)
+}
+
+func (s *p2pSuite) TestServeHTTPOverNetwork(c *C) {
+	digest := s.mockBlob(c, "blob data")
+	srv := s.startServer(c)
+
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/v1/blobs/%s", srv.Port(), digest))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Check(resp.StatusCode, Equals, 200)
+	c.Check(resp.ContentLength, Equals, int64(len("blob data")))
+}
@@ -0,0 +1,346 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package p2p
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+)
+
+const (
+	// httpReadHeaderTimeout and httpIdleTimeout bound how long peers can
+	// keep connections to the server open without using them.
+	httpReadHeaderTimeout = 10 * time.Second
+	httpIdleTimeout       = 60 * time.Second
+)
+
+// Server answers the queries of peers for the blobs in a download cache
+// directory, where blobs are named after their sha3-384 digest, and serves
+// them over HTTP. Only the blobs the shareable function allows are served,
+// and only to peers on the subnets of the configured network interfaces.
+type Server struct {
+	cacheDir  string
+	cfg       *Config
+	shareable func(sha3_384 string) bool
+
+	mu       sync.Mutex
+	started  bool
+	udpConns []*net.UDPConn
+	ifaces   []*serverIface
+	http     *http.Server
+	wg       sync.WaitGroup
+}
+
+// serverIface is a network interface blobs are shared on.
+type serverIface struct {
+	nets     []*net.IPNet
+	listener net.Listener
+}
+
+func (si *serverIface) contains(ip net.IP) bool {
+	for _, n := range si.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewServer returns a new Server for the blobs in the given cache directory.
+// shareable tells whether the blob with the given digest can be served to
+// peers, with nil no blob is served.
+func NewServer(cacheDir string, cfg *Config, shareable func(sha3_384 string) bool) *Server {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	return &Server{
+		cacheDir:  cacheDir,
+		cfg:       cfg,
+		shareable: shareable,
+	}
+}
+
+// interfaceNets returns the IPv4 networks of the network interface.
+func interfaceNets(ifi *net.Interface) ([]*net.IPNet, error) {
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	var nets []*net.IPNet
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.To4() == nil {
+			continue
+		}
+		nets = append(nets, ipnet)
+	}
+	if len(nets) == 0 {
+		return nil, fmt.Errorf("network interface %q has no IPv4 address", ifi.Name)
+	}
+	return nets, nil
+}
+
+// Start starts answering queries and serving blobs on the configured
+// network interfaces.
+func (s *Server) Start() (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("internal error: peer server already started")
+	}
+	if len(s.cfg.Interfaces) == 0 {
+		return fmt.Errorf("no network interfaces to share snaps on")
+	}
+
+	group, err := groupAddr(s.cfg)
+	if err != nil {
+		return fmt.Errorf("cannot resolve peer discovery address: %v", err)
+	}
+
+	var udpConns []*net.UDPConn
+	var ifaces []*serverIface
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, conn := range udpConns {
+			conn.Close()
+		}
+		for _, si := range ifaces {
+			si.listener.Close()
+		}
+	}()
+	for _, name := range s.cfg.Interfaces {
+		ifi, err := net.InterfaceByName(name)
+		if err != nil {
+			return fmt.Errorf("cannot share snaps with peers: %v", err)
+		}
+		nets, err := interfaceNets(ifi)
+		if err != nil {
+			return fmt.Errorf("cannot share snaps with peers: %v", err)
+		}
+		listener, err := net.Listen("tcp4", net.JoinHostPort(nets[0].IP.String(), "0"))
+		if err != nil {
+			return fmt.Errorf("cannot listen for peer downloads: %v", err)
+		}
+		si := &serverIface{nets: nets, listener: listener}
+		ifaces = append(ifaces, si)
+		if group.IP.IsMulticast() {
+			conn, err := net.ListenMulticastUDP("udp4", ifi, group)
+			if err != nil {
+				return fmt.Errorf("cannot listen for peer queries: %v", err)
+			}
+			udpConns = append(udpConns, conn)
+		}
+	}
+	var queryIfaces [][]*serverIface
+	if group.IP.IsMulticast() {
+		// one group membership for each interface
+		for _, si := range ifaces {
+			queryIfaces = append(queryIfaces, []*serverIface{si})
+		}
+	} else {
+		conn, err := net.ListenUDP("udp4", group)
+		if err != nil {
+			return fmt.Errorf("cannot listen for peer queries: %v", err)
+		}
+		udpConns = append(udpConns, conn)
+		queryIfaces = append(queryIfaces, ifaces)
+	}
+
+	s.started = true
+	s.udpConns = udpConns
+	s.ifaces = ifaces
+	s.http = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: httpReadHeaderTimeout,
+		IdleTimeout:       httpIdleTimeout,
+	}
+
+	for i, conn := range udpConns {
+		s.wg.Add(1)
+		go func(conn *net.UDPConn, ifaces []*serverIface) {
+			defer s.wg.Done()
+			s.answerQueries(conn, ifaces)
+		}(conn, queryIfaces[i])
+	}
+	for _, si := range ifaces {
+		s.wg.Add(1)
+		go func(listener net.Listener) {
+			defer s.wg.Done()
+			if err := s.http.Serve(listener); err != nil && err != http.ErrServerClosed {
+				logger.Noticef("cannot serve peer downloads: %v", err)
+			}
+		}(si.listener)
+	}
+	return nil
+}
+
+// Stop stops answering queries and serving blobs.
+func (s *Server) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started {
+		return nil
+	}
+	var firstErr error
+	for _, conn := range s.udpConns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := s.http.Close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	s.wg.Wait()
+	s.started = false
+	s.udpConns = nil
+	s.ifaces = nil
+	s.http = nil
+	return firstErr
+}
+
+// Port returns the port blobs are served from on the first of the
+// configured network interfaces, or 0 if the server is not started.
+func (s *Server) Port() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.ifaces) == 0 {
+		return 0
+	}
+	return s.ifaces[0].listener.Addr().(*net.TCPAddr).Port
+}
+
+// peerAllowed returns whether the peer with the given address is on the
+// subnet of one of the configured network interfaces.
+func (s *Server) peerAllowed(ip net.IP) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, si := range s.ifaces {
+		if si.contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// blobPath returns the path of the blob with the given digest in the cache,
+// or an empty string if there is no such blob or it cannot be shared.
+func (s *Server) blobPath(digest string) string {
+	if !validDigest(digest) {
+		return ""
+	}
+	// most queries are for blobs that are not in the cache at all
+	path := filepath.Join(s.cacheDir, digest)
+	if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+		return ""
+	}
+	if s.shareable == nil || !s.shareable(digest) {
+		return ""
+	}
+	return path
+}
+
+func (s *Server) answerQueries(conn *net.UDPConn, ifaces []*serverIface) {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Noticef("cannot read peer query: %v", err)
+			}
+			return
+		}
+		var si *serverIface
+		for _, candidate := range ifaces {
+			if candidate.contains(from.IP) {
+				si = candidate
+				break
+			}
+		}
+		if si == nil {
+			logger.Debugf("ignoring peer query from %s outside of the shared networks", from)
+			continue
+		}
+		var q query
+		if err := json.Unmarshal(buf[:n], &q); err != nil {
+			logger.Debugf("ignoring invalid peer query from %s: %v", from, err)
+			continue
+		}
+		if s.blobPath(q.Sha3_384) == "" {
+			continue
+		}
+		port := si.listener.Addr().(*net.TCPAddr).Port
+		rep := marshalDatagram(&reply{Sha3_384: q.Sha3_384, Port: port})
+		if _, err := conn.WriteToUDP(rep, from); err != nil {
+			logger.Debugf("cannot reply to peer query from %s: %v", from, err)
+		}
+	}
+}
+
+// ServeHTTP serves the blobs at /v1/blobs/<sha3-384>.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil || !s.peerAllowed(net.ParseIP(host)) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, blobsPath) {
+		http.NotFound(w, r)
+		return
+	}
+	path := s.blobPath(strings.TrimPrefix(r.URL.Path, blobsPath))
+	if path == "" {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		// removed by a cache cleanup in the meantime
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		http.Error(w, "cannot read blob", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, "", fi.ModTime(), f)
+}
@@ -165,6 +165,7 @@ type Store struct {
 	suggestedCurrency string
 
 	cacher downloadCache
+	peers  PeerFetcher
 
 	proxy              func(*http.Request) (*url.URL, error)
 	proxyConnectHeader http.Header
@@ -321,9 +322,9 @@ func init() {
 		panic(err)
 	}
 	defaultConfig.DetailFields = jsonutil.StructFields((*snapDetails)(nil), "snap_yaml_raw", "integrity")
-	defaultConfig.InfoFields = jsonutil.StructFields((*storeSnap)(nil), "snap-yaml", "integrity")
+	defaultConfig.InfoFields = jsonutil.StructFields((*storeSnap)(nil), "changelog", "snap-yaml", "integrity")
 	defaultConfig.FindFields = append(jsonutil.StructFields((*storeSnap)(nil),
-		"architectures", "created-at", "epoch", "name", "snap-id", "snap-yaml", "resources", "integrity"),
+		"architectures", "changelog", "created-at", "epoch", "name", "snap-id", "snap-yaml", "resources", "integrity"),
 		"channel")
 }
 
@@ -51,6 +51,11 @@ type RefreshOptions struct {
 	// IncludeResources indicates to the store that resources should be included
 	// in the response.
 	IncludeResources bool
+
+	// IncludeChangelog indicates to the store that the changelog of the
+	// revisions should be included in the response. The store leaves it
+	// out for revisions it has no changelog for.
+	IncludeChangelog bool
 }
 
 // snap action: install/refresh
@@ -223,7 +228,7 @@ type snapActionResultList struct {
 	ErrorList []errorListEntry    `json:"error-list"`
 }
 
-var snapActionFields = jsonutil.StructFields((*storeSnap)(nil), "resources")
+var snapActionFields = jsonutil.StructFields((*storeSnap)(nil), "resources", "changelog")
 
 // SnapAction queries the store for snap information for the given
 // install/refresh actions, given the context information about
@@ -572,6 +577,9 @@ func (s *Store) snapAction(ctx context.Context, currentSnaps []*CurrentSnap, act
 	if opts.IncludeResources {
 		fields = append(fields, "resources")
 	}
+	if opts.IncludeChangelog {
+		fields = append(fields, "changelog")
+	}
 
 	// build input for the install/refresh endpoint
 	jsonData, err := json.Marshal(snapActionRequest{
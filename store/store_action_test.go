@@ -339,6 +339,74 @@ func (s *storeActionSuite) TestSnapActionNonZeroEpochAndEpochBump(c *C) {
 	c.Assert(numReqs, Equals, 1) // should be >1 soon :-)
 }
 
+func (s *storeActionSuite) TestSnapActionRefreshChangelogOnlyWhenAsked(c *C) {
+	var fields []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assertRequest(c, r, "POST", snapActionPath)
+
+		jsonReq, err := io.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+		var req struct {
+			Fields []string `json:"fields"`
+		}
+		c.Assert(json.Unmarshal(jsonReq, &req), IsNil)
+		fields = req.Fields
+
+		// the store is free to leave out a changelog it does not have
+		io.WriteString(w, `{
+  "results": [{
+     "result": "refresh",
+     "instance-key": "buPKUD3TKqCOgLEjjHx5kSiCpIs5cMuQ",
+     "snap-id": "buPKUD3TKqCOgLEjjHx5kSiCpIs5cMuQ",
+     "name": "hello-world",
+     "snap": {
+       "snap-id": "buPKUD3TKqCOgLEjjHx5kSiCpIs5cMuQ",
+       "name": "hello-world",
+       "revision": 26,
+       "version": "6.1"
+     }
+  }]
+}`)
+	}))
+
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+
+	mockServerURL, _ := url.Parse(mockServer.URL)
+	cfg := store.Config{
+		StoreBaseURL: mockServerURL,
+	}
+	dauthCtx := &testDauthContext{c: c, device: s.device}
+	sto := store.New(&cfg, dauthCtx)
+
+	for _, includeChangelog := range []bool{false, true} {
+		results, _, err := sto.SnapAction(s.ctx, []*store.CurrentSnap{
+			{
+				InstanceName:    "hello-world",
+				SnapID:          helloWorldSnapID,
+				TrackingChannel: "stable",
+				Revision:        snap.R(1),
+				RefreshedDate:   helloRefreshedDate,
+			},
+		}, []*store.SnapAction{
+			{
+				Action:       "refresh",
+				SnapID:       helloWorldSnapID,
+				InstanceName: "hello-world",
+			},
+		}, nil, nil, &store.RefreshOptions{IncludeChangelog: includeChangelog})
+		c.Assert(err, IsNil)
+		c.Assert(results, HasLen, 1)
+		c.Check(results[0].Revision, Equals, snap.R(26))
+		c.Check(results[0].Changelog, Equals, "")
+		if includeChangelog {
+			c.Check(fields, DeepEquals, append(store.SnapActionFields, "changelog"))
+		} else {
+			c.Check(fields, DeepEquals, store.SnapActionFields)
+		}
+	}
+}
+
 func (s *storeActionSuite) TestSnapActionNoResults(c *C) {
 	restore := release.MockOnClassic(false)
 	defer restore()
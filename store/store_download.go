@@ -188,6 +188,10 @@ type DownloadOptions struct {
 	RateLimit           int64
 	Scheduled           bool
 	LeavePartialOnError bool
+	// RateLimitRecheck, if set, is called periodically during the
+	// download to get the rate limit to apply from then on, 0 meaning no
+	// limit, as the limit can depend on the time of day.
+	RateLimitRecheck func() int64
 }
 
 // Download downloads the snap addressed by download info and returns its
@@ -211,6 +215,20 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 		return nil
 	}
 
+	if s.peers != nil && downloadInfo.Sha3_384 != "" && downloadInfo.Size > 0 {
+		// the store still decides whether the snap can be downloaded,
+		// e.g. whether it was bought
+		if err := authorizeDownload(ctx, name, downloadInfo.DownloadURL, user, s, dlOpts); err != nil {
+			return err
+		}
+		err := s.fetchFromPeers(ctx, name, targetPath, downloadInfo, pbar)
+		if err == nil {
+			return s.cacher.Put(downloadInfo.Sha3_384, targetPath)
+		}
+		// fall back to the store
+		logger.Debugf("Cannot fetch %s from peers: %v", name, err)
+	}
+
 	if s.useDeltas() {
 		logger.Debugf("Available deltas returned by store: %v", downloadInfo.Deltas)
 
@@ -240,6 +258,9 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 	if err != nil {
 		return err
 	}
+	// big snaps are downloaded in chunks, which are kept on errors as the
+	// chunk map tells which of them are complete
+	chunked := downloadInfo.Size >= chunkedDownloadMinSize
 	defer func() {
 		fi, _ := w.Stat()
 		if cerr := w.Close(); cerr != nil && err == nil {
@@ -248,23 +269,44 @@ func (s *Store) Download(ctx context.Context, name string, targetPath string, do
 		if err == nil {
 			return
 		}
+		if chunked && osutil.FileExists(chunkMapPath(w.Name())) {
+			return
+		}
 		if dlOpts == nil || !dlOpts.LeavePartialOnError || fi == nil || fi.Size() == 0 {
 			os.Remove(w.Name())
 		}
 	}()
-	if resume > 0 {
-		logger.Debugf("Resuming download of %q at %d.", partialPath, resume)
-	} else {
-		logger.Debugf("Starting download of %q.", partialPath)
+	if !chunked {
+		if resume > 0 {
+			logger.Debugf("Resuming download of %q at %d.", partialPath, resume)
+		} else {
+			logger.Debugf("Starting download of %q.", partialPath)
+		}
 	}
 
 	url := downloadInfo.DownloadURL
-	if downloadInfo.Size == 0 || resume < downloadInfo.Size {
+	switch {
+	case chunked:
+		err = downloadChunked(ctx, name, downloadInfo, user, s, w, pbar, dlOpts)
+		if err == errRangeUnsupported {
+			logger.Debugf("Cannot download %q in chunks, downloading it in a single stream.", partialPath)
+			if err := w.Truncate(0); err != nil {
+				return err
+			}
+			if _, err := w.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			err = download(ctx, name, downloadInfo.Sha3_384, url, user, s, w, 0, pbar, dlOpts)
+		}
+		if err != nil {
+			logger.Debugf("download of %q failed: %#v", url, err)
+		}
+	case downloadInfo.Size == 0 || resume < downloadInfo.Size:
 		err = download(ctx, name, downloadInfo.Sha3_384, url, user, s, w, resume, pbar, dlOpts)
 		if err != nil {
 			logger.Debugf("download of %q failed: %#v", url, err)
 		}
-	} else {
+	default:
 		// we're done! check the hash though
 		h := crypto.SHA3_384.New()
 		if _, err := w.Seek(0, os.SEEK_SET); err != nil {
@@ -413,6 +455,23 @@ func downloadReqOpts(storeURL *url.URL, cdnHeader string, opts *DownloadOptions)
 	return &reqOptions
 }
 
+// newDownloadHTTPClient returns the http.Client to download blobs with.
+func (s *Store) newDownloadHTTPClient(reqOptions *requestOptions) *http.Client {
+	cli := s.newHTTPClient(nil) // XXX: there's no timeout defined for this client, and the context is context.TODO(), so it won't be cancelled
+	oldCheckRedirect := cli.CheckRedirect
+	if oldCheckRedirect == nil {
+		panic("internal error: the httputil.NewHTTPClient-produced http.Client must have CheckRedirect defined")
+	}
+	cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		// remove user/device auth headers from being sent in "CDN" redirects
+		// see also: https://bugs.launchpad.net/snapd/+bug/2027993
+		// TODO: do we need to remove other identifying headers?
+		dropAuthorization(req, &AuthorizeOptions{deviceAuth: true, apiLevel: reqOptions.APILevel})
+		return oldCheckRedirect(req, via)
+	}
+	return cli
+}
+
 type transferSpeedError struct {
 	Speed float64
 }
@@ -523,6 +582,114 @@ func (w *TransferSpeedMonitoringWriter) Err() error {
 
 var ratelimitReader = ratelimit.Reader
 
+// downloadRateLimitRecheckInterval is how often the rate limit of a
+// download is checked again, with DownloadOptions.RateLimitRecheck.
+var downloadRateLimitRecheckInterval = time.Minute
+
+// downloadRateLimiter limits the rate of a download, which can be made of
+// concurrent requests, following the changes of the rate limit when
+// DownloadOptions.RateLimitRecheck is set.
+type downloadRateLimiter struct {
+	mu      sync.Mutex
+	rate    int64
+	bucket  *ratelimit.Bucket
+	recheck func() int64
+	checked time.Time
+}
+
+func newDownloadRateLimiter(dlOpts *DownloadOptions) *downloadRateLimiter {
+	l := &downloadRateLimiter{
+		recheck: dlOpts.RateLimitRecheck,
+		checked: time.Now(),
+	}
+	l.setRate(dlOpts.RateLimit)
+	return l
+}
+
+func (l *downloadRateLimiter) setRate(rate int64) {
+	l.rate = rate
+	l.bucket = nil
+	if rate > 0 {
+		l.bucket = ratelimit.NewBucketWithRate(float64(rate), 2*rate)
+	}
+}
+
+// currentBucket returns the bucket to limit the download with, or nil if it
+// is not limited.
+func (l *downloadRateLimiter) currentBucket() *ratelimit.Bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.recheck != nil && time.Since(l.checked) >= downloadRateLimitRecheckInterval {
+		l.checked = time.Now()
+		if rate := l.recheck(); rate != l.rate {
+			logger.Debugf("Download rate limit changed from %d to %d.", l.rate, rate)
+			l.setRate(rate)
+		}
+	}
+	return l.bucket
+}
+
+// reader returns a reader limiting the rate at which r is read.
+func (l *downloadRateLimiter) reader(r io.Reader) io.Reader {
+	if l.recheck == nil {
+		if l.bucket == nil {
+			return r
+		}
+		return ratelimitReader(r, l.bucket)
+	}
+	return &rateLimitedReader{r: r, limiter: l}
+}
+
+type rateLimitedReader struct {
+	r       io.Reader
+	limiter *downloadRateLimiter
+}
+
+func (rr *rateLimitedReader) Read(p []byte) (int, error) {
+	bucket := rr.limiter.currentBucket()
+	if bucket == nil {
+		return rr.r.Read(p)
+	}
+	return ratelimitReader(rr.r, bucket).Read(p)
+}
+
+var authorizeDownload = authorizeDownloadImpl
+
+// authorizeDownload checks with the store that the user can download the
+// snap, without downloading it. The store redirects authorized downloads
+// to the CDN, the redirect is not followed.
+func authorizeDownloadImpl(ctx context.Context, name, downloadURL string, user *auth.UserState, s *Store, dlOpts *DownloadOptions) error {
+	storeURL, err := url.Parse(downloadURL)
+	if err != nil {
+		return err
+	}
+	cdnHeader, err := s.cdnHeader()
+	if err != nil {
+		return err
+	}
+	reqOptions := downloadReqOpts(storeURL, cdnHeader, dlOpts)
+	reqOptions.ExtraHeaders["Range"] = "bytes=0-0"
+	cli := s.newDownloadHTTPClient(reqOptions)
+	cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := s.doRequest(ctx, cli, reqOptions, user)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == 402: // Payment Required
+		return fmt.Errorf("please buy %s before installing it", name)
+	case resp.StatusCode >= 200 && resp.StatusCode < 400:
+		return nil
+	default:
+		return &DownloadError{Code: resp.StatusCode, URL: resp.Request.URL}
+	}
+}
+
 var download = downloadImpl
 
 // download writes an http.Request showing a progress.Meter
@@ -542,6 +709,7 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 	}
 
 	tc, downloadCtx := NewTransferSpeedMonitoringWriterAndContext(ctx, downloadSpeedMeasureWindow, downloadSpeedMin)
+	rateLimiter := newDownloadRateLimiter(dlOpts)
 
 	var finalErr error
 	var dlSize float64
@@ -572,18 +740,7 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 			return fmt.Errorf("the download has been cancelled: %s", downloadCtx.Err())
 		}
 		var resp *http.Response
-		cli := s.newHTTPClient(nil) // XXX: there's no timeout defined for this client, and the context is context.TODO(), so it won't be cancelled
-		oldCheckRedirect := cli.CheckRedirect
-		if oldCheckRedirect == nil {
-			panic("internal error: the httputil.NewHTTPClient-produced http.Client must have CheckRedirect defined")
-		}
-		cli.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			// remove user/device auth headers from being sent in "CDN" redirects
-			// see also: https://bugs.launchpad.net/snapd/+bug/2027993
-			// TODO: do we need to remove other identifying headers?
-			dropAuthorization(req, &AuthorizeOptions{deviceAuth: true, apiLevel: reqOptions.APILevel})
-			return oldCheckRedirect(req, via)
-		}
+		cli := s.newDownloadHTTPClient(reqOptions)
 		resp, finalErr = s.doRequest(downloadCtx, cli, reqOptions, user)
 		if cancelled(downloadCtx) {
 			return fmt.Errorf("the download has been cancelled: %s", downloadCtx.Err())
@@ -630,12 +787,7 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 		}
 		pbar.Start(name, dlSize)
 		mw := io.MultiWriter(w, h, pbar, tc)
-		var limiter io.Reader
-		limiter = resp.Body
-		if limit := dlOpts.RateLimit; limit > 0 {
-			bucket := ratelimit.NewBucketWithRate(float64(limit), 2*limit)
-			limiter = ratelimitReader(resp.Body, bucket)
-		}
+		limiter := rateLimiter.reader(resp.Body)
 
 		stopMonitorCh := tc.Monitor()
 		_, finalErr = io.Copy(mw, limiter)
@@ -982,9 +1134,61 @@ func (s *Store) SetCachePolicy(policy CachePolicy) {
 	}
 }
 
+// PeerFetcher fetches snap blobs from peers on the local network.
+type PeerFetcher interface {
+	// Fetch fetches the blob with the given sha3-384 digest and size into
+	// targetPath, checking it matches both.
+	Fetch(ctx context.Context, name, sha3_384, targetPath string, size int64, pbar progress.Meter) error
+}
+
+// monitoredMeter is a progress.Meter that also passes what is written to
+// the transfer speed monitor.
+type monitoredMeter struct {
+	progress.Meter
+	tc *TransferSpeedMonitoringWriter
+}
+
+func (m *monitoredMeter) Write(p []byte) (int, error) {
+	m.tc.Write(p)
+	return m.Meter.Write(p)
+}
+
+// fetchFromPeers fetches the snap from peers, giving up on peers that are
+// too slow the same way as on the store.
+func (s *Store) fetchFromPeers(ctx context.Context, name, targetPath string, downloadInfo *snap.DownloadInfo, pbar progress.Meter) error {
+	if pbar == nil {
+		pbar = progress.Null
+	}
+	tc, fetchCtx := NewTransferSpeedMonitoringWriterAndContext(ctx, downloadSpeedMeasureWindow, downloadSpeedMin)
+	stopMonitorCh := tc.Monitor()
+	err := s.peers.Fetch(fetchCtx, name, downloadInfo.Sha3_384, targetPath, downloadInfo.Size, &monitoredMeter{Meter: pbar, tc: tc})
+	close(stopMonitorCh)
+	if tcErr := tc.Err(); tcErr != nil {
+		return tcErr
+	}
+	return err
+}
+
+// SetPeerFetcher configures where snaps are fetched from before falling back
+// to downloading them from the store. With nil, they are not fetched from
+// peers.
+func (s *Store) SetPeerFetcher(peers PeerFetcher) {
+	s.peers = peers
+}
+
 // CleanDownloadsCache attempts cleanup of snap downloads cache.
 //
 // Returns ErrCleanupBusy if the cache was locked for other operations.
 func (s *Store) CleanDownloadsCache() error {
 	return s.cacher.Cleanup()
 }
+
+// PruneDownloadsCache removes snaps from the downloads cache that are not used
+// elsewhere, oldest first, until at least target bytes were freed or, with a
+// zero target, all of them. It returns the number of bytes freed.
+//
+// Returns ErrCleanupBusy if the cache was locked for other operations.
+func (s *Store) PruneDownloadsCache(target uint64) (uint64, error) {
+	_, freed, err := s.cacher.Prune(target)
+	return freed, err
+}
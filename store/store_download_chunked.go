@@ -0,0 +1,368 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/retry.v1"
+
+	"github.com/snapcore/snapd/httputil"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/progress"
+	"github.com/snapcore/snapd/snap"
+)
+
+var (
+	// chunkedDownloadMinSize is the size from which snaps are downloaded
+	// in chunks over parallel ranged requests.
+	chunkedDownloadMinSize int64 = 64 * 1024 * 1024
+	// downloadChunkSize is the size of each chunk.
+	downloadChunkSize int64 = 8 * 1024 * 1024
+	// downloadChunkWorkers is how many chunks are downloaded in parallel.
+	downloadChunkWorkers = 4
+)
+
+var fileSync = (*os.File).Sync
+
+// errRangeUnsupported is returned by downloadChunked when the server does
+// not support ranged requests, and the snap needs to be downloaded in a
+// single stream instead.
+var errRangeUnsupported = errors.New("server does not support ranged requests")
+
+// chunkMap records which chunks of a chunked download are complete, so that
+// an interrupted download can be continued where it stopped. It is kept next
+// to the partial download.
+type chunkMap struct {
+	Size      int64  `json:"size"`
+	Sha3_384  string `json:"sha3-384"`
+	ChunkSize int64  `json:"chunk-size"`
+	Done      []bool `json:"done"`
+}
+
+func chunkMapPath(partialPath string) string {
+	return partialPath + ".chunks"
+}
+
+func newChunkMap(size int64, sha3_384 string) *chunkMap {
+	n := (size + downloadChunkSize - 1) / downloadChunkSize
+	return &chunkMap{
+		Size:      size,
+		Sha3_384:  sha3_384,
+		ChunkSize: downloadChunkSize,
+		Done:      make([]bool, n),
+	}
+}
+
+// loadChunkMap loads the chunk map of the partial download at partialPath.
+// If there is none, or it is for another blob, a new chunk map is returned
+// where the chunks already covered by the partial file, as left behind by a
+// download in a single stream, are marked as complete.
+func loadChunkMap(partialPath string, partialSize int64, downloadInfo *snap.DownloadInfo) *chunkMap {
+	data, err := os.ReadFile(chunkMapPath(partialPath))
+	if err == nil {
+		var cm chunkMap
+		if err := json.Unmarshal(data, &cm); err == nil && cm.valid(downloadInfo) {
+			return &cm
+		}
+		logger.Debugf("Ignoring invalid chunk map for %q.", partialPath)
+		// the partial file cannot be trusted either
+		partialSize = 0
+	}
+
+	cm := newChunkMap(downloadInfo.Size, downloadInfo.Sha3_384)
+	for i := range cm.Done {
+		_, end := cm.chunkRange(i)
+		cm.Done[i] = end < partialSize
+	}
+	return cm
+}
+
+func (cm *chunkMap) valid(downloadInfo *snap.DownloadInfo) bool {
+	if cm.Size != downloadInfo.Size || cm.Sha3_384 != downloadInfo.Sha3_384 || cm.ChunkSize <= 0 {
+		return false
+	}
+	return int64(len(cm.Done)) == (cm.Size+cm.ChunkSize-1)/cm.ChunkSize
+}
+
+// chunkRange returns the offsets of the first and last bytes of the chunk.
+func (cm *chunkMap) chunkRange(i int) (start, end int64) {
+	start = int64(i) * cm.ChunkSize
+	end = start + cm.ChunkSize - 1
+	if end >= cm.Size {
+		end = cm.Size - 1
+	}
+	return start, end
+}
+
+func (cm *chunkMap) doneSize() int64 {
+	var done int64
+	for i, ok := range cm.Done {
+		if ok {
+			start, end := cm.chunkRange(i)
+			done += end - start + 1
+		}
+	}
+	return done
+}
+
+func (cm *chunkMap) save(path string) error {
+	data, err := json.Marshal(cm)
+	if err != nil {
+		return err
+	}
+	return osutil.AtomicWriteFile(path, data, 0600, 0)
+}
+
+// offsetWriter writes to w from the given offset onwards.
+type offsetWriter struct {
+	w   io.WriterAt
+	off int64
+}
+
+func (ow *offsetWriter) Write(p []byte) (int, error) {
+	n, err := ow.w.WriteAt(p, ow.off)
+	ow.off += int64(n)
+	return n, err
+}
+
+// lockedWriter serializes the writes of the chunk downloads to w.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+var downloadChunked = downloadChunkedImpl
+
+// downloadChunkedImpl downloads the snap into the partial file w in chunks,
+// using parallel ranged requests that are retried independently. Completed
+// chunks are recorded in the chunk map next to w, so that the download can
+// be continued after an interruption, even across restarts.
+func downloadChunkedImpl(ctx context.Context, name string, downloadInfo *snap.DownloadInfo, user *auth.UserState, s *Store, w *os.File, pbar progress.Meter, dlOpts *DownloadOptions) error {
+	if dlOpts == nil {
+		dlOpts = &DownloadOptions{}
+	}
+	if pbar == nil {
+		pbar = progress.Null
+	}
+
+	storeURL, err := url.Parse(downloadInfo.DownloadURL)
+	if err != nil {
+		return err
+	}
+	cdnHeader, err := s.cdnHeader()
+	if err != nil {
+		return err
+	}
+
+	fi, err := w.Stat()
+	if err != nil {
+		return err
+	}
+	mapPath := chunkMapPath(w.Name())
+	cm := loadChunkMap(w.Name(), fi.Size(), downloadInfo)
+	if err := w.Truncate(cm.Size); err != nil {
+		return err
+	}
+
+	var pending []int
+	for i, done := range cm.Done {
+		if !done {
+			pending = append(pending, i)
+		}
+	}
+	if done := cm.doneSize(); done > 0 {
+		logger.Debugf("Resuming chunked download of %q with %d of %d bytes done.", w.Name(), done, cm.Size)
+	} else {
+		logger.Debugf("Starting chunked download of %q.", w.Name())
+	}
+
+	tc, downloadCtx := NewTransferSpeedMonitoringWriterAndContext(ctx, downloadSpeedMeasureWindow, downloadSpeedMin)
+	chunkCtx, cancel := context.WithCancel(downloadCtx)
+	defer cancel()
+
+	// the limit is shared by the chunks downloaded in parallel
+	rateLimiter := newDownloadRateLimiter(dlOpts)
+
+	pbar.Start(name, float64(cm.Size))
+	pbar.Set(float64(cm.doneSize()))
+	progressWriter := &lockedWriter{w: io.MultiWriter(pbar, tc)}
+
+	var mu sync.Mutex
+	var firstErr error
+	chunks := make(chan int)
+	var wg sync.WaitGroup
+	workers := downloadChunkWorkers
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+	startTime := time.Now()
+	stopMonitorCh := tc.Monitor()
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range chunks {
+				start, end := cm.chunkRange(i)
+				reqOptions := downloadReqOpts(storeURL, cdnHeader, dlOpts)
+				err := downloadChunk(chunkCtx, name, s, reqOptions, user, w, start, end, rateLimiter, progressWriter)
+				if err == nil {
+					// the chunk must be on disk before the map says
+					// it is done
+					err = fileSync(w)
+				}
+				mu.Lock()
+				if err == nil {
+					cm.Done[i] = true
+					err = cm.save(mapPath)
+				}
+				if err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, i := range pending {
+		if chunkCtx.Err() != nil {
+			break
+		}
+		chunks <- i
+	}
+	close(chunks)
+	wg.Wait()
+	close(stopMonitorCh)
+	pbar.Finished()
+
+	if err := tc.Err(); err != nil {
+		return err
+	}
+	if cancelled(downloadCtx) {
+		return fmt.Errorf("the download has been cancelled: %s", downloadCtx.Err())
+	}
+	if firstErr != nil {
+		if firstErr == errRangeUnsupported {
+			os.Remove(mapPath)
+		}
+		return firstErr
+	}
+
+	if _, err := w.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	h := crypto.SHA3_384.New()
+	if _, err := io.Copy(h, w); err != nil {
+		return err
+	}
+	// the chunk map is of no use anymore, if the blob is wrong it needs to
+	// be downloaded again from scratch
+	os.Remove(mapPath)
+	actualSha3 := fmt.Sprintf("%x", h.Sum(nil))
+	if downloadInfo.Sha3_384 != "" && downloadInfo.Sha3_384 != actualSha3 {
+		return HashError{name, actualSha3, downloadInfo.Sha3_384}
+	}
+	logger.Debugf("Chunked download succeeded in %.03fs.", time.Since(startTime).Seconds())
+	return nil
+}
+
+// downloadChunk downloads the bytes from start to end, inclusive, into w,
+// retrying and continuing from where it stopped on errors.
+func downloadChunk(ctx context.Context, name string, s *Store, reqOptions *requestOptions, user *auth.UserState, w io.WriterAt, start, end int64, rateLimiter *downloadRateLimiter, progressWriter io.Writer) error {
+	var finalErr error
+	startTime := time.Now()
+	for attempt := retry.Start(downloadRetryStrategy, nil); attempt.Next(); {
+		httputil.MaybeLogRetryAttempt(reqOptions.URL.String(), attempt, startTime)
+		reqOptions.ExtraHeaders["Range"] = fmt.Sprintf("bytes=%d-%d", start, end)
+
+		if cancelled(ctx) {
+			return ctx.Err()
+		}
+		cli := s.newDownloadHTTPClient(reqOptions)
+		var resp *http.Response
+		resp, finalErr = s.doRequest(ctx, cli, reqOptions, user)
+		if cancelled(ctx) {
+			return ctx.Err()
+		}
+		if finalErr != nil {
+			if httputil.ShouldRetryAttempt(attempt, finalErr) {
+				continue
+			}
+			break
+		}
+		if httputil.ShouldRetryHttpResponse(attempt, resp) {
+			resp.Body.Close()
+			continue
+		}
+
+		switch resp.StatusCode {
+		case 206: // Partial Content
+		case 200:
+			resp.Body.Close()
+			return errRangeUnsupported
+		case 402: // Payment Required
+			resp.Body.Close()
+			return fmt.Errorf("please buy %s before installing it", name)
+		default:
+			resp.Body.Close()
+			return &DownloadError{Code: resp.StatusCode, URL: resp.Request.URL}
+		}
+		if resp.ContentLength != end-start+1 {
+			resp.Body.Close()
+			return fmt.Errorf("unexpected size %d of range %d-%d", resp.ContentLength, start, end)
+		}
+
+		body := rateLimiter.reader(resp.Body)
+		ow := &offsetWriter{w: w, off: start}
+		_, finalErr = io.Copy(io.MultiWriter(ow, progressWriter), body)
+		resp.Body.Close()
+		if cancelled(ctx) {
+			return ctx.Err()
+		}
+		if finalErr != nil {
+			if httputil.ShouldRetryAttempt(attempt, finalErr) {
+				// continue from where it stopped
+				start = ow.off
+				continue
+			}
+			break
+		}
+		break
+	}
+	return finalErr
+}
@@ -0,0 +1,287 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package store_test
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/progress/progresstest"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/store"
+	"github.com/snapcore/snapd/testutil"
+)
+
+const chunkedContent = "0123456789abcdefghij"
+
+type rangeServer struct {
+	mu     sync.Mutex
+	ranges []string
+	// fail returns the status to fail the request for the range with, or 0
+	fail func(rng string, n int) int
+	// noRanges makes the server ignore the Range header
+	noRanges bool
+}
+
+func (rs *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rng := r.Header.Get("Range")
+	rs.mu.Lock()
+	n := 0
+	for _, seen := range rs.ranges {
+		if seen == rng {
+			n++
+		}
+	}
+	rs.ranges = append(rs.ranges, rng)
+	rs.mu.Unlock()
+
+	if rs.fail != nil {
+		if status := rs.fail(rng, n); status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+	}
+	if rs.noRanges {
+		r.Header.Del("Range")
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader([]byte(chunkedContent)))
+}
+
+func (rs *rangeServer) requestedRanges() []string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	ranges := append([]string(nil), rs.ranges...)
+	sort.Strings(ranges)
+	return ranges
+}
+
+func (s *storeDownloadSuite) chunkedDownloadInfo(url string) *snap.DownloadInfo {
+	h := crypto.SHA3_384.New()
+	h.Write([]byte(chunkedContent))
+	return &snap.DownloadInfo{
+		DownloadURL: url,
+		Sha3_384:    fmt.Sprintf("%x", h.Sum(nil)),
+		Size:        int64(len(chunkedContent)),
+	}
+}
+
+func (s *storeDownloadSuite) TestDownloadChunked(c *C) {
+	s.AddCleanup(store.MockChunkedDownload(1, 6, 2))
+	rs := &rangeServer{}
+	mockServer := httptest.NewServer(rs)
+	defer mockServer.Close()
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	pbar := &progresstest.Meter{}
+	err := s.store.Download(s.ctx, "foo", targetFn, s.chunkedDownloadInfo(mockServer.URL), pbar, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, chunkedContent)
+	c.Check(targetFn+".partial", testutil.FileAbsent)
+	c.Check(targetFn+".partial.chunks", testutil.FileAbsent)
+
+	c.Check(rs.requestedRanges(), DeepEquals, []string{
+		"bytes=0-5", "bytes=12-17", "bytes=18-19", "bytes=6-11",
+	})
+	c.Check(pbar.Labels, DeepEquals, []string{"foo"})
+	c.Check(pbar.Totals, DeepEquals, []float64{float64(len(chunkedContent))})
+	c.Check(pbar.Finishes, Equals, 1)
+	var written int
+	for _, w := range pbar.Written {
+		written += len(w)
+	}
+	c.Check(written, Equals, len(chunkedContent))
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedResume(c *C) {
+	s.AddCleanup(store.MockChunkedDownload(1, 6, 2))
+	rs := &rangeServer{}
+	mockServer := httptest.NewServer(rs)
+	defer mockServer.Close()
+	downloadInfo := s.chunkedDownloadInfo(mockServer.URL)
+
+	// a previous download was interrupted after getting the first and third
+	// chunks
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	partial := []byte(chunkedContent)
+	copy(partial[6:12], "xxxxxx")
+	copy(partial[18:], "xx")
+	c.Assert(os.WriteFile(targetFn+".partial", partial, 0600), IsNil)
+	data, err := json.Marshal(&store.ChunkMap{
+		Size:      downloadInfo.Size,
+		Sha3_384:  downloadInfo.Sha3_384,
+		ChunkSize: 6,
+		Done:      []bool{true, false, true, false},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(os.WriteFile(targetFn+".partial.chunks", data, 0600), IsNil)
+
+	pbar := &progresstest.Meter{}
+	err = s.store.Download(s.ctx, "foo", targetFn, downloadInfo, pbar, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, chunkedContent)
+	c.Check(rs.requestedRanges(), DeepEquals, []string{"bytes=18-19", "bytes=6-11"})
+	c.Check(pbar.Values, DeepEquals, []float64{12})
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedResumeSingleStream(c *C) {
+	s.AddCleanup(store.MockChunkedDownload(1, 6, 2))
+	rs := &rangeServer{}
+	mockServer := httptest.NewServer(rs)
+	defer mockServer.Close()
+
+	// left behind by a download in a single stream
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	c.Assert(os.WriteFile(targetFn+".partial", []byte(chunkedContent[:8]), 0600), IsNil)
+
+	err := s.store.Download(s.ctx, "foo", targetFn, s.chunkedDownloadInfo(mockServer.URL), nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, chunkedContent)
+	c.Check(rs.requestedRanges(), DeepEquals, []string{"bytes=12-17", "bytes=18-19", "bytes=6-11"})
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedRetriesChunk(c *C) {
+	s.AddCleanup(store.MockChunkedDownload(1, 6, 2))
+	rs := &rangeServer{
+		fail: func(rng string, n int) int {
+			if rng == "bytes=6-11" && n < 2 {
+				return 503
+			}
+			return 0
+		},
+	}
+	mockServer := httptest.NewServer(rs)
+	defer mockServer.Close()
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, s.chunkedDownloadInfo(mockServer.URL), nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, chunkedContent)
+	c.Check(rs.requestedRanges(), DeepEquals, []string{
+		"bytes=0-5", "bytes=12-17", "bytes=18-19", "bytes=6-11", "bytes=6-11", "bytes=6-11",
+	})
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedErrorKeepsPartial(c *C) {
+	s.AddCleanup(store.MockChunkedDownload(1, 6, 1))
+	rs := &rangeServer{
+		fail: func(rng string, n int) int {
+			if rng == "bytes=6-11" {
+				return 404
+			}
+			return 0
+		},
+	}
+	mockServer := httptest.NewServer(rs)
+	defer mockServer.Close()
+	downloadInfo := s.chunkedDownloadInfo(mockServer.URL)
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, downloadInfo, nil, nil, nil)
+	c.Assert(err, FitsTypeOf, &store.DownloadError{})
+	c.Check(targetFn, testutil.FileAbsent)
+
+	// the partial download and its chunk map are kept to continue later
+	data, err := os.ReadFile(targetFn + ".partial.chunks")
+	c.Assert(err, IsNil)
+	var cm store.ChunkMap
+	c.Assert(json.Unmarshal(data, &cm), IsNil)
+	c.Check(cm, DeepEquals, store.ChunkMap{
+		Size:      downloadInfo.Size,
+		Sha3_384:  downloadInfo.Sha3_384,
+		ChunkSize: 6,
+		Done:      []bool{true, false, false, false},
+	})
+	partial, err := os.ReadFile(targetFn + ".partial")
+	c.Assert(err, IsNil)
+	c.Check(partial, HasLen, len(chunkedContent))
+	c.Check(string(partial[:6]), Equals, chunkedContent[:6])
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedSyncsBeforeSavingMap(c *C) {
+	s.AddCleanup(store.MockChunkedDownload(1, 6, 1))
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	syncs := 0
+	s.AddCleanup(store.MockFileSync(func(f *os.File) error {
+		c.Check(f.Name(), Equals, targetFn+".partial")
+		syncs++
+		if syncs == 2 {
+			return fmt.Errorf("sync failed")
+		}
+		return f.Sync()
+	}))
+	rs := &rangeServer{}
+	mockServer := httptest.NewServer(rs)
+	defer mockServer.Close()
+	downloadInfo := s.chunkedDownloadInfo(mockServer.URL)
+
+	err := s.store.Download(s.ctx, "foo", targetFn, downloadInfo, nil, nil, nil)
+	c.Assert(err, ErrorMatches, "sync failed")
+	c.Check(syncs, Equals, 2)
+
+	// the chunk that did not make it to disk is not marked as done
+	data, err := os.ReadFile(targetFn + ".partial.chunks")
+	c.Assert(err, IsNil)
+	var cm store.ChunkMap
+	c.Assert(json.Unmarshal(data, &cm), IsNil)
+	c.Check(cm.Done, DeepEquals, []bool{true, false, false, false})
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedNoRangeSupport(c *C) {
+	s.AddCleanup(store.MockChunkedDownload(1, 6, 2))
+	rs := &rangeServer{noRanges: true}
+	mockServer := httptest.NewServer(rs)
+	defer mockServer.Close()
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, s.chunkedDownloadInfo(mockServer.URL), nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(targetFn, testutil.FileEquals, chunkedContent)
+	c.Check(targetFn+".partial.chunks", testutil.FileAbsent)
+	// downloaded again in a single stream
+	c.Check(rs.requestedRanges()[0], Equals, "")
+}
+
+func (s *storeDownloadSuite) TestDownloadChunkedHashError(c *C) {
+	s.AddCleanup(store.MockChunkedDownload(1, 6, 2))
+	rs := &rangeServer{}
+	mockServer := httptest.NewServer(rs)
+	defer mockServer.Close()
+	downloadInfo := s.chunkedDownloadInfo(mockServer.URL)
+	downloadInfo.Sha3_384 = "bad-sha3"
+
+	targetFn := filepath.Join(c.MkDir(), "foo_1.0_all.snap")
+	err := s.store.Download(s.ctx, "foo", targetFn, downloadInfo, nil, nil, nil)
+	c.Assert(err, FitsTypeOf, store.HashError{})
+	c.Check(targetFn+".partial", testutil.FileAbsent)
+	c.Check(targetFn+".partial.chunks", testutil.FileAbsent)
+	// retried once in a single stream from scratch
+	c.Check(rs.requestedRanges()[0], Equals, "")
+}
@@ -720,6 +720,7 @@ type cacheObserver struct {
 	putErrHits    map[string]int
 
 	cleanupCalls int
+	pruneTargets []uint64
 }
 
 func (co *cacheObserver) Get(cacheKey, targetPath string) bool {
@@ -753,6 +754,22 @@ func (co *cacheObserver) Cleanup() error {
 	return nil
 }
 
+func (co *cacheObserver) Prune(target uint64) (int, uint64, error) {
+	co.pruneTargets = append(co.pruneTargets, target)
+	return 1, 1000, nil
+}
+
+func (s *storeDownloadSuite) TestPruneDownloadsCache(c *C) {
+	obs := &cacheObserver{}
+	restore := s.store.MockCacher(obs)
+	defer restore()
+
+	freed, err := s.store.PruneDownloadsCache(500)
+	c.Assert(err, IsNil)
+	c.Check(freed, Equals, uint64(1000))
+	c.Check(obs.pruneTargets, DeepEquals, []uint64{500})
+}
+
 func (s *storeDownloadSuite) TestDownloadCacheHit(c *C) {
 	obs := &cacheObserver{inCache: map[string]bool{"the-snaps-sha3_384": true}}
 	restore := s.store.MockCacher(obs)
@@ -800,6 +817,185 @@ func (s *storeDownloadSuite) TestDownloadCacheMiss(c *C) {
 	c.Check(obs.puts, DeepEquals, []string{fmt.Sprintf("the-snaps-sha3_384:%s", path)})
 }
 
+type fakePeerFetcher struct {
+	fetched []string
+	err     error
+	// stall makes the fetch hang until it is cancelled
+	stall bool
+}
+
+func (f *fakePeerFetcher) Fetch(ctx context.Context, name, sha3_384, targetPath string, size int64, pbar progress.Meter) error {
+	f.fetched = append(f.fetched, fmt.Sprintf("%s:%s:%d", name, sha3_384, size))
+	if f.err != nil {
+		return f.err
+	}
+	if f.stall {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return os.WriteFile(targetPath, []byte("from a peer"), 0600)
+}
+
+func (s *storeDownloadSuite) TestDownloadFromPeer(c *C) {
+	obs := &cacheObserver{inCache: map[string]bool{}}
+	restore := s.store.MockCacher(obs)
+	defer restore()
+
+	restore = store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		c.Fatalf("download should not be called when fetched from a peer")
+		return nil
+	})
+	defer restore()
+	authorized := 0
+	restore = store.MockAuthorizeDownload(func(ctx context.Context, name, url string, user *auth.UserState, s *store.Store, dlOpts *store.DownloadOptions) error {
+		authorized++
+		c.Check(name, Equals, "foo")
+		c.Check(url, Equals, "http://example.com/foo")
+		return nil
+	})
+	defer restore()
+
+	peers := &fakePeerFetcher{}
+	s.store.SetPeerFetcher(peers)
+	defer s.store.SetPeerFetcher(nil)
+
+	snap := &snap.Info{}
+	snap.Sha3_384 = "the-snaps-sha3_384"
+	snap.Size = int64(len("from a peer"))
+	snap.DownloadURL = "http://example.com/foo"
+
+	path := filepath.Join(c.MkDir(), "downloaded-file")
+	err := s.store.Download(s.ctx, "foo", path, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(path, testutil.FileEquals, "from a peer")
+	c.Check(authorized, Equals, 1)
+
+	c.Check(peers.fetched, DeepEquals, []string{"foo:the-snaps-sha3_384:11"})
+	// what comes from peers is cached as well
+	c.Check(obs.puts, DeepEquals, []string{fmt.Sprintf("the-snaps-sha3_384:%s", path)})
+}
+
+func (s *storeDownloadSuite) TestDownloadFromPeerFallsBackToStore(c *C) {
+	obs := &cacheObserver{inCache: map[string]bool{}}
+	restore := s.store.MockCacher(obs)
+	defer restore()
+
+	downloadWasCalled := false
+	restore = store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		downloadWasCalled = true
+		return nil
+	})
+	defer restore()
+
+	restore = store.MockAuthorizeDownload(func(ctx context.Context, name, url string, user *auth.UserState, s *store.Store, dlOpts *store.DownloadOptions) error {
+		return nil
+	})
+	defer restore()
+
+	peers := &fakePeerFetcher{err: errors.New("no peer has the blob")}
+	s.store.SetPeerFetcher(peers)
+	defer s.store.SetPeerFetcher(nil)
+
+	snap := &snap.Info{}
+	snap.Sha3_384 = "the-snaps-sha3_384"
+	snap.Size = 1234
+
+	path := filepath.Join(c.MkDir(), "downloaded-file")
+	err := s.store.Download(s.ctx, "foo", path, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(peers.fetched, DeepEquals, []string{"foo:the-snaps-sha3_384:1234"})
+	c.Check(downloadWasCalled, Equals, true)
+}
+
+func (s *storeDownloadSuite) TestDownloadFromStalledPeerFallsBackToStore(c *C) {
+	obs := &cacheObserver{inCache: map[string]bool{}}
+	restore := s.store.MockCacher(obs)
+	defer restore()
+	restore = store.MockDownloadSpeedParams(50*time.Millisecond, 1)
+	defer restore()
+
+	downloadWasCalled := false
+	restore = store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		downloadWasCalled = true
+		return nil
+	})
+	defer restore()
+	restore = store.MockAuthorizeDownload(func(ctx context.Context, name, url string, user *auth.UserState, s *store.Store, dlOpts *store.DownloadOptions) error {
+		return nil
+	})
+	defer restore()
+
+	// the peer stops sending data
+	peers := &fakePeerFetcher{stall: true}
+	s.store.SetPeerFetcher(peers)
+	defer s.store.SetPeerFetcher(nil)
+
+	snap := &snap.Info{}
+	snap.Sha3_384 = "the-snaps-sha3_384"
+	snap.Size = 1234
+
+	path := filepath.Join(c.MkDir(), "downloaded-file")
+	err := s.store.Download(s.ctx, "foo", path, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(peers.fetched, HasLen, 1)
+	c.Check(downloadWasCalled, Equals, true)
+}
+
+func (s *storeDownloadSuite) TestDownloadFromPeerNeedsSize(c *C) {
+	obs := &cacheObserver{inCache: map[string]bool{}}
+	restore := s.store.MockCacher(obs)
+	defer restore()
+
+	restore = store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		return nil
+	})
+	defer restore()
+
+	peers := &fakePeerFetcher{}
+	s.store.SetPeerFetcher(peers)
+	defer s.store.SetPeerFetcher(nil)
+
+	// without a size, what peers send could not be bounded
+	snap := &snap.Info{}
+	snap.Sha3_384 = "the-snaps-sha3_384"
+
+	path := filepath.Join(c.MkDir(), "downloaded-file")
+	err := s.store.Download(s.ctx, "foo", path, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, IsNil)
+	c.Check(peers.fetched, HasLen, 0)
+}
+
+func (s *storeDownloadSuite) TestDownloadFromPeerNotAuthorized(c *C) {
+	obs := &cacheObserver{inCache: map[string]bool{}}
+	restore := s.store.MockCacher(obs)
+	defer restore()
+
+	restore = store.MockDownload(func(ctx context.Context, name, sha3, url string, user *auth.UserState, s *store.Store, w io.ReadWriteSeeker, resume int64, pbar progress.Meter, dlOpts *store.DownloadOptions) error {
+		c.Fatalf("download should not be called when not authorized")
+		return nil
+	})
+	defer restore()
+	restore = store.MockAuthorizeDownload(func(ctx context.Context, name, url string, user *auth.UserState, s *store.Store, dlOpts *store.DownloadOptions) error {
+		return fmt.Errorf("please buy %s before installing it", name)
+	})
+	defer restore()
+
+	peers := &fakePeerFetcher{}
+	s.store.SetPeerFetcher(peers)
+	defer s.store.SetPeerFetcher(nil)
+
+	snap := &snap.Info{}
+	snap.Sha3_384 = "the-snaps-sha3_384"
+	snap.Size = 1234
+
+	path := filepath.Join(c.MkDir(), "downloaded-file")
+	err := s.store.Download(s.ctx, "foo", path, &snap.DownloadInfo, nil, nil, nil)
+	c.Assert(err, ErrorMatches, "please buy foo before installing it")
+	// peers are not asked for snaps the store does not allow
+	c.Check(peers.fetched, HasLen, 0)
+	c.Check(path, testutil.FileAbsent)
+}
+
 func (s *storeDownloadSuite) TestDownloadDeltaCacheMiss(c *C) {
 	obs := &cacheObserver{inCache: map[string]bool{}}
 	restore := s.store.MockCacher(obs)
@@ -1110,6 +1306,10 @@ func (co *fakeCacher) Cleanup() error {
 	panic("unexpected call")
 }
 
+func (co *fakeCacher) Prune(target uint64) (int, uint64, error) {
+	panic("unexpected call")
+}
+
 func (s *storeDownloadSuite) TestDownloadStreamGoneFromCache(c *C) {
 	expectedContent := []byte("I was downloaded")
 	restore := store.MockDoDownloadReq(func(ctx context.Context, url *url.URL, cdnHeader string, resume int64, s *store.Store, user *auth.UserState) (*http.Response, error) {
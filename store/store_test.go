@@ -2777,6 +2777,15 @@ func (s *storeTestSuite) TestFindV2FindFields(c *C) {
 		"version", "website"})
 }
 
+func (s *storeTestSuite) TestInfoFieldsNoChangelog(c *C) {
+	dauthCtx := &testDauthContext{c: c, device: s.device}
+	sto := store.New(nil, dauthCtx)
+
+	// the changelog is only asked for with refresh candidates
+	c.Check(sto.InfoFields(), Not(testutil.Contains), "changelog")
+	c.Check(sto.InfoFields(), testutil.Contains, "version")
+}
+
 func (s *storeTestSuite) testFindPrivate(c *C, apiV1 bool) {
 	n := 0
 	var v1Fallback, v2Hit bool
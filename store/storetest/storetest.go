@@ -131,6 +131,10 @@ func (Store) CleanDownloadsCache() error {
 	panic("CleanDownloadsCache not expected")
 }
 
+func (Store) PruneDownloadsCache(target uint64) (uint64, error) {
+	panic("PruneDownloadsCache not expected")
+}
+
 func (Store) ExchangeMessages(ctx context.Context, req *store.MessageExchangeRequest) (*store.MessageExchangeResponse, error) {
 	panic("ExchangeMessages not expected")
 }
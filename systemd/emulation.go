@@ -112,6 +112,10 @@ func (s *emulation) CurrentTasksCount(unit string) (uint64, error) {
 	return 0, &notImplementedError{"CurrentTasksCount"}
 }
 
+func (s *emulation) CurrentNetworkUsage(unit string) (ingress, egress quantity.Size, err error) {
+	return 0, 0, &notImplementedError{"CurrentNetworkUsage"}
+}
+
 func (s *emulation) IsEnabled(service string) (bool, error) {
 	return false, &notImplementedError{"IsEnabled"}
 }
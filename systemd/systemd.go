@@ -449,6 +449,9 @@ type Systemd interface {
 	// threads if enabled, etc) part of the unit, which can be a service or a
 	// slice.
 	CurrentTasksCount(unit string) (uint64, error)
+	// CurrentNetworkUsage returns the number of bytes received and sent over
+	// IP by the unit, which needs to have IP accounting enabled.
+	CurrentNetworkUsage(unit string) (ingress, egress quantity.Size, err error)
 	// Run a command
 	Run(command []string, opts *RunOptions) ([]byte, error)
 	// Set log level for the system
@@ -921,6 +924,25 @@ func (s *systemd) CurrentTasksCount(unit string) (uint64, error) {
 	return tasksCount, nil
 }
 
+func (s *systemd) CurrentNetworkUsage(unit string) (ingress, egress quantity.Size, err error) {
+	ingressBytes, err := s.getPropertyUintValue(unit, "IPIngressBytes")
+	if err != nil && err != errNotSet {
+		return 0, 0, err
+	}
+	if err == errNotSet {
+		return 0, 0, fmt.Errorf("network usage unavailable")
+	}
+	egressBytes, err := s.getPropertyUintValue(unit, "IPEgressBytes")
+	if err != nil && err != errNotSet {
+		return 0, 0, err
+	}
+	if err == errNotSet {
+		return 0, 0, fmt.Errorf("network usage unavailable")
+	}
+
+	return quantity.Size(ingressBytes), quantity.Size(egressBytes), nil
+}
+
 func (s *systemd) CurrentMemoryUsage(unit string) (quantity.Size, error) {
 	memBytes, err := s.getPropertyUintValue(unit, "MemoryCurrent")
 	if err != nil && err != errNotSet {
@@ -2595,6 +2595,26 @@ func (s *SystemdTestSuite) TestCurrentUsageFamilyInvalid(c *C) {
 	})
 }
 
+func (s *SystemdTestSuite) TestCurrentNetworkUsage(c *C) {
+	s.outs = [][]byte{
+		[]byte(`IPIngressBytes=2048`),
+		[]byte(`IPEgressBytes=1024`),
+		[]byte(`IPIngressBytes=[not set]`),
+	}
+	sysd := New(SystemMode, s.rep)
+	ingress, egress, err := sysd.CurrentNetworkUsage("bar.slice")
+	c.Assert(err, IsNil)
+	c.Check(ingress, Equals, 2*quantity.SizeKiB)
+	c.Check(egress, Equals, quantity.SizeKiB)
+	_, _, err = sysd.CurrentNetworkUsage("bar.slice")
+	c.Assert(err, ErrorMatches, "network usage unavailable")
+	c.Check(s.argses, DeepEquals, [][]string{
+		{"show", "--property", "IPIngressBytes", "bar.slice"},
+		{"show", "--property", "IPEgressBytes", "bar.slice"},
+		{"show", "--property", "IPIngressBytes", "bar.slice"},
+	})
+}
+
 func (s *SystemdTestSuite) TestCurrentUsageFamilyHappy(c *C) {
 	s.outs = [][]byte{
 		[]byte(`MemoryCurrent=1024`),
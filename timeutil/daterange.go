@@ -0,0 +1,109 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package timeutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// DateRange is a range of whole calendar days in local time.
+type DateRange struct {
+	// Start is the beginning of the first day of the range.
+	Start time.Time
+	// End is the beginning of the day after the last day of the range.
+	End time.Time
+}
+
+func (r DateRange) String() string {
+	last := r.End.AddDate(0, 0, -1)
+	if last.Equal(r.Start) {
+		return r.Start.Format(dateLayout)
+	}
+	return fmt.Sprintf("%s..%s", r.Start.Format(dateLayout), last.Format(dateLayout))
+}
+
+// Includes returns whether the given time falls within the range.
+func (r DateRange) Includes(t time.Time) bool {
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+func parseDate(s string) (time.Time, error) {
+	return time.ParseInLocation(dateLayout, s, time.Local)
+}
+
+func parseDateRange(s string) (DateRange, error) {
+	startStr, endStr, isRange := strings.Cut(s, "..")
+	start, err := parseDate(startStr)
+	if err != nil {
+		return DateRange{}, fmt.Errorf("cannot parse %q: not a valid date", startStr)
+	}
+	end := start
+	if isRange {
+		end, err = parseDate(endStr)
+		if err != nil {
+			return DateRange{}, fmt.Errorf("cannot parse %q: not a valid date", endStr)
+		}
+		if end.Before(start) {
+			return DateRange{}, fmt.Errorf("cannot parse %q: range ends before it starts", s)
+		}
+	}
+	return DateRange{Start: start, End: end.AddDate(0, 0, 1)}, nil
+}
+
+// ParseDateRanges parses a comma separated list of dates in the
+// YYYY-MM-DD format, or inclusive ranges of them in the
+// YYYY-MM-DD..YYYY-MM-DD format, eg.
+// "2026-12-20..2027-01-06,2027-04-01".
+func ParseDateRanges(spec string) ([]DateRange, error) {
+	var ranges []DateRange
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, fmt.Errorf("cannot parse %q: empty date range", spec)
+		}
+		r, err := parseDateRange(s)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// InDateRanges returns whether the given time falls within any of the
+// ranges, and if so, when the last of the ranges including it ends.
+// Overlapping and adjacent ranges are considered as one.
+func InDateRanges(ranges []DateRange, t time.Time) (bool, time.Time) {
+	var end time.Time
+	for extended := true; extended; {
+		extended = false
+		for _, r := range ranges {
+			if r.End.After(end) && (r.Includes(t) || (!end.IsZero() && r.Includes(end))) {
+				end = r.End
+				extended = true
+			}
+		}
+	}
+	return !end.IsZero(), end
+}
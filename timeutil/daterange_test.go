@@ -0,0 +1,107 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package timeutil_test
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/timeutil"
+)
+
+type dateRangeSuite struct{}
+
+var _ = Suite(&dateRangeSuite{})
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
+
+func (s *dateRangeSuite) TestParseDateRanges(c *C) {
+	for _, t := range []struct {
+		in       string
+		expected []timeutil.DateRange
+		str      []string
+	}{
+		{"2026-12-24", []timeutil.DateRange{
+			{Start: date(2026, 12, 24), End: date(2026, 12, 25)},
+		}, []string{"2026-12-24"}},
+		{"2026-12-20..2027-01-06", []timeutil.DateRange{
+			{Start: date(2026, 12, 20), End: date(2027, 1, 7)},
+		}, []string{"2026-12-20..2027-01-06"}},
+		{"2026-12-20..2026-12-20", []timeutil.DateRange{
+			{Start: date(2026, 12, 20), End: date(2026, 12, 21)},
+		}, []string{"2026-12-20"}},
+		{"2026-12-20..2027-01-06, 2027-04-01", []timeutil.DateRange{
+			{Start: date(2026, 12, 20), End: date(2027, 1, 7)},
+			{Start: date(2027, 4, 1), End: date(2027, 4, 2)},
+		}, []string{"2026-12-20..2027-01-06", "2027-04-01"}},
+	} {
+		ranges, err := timeutil.ParseDateRanges(t.in)
+		c.Assert(err, IsNil, Commentf("%q", t.in))
+		c.Check(ranges, DeepEquals, t.expected, Commentf("%q", t.in))
+		var strs []string
+		for _, r := range ranges {
+			strs = append(strs, r.String())
+		}
+		c.Check(strs, DeepEquals, t.str, Commentf("%q", t.in))
+	}
+}
+
+func (s *dateRangeSuite) TestParseDateRangesErrors(c *C) {
+	for _, t := range []struct {
+		in  string
+		err string
+	}{
+		{"", `cannot parse "": empty date range`},
+		{"2026-12-20,", `cannot parse "2026-12-20,": empty date range`},
+		{"tomorrow", `cannot parse "tomorrow": not a valid date`},
+		{"2026-13-01", `cannot parse "2026-13-01": not a valid date`},
+		{"2026-12-20..", `cannot parse "": not a valid date`},
+		{"2026-12-20..2027-01-06T10:00", `cannot parse "2027-01-06T10:00": not a valid date`},
+		{"2026-12-20..2026-12-19", `cannot parse "2026-12-20..2026-12-19": range ends before it starts`},
+	} {
+		_, err := timeutil.ParseDateRanges(t.in)
+		c.Check(err, ErrorMatches, t.err, Commentf("%q", t.in))
+	}
+}
+
+func (s *dateRangeSuite) TestInDateRanges(c *C) {
+	ranges, err := timeutil.ParseDateRanges("2026-12-20..2026-12-24,2026-12-25..2026-12-26,2026-12-26..2026-12-28,2027-01-02")
+	c.Assert(err, IsNil)
+
+	for _, t := range []struct {
+		t   time.Time
+		in  bool
+		end time.Time
+	}{
+		{date(2026, 12, 19).Add(23*time.Hour + 59*time.Minute), false, time.Time{}},
+		// adjacent and overlapping ranges are merged
+		{date(2026, 12, 20), true, date(2026, 12, 29)},
+		{date(2026, 12, 26).Add(12 * time.Hour), true, date(2026, 12, 29)},
+		{date(2026, 12, 29), false, time.Time{}},
+		{date(2027, 1, 2).Add(time.Hour), true, date(2027, 1, 3)},
+	} {
+		in, end := timeutil.InDateRanges(ranges, t.t)
+		c.Check(in, Equals, t.in, Commentf("%s", t.t))
+		c.Check(end.Equal(t.end), Equals, true, Commentf("%s: %s", t.t, end))
+	}
+}
@@ -0,0 +1,73 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package timeutil
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snapcore/snapd/dbusutil"
+)
+
+type NoLogin1Error struct {
+	Err error
+}
+
+func (e NoLogin1Error) Error() string {
+	return fmt.Sprintf("cannot find org.freedesktop.login1 dbus service: %v", e.Err)
+}
+
+// IdleSince returns since when the system has been idle according to
+// systemd-logind, that is since when none of the sessions saw any user
+// activity. It returns the zero time if the system is not idle.
+func IdleSince() (time.Time, error) {
+	// shared connection, no need to close
+	conn, err := dbusutil.SystemBus()
+	if err != nil {
+		return time.Time{}, NoLogin1Error{err}
+	}
+
+	loginObj := conn.Object("org.freedesktop.login1", "/org/freedesktop/login1")
+	dbusV, err := loginObj.GetProperty("org.freedesktop.login1.Manager.IdleHint")
+	if err != nil {
+		if isNoServiceOrUnknownPropertyDbusErr(err) {
+			return time.Time{}, NoLogin1Error{err}
+		}
+		return time.Time{}, fmt.Errorf("cannot check if the system is idle: %v", err)
+	}
+	idle, ok := dbusV.Value().(bool)
+	if !ok {
+		return time.Time{}, fmt.Errorf("login1 returned invalid value for IdleHint property: %s", dbusV)
+	}
+	if !idle {
+		return time.Time{}, nil
+	}
+
+	dbusV, err = loginObj.GetProperty("org.freedesktop.login1.Manager.IdleSinceHint")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot check since when the system is idle: %v", err)
+	}
+	usec, ok := dbusV.Value().(uint64)
+	if !ok {
+		return time.Time{}, fmt.Errorf("login1 returned invalid value for IdleSinceHint property: %s", dbusV)
+	}
+
+	return time.UnixMicro(int64(usec)), nil
+}
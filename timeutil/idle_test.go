@@ -0,0 +1,175 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package timeutil_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dbusutil"
+	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timeutil"
+)
+
+const (
+	login1BusName    = "org.freedesktop.login1"
+	login1ObjectPath = "/org/freedesktop/login1"
+)
+
+type mockLogin1 struct {
+	conn *dbus.Conn
+
+	IdleHint      bool
+	IdleSinceHint uint64
+
+	m                 sync.Mutex
+	getPropertyCalled []string
+}
+
+func newMockLogin1() (*mockLogin1, error) {
+	conn, err := dbusutil.SessionBusPrivate()
+	if err != nil {
+		return nil, err
+	}
+
+	server := &mockLogin1{
+		conn: conn,
+	}
+
+	reply, err := conn.RequestName(login1BusName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return nil, fmt.Errorf("cannot obtain bus name %q", login1BusName)
+	}
+
+	return server, nil
+}
+
+func (server *mockLogin1) Export() {
+	server.conn.Export(&login1Api{server}, login1ObjectPath, "org.freedesktop.DBus.Properties")
+}
+
+func (server *mockLogin1) Stop() error {
+	if _, err := server.conn.ReleaseName(login1BusName); err != nil {
+		return err
+	}
+	return server.conn.Close()
+}
+
+func (server *mockLogin1) reset(idle bool, idleSince uint64) {
+	server.m.Lock()
+	defer server.m.Unlock()
+
+	server.IdleHint = idle
+	server.IdleSinceHint = idleSince
+	server.getPropertyCalled = nil
+}
+
+type login1Api struct {
+	server *mockLogin1
+}
+
+func (a *login1Api) Get(iff, prop string) (dbus.Variant, *dbus.Error) {
+	a.server.m.Lock()
+	defer a.server.m.Unlock()
+
+	a.server.getPropertyCalled = append(a.server.getPropertyCalled, fmt.Sprintf("if=%s;prop=%s", iff, prop))
+	if prop == "IdleSinceHint" {
+		return dbus.MakeVariant(a.server.IdleSinceHint), nil
+	}
+	return dbus.MakeVariant(a.server.IdleHint), nil
+}
+
+type idleSuite struct {
+	testutil.BaseTest
+	testutil.DBusTest
+}
+
+var _ = Suite(&idleSuite{})
+
+func (s *idleSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.DBusTest.SetUpTest(c)
+
+	restore := dbusutil.MockOnlySystemBusAvailable(s.SessionBus)
+	s.AddCleanup(restore)
+}
+
+func (s *idleSuite) TearDownTest(c *C) {
+	s.DBusTest.TearDownTest(c)
+	s.BaseTest.TearDownTest(c)
+}
+
+func (s *idleSuite) TestIdleSince(c *C) {
+	backend, err := newMockLogin1()
+	c.Assert(err, IsNil)
+	defer backend.Stop()
+	backend.Export()
+
+	since := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	backend.reset(true, uint64(since.UnixMicro()))
+
+	idleSince, err := timeutil.IdleSince()
+	c.Assert(err, IsNil)
+	c.Check(idleSince.Equal(since), Equals, true)
+
+	backend.m.Lock()
+	c.Check(backend.getPropertyCalled, DeepEquals, []string{
+		"if=org.freedesktop.login1.Manager;prop=IdleHint",
+		"if=org.freedesktop.login1.Manager;prop=IdleSinceHint",
+	})
+	backend.m.Unlock()
+}
+
+func (s *idleSuite) TestIdleSinceNotIdle(c *C) {
+	backend, err := newMockLogin1()
+	c.Assert(err, IsNil)
+	defer backend.Stop()
+	backend.Export()
+
+	backend.reset(false, 123)
+
+	idleSince, err := timeutil.IdleSince()
+	c.Assert(err, IsNil)
+	c.Check(idleSince.IsZero(), Equals, true)
+
+	backend.m.Lock()
+	c.Check(backend.getPropertyCalled, DeepEquals, []string{
+		"if=org.freedesktop.login1.Manager;prop=IdleHint",
+	})
+	backend.m.Unlock()
+}
+
+func (s *idleSuite) TestIdleSinceNoLogin1(c *C) {
+	// note that there is no mock login1 created so we are on an empty bus
+	_, err := timeutil.IdleSince()
+	c.Check(err, ErrorMatches, `cannot find org.freedesktop.login1 dbus service: .*`)
+	c.Check(errors.As(err, &timeutil.NoLogin1Error{}), Equals, true)
+}
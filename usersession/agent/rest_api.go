@@ -381,6 +381,10 @@ func getLocalizedAppNameFromDesktopFile(parser *goconfigparser.ConfigParser, def
 	return defaultName
 }
 
+// maxNotificationChangelogLength is the length at which the changelog shown
+// in a pending refresh notification is cut.
+const maxNotificationChangelogLength = 300
+
 func postPendingRefreshNotification(c *Command, r *http.Request) Response {
 	if ok, resp := validateJSONRequest(r); !ok {
 		return resp
@@ -445,6 +449,13 @@ func postPendingRefreshNotification(c *Command, r *http.Request) Response {
 		summary = fmt.Sprintf(i18n.G("%s is updating now!"), name)
 		urgencyLevel = notification.CriticalUrgency
 	}
+	if refreshInfo.Changelog != "" {
+		if body != "" {
+			body += "\n\n"
+		}
+		// notification servers show only a few lines of the body
+		body += i18n.G("What's new:") + "\n" + strutil.ElliptRight(refreshInfo.Changelog, maxNotificationChangelogLength)
+	}
 	hints = append(hints, notification.WithUrgency(urgencyLevel))
 	// The notification is provided by snapd session agent.
 	hints = append(hints, notification.WithDesktopEntry("io.snapcraft.SessionAgent"))
@@ -28,6 +28,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/godbus/dbus/v5"
@@ -957,6 +958,34 @@ func (s *restSuite) TestPostPendingRefreshNotificationFewMinutes(c *C) {
 	})
 }
 
+func (s *restSuite) TestPostPendingRefreshNotificationChangelog(c *C) {
+	refreshInfo := &client.PendingSnapRefreshInfo{
+		InstanceName:  "pkg",
+		TimeRemaining: time.Hour * 72,
+		Changelog:     "* faster startup\n* new icon",
+	}
+	s.testPostPendingRefreshNotificationBody(c, refreshInfo)
+	notifications := s.notify.GetAll()
+	c.Assert(notifications, HasLen, 1)
+	n := notifications[0]
+	c.Check(n.Summary, Equals, `Update available for pkg.`)
+	c.Check(n.Body, Equals, "Close the application to update now. It will update automatically in 3 days.\n\nWhat's new:\n* faster startup\n* new icon")
+}
+
+func (s *restSuite) TestPostPendingRefreshNotificationChangelogHappeningNow(c *C) {
+	refreshInfo := &client.PendingSnapRefreshInfo{
+		InstanceName: "pkg",
+		Changelog:    strings.Repeat("a", 400),
+	}
+	s.testPostPendingRefreshNotificationBody(c, refreshInfo)
+	notifications := s.notify.GetAll()
+	c.Assert(notifications, HasLen, 1)
+	n := notifications[0]
+	c.Check(n.Summary, Equals, `pkg is updating now!`)
+	// long changelogs are cut
+	c.Check(n.Body, Equals, "What's new:\n"+strings.Repeat("a", 299)+"…")
+}
+
 func (s *restSuite) TestPostPendingRefreshNotificationBusyAppDesktopFile(c *C) {
 	refreshInfo := &client.PendingSnapRefreshInfo{
 		InstanceName:        "pkg",
@@ -511,6 +511,7 @@ type PendingSnapRefreshInfo struct {
 	TimeRemaining       time.Duration `json:"time-remaining,omitempty"`
 	BusyAppName         string        `json:"busy-app-name,omitempty"`
 	BusyAppDesktopEntry string        `json:"busy-app-desktop-entry,omitempty"`
+	Changelog           string        `json:"changelog,omitempty"`
 }
 
 // PendingRefreshNotification broadcasts information about a refresh.
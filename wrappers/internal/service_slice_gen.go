@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"runtime"
 
+	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/snap/quota"
 	"github.com/snapcore/snapd/strutil"
 )
@@ -70,10 +71,21 @@ MemoryAccounting=true
 		valuesTemplate := `MemoryMax=%[1]d
 # for compatibility with older versions of systemd
 MemoryLimit=%[1]d
-
 `
 		fmt.Fprintf(buf, valuesTemplate, grp.MemoryLimit)
 	}
+	if grp.MemoryHigh != 0 {
+		fmt.Fprintf(buf, "MemoryHigh=%d\n", grp.MemoryHigh)
+	}
+	if grp.MemoryLow != 0 {
+		fmt.Fprintf(buf, "MemoryLow=%d\n", grp.MemoryLow)
+	}
+	if grp.MemorySwapMax != 0 {
+		fmt.Fprintf(buf, "MemorySwapMax=%d\n", grp.MemorySwapMax)
+	}
+	if grp.MemoryLimit != 0 || grp.MemoryHigh != 0 || grp.MemoryLow != 0 || grp.MemorySwapMax != 0 {
+		buf.WriteString("\n")
+	}
 	return buf.String()
 }
 
@@ -90,6 +102,38 @@ TasksAccounting=true
 	return buf.String()
 }
 
+func formatIOGroupSlice(grp *quota.Group) string {
+	if grp.IOLimit == nil {
+		return ""
+	}
+	header := `# Always enable io accounting, so the following io quota options have an effect
+IOAccounting=true
+`
+	buf := bytes.NewBufferString(header)
+	if grp.IOLimit.Weight != 0 {
+		fmt.Fprintf(buf, "IOWeight=%d\n", grp.IOLimit.Weight)
+	}
+	// systemd resolves the path to the block device backing it, so the
+	// bandwidth limits only apply to that single device
+	if grp.IOLimit.ReadBandwidth != 0 {
+		fmt.Fprintf(buf, "IOReadBandwidthMax=%s %d\n", dirs.StripRootDir(dirs.SnapDataDir), grp.IOLimit.ReadBandwidth)
+	}
+	if grp.IOLimit.WriteBandwidth != 0 {
+		fmt.Fprintf(buf, "IOWriteBandwidthMax=%s %d\n", dirs.StripRootDir(dirs.SnapDataDir), grp.IOLimit.WriteBandwidth)
+	}
+	return buf.String()
+}
+
+func formatNetworkGroupSlice(grp *quota.Group) string {
+	if !grp.NetworkAccounting {
+		return ""
+	}
+	return `# Enable ip accounting in order to be able to report the network traffic
+# of the slice
+IPAccounting=true
+`
+}
+
 // GenerateQuotaSliceUnitFile generates a systemd slice unit definition for the
 // specified quota group.
 func GenerateQuotaSliceUnitFile(grp *quota.Group) []byte {
@@ -98,6 +142,8 @@ func GenerateQuotaSliceUnitFile(grp *quota.Group) []byte {
 	cpuOptions := formatCpuGroupSlice(grp)
 	memoryOptions := formatMemoryGroupSlice(grp)
 	taskOptions := formatTaskGroupSlice(grp)
+	ioOptions := formatIOGroupSlice(grp)
+	networkOptions := formatNetworkGroupSlice(grp)
 	template := `[Unit]
 Description=Slice for snap quota group %[1]s
 Before=slices.target
@@ -107,6 +153,6 @@ X-Snappy=yes
 `
 
 	fmt.Fprintf(&buf, template, grp.Name)
-	fmt.Fprint(&buf, cpuOptions, memoryOptions, taskOptions)
+	fmt.Fprint(&buf, cpuOptions, memoryOptions, taskOptions, ioOptions, networkOptions)
 	return buf.Bytes()
 }
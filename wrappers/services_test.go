@@ -333,6 +333,124 @@ TasksMax=%[5]d
 	c.Assert(svcFile, testutil.FileEquals, svcContent)
 }
 
+func (s *servicesTestSuite) TestEnsureSnapServicesWithIOQuotas(c *C) {
+	info := snaptest.MockSnap(c, packageHello, &snap.SideInfo{Revision: snap.R(12)})
+
+	resourceLimits := quota.NewResourcesBuilder().
+		WithIOReadBandwidth(20 * quantity.SizeMiB).
+		WithIOWriteBandwidth(10 * quantity.SizeMiB).
+		WithIOWeight(50).
+		WithNetworkAccounting(true).
+		Build()
+	grp, err := quota.NewGroup("foogroup", resourceLimits)
+	c.Assert(err, IsNil)
+
+	m := map[*snap.Info]*wrappers.SnapServiceOptions{
+		info: {QuotaGroup: grp},
+	}
+
+	sliceContent := `[Unit]
+Description=Slice for snap quota group foogroup
+Before=slices.target
+X-Snappy=yes
+
+[Slice]
+# Always enable cpu accounting, so the following cpu quota options have an effect
+CPUAccounting=true
+
+# Always enable memory accounting otherwise the MemoryMax setting does nothing.
+MemoryAccounting=true
+# Always enable task accounting in order to be able to count the processes/
+# threads, etc for a slice
+TasksAccounting=true
+# Always enable io accounting, so the following io quota options have an effect
+IOAccounting=true
+IOWeight=50
+IOReadBandwidthMax=/var/snap 20971520
+IOWriteBandwidthMax=/var/snap 10485760
+# Enable ip accounting in order to be able to report the network traffic
+# of the slice
+IPAccounting=true
+`
+
+	err = wrappers.EnsureSnapServices(m, nil, nil, progress.Null)
+	c.Assert(err, IsNil)
+	c.Check(filepath.Join(dirs.SnapServicesDir, "snap.foogroup.slice"), testutil.FileEquals, sliceContent)
+}
+
+func (s *servicesTestSuite) TestEnsureSnapServicesWithMemorySoftLimits(c *C) {
+	info := snaptest.MockSnap(c, packageHello, &snap.SideInfo{Revision: snap.R(12)})
+
+	resourceLimits := quota.NewResourcesBuilder().
+		WithMemoryLimit(quantity.SizeGiB).
+		WithMemoryHigh(768 * quantity.SizeMiB).
+		WithMemoryLow(256 * quantity.SizeMiB).
+		WithMemorySwapMax(512 * quantity.SizeMiB).
+		Build()
+	grp, err := quota.NewGroup("foogroup", resourceLimits)
+	c.Assert(err, IsNil)
+
+	m := map[*snap.Info]*wrappers.SnapServiceOptions{
+		info: {QuotaGroup: grp},
+	}
+
+	sliceContent := `[Unit]
+Description=Slice for snap quota group foogroup
+Before=slices.target
+X-Snappy=yes
+
+[Slice]
+# Always enable cpu accounting, so the following cpu quota options have an effect
+CPUAccounting=true
+
+# Always enable memory accounting otherwise the MemoryMax setting does nothing.
+MemoryAccounting=true
+MemoryMax=1073741824
+# for compatibility with older versions of systemd
+MemoryLimit=1073741824
+MemoryHigh=805306368
+MemoryLow=268435456
+MemorySwapMax=536870912
+
+# Always enable task accounting in order to be able to count the processes/
+# threads, etc for a slice
+TasksAccounting=true
+`
+
+	err = wrappers.EnsureSnapServices(m, nil, nil, progress.Null)
+	c.Assert(err, IsNil)
+	c.Check(filepath.Join(dirs.SnapServicesDir, "snap.foogroup.slice"), testutil.FileEquals, sliceContent)
+
+	// removing the soft and swap limits and protection drops them from the
+	// slice
+	err = grp.UpdateQuotaLimits(quota.NewResourcesBuilder().WithMemoryHigh(0).WithMemoryLow(0).WithMemorySwapMax(0).Build())
+	c.Assert(err, IsNil)
+
+	sliceContent = `[Unit]
+Description=Slice for snap quota group foogroup
+Before=slices.target
+X-Snappy=yes
+
+[Slice]
+# Always enable cpu accounting, so the following cpu quota options have an effect
+CPUAccounting=true
+
+# Always enable memory accounting otherwise the MemoryMax setting does nothing.
+MemoryAccounting=true
+MemoryMax=1073741824
+# for compatibility with older versions of systemd
+MemoryLimit=1073741824
+
+# Always enable task accounting in order to be able to count the processes/
+# threads, etc for a slice
+TasksAccounting=true
+`
+
+	err = wrappers.EnsureSnapServices(m, nil, nil, progress.Null)
+	c.Assert(err, IsNil)
+	c.Check(filepath.Join(dirs.SnapServicesDir, "snap.foogroup.slice"), testutil.FileEquals, sliceContent)
+}
+
 func (s *servicesTestSuite) TestEnsureSnapServicesWithZeroCpuCountQuotas(c *C) {
 	// Kind of a special case, if the cpu count is zero it needs to automatically scale
 	// at the moment of writing the service file to the current number of cpu cores